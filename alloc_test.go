@@ -0,0 +1,107 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/examples"
+)
+
+// TestAllocs guards against allocation regressions in key operations on the
+// documents in the examples corpus. Each case asserts a maximum number of
+// allocations per run; lower the maximum when a change reduces allocations
+// so that the improvement is protected, too.
+//
+// Not parallel because testing.AllocsPerRun sets GOMAXPROCS to 1.
+//
+//nolint:paralleltest,tparallel
+func TestAllocs(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		doc   string
+		query string
+		op    string
+		max   float64
+	}{
+		{
+			name:  "parse_medium_query",
+			query: `$.store.book[?@.price < 10 && @.category == "fiction"]["title","author"]`,
+			op:    "parse",
+			max:   60,
+		},
+		{
+			name:  "singular_select_bookstore",
+			doc:   "bookstore",
+			query: `$.store.book[2].author`,
+			op:    "select",
+			max:   13,
+		},
+		{
+			name:  "singular_select_pod",
+			doc:   "pod",
+			query: `$.spec.containers[0].image`,
+			op:    "select",
+			max:   13,
+		},
+		{
+			name:  "name_chain_select_pod",
+			doc:   "pod",
+			query: `$.metadata.labels.app`,
+			op:    "select",
+			max:   1,
+		},
+		{
+			name:  "select_singular_pod",
+			doc:   "pod",
+			query: `$.spec.containers[0].image`,
+			op:    "singular",
+			max:   0,
+		},
+		{
+			name:  "located_descendant_select_bookstore",
+			doc:   "bookstore",
+			query: `$..author`,
+			op:    "located",
+			max:   39,
+		},
+		{
+			name:  "located_descendant_select_geojson",
+			doc:   "geojson",
+			query: `$..coordinates`,
+			op:    "located",
+			max:   35,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var run func()
+			switch tc.op {
+			case "parse":
+				run = func() { _, _ = Parse(tc.query) }
+			case "select":
+				doc, err := examples.Document(tc.doc)
+				require.NoError(t, err)
+				p := MustParse(tc.query)
+				require.NotEmpty(t, p.Select(doc))
+				run = func() { _ = p.Select(doc) }
+			case "singular":
+				doc, err := examples.Document(tc.doc)
+				require.NoError(t, err)
+				p := MustParse(tc.query)
+				_, ok := p.SelectSingular(doc)
+				require.True(t, ok)
+				run = func() { _, _ = p.SelectSingular(doc) }
+			case "located":
+				doc, err := examples.Document(tc.doc)
+				require.NoError(t, err)
+				p := MustParse(tc.query)
+				require.NotEmpty(t, p.SelectLocated(doc))
+				run = func() { _ = p.SelectLocated(doc) }
+			default:
+				t.Fatalf("unknown operation %q", tc.op)
+			}
+
+			assert.LessOrEqual(t, testing.AllocsPerRun(100, run), tc.max)
+		})
+	}
+}
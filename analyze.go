@@ -0,0 +1,345 @@
+package jsonpath
+
+import (
+	"math"
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Complexity classifies the estimated cost of evaluating a query relative to
+// the size of its input.
+type Complexity uint8
+
+const (
+	// ComplexityConstant queries select at most one node by name or index,
+	// so their cost does not depend on the size of the input.
+	ComplexityConstant Complexity = iota
+
+	// ComplexityLinear queries visit each node of the input at most a
+	// bounded number of times.
+	ComplexityLinear
+
+	// ComplexityQuadratic queries evaluate a filter query that may visit
+	// every node of the input for each node they filter, such as
+	// $..a[?$..b].
+	ComplexityQuadratic
+
+	// ComplexityPolynomial queries nest filter queries that may visit every
+	// node of the input within one another.
+	ComplexityPolynomial
+)
+
+// String returns the name of c.
+func (c Complexity) String() string {
+	switch c {
+	case ComplexityConstant:
+		return "constant"
+	case ComplexityLinear:
+		return "linear"
+	case ComplexityQuadratic:
+		return "quadratic"
+	default:
+		return "polynomial"
+	}
+}
+
+// MarshalText marshals c into text. Implements [encoding.TextMarshaler].
+func (c Complexity) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// Usage counts the use of query features that affect the cost of
+// evaluation.
+type Usage struct {
+	// Filters is the number of filter selectors.
+	Filters int `json:"filters"`
+
+	// Descendants is the number of descendant segments.
+	Descendants int `json:"descendants"`
+
+	// Wildcards is the number of wildcard selectors.
+	Wildcards int `json:"wildcards"`
+
+	// Slices is the number of slice selectors.
+	Slices int `json:"slices"`
+
+	// Functions lists the sorted names of the functions called.
+	Functions []string `json:"functions"`
+}
+
+// Finding describes a potential problem with a query.
+type Finding struct {
+	// Code identifies the kind of finding.
+	Code string `json:"code"`
+
+	// Message describes the finding.
+	Message string `json:"message"`
+}
+
+// Lint finding codes.
+const (
+	// FindingDescendantWildcard indicates a descendant wildcard, such as
+	// $..*, which selects every node in the input.
+	FindingDescendantWildcard = "descendant-wildcard"
+
+	// FindingRootQueryInFilter indicates a filter that evaluates a
+	// non-singular query against the root node for every node it tests.
+	FindingRootQueryInFilter = "root-query-in-filter"
+
+	// FindingDuplicateSelector indicates a segment that repeats a
+	// selector, and so selects the same nodes more than once.
+	FindingDuplicateSelector = "duplicate-selector"
+
+	// FindingConstantComparison indicates a comparison of two literals,
+	// which has the same result for every node.
+	FindingConstantComparison = "constant-comparison"
+)
+
+// Analysis describes the estimated complexity of a query, the features it
+// uses, and potential problems with it.
+type Analysis struct {
+	// Query is the normalized string representation of the query.
+	Query string `json:"query"`
+
+	// Singular is true if the query selects at most one node.
+	Singular bool `json:"singular"`
+
+	// Complexity is the estimated complexity of the query.
+	Complexity Complexity `json:"complexity"`
+
+	// Usage counts the query features used.
+	Usage Usage `json:"usage"`
+
+	// Findings lists potential problems with the query.
+	Findings []Finding `json:"findings"`
+}
+
+// Analyze estimates the complexity of evaluating p, counts the features it
+// uses, and reports potential problems with it, without evaluating it.
+// Useful for gating queries from untrusted sources or in code review.
+func (p *Path) Analyze() *Analysis {
+	z := &analyzer{Analysis: &Analysis{
+		Query:    p.String(),
+		Singular: p.q.Singular() != nil,
+		Usage:    Usage{Functions: []string{}},
+		Findings: []Finding{},
+	}}
+
+	z.Complexity = Complexity(min(z.query(p.q), int(ComplexityPolynomial)))
+	slices.Sort(z.Usage.Functions)
+	z.Usage.Functions = slices.Compact(z.Usage.Functions)
+	return z.Analysis
+}
+
+// Cost estimates the cost of evaluating a query, as returned by
+// [Path.Complexity]. Counts include the features of queries in filter
+// expressions.
+type Cost struct {
+	// Complexity is the estimated complexity of the query.
+	Complexity Complexity `json:"complexity"`
+
+	// Descendants is the number of descendant segments.
+	Descendants int `json:"descendants"`
+
+	// Wildcards is the number of wildcard selectors.
+	Wildcards int `json:"wildcards"`
+
+	// Filters is the number of filter selectors.
+	Filters int `json:"filters"`
+
+	// Functions is the number of function expressions.
+	Functions int `json:"functions"`
+
+	// FanOut is the maximum number of times the query may select a single
+	// node of the input, the product of the number of selectors in each of
+	// its segments, as in 4 for $[*,*][*,*]. Descendant segments may select
+	// a node an additional time for each of its ancestors that a previous
+	// segment selects.
+	FanOut int `json:"fan_out"`
+}
+
+// Exceeds returns true if any field of c exceeds the corresponding field of
+// limit. Fields of limit set to zero impose no limit, so limit cannot
+// require [ComplexityConstant] or a FanOut of zero. Use Exceeds to reject
+// queries from untrusted sources that would be too costly to evaluate,
+// together with evaluation limits such as [WithMemoryBudget].
+func (c Cost) Exceeds(limit Cost) bool {
+	exceeds := func(val, limit int) bool { return limit > 0 && val > limit }
+	return exceeds(int(c.Complexity), int(limit.Complexity)) ||
+		exceeds(c.Descendants, limit.Descendants) ||
+		exceeds(c.Wildcards, limit.Wildcards) ||
+		exceeds(c.Filters, limit.Filters) ||
+		exceeds(c.Functions, limit.Functions) ||
+		exceeds(c.FanOut, limit.FanOut)
+}
+
+// Complexity estimates the cost of evaluating p without evaluating it,
+// counting the features that affect the cost and computing its worst-case
+// fan-out. Use [Cost.Exceeds] to reject costly queries.
+func (p *Path) Complexity() Cost {
+	z := &analyzer{Analysis: &Analysis{}}
+	degree := z.query(p.q)
+	return Cost{
+		Complexity:  Complexity(min(degree, int(ComplexityPolynomial))),
+		Descendants: z.Usage.Descendants,
+		Wildcards:   z.Usage.Wildcards,
+		Filters:     z.Usage.Filters,
+		// The analyzer appends the name of each function it finds.
+		Functions: len(z.Usage.Functions),
+		FanOut:    fanOut(p.q),
+	}
+}
+
+// fanOut returns the product of the number of selectors in each segment of
+// q, or math.MaxInt if the product overflows.
+func fanOut(q *spec.PathQuery) int {
+	res := 1
+	for _, seg := range q.Segments() {
+		n := len(seg.Selectors())
+		if n > 0 && res > math.MaxInt/n {
+			return math.MaxInt
+		}
+		res *= n
+	}
+	return res
+}
+
+// analyzer walks a query to populate an Analysis.
+type analyzer struct {
+	*Analysis
+}
+
+// find records a finding unless it has already been recorded.
+func (z *analyzer) find(code, msg string) {
+	f := Finding{Code: code, Message: msg}
+	if !slices.Contains(z.Findings, f) {
+		z.Findings = append(z.Findings, f)
+	}
+}
+
+// query analyzes q and returns the degree of the polynomial that estimates
+// the cost of evaluating it: 0 for singular queries, 1 for queries that
+// visit each node a bounded number of times, plus the degree of the most
+// costly filter.
+func (z *analyzer) query(q *spec.PathQuery) int {
+	if q.Singular() != nil {
+		return 0
+	}
+
+	degree := 0
+	for _, seg := range q.Segments() {
+		if seg.IsDescendant() {
+			z.Usage.Descendants++
+		}
+
+		seen := make(map[string]struct{}, len(seg.Selectors()))
+		for _, sel := range seg.Selectors() {
+			str := sel.String()
+			if _, ok := seen[str]; ok {
+				z.find(FindingDuplicateSelector, "segment "+seg.String()+" repeats selector "+str)
+			}
+			seen[str] = struct{}{}
+
+			switch sel := sel.(type) {
+			case spec.WildcardSelector:
+				z.Usage.Wildcards++
+				if seg.IsDescendant() {
+					z.find(FindingDescendantWildcard, "descendant wildcard selects every node in the input")
+				}
+			case spec.SliceSelector:
+				z.Usage.Slices++
+			case *spec.FilterSelector:
+				z.Usage.Filters++
+				degree = max(degree, z.or(sel.LogicalOr))
+			}
+		}
+	}
+
+	return 1 + degree
+}
+
+// subquery analyzes q, a query in a filter expression, and returns the
+// degree of the cost of evaluating it for each node tested by the filter.
+// Relative queries without descendant segments visit only the children of
+// the node, so contribute only the cost of their own filters.
+func (z *analyzer) subquery(q *spec.PathQuery) int {
+	degree := z.query(q)
+	switch {
+	case degree == 0:
+		return 0
+	case q.IsRoot():
+		z.find(FindingRootQueryInFilter, "filter evaluates "+q.String()+" for every node it tests")
+		return degree
+	case slices.ContainsFunc(q.Segments(), (*spec.Segment).IsDescendant):
+		return degree
+	default:
+		return degree - 1
+	}
+}
+
+// or analyzes lo and returns the degree of its most costly expression.
+func (z *analyzer) or(lo spec.LogicalOr) int {
+	degree := 0
+	for expr := range lo.Expressions() {
+		degree = max(degree, z.expr(expr))
+	}
+	return degree
+}
+
+// expr analyzes expr and returns the degree of its cost.
+func (z *analyzer) expr(expr spec.BasicExpr) int {
+	switch expr := expr.(type) {
+	case *spec.ParenExpr:
+		return z.or(expr.Inner())
+	case *spec.NotParenExpr:
+		return z.or(expr.Inner())
+	case *spec.ComparisonExpr:
+		_, lLit := expr.Left.(*spec.LiteralArg)
+		_, rLit := expr.Right.(*spec.LiteralArg)
+		if lLit && rLit {
+			z.find(FindingConstantComparison, "comparison of literals has the same result for every node")
+		}
+		return max(z.arg(expr.Left), z.arg(expr.Right))
+	case *spec.MembershipExpr:
+		_, lLit := expr.Left.(*spec.LiteralArg)
+		_, rLit := expr.Right.(*spec.LiteralArg)
+		if lLit && rLit {
+			z.find(FindingConstantComparison, "membership test of literals has the same result for every node")
+		}
+		return max(z.arg(expr.Left), z.arg(expr.Right))
+	case *spec.ExistExpr:
+		return z.subquery(expr.PathQuery)
+	case *spec.NonExistExpr:
+		return z.subquery(expr.PathQuery)
+	case *spec.FunctionExpr:
+		return z.arg(expr)
+	case spec.NotFuncExpr:
+		return z.arg(expr.FunctionExpr)
+	default:
+		return 0
+	}
+}
+
+// arg analyzes arg, a comparison value or function argument, and returns
+// the degree of its cost.
+func (z *analyzer) arg(arg any) int {
+	switch arg := arg.(type) {
+	case *spec.FilterQueryExpr:
+		return z.subquery(arg.PathQuery)
+	case spec.LogicalOr:
+		return z.or(arg)
+	case *spec.FunctionExpr:
+		z.Usage.Functions = append(z.Usage.Functions, arg.Name())
+		degree := 0
+		for _, a := range arg.Args() {
+			degree = max(degree, z.arg(a))
+		}
+		return degree
+	case *spec.ArithmeticExpr:
+		return max(z.arg(arg.Left), z.arg(arg.Right))
+	default:
+		// Literals and singular queries.
+		return 0
+	}
+}
@@ -0,0 +1,263 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	noFuncs := []string{}
+	noFindings := []Finding{}
+
+	for _, tc := range []struct {
+		name     string
+		path     string
+		singular bool
+		cx       Complexity
+		usage    Usage
+		findings []Finding
+	}{
+		{
+			name:     "root",
+			path:     `$`,
+			singular: true,
+			cx:       ComplexityConstant,
+			usage:    Usage{Functions: noFuncs},
+			findings: noFindings,
+		},
+		{
+			name:     "singular",
+			path:     `$.a[0].b`,
+			singular: true,
+			cx:       ComplexityConstant,
+			usage:    Usage{Functions: noFuncs},
+			findings: noFindings,
+		},
+		{
+			name:     "wildcard_and_slice",
+			path:     `$.a[*][1:3]`,
+			cx:       ComplexityLinear,
+			usage:    Usage{Wildcards: 1, Slices: 1, Functions: noFuncs},
+			findings: noFindings,
+		},
+		{
+			name:  "descendant_wildcard",
+			path:  `$..*`,
+			cx:    ComplexityLinear,
+			usage: Usage{Descendants: 1, Wildcards: 1, Functions: noFuncs},
+			findings: []Finding{{
+				FindingDescendantWildcard,
+				"descendant wildcard selects every node in the input",
+			}},
+		},
+		{
+			name:     "singular_filter",
+			path:     `$.a[?@.b == $.c]`,
+			cx:       ComplexityLinear,
+			usage:    Usage{Filters: 1, Functions: noFuncs},
+			findings: noFindings,
+		},
+		{
+			name:     "child_filter_query",
+			path:     `$[?@.*]`,
+			cx:       ComplexityLinear,
+			usage:    Usage{Filters: 1, Wildcards: 1, Functions: noFuncs},
+			findings: noFindings,
+		},
+		{
+			name:     "descendant_filter_query",
+			path:     `$[?!@..x]`,
+			cx:       ComplexityQuadratic,
+			usage:    Usage{Filters: 1, Descendants: 1, Functions: noFuncs},
+			findings: noFindings,
+		},
+		{
+			name:  "root_filter_query",
+			path:  `$..a[?count($..b) > 1 || !(length(@) == 1)]`,
+			cx:    ComplexityQuadratic,
+			usage: Usage{Filters: 1, Descendants: 2, Functions: []string{"count", "length"}},
+			findings: []Finding{{
+				FindingRootQueryInFilter,
+				`filter evaluates $..["b"] for every node it tests`,
+			}},
+		},
+		{
+			name:  "nested_root_filter_query",
+			path:  `$[?$[?!(count($..x) == 1)]]`,
+			cx:    ComplexityPolynomial,
+			usage: Usage{Filters: 2, Descendants: 1, Functions: []string{"count"}},
+			findings: []Finding{
+				{FindingRootQueryInFilter, `filter evaluates $..["x"] for every node it tests`},
+				{FindingRootQueryInFilter, `filter evaluates $[?!(count($..["x"]) == 1)] for every node it tests`},
+			},
+		},
+		{
+			name:  "duplicate_selector",
+			path:  `$[0,"a",0,"a"]`,
+			cx:    ComplexityLinear,
+			usage: Usage{Functions: noFuncs},
+			findings: []Finding{
+				{FindingDuplicateSelector, `segment [0,"a",0,"a"] repeats selector 0`},
+				{FindingDuplicateSelector, `segment [0,"a",0,"a"] repeats selector "a"`},
+			},
+		},
+		{
+			name:  "constant_comparison",
+			path:  `$[?1 == 1 || (true != false)]`,
+			cx:    ComplexityLinear,
+			usage: Usage{Filters: 1, Functions: noFuncs},
+			findings: []Finding{{
+				FindingConstantComparison,
+				"comparison of literals has the same result for every node",
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+			a.Equal(&Analysis{
+				Query:      p.String(),
+				Singular:   tc.singular,
+				Complexity: tc.cx,
+				Usage:      tc.usage,
+				Findings:   tc.findings,
+			}, p.Analyze())
+		})
+	}
+}
+
+func TestAnalyzeArithmetic(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	p := NewParser(WithArithmetic()).MustParse(`$..a[?@.x + count($..b) * 2 > 1]`)
+	a.Equal(&Analysis{
+		Query:      p.String(),
+		Complexity: ComplexityQuadratic,
+		Usage:      Usage{Filters: 1, Descendants: 2, Functions: []string{"count"}},
+		Findings: []Finding{{
+			FindingRootQueryInFilter,
+			`filter evaluates $..["b"] for every node it tests`,
+		}},
+	}, p.Analyze())
+}
+
+func TestComplexity(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	for _, tc := range []struct {
+		cx  Complexity
+		str string
+	}{
+		{ComplexityConstant, "constant"},
+		{ComplexityLinear, "linear"},
+		{ComplexityQuadratic, "quadratic"},
+		{ComplexityPolynomial, "polynomial"},
+	} {
+		a.Equal(tc.str, tc.cx.String())
+		text, err := tc.cx.MarshalText()
+		r.NoError(err)
+		a.Equal(tc.str, string(text))
+	}
+
+	js, err := json.Marshal(MustParse(`$.x`).Analyze())
+	r.NoError(err)
+	a.JSONEq(
+		`{"query": "$[\"x\"]", "singular": true, "complexity": "constant", "usage": {"filters": 0, "descendants": 0, "wildcards": 0, "slices": 0, "functions": []}, "findings": []}`,
+		string(js),
+	)
+}
+
+func TestPathComplexity(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		path string
+		cost Cost
+	}{
+		{
+			name: "root",
+			path: `$`,
+			cost: Cost{Complexity: ComplexityConstant, FanOut: 1},
+		},
+		{
+			name: "names",
+			path: `$.a[0]["b"]`,
+			cost: Cost{Complexity: ComplexityConstant, FanOut: 1},
+		},
+		{
+			name: "wildcards",
+			path: `$[*,*]..[*,0,1:]`,
+			cost: Cost{Complexity: ComplexityLinear, Descendants: 1, Wildcards: 3, FanOut: 6},
+		},
+		{
+			name: "filter_functions",
+			path: `$..a[?length(@.b) > count($..c[?match(@, "x")])]`,
+			cost: Cost{
+				Complexity:  ComplexityQuadratic,
+				Descendants: 2,
+				Filters:     2,
+				Functions:   3,
+				FanOut:      1,
+			},
+		},
+		{
+			name: "repeated_functions",
+			path: `$[?length(@.a) > 1 || length(@.b) > 1, ?@.c]`,
+			cost: Cost{Complexity: ComplexityLinear, Filters: 2, Functions: 2, FanOut: 2},
+		},
+		{
+			name: "nested_filters",
+			path: `$..*[?$..*[?$..x]]`,
+			cost: Cost{Complexity: ComplexityPolynomial, Descendants: 3, Wildcards: 2, Filters: 2, FanOut: 1},
+		},
+		{
+			name: "fan_out_overflow",
+			path: "$" + strings.Repeat("[*,*,*,*,*,*,*,*]", 22),
+			cost: Cost{Complexity: ComplexityLinear, Wildcards: 176, FanOut: math.MaxInt},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.cost, MustParse(tc.path).Complexity())
+		})
+	}
+}
+
+func TestCostExceeds(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	cost := MustParse(`$..a[?count($..b) > 1][*,*]`).Complexity()
+	a.Equal(Cost{ComplexityQuadratic, 2, 2, 1, 1, 2}, cost)
+
+	for _, tc := range []struct {
+		name    string
+		limit   Cost
+		exceeds bool
+	}{
+		{"no_limit", Cost{}, false},
+		{"equal", cost, false},
+		{"complexity", Cost{Complexity: ComplexityLinear}, true},
+		{"polynomial", Cost{Complexity: ComplexityPolynomial}, false},
+		{"descendants", Cost{Descendants: 1}, true},
+		{"wildcards", Cost{Wildcards: 1}, true},
+		{"filters", Cost{Filters: 2}, false},
+		{"functions", Cost{Functions: 1}, false},
+		{"fan_out", Cost{FanOut: 1}, true},
+	} {
+		a.Equal(tc.exceeds, cost.Exceeds(tc.limit), tc.name)
+	}
+}
@@ -0,0 +1,176 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrBind errors are returned by [Bind] when it cannot populate a struct,
+// and by [Path.SelectInto] when it cannot populate its destination.
+var ErrBind = errors.New("jsonpath: bind")
+
+// ErrNoMatch errors are returned by [Path.SelectInto] when a singular query
+// selects no node.
+var ErrNoMatch = errors.New("jsonpath: no match")
+
+// binderCache caches binders by struct type.
+//
+//nolint:gochecknoglobals
+var binderCache sync.Map
+
+// binder populates the tagged fields of a struct type.
+type binder struct {
+	fields []boundField
+}
+
+// boundField associates a struct field with a query.
+type boundField struct {
+	index []int
+	name  string
+	path  *Path
+	// singular is true if path selects at most one node, in which case the
+	// node itself binds to the field rather than the list of nodes.
+	singular bool
+}
+
+// Bind populates the fields of the struct pointed to by out with the values
+// selected from input by the queries in their jsonpath struct tags. For
+// example:
+//
+//	type Book struct {
+//		Title   string   `jsonpath:"$.book.title"`
+//		Authors []string `jsonpath:"$.book.authors[*].name"`
+//	}
+//
+// A field tagged with a singular query, one that selects at most one node,
+// is set to the selected node, while a field tagged with any other query is
+// set to the list of selected nodes and therefore must be a slice, array, or
+// interface. Fields are left unchanged when their queries select nothing.
+// Bind converts selected values to field types as [encoding/json] does.
+//
+// Bind parses the tags of each struct type only once and caches the
+// compiled queries. Returns an [ErrBind] error if out is not a non-nil
+// pointer to a struct, if a tag fails to parse, or if a selected value
+// cannot be converted to its field's type.
+func Bind(input, out any) error {
+	val := reflect.ValueOf(out)
+	if val.Kind() != reflect.Pointer || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%w: expected non-nil pointer to struct but got %T", ErrBind, out)
+	}
+
+	b, err := binderFor(val.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return b.bind(input, val.Elem())
+}
+
+// SelectInto selects values from input and stores them in the value
+// pointed to by dest, much like [database/sql.Row.Scan], sparing callers a
+// JSON round trip of the results to convert them to typed values. As with
+// [Bind], if p is singular, selecting at most one node, SelectInto stores
+// that node in dest, which may therefore be a struct or any other type the
+// node converts to. Otherwise it stores the list of selected nodes, which
+// may be empty, so dest must point to a slice, array, or interface.
+// SelectInto converts selected values to the type of dest as
+// [encoding/json] does.
+//
+// Returns an [ErrNoMatch] error, leaving dest unchanged, if p is singular
+// and selects no node, and an [ErrBind] error if dest is not a non-nil
+// pointer or if the selected values cannot be converted to its type.
+func (p *Path) SelectInto(input, dest any) error {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Pointer || val.IsNil() {
+		return fmt.Errorf("%w: expected non-nil pointer but got %T", ErrBind, dest)
+	}
+
+	var src any
+	nodes := p.Select(input)
+	if p.q.Singular() != nil {
+		if len(nodes) == 0 {
+			return fmt.Errorf("%w: %v", ErrNoMatch, p)
+		}
+		src = nodes[0]
+	} else {
+		src = []any(nodes)
+	}
+
+	if err := assign(val.Elem(), src); err != nil {
+		return fmt.Errorf("%w: %w", ErrBind, err)
+	}
+	return nil
+}
+
+// binderFor returns the binder for typ, compiling and caching it if
+// necessary.
+func binderFor(typ reflect.Type) (*binder, error) {
+	if b, ok := binderCache.Load(typ); ok {
+		return b.(*binder), nil //nolint:forcetypeassert
+	}
+
+	b := &binder{}
+	for _, field := range reflect.VisibleFields(typ) {
+		tag, ok := field.Tag.Lookup("jsonpath")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		path, err := Parse(tag)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %v: %w", ErrBind, field.Name, err)
+		}
+		b.fields = append(b.fields, boundField{
+			index:    field.Index,
+			name:     field.Name,
+			path:     path,
+			singular: path.q.Singular() != nil,
+		})
+	}
+
+	actual, _ := binderCache.LoadOrStore(typ, b)
+	return actual.(*binder), nil //nolint:forcetypeassert
+}
+
+// bind populates the fields of val from input.
+func (b *binder) bind(input any, val reflect.Value) error {
+	for _, f := range b.fields {
+		var src any
+		nodes := f.path.Select(input)
+		switch {
+		case len(nodes) == 0:
+			continue
+		case f.singular:
+			src = nodes[0]
+		default:
+			src = []any(nodes)
+		}
+
+		field, err := val.FieldByIndexErr(f.index)
+		if err == nil {
+			err = assign(field, src)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: field %v: %w", ErrBind, f.name, err)
+		}
+	}
+	return nil
+}
+
+// assign converts src to the type of dst and assigns it to dst.
+func assign(dst reflect.Value, src any) error {
+	if src != nil {
+		if sv := reflect.ValueOf(src); sv.Type().AssignableTo(dst.Type()) {
+			dst.Set(sv)
+			return nil
+		}
+	}
+
+	// Fall back on a JSON round trip for conversions.
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	return json.Unmarshal(data, dst.Addr().Interface()) //nolint:wrapcheck
+}
@@ -0,0 +1,294 @@
+package jsonpath
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/examples"
+)
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	type Book struct {
+		Title  string  `jsonpath:"$.title"`
+		Author string  `jsonpath:"$.author"`
+		Price  float64 `jsonpath:"$.price"`
+	}
+
+	type Embedded struct {
+		Color string `jsonpath:"$.store.bicycle.color"`
+	}
+
+	type Store struct {
+		Embedded
+		First      string         `jsonpath:"$.store.book[0].title"`
+		Authors    []string       `jsonpath:"$..author"`
+		Prices     [2]float64     `jsonpath:"$.store.book[:2].price"`
+		Books      []Book         `jsonpath:"$.store.book[?@.price < 10]"`
+		Bicycle    map[string]any `jsonpath:"$.store.bicycle"`
+		Price      int            `jsonpath:"$.store.bicycle.price"`
+		Missing    string         `jsonpath:"$.nonesuch"`
+		Any        any            `jsonpath:"$.store.book[3].isbn"`
+		Skipped    string         `jsonpath:"-"`
+		Untagged   string
+		unexported string `jsonpath:"$.store"`
+	}
+
+	out := Store{Missing: "default", Untagged: "hi"}
+	r.NoError(Bind(examples.Bookstore(), &out))
+	a.Equal(Store{
+		Embedded: Embedded{Color: "red"},
+		First:    "Sayings of the Century",
+		Authors:  []string{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"},
+		Prices:   [2]float64{8.95, 12.99},
+		Books: []Book{
+			{Title: "Sayings of the Century", Author: "Nigel Rees", Price: 8.95},
+			{Title: "Moby Dick", Author: "Herman Melville", Price: 8.99},
+		},
+		Bicycle:  map[string]any{"color": "red", "price": 399.0},
+		Price:    399,
+		Missing:  "default",
+		Any:      "0-395-19395-8",
+		Untagged: "hi",
+	}, out)
+
+	// Binder should be cached.
+	b, ok := binderCache.Load(reflect.TypeOf(out))
+	r.True(ok)
+	r.NoError(Bind(examples.Bookstore(), &out))
+	b2, _ := binderCache.Load(reflect.TypeOf(out))
+	a.Same(b, b2)
+}
+
+func TestBindErrors(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	type BadTag struct {
+		X string `jsonpath:"x"`
+	}
+
+	type BadType struct {
+		X int `jsonpath:"$.x"`
+	}
+
+	type NotSlice struct {
+		X int `jsonpath:"$.*"`
+	}
+
+	type Inner struct {
+		X int `jsonpath:"$.x"`
+	}
+
+	type NilEmbed struct {
+		*Inner
+	}
+
+	input := map[string]any{"x": "hi"}
+	for _, tc := range []struct {
+		name string
+		out  any
+		err  string
+		is   error
+	}{
+		{
+			name: "nil",
+			out:  nil,
+			err:  "jsonpath: bind: expected non-nil pointer to struct but got <nil>",
+		},
+		{
+			name: "not_pointer",
+			out:  BadType{},
+			err:  "jsonpath: bind: expected non-nil pointer to struct but got jsonpath.BadType",
+		},
+		{
+			name: "nil_pointer",
+			out:  (*BadType)(nil),
+			err:  "jsonpath: bind: expected non-nil pointer to struct but got *jsonpath.BadType",
+		},
+		{
+			name: "not_struct",
+			out:  new(string),
+			err:  "jsonpath: bind: expected non-nil pointer to struct but got *string",
+		},
+		{
+			name: "bad_tag",
+			out:  &BadTag{},
+			err:  "jsonpath: bind: field X: jsonpath: unexpected identifier at position 1",
+			is:   ErrPathParse,
+		},
+		{
+			name: "bad_type",
+			out:  &BadType{},
+			err:  "jsonpath: bind: field X: json: cannot unmarshal string into Go value of type int",
+		},
+		{
+			name: "not_slice",
+			out:  &NotSlice{},
+			err:  "jsonpath: bind: field X: json: cannot unmarshal array into Go value of type int",
+		},
+		{
+			name: "nil_embedded",
+			out:  &NilEmbed{},
+			err:  "jsonpath: bind: field X: reflect: indirection through nil pointer to embedded struct field Inner",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := Bind(input, tc.out)
+			a.EqualError(err, tc.err)
+			a.ErrorIs(err, ErrBind)
+			if tc.is != nil {
+				a.ErrorIs(err, tc.is)
+			}
+		})
+	}
+}
+
+func TestSelectInto(t *testing.T) {
+	t.Parallel()
+
+	type Book struct {
+		Title  string  `json:"title"`
+		Author string  `json:"author"`
+		Price  float64 `json:"price"`
+	}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		dest any
+		exp  any
+	}{
+		{
+			name: "struct",
+			path: "$.store.book[0]",
+			dest: &Book{},
+			exp:  &Book{Title: "Sayings of the Century", Author: "Nigel Rees", Price: 8.95},
+		},
+		{
+			name: "struct_slice",
+			path: "$.store.book[?@.price < 10]",
+			dest: &[]Book{},
+			exp: &[]Book{
+				{Title: "Sayings of the Century", Author: "Nigel Rees", Price: 8.95},
+				{Title: "Moby Dick", Author: "Herman Melville", Price: 8.99},
+			},
+		},
+		{
+			name: "scalar",
+			path: "$.store.bicycle.price",
+			dest: new(int),
+			exp:  ptr(399),
+		},
+		{
+			name: "string",
+			path: "$.store.book[0].title",
+			dest: new(string),
+			exp:  ptr("Sayings of the Century"),
+		},
+		{
+			name: "string_slice",
+			path: "$..author",
+			dest: &[]string{"replaced"},
+			exp:  &[]string{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"},
+		},
+		{
+			name: "array",
+			path: "$.store.book[:2].price",
+			dest: &[2]float64{},
+			exp:  &[2]float64{8.95, 12.99},
+		},
+		{
+			name: "interface",
+			path: "$.store.book[1:3].category",
+			dest: new(any),
+			exp:  ptr[any]([]any{"fiction", "fiction"}),
+		},
+		{
+			name: "no_nodes",
+			path: "$.store.book[?@.price > 100]",
+			dest: &[]Book{{Title: "replaced"}},
+			exp:  &[]Book{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+			require.NoError(t, p.SelectInto(examples.Bookstore(), tc.dest))
+			assert.Equal(t, tc.exp, tc.dest)
+		})
+	}
+}
+
+func TestSelectIntoErrors(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{"x": "hi", "y": []any{1, 2}}
+	for _, tc := range []struct {
+		name string
+		path string
+		dest any
+		err  string
+		is   error
+	}{
+		{
+			name: "nil",
+			path: "$.x",
+			dest: nil,
+			err:  "jsonpath: bind: expected non-nil pointer but got <nil>",
+			is:   ErrBind,
+		},
+		{
+			name: "not_pointer",
+			path: "$.x",
+			dest: "",
+			err:  "jsonpath: bind: expected non-nil pointer but got string",
+			is:   ErrBind,
+		},
+		{
+			name: "nil_pointer",
+			path: "$.x",
+			dest: (*string)(nil),
+			err:  "jsonpath: bind: expected non-nil pointer but got *string",
+			is:   ErrBind,
+		},
+		{
+			name: "no_match",
+			path: "$.z",
+			dest: new(string),
+			err:  `jsonpath: no match: $["z"]`,
+			is:   ErrNoMatch,
+		},
+		{
+			name: "bad_type",
+			path: "$.x",
+			dest: new(int),
+			err:  "jsonpath: bind: json: cannot unmarshal string into Go value of type int",
+			is:   ErrBind,
+		},
+		{
+			name: "not_slice",
+			path: "$.y[*]",
+			dest: new(int),
+			err:  "jsonpath: bind: json: cannot unmarshal array into Go value of type int",
+			is:   ErrBind,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			err := MustParse(tc.path).SelectInto(input, tc.dest)
+			a.EqualError(err, tc.err)
+			a.ErrorIs(err, tc.is)
+		})
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T { return &v }
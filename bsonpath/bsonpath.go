@@ -0,0 +1,224 @@
+// Package bsonpath evaluates JSONPath queries against BSON documents from
+// the MongoDB Go driver, such as those decoded from a cursor, so that the
+// same queries extract values from JSON API payloads and from documents
+// read from MongoDB:
+//
+//	var doc bson.D
+//	if err := cursor.Decode(&doc); err != nil {
+//		return err
+//	}
+//	for _, title := range bsonpath.Select(path, doc) {
+//		fmt.Println(title)
+//	}
+//
+// Queries select from [bson.D] documents in the order of their elements,
+// and from [bson.M] documents, as from map[string]any, in no particular
+// order. [bson.A] and []any values are arrays. Queries adapt documents only
+// as they descend into them, and select the original BSON values, so that
+// applications can pass them back to the driver.
+//
+// Filter expressions compare BSON values as their JSON equivalents in API
+// payloads: numeric types as numbers, ObjectIDs as hexadecimal strings,
+// DateTimes and time.Time values as RFC 3339 strings in UTC, Decimal128
+// values as numbers, Symbols and JavaScript code as strings, and Null and
+// Undefined as null. Other BSON types compare as their relaxed Extended
+// JSON representations.
+package bsonpath
+
+import (
+	"encoding/json"
+	"iter"
+	"time"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Select returns the values p selects from doc, a BSON document such as a
+// [bson.D] or [bson.M], in the order p selects them.
+func Select(p *jsonpath.Path, doc any) jsonpath.NodeList {
+	nodes := p.Select(adapt(doc))
+	for i, n := range nodes {
+		nodes[i] = unwrap(n)
+	}
+	return nodes
+}
+
+// SelectLocated returns the values p selects from doc, a BSON document
+// such as a [bson.D] or [bson.M], along with their normalized paths, in the
+// order p selects them.
+func SelectLocated(p *jsonpath.Path, doc any) jsonpath.LocatedNodeList {
+	nodes := p.SelectLocated(adapt(doc))
+	for _, n := range nodes {
+		n.Node = unwrap(n.Node)
+	}
+	return nodes
+}
+
+// document adapts a [bson.D] to [spec.OrderedMap]. Get returns the first
+// of elements with duplicate keys, while All yields them all.
+type document struct{ d bson.D }
+
+// Len returns the number of elements in d.
+func (d document) Len() int { return len(d.d) }
+
+// Get returns the adapted value of the first element of d with the key
+// name.
+func (d document) Get(name string) (any, bool) {
+	for _, e := range d.d {
+		if e.Key == name {
+			return adapt(e.Value), true
+		}
+	}
+	return nil, false
+}
+
+// All returns an iterator over the keys and adapted values of the elements
+// of d, in order.
+func (d document) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, e := range d.d {
+			if !yield(e.Key, adapt(e.Value)) {
+				return
+			}
+		}
+	}
+}
+
+// object adapts a [bson.M] or map[string]any to [spec.OrderedMap]. All
+// yields its members in no particular order.
+type object struct {
+	orig any
+	m    map[string]any
+}
+
+// Len returns the number of members in o.
+func (o object) Len() int { return len(o.m) }
+
+// Get returns the adapted value of the member of o named name.
+func (o object) Get(name string) (any, bool) {
+	val, ok := o.m[name]
+	if !ok {
+		return nil, false
+	}
+	return adapt(val), true
+}
+
+// All returns an iterator over the names and adapted values of the members
+// of o.
+func (o object) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for k, v := range o.m {
+			if !yield(k, adapt(v)) {
+				return
+			}
+		}
+	}
+}
+
+// array adapts a [bson.A] or []any to [spec.Array].
+type array struct {
+	orig any
+	a    []any
+}
+
+// Len returns the number of elements in a.
+func (a array) Len() int { return len(a.a) }
+
+// Index returns the adapted value of the element of a at index i.
+func (a array) Index(i int) any { return adapt(a.a[i]) }
+
+// scalar adapts a BSON scalar type with no JSON equivalent to
+// [spec.Adapter].
+type scalar struct{ val any }
+
+// Adapt returns the JSON equivalent of s.
+func (s scalar) Adapt() any {
+	switch v := s.val.(type) {
+	case primitive.ObjectID:
+		return v.Hex()
+	case primitive.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case primitive.Decimal128:
+		if v.IsNaN() || v.IsInf() != 0 {
+			return v.String()
+		}
+		return json.Number(v.String())
+	case primitive.Symbol:
+		return string(v)
+	case primitive.JavaScript:
+		return string(v)
+	case primitive.Null, primitive.Undefined:
+		return nil
+	default:
+		return extJSON(v)
+	}
+}
+
+// extJSON returns the relaxed Extended JSON representation of val decoded
+// into the JSON data model, or nil if val cannot be encoded.
+func extJSON(val any) any {
+	src, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: val}}, false, false)
+	if err != nil {
+		return nil
+	}
+	var doc struct{ V any }
+	if err := json.Unmarshal(src, &doc); err != nil {
+		return nil
+	}
+	return doc.V
+}
+
+// adapt adapts val for selection: documents to [spec.OrderedMap] values,
+// arrays to [spec.Array] values, and BSON scalar types with no JSON
+// equivalent to [spec.Adapter] values. Returns other values, such as
+// strings and numbers, unchanged.
+func adapt(val any) any {
+	switch v := val.(type) {
+	case primitive.ObjectID, primitive.DateTime, time.Time, primitive.Decimal128,
+		primitive.Symbol, primitive.JavaScript, primitive.Null, primitive.Undefined,
+		primitive.Binary, primitive.Regex, primitive.Timestamp, primitive.MinKey,
+		primitive.MaxKey, primitive.DBPointer, primitive.CodeWithScope:
+		return scalar{v}
+	case bson.D:
+		return document{v}
+	case bson.M:
+		return object{v, v}
+	case map[string]any:
+		return object{v, v}
+	case bson.A:
+		return array{v, v}
+	case []any:
+		return array{v, v}
+	default:
+		return v
+	}
+}
+
+// unwrap returns the original BSON value of val, as adapted by [adapt].
+func unwrap(val any) any {
+	switch v := val.(type) {
+	case document:
+		return v.d
+	case object:
+		return v.orig
+	case array:
+		return v.orig
+	case scalar:
+		return v.val
+	default:
+		return v
+	}
+}
+
+// The adapted types implement the data model interfaces.
+var (
+	_ spec.OrderedMap = document{}
+	_ spec.OrderedMap = object{}
+	_ spec.Array      = array{}
+	_ spec.Adapter    = scalar{}
+)
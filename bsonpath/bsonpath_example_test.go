@@ -0,0 +1,42 @@
+package bsonpath_test
+
+import (
+	"fmt"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/bsonpath"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ExampleSelect() {
+	doc := bson.D{
+		{Key: "store", Value: bson.D{
+			{Key: "book", Value: bson.A{
+				bson.D{{Key: "title", Value: "Sayings of the Century"}, {Key: "price", Value: 8.95}},
+				bson.D{{Key: "title", Value: "Sword of Honour"}, {Key: "price", Value: int32(12)}},
+			}},
+		}},
+	}
+
+	p := jsonpath.MustParse("$.store.book[?@.price < 10].title")
+	for _, title := range bsonpath.Select(p, doc) {
+		fmt.Println(title)
+	}
+	// Output:
+	// Sayings of the Century
+}
+
+func ExampleSelectLocated() {
+	doc := bson.D{
+		{Key: "name", Value: "web"},
+		{Key: "ports", Value: bson.A{int32(80), int32(443)}},
+	}
+
+	p := jsonpath.MustParse("$.*")
+	for _, node := range bsonpath.SelectLocated(p, doc) {
+		fmt.Printf("%v: %v\n", node.Path, node.Node)
+	}
+	// Output:
+	// $['name']: web
+	// $['ports']: [80 443]
+}
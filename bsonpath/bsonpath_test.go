@@ -0,0 +1,195 @@
+package bsonpath
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	id, err := primitive.ObjectIDFromHex("65e1f2a3b4c5d6e7f8091a2b")
+	require.NoError(t, err)
+	created := primitive.NewDateTimeFromTime(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	price, err := primitive.ParseDecimal128("12.50")
+	require.NoError(t, err)
+	tags := bson.A{"go", "json"}
+	items := bson.A{
+		bson.D{{Key: "sku", Value: "a1"}, {Key: "qty", Value: int32(2)}},
+		bson.M{"sku": "b2", "qty": int64(5)},
+	}
+
+	doc := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "name", Value: "order"},
+		{Key: "created", Value: created},
+		{Key: "price", Value: price},
+		{Key: "tags", Value: tags},
+		{Key: "items", Value: items},
+		{Key: "note", Value: primitive.Null{}},
+		{Key: "name", Value: "duplicate"},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		exp   jsonpath.NodeList
+		paths []string
+	}{
+		{
+			name:  "object_id",
+			path:  "$._id",
+			exp:   jsonpath.NodeList{id},
+			paths: []string{"$['_id']"},
+		},
+		{
+			name:  "first_duplicate",
+			path:  "$.name",
+			exp:   jsonpath.NodeList{"order"},
+			paths: []string{"$['name']"},
+		},
+		{
+			name: "wildcard_in_order",
+			path: "$.*",
+			exp:  jsonpath.NodeList{id, "order", created, price, tags, items, primitive.Null{}, "duplicate"},
+			paths: []string{
+				"$['_id']", "$['name']", "$['created']", "$['price']",
+				"$['tags']", "$['items']", "$['note']", "$['name']",
+			},
+		},
+		{
+			name:  "array",
+			path:  "$.tags[-1]",
+			exp:   jsonpath.NodeList{"json"},
+			paths: []string{"$['tags'][1]"},
+		},
+		{
+			name:  "document_in_array",
+			path:  "$.items[0]",
+			exp:   jsonpath.NodeList{items[0]},
+			paths: []string{"$['items'][0]"},
+		},
+		{
+			name:  "compare_int32_and_int64",
+			path:  "$.items[?@.qty > 1].sku",
+			exp:   jsonpath.NodeList{"a1", "b2"},
+			paths: []string{"$['items'][0]['sku']", "$['items'][1]['sku']"},
+		},
+		{
+			name:  "compare_object_id",
+			path:  `$[?@ == "65e1f2a3b4c5d6e7f8091a2b"]`,
+			exp:   jsonpath.NodeList{id},
+			paths: []string{"$['_id']"},
+		},
+		{
+			name:  "compare_date_time",
+			path:  `$[?@ >= "2024-03-01" && @ < "2024-03-02"]`,
+			exp:   jsonpath.NodeList{created},
+			paths: []string{"$['created']"},
+		},
+		{
+			name:  "compare_decimal",
+			path:  `$[?@ == 12.5]`,
+			exp:   jsonpath.NodeList{price},
+			paths: []string{"$['price']"},
+		},
+		{
+			name:  "compare_null",
+			path:  `$[?@ == null]`,
+			exp:   jsonpath.NodeList{primitive.Null{}},
+			paths: []string{"$['note']"},
+		},
+		{
+			name:  "length",
+			path:  `$[?length(@) == 2]`,
+			exp:   jsonpath.NodeList{tags, items},
+			paths: []string{"$['tags']", "$['items']"},
+		},
+		{
+			name:  "descendants",
+			path:  `$..sku`,
+			exp:   jsonpath.NodeList{"a1", "b2"},
+			paths: []string{"$['items'][0]['sku']", "$['items'][1]['sku']"},
+		},
+		{
+			name:  "none",
+			path:  `$.items[5]`,
+			exp:   jsonpath.NodeList{},
+			paths: []string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := jsonpath.MustParse(tc.path)
+			a.Equal(tc.exp, Select(p, doc))
+
+			located := SelectLocated(p, doc)
+			a.Len(located, len(tc.exp))
+			paths := make([]string, len(located))
+			for i, n := range located {
+				a.Equal(tc.exp[i], n.Node)
+				paths[i] = n.Path.String()
+			}
+			a.Equal(tc.paths, paths)
+		})
+	}
+}
+
+func TestSelectMap(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	doc := bson.M{
+		"user": bson.M{"name": "Kim", "roles": bson.A{"admin", "dev"}},
+		"tags": []any{map[string]any{"k": "v"}},
+	}
+
+	a.Equal(jsonpath.NodeList{"Kim"}, Select(jsonpath.MustParse("$.user.name"), doc))
+	a.Equal(
+		jsonpath.NodeList{bson.A{"admin", "dev"}},
+		Select(jsonpath.MustParse(`$.user[?@[0] == "admin"]`), doc),
+	)
+	a.Equal(jsonpath.NodeList{"v"}, Select(jsonpath.MustParse("$.tags[0].k"), doc))
+	a.Equal(jsonpath.NodeList{doc}, Select(jsonpath.MustParse("$"), doc))
+}
+
+func TestAdapt(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		val  any
+		exp  any
+	}{
+		{"string", "hi", "hi"},
+		{"int32", int32(3), int32(3)},
+		{"time", time.Date(2024, 3, 1, 0, 0, 0, 5e6, time.FixedZone("", 3600)), "2024-02-29T23:00:00.005Z"},
+		{"decimal_nan", primitive.NewDecimal128(0x7c00000000000000, 0), "NaN"},
+		{"symbol", primitive.Symbol("sym"), "sym"},
+		{"javascript", primitive.JavaScript("f()"), "f()"},
+		{"undefined", primitive.Undefined{}, nil},
+		{"binary", primitive.Binary{Data: []byte("hi")}, map[string]any{
+			"$binary": map[string]any{"base64": "aGk=", "subType": "00"},
+		}},
+		{"regex", primitive.Regex{Pattern: "^a", Options: "i"}, map[string]any{
+			"$regularExpression": map[string]any{"pattern": "^a", "options": "i"},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			val := adapt(tc.val)
+			if s, ok := val.(scalar); ok {
+				val = s.Adapt()
+			}
+			assert.Equal(t, tc.exp, val)
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package jsonpath
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Builder builds JSONPath queries programmatically, without the nesting of
+// the [spec] constructors or the quoting of query strings. Create a Builder
+// with [Query] or [Current] and chain its methods to add segments:
+//
+//	path := jsonpath.Query().Child("store").Descendant("price").Path()
+//
+// Each method returns a new Builder and leaves its receiver unchanged, so
+// that a Builder may serve as the prefix of several queries.
+type Builder struct {
+	segs []*spec.Segment
+	root bool
+}
+
+// Query returns a Builder for a query that selects from the root node ($).
+func Query() *Builder {
+	return &Builder{root: true}
+}
+
+// Current returns a Builder for a relative query that selects from the
+// current node (@), such as for the filter expressions passed to
+// [Builder.Filter]:
+//
+//	price := jsonpath.Current().Child("price").Singular()
+//	path := jsonpath.Query().Child("store", "book").Filter(
+//		spec.Comparison(price, spec.LessThan, spec.Literal(10)),
+//	).Path()
+func Current() *Builder {
+	return &Builder{}
+}
+
+// Child returns a Builder that appends a child segment consisting of
+// selectors to b's query. Each selector must be a string, which selects an
+// object member by name, an int, which selects an array element by index,
+// or a [spec.Selector]. Panics for any other type, which indicates a
+// programming error.
+func (b *Builder) Child(selectors ...any) *Builder {
+	return b.Segments(spec.Child(toSelectors(selectors)...))
+}
+
+// Descendant returns a Builder that appends a descendant segment consisting
+// of selectors to b's query. Accepts the same selectors as [Builder.Child].
+func (b *Builder) Descendant(selectors ...any) *Builder {
+	return b.Segments(spec.Descendant(toSelectors(selectors)...))
+}
+
+// Wildcard returns a Builder that appends a child segment with a wildcard
+// selector ([*]) to b's query.
+func (b *Builder) Wildcard() *Builder {
+	return b.Segments(spec.Child(spec.Wildcard()))
+}
+
+// Slice returns a Builder that appends a child segment with an array slice
+// selector to b's query. Pass up to three integers or nils for the start,
+// end, and step arguments, as for [spec.Slice]. Panics if any of the
+// arguments is neither an integer nor nil.
+func (b *Builder) Slice(args ...any) *Builder {
+	return b.Segments(spec.Child(spec.Slice(args...)))
+}
+
+// Filter returns a Builder that appends a child segment with a filter
+// selector to b's query. The filter selects the children of a node for
+// which all of exprs are true; use [spec.Paren] with a [spec.LogicalOr] for
+// alternatives.
+func (b *Builder) Filter(exprs ...spec.BasicExpr) *Builder {
+	return b.Segments(spec.Child(spec.Filter(spec.LogicalOr{spec.LogicalAnd(exprs)})))
+}
+
+// Segments returns a Builder that appends segments to b's query.
+func (b *Builder) Segments(segments ...*spec.Segment) *Builder {
+	return &Builder{root: b.root, segs: append(slices.Clip(b.segs), segments...)}
+}
+
+// Query returns the query b has built.
+func (b *Builder) Query() *spec.PathQuery {
+	return spec.Query(b.root, slices.Clone(b.segs))
+}
+
+// Singular returns the query b has built as a singular query for use in
+// filter expressions, or nil if the query is not singular, that is, if any
+// of its segments is a descendant segment or has a selector other than a
+// single name or index.
+func (b *Builder) Singular() *spec.SingularQueryExpr {
+	return b.Query().Singular()
+}
+
+// Path returns a [Path] for the query b has built.
+func (b *Builder) Path() *Path {
+	return New(b.Query())
+}
+
+// String returns a string representation of the query b has built.
+func (b *Builder) String() string {
+	return b.Query().String()
+}
+
+// toSelectors converts strings to [spec.Name] selectors and ints to
+// [spec.Index] selectors, and panics for values that are neither strings,
+// ints, nor selectors.
+func toSelectors(args []any) []spec.Selector {
+	sels := make([]spec.Selector, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case string:
+			sels[i] = spec.Name(arg)
+		case int:
+			sels[i] = spec.Index(arg)
+		case spec.Selector:
+			sels[i] = arg
+		default:
+			panic(fmt.Sprintf("jsonpath: cannot use %T as a selector", arg))
+		}
+	}
+	return sels
+}
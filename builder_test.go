@@ -0,0 +1,126 @@
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath/examples"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	price := Current().Child("price").Singular()
+	cheap := spec.Comparison(price, spec.LessThan, spec.Literal(10))
+
+	for _, tc := range []struct {
+		name string
+		b    *Builder
+		exp  string
+	}{
+		{"root", Query(), "$"},
+		{"current", Current(), "@"},
+		{"child_name", Query().Child("store"), `$["store"]`},
+		{"child_index", Query().Child(0), "$[0]"},
+		{"child_union", Query().Child("a", 1, spec.Wildcard()), `$["a",1,*]`},
+		{"quoted_name", Query().Child(`it's "x"`), `$["it's \"x\""]`},
+		{"descendant", Query().Descendant("price"), `$..["price"]`},
+		{"wildcard", Query().Child("store").Wildcard(), `$["store"][*]`},
+		{"slice", Query().Slice(1, nil, 2), "$[1::2]"},
+		{"filter", Query().Child("book").Filter(cheap), `$["book"][?@["price"] < 10]`},
+		{
+			name: "filter_and",
+			b:    Query().Filter(cheap, spec.Existence(Current().Child("isbn").Query())),
+			exp:  `$[?@["price"] < 10 && @["isbn"]]`,
+		},
+		{
+			name: "segments",
+			b:    Query().Segments(spec.Child(spec.Name("a")), spec.Descendant(spec.Index(0))),
+			exp:  `$["a"]..[0]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			a.Equal(tc.exp, tc.b.String())
+			a.Equal(tc.exp, tc.b.Query().String())
+			a.Equal(tc.exp, tc.b.Path().String())
+
+			// Builds a valid query.
+			p, err := NewParser(WithRelative()).Parse(tc.exp)
+			a.NoError(err)
+			a.Equal(p.String(), tc.b.String())
+		})
+	}
+}
+
+func TestBuilderImmutable(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	store := Query().Child("store")
+	books := store.Child("book")
+	bikes := store.Child("bicycle")
+	a.Equal(`$["store"]`, store.String())
+	a.Equal(`$["store"]["book"]`, books.String())
+	a.Equal(`$["store"]["bicycle"]`, bikes.String())
+
+	// Modifying a built query does not modify the builder.
+	q := books.Query()
+	segs := q.Segments()
+	segs[0] = spec.Child(spec.Name("nope"))
+	a.Equal(`$["store"]["book"]`, books.String())
+
+	// Selects from the bookstore.
+	a.Equal(NodeList{"red"}, bikes.Child("color").Path().Select(examples.Bookstore()))
+}
+
+func TestBuilderSingular(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(spec.SingularQuery(true, []spec.Selector{spec.Name("a"), spec.Index(1)}), Query().Child("a").Child(1).Singular())
+	a.Equal(spec.SingularQuery(false, []spec.Selector{spec.Name("a")}), Current().Child("a").Singular())
+	a.Nil(Query().Child("a", "b").Singular())
+	a.Nil(Query().Descendant("a").Singular())
+	a.Nil(Query().Wildcard().Singular())
+}
+
+func TestBuilderPanic(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.PanicsWithValue("jsonpath: cannot use float64 as a selector", func() { Query().Child(1.5) })
+	a.PanicsWithValue("jsonpath: cannot use <nil> as a selector", func() { Query().Descendant(nil) })
+}
+
+func TestPathAppend(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	books := MustParse("$.store.book")
+	titles := books.Append(spec.Child(spec.Wildcard()), spec.Child(spec.Name("title")))
+	a.Equal(`$["store"]["book"][*]["title"]`, titles.String())
+	a.Equal(`$["store"]["book"]`, books.String())
+	a.Equal(
+		NodeList{"Sayings of the Century", "Sword of Honour", "Moby Dick", "The Lord of the Rings"},
+		titles.Select(examples.Bookstore()),
+	)
+
+	// Appending to the same path twice does not share segments.
+	first := books.Append(spec.Child(spec.Index(0)))
+	last := books.Append(spec.Child(spec.Index(-1)))
+	a.Equal(`$["store"]["book"][0]`, first.String())
+	a.Equal(`$["store"]["book"][-1]`, last.String())
+
+	// Singular lookups take the fast path.
+	a.Equal([]spec.Selector{spec.Name("store"), spec.Name("book"), spec.Index(0)}, first.lookups)
+	a.Nil(titles.lookups)
+
+	// Composes with a Builder.
+	rel := MustParse("$.store").Append(Current().Child("bicycle").Child("color").Query().Segments()...)
+	a.Equal(NodeList{"red"}, rel.Select(examples.Bookstore()))
+	a.Equal(books, books.Append())
+}
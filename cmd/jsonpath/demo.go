@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/examples"
+)
+
+// demoQueries lists the queries run by the demo subcommand for each document
+// in the examples corpus. Queries select from arrays or single members so
+// that the output order is deterministic.
+//
+//nolint:gochecknoglobals
+var demoQueries = []struct {
+	doc     string
+	queries []string
+}{
+	{
+		doc: "bookstore",
+		queries: []string{
+			`$.store.book[*].author`,
+			`$..book[2].title`,
+			`$..book[-1].title`,
+			`$..book[0,1].title`,
+			`$..book[:2].title`,
+			`$..book[?@.isbn].title`,
+			`$..book[?@.price < 10].title`,
+			`$.store.bicycle.color`,
+		},
+	},
+	{
+		doc: "pod",
+		queries: []string{
+			`$.metadata.labels.app`,
+			`$.spec.containers[*].image`,
+			`$.spec.containers[*].ports[*].containerPort`,
+			`$.spec.containers[?@.resources].name`,
+			`$.status.conditions[?@.type == 'Ready'].status`,
+		},
+	},
+	{
+		doc: "geojson",
+		queries: []string{
+			`$.features[*].geometry.type`,
+			`$.features[?@.geometry.type == 'Point'].properties.name`,
+			`$.features[?@.properties.population > 0].properties.name`,
+			`$.features[?length(@.geometry.coordinates) > 3].properties.name`,
+			`$.features[?search(@.properties.name, 'Route')].properties.length`,
+		},
+	},
+}
+
+// demo runs demoQueries against the examples corpus and writes each query
+// and its results to out. Serves as both a smoke test and a learning aid.
+func demo(out io.Writer) error {
+	for i, set := range demoQueries {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		doc, err := examples.Document(set.doc)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		fmt.Fprintf(out, "# %v\n", set.doc)
+
+		for _, q := range set.queries {
+			p, err := jsonpath.Parse(q)
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			fmt.Fprintf(out, "\n%v\n", q)
+			if err := writeJSON(out, p.Select(doc), ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// e2eCase describes an end-to-end test of the jsonpath command, as loaded
+// from testdata/e2e.json.
+type e2eCase struct {
+	// Name names the test.
+	Name string `json:"name"`
+
+	// Args are the command line arguments. Paths are relative to the
+	// testdata directory, in which the command runs.
+	Args []string `json:"args"`
+
+	// Stdin is the content of standard input.
+	Stdin string `json:"stdin"`
+
+	// StdinFile names a file in the testdata directory to attach to standard
+	// input instead of Stdin.
+	StdinFile string `json:"stdin_file"`
+
+	// Stdout is the expected standard output. Ignored when StdoutContains is
+	// set.
+	Stdout string `json:"stdout"`
+
+	// StdoutContains is a string standard output must contain.
+	StdoutContains string `json:"stdout_contains"`
+
+	// Stderr is the expected standard error. Ignored when StderrContains is
+	// set.
+	Stderr string `json:"stderr"`
+
+	// StderrContains is a string standard error must contain.
+	StderrContains string `json:"stderr_contains"`
+
+	// Code is the expected exit code.
+	Code int `json:"code"`
+}
+
+// TestE2E runs the jsonpath command as a subprocess for each case in
+// testdata/e2e.json. It tests the binary named by the JSONPATH_BIN
+// environment variable, or else builds one from source. Packaging smoke
+// tests can therefore run the same cases against an installed binary:
+//
+//	JSONPATH_BIN=/usr/local/bin/jsonpath go test -run TestE2E ./cmd/jsonpath
+func TestE2E(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	bin := e2eBinary(t)
+	data, err := os.ReadFile(filepath.Join("testdata", "e2e.json"))
+	r.NoError(err)
+	var cases []e2eCase
+	r.NoError(json.Unmarshal(data, &cases))
+	r.NotEmpty(cases)
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			stdout, stderr, code := e2eRun(t, bin, tc)
+			a.Equal(tc.Code, code)
+			if tc.StdoutContains != "" {
+				a.Contains(stdout, tc.StdoutContains)
+			} else {
+				a.Equal(tc.Stdout, stdout)
+			}
+			if tc.StderrContains != "" {
+				a.Contains(stderr, tc.StderrContains)
+			} else {
+				a.Equal(tc.Stderr, stderr)
+			}
+		})
+	}
+}
+
+// e2eBinary returns the absolute path to the jsonpath binary named by the
+// JSONPATH_BIN environment variable, or else builds it in a temporary
+// directory. Skips the test in short mode when it must build the binary.
+func e2eBinary(t *testing.T) string {
+	t.Helper()
+	if bin := os.Getenv("JSONPATH_BIN"); bin != "" {
+		bin, err := filepath.Abs(bin)
+		require.NoError(t, err)
+		return bin
+	}
+	if testing.Short() {
+		t.Skip("skipping build of jsonpath binary in short mode")
+	}
+
+	bin := filepath.Join(t.TempDir(), "jsonpath")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	//nolint:gosec
+	out, err := exec.Command("go", "build", "-o", bin, ".").CombinedOutput()
+	require.NoError(t, err, "go build: %s", out)
+	return bin
+}
+
+// e2eRun runs bin in the testdata directory with tc's arguments and input,
+// and returns its standard output, standard error, and exit code.
+func e2eRun(t *testing.T, bin string, tc e2eCase) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(bin, tc.Args...) //nolint:gosec
+	cmd.Dir = "testdata"
+	cmd.Stdin = strings.NewReader(tc.Stdin)
+	if tc.StdinFile != "" {
+		f, err := os.Open(filepath.Join("testdata", tc.StdinFile))
+		require.NoError(t, err)
+		defer f.Close()
+		cmd.Stdin = f
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		require.NoError(t, err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
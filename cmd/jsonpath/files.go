@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+
+	"github.com/theory/jsonpath"
+)
+
+// The name of standard input in file arguments and, like grep, its label in
+// output prefixes.
+const (
+	stdinName  = "-"
+	stdinLabel = "(standard input)"
+)
+
+var (
+	// errDirectory is returned for a directory argument without --recursive.
+	errDirectory = errors.New("is a directory; pass --recursive to search it")
+
+	// errEmptyFile is returned for a file that contains no JSON value.
+	errEmptyFile = errors.New("no JSON value")
+)
+
+// queryFiles selects with p from the JSON value in each file named by names,
+// or in stdin if names is empty or for the name "-", and writes the results
+// for each to stdout as an indented JSON array, or, if opts.stream is true,
+// for each line as a JSON array on a single line, unless opts configures
+// another output format. Like grep, prefixes each line
+// of output with the file name when reading more than one file or searching
+// recursively, unless overridden by opts. Writes errors to stderr and
+// continues with the next file. Returns exitError if any file failed and,
+// if opts.exitStatus is true, exitNoMatch if p selected no values.
+func queryFiles(
+	p *jsonpath.Path,
+	names []string,
+	stdin io.Reader,
+	stdout, stderr io.Writer,
+	opts *options,
+) int {
+	if len(names) == 0 {
+		names = []string{stdinName}
+	}
+	prefix := opts.withFilename || !opts.noFilename && (len(names) > 1 || opts.recursive)
+
+	failed, matched := false, false
+	for _, name := range names {
+		for file, err := range inputFiles(name, opts) {
+			if err == nil {
+				var ok bool
+				ok, err = queryFile(p, file, stdin, stdout, prefix, opts)
+				matched = matched || ok
+			}
+			if err != nil {
+				printError(stderr, err)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		return exitError
+	}
+	return opts.exitCode(matched)
+}
+
+// inputFiles returns an iterator over the files to read for name, the name
+// of a file, of stdin, or of a directory. With opts.recursive, yields the
+// files in a directory and its subdirectories in lexical order, skipping
+// those with names that don't match opts.glob. Yields an error for a
+// directory that cannot be read or, without opts.recursive, for any
+// directory.
+func inputFiles(name string, opts *options) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		if name == stdinName {
+			yield(name, nil)
+			return
+		}
+		info, err := os.Stat(name)
+		if err != nil || !info.IsDir() {
+			// Let queryFile report a missing file.
+			yield(name, nil)
+			return
+		}
+		if !opts.recursive {
+			yield("", fmt.Errorf("%v: %w", name, errDirectory))
+			return
+		}
+
+		_ = filepath.WalkDir(name, func(path string, d fs.DirEntry, err error) error {
+			switch {
+			case err != nil:
+				if !yield("", fmt.Errorf("cannot read directory: %w", err)) {
+					return filepath.SkipAll
+				}
+				return nil
+			case d.IsDir():
+				return nil
+			case opts.glob != "":
+				if ok, _ := filepath.Match(opts.glob, d.Name()); !ok {
+					return nil
+				}
+			}
+			if !yield(path, nil) {
+				return filepath.SkipAll
+			}
+			return nil
+		})
+	}
+}
+
+// queryFile selects with p from the JSON value in the file name, or in stdin
+// if name is "-", and writes the results to out as an indented JSON array,
+// prefixing each line with the file name if prefix is true. If opts.stream
+// is true, instead selects from each line of the file. Returns true if p
+// selected any values.
+func queryFile(
+	p *jsonpath.Path,
+	name string,
+	stdin io.Reader,
+	out io.Writer,
+	prefix bool,
+	opts *options,
+) (bool, error) {
+	in, label := stdin, stdinLabel
+	if name != stdinName {
+		f, err := os.Open(name)
+		if err != nil {
+			return false, fmt.Errorf("cannot open input: %w", err)
+		}
+		defer f.Close()
+		in, label = f, name
+	}
+
+	if prefix {
+		out = &prefixWriter{w: out, prefix: label + ":", bol: true}
+	}
+	if opts.stream {
+		matched, err := stream(p, in, out, opts)
+		if err != nil && name != stdinName {
+			err = fmt.Errorf("%v: %w", name, err)
+		}
+		return matched, err
+	}
+
+	doc, err := decode(in, inputFormat(name, opts))
+	if err != nil {
+		if name == stdinName {
+			return false, err
+		}
+		if errors.Is(err, errNoInput) {
+			err = errEmptyFile
+		}
+		return false, fmt.Errorf("%v: %w", name, err)
+	}
+	return query(p, doc, out, opts)
+}
+
+// stream selects with p from the JSON value on each line of in and writes
+// the results for each line to out as a JSON array on a single line, or in
+// the format configured by opts. Returns true if p selected any values from
+// any line, including lines before an error.
+func stream(p *jsonpath.Path, in io.Reader, out io.Writer, opts *options) (bool, error) {
+	if isTerminal(in) {
+		return false, errNoInput
+	}
+	matched := false
+	var err error
+	if opts.located {
+		err = jsonpath.EachLineLocated(in, p, func(nodes jsonpath.LocatedNodeList) error {
+			matched = matched || len(nodes) > 0
+			return writeResults(out, locatedNodes(nodes), "", opts)
+		})
+	} else {
+		err = jsonpath.EachLine(in, p, func(res []any) error {
+			matched = matched || len(res) > 0
+			return writeResults(out, res, "", opts)
+		})
+	}
+	return matched, err
+}
+
+// prefixWriter writes prefix to w at the start of each line.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	// bol is true at the beginning of a line.
+	bol bool
+}
+
+// Write writes p to w, inserting the prefix at the start of each line.
+func (pw *prefixWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if pw.bol {
+			if _, err := io.WriteString(pw.w, pw.prefix); err != nil {
+				return written, err //nolint:wrapcheck
+			}
+			pw.bol = false
+		}
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line = p[:i+1]
+			pw.bol = true
+		}
+		n, err := pw.w.Write(line)
+		written += n
+		if err != nil {
+			return written, err //nolint:wrapcheck
+		}
+		p = p[len(line):]
+	}
+	return written, nil
+}
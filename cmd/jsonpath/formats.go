@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Input and output formats.
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
+var (
+	// errFormat is returned for an unknown --input or --output format.
+	errFormat = errors.New("unknown format")
+
+	// errStreamInput is returned for --stream with non-JSON --input.
+	errStreamInput = errors.New("--stream supports only JSON input")
+
+	// errYAMLOutput is returned for --output yaml with a line-oriented
+	// output flag.
+	errYAMLOutput = errors.New(
+		"--output yaml does not support --stream, --raw-output, --compact-output, --tsv, or --count",
+	)
+)
+
+// validateFormats returns an error if opts specify an unknown input or
+// output format, or a format that does not support other options.
+func (opts *options) validateFormats() error {
+	switch opts.input {
+	case "", formatJSON, formatYAML, formatTOML:
+	default:
+		return fmt.Errorf("invalid --input: %w %q", errFormat, opts.input)
+	}
+
+	switch opts.output {
+	case "", formatJSON:
+	case formatYAML:
+		if opts.stream || opts.rawOutput || opts.compact || opts.tsv || opts.count {
+			return errYAMLOutput
+		}
+	default:
+		return fmt.Errorf("invalid --output: %w %q", errFormat, opts.output)
+	}
+
+	if opts.stream && opts.input != "" && opts.input != formatJSON {
+		return errStreamInput
+	}
+	return nil
+}
+
+// inputFormat returns the format of the file name: opts.input if set,
+// otherwise YAML for names ending in .yaml or .yml, TOML for names ending
+// in .toml, and JSON for all others, including standard input.
+func inputFormat(name string, opts *options) string {
+	if opts.input != "" {
+		return opts.input
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	default:
+		return formatJSON
+	}
+}
+
+// decodeFormat decodes a single value in format from in and converts it to
+// the JSON data model: scalars, []any, and map[string]any. For YAML, decodes
+// only the first document. Returns io.EOF if in contains no value.
+func decodeFormat(in io.Reader, format string) (any, error) {
+	var doc any
+	switch format {
+	case formatYAML:
+		var node yaml.Node
+		if err := yaml.NewDecoder(in).Decode(&node); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		timestampsToStrings(&node)
+		if err := node.Decode(&doc); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	case formatTOML:
+		if err := toml.NewDecoder(in).Decode(&doc); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	default:
+		if err := json.NewDecoder(in).Decode(&doc); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return doc, nil
+	}
+	return toJSONModel(doc)
+}
+
+// timestampsToStrings retags the timestamp scalars in the YAML tree rooted
+// at node as strings, so that they decode to their original text rather
+// than to [time.Time] values.
+func timestampsToStrings(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!timestamp" {
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		timestampsToStrings(child)
+	}
+}
+
+// toJSONModel converts val, decoded from YAML or TOML, to the JSON data
+// model. Converts the keys of map[any]any, which YAML decodes for mappings
+// with non-string keys, to strings, and values that implement
+// [encoding.TextMarshaler], such as TOML dates and times, to their text.
+func toJSONModel(val any) (any, error) {
+	switch val := val.(type) {
+	case []any:
+		for i, v := range val {
+			conv, err := toJSONModel(v)
+			if err != nil {
+				return nil, err
+			}
+			val[i] = conv
+		}
+		return val, nil
+	case map[string]any:
+		for k, v := range val {
+			conv, err := toJSONModel(v)
+			if err != nil {
+				return nil, err
+			}
+			val[k] = conv
+		}
+		return val, nil
+	case map[any]any:
+		res := make(map[string]any, len(val))
+		for k, v := range val {
+			conv, err := toJSONModel(v)
+			if err != nil {
+				return nil, err
+			}
+			res[fmt.Sprint(k)] = conv
+		}
+		return res, nil
+	case encoding.TextMarshaler:
+		text, err := val.MarshalText()
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return string(text), nil
+	default:
+		return val, nil
+	}
+}
+
+// writeYAML writes val to out as a YAML document indented by two spaces.
+func writeYAML(out io.Writer, val any) error {
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(val); err != nil {
+		return fmt.Errorf("cannot encode output: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("cannot encode output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"plugin"
+	"strings"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/funcs"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+// pluginSymbol is the name of the function that --funcs plugins must export
+// to register their functions.
+const pluginSymbol = "Register"
+
+// errPluginSymbol is returned for a --funcs plugin that does not export a
+// Register function with the expected signature.
+var errPluginSymbol = fmt.Errorf(
+	"plugin must export %v as func(*registry.Registry) error", pluginSymbol,
+)
+
+// fileList is a flag.Value that collects the values of a repeatable flag.
+type fileList []string
+
+// String returns the files in list separated by commas.
+func (list *fileList) String() string { return strings.Join(*list, ",") }
+
+// Set appends file to list.
+func (list *fileList) Set(file string) error {
+	*list = append(*list, file)
+	return nil
+}
+
+// newParser creates a parser with a registry containing the RFC 9535
+// functions, the extra functions if opts.extraFuncs is true, and the
+// functions registered by each plugin in opts.funcs.
+func newParser(opts *options) (*jsonpath.Parser, error) {
+	parser := jsonpath.NewParser()
+	if opts.extraFuncs {
+		if err := registerExtras(parser.Registry()); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range opts.funcs {
+		if err := loadPlugin(file, parser.Registry()); err != nil {
+			return nil, err
+		}
+	}
+	return parser, nil
+}
+
+// loadPlugin opens the Go plugin file and calls its Register function to
+// register its functions in reg.
+func loadPlugin(file string, reg *registry.Registry) error {
+	plug, err := plugin.Open(file)
+	if err != nil {
+		return fmt.Errorf("cannot load --funcs: %w", err)
+	}
+	sym, err := plug.Lookup(pluginSymbol)
+	if err != nil {
+		return fmt.Errorf("cannot load --funcs %v: %w", file, errPluginSymbol)
+	}
+	register, ok := sym.(func(*registry.Registry) error)
+	if !ok {
+		return fmt.Errorf("cannot load --funcs %v: %w", file, errPluginSymbol)
+	}
+	if err := register(reg); err != nil {
+		return fmt.Errorf("cannot load --funcs %v: %w", file, err)
+	}
+	return nil
+}
+
+// registerExtras registers the extra functions enabled by --extra-funcs in
+// reg: those provided by [github.com/theory/jsonpath/funcs], plus:
+//
+//   - lower(string): returns its argument converted to lowercase
+//   - upper(string): returns its argument converted to uppercase
+//
+// Each returns nil for an argument of any other type.
+func registerExtras(reg *registry.Registry) error {
+	if err := funcs.RegisterAll(reg); err != nil {
+		return fmt.Errorf("cannot register --extra-funcs: %w", err)
+	}
+	for name, fn := range map[string]func(string) string{
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	} {
+		if err := reg.Register(name, spec.FuncValue, checkValueArg, stringFunc(fn)); err != nil {
+			return fmt.Errorf("cannot register --extra-funcs: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkValueArg returns an error unless args contains a single expression
+// convertible to ValueType.
+func checkValueArg(args []spec.FunctionExprArg) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 argument but found %v", len(args))
+	}
+	if !args[0].ResultType().ConvertsTo(spec.PathValue) {
+		return errors.New("cannot convert argument to ValueType")
+	}
+	return nil
+}
+
+// stringFunc returns an evaluator that applies fn to a string value and
+// returns nil for any other value.
+func stringFunc(fn func(string) string) registry.Evaluator {
+	return func(args []spec.JSONPathValue) spec.JSONPathValue {
+		if v, err := spec.ValueFromErr(args[0]); err == nil && v != nil {
+			if str, ok := v.Value().(string); ok {
+				return spec.Value(fn(str))
+			}
+		}
+		return nil
+	}
+}
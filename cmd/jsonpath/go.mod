@@ -0,0 +1,18 @@
+module github.com/theory/jsonpath/cmd/jsonpath
+
+go 1.23
+
+require (
+	github.com/pelletier/go-toml/v2 v2.2.4
+	github.com/stretchr/testify v1.10.0
+	github.com/theory/jsonpath v0.0.0-00010101000000-000000000000
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
+
+replace github.com/theory/jsonpath => ../..
@@ -0,0 +1,492 @@
+// Command jsonpath selects values from JSON input using RFC 9535 JSONPath
+// queries.
+//
+// Usage:
+//
+//	jsonpath [flags] QUERY [FILE...]
+//	jsonpath analyze QUERY
+//	jsonpath profile [flags] QUERY FILE
+//	jsonpath demo
+//
+// The first form parses QUERY, reads a JSON value from each FILE, or from
+// standard input if there are no FILEs or for the FILE "-", and prints the
+// values selected from each as a JSON array. Pass --recursive (-R) to search
+// directories for files, and --glob to search only those with names that
+// match a pattern, such as '*.json'. Like grep, when reading more than one
+// file or searching recursively, prefixes each line of output with the name
+// of the file; pass --with-filename (-H) or --no-filename to override. Pass
+// --null-input (-n) to evaluate QUERY against null instead of reading input.
+// Pass --stream to read newline-delimited JSON (JSON Lines), such as logs,
+// and print the values selected from each line as a JSON array on a single
+// line.
+// Pass --located (-l) to print each selected value as an object with its
+// normalized path, as in {"path": "$['a'][0]", "node": 1}.
+//
+// Reads YAML from files with names ending in .yaml or .yml, TOML from files
+// with names ending in .toml, and JSON from all others, including standard
+// input; pass --input FORMAT to read json, yaml, or toml regardless of name.
+// Decodes only the first document in YAML input, and queries YAML and TOML
+// input as JSON, converting dates and times to strings. Pass --output yaml
+// to print results as YAML.
+//
+// Like jq, output flags print each selected value on its own line instead
+// of as an array: --compact-output (-c) prints each as compact JSON;
+// --raw-output (-r) prints strings without quotes and other values as
+// compact JSON; and --tsv prints the items of arrays as tab-separated fields,
+// escaping tabs, newlines, carriage returns, and backslashes in strings.
+// With --located, --tsv prints the normalized path as the first field.
+// Pass --count to print only the number of selected values. Output flags
+// are mutually exclusive, and with --stream apply to each line.
+//
+// Exits with status 0 on success and 2 on error. Pass --exit-status (-e) to
+// exit with status 1 if QUERY selects no values from any input, for use in
+// shell conditionals:
+//
+//	if jsonpath -e '$.spec.replicas' deploy.json > /dev/null; then ...
+//
+// In addition to the RFC 9535 functions, queries may use lower(), upper(),
+// and the functions provided by [github.com/theory/jsonpath/funcs], such as
+// keys(), sum(), and starts_with(), when passed --extra-funcs, and functions
+// loaded from Go plugins by --funcs FILE. A plugin must be built
+// with -buildmode=plugin against the same version of this module as the
+// command, and export a function to register its functions:
+//
+//	func Register(reg *registry.Registry) error
+//
+// The analyze subcommand parses QUERY and prints, as a JSON object, its
+// estimated complexity class, the features it uses, and lint findings, as
+// returned by [github.com/theory/jsonpath.Path.Analyze]. Useful for gating
+// queries in code review bots.
+//
+// The profile subcommand parses QUERY, reads a JSON value from FILE, or
+// from standard input if FILE is "-", evaluates QUERY against it --count
+// times (default 100), and prints, as a JSON object, the wall time, mean
+// allocations, nodes visited, and a per-segment timing breakdown. Pass
+// --cpuprofile to also write a pprof CPU profile of the evaluations. Useful
+// for attaching performance data to issues.
+//
+// The demo subcommand runs a curated set of queries against the documents
+// in the [github.com/theory/jsonpath/examples] package and prints each query
+// with its results.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+// Exit codes.
+const (
+	exitOK = 0
+	// exitNoMatch indicates that a query selected no values when configured
+	// with --exit-status.
+	exitNoMatch = 1
+	// exitError indicates an error. Like grep, uses 2 so that 1 may
+	// indicate the absence of results.
+	exitError = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the jsonpath command with args, reading input from stdin and
+// writing results to stdout and errors to stderr. Returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "demo":
+			if err := demo(stdout); err != nil {
+				printError(stderr, err)
+				return exitError
+			}
+			return exitOK
+		case "analyze":
+			if len(args) != 2 {
+				fmt.Fprintln(stderr, "jsonpath: analyze expects a single QUERY argument")
+				return exitError
+			}
+			if err := analyze(args[1], stdout); err != nil {
+				printError(stderr, err)
+				return exitError
+			}
+			return exitOK
+		case "profile":
+			return profileCmd(args[1:], stdin, stdout, stderr)
+		}
+	}
+
+	flags := flag.NewFlagSet("jsonpath", flag.ContinueOnError)
+	var opts options
+	flags.BoolVar(&opts.nullInput, "null-input", false, "evaluate QUERY against null without reading input")
+	flags.BoolVar(&opts.nullInput, "n", false, "shorthand for --null-input")
+	flags.BoolVar(&opts.located, "located", false, "output the normalized path of each selected value")
+	flags.BoolVar(&opts.located, "l", false, "shorthand for --located")
+	flags.BoolVar(&opts.stream, "stream", false, "read newline-delimited JSON and select from each line")
+	flags.StringVar(&opts.input, "input", "", "read input as `FORMAT` json, yaml, or toml (default: by file extension)")
+	flags.StringVar(&opts.output, "output", formatJSON, "write output as `FORMAT` json or yaml")
+	flags.BoolVar(&opts.rawOutput, "raw-output", false, "output each selected value on a line, strings without quotes")
+	flags.BoolVar(&opts.rawOutput, "r", false, "shorthand for --raw-output")
+	flags.BoolVar(&opts.compact, "compact-output", false, "output each selected value on a line as compact JSON")
+	flags.BoolVar(&opts.compact, "c", false, "shorthand for --compact-output")
+	flags.BoolVar(&opts.tsv, "tsv", false, "output each selected value on a line as tab-separated fields")
+	flags.BoolVar(&opts.count, "count", false, "output only the number of selected values")
+	flags.BoolVar(&opts.exitStatus, "exit-status", false, "exit with status 1 if QUERY selects no values")
+	flags.BoolVar(&opts.exitStatus, "e", false, "shorthand for --exit-status")
+	flags.BoolVar(&opts.recursive, "recursive", false, "search directories for files to read")
+	flags.BoolVar(&opts.recursive, "R", false, "shorthand for --recursive")
+	flags.StringVar(&opts.glob, "glob", "", "search only files in directories with names matching `PATTERN`")
+	flags.BoolVar(&opts.withFilename, "with-filename", false, "prefix output lines with file names")
+	flags.BoolVar(&opts.withFilename, "H", false, "shorthand for --with-filename")
+	flags.BoolVar(&opts.noFilename, "no-filename", false, "never prefix output lines with file names")
+	flags.BoolVar(&opts.extraFuncs, "extra-funcs", false, "enable extra functions, such as lower(), upper(), and keys()")
+	flags.Var(&opts.funcs, "funcs", "load functions from the Go plugin `FILE`; may be repeated")
+	flags.SetOutput(stderr)
+	flags.Usage = func() { usage(flags) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if flags.NArg() < 1 {
+		fmt.Fprintln(stderr, "jsonpath: expected a QUERY argument")
+		usage(flags)
+		return exitError
+	}
+
+	if err := opts.validate(flags.NArg() - 1); err != nil {
+		printError(stderr, err)
+		return exitError
+	}
+
+	parser, err := newParser(&opts)
+	if err != nil {
+		printError(stderr, err)
+		return exitError
+	}
+
+	p, err := parser.Parse(flags.Arg(0))
+	if err != nil {
+		printError(stderr, err)
+		return exitError
+	}
+
+	if opts.nullInput {
+		matched, err := query(p, nil, stdout, &opts)
+		if err != nil {
+			printError(stderr, err)
+			return exitError
+		}
+		return opts.exitCode(matched)
+	}
+
+	return queryFiles(p, flags.Args()[1:], stdin, stdout, stderr, &opts)
+}
+
+// printError writes err to stderr prefixed with "jsonpath: ", unless its
+// message already starts with the prefix, as do the errors returned by the
+// jsonpath package.
+func printError(stderr io.Writer, err error) {
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "jsonpath: ") {
+		msg = "jsonpath: " + msg
+	}
+	fmt.Fprintln(stderr, msg)
+}
+
+// usage writes the command usage to flags' output.
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintln(out, "  jsonpath [flags] QUERY [FILE...]")
+	fmt.Fprintln(out, "  jsonpath analyze QUERY")
+	fmt.Fprintln(out, "  jsonpath profile [flags] QUERY FILE")
+	fmt.Fprintln(out, "  jsonpath demo")
+
+	hasFlags := false
+	flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		fmt.Fprintln(out, "\nFlags:")
+		flags.PrintDefaults()
+	}
+}
+
+// options contains the options for a query, as set by command line flags.
+type options struct {
+	// nullInput evaluates the query against null instead of reading input.
+	nullInput bool
+
+	// located outputs the normalized path of each selected value.
+	located bool
+
+	// stream reads newline-delimited JSON and selects from each line.
+	stream bool
+
+	// input is the input format; if empty, determined by file extension.
+	input string
+
+	// output is the output format.
+	output string
+
+	// rawOutput outputs each selected value on a line, strings without
+	// quotes.
+	rawOutput bool
+
+	// compact outputs each selected value on a line as compact JSON.
+	compact bool
+
+	// tsv outputs each selected value on a line as tab-separated fields.
+	tsv bool
+
+	// count outputs only the number of selected values.
+	count bool
+
+	// exitStatus exits with exitNoMatch if the query selects no values.
+	exitStatus bool
+
+	// recursive searches directories for files to read.
+	recursive bool
+
+	// glob filters the files found in directories by name.
+	glob string
+
+	// withFilename prefixes output lines with file names.
+	withFilename bool
+
+	// noFilename never prefixes output lines with file names.
+	noFilename bool
+
+	// extraFuncs enables the extra functions.
+	extraFuncs bool
+
+	// funcs lists Go plugin files from which to load functions.
+	funcs fileList
+}
+
+// validate returns an error if opts are invalid for a query with files file
+// arguments.
+func (opts *options) validate(files int) error {
+	if opts.nullInput && files > 0 {
+		return errNullInputFiles
+	}
+	if opts.nullInput && opts.stream {
+		return errNullInputStream
+	}
+	if opts.withFilename && opts.noFilename {
+		return errFilenameFlags
+	}
+	formats := 0
+	for _, set := range []bool{opts.rawOutput, opts.compact, opts.tsv, opts.count} {
+		if set {
+			formats++
+		}
+	}
+	if formats > 1 {
+		return errOutputFlags
+	}
+	if err := opts.validateFormats(); err != nil {
+		return err
+	}
+	if _, err := filepath.Match(opts.glob, ""); err != nil {
+		return fmt.Errorf("invalid --glob: %w", err)
+	}
+	return nil
+}
+
+// exitCode returns the exit code for a successful query: exitNoMatch if
+// opts.exitStatus is true and matched is false, and exitOK otherwise.
+func (opts *options) exitCode(matched bool) int {
+	if opts.exitStatus && !matched {
+		return exitNoMatch
+	}
+	return exitOK
+}
+
+// locatedNode is the output of a value selected by a query and its
+// normalized path, in that order, when configured with --located.
+type locatedNode struct {
+	Path spec.NormalizedPath `json:"path" yaml:"path"`
+	Node any                 `json:"node" yaml:"node"`
+}
+
+var (
+	// errNoInput is returned when there is no input to query.
+	errNoInput = errors.New("no input; pipe JSON to standard input or pass --null-input")
+
+	// errNullInputFiles is returned for --null-input with FILE arguments.
+	errNullInputFiles = errors.New("--null-input does not accept FILE arguments")
+
+	// errNullInputStream is returned for both --null-input and --stream.
+	errNullInputStream = errors.New("--null-input and --stream are mutually exclusive")
+
+	// errFilenameFlags is returned for both --with-filename and
+	// --no-filename.
+	errFilenameFlags = errors.New("--with-filename and --no-filename are mutually exclusive")
+
+	// errOutputFlags is returned for more than one output format flag.
+	errOutputFlags = errors.New("--raw-output, --compact-output, --tsv, and --count are mutually exclusive")
+)
+
+// query selects from doc with p and writes the results to out as an indented
+// JSON array, or in the format configured by opts. Writes each result as a
+// locatedNode if opts.located is true. Returns true if p selected any
+// values.
+func query(p *jsonpath.Path, doc any, out io.Writer, opts *options) (bool, error) {
+	if !opts.located {
+		res := p.Select(doc)
+		return len(res) > 0, writeResults(out, res, "  ", opts)
+	}
+	res := p.SelectLocated(doc)
+	return len(res) > 0, writeResults(out, locatedNodes(res), "  ", opts)
+}
+
+// writeResults writes results to out in the format configured by opts:
+// by default, as a JSON array indented by indent unless indent is empty;
+// with opts.output set to formatYAML, as a YAML sequence; with opts.count,
+// as the number of results; otherwise, each result on its own line.
+func writeResults[T any](out io.Writer, results []T, indent string, opts *options) error {
+	switch {
+	case opts.count:
+		if _, err := fmt.Fprintln(out, len(results)); err != nil {
+			return fmt.Errorf("cannot write output: %w", err)
+		}
+		return nil
+	case opts.rawOutput, opts.compact, opts.tsv:
+		for _, res := range results {
+			if err := writeLine(out, res, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	case opts.output == formatYAML:
+		return writeYAML(out, results)
+	default:
+		return writeJSON(out, results, indent)
+	}
+}
+
+// writeLine writes res, a selected value or locatedNode, to out on a single
+// line: as tab-separated fields if opts.tsv is true; without quotes if
+// opts.rawOutput is true and res is a string; otherwise as compact JSON.
+func writeLine(out io.Writer, res any, opts *options) error {
+	var line string
+	switch {
+	case opts.tsv:
+		var fields []string
+		if n, ok := res.(locatedNode); ok {
+			fields, res = []string{n.Path.String()}, n.Node
+		}
+		if vals, ok := res.([]any); ok {
+			for _, val := range vals {
+				fields = append(fields, tsvField(val))
+			}
+		} else {
+			fields = append(fields, tsvField(res))
+		}
+		line = strings.Join(fields, "\t")
+	case opts.rawOutput:
+		if str, ok := res.(string); ok {
+			line = str
+			break
+		}
+		fallthrough
+	default:
+		return writeJSON(out, res, "")
+	}
+
+	if _, err := fmt.Fprintln(out, line); err != nil {
+		return fmt.Errorf("cannot write output: %w", err)
+	}
+	return nil
+}
+
+// tsvEscaper escapes special characters in tab-separated fields.
+var tsvEscaper = strings.NewReplacer(
+	"\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r",
+)
+
+// tsvField formats val as a tab-separated field: strings escaped by
+// tsvEscaper, null as an empty string, and other values as compact JSON.
+func tsvField(val any) string {
+	switch val := val.(type) {
+	case string:
+		return tsvEscaper.Replace(val)
+	case nil:
+		return ""
+	default:
+		buf := new(bytes.Buffer)
+		_ = writeJSON(buf, val, "")
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+}
+
+// locatedNodes converts nodes to locatedNodes for output.
+func locatedNodes(nodes jsonpath.LocatedNodeList) []locatedNode {
+	res := make([]locatedNode, len(nodes))
+	for i, n := range nodes {
+		res[i] = locatedNode{Path: n.Path, Node: n.Node}
+	}
+	return res
+}
+
+// analyze parses path and writes its analysis to out as an indented JSON
+// object.
+func analyze(path string, out io.Writer) error {
+	p, err := jsonpath.Parse(path)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	return writeJSON(out, p.Analyze(), "  ")
+}
+
+// decode decodes a single value in format, such as formatJSON, from in.
+// Returns errNoInput if in is an interactive terminal, which would otherwise
+// block waiting for input, or if it contains nothing but blank space.
+func decode(in io.Reader, format string) (any, error) {
+	if isTerminal(in) {
+		return nil, errNoInput
+	}
+
+	doc, err := decodeFormat(in, format)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errNoInput
+		}
+		return nil, fmt.Errorf("cannot decode input: %w", err)
+	}
+	return doc, nil
+}
+
+// isTerminal returns true if in is a file connected to a terminal.
+func isTerminal(in io.Reader) bool {
+	if f, ok := in.(*os.File); ok {
+		if fi, err := f.Stat(); err == nil {
+			return fi.Mode()&os.ModeCharDevice != 0
+		}
+	}
+	return false
+}
+
+// writeJSON writes val to out as JSON followed by a newline, indented by
+// indent unless indent is empty.
+func writeJSON(out io.Writer, val any, indent string) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", indent)
+	if err := enc.Encode(val); err != nil {
+		return fmt.Errorf("cannot encode output: %w", err)
+	}
+	return nil
+}
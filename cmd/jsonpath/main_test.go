@@ -0,0 +1,847 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name  string
+		args  []string
+		input string
+		out   string
+		err   string
+		code  int
+	}{
+		{
+			name:  "select",
+			args:  []string{"$.a[*]"},
+			input: `{"a": [1, "x", true]}`,
+			out:   "[\n  1,\n  \"x\",\n  true\n]\n",
+		},
+		{
+			name:  "no_results",
+			args:  []string{"$.b"},
+			input: `{"a": 1}`,
+			out:   "[]\n",
+		},
+		{
+			name:  "filter",
+			args:  []string{"$[?@.price < 10].title"},
+			input: `[{"title": "a", "price": 5}, {"title": "b", "price": 20}]`,
+			out:   "[\n  \"a\"\n]\n",
+		},
+		{
+			name: "null_input",
+			args: []string{"--null-input", "$"},
+			out:  "[\n  null\n]\n",
+		},
+		{
+			name:  "null_input_short",
+			args:  []string{"-n", "$.x"},
+			input: `{"x": 1}`,
+			out:   "[]\n",
+		},
+		{
+			name:  "located",
+			args:  []string{"--located", "$.a[*]"},
+			input: `{"a": [1, {"b": "x"}]}`,
+			out:   "[\n  {\n    \"path\": \"$['a'][0]\",\n    \"node\": 1\n  },\n  {\n    \"path\": \"$['a'][1]\",\n    \"node\": {\n      \"b\": \"x\"\n    }\n  }\n]\n",
+		},
+		{
+			name:  "located_short",
+			args:  []string{"-l", "$..x"},
+			input: `{"x": [{"x": null}]}`,
+			out:   "[\n  {\n    \"path\": \"$['x']\",\n    \"node\": [\n      {\n        \"x\": null\n      }\n    ]\n  },\n  {\n    \"path\": \"$['x'][0]['x']\",\n    \"node\": null\n  }\n]\n",
+		},
+		{
+			name:  "located_no_results",
+			args:  []string{"-l", "$.b"},
+			input: `{"a": 1}`,
+			out:   "[]\n",
+		},
+		{
+			name: "located_null_input",
+			args: []string{"-n", "-l", "$"},
+			out:  "[\n  {\n    \"path\": \"$\",\n    \"node\": null\n  }\n]\n",
+		},
+		{
+			name:  "stream",
+			args:  []string{"--stream", "$.a"},
+			input: "{\"a\": 1}\n{\"b\": 2}\n\n{\"a\": [3, 4]}",
+			out:   "[1]\n[]\n[[3,4]]\n",
+		},
+		{
+			name:  "stream_located",
+			args:  []string{"--stream", "-l", "$[?@ > 1]"},
+			input: "[1, 2]\n{\"x\": 3}\n",
+			out:   "[{\"path\":\"$[1]\",\"node\":2}]\n[{\"path\":\"$['x']\",\"node\":3}]\n",
+		},
+		{
+			name: "stream_empty",
+			args: []string{"--stream", "$"},
+		},
+		{
+			name:  "stream_invalid",
+			args:  []string{"--stream", "$"},
+			input: "1\n{\n3\n",
+			out:   "[1]\n",
+			err:   "jsonpath: invalid JSON line 2: unexpected end of JSON input\n",
+			code:  exitError,
+		},
+		{
+			name: "stream_null_input",
+			args: []string{"--stream", "-n", "$"},
+			err:  "jsonpath: --null-input and --stream are mutually exclusive\n",
+			code: exitError,
+		},
+		{
+			name:  "compact",
+			args:  []string{"-c", "$.a[*]"},
+			input: `{"a": [1, "x", {"b": [true, null]}]}`,
+			out:   "1\n\"x\"\n{\"b\":[true,null]}\n",
+		},
+		{
+			name:  "compact_located",
+			args:  []string{"--compact-output", "-l", "$.a[*]"},
+			input: `{"a": [1, "x"]}`,
+			out:   "{\"path\":\"$['a'][0]\",\"node\":1}\n{\"path\":\"$['a'][1]\",\"node\":\"x\"}\n",
+		},
+		{
+			name:  "compact_no_results",
+			args:  []string{"-c", "$.b"},
+			input: `{"a": 1}`,
+		},
+		{
+			name:  "raw_output",
+			args:  []string{"--raw-output", "$.a[*]"},
+			input: `{"a": ["x", "y\tz", 1, null, ["w"]]}`,
+			out:   "x\ny\tz\n1\nnull\n[\"w\"]\n",
+		},
+		{
+			name:  "raw_output_short",
+			args:  []string{"-r", "$.a"},
+			input: `{"a": "x"}`,
+			out:   "x\n",
+		},
+		{
+			name:  "tsv",
+			args:  []string{"--tsv", "$[*]"},
+			input: `[["a", 1, true, null, {"b": 2}, [3]], "x\ty\nz\\", 4]`,
+			out:   "a\t1\ttrue\t\t{\"b\":2}\t[3]\nx\\ty\\nz\\\\\n4\n",
+		},
+		{
+			name:  "tsv_located",
+			args:  []string{"--tsv", "-l", "$[*]"},
+			input: `[["a", "b"], "c"]`,
+			out:   "$[0]\ta\tb\n$[1]\tc\n",
+		},
+		{
+			name:  "count",
+			args:  []string{"--count", "$..*"},
+			input: `{"a": [1, 2]}`,
+			out:   "3\n",
+		},
+		{
+			name:  "count_no_results",
+			args:  []string{"--count", "-l", "$.b"},
+			input: `{"a": 1}`,
+			out:   "0\n",
+		},
+		{
+			name:  "stream_count",
+			args:  []string{"--stream", "--count", "$.a[*]"},
+			input: "{\"a\": [1, 2]}\n{\"b\": 2}\n",
+			out:   "2\n0\n",
+		},
+		{
+			name:  "stream_raw_output",
+			args:  []string{"--stream", "--raw-output", "$.a"},
+			input: "{\"a\": \"x\"}\n{\"b\": 2}\n{\"a\": 3}\n",
+			out:   "x\n3\n",
+		},
+		{
+			name: "output_flags",
+			args: []string{"-n", "-c", "--tsv", "$"},
+			err:  "jsonpath: --raw-output, --compact-output, --tsv, and --count are mutually exclusive\n",
+			code: exitError,
+		},
+		{
+			name:  "exit_status",
+			args:  []string{"-e", "$.a"},
+			input: `{"a": 1}`,
+			out:   "[\n  1\n]\n",
+		},
+		{
+			name:  "exit_status_no_match",
+			args:  []string{"--exit-status", "$.b"},
+			input: `{"a": 1}`,
+			out:   "[]\n",
+			code:  exitNoMatch,
+		},
+		{
+			name:  "no_match",
+			args:  []string{"$.b"},
+			input: `{"a": 1}`,
+			out:   "[]\n",
+		},
+		{
+			name: "exit_status_null_input",
+			args: []string{"-e", "-n", "$.a"},
+			out:  "[]\n",
+			code: exitNoMatch,
+		},
+		{
+			name:  "exit_status_stream",
+			args:  []string{"-e", "--stream", "--count", "$.a"},
+			input: "{\"b\": 1}\n{\"a\": 2}\n",
+			out:   "0\n1\n",
+		},
+		{
+			name:  "exit_status_stream_no_match",
+			args:  []string{"-e", "--stream", "-l", "$.a"},
+			input: "{\"b\": 1}\n{\"c\": 2}\n",
+			out:   "[]\n[]\n",
+			code:  exitNoMatch,
+		},
+		{
+			name:  "exit_status_error",
+			args:  []string{"-e", "$.a"},
+			input: `{"a": `,
+			err:   "jsonpath: cannot decode input: unexpected EOF\n",
+			code:  exitError,
+		},
+		{
+			name:  "input_yaml",
+			args:  []string{"--input", "yaml", "$.a[?@.b > 1]"},
+			input: "a:\n  - b: 1\n  - b: 2\n    c: [x, 'y']\n",
+			out:   "[\n  {\n    \"b\": 2,\n    \"c\": [\n      \"x\",\n      \"y\"\n    ]\n  }\n]\n",
+		},
+		{
+			name:  "input_yaml_keys",
+			args:  []string{"--input", "yaml", "-c", "$"},
+			input: "1: one\ntrue: yes\nt: 2024-03-01\nu: 2024-03-01T12:30:00Z\n",
+			out:   "{\"1\":\"one\",\"t\":\"2024-03-01\",\"true\":\"yes\",\"u\":\"2024-03-01T12:30:00Z\"}\n",
+		},
+		{
+			name:  "input_yaml_empty",
+			args:  []string{"--input", "yaml", "$"},
+			input: "\n",
+			err:   "jsonpath: no input; pipe JSON to standard input or pass --null-input\n",
+			code:  exitError,
+		},
+		{
+			name:  "input_yaml_invalid",
+			args:  []string{"--input", "yaml", "$"},
+			input: "a: [",
+			err:   "jsonpath: cannot decode input: yaml: line 1: did not find expected node content\n",
+			code:  exitError,
+		},
+		{
+			name:  "input_toml",
+			args:  []string{"--input", "toml", "-c", "$.server"},
+			input: "[server]\nport = 80\nstarted = 2024-03-01T12:30:00Z\n",
+			out:   "{\"port\":80,\"started\":\"2024-03-01T12:30:00Z\"}\n",
+		},
+		{
+			name:  "input_toml_invalid",
+			args:  []string{"--input", "toml", "$"},
+			input: "a = ",
+			err:   "jsonpath: cannot decode input: toml: ",
+			code:  exitError,
+		},
+		{
+			name:  "input_json",
+			args:  []string{"--input", "json", "$.a"},
+			input: `{"a": 1}`,
+			out:   "[\n  1\n]\n",
+		},
+		{
+			name:  "output_yaml",
+			args:  []string{"--output", "yaml", "$.a"},
+			input: `{"a": {"b": [1, "x"], "c": null}}`,
+			out:   "- b:\n    - 1\n    - x\n  c: null\n",
+		},
+		{
+			name:  "output_yaml_located",
+			args:  []string{"--output", "yaml", "-l", "$.a"},
+			input: `{"a": true}`,
+			out:   "- path: $['a']\n  node: true\n",
+		},
+		{
+			name:  "output_yaml_no_results",
+			args:  []string{"--output", "yaml", "$.b"},
+			input: `{"a": true}`,
+			out:   "[]\n",
+		},
+		{
+			name: "bad_input_format",
+			args: []string{"-n", "--input", "xml", "$"},
+			err:  "jsonpath: invalid --input: unknown format \"xml\"\n",
+			code: exitError,
+		},
+		{
+			name: "bad_output_format",
+			args: []string{"-n", "--output", "toml", "$"},
+			err:  "jsonpath: invalid --output: unknown format \"toml\"\n",
+			code: exitError,
+		},
+		{
+			name: "output_yaml_flags",
+			args: []string{"-n", "--output", "yaml", "--count", "$"},
+			err:  "jsonpath: --output yaml does not support --stream, --raw-output, --compact-output, --tsv, or --count\n",
+			code: exitError,
+		},
+		{
+			name: "stream_input_yaml",
+			args: []string{"--stream", "--input", "yaml", "$"},
+			err:  "jsonpath: --stream supports only JSON input\n",
+			code: exitError,
+		},
+		{
+			name: "empty_input",
+			args: []string{"$"},
+			err:  "jsonpath: no input; pipe JSON to standard input or pass --null-input\n",
+			code: exitError,
+		},
+		{
+			name:  "blank_input",
+			args:  []string{"$"},
+			input: "  \n\t",
+			err:   "jsonpath: no input; pipe JSON to standard input or pass --null-input\n",
+			code:  exitError,
+		},
+		{
+			name: "help",
+			args: []string{"-h"},
+			err:  "Usage:\n  jsonpath [flags] QUERY [FILE...]\n  jsonpath analyze QUERY\n  jsonpath profile [flags] QUERY FILE\n  jsonpath demo\n",
+		},
+		{
+			name: "no_query",
+			args: []string{},
+			err:  "jsonpath: expected a QUERY argument\nUsage:",
+			code: exitError,
+		},
+		{
+			name: "bad_flag",
+			args: []string{"--nope", "$"},
+			err:  "flag provided but not defined: -nope",
+			code: exitError,
+		},
+		{
+			name: "parse_error",
+			args: []string{"$.x["},
+			err:  "jsonpath: unexpected eof at position 5\n",
+			code: exitError,
+		},
+		{
+			name:  "extra_funcs",
+			args:  []string{"--extra-funcs", "$[?lower(@.name) == 'ann'].id"},
+			input: `[{"name": "ANN", "id": 1}, {"name": "Bob", "id": 2}, {"name": 3, "id": 3}]`,
+			out:   "[\n  1\n]\n",
+		},
+		{
+			name:  "extra_funcs_upper_keys",
+			args:  []string{"--extra-funcs", "$[?upper(@.a) == 'X' && keys(@) == $[0].k].a"},
+			input: `[{"k": ["a", "k"], "a": "x"}, {"a": "x"}, {"k": ["a", "k"], "a": "y"}]`,
+			out:   "[\n  \"x\"\n]\n",
+		},
+		{
+			name: "no_extra_funcs",
+			args: []string{"-n", "$[?lower(@) == 'a']"},
+			err:  "jsonpath: unknown function lower() at position 4\n",
+			code: exitError,
+		},
+		{
+			name: "extra_funcs_args",
+			args: []string{"-n", "--extra-funcs", "$[?keys(@, 1) == 'a']"},
+			err:  "jsonpath: function keys() expected 1 argument but found 2 at position 8\n",
+			code: exitError,
+		},
+		{
+			name: "funcs_no_plugin",
+			args: []string{"-n", "--funcs", filepath.Join("testdata", "nonesuch.so"), "$"},
+			err:  "jsonpath: cannot load --funcs: ",
+			code: exitError,
+		},
+		{
+			name: "analyze",
+			args: []string{"analyze", "$..*"},
+			out: `{
+  "query": "$..[*]",
+  "singular": false,
+  "complexity": "linear",
+  "usage": {
+    "filters": 0,
+    "descendants": 1,
+    "wildcards": 1,
+    "slices": 0,
+    "functions": []
+  },
+  "findings": [
+    {
+      "code": "descendant-wildcard",
+      "message": "descendant wildcard selects every node in the input"
+    }
+  ]
+}
+`,
+		},
+		{
+			name: "analyze_no_query",
+			args: []string{"analyze"},
+			err:  "jsonpath: analyze expects a single QUERY argument\n",
+			code: exitError,
+		},
+		{
+			name: "analyze_parse_error",
+			args: []string{"analyze", "$["},
+			err:  "jsonpath: unexpected eof at position 3\n",
+			code: exitError,
+		},
+		{
+			name: "profile_no_args",
+			args: []string{"profile", "$"},
+			err:  "jsonpath: profile expects QUERY and FILE arguments\n",
+			code: exitError,
+		},
+		{
+			name: "profile_help",
+			args: []string{"profile", "-h"},
+			err:  "Usage:\n",
+		},
+		{
+			name: "profile_bad_flag",
+			args: []string{"profile", "--nope", "$", "-"},
+			err:  "flag provided but not defined: -nope",
+			code: exitError,
+		},
+		{
+			name:  "profile_bad_count",
+			args:  []string{"profile", "--count", "0", "$", "-"},
+			input: `{}`,
+			err:   "jsonpath: --count must be greater than zero\n",
+			code:  exitError,
+		},
+		{
+			name: "profile_parse_error",
+			args: []string{"profile", "$[", "-"},
+			err:  "jsonpath: unexpected eof at position 3\n",
+			code: exitError,
+		},
+		{
+			name: "profile_no_file",
+			args: []string{"profile", "$", filepath.Join("testdata", "nonesuch.json")},
+			err:  "jsonpath: cannot open input: open testdata/nonesuch.json: no such file or directory\n",
+			code: exitError,
+		},
+		{
+			name: "profile_no_input",
+			args: []string{"profile", "$", "-"},
+			err:  "jsonpath: no input; pipe JSON to standard input or pass --null-input\n",
+			code: exitError,
+		},
+		{
+			name:  "invalid_json",
+			args:  []string{"$"},
+			input: `{"a": `,
+			err:   "jsonpath: cannot decode input: unexpected EOF\n",
+			code:  exitError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			code := run(tc.args, strings.NewReader(tc.input), stdout, stderr)
+			a.Equal(tc.code, code)
+			a.Equal(tc.out, stdout.String())
+			if tc.err == "" {
+				a.Empty(stderr.String())
+			} else {
+				a.Contains(stderr.String(), tc.err)
+			}
+		})
+	}
+}
+
+func TestQueryFiles(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	dir := filepath.Join("testdata", "files")
+	fileA := filepath.Join(dir, "a.json")
+	fileB := filepath.Join(dir, "b.json")
+	fileC := filepath.Join(dir, "sub", "c.json")
+	notes := filepath.Join(dir, "sub", "notes.txt")
+	logs := filepath.Join("testdata", "logs.ndjson")
+	deploy := filepath.Join("testdata", "deploy.yaml")
+	config := filepath.Join("testdata", "config.toml")
+
+	tmp := t.TempDir()
+	empty := filepath.Join(tmp, "empty.json")
+	invalid := filepath.Join(tmp, "invalid.json")
+	require.NoError(t, os.WriteFile(empty, []byte(" \n"), 0o600))
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"name":`), 0o600))
+
+	for _, tc := range []struct {
+		name  string
+		args  []string
+		input string
+		out   string
+		err   string
+		code  int
+	}{
+		{
+			name: "file",
+			args: []string{"$.name", fileA},
+			out:  "[\n  \"a\"\n]\n",
+		},
+		{
+			name: "file_with_filename",
+			args: []string{"-H", "$.name", fileA},
+			out:  fileA + ":[\n" + fileA + ":  \"a\"\n" + fileA + ":]\n",
+		},
+		{
+			name: "files",
+			args: []string{"$.name", fileA, fileB},
+			out:  fileA + ":[\n" + fileA + ":  \"a\"\n" + fileA + ":]\n" + fileB + ":[\n" + fileB + ":  \"b\"\n" + fileB + ":]\n",
+		},
+		{
+			name: "files_no_filename",
+			args: []string{"--no-filename", "$.name", fileA, fileB},
+			out:  "[\n  \"a\"\n]\n[\n  \"b\"\n]\n",
+		},
+		{
+			name:  "stdin_and_file",
+			args:  []string{"$.name", "-", fileB},
+			input: `{"name": "in"}`,
+			out:   "(standard input):[\n(standard input):  \"in\"\n(standard input):]\n" + fileB + ":[\n" + fileB + ":  \"b\"\n" + fileB + ":]\n",
+		},
+		{
+			name: "located_file",
+			args: []string{"--located", "--with-filename", "$.tags[0]", fileA},
+			out:  fileA + ":[\n" + fileA + ":  {\n" + fileA + ":    \"path\": \"$['tags'][0]\",\n" + fileA + ":    \"node\": \"x\"\n" + fileA + ":  }\n" + fileA + ":]\n",
+		},
+		{
+			name: "stream_files",
+			args: []string{"--stream", "$.msg", logs, fileA},
+			out:  logs + ":[\"started\"]\n" + logs + ":[\"failed\"]\n" + logs + ":[\"retry failed\"]\n" + fileA + ":[]\n",
+		},
+		{
+			name: "stream_invalid_file",
+			args: []string{"--stream", "--no-filename", "$.name", invalid, fileB},
+			out:  "[\"b\"]\n",
+			err:  "jsonpath: " + invalid + ": jsonpath: invalid JSON line 1: unexpected end of JSON input\n",
+			code: exitError,
+		},
+		{
+			name: "exit_status_files",
+			args: []string{"-e", "--count", "$.tags", fileA, fileB},
+			out:  fileA + ":1\n" + fileB + ":0\n",
+		},
+		{
+			name: "exit_status_files_no_match",
+			args: []string{"-e", "--count", "$.nonesuch", fileA, fileB},
+			out:  fileA + ":0\n" + fileB + ":0\n",
+			code: exitNoMatch,
+		},
+		{
+			name: "exit_status_files_error",
+			args: []string{"-e", "--no-filename", "$.nonesuch", invalid, fileA},
+			out:  "[]\n",
+			err:  "jsonpath: " + invalid + ": cannot decode input: unexpected EOF\n",
+			code: exitError,
+		},
+		{
+			name: "yaml_file",
+			args: []string{"-c", "$.spec.template.spec.containers[*].image", deploy},
+			out:  "\"nginx:1.27\"\n\"envoy:1.31\"\n",
+		},
+		{
+			name: "toml_file",
+			args: []string{"-c", "$.users[?@.admin == true].name", config},
+			out:  "\"ann\"\n",
+		},
+		{
+			name: "toml_file_dates",
+			args: []string{"-c", "$['released', 'server']", config},
+			out:  "\"2024-03-01\"\n{\"host\":\"localhost\",\"ports\":[8080,8081],\"started\":\"2024-03-01T12:30:00Z\"}\n",
+		},
+		{
+			name: "mixed_files",
+			args: []string{"--count", "$..name", deploy, config, fileA},
+			out:  deploy + ":3\n" + config + ":2\n" + fileA + ":1\n",
+		},
+		{
+			name: "input_overrides_extension",
+			args: []string{"--input", "json", "$", deploy},
+			err:  "jsonpath: " + deploy + ": cannot decode input: invalid character 'a' looking for beginning of value\n",
+			code: exitError,
+		},
+		{
+			name: "recursive",
+			args: []string{"--recursive", "$.name", dir},
+			out:  fileA + ":[\n" + fileA + ":  \"a\"\n" + fileA + ":]\n" + fileB + ":[\n" + fileB + ":  \"b\"\n" + fileB + ":]\n" + fileC + ":[\n" + fileC + ":  \"c\"\n" + fileC + ":]\n" + notes + ":[\n" + notes + ":  \"notes\"\n" + notes + ":]\n",
+		},
+		{
+			name: "recursive_glob",
+			args: []string{"-R", "--glob", "*.txt", "--no-filename", "$.name", dir},
+			out:  "[\n  \"notes\"\n]\n",
+		},
+		{
+			name: "glob_ignores_files",
+			args: []string{"-R", "--glob", "*.txt", "$.name", fileA, filepath.Join(dir, "sub")},
+			out:  fileA + ":[\n" + fileA + ":  \"a\"\n" + fileA + ":]\n" + notes + ":[\n" + notes + ":  \"notes\"\n" + notes + ":]\n",
+		},
+		{
+			name: "directory",
+			args: []string{"$.name", dir, fileB},
+			out:  fileB + ":[\n" + fileB + ":  \"b\"\n" + fileB + ":]\n",
+			err:  "jsonpath: " + dir + ": is a directory; pass --recursive to search it\n",
+			code: exitError,
+		},
+		{
+			name: "missing_file",
+			args: []string{"--no-filename", "$.name", filepath.Join(dir, "nonesuch.json"), fileB},
+			out:  "[\n  \"b\"\n]\n",
+			err:  "jsonpath: cannot open input: open " + filepath.Join(dir, "nonesuch.json") + ": ",
+			code: exitError,
+		},
+		{
+			name: "empty_file",
+			args: []string{"$", empty},
+			err:  "jsonpath: " + empty + ": no JSON value\n",
+			code: exitError,
+		},
+		{
+			name: "invalid_file",
+			args: []string{"--no-filename", "$.name", invalid, fileA},
+			out:  "[\n  \"a\"\n]\n",
+			err:  "jsonpath: " + invalid + ": cannot decode input: unexpected EOF\n",
+			code: exitError,
+		},
+		{
+			name: "bad_glob",
+			args: []string{"-R", "--glob", "[", "$", dir},
+			err:  "jsonpath: invalid --glob: syntax error in pattern\n",
+			code: exitError,
+		},
+		{
+			name: "null_input_files",
+			args: []string{"-n", "$", fileA},
+			err:  "jsonpath: --null-input does not accept FILE arguments\n",
+			code: exitError,
+		},
+		{
+			name: "filename_flags",
+			args: []string{"-H", "--no-filename", "$", fileA},
+			err:  "jsonpath: --with-filename and --no-filename are mutually exclusive\n",
+			code: exitError,
+		},
+		{
+			name: "parse_error",
+			args: []string{"$[", fileA},
+			err:  "jsonpath: unexpected eof at position 3\n",
+			code: exitError,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			code := run(tc.args, strings.NewReader(tc.input), stdout, stderr)
+			a.Equal(tc.code, code)
+			a.Equal(tc.out, stdout.String())
+			if tc.err == "" {
+				a.Empty(stderr.String())
+			} else {
+				a.Contains(stderr.String(), tc.err)
+			}
+		})
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	buf := new(bytes.Buffer)
+	pw := &prefixWriter{w: buf, prefix: "f:", bol: true}
+	for _, str := range []string{"", "a", "b\nc\n", "\n", "d\ne"} {
+		n, err := pw.Write([]byte(str))
+		r.NoError(err)
+		a.Equal(len(str), n)
+	}
+	a.Equal("f:ab\nf:c\nf:\nf:d\nf:e", buf.String())
+}
+
+func TestExtraFuncs(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	reg := registry.NewEmpty()
+	r.NoError(registerExtras(reg))
+	a.Equal([]string{
+		"avg", "contains", "ends_with", "keys", "lower", "max", "min",
+		"starts_with", "sum", "type", "upper", "values",
+	}, reg.Names())
+	r.ErrorIs(registerExtras(reg), registry.ErrRegister)
+
+	for _, tc := range []struct {
+		name string
+		arg  any
+		exp  spec.JSONPathValue
+	}{
+		{"lower", "HeLLo", spec.Value("hello")},
+		{"lower", 42, nil},
+		{"upper", "HeLLo", spec.Value("HELLO")},
+		{"upper", []any{"x"}, nil},
+	} {
+		a.Equal(tc.exp, reg.Get(tc.name).Evaluate([]spec.JSONPathValue{spec.Value(tc.arg)}))
+		a.Nil(reg.Get(tc.name).Evaluate([]spec.JSONPathValue{nil}))
+	}
+
+	// Collect --funcs files.
+	var files fileList
+	r.NoError(files.Set("a.so"))
+	r.NoError(files.Set("b.so"))
+	a.Equal(fileList{"a.so", "b.so"}, files)
+	a.Equal("a.so,b.so", files.String())
+}
+
+func TestIsTerminal(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	a.False(isTerminal(strings.NewReader("")))
+
+	f, err := os.Open(filepath.Join("testdata", "demo.txt"))
+	r.NoError(err)
+	defer f.Close()
+	a.False(isTerminal(f))
+}
+
+func TestDemo(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	exp, err := os.ReadFile(filepath.Join("testdata", "demo.txt"))
+	r.NoError(err)
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	a.Equal(exitOK, run([]string{"demo"}, strings.NewReader(""), stdout, stderr))
+	a.Equal(string(exp), stdout.String())
+	a.Empty(stderr.String())
+}
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	for _, tc := range []struct {
+		name    string
+		args    []string
+		runs    int
+		results int
+		visited int
+		segs    []*segmentProfile
+	}{
+		{
+			name:    "root",
+			args:    []string{"$", "-"},
+			runs:    defaultRuns,
+			results: 1,
+			segs:    []*segmentProfile{},
+		},
+		{
+			name:    "children",
+			args:    []string{"--count", "3", "$.a[*]", "-"},
+			runs:    3,
+			results: 3,
+			visited: 2,
+			segs: []*segmentProfile{
+				{Segment: `["a"]`, Visited: 1, Selected: 1},
+				{Segment: `[*]`, Visited: 1, Selected: 3},
+			},
+		},
+		{
+			name:    "descendants",
+			args:    []string{"--count=1", "$..x", filepath.Join("testdata", "profile.json")},
+			runs:    1,
+			results: 2,
+			visited: 9,
+			segs: []*segmentProfile{
+				{Segment: `..["x"]`, Visited: 9, Selected: 2},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			input := strings.NewReader(`{"a": [1, {"x": 2}, [{"x": 3}]]}`)
+			a.Equal(exitOK, run(append([]string{"profile"}, tc.args...), input, stdout, stderr))
+			a.Empty(stderr.String())
+
+			var prof queryProfile
+			r.NoError(json.Unmarshal(stdout.Bytes(), &prof))
+			a.Equal(tc.runs, prof.Runs)
+			a.Equal(tc.results, prof.Results)
+			a.Equal(tc.visited, prof.NodesVisited)
+			a.NotEmpty(prof.WallTime)
+			a.NotEmpty(prof.MeanTime)
+
+			r.Len(prof.Segments, len(tc.segs))
+			for i, seg := range prof.Segments {
+				a.NotEmpty(seg.MeanTime)
+				seg.MeanTime = ""
+				a.Equal(tc.segs[i], seg)
+			}
+		})
+	}
+
+	t.Run("cpuprofile", func(t *testing.T) {
+		t.Parallel()
+		file := filepath.Join(t.TempDir(), "cpu.pprof")
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		args := []string{"profile", "--cpuprofile", file, "$..x", "-"}
+		a.Equal(exitOK, run(args, strings.NewReader(`[{"x": 1}]`), stdout, stderr))
+		a.Empty(stderr.String())
+		a.Contains(stdout.String(), `"results": 1`)
+		info, err := os.Stat(file)
+		r.NoError(err)
+		a.Positive(info.Size())
+	})
+
+	t.Run("bad_cpuprofile", func(t *testing.T) {
+		t.Parallel()
+		file := filepath.Join(t.TempDir(), "nonesuch", "cpu.pprof")
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		args := []string{"profile", "--cpuprofile", file, "$", "-"}
+		a.Equal(exitError, run(args, strings.NewReader(`{}`), stdout, stderr))
+		a.Contains(stderr.String(), "jsonpath: cannot create CPU profile: ")
+		a.Empty(stdout.String())
+	})
+}
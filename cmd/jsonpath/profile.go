@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"time"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+// defaultRuns is the default number of times the profile subcommand
+// evaluates a query.
+const defaultRuns = 100
+
+// errRuns is returned when the profile subcommand is asked to run a query
+// less than once.
+var errRuns = errors.New("--count must be greater than zero")
+
+// profileOptions contains the options for the profile subcommand, as set by
+// command line flags.
+type profileOptions struct {
+	// runs is the number of times to evaluate the query.
+	runs int
+
+	// cpuProfile is the name of a file to which to write a pprof CPU profile
+	// of the evaluations. Empty for no profile.
+	cpuProfile string
+}
+
+// queryProfile reports the performance of evaluating a query against a JSON
+// value.
+type queryProfile struct {
+	// Query is the normalized string representation of the query.
+	Query string `json:"query"`
+
+	// Runs is the number of times the query was evaluated.
+	Runs int `json:"runs"`
+
+	// Results is the number of nodes the query selects.
+	Results int `json:"results"`
+
+	// WallTime is the total time spent evaluating the query.
+	WallTime string `json:"wall_time"`
+
+	// MeanTime is the mean time spent evaluating the query once.
+	MeanTime string `json:"mean_time"`
+
+	// AllocsPerRun is the mean number of allocations per evaluation.
+	AllocsPerRun uint64 `json:"allocs_per_run"`
+
+	// BytesPerRun is the mean number of bytes allocated per evaluation.
+	BytesPerRun uint64 `json:"bytes_per_run"`
+
+	// NodesVisited is the number of nodes against which the query's
+	// selectors were evaluated.
+	NodesVisited int `json:"nodes_visited"`
+
+	// Segments reports the performance of each segment of the query.
+	Segments []*segmentProfile `json:"segments"`
+}
+
+// segmentProfile reports the performance of evaluating a single segment of
+// a query.
+type segmentProfile struct {
+	// Segment is the string representation of the segment.
+	Segment string `json:"segment"`
+
+	// Visited is the number of nodes against which the segment's selectors
+	// were evaluated, including descendants for descendant segments.
+	Visited int `json:"visited"`
+
+	// Selected is the number of nodes the segment selected.
+	Selected int `json:"selected"`
+
+	// MeanTime is the mean time spent evaluating the segment once.
+	MeanTime string `json:"mean_time"`
+
+	// elapsed is the total time spent evaluating the segment.
+	elapsed time.Duration
+}
+
+// profileCmd executes the profile subcommand with args, reading input from
+// stdin when the file argument is "-" and writing the profile to stdout and
+// errors to stderr. Returns the exit code.
+func profileCmd(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("jsonpath profile", flag.ContinueOnError)
+	opts := profileOptions{}
+	flags.IntVar(&opts.runs, "count", defaultRuns, "number of times to evaluate QUERY")
+	flags.StringVar(&opts.cpuProfile, "cpuprofile", "", "write a pprof CPU profile to `file`")
+	flags.SetOutput(stderr)
+	flags.Usage = func() { usage(flags) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if flags.NArg() != 2 {
+		fmt.Fprintln(stderr, "jsonpath: profile expects QUERY and FILE arguments")
+		return exitError
+	}
+
+	if err := profile(flags.Arg(0), flags.Arg(1), stdin, stdout, &opts); err != nil {
+		printError(stderr, err)
+		return exitError
+	}
+	return exitOK
+}
+
+// profile parses path, decodes a JSON value from file, or from stdin if file
+// is "-", evaluates the query against it opts.runs times, and writes the
+// resulting queryProfile to out as an indented JSON object.
+func profile(path, file string, stdin io.Reader, out io.Writer, opts *profileOptions) error {
+	if opts.runs < 1 {
+		return errRuns
+	}
+
+	p, err := jsonpath.Parse(path)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	in := stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return fmt.Errorf("cannot open input: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	doc, err := decode(in, formatJSON)
+	if err != nil {
+		return err
+	}
+
+	if opts.cpuProfile != "" {
+		f, err := os.Create(opts.cpuProfile)
+		if err != nil {
+			return fmt.Errorf("cannot create CPU profile: %w", err)
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("cannot start CPU profile: %w", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	return writeJSON(out, newProfile(p, doc, opts.runs), "  ")
+}
+
+// newProfile evaluates p against doc runs times and returns the resulting
+// queryProfile.
+func newProfile(p *jsonpath.Path, doc any, runs int) *queryProfile {
+	segs := p.Query().Segments()
+	prof := &queryProfile{
+		Query:    p.String(),
+		Runs:     runs,
+		Segments: make([]*segmentProfile, len(segs)),
+	}
+	for i, seg := range segs {
+		prof.Segments[i] = &segmentProfile{Segment: seg.String()}
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	var res []any
+	for range runs {
+		res = evaluate(segs, doc, prof.Segments)
+	}
+	wall := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	prof.Results = len(res)
+	prof.WallTime = wall.String()
+	prof.MeanTime = (wall / time.Duration(runs)).String()
+	prof.AllocsPerRun = (after.Mallocs - before.Mallocs) / uint64(runs)
+	prof.BytesPerRun = (after.TotalAlloc - before.TotalAlloc) / uint64(runs)
+
+	// Count nodes outside the timed evaluations.
+	nodes := []any{doc}
+	for i, seg := range segs {
+		sp := prof.Segments[i]
+		sp.MeanTime = (sp.elapsed / time.Duration(runs)).String()
+		next := []any{}
+		for _, v := range nodes {
+			sp.Visited += visited(seg, v)
+			next = append(next, seg.Select(v, doc)...)
+		}
+		sp.Selected = len(next)
+		prof.NodesVisited += sp.Visited
+		nodes = next
+	}
+
+	return prof
+}
+
+// evaluate selects segs from doc, adding the time spent evaluating each
+// segment to the corresponding segmentProfile in profs, and returns the
+// selected values.
+func evaluate(segs []*spec.Segment, doc any, profs []*segmentProfile) []any {
+	res := []any{doc}
+	for i, seg := range segs {
+		start := time.Now()
+		next := []any{}
+		for _, v := range res {
+			next = append(next, seg.Select(v, doc)...)
+		}
+		profs[i].elapsed += time.Since(start)
+		res = next
+	}
+	return res
+}
+
+// visited returns the number of nodes against which seg evaluates its
+// selectors when selecting from current: 1 for child segments, and current
+// plus all of its descendants for descendant segments.
+func visited(seg *spec.Segment, current any) int {
+	if !seg.IsDescendant() {
+		return 1
+	}
+	count := 1
+	switch val := current.(type) {
+	case []any:
+		for _, v := range val {
+			count += visited(seg, v)
+		}
+	case map[string]any:
+		for _, v := range val {
+			count += visited(seg, v)
+		}
+	}
+	return count
+}
@@ -0,0 +1,248 @@
+// Package compliance runs test cases from the [JSONPath Compliance Test
+// Suite] against the JSONPath engine and reports which pass and fail.
+//
+// The suite's cts.json file lives in the jsonpath-compliance-test-suite Git
+// submodule of this repository, which the tests for this package run in
+// full (use `make submodules` to check it out). Applications that extend
+// the engine, such as with function extensions, can run the suite against
+// their own [jsonpath.Parser], loading it with [testsupport.LoadFile] or
+// downloading it with [Fetch], and use a [SkipList] to skip the cases they
+// knowingly fail:
+//
+//	cases, err := compliance.Fetch(ctx, http.DefaultClient, compliance.SuiteURL)
+//	if err != nil {
+//		return err
+//	}
+//	report := compliance.Run(parser, cases, compliance.SkipList{
+//		"name of a failing case": "reason it fails",
+//	})
+//	fmt.Println(report)
+//
+// [JSONPath Compliance Test Suite]: https://github.com/jsonpath-standard/jsonpath-compliance-test-suite
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"slices"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/testsupport"
+)
+
+// SuiteURL is the URL of the cts.json file of the latest version of the
+// JSONPath Compliance Test Suite.
+const SuiteURL = "https://raw.githubusercontent.com/jsonpath-standard/jsonpath-compliance-test-suite/main/cts.json"
+
+// ErrFailed errors are returned by [Check] for test cases that fail.
+var ErrFailed = errors.New("compliance: test case failed")
+
+// SkipList maps the names of test cases to skip to the reasons to skip
+// them.
+type SkipList map[string]string
+
+// Result is the result of running a single test case.
+type Result struct {
+	// Case is the test case.
+	Case testsupport.Case
+
+	// Err describes why Case failed. Nil if it passed or was skipped.
+	Err error
+
+	// Skip is the reason Case was skipped, from a [SkipList]. Empty if it
+	// was not skipped.
+	Skip string
+}
+
+// Passed returns true if r's test case passed.
+func (r Result) Passed() bool {
+	return r.Err == nil && r.Skip == ""
+}
+
+// Report lists the results of running test cases.
+type Report struct {
+	// Results lists the result of each test case, in the order run.
+	Results []Result
+}
+
+// Passed returns the number of test cases that passed.
+func (r *Report) Passed() int {
+	return r.count(func(res Result) bool { return res.Passed() })
+}
+
+// Failed returns the number of test cases that failed.
+func (r *Report) Failed() int {
+	return r.count(func(res Result) bool { return res.Err != nil })
+}
+
+// Skipped returns the number of test cases that were skipped.
+func (r *Report) Skipped() int {
+	return r.count(func(res Result) bool { return res.Skip != "" })
+}
+
+// Failures returns the results of the test cases that failed.
+func (r *Report) Failures() []Result {
+	res := []Result{}
+	for _, result := range r.Results {
+		if result.Err != nil {
+			res = append(res, result)
+		}
+	}
+	return res
+}
+
+// String returns a summary of r, such as "700 passed, 2 failed, 1 skipped".
+func (r *Report) String() string {
+	return fmt.Sprintf("%d passed, %d failed, %d skipped", r.Passed(), r.Failed(), r.Skipped())
+}
+
+// count returns the number of results for which fn returns true.
+func (r *Report) count(fn func(Result) bool) int {
+	n := 0
+	for _, res := range r.Results {
+		if fn(res) {
+			n++
+		}
+	}
+	return n
+}
+
+// Run runs each of cases against p with [Check], except for those named in
+// skip, and reports the results.
+func Run(p *jsonpath.Parser, cases []testsupport.Case, skip SkipList) *Report {
+	report := &Report{Results: make([]Result, len(cases))}
+	for i, c := range cases {
+		report.Results[i].Case = c
+		if reason, ok := skip[c.Name]; ok {
+			report.Results[i].Skip = reason
+			continue
+		}
+		report.Results[i].Err = Check(p, c)
+	}
+	return report
+}
+
+// Check parses the selector of c with p and, if c expects a valid selector,
+// selects from its document. Returns nil if c passes and otherwise an
+// [ErrFailed] error that describes how it failed. A test case passes if:
+//
+//   - It expects an invalid selector and parsing fails with
+//     [jsonpath.ErrPathParse].
+//   - It expects a valid selector, parsing succeeds, and the query selects
+//     its result or one of its alternative results, with the matching
+//     normalized paths, if it specifies any.
+func Check(p *jsonpath.Parser, c testsupport.Case) error {
+	query, err := p.Parse(c.Selector)
+	if c.InvalidSelector {
+		switch {
+		case err == nil:
+			return fmt.Errorf("%w: parsed invalid selector %q as %v", ErrFailed, c.Selector, query)
+		case !errors.Is(err, jsonpath.ErrPathParse):
+			return fmt.Errorf("%w: unexpected error parsing %q: %w", ErrFailed, c.Selector, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%w: cannot parse %q: %w", ErrFailed, c.Selector, err)
+	}
+
+	results, paths := c.Results, c.ResultsPaths
+	if c.Result != nil {
+		results = [][]any{c.Result}
+		paths = nil
+		if c.ResultPaths != nil {
+			paths = [][]string{c.ResultPaths}
+		}
+	}
+	if results == nil {
+		// Nothing to compare.
+		return nil
+	}
+
+	// Select nodes and their paths together, since separate evaluations
+	// may select object members in different orders.
+	var nodes []any
+	var normal []string
+	if paths == nil {
+		nodes = query.Select(c.Document)
+	} else {
+		nodes, normal = []any{}, []string{}
+		for _, n := range query.SelectLocated(c.Document) {
+			nodes = append(nodes, n.Node)
+			normal = append(normal, n.Path.String())
+		}
+	}
+
+	for i, exp := range results {
+		if !slices.EqualFunc(nodes, exp, reflect.DeepEqual) {
+			continue
+		}
+		if i < len(paths) && !slices.Equal(normal, paths[i]) {
+			continue
+		}
+		return nil
+	}
+
+	if len(results) == 1 {
+		return fmt.Errorf(
+			"%w: %q selected %v%v but expected %v",
+			ErrFailed, c.Selector, jsonText(nodes), at(normal), jsonText(results[0]),
+		)
+	}
+	return fmt.Errorf(
+		"%w: %q selected %v%v but expected one of %v",
+		ErrFailed, c.Selector, jsonText(nodes), at(normal), jsonText(results),
+	)
+}
+
+// Fetch downloads and loads the test cases in the corpus at url, such as
+// [SuiteURL], with client. Sets the Source of each case to the base name of
+// url.
+func Fetch(ctx context.Context, client *http.Client, url string) ([]testsupport.Case, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("compliance: %w", err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("compliance: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("compliance: cannot fetch %v: %v", url, res.Status)
+	}
+
+	cases, err := testsupport.Load(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	name := path.Base(req.URL.Path)
+	for i := range cases {
+		cases[i].Source = name
+	}
+	return cases, nil
+}
+
+// at returns " at " followed by the JSON representation of paths, or an
+// empty string if paths is nil.
+func at(paths []string) string {
+	if paths == nil {
+		return ""
+	}
+	return " at " + jsonText(paths)
+}
+
+// jsonText returns the JSON representation of val for use in error
+// messages.
+func jsonText(val any) string {
+	js, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(js)
+}
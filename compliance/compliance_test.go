@@ -0,0 +1,219 @@
+package compliance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/testsupport"
+)
+
+// skip lists the cases of the JSONPath Compliance Test Suite that the
+// engine knowingly fails.
+//
+//nolint:gochecknoglobals
+var skip = SkipList{}
+
+func TestSuite(t *testing.T) {
+	t.Parallel()
+	cases, err := testsupport.LoadFile(
+		filepath.Join("..", "jsonpath-compliance-test-suite", "cts.json"),
+	)
+	require.NoError(t, err, "run make submodules to check out the suite")
+	require.NotEmpty(t, cases)
+	p := jsonpath.NewParser()
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			if reason, ok := skip[tc.Name]; ok {
+				t.Skip(reason)
+			}
+			if err := Check(p, tc); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+	p := jsonpath.NewParser()
+	doc := map[string]any{"a": []any{1.0, 2.0}, "o": map[string]any{"x": 1.0, "y": 2.0}}
+
+	for _, tc := range []struct {
+		name string
+		tc   testsupport.Case
+		err  string
+	}{
+		{
+			name: "result",
+			tc:   testsupport.Case{Selector: "$.a[1]", Document: doc, Result: []any{2.0}},
+		},
+		{
+			name: "empty_result",
+			tc:   testsupport.Case{Selector: "$.nope", Document: doc, Result: []any{}},
+		},
+		{
+			name: "result_paths",
+			tc: testsupport.Case{
+				Selector:    "$.a[*]",
+				Document:    doc,
+				Result:      []any{1.0, 2.0},
+				ResultPaths: []string{"$['a'][0]", "$['a'][1]"},
+			},
+		},
+		{
+			name: "results",
+			tc: testsupport.Case{
+				Selector: "$.o.*",
+				Document: doc,
+				Results:  [][]any{{1.0, 2.0}, {2.0, 1.0}},
+			},
+		},
+		{
+			name: "results_paths",
+			tc: testsupport.Case{
+				Selector:     "$.o.*",
+				Document:     doc,
+				Results:      [][]any{{1.0, 2.0}, {2.0, 1.0}},
+				ResultsPaths: [][]string{{"$['o']['x']", "$['o']['y']"}, {"$['o']['y']", "$['o']['x']"}},
+			},
+		},
+		{
+			name: "invalid",
+			tc:   testsupport.Case{Selector: "$[", InvalidSelector: true},
+		},
+		{
+			name: "no_expectation",
+			tc:   testsupport.Case{Selector: "$", Document: doc},
+		},
+		{
+			name: "wrong_result",
+			tc:   testsupport.Case{Selector: "$.a[0]", Document: doc, Result: []any{2.0}},
+			err:  `compliance: test case failed: "$.a[0]" selected [1] but expected [2]`,
+		},
+		{
+			name: "wrong_results",
+			tc: testsupport.Case{
+				Selector: "$.a.*",
+				Document: doc,
+				Results:  [][]any{{2.0, 1.0}, {3.0}},
+			},
+			err: `compliance: test case failed: "$.a.*" selected [1,2] but expected one of [[2,1],[3]]`,
+		},
+		{
+			name: "wrong_paths",
+			tc: testsupport.Case{
+				Selector:    "$.a[1]",
+				Document:    doc,
+				Result:      []any{2.0},
+				ResultPaths: []string{"$['a'][0]"},
+			},
+			err: `compliance: test case failed: "$.a[1]" selected [2] at ["$['a'][1]"] but expected [2]`,
+		},
+		{
+			name: "valid_invalid",
+			tc:   testsupport.Case{Selector: "$.a", InvalidSelector: true},
+			err:  `compliance: test case failed: parsed invalid selector "$.a" as $["a"]`,
+		},
+		{
+			name: "invalid_valid",
+			tc:   testsupport.Case{Selector: "$[", Document: doc, Result: []any{}},
+			err:  `compliance: test case failed: cannot parse "$[": jsonpath: unexpected eof at position 3`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := Check(p, tc.tc)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+			require.ErrorIs(t, err, ErrFailed)
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	cases := []testsupport.Case{
+		{Name: "pass", Selector: "$", Document: 1.0, Result: []any{1.0}},
+		{Name: "fail", Selector: "$", Document: 1.0, Result: []any{2.0}},
+		{Name: "skip", Selector: "$", Document: 1.0, Result: []any{3.0}},
+		{Name: "invalid", Selector: "x", InvalidSelector: true},
+	}
+	report := Run(jsonpath.NewParser(), cases, SkipList{"skip": "known failure"})
+	r.Len(report.Results, 4)
+	a.Equal(2, report.Passed())
+	a.Equal(1, report.Failed())
+	a.Equal(1, report.Skipped())
+	a.Equal("2 passed, 1 failed, 1 skipped", report.String())
+
+	for i, res := range report.Results {
+		a.Equal(cases[i], res.Case)
+	}
+	a.True(report.Results[0].Passed())
+	a.False(report.Results[1].Passed())
+	a.ErrorIs(report.Results[1].Err, ErrFailed)
+	a.False(report.Results[2].Passed())
+	a.NoError(report.Results[2].Err)
+	a.Equal("known failure", report.Results[2].Skip)
+	a.Equal([]Result{report.Results[1]}, report.Failures())
+
+	// The embedded corpus passes.
+	report = Run(jsonpath.NewParser(), testsupport.Embedded(), nil)
+	a.Empty(report.Failures())
+	a.Equal(len(report.Results), report.Passed())
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/suite/cts.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"tests": [{"name": "root", "selector": "$", "document": 1, "result": [1]}]}`))
+	})
+	mux.HandleFunc("/bad.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"tests": `))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	cases, err := Fetch(ctx, srv.Client(), srv.URL+"/suite/cts.json")
+	r.NoError(err)
+	a.Equal([]testsupport.Case{{
+		Name:     "root",
+		Selector: "$",
+		Document: 1.0,
+		Result:   []any{1.0},
+		Source:   "cts.json",
+	}}, cases)
+
+	_, err = Fetch(ctx, srv.Client(), srv.URL+"/nonesuch.json")
+	r.EqualError(err, "compliance: cannot fetch "+srv.URL+"/nonesuch.json: 404 Not Found")
+
+	_, err = Fetch(ctx, srv.Client(), srv.URL+"/bad.json")
+	r.EqualError(err, "testsupport: cannot decode corpus: unexpected EOF")
+
+	_, err = Fetch(ctx, srv.Client(), "::nope")
+	r.Error(err)
+	r.ErrorContains(err, "compliance: ")
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = Fetch(canceled, srv.Client(), srv.URL+"/suite/cts.json")
+	r.ErrorIs(err, context.Canceled)
+}
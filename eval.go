@@ -0,0 +1,918 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+// ErrMemoryBudget errors are returned by [Path.SelectWith] and
+// [Path.SelectLocatedWith] when the nodes selected by a query exceed the
+// budget set by [WithMemoryBudget].
+var ErrMemoryBudget = errors.New("jsonpath: memory budget exceeded")
+
+// ErrSegmentTimeout is the [Warning] error reported for selectors abandoned
+// because evaluation of their segment exceeded the timeout set by
+// [WithSegmentTimeout].
+var ErrSegmentTimeout = errors.New("jsonpath: segment timed out")
+
+// SelectOption defines an option for evaluating a query with
+// [Path.SelectWith] and [Path.SelectLocatedWith].
+type SelectOption func(*evalConfig)
+
+// evalConfig contains the configuration for evaluating a query.
+type evalConfig struct {
+	// maxBytes is the maximum approximate number of bytes retained by
+	// selected nodes. Zero means no limit.
+	maxBytes int
+
+	// warn receives warnings for panics recovered during evaluation.
+	warn func(Warning)
+
+	// repanic disables the recovery of panics during evaluation.
+	repanic bool
+
+	// timeout is the maximum duration of the evaluation of each segment.
+	// Zero means no limit.
+	timeout time.Duration
+
+	// nodeInfo annotates located nodes with their types and sizes.
+	nodeInfo bool
+
+	// cacheSingular caches the values of absolute singular queries in
+	// filter expressions.
+	cacheSingular bool
+
+	// transform transforms each selected value before it's returned.
+	transform func(node any) any
+
+	// docOrder merges the results of segments with multiple selectors in
+	// document order, without duplicates.
+	docOrder bool
+
+	// hints describe the sizes of nodes for planning the evaluation of
+	// segments.
+	hints []Hint
+
+	// structs converts the input from Go values of any type before
+	// evaluation.
+	structs bool
+
+	// funcs override the evaluation of functions by name.
+	funcs map[string]func(args []spec.JSONPathValue) spec.JSONPathValue
+
+	// foldNames matches name selectors to member names case-insensitively.
+	foldNames bool
+
+	// collation compares strings in filter expressions by collation key.
+	collation spec.Collation
+
+	// sortNames selects the members of map[string]any objects in name
+	// order.
+	sortNames bool
+
+	// descOrder is the order in which descendant segments visit nodes.
+	descOrder DescendantOrder
+
+	// maxDescent is the maximum number of levels below the node to which
+	// a descendant segment applies from which it selects nodes. Zero means
+	// no limit.
+	maxDescent int
+}
+
+// Warning describes a selector that failed to select from a node, either
+// because it panicked, such as in a buggy function extension, or because it
+// exceeded the timeout set by [WithSegmentTimeout]. The failure aborts only
+// the selection of the selector from a single node, so that evaluation of
+// the rest of the query continues.
+type Warning struct {
+	// Selector is the selector that failed.
+	Selector spec.Selector
+
+	// Path is the normalized path to the node from which Selector
+	// selected. Set only by [Path.SelectLocatedWith].
+	Path spec.NormalizedPath
+
+	// Value is the value passed to panic, if Selector panicked.
+	Value any
+
+	// Err is [ErrSegmentTimeout] if Selector timed out.
+	Err error
+}
+
+// String returns a string representation of w.
+func (w Warning) String() string {
+	var at string
+	if w.Path != nil {
+		at = " at " + w.Path.String()
+	}
+	if errors.Is(w.Err, ErrSegmentTimeout) {
+		return fmt.Sprintf("selector %v timed out%v", w.Selector, at)
+	}
+	return fmt.Sprintf("selector %v panicked%v: %v", w.Selector, at, w.Value)
+}
+
+// WithMemoryBudget limits the approximate number of bytes retained by the
+// nodes selected at any stage of query evaluation to maxBytes. The size of
+// each selected node is estimated from its value, including all of its
+// descendants, so that queries such as `$..*` on huge documents stop with an
+// [ErrMemoryBudget] error rather than exhausting memory. Values of maxBytes
+// less than 1 disable the limit.
+func WithMemoryBudget(maxBytes int) SelectOption {
+	return func(c *evalConfig) { c.maxBytes = max(maxBytes, 0) }
+}
+
+// WithWarnings passes a [Warning] to fn for each panic recovered during
+// evaluation. [Path.SelectWith] and [Path.SelectLocatedWith] recover from
+// panics raised by selectors, including those raised by function
+// extensions, and omit the nodes the panicking selectors would have
+// selected; use WithWarnings to learn about them.
+func WithWarnings(fn func(Warning)) SelectOption {
+	return func(c *evalConfig) { c.warn = fn }
+}
+
+// WithRepanic disables the recovery of panics raised by selectors during
+// evaluation, so that they propagate to the caller with their original
+// stack traces. Useful for debugging function extensions.
+func WithRepanic() SelectOption {
+	return func(c *evalConfig) { c.repanic = true }
+}
+
+// WithSegmentTimeout limits the evaluation of each segment of a query to d,
+// so that a slow selector, such as one that calls a function extension that
+// fetches data from a slow backend, cannot stall the entire query. Once a
+// segment's deadline passes, evaluation abandons the selector running at the
+// time and skips the remaining nodes of the segment, reporting a [Warning]
+// with [ErrSegmentTimeout] for each to the function set by [WithWarnings],
+// then proceeds to the next segment with the nodes selected so far.
+//
+// To abandon selectors, evaluation runs each in a separate goroutine, which
+// continues to run until the selector returns. Values of d less than 1
+// disable the limit.
+func WithSegmentTimeout(d time.Duration) SelectOption {
+	return func(c *evalConfig) { c.timeout = max(d, 0) }
+}
+
+// WithNodeInfo configures [Path.SelectLocatedWith] to set the Info field of
+// each selected [spec.LocatedNode] to a [spec.NodeInfo] that describes the
+// node's JSON type and, for arrays and objects, number of children. Useful
+// for rendering result trees lazily without inspecting the values again.
+// Ignored by [Path.SelectWith].
+func WithNodeInfo() SelectOption {
+	return func(c *evalConfig) { c.nodeInfo = true }
+}
+
+// WithSingularCache configures [Path.SelectWith] and
+// [Path.SelectLocatedWith] to select the value of each absolute singular
+// query in filter expressions, such as $.config.threshold in
+// $.items[?@.value > $.config.threshold], only once per call rather than
+// once for every node the filter tests. Relative singular queries, such as
+// @.value, are never cached. Useful for queries that filter many nodes by
+// comparison to configuration values. See [spec.CacheSingular].
+func WithSingularCache() SelectOption {
+	return func(c *evalConfig) { c.cacheSingular = true }
+}
+
+// WithFunction configures [Path.SelectWith] and [Path.SelectLocatedWith] to
+// execute fn in place of the function named name for a single evaluation,
+// shadowing the function of that name in the registry of the [Parser] that
+// parsed the query. Use it for request-scoped functions in multi-tenant
+// servers, such as a has_permission() function that checks the permissions
+// of the user making a request: register the function with the parser's
+// registry so that queries can use it, then pass a closure over the user's
+// permissions to each evaluation, rather than creating a parser with a new
+// registry for every request.
+//
+// Parsing validates the arguments to each function and determines its
+// result type, so fn must accept the same arguments and return the same
+// type as the registered function. Overrides for functions that the query
+// does not use have no effect. See [spec.OverrideFunctions].
+func WithFunction(name string, fn registry.Evaluator) SelectOption {
+	return func(c *evalConfig) {
+		if c.funcs == nil {
+			c.funcs = map[string]func([]spec.JSONPathValue) spec.JSONPathValue{}
+		}
+		c.funcs[name] = fn
+	}
+}
+
+// WithTransform configures [Path.SelectWith] and [Path.SelectLocatedWith] to
+// replace each selected value with the value returned by passing it to fn,
+// such as to mask strings that match a secret pattern, so that API layers
+// can enforce data-handling policies in one place. Evaluation calls fn only
+// for the values the query selects, not for the intermediate values from
+// which it selects them, so fn must itself handle values that contain
+// others, such as objects and arrays. To avoid modifying the input, fn must
+// return a new value rather than change the value passed to it.
+func WithTransform(fn func(node any) any) SelectOption {
+	return func(c *evalConfig) { c.transform = fn }
+}
+
+// WithDocumentOrder configures [Path.SelectWith] and
+// [Path.SelectLocatedWith] to merge the nodes that the selectors of a
+// segment with multiple selectors select from each node in document order
+// and without duplicates, rather than in selector order as required by RFC
+// 9535. Array elements sort by index and, because Go maps have no order,
+// object members sort by name. For example, $[2,0,0] selects the first and
+// third elements of an array, in that order, and $["b","a"] selects members
+// "a" and "b". Several other JSONPath implementations order unions this
+// way, so this option eases comparison of their results.
+func WithDocumentOrder() SelectOption {
+	return func(c *evalConfig) { c.docOrder = true }
+}
+
+// WithCaseInsensitiveNames configures [Path.SelectWith] and
+// [Path.SelectLocatedWith] to match name selectors to member names
+// case-insensitively, so that $.FOO selects the member named "foo", "Foo",
+// or "FOO", as does @.FOO in a filter expression. Useful for querying
+// documents from sources that disagree on the case of member names. A name
+// selector prefers the member whose name matches exactly and otherwise
+// selects the first case-insensitive match, so it never selects more than
+// one member; [Path.SelectLocatedWith] reports the name of the member it
+// selected. See [spec.FoldNames].
+func WithCaseInsensitiveNames() SelectOption {
+	return func(c *evalConfig) { c.foldNames = true }
+}
+
+// WithStringCollation configures [Path.SelectWith] and
+// [Path.SelectLocatedWith] to compare strings in filter comparisons and
+// membership tests by the collation keys that keys return for them rather
+// than byte by byte. Pass [spec.FoldCase] to compare strings
+// case-insensitively, so that $[?@.name == "alice"] selects objects with
+// the name "Alice", or a Unicode normalization function, such as
+// norm.NFC.String from golang.org/x/text/unicode/norm, so that strings from
+// systems that emit NFD-normalized strings equal the NFC strings typical of
+// queries. Passing several applies each in turn, as in:
+//
+//	p.SelectWith(input, WithStringCollation(norm.NFC.String, spec.FoldCase))
+//
+// Functions such as match() and search() still compare strings exactly.
+// See [spec.Collate].
+func WithStringCollation(keys ...spec.Collation) SelectOption {
+	return func(c *evalConfig) {
+		switch len(keys) {
+		case 0:
+			c.collation = nil
+		case 1:
+			c.collation = keys[0]
+		default:
+			c.collation = func(s string) string {
+				for _, key := range keys {
+					s = key(s)
+				}
+				return s
+			}
+		}
+	}
+}
+
+// WithSortedNames configures [Path.SelectWith] and [Path.SelectLocatedWith]
+// to select the members of map[string]any objects in the order of their
+// names rather than in the random order in which Go iterates over maps, so
+// that wildcard, filter, and descendant selectors select the same nodes in
+// the same order every time, as snapshot tests and reproducible pipelines
+// require. Names sort byte by byte, as in [LocatedNodeList.Sort]. Objects
+// that have an order of their own, such as [json.RawMessage] and
+// [OrderedMap] objects, keep it.
+func WithSortedNames() SelectOption {
+	return func(c *evalConfig) { c.sortNames = true }
+}
+
+// DescendantOrder defines the order in which descendant segments visit
+// nodes, as configured by [WithDescendantOrder].
+type DescendantOrder uint8
+
+const (
+	// DescendantPreOrder visits each node, then the descendants of each of
+	// its children in turn, so that a descendant segment selects nodes in
+	// document order. The default.
+	DescendantPreOrder DescendantOrder = iota
+
+	// DescendantBreadthFirst visits the nodes at each depth, in document
+	// order, before those at the next depth, so that a descendant segment
+	// selects the shallowest nodes first.
+	DescendantBreadthFirst
+)
+
+// WithDescendantOrder configures [Path.SelectWith] and
+// [Path.SelectLocatedWith] to visit the nodes of descendant segments in
+// order. Pass [DescendantBreadthFirst] to select the shallowest matches
+// first, for example to pick the outermost matching object with
+// $..[?@.kind == "Deployment"]. Both orders select the same nodes, and
+// both visit each node before its descendants and the elements of each
+// array in order, as RFC 9535 requires.
+func WithDescendantOrder(order DescendantOrder) SelectOption {
+	return func(c *evalConfig) { c.descOrder = order }
+}
+
+// WithDescendantDepthLimit configures [Path.SelectWith] and
+// [Path.SelectLocatedWith] to limit descendant segments to selecting nodes
+// at most depth levels below the nodes to which they apply, to protect
+// against deeply nested or adversarial documents, or to search only a few
+// levels down. With a depth of 1, $..name selects the same nodes as
+// $.name, and with a depth of 2, it also selects the name members of the
+// children of the root. Depths of zero or less mean no limit, the default.
+// Descendant segments in filter expressions are not limited.
+func WithDescendantDepthLimit(depth int) SelectOption {
+	return func(c *evalConfig) { c.maxDescent = depth }
+}
+
+// SelectWith returns the values that JSONPath query p selects from input,
+// evaluated according to opt. Returns an error if evaluation violates a
+// limit set by opt, such as [WithMemoryBudget].
+func (p *Path) SelectWith(input any, opt ...SelectOption) (NodeList, error) {
+	e, err := newEvaluator(input, opt)
+	if err != nil {
+		return nil, err
+	}
+	e.strategies = e.plan(p.q)
+	res := []any{e.input}
+	for i, seg := range p.q.Segments() {
+		e.startSegment(i)
+		next := []any{}
+		for _, v := range res {
+			if next, err = e.selectSegment(seg, v, 0, next); err != nil {
+				return nil, err
+			}
+		}
+		res = next
+	}
+
+	if e.transform != nil {
+		for i, v := range res {
+			res[i] = e.transform(v)
+		}
+	}
+	return res, nil
+}
+
+// SelectLocatedWith returns the values that JSONPath query p selects from
+// input as [spec.LocatedNode] structs, evaluated according to opt. Returns an
+// error if evaluation violates a limit set by opt, such as
+// [WithMemoryBudget].
+func (p *Path) SelectLocatedWith(input any, opt ...SelectOption) (LocatedNodeList, error) {
+	e, err := newEvaluator(input, opt)
+	if err != nil {
+		return nil, err
+	}
+	e.strategies = e.plan(p.q)
+	res := []*spec.LocatedNode{{Node: e.input, Path: spec.NormalizedPath{}}}
+	for i, seg := range p.q.Segments() {
+		e.startSegment(i)
+		next := []*spec.LocatedNode{}
+		for _, n := range res {
+			if next, err = e.selectSegmentLocated(seg, n.Node, 0, n.Path, next); err != nil {
+				return nil, err
+			}
+		}
+		res = next
+	}
+
+	for _, n := range res {
+		if e.transform != nil {
+			n.Node = e.transform(n.Node)
+		}
+		if e.nodeInfo {
+			n.Info = spec.InfoOf(n.Node)
+		}
+	}
+	return res, nil
+}
+
+// evaluator evaluates a query against a root value according to an
+// evalConfig.
+type evaluator struct {
+	evalConfig
+
+	// input is the value from which to select.
+	input any
+
+	// root is the root value passed to selectors: input, wrapped by
+	// spec.FoldNames if configured with WithCaseInsensitiveNames, by
+	// spec.Collate if configured with WithStringCollation, by
+	// spec.CacheSingular if configured with WithSingularCache, and by
+	// spec.OverrideFunctions if configured with WithFunction.
+	root any
+
+	// used is the approximate number of bytes retained by the nodes
+	// selected by the current segment.
+	used int
+
+	// deadline is the time by which evaluation of the current segment must
+	// finish. Zero when there is no timeout.
+	deadline time.Time
+
+	// strategies lists the evaluation strategy for each segment, as
+	// planned from hints. Nil when every segment evaluates sequentially.
+	strategies []strategy
+
+	// strategy is the evaluation strategy for the current segment.
+	strategy strategy
+}
+
+// newEvaluator creates a new evaluator for input configured by opts.
+// Returns an error if configured with WithStructSupport and input cannot be
+// converted.
+func newEvaluator(input any, opts []SelectOption) (*evaluator, error) {
+	e := &evaluator{}
+	for _, o := range opts {
+		o(&e.evalConfig)
+	}
+	if e.structs {
+		var err error
+		if input, err = toJSONValue(input); err != nil {
+			return nil, err
+		}
+	}
+	e.input, e.root = input, input
+	if e.foldNames {
+		e.root = spec.FoldNames(e.root)
+	}
+	if e.collation != nil {
+		e.root = spec.Collate(e.root, e.collation)
+	}
+	if e.cacheSingular {
+		e.root = spec.CacheSingular(e.root)
+	}
+	if e.funcs != nil {
+		e.root = spec.OverrideFunctions(e.root, e.funcs)
+	}
+	return e, nil
+}
+
+// startSegment resets the per-segment state of e for the segment at index
+// i.
+func (e *evaluator) startSegment(i int) {
+	e.used = 0
+	e.strategy = strategySequential
+	if e.strategies != nil {
+		e.strategy = e.strategies[i]
+	}
+	if e.timeout > 0 {
+		e.deadline = time.Now().Add(e.timeout)
+	}
+}
+
+// selectSegment appends the values seg selects from current, depth levels
+// below the node to which a descendant segment applies, to res and returns
+// the result.
+func (e *evaluator) selectSegment(seg *spec.Segment, current any, depth int, res []any) ([]any, error) {
+	if seg.IsDescendant() && e.descOrder == DescendantBreadthFirst {
+		return e.selectBreadthFirst(seg, current, res)
+	}
+
+	current = e.sorted(current)
+	res, err := e.selectSelectors(seg, current, res)
+	if err != nil {
+		return nil, err
+	}
+
+	if seg.IsDescendant() && e.descends(depth) {
+		switch val := current.(type) {
+		case []any:
+			for _, v := range val {
+				if res, err = e.selectSegment(seg, v, depth+1, res); err != nil {
+					return nil, err
+				}
+			}
+		case map[string]any:
+			for _, v := range val {
+				if res, err = e.selectSegment(seg, v, depth+1, res); err != nil {
+					return nil, err
+				}
+			}
+		case json.RawMessage, spec.OrderedMap, spec.Array, spec.Adapter:
+			// Let the wildcard selector decode and order it.
+			for _, v := range spec.Wildcard().Select(val, nil) {
+				if res, err = e.selectSegment(seg, v, depth+1, res); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// selectSelectors appends the values that the selectors of seg select from
+// current to res and returns the result.
+func (e *evaluator) selectSelectors(seg *spec.Segment, current any, res []any) ([]any, error) {
+	if e.docOrder && len(seg.Selectors()) > 1 {
+		for _, n := range e.selectUnion(seg, current, nil) {
+			if err := e.retain(n.Node); err != nil {
+				return nil, err
+			}
+			res = append(res, n.Node)
+		}
+		return res, nil
+	}
+
+	for _, sel := range seg.Selectors() {
+		for _, v := range e.selectFrom(sel, current) {
+			if err := e.retain(v); err != nil {
+				return nil, err
+			}
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+// selectBreadthFirst appends the values that descendant segment seg selects
+// from current and its descendants to res, visiting them breadth-first, and
+// returns the result.
+func (e *evaluator) selectBreadthFirst(seg *spec.Segment, current any, res []any) ([]any, error) {
+	level := []any{current}
+	for depth := 0; len(level) > 0; depth++ {
+		var next []any
+		for _, node := range level {
+			node = e.sorted(node)
+			var err error
+			if res, err = e.selectSelectors(seg, node, res); err != nil {
+				return nil, err
+			}
+			if e.descends(depth) {
+				// Let the wildcard selector decode and order the children.
+				next = append(next, spec.Wildcard().Select(node, nil)...)
+			}
+		}
+		level = next
+	}
+	return res, nil
+}
+
+// selectSegmentLocated appends the nodes seg selects from current, located
+// at parent and depth levels below the node to which a descendant segment
+// applies, to res and returns the result.
+func (e *evaluator) selectSegmentLocated(
+	seg *spec.Segment,
+	current any,
+	depth int,
+	parent spec.NormalizedPath,
+	res []*spec.LocatedNode,
+) ([]*spec.LocatedNode, error) {
+	if seg.IsDescendant() && e.descOrder == DescendantBreadthFirst {
+		return e.selectBreadthFirstLocated(seg, current, parent, res)
+	}
+
+	current = e.sorted(current)
+	res, err := e.selectSelectorsLocated(seg, current, parent, res)
+	if err != nil {
+		return nil, err
+	}
+
+	if seg.IsDescendant() && e.descends(depth) {
+		switch val := current.(type) {
+		case []any:
+			for i, v := range val {
+				path := append(parent, spec.Index(i))
+				if res, err = e.selectSegmentLocated(seg, v, depth+1, path, res); err != nil {
+					return nil, err
+				}
+			}
+		case map[string]any:
+			for k, v := range val {
+				path := append(parent, spec.Name(k))
+				if res, err = e.selectSegmentLocated(seg, v, depth+1, path, res); err != nil {
+					return nil, err
+				}
+			}
+		case json.RawMessage, spec.OrderedMap, spec.Array, spec.Adapter:
+			// Let the wildcard selector decode and order it.
+			for _, n := range spec.Wildcard().SelectLocated(val, nil, parent) {
+				if res, err = e.selectSegmentLocated(seg, n.Node, depth+1, n.Path, res); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// sorted returns current as a *sortedObject if e is configured with
+// WithSortedNames and current is a map[string]any, and otherwise returns
+// current unchanged.
+func (e *evaluator) sorted(current any) any {
+	if obj, ok := current.(map[string]any); ok && e.sortNames {
+		return &sortedObject{obj: obj}
+	}
+	return current
+}
+
+// selectSelectorsLocated appends the nodes that the selectors of seg select
+// from current, located at parent, to res and returns the result.
+func (e *evaluator) selectSelectorsLocated(
+	seg *spec.Segment,
+	current any,
+	parent spec.NormalizedPath,
+	res []*spec.LocatedNode,
+) ([]*spec.LocatedNode, error) {
+	if e.docOrder && len(seg.Selectors()) > 1 {
+		for _, n := range e.selectUnion(seg, current, parent) {
+			if err := e.retain(n.Node); err != nil {
+				return nil, err
+			}
+			res = append(res, n)
+		}
+		return res, nil
+	}
+
+	for _, sel := range seg.Selectors() {
+		for _, n := range e.selectLocatedFrom(sel, current, parent) {
+			if err := e.retain(n.Node); err != nil {
+				return nil, err
+			}
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
+
+// selectBreadthFirstLocated appends the nodes that descendant segment seg
+// selects from current, located at parent, and its descendants to res,
+// visiting them breadth-first, and returns the result.
+func (e *evaluator) selectBreadthFirstLocated(
+	seg *spec.Segment,
+	current any,
+	parent spec.NormalizedPath,
+	res []*spec.LocatedNode,
+) ([]*spec.LocatedNode, error) {
+	level := []*spec.LocatedNode{{Node: current, Path: parent}}
+	for depth := 0; len(level) > 0; depth++ {
+		var next []*spec.LocatedNode
+		for _, n := range level {
+			node := e.sorted(n.Node)
+			var err error
+			if res, err = e.selectSelectorsLocated(seg, node, n.Path, res); err != nil {
+				return nil, err
+			}
+			if e.descends(depth) {
+				// Let the wildcard selector decode and order the children.
+				next = append(next, spec.Wildcard().SelectLocated(node, nil, n.Path)...)
+			}
+		}
+		level = next
+	}
+	return res, nil
+}
+
+// descends returns true if a descendant segment descends into the children
+// of a node depth levels below the node to which it applies, as limited by
+// WithDescendantDepthLimit.
+func (e *evaluator) descends(depth int) bool {
+	return e.maxDescent <= 0 || depth+1 < e.maxDescent
+}
+
+// selectUnion returns the nodes that the selectors of seg select from
+// current, located at parent, in document order and without duplicates.
+func (e *evaluator) selectUnion(
+	seg *spec.Segment,
+	current any,
+	parent spec.NormalizedPath,
+) LocatedNodeList {
+	var nodes LocatedNodeList
+	for _, sel := range seg.Selectors() {
+		nodes = append(nodes, e.selectLocatedFrom(sel, current, parent)...)
+	}
+	nodes.Sort()
+	return nodes.Deduplicate()
+}
+
+// selectFrom returns the values sel selects from current. Unless configured
+// with WithRepanic, recovers from a panic raised by sel, reports it to the
+// warning function, and returns no values.
+func (e *evaluator) selectFrom(sel spec.Selector, current any) []any {
+	if !e.deadline.IsZero() {
+		return withDeadline(e, sel, nil, func() []any {
+			return sel.Select(current, e.root)
+		})
+	}
+	if res, ok := parallelFilter(e, sel, current, nil, func(_ int, v any) any {
+		return v
+	}); ok {
+		return res
+	}
+	if !e.repanic {
+		defer e.recover(sel, nil)
+	}
+	return sel.Select(current, e.root)
+}
+
+// selectLocatedFrom returns the nodes sel selects from current, located at
+// parent. Unless configured with WithRepanic, recovers from a panic raised
+// by sel, reports it to the warning function, and returns no nodes.
+func (e *evaluator) selectLocatedFrom(
+	sel spec.Selector,
+	current any,
+	parent spec.NormalizedPath,
+) []*spec.LocatedNode {
+	if !e.deadline.IsZero() {
+		// Clone parent, which the caller may append to after withDeadline
+		// abandons sel.
+		path := slices.Clone(parent)
+		return withDeadline(e, sel, parent, func() []*spec.LocatedNode {
+			return sel.SelectLocated(current, e.root, path)
+		})
+	}
+	if res, ok := parallelFilter(e, sel, current, parent, func(i int, v any) *spec.LocatedNode {
+		path := append(make(spec.NormalizedPath, 0, len(parent)+1), parent...)
+		return &spec.LocatedNode{Node: v, Path: append(path, spec.Index(i))}
+	}); ok {
+		return res
+	}
+	if !e.repanic {
+		defer e.recover(sel, parent)
+	}
+	return sel.SelectLocated(current, e.root, parent)
+}
+
+// recover recovers from a panic raised by sel and reports it to the warning
+// function. Must be deferred.
+func (e *evaluator) recover(sel spec.Selector, path spec.NormalizedPath) {
+	if val := recover(); val != nil {
+		e.report(Warning{Selector: sel, Path: path, Value: val})
+	}
+}
+
+// report passes w to the warning function, if any.
+func (e *evaluator) report(w Warning) {
+	if e.warn == nil {
+		return
+	}
+	if w.Path != nil {
+		w.Path = append(spec.NormalizedPath{}, w.Path...)
+	}
+	e.warn(w)
+}
+
+// withDeadline runs sel in a goroutine and returns its result, unless e's
+// deadline passes first, in which case it abandons sel, reports a timeout
+// warning, and returns the zero value. Also reports a timeout without
+// running sel if the deadline has already passed. Recovers from a panic
+// raised by sel and reports it, or re-raises it if configured with
+// WithRepanic.
+func withDeadline[T any](e *evaluator, sel spec.Selector, path spec.NormalizedPath, fn func() T) T {
+	var zero T
+	wait := time.Until(e.deadline)
+	if wait <= 0 {
+		e.report(Warning{Selector: sel, Path: path, Err: ErrSegmentTimeout})
+		return zero
+	}
+
+	type result struct {
+		val      T
+		panicked bool
+		panicVal any
+	}
+
+	// Buffer the channel so that an abandoned goroutine can exit.
+	ch := make(chan result, 1)
+	go func() {
+		defer func() {
+			if val := recover(); val != nil {
+				ch <- result{panicked: true, panicVal: val}
+			}
+		}()
+		ch <- result{val: fn()}
+	}()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case res := <-ch:
+		if res.panicked {
+			if e.repanic {
+				panic(res.panicVal)
+			}
+			e.report(Warning{Selector: sel, Path: path, Value: res.panicVal})
+		}
+		return res.val
+	case <-timer.C:
+		e.report(Warning{Selector: sel, Path: path, Err: ErrSegmentTimeout})
+		return zero
+	}
+}
+
+// retain accounts for the retention of val in a result set. Returns an
+// ErrMemoryBudget error if the retention exceeds the memory budget.
+func (e *evaluator) retain(val any) error {
+	if e.maxBytes == 0 {
+		return nil
+	}
+	e.used += sizeOf(val, e.maxBytes-e.used)
+	if e.used > e.maxBytes {
+		return fmt.Errorf(
+			"%w: selected nodes exceed %d bytes",
+			ErrMemoryBudget, e.maxBytes,
+		)
+	}
+	return nil
+}
+
+// Approximate memory sizes of JSON values.
+const (
+	ifaceSize = 16 // interface value
+	sliceSize = 24 // slice header
+	mapSize   = 48 // map header
+	entrySize = 32 // map entry overhead: string header and interface value
+	nameSize  = 16 // string header
+)
+
+// sizeOf returns an estimate of the number of bytes required to hold val
+// and all of its descendants. Stops estimating once the estimate exceeds
+// limit, so that the cost of estimating sizes is bounded by the budget.
+func sizeOf(val any, limit int) int {
+	switch v := val.(type) {
+	case string:
+		return ifaceSize + len(v)
+	case json.RawMessage:
+		return ifaceSize + sliceSize + len(v)
+	case []any:
+		size := ifaceSize + sliceSize
+		for _, e := range v {
+			if size > limit {
+				break
+			}
+			size += sizeOf(e, limit-size)
+		}
+		return size
+	case map[string]any:
+		size := ifaceSize + mapSize
+		for k, e := range v {
+			if size > limit {
+				break
+			}
+			size += entrySize + len(k) + sizeOf(e, limit-size)
+		}
+		return size
+	case spec.OrderedMap:
+		return sizeOfOrdered(v, limit)
+	case spec.Array:
+		size := ifaceSize + sliceSize
+		for i := range v.Len() {
+			if size > limit {
+				break
+			}
+			size += sizeOf(v.Index(i), limit-size)
+		}
+		return size
+	case spec.Adapter:
+		return sizeOf(v.Adapt(), limit)
+	default:
+		return ifaceSize
+	}
+}
+
+// sizeOfOrdered returns the estimated size of obj for [sizeOf]: that of a
+// map with the same members plus a slice of their names.
+func sizeOfOrdered(obj spec.OrderedMap, limit int) int {
+	size := ifaceSize + mapSize + sliceSize + obj.Len()*nameSize
+	for k, e := range obj.All() {
+		if size > limit {
+			break
+		}
+		size += entrySize + len(k) + sizeOf(e, limit-size)
+	}
+	return size
+}
+
+// sortedObject is a [spec.OrderedMap] view of a map[string]any that orders
+// its members by name, for [WithSortedNames].
+type sortedObject struct {
+	obj map[string]any
+
+	// names lists the names of the members of obj in order. Sorted only
+	// when needed, so that name selectors don't pay for it.
+	names []string
+}
+
+// Len returns the number of members in o.
+func (o *sortedObject) Len() int { return len(o.obj) }
+
+// Get returns the value of the member of o named name.
+func (o *sortedObject) Get(name string) (any, bool) {
+	val, ok := o.obj[name]
+	return val, ok
+}
+
+// All returns an iterator over the names and values of the members of o,
+// sorted by name.
+func (o *sortedObject) All() iter.Seq2[string, any] {
+	if o.names == nil {
+		o.names = slices.Sorted(maps.Keys(o.obj))
+	}
+	return func(yield func(string, any) bool) {
+		for _, name := range o.names {
+			if !yield(name, o.obj[name]) {
+				return
+			}
+		}
+	}
+}
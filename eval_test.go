@@ -0,0 +1,1341 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/examples"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestSelectWithDefaults(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	doc := examples.Bookstore()
+
+	for _, path := range []string{
+		`$`,
+		`$.store.book[*].author`,
+		`$..author`,
+		`$.store.*`,
+		`$.store..price`,
+		`$..book[2]`,
+		`$..book[-1]`,
+		`$..book[0,1]`,
+		`$..book[:2]`,
+		`$..book[?@.isbn]`,
+		`$..book[?@.price<10]`,
+		`$..*`,
+		`$.nonesuch`,
+	} {
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(path)
+
+			res, err := p.SelectWith(doc)
+			r.NoError(err)
+			a.ElementsMatch(p.Select(doc), res)
+
+			loc, err := p.SelectLocatedWith(doc)
+			r.NoError(err)
+			a.ElementsMatch(p.SelectLocated(doc), loc)
+		})
+	}
+}
+
+func TestSelectWithRawMessage(t *testing.T) {
+	t.Parallel()
+	skipRaw(t)
+	a := assert.New(t)
+	r := require.New(t)
+	doc, err := json.Marshal(examples.Bookstore())
+	r.NoError(err)
+	raw := json.RawMessage(doc)
+
+	for _, path := range []string{
+		`$.store.book[*].author`,
+		`$..author`,
+		`$.store..price`,
+		`$..book[?@.price<10].title`,
+		`$..*`,
+	} {
+		t.Run(path, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(path)
+
+			res, err := p.SelectWith(raw)
+			r.NoError(err)
+			a.Equal(p.Select(raw), res)
+			a.NotEmpty(res)
+
+			loc, err := p.SelectLocatedWith(raw)
+			r.NoError(err)
+			a.Equal(p.SelectLocated(raw), loc)
+		})
+	}
+}
+
+func TestWithMemoryBudget(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	doc := examples.Bookstore()
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		budget int
+		err    bool
+	}{
+		{
+			name:   "no_limit",
+			path:   `$..*`,
+			budget: 0,
+		},
+		{
+			name:   "negative_no_limit",
+			path:   `$..*`,
+			budget: -1,
+		},
+		{
+			name:   "small_result",
+			path:   `$.store.bicycle.color`,
+			budget: 4096,
+		},
+		{
+			name:   "descendants_exceed",
+			path:   `$..*`,
+			budget: 1024,
+			err:    true,
+		},
+		{
+			name:   "descendants_fit",
+			path:   `$..*`,
+			budget: 1 << 20,
+		},
+		{
+			name:   "string_exceeds",
+			path:   `$.store.book[0].title`,
+			budget: 20,
+			err:    true,
+		},
+		{
+			name:   "intermediate_exceeds",
+			path:   `$.store.book[0].title`,
+			budget: 300,
+			err:    true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+			opt := WithMemoryBudget(tc.budget)
+
+			res, err := p.SelectWith(doc, opt)
+			loc, lErr := p.SelectLocatedWith(doc, opt)
+			if tc.err {
+				r.ErrorIs(err, ErrMemoryBudget)
+				r.EqualError(err, "jsonpath: memory budget exceeded: selected nodes exceed "+
+					strconv.Itoa(tc.budget)+" bytes")
+				a.Nil(res)
+				r.ErrorIs(lErr, ErrMemoryBudget)
+				a.Nil(loc)
+			} else {
+				r.NoError(err)
+				a.ElementsMatch(p.Select(doc), res)
+				r.NoError(lErr)
+				a.ElementsMatch(p.SelectLocated(doc), loc)
+			}
+		})
+	}
+}
+
+func TestSelectRecover(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Register a function that panics on the value 2.
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"boom",
+		spec.FuncLogical,
+		func([]spec.FunctionExprArg) error { return nil },
+		func(args []spec.JSONPathValue) spec.JSONPathValue {
+			if v := spec.ValueFrom(args[0]); v != nil && v.Value() == 2.0 {
+				panic("boom")
+			}
+			return spec.LogicalTrue
+		},
+	))
+	parser := NewParser(WithRegistry(reg))
+
+	doc := map[string]any{
+		"a": []any{1.0, 3.0},
+		"b": []any{2.0, 4.0},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		exp   []any
+		warns []string
+	}{
+		{
+			name: "no_panic",
+			path: `$.a[?boom(@)]`,
+			exp:  []any{1.0, 3.0},
+		},
+		{
+			name:  "one_branch",
+			path:  `$.*[?boom(@)]`,
+			exp:   []any{1.0, 3.0},
+			warns: []string{`selector ?boom(@) panicked at $['b']: boom`},
+		},
+		{
+			name:  "union",
+			path:  `$.b[?boom(@), 1]`,
+			exp:   []any{4.0},
+			warns: []string{`selector ?boom(@) panicked at $['b']: boom`},
+		},
+		{
+			name:  "descendant",
+			path:  `$..[?boom(@)]`,
+			exp:   []any{doc["a"], doc["b"], 1.0, 3.0},
+			warns: []string{`selector ?boom(@) panicked at $['b']: boom`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := parser.Parse(tc.path)
+			r.NoError(err)
+
+			// Select with warnings.
+			var warns []Warning
+			res, err := p.SelectWith(doc, WithWarnings(func(w Warning) { warns = append(warns, w) }))
+			r.NoError(err)
+			a.ElementsMatch(tc.exp, res)
+			r.Len(warns, len(tc.warns))
+			for _, w := range warns {
+				a.Nil(w.Path)
+				a.Equal("boom", w.Value)
+				a.Equal("?boom(@)", w.Selector.String())
+			}
+
+			// Located select.
+			var locWarns []string
+			loc, err := p.SelectLocatedWith(doc, WithWarnings(func(w Warning) {
+				locWarns = append(locWarns, w.String())
+			}))
+			r.NoError(err)
+			a.ElementsMatch(tc.exp, slices.Collect(loc.Nodes()))
+			a.Equal(tc.warns, locWarns)
+
+			// No warning function.
+			res, err = p.SelectWith(doc)
+			r.NoError(err)
+			a.ElementsMatch(tc.exp, res)
+
+			// Repanic.
+			if len(tc.warns) > 0 {
+				a.PanicsWithValue("boom", func() { _, _ = p.SelectWith(doc, WithRepanic()) })
+				a.PanicsWithValue("boom", func() { _, _ = p.SelectLocatedWith(doc, WithRepanic()) })
+			}
+		})
+	}
+}
+
+func TestWarningString(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	w := Warning{Selector: spec.Name("x"), Value: "oops"}
+	a.Equal(`selector "x" panicked: oops`, w.String())
+	w.Path = spec.NormalizedPath{spec.Index(1)}
+	a.Equal(`selector "x" panicked at $[1]: oops`, w.String())
+
+	w = Warning{Selector: spec.Index(0), Err: ErrSegmentTimeout}
+	a.Equal(`selector 0 timed out`, w.String())
+	w.Path = spec.NormalizedPath{spec.Name("y")}
+	a.Equal(`selector 0 timed out at $['y']`, w.String())
+}
+
+func TestWithSegmentTimeout(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Register a function that blocks on the value 2 until the test ends
+	// and panics on the value 3.
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"slow",
+		spec.FuncLogical,
+		func([]spec.FunctionExprArg) error { return nil },
+		func(args []spec.JSONPathValue) spec.JSONPathValue {
+			switch spec.ValueFrom(args[0]).Value() {
+			case 2.0:
+				<-block
+			case 3.0:
+				panic("slow")
+			}
+			return spec.LogicalTrue
+		},
+	))
+	parser := NewParser(WithRegistry(reg))
+
+	// Panics are still recovered and re-raised.
+	p, err := parser.Parse(`$[*][?slow(@)]`)
+	r.NoError(err)
+	panicDoc := []any{[]any{3.0}}
+	var warns []string
+	res, err := p.SelectWith(panicDoc, WithSegmentTimeout(time.Second), WithWarnings(func(w Warning) {
+		warns = append(warns, w.String())
+	}))
+	r.NoError(err)
+	a.Empty(res)
+	a.Equal([]string{`selector ?slow(@) panicked: slow`}, warns)
+	a.PanicsWithValue("slow", func() {
+		_, _ = p.SelectLocatedWith(panicDoc, WithSegmentTimeout(time.Second), WithRepanic())
+	})
+
+	doc := []any{[]any{1.0, 4.0}, []any{2.0}, []any{5.0}}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		exp   []any
+		warns []string
+	}{
+		{
+			name: "fast",
+			path: `$[0][?slow(@)]`,
+			exp:  []any{1.0, 4.0},
+		},
+		{
+			name: "timeout",
+			path: `$[*][?slow(@)]`,
+			exp:  []any{1.0, 4.0},
+			warns: []string{
+				`selector ?slow(@) timed out at $[1]`,
+				`selector ?slow(@) timed out at $[2]`,
+			},
+		},
+		{
+			name: "abandon_selector",
+			path: `$[?slow(@[0])][0]`,
+			exp:  []any{},
+			warns: []string{
+				`selector ?slow(@[0]) timed out at $`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := parser.Parse(tc.path)
+			r.NoError(err)
+			opt := WithSegmentTimeout(50 * time.Millisecond)
+
+			var warns []Warning
+			res, err := p.SelectWith(doc, opt, WithWarnings(func(w Warning) { warns = append(warns, w) }))
+			r.NoError(err)
+			a.Equal(tc.exp, []any(res))
+			r.Len(warns, len(tc.warns))
+			for _, w := range warns {
+				a.Nil(w.Path)
+				a.ErrorIs(w.Err, ErrSegmentTimeout)
+			}
+
+			var locWarns []string
+			loc, err := p.SelectLocatedWith(doc, opt, WithWarnings(func(w Warning) {
+				locWarns = append(locWarns, w.String())
+			}))
+			r.NoError(err)
+			a.Len(loc, len(res))
+			a.Equal(tc.warns, locWarns)
+		})
+	}
+
+}
+
+func TestWithSegmentTimeoutAbandoned(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Register a function that blocks on the value 99 until the test ends,
+	// so that the abandoned filter selector builds the paths of the nodes
+	// it filters while evaluation descends into the same nodes. Run with
+	// -race to detect the sharing of their paths.
+	block := make(chan struct{})
+	t.Cleanup(func() { close(block) })
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"slow",
+		spec.FuncLogical,
+		func([]spec.FunctionExprArg) error { return nil },
+		func(args []spec.JSONPathValue) spec.JSONPathValue {
+			if v, _ := spec.ValueFromErr(args[0]); v != nil && v.Value() == 99 {
+				<-block
+			}
+			return spec.LogicalTrue
+		},
+	))
+	p, err := NewParser(WithRegistry(reg)).Parse(`$..[?slow(@)]`)
+	r.NoError(err)
+
+	// The path to $[0][0][0] has spare capacity, which the filter selector
+	// and the descendant segment both append to.
+	doc := []any{[]any{[]any{[]any{1, 99, 2}}}}
+	var warns []string
+	res, err := p.SelectLocatedWith(doc, WithSegmentTimeout(20*time.Millisecond), WithWarnings(func(w Warning) {
+		warns = append(warns, w.String())
+	}))
+	r.NoError(err)
+	paths := []string{}
+	for path := range res.Paths() {
+		paths = append(paths, path.String())
+	}
+	a.Equal([]string{"$[0]", "$[0][0]", "$[0][0][0]"}, paths)
+	a.Equal([]string{
+		`selector ?slow(@) timed out at $[0][0][0]`,
+		`selector ?slow(@) timed out at $[0][0][0][0]`,
+		`selector ?slow(@) timed out at $[0][0][0][1]`,
+		`selector ?slow(@) timed out at $[0][0][0][2]`,
+	}, warns)
+}
+
+func TestWithNodeInfo(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	doc := examples.Bookstore()
+
+	p := MustParse(`$.store[*]`)
+	res, err := p.SelectLocatedWith(doc, WithNodeInfo())
+	r.NoError(err)
+	res.Sort()
+	a.Equal([]*spec.NodeInfo{
+		{Type: spec.JSONObject, Len: 2},
+		{Type: spec.JSONArray, Len: 4},
+	}, []*spec.NodeInfo{res[0].Info, res[1].Info})
+
+	p = MustParse(`$`)
+	res, err = p.SelectLocatedWith("hi", WithNodeInfo())
+	r.NoError(err)
+	a.Equal(&spec.NodeInfo{Type: spec.JSONString}, res[0].Info)
+
+	// No info by default.
+	res, err = p.SelectLocatedWith(doc)
+	r.NoError(err)
+	a.Nil(res[0].Info)
+}
+
+func TestWithSingularCache(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	doc := map[string]any{
+		"config": map[string]any{"threshold": 10, "color": "red"},
+		"items": []any{
+			map[string]any{"value": 5, "limit": 5, "color": "red"},
+			map[string]any{"value": 15, "limit": 20, "color": "blue"},
+			map[string]any{"value": 25, "limit": 20, "color": "red"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  NodeList
+	}{
+		{
+			name: "absolute",
+			path: `$.items[?@.value > $.config.threshold].value`,
+			exp:  NodeList{15, 25},
+		},
+		{
+			name: "repeated",
+			path: `$.items[?@.value > $.config.threshold && @.color == $.config.color].value`,
+			exp:  NodeList{25},
+		},
+		{
+			name: "relative",
+			path: `$.items[?@.value >= @.limit].value`,
+			exp:  NodeList{5, 25},
+		},
+		{
+			name: "mixed",
+			path: `$.items[?@.value > @.limit || @.limit < $.config.threshold].value`,
+			exp:  NodeList{5, 25},
+		},
+		{
+			name: "missing",
+			path: `$.items[?@.nonesuch == $.config.nonesuch].value`,
+			exp:  NodeList{5, 15, 25},
+		},
+		{
+			name: "nested",
+			path: `$.items[?$.items[?@.value > $.config.threshold]].value`,
+			exp:  NodeList{5, 15, 25},
+		},
+		{
+			name: "root",
+			path: `$.items[?$.config == $["config"]].value`,
+			exp:  NodeList{5, 15, 25},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+			a.Equal(tc.exp, p.Select(doc))
+
+			res, err := p.SelectWith(doc, WithSingularCache())
+			r.NoError(err)
+			a.Equal(tc.exp, res)
+
+			located, err := p.SelectLocatedWith(doc, WithSingularCache())
+			r.NoError(err)
+			a.Equal(tc.exp, NodeList(slices.Collect(located.Nodes())))
+		})
+	}
+}
+
+func TestWithFunction(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Register has_permission() to deny all by default.
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"has_permission",
+		spec.FuncLogical,
+		func(args []spec.FunctionExprArg) error {
+			if len(args) != 1 {
+				return errors.New("expected 1 argument")
+			}
+			return nil
+		},
+		func([]spec.JSONPathValue) spec.JSONPathValue { return spec.LogicalFalse },
+	))
+	parser := NewParser(WithRegistry(reg))
+	allow := func(users ...string) registry.Evaluator {
+		return func(args []spec.JSONPathValue) spec.JSONPathValue {
+			if v := spec.ValueFrom(args[0]); v != nil {
+				if owner, ok := v.Value().(string); ok && slices.Contains(users, owner) {
+					return spec.LogicalTrue
+				}
+			}
+			return spec.LogicalFalse
+		}
+	}
+
+	doc := map[string]any{
+		"config": map[string]any{"min": 2},
+		"docs": []any{
+			map[string]any{"id": 1, "owner": "alice"},
+			map[string]any{"id": 2, "owner": "bob"},
+			map[string]any{"id": 3, "owner": "alice"},
+		},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		opts  []SelectOption
+		exp   NodeList
+		paths []string
+	}{
+		{
+			name:  "registered",
+			path:  `$.docs[?has_permission(@.owner)].id`,
+			exp:   NodeList{},
+			paths: []string{},
+		},
+		{
+			name:  "override",
+			path:  `$.docs[?has_permission(@.owner)].id`,
+			opts:  []SelectOption{WithFunction("has_permission", allow("alice"))},
+			exp:   NodeList{1, 3},
+			paths: []string{`$['docs'][0]['id']`, `$['docs'][2]['id']`},
+		},
+		{
+			name:  "last_override",
+			path:  `$.docs[?has_permission(@.owner)].id`,
+			opts:  []SelectOption{WithFunction("has_permission", allow("alice")), WithFunction("has_permission", allow("bob"))},
+			exp:   NodeList{2},
+			paths: []string{`$['docs'][1]['id']`},
+		},
+		{
+			name:  "not_function",
+			path:  `$.docs[?!has_permission(@.owner)].id`,
+			opts:  []SelectOption{WithFunction("has_permission", allow("bob"))},
+			exp:   NodeList{1, 3},
+			paths: []string{`$['docs'][0]['id']`, `$['docs'][2]['id']`},
+		},
+		{
+			name: "standard_function",
+			path: `$.docs[?length(@.owner) == 3].id`,
+			opts: []SelectOption{WithFunction("length", func([]spec.JSONPathValue) spec.JSONPathValue {
+				return spec.Value(3)
+			})},
+			exp:   NodeList{1, 2, 3},
+			paths: []string{`$['docs'][0]['id']`, `$['docs'][1]['id']`, `$['docs'][2]['id']`},
+		},
+		{
+			name:  "unused",
+			path:  `$.docs[?@.owner == "bob"].id`,
+			opts:  []SelectOption{WithFunction("nonesuch", allow())},
+			exp:   NodeList{2},
+			paths: []string{`$['docs'][1]['id']`},
+		},
+		{
+			name: "singular_cache",
+			path: `$.docs[?has_permission(@.owner) && @.id >= $.config.min].id`,
+			opts: []SelectOption{
+				WithFunction("has_permission", allow("alice", "bob")),
+				WithSingularCache(),
+			},
+			exp:   NodeList{2, 3},
+			paths: []string{`$['docs'][1]['id']`, `$['docs'][2]['id']`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := parser.MustParse(tc.path)
+
+			res, err := p.SelectWith(doc, tc.opts...)
+			r.NoError(err)
+			a.Equal(tc.exp, res)
+
+			loc, err := p.SelectLocatedWith(doc, tc.opts...)
+			r.NoError(err)
+			paths := []string{}
+			for path := range loc.Paths() {
+				paths = append(paths, path.String())
+			}
+			a.Equal(tc.paths, paths)
+		})
+	}
+}
+
+func TestWithTransform(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	doc := map[string]any{
+		"users": []any{
+			map[string]any{"name": "alice", "key": "sk-123"},
+			map[string]any{"name": "bob", "key": "pk-456"},
+		},
+	}
+
+	// Masks secret strings, including those in objects.
+	var mask func(node any) any
+	mask = func(node any) any {
+		switch val := node.(type) {
+		case string:
+			if strings.HasPrefix(val, "sk-") {
+				return "***"
+			}
+		case map[string]any:
+			masked := make(map[string]any, len(val))
+			for k, v := range val {
+				masked[k] = mask(v)
+			}
+			return masked
+		}
+		return node
+	}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  []any
+	}{
+		{
+			name: "strings",
+			path: `$.users[*].key`,
+			exp:  []any{"***", "pk-456"},
+		},
+		{
+			name: "objects",
+			path: `$.users[0]`,
+			exp:  []any{map[string]any{"name": "alice", "key": "***"}},
+		},
+		{
+			name: "filter_sees_original",
+			path: `$.users[?@.key == "sk-123"].key`,
+			exp:  []any{"***"},
+		},
+		{
+			name: "root",
+			path: `$`,
+			exp: []any{map[string]any{"users": []any{
+				map[string]any{"name": "alice", "key": "sk-123"},
+				map[string]any{"name": "bob", "key": "pk-456"},
+			}}},
+		},
+		{
+			name: "nothing",
+			path: `$.nonesuch`,
+			exp:  []any{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+			res, err := p.SelectWith(doc, WithTransform(mask))
+			r.NoError(err)
+			a.Equal(NodeList(tc.exp), res)
+
+			located, err := p.SelectLocatedWith(doc, WithTransform(mask), WithNodeInfo())
+			r.NoError(err)
+			a.ElementsMatch(tc.exp, slices.Collect(located.Nodes()))
+			for _, n := range located {
+				a.Equal(spec.InfoOf(n.Node), n.Info)
+			}
+		})
+	}
+
+	// The input is unchanged.
+	a.Equal("sk-123", doc["users"].([]any)[0].(map[string]any)["key"])
+}
+
+func TestWithDocumentOrder(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	doc := map[string]any{
+		"a": []any{"x", "y", "z"},
+		"b": map[string]any{"m": 1, "n": 2, "o": 3},
+		"c": []any{
+			[]any{1, 2, 3},
+			map[string]any{"k": []any{4, 5}},
+		},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		exp   []any
+		std   []any
+		paths []string
+	}{
+		{
+			name:  "indexes",
+			path:  `$.a[2,0,0]`,
+			exp:   []any{"x", "z"},
+			std:   []any{"z", "x", "x"},
+			paths: []string{"$['a'][0]", "$['a'][2]"},
+		},
+		{
+			name:  "negative_index",
+			path:  `$.a[-1,1]`,
+			exp:   []any{"y", "z"},
+			std:   []any{"z", "y"},
+			paths: []string{"$['a'][1]", "$['a'][2]"},
+		},
+		{
+			name:  "slice_and_index",
+			path:  `$.a[::-1,1]`,
+			exp:   []any{"x", "y", "z"},
+			std:   []any{"z", "y", "x", "y"},
+			paths: []string{"$['a'][0]", "$['a'][1]", "$['a'][2]"},
+		},
+		{
+			name:  "names",
+			path:  `$.b["o","m","o"]`,
+			exp:   []any{1, 3},
+			std:   []any{3, 1, 3},
+			paths: []string{"$['b']['m']", "$['b']['o']"},
+		},
+		{
+			name:  "filter_and_name",
+			path:  `$.b[?@ > 1,"m"]`,
+			exp:   []any{1, 2, 3},
+			paths: []string{"$['b']['m']", "$['b']['n']", "$['b']['o']"},
+		},
+		{
+			name:  "single_selector",
+			path:  `$.a[::-1]`,
+			exp:   []any{"z", "y", "x"},
+			std:   []any{"z", "y", "x"},
+			paths: []string{"$['a'][2]", "$['a'][1]", "$['a'][0]"},
+		},
+		{
+			name:  "descendant",
+			path:  `$.c..[1,0]`,
+			exp:   []any{[]any{1, 2, 3}, map[string]any{"k": []any{4, 5}}, 1, 2, 4, 5},
+			std:   []any{map[string]any{"k": []any{4, 5}}, []any{1, 2, 3}, 2, 1, 5, 4},
+			paths: []string{"$['c'][0]", "$['c'][1]", "$['c'][0][0]", "$['c'][0][1]", "$['c'][1]['k'][0]", "$['c'][1]['k'][1]"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+			if tc.std != nil {
+				a.Equal(NodeList(tc.std), p.Select(doc))
+			}
+
+			res, err := p.SelectWith(doc, WithDocumentOrder())
+			r.NoError(err)
+			a.Equal(NodeList(tc.exp), res)
+
+			located, err := p.SelectLocatedWith(doc, WithDocumentOrder())
+			r.NoError(err)
+			a.Equal(tc.exp, slices.Collect(located.Nodes()))
+			paths := []string{}
+			for path := range located.Paths() {
+				paths = append(paths, path.String())
+			}
+			a.Equal(tc.paths, paths)
+		})
+	}
+}
+
+func TestWithCaseInsensitiveNames(t *testing.T) {
+	t.Parallel()
+
+	doc := map[string]any{
+		"Store": map[string]any{
+			"BOOK": []any{
+				map[string]any{"Title": "Sayings", "price": 8},
+				map[string]any{"title": "Sword", "Price": 12},
+			},
+			"name": "exact",
+			"Name": "upper",
+			"NAME": "caps",
+		},
+		"limit": 10,
+	}
+	raw := json.RawMessage(`{"Store": {"Name": "first", "NAME": "second"}}`)
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		input any
+		exp   []any
+		paths []string
+	}{
+		{
+			name:  "lower",
+			path:  `$.store.book[0].title`,
+			input: doc,
+			exp:   []any{"Sayings"},
+			paths: []string{"$['Store']['BOOK'][0]['Title']"},
+		},
+		{
+			name:  "upper",
+			path:  `$.STORE["Book"][*].TITLE`,
+			input: doc,
+			exp:   []any{"Sayings", "Sword"},
+			paths: []string{"$['Store']['BOOK'][0]['Title']", "$['Store']['BOOK'][1]['title']"},
+		},
+		{
+			name:  "exact_match_wins",
+			path:  `$.Store.Name`,
+			input: doc,
+			exp:   []any{"upper"},
+			paths: []string{"$['Store']['Name']"},
+		},
+		{
+			name:  "lowest_map_name",
+			path:  `$.Store.nAmE`,
+			input: doc,
+			exp:   []any{"caps"},
+			paths: []string{"$['Store']['NAME']"},
+		},
+		{
+			name:  "filter",
+			path:  `$.store.book[?@.PRICE < $.LIMIT].TITLE`,
+			input: doc,
+			exp:   []any{"Sayings"},
+			paths: []string{"$['Store']['BOOK'][0]['Title']"},
+		},
+		{
+			name:  "descendant",
+			path:  `$..TITLE`,
+			input: doc,
+			exp:   []any{"Sayings", "Sword"},
+			paths: []string{"$['Store']['BOOK'][0]['Title']", "$['Store']['BOOK'][1]['title']"},
+		},
+		{
+			name:  "no_match",
+			path:  `$.store.nope`,
+			input: doc,
+			exp:   []any{},
+			paths: []string{},
+		},
+		{
+			name:  "raw_document_order",
+			path:  `$.store.name`,
+			input: raw,
+			exp:   []any{"first"},
+			paths: []string{"$['Store']['Name']"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if _, ok := tc.input.(json.RawMessage); ok {
+				skipRaw(t)
+			}
+			a := assert.New(t)
+			r := require.New(t)
+			p := MustParse(tc.path)
+
+			res, err := p.SelectWith(tc.input, WithCaseInsensitiveNames())
+			r.NoError(err)
+			a.ElementsMatch(tc.exp, res)
+
+			located, err := p.SelectLocatedWith(tc.input, WithCaseInsensitiveNames(), WithSingularCache())
+			r.NoError(err)
+			a.ElementsMatch(tc.exp, slices.Collect(located.Nodes()))
+			paths := []string{}
+			for path := range located.Paths() {
+				paths = append(paths, path.String())
+			}
+			a.ElementsMatch(tc.paths, paths)
+		})
+	}
+
+	// Names match exactly by default.
+	assert.Empty(t, MustParse(`$.store`).Select(doc))
+}
+
+func TestWithStringCollation(t *testing.T) {
+	t.Parallel()
+
+	// nfc composes the only decomposed character in the tests.
+	nfc := func(s string) string { return strings.ReplaceAll(s, "e\u0301", "\u00e9") }
+	doc := []any{
+		map[string]any{"name": "cafe\u0301", "kind": "Drink"},
+		map[string]any{"name": "Caf\u00e9", "kind": "drink"},
+		map[string]any{"name": "tea", "kind": "DRINK"},
+		map[string]any{"name": "bread", "kind": "food"},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		keys  []spec.Collation
+		exp   []any
+		exact []any
+	}{
+		{
+			name:  "nfc",
+			path:  `$[?@.name == "caf\u00e9"].kind`,
+			keys:  []spec.Collation{nfc},
+			exp:   []any{"Drink"},
+			exact: []any{},
+		},
+		{
+			name:  "fold",
+			path:  `$[?@.kind == "drink"].name`,
+			keys:  []spec.Collation{spec.FoldCase},
+			exp:   []any{"cafe\u0301", "Caf\u00e9", "tea"},
+			exact: []any{"Caf\u00e9"},
+		},
+		{
+			name:  "nfc_and_fold",
+			path:  `$[?@.name == "CAF\u00c9"].kind`,
+			keys:  []spec.Collation{nfc, spec.FoldCase},
+			exp:   []any{"Drink", "drink"},
+			exact: []any{},
+		},
+		{
+			name:  "order",
+			path:  `$[?@.kind < "E"].name`,
+			keys:  []spec.Collation{spec.FoldCase},
+			exp:   []any{"cafe\u0301", "Caf\u00e9", "tea"},
+			exact: []any{"cafe\u0301", "tea"},
+		},
+		{
+			name:  "no_keys",
+			path:  `$[?@.kind == "drink"].name`,
+			exp:   []any{"Caf\u00e9"},
+			exact: []any{"Caf\u00e9"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+			p := MustParse(tc.path)
+
+			res, err := p.SelectWith(doc, WithStringCollation(tc.keys...))
+			r.NoError(err)
+			a.Equal(NodeList(tc.exp), res)
+			a.Equal(NodeList(tc.exact), p.Select(doc))
+
+			located, err := p.SelectLocatedWith(doc, WithStringCollation(tc.keys...), WithSingularCache())
+			r.NoError(err)
+			a.Equal(tc.exp, slices.Collect(located.Nodes()))
+		})
+	}
+
+	t.Run("membership", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		r := require.New(t)
+		parser := NewParser(WithMembership())
+		p, err := parser.Parse(`$[?@.kind in ["FOOD", "fruit"]].name`)
+		r.NoError(err)
+		res, err := p.SelectWith(doc, WithStringCollation(spec.FoldCase))
+		r.NoError(err)
+		a.Equal(NodeList{"bread"}, res)
+		a.Empty(p.Select(doc))
+	})
+}
+
+func TestWithSortedNames(t *testing.T) {
+	t.Parallel()
+	skipRaw(t)
+	a := assert.New(t)
+	r := require.New(t)
+
+	doc := map[string]any{
+		"d": map[string]any{"z": 1, "y": 2, "x": 3, "w": 4, "v": 5},
+		"b": []any{
+			map[string]any{"q": 1, "p": 2},
+			map[string]any{"o": 3, "n": 4},
+		},
+		"c": json.RawMessage(`{"t": 1, "s": 2, "u": 3}`),
+		"a": 0,
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		exp   []any
+		paths []string
+	}{
+		{
+			name:  "wildcard",
+			path:  `$.d.*`,
+			exp:   []any{5, 4, 3, 2, 1},
+			paths: []string{"$['d']['v']", "$['d']['w']", "$['d']['x']", "$['d']['y']", "$['d']['z']"},
+		},
+		{
+			name:  "filter",
+			path:  `$.d[?@ > 2]`,
+			exp:   []any{5, 4, 3},
+			paths: []string{"$['d']['v']", "$['d']['w']", "$['d']['x']"},
+		},
+		{
+			name:  "name",
+			path:  `$.d.x`,
+			exp:   []any{3},
+			paths: []string{"$['d']['x']"},
+		},
+		{
+			name: "root_wildcard",
+			path: `$[?@ != 0].*`,
+			exp:  []any{doc["b"].([]any)[0], doc["b"].([]any)[1], 1.0, 2.0, 3.0, 5, 4, 3, 2, 1},
+			paths: []string{
+				"$['b'][0]", "$['b'][1]",
+				"$['c']['t']", "$['c']['s']", "$['c']['u']",
+				"$['d']['v']", "$['d']['w']", "$['d']['x']", "$['d']['y']", "$['d']['z']",
+			},
+		},
+		{
+			name:  "descendants",
+			path:  `$.b..*`,
+			exp:   []any{doc["b"].([]any)[0], doc["b"].([]any)[1], 2, 1, 4, 3},
+			paths: []string{"$['b'][0]", "$['b'][1]", "$['b'][0]['p']", "$['b'][0]['q']", "$['b'][1]['n']", "$['b'][1]['o']"},
+		},
+		{
+			name:  "raw_message_order",
+			path:  `$.c.*`,
+			exp:   []any{float64(1), float64(2), float64(3)},
+			paths: []string{"$['c']['t']", "$['c']['s']", "$['c']['u']"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+
+			// Repeat to guard against map order happening to sort.
+			for range 5 {
+				res, err := p.SelectWith(doc, WithSortedNames())
+				r.NoError(err)
+				a.Equal(NodeList(tc.exp), res)
+
+				located, err := p.SelectLocatedWith(doc, WithSortedNames())
+				r.NoError(err)
+				a.Equal(tc.exp, slices.Collect(located.Nodes()))
+				paths := []string{}
+				for path := range located.Paths() {
+					paths = append(paths, path.String())
+				}
+				a.Equal(tc.paths, paths)
+			}
+		})
+	}
+}
+
+func TestWithDescendantOrder(t *testing.T) {
+	t.Parallel()
+	skipRaw(t)
+	a := assert.New(t)
+	r := require.New(t)
+
+	doc := []any{
+		[]any{1, []any{2, []any{3}}, []any{4}},
+		[]any{
+			map[string]any{"x": map[string]any{"y": map[string]any{"kind": "deep", "id": 1}}},
+			map[string]any{"z": map[string]any{"kind": "shallow", "id": 2}},
+		},
+		json.RawMessage(`{"a": {"b": {"c": 1}}, "d": {"e": 2}}`),
+	}
+
+	for _, tc := range []struct {
+		name     string
+		path     string
+		preOrder []string
+		breadth  []string
+		sorted   bool
+	}{
+		{
+			name: "wildcard",
+			path: `$[0]..*`,
+			preOrder: []string{
+				"$[0][0]", "$[0][1]", "$[0][2]",
+				"$[0][1][0]", "$[0][1][1]", "$[0][1][1][0]",
+				"$[0][2][0]",
+			},
+			breadth: []string{
+				"$[0][0]", "$[0][1]", "$[0][2]",
+				"$[0][1][0]", "$[0][1][1]", "$[0][2][0]",
+				"$[0][1][1][0]",
+			},
+		},
+		{
+			name:     "filter",
+			path:     `$[1]..[?@.kind]`,
+			preOrder: []string{"$[1][0]['x']['y']", "$[1][1]['z']"},
+			breadth:  []string{"$[1][1]['z']", "$[1][0]['x']['y']"},
+		},
+		{
+			name:     "name",
+			path:     `$..id`,
+			preOrder: []string{"$[1][0]['x']['y']['id']", "$[1][1]['z']['id']"},
+			breadth:  []string{"$[1][1]['z']['id']", "$[1][0]['x']['y']['id']"},
+		},
+		{
+			name:     "raw_message",
+			path:     `$[2]..*`,
+			preOrder: []string{"$[2]['a']", "$[2]['d']", "$[2]['a']['b']", "$[2]['a']['b']['c']", "$[2]['d']['e']"},
+			breadth:  []string{"$[2]['a']", "$[2]['d']", "$[2]['a']['b']", "$[2]['d']['e']", "$[2]['a']['b']['c']"},
+		},
+		{
+			name:   "sorted_names",
+			path:   `$[1][1]..*`,
+			sorted: true,
+			preOrder: []string{
+				"$[1][1]['z']", "$[1][1]['z']['id']", "$[1][1]['z']['kind']",
+			},
+			breadth: []string{
+				"$[1][1]['z']", "$[1][1]['z']['id']", "$[1][1]['z']['kind']",
+			},
+		},
+		{
+			name: "nested_descendants",
+			path: `$[0]..[1]..*`,
+			preOrder: []string{
+				"$[0][1][0]", "$[0][1][1]", "$[0][1][1][0]", "$[0][1][1][0]",
+			},
+			breadth: []string{
+				"$[0][1][0]", "$[0][1][1]", "$[0][1][1][0]", "$[0][1][1][0]",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+
+			for _, order := range []struct {
+				opt SelectOption
+				exp []string
+			}{
+				{WithDescendantOrder(DescendantPreOrder), tc.preOrder},
+				{WithDescendantOrder(DescendantBreadthFirst), tc.breadth},
+			} {
+				opts := []SelectOption{order.opt}
+				if tc.sorted {
+					opts = append(opts, WithSortedNames())
+				}
+
+				located, err := p.SelectLocatedWith(doc, opts...)
+				r.NoError(err)
+				paths := []string{}
+				for path := range located.Paths() {
+					paths = append(paths, path.String())
+				}
+				a.Equal(order.exp, paths)
+
+				// SelectWith selects the same values in the same order.
+				res, err := p.SelectWith(doc, opts...)
+				r.NoError(err)
+				a.Equal(slices.Collect(located.Nodes()), []any(res))
+			}
+		})
+	}
+
+	// Pre-order is the default and selects nodes as Select does. Query only
+	// arrays, because Go maps select members in no particular order.
+	p := MustParse(`$[0]..*`)
+	res, err := p.SelectWith(doc)
+	r.NoError(err)
+	a.Equal(p.Select(doc), res)
+}
+
+func TestWithDescendantDepthLimit(t *testing.T) {
+	t.Parallel()
+	skipRaw(t)
+	a := assert.New(t)
+	r := require.New(t)
+
+	doc := map[string]any{
+		"name": "a",
+		"kids": []any{
+			map[string]any{"name": "b", "kids": []any{
+				map[string]any{"name": "c"},
+			}},
+		},
+		"raw": json.RawMessage(`{"name": "d", "more": {"name": "e"}}`),
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		depth int
+		exp   []string
+	}{
+		{
+			name:  "no_limit",
+			path:  `$..name`,
+			depth: 0,
+			exp: []string{
+				"$['kids'][0]['kids'][0]['name']", "$['kids'][0]['name']", "$['name']",
+				"$['raw']['more']['name']", "$['raw']['name']",
+			},
+		},
+		{
+			name:  "negative",
+			path:  `$..name`,
+			depth: -1,
+			exp: []string{
+				"$['kids'][0]['kids'][0]['name']", "$['kids'][0]['name']", "$['name']",
+				"$['raw']['more']['name']", "$['raw']['name']",
+			},
+		},
+		{
+			name:  "one",
+			path:  `$..name`,
+			depth: 1,
+			exp:   []string{"$['name']"},
+		},
+		{
+			name:  "two",
+			path:  `$..name`,
+			depth: 2,
+			exp:   []string{"$['name']", "$['raw']['name']"},
+		},
+		{
+			name:  "three",
+			path:  `$..name`,
+			depth: 3,
+			exp:   []string{"$['kids'][0]['name']", "$['name']", "$['raw']['more']['name']", "$['raw']['name']"},
+		},
+		{
+			name:  "wildcard",
+			path:  `$.kids..*`,
+			depth: 2,
+			exp:   []string{"$['kids'][0]", "$['kids'][0]['kids']", "$['kids'][0]['name']"},
+		},
+		{
+			name:  "per_segment",
+			path:  `$..kids..name`,
+			depth: 1,
+			exp:   []string{},
+		},
+		{
+			name:  "each_segment",
+			path:  `$..kids[0]..name`,
+			depth: 2,
+			exp:   []string{"$['kids'][0]['name']"},
+		},
+		{
+			name:  "filter_not_limited",
+			path:  `$..[?count(@..name) == 2]`,
+			depth: 1,
+			exp:   []string{"$['kids']", "$['raw']"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+
+			for _, order := range []DescendantOrder{DescendantPreOrder, DescendantBreadthFirst} {
+				opts := []SelectOption{WithDescendantDepthLimit(tc.depth), WithDescendantOrder(order)}
+				located, err := p.SelectLocatedWith(doc, opts...)
+				r.NoError(err)
+				located.Sort()
+				paths := []string{}
+				for path := range located.Paths() {
+					paths = append(paths, path.String())
+				}
+				a.Equal(tc.exp, paths)
+
+				res, err := p.SelectWith(doc, opts...)
+				r.NoError(err)
+				a.Len(res, len(tc.exp))
+			}
+		})
+	}
+}
+
+func TestSizeOf(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name  string
+		val   any
+		limit int
+		exp   int
+	}{
+		{"nil", nil, 100, ifaceSize},
+		{"bool", true, 100, ifaceSize},
+		{"number", 42.1, 100, ifaceSize},
+		{"string", "hello", 100, ifaceSize + 5},
+		{"empty_array", []any{}, 100, ifaceSize + sliceSize},
+		{"array", []any{1, "x"}, 100, ifaceSize + sliceSize + ifaceSize + ifaceSize + 1},
+		{"empty_object", map[string]any{}, 100, ifaceSize + mapSize},
+		{"object", map[string]any{"ab": true}, 1000, ifaceSize + mapSize + entrySize + 2 + ifaceSize},
+		{"nested", []any{[]any{"abc"}}, 1000, 2*(ifaceSize+sliceSize) + ifaceSize + 3},
+		{"array_limit", []any{1, 2, 3, 4, 5}, 1, ifaceSize + sliceSize},
+		{"object_limit", map[string]any{"a": 1, "b": 2}, 1, ifaceSize + mapSize},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(tc.exp, sizeOf(tc.val, tc.limit))
+		})
+	}
+}
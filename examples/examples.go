@@ -0,0 +1,91 @@
+// Package examples provides a small corpus of canonical JSON documents for
+// use in documentation, tests, benchmarks, and demos. Sharing the corpus
+// ensures that every surface demonstrates JSONPath queries against identical
+// data.
+//
+// The documents are:
+//
+//   - [Bookstore]: the bookstore example from [RFC 9535 Section 1.5]
+//   - [Pod]: a Kubernetes Pod manifest
+//   - [GeoJSON]: a GeoJSON FeatureCollection as defined by [RFC 7946]
+//
+// [RFC 9535 Section 1.5]: https://www.rfc-editor.org/rfc/rfc9535#section-1.5
+// [RFC 7946]: https://www.rfc-editor.org/rfc/rfc7946.html
+package examples
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+//go:embed *.json
+var corpus embed.FS
+
+// ErrUnknown errors are returned by [JSON] and [Document] for unknown
+// document names.
+var ErrUnknown = errors.New("examples: unknown document")
+
+// Names returns the sorted names of the documents in the corpus, suitable
+// for passing to [JSON] and [Document].
+func Names() []string {
+	entries, _ := corpus.ReadDir(".")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// JSON returns the raw JSON source of the document called name. Returns an
+// error if no such document exists.
+func JSON(name string) ([]byte, error) {
+	src, err := corpus.ReadFile(name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+	return src, nil
+}
+
+// Document returns a freshly unmarshaled copy of the document called name.
+// Returns an error if no such document exists.
+func Document(name string) (any, error) {
+	src, err := JSON(name)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(src, &doc); err != nil {
+		return nil, fmt.Errorf("examples: %w", err)
+	}
+	return doc, nil
+}
+
+// mustDocument returns the document called name and panics if it does not
+// exist or fails to unmarshal.
+func mustDocument(name string) any {
+	doc, err := Document(name)
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+// Bookstore returns a freshly unmarshaled copy of the bookstore example from
+// [RFC 9535 Section 1.5].
+//
+// [RFC 9535 Section 1.5]: https://www.rfc-editor.org/rfc/rfc9535#section-1.5
+func Bookstore() any { return mustDocument("bookstore") }
+
+// Pod returns a freshly unmarshaled copy of a Kubernetes Pod manifest with
+// two containers.
+func Pod() any { return mustDocument("pod") }
+
+// GeoJSON returns a freshly unmarshaled copy of a GeoJSON FeatureCollection
+// containing a Point, a LineString, and a Polygon.
+func GeoJSON() any { return mustDocument("geojson") }
@@ -0,0 +1,59 @@
+package examples
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorpus(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	a.Equal([]string{"bookstore", "geojson", "pod"}, Names())
+
+	for _, tc := range []struct {
+		name string
+		fn   func() any
+	}{
+		{"bookstore", Bookstore},
+		{"geojson", GeoJSON},
+		{"pod", Pod},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			src, err := JSON(tc.name)
+			r.NoError(err)
+			a.True(json.Valid(src))
+
+			doc, err := Document(tc.name)
+			r.NoError(err)
+			a.IsType(map[string]any{}, doc)
+			a.Equal(doc, tc.fn())
+
+			// Each call returns a fresh copy.
+			obj, _ := doc.(map[string]any)
+			obj["x"] = true
+			a.NotEqual(doc, tc.fn())
+		})
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	src, err := JSON("nope")
+	a.Nil(src)
+	a.ErrorIs(err, ErrUnknown)
+	a.EqualError(err, `examples: unknown document "nope"`)
+
+	doc, err := Document("nope")
+	a.Nil(doc)
+	a.EqualError(err, `examples: unknown document "nope"`)
+
+	a.PanicsWithError(`examples: unknown document "nope"`, func() { mustDocument("nope") })
+}
@@ -0,0 +1,182 @@
+package jsonpath
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// FingerprintResults returns a stable 64-bit hash of nodes, such as the
+// results of [Path.Select], so that change-detection pipelines can cheaply
+// determine whether the output of a query changed between versions of a
+// document without persisting the full output. The fingerprint depends on
+// the order of nodes; use [FingerprintResultsUnordered] to ignore order.
+//
+// Nodes are hashed according to their JSON semantics: object members are
+// hashed in key order, and all numeric types hash by their float64 value,
+// so that 1 and 1.0 produce the same fingerprint. [json.RawMessage] values
+// hash as the values they decode to, regardless of formatting, pointers as
+// the values they point to, and other Go values as their JSON
+// representations, as described for [WithStructSupport], where available.
+// Fingerprints are stable across processes and releases, but are not
+// cryptographically secure.
+func FingerprintResults(nodes []any) uint64 {
+	h := fnv.New64a()
+	writeTag(h, 'l', len(nodes))
+	for _, n := range nodes {
+		hashValue(h, n)
+	}
+	return h.Sum64()
+}
+
+// FingerprintResultsUnordered returns a stable 64-bit hash of nodes that
+// ignores their order, so that queries such as `$.*` that select object
+// members in arbitrary order produce consistent fingerprints. Duplicate
+// nodes still affect the fingerprint. See [FingerprintResults] for details.
+func FingerprintResultsUnordered(nodes []any) uint64 {
+	var sum uint64
+	h := fnv.New64a()
+	for _, n := range nodes {
+		h.Reset()
+		hashValue(h, n)
+		sum += mix(h.Sum64())
+	}
+
+	h.Reset()
+	writeTag(h, 'u', len(nodes))
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], sum)
+	_, _ = h.Write(buf[:])
+	return h.Sum64()
+}
+
+// mix scrambles x with the SplitMix64 finalizer so that the sum of mixed
+// hashes distributes well.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// writeTag writes tag and size to h.
+func writeTag(h hash.Hash64, tag byte, size int) {
+	var buf [binary.MaxVarintLen64 + 1]byte
+	buf[0] = tag
+	n := binary.PutUvarint(buf[1:], uint64(size)) //nolint:gosec // size never negative
+	_, _ = h.Write(buf[:n+1])
+}
+
+// hashValue writes a canonical encoding of val to h.
+func hashValue(h hash.Hash64, val any) {
+	switch v := val.(type) {
+	case nil:
+		_, _ = h.Write([]byte{'n'})
+	case bool:
+		if v {
+			_, _ = h.Write([]byte{'t'})
+		} else {
+			_, _ = h.Write([]byte{'f'})
+		}
+	case string:
+		writeTag(h, 's', len(v))
+		_, _ = h.Write([]byte(v))
+	case []any:
+		writeTag(h, 'a', len(v))
+		for _, e := range v {
+			hashValue(h, e)
+		}
+	case map[string]any:
+		writeTag(h, 'o', len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			writeTag(h, 's', len(k))
+			_, _ = h.Write([]byte(k))
+			hashValue(h, v[k])
+		}
+	case spec.OrderedMap:
+		hashValue(h, maps.Collect(v.All()))
+	case spec.Array:
+		writeTag(h, 'a', v.Len())
+		for i := range v.Len() {
+			hashValue(h, v.Index(i))
+		}
+	case spec.Adapter:
+		hashValue(h, v.Adapt())
+	case json.RawMessage:
+		var dec any
+		if err := json.Unmarshal(v, &dec); err != nil {
+			writeTag(h, 'r', len(v))
+			_, _ = h.Write(v)
+			return
+		}
+		hashValue(h, dec)
+	case json.Number:
+		if f, ok := spec.ToFloat64(v); ok {
+			hashNumber(h, f)
+		} else {
+			writeTag(h, 'N', len(v))
+			_, _ = h.Write([]byte(v))
+		}
+	default:
+		if f, ok := spec.ToFloat64(v); ok {
+			hashNumber(h, f)
+			return
+		}
+		if elem, ok := deref(v); ok {
+			hashValue(h, elem)
+			return
+		}
+		if conv, err := toJSONValue(v); err == nil {
+			hashValue(h, conv)
+			return
+		}
+		str := fmt.Sprintf("%T:%v", v, v)
+		writeTag(h, 'x', len(str))
+		_, _ = h.Write([]byte(str))
+	}
+}
+
+// maxPointerChain is the maximum number of pointers deref follows, to
+// bound the pointer cycles possible with recursive pointer types.
+const maxPointerChain = 1000
+
+// deref returns the value to which val points and true if val is a pointer,
+// or nil and true if it's a nil pointer. Returns false if val is not a
+// pointer or starts a chain of more than maxPointerChain pointers.
+func deref(val any) (any, bool) {
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Pointer {
+		return nil, false
+	}
+	for range maxPointerChain {
+		if rv.IsNil() {
+			return nil, true
+		}
+		rv = rv.Elem()
+		if rv.Kind() != reflect.Pointer {
+			return rv.Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// hashNumber writes a canonical encoding of f to h.
+func hashNumber(h hash.Hash64, f float64) {
+	if f == 0 {
+		f = 0 // Normalize -0.
+	}
+	var buf [9]byte
+	buf[0] = 'd'
+	binary.BigEndian.PutUint64(buf[1:], math.Float64bits(f))
+	_, _ = h.Write(buf[:])
+}
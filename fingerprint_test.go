@@ -0,0 +1,181 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath/examples"
+)
+
+func TestFingerprintResults(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name  string
+		left  []any
+		right []any
+		same  bool
+		uniq  bool // same when unordered
+	}{
+		{
+			name:  "empty",
+			left:  []any{},
+			right: nil,
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "scalars",
+			left:  []any{nil, true, false, "hi", 42.0},
+			right: []any{nil, true, false, "hi", 42.0},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "numeric_types",
+			left:  []any{1, int8(2), int16(3), int32(4), int64(5), float32(6)},
+			right: []any{1.0, uint(2), uint8(3), uint16(4), uint32(5), uint64(6)},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "ordered_object",
+			left:  []any{orderedObject("b", 1, "a", []any{2})},
+			right: []any{map[string]any{"a": []any{2}, "b": 1}},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "json_number",
+			left:  []any{json.Number("42"), json.Number("1e400")},
+			right: []any{42, json.Number("1e400")},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "negative_zero",
+			left:  []any{0.0},
+			right: []any{-0.0 * 1},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "order",
+			left:  []any{"a", "b"},
+			right: []any{"b", "a"},
+			uniq:  true,
+		},
+		{
+			name:  "objects_in_key_order",
+			left:  []any{map[string]any{"a": 1, "b": []any{2, "x"}}},
+			right: []any{map[string]any{"b": []any{2, "x"}, "a": 1}},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "different_values",
+			left:  []any{"a"},
+			right: []any{"b"},
+		},
+		{
+			name:  "string_vs_number",
+			left:  []any{"1"},
+			right: []any{1},
+		},
+		{
+			name:  "nesting",
+			left:  []any{[]any{"a", "b"}},
+			right: []any{[]any{"a"}, []any{"b"}},
+		},
+		{
+			name:  "concatenation",
+			left:  []any{"ab", "c"},
+			right: []any{"a", "bc"},
+		},
+		{
+			name:  "duplicates",
+			left:  []any{"a", "a"},
+			right: []any{"a"},
+		},
+		{
+			name:  "duplicates_pair",
+			left:  []any{"a", "a", "b", "b"},
+			right: []any{"c", "c", "d", "d"},
+		},
+		{
+			name:  "raw_message_whitespace",
+			left:  []any{json.RawMessage(`{"a":[1,2],"b":"x"}`)},
+			right: []any{json.RawMessage("{\n  \"b\": \"x\",\n  \"a\": [ 1, 2.0 ]\n}")},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "raw_message_decoded",
+			left:  []any{json.RawMessage(`{"a": [1, 2], "b": null}`), json.RawMessage(`"hi"`)},
+			right: []any{map[string]any{"a": []any{1, 2}, "b": nil}, "hi"},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "raw_message_different",
+			left:  []any{json.RawMessage(`{"a": 1}`)},
+			right: []any{json.RawMessage(`{"a": 2}`)},
+		},
+		{
+			name:  "raw_message_invalid",
+			left:  []any{json.RawMessage(`{"a": 1`)},
+			right: []any{json.RawMessage(`{"a": 2`)},
+		},
+		{
+			name:  "pointers",
+			left:  []any{ptr("a"), ptr(ptr(1)), (*string)(nil)},
+			right: []any{"a", 1, nil},
+			same:  true,
+			uniq:  true,
+		},
+		{
+			name:  "different_pointers",
+			left:  []any{ptr("a")},
+			right: []any{ptr("b")},
+		},
+		{
+			name:  "other_types",
+			left:  []any{struct{ X int }{1}},
+			right: []any{struct{ X int }{2}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if tc.same {
+				a.Equal(FingerprintResults(tc.left), FingerprintResults(tc.right))
+			} else {
+				a.NotEqual(FingerprintResults(tc.left), FingerprintResults(tc.right))
+			}
+			if tc.uniq {
+				a.Equal(FingerprintResultsUnordered(tc.left), FingerprintResultsUnordered(tc.right))
+			} else {
+				a.NotEqual(FingerprintResultsUnordered(tc.left), FingerprintResultsUnordered(tc.right))
+			}
+			a.NotEqual(FingerprintResults(tc.left), FingerprintResultsUnordered(tc.left))
+		})
+	}
+}
+
+func TestFingerprintStable(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Fingerprints must not change between releases.
+	a.Equal(uint64(0x08ace007b55361b1), FingerprintResults([]any{}))
+	a.Equal(uint64(0x727aa7a89de08071), FingerprintResults([]any{"hi", 42, nil}))
+
+	// Object member order doesn't matter.
+	doc := examples.Bookstore()
+	p := MustParse(`$.store.*`)
+	exp := FingerprintResultsUnordered(p.Select(doc))
+	for range 10 {
+		a.Equal(exp, FingerprintResultsUnordered(p.Select(doc)))
+	}
+}
@@ -0,0 +1,294 @@
+// Package funcs provides a curated set of commonly requested JSONPath
+// function extensions that RFC 9535 does not define. Register them all with
+// [RegisterAll], or select individual functions from [Functions]:
+//
+//	parser := jsonpath.NewParser()
+//	if err := funcs.RegisterAll(parser.Registry()); err != nil {
+//		return err
+//	}
+//
+// The functions are:
+//
+//   - min(nodes), max(nodes): the smallest or largest number in nodes
+//   - sum(nodes), avg(nodes): the sum or mean of the numbers in nodes
+//   - keys(value): an array of the member names of an object, sorted
+//   - values(value): an array of the member values of an object, sorted
+//     by name
+//   - type(value): the JSON type of value: "null", "boolean", "number",
+//     "string", "array", or "object"
+//   - starts_with(value, prefix), ends_with(value, suffix): true if value
+//     is a string that starts or ends with the string prefix or suffix
+//   - contains(value, item): true if value is a string that contains the
+//     string item, or an array that contains a value equal to item, as
+//     compared by the == operator
+//
+// The aggregate functions min(), max(), sum(), and avg() take node lists,
+// such as @.prices[*], and return nothing if the list is empty or contains
+// a value other than a number. The other functions return nothing or false
+// for arguments of other types.
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+// Functions returns the function extensions defined by this package, sorted
+// by name.
+func Functions() []*registry.Function {
+	return []*registry.Function{
+		registry.NewFunction("avg", spec.FuncValue, checkNodesArg, avgFunc),
+		registry.NewFunction("contains", spec.FuncLogical, checkValueArgs(2), containsFunc),
+		registry.NewFunction("ends_with", spec.FuncLogical, checkValueArgs(2), stringTest(strings.HasSuffix)),
+		registry.NewFunction("keys", spec.FuncValue, checkValueArgs(1), keysFunc),
+		registry.NewFunction("max", spec.FuncValue, checkNodesArg, extremeFunc(1)),
+		registry.NewFunction("min", spec.FuncValue, checkNodesArg, extremeFunc(-1)),
+		registry.NewFunction("starts_with", spec.FuncLogical, checkValueArgs(2), stringTest(strings.HasPrefix)),
+		registry.NewFunction("sum", spec.FuncValue, checkNodesArg, sumFunc),
+		registry.NewFunction("type", spec.FuncValue, checkValueArgs(1), typeFunc),
+		registry.NewFunction("values", spec.FuncValue, checkValueArgs(1), valuesFunc),
+	}
+}
+
+// RegisterAll registers all of the function extensions returned by
+// [Functions] in reg. Returns a [registry.ErrRegister] error if reg already
+// contains a function with the same name as one of them.
+func RegisterAll(reg *registry.Registry) error {
+	for _, fn := range Functions() {
+		if err := reg.RegisterFunction(fn); err != nil {
+			return fmt.Errorf("funcs: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkNodesArg returns an error unless args contains a single expression
+// convertible to [spec.PathNodes].
+func checkNodesArg(args []spec.FunctionExprArg) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected 1 argument but found %v", len(args))
+	}
+	if !args[0].ResultType().ConvertsTo(spec.PathNodes) {
+		return errors.New("cannot convert argument to PathNodes")
+	}
+	return nil
+}
+
+// checkValueArgs returns a validator that returns an error unless args
+// contains num expressions convertible to [spec.PathValue].
+func checkValueArgs(num int) registry.Validator {
+	return func(args []spec.FunctionExprArg) error {
+		if len(args) != num {
+			if num == 1 {
+				return fmt.Errorf("expected 1 argument but found %v", len(args))
+			}
+			return fmt.Errorf("expected %v arguments but found %v", num, len(args))
+		}
+		for i, arg := range args {
+			if !arg.ResultType().ConvertsTo(spec.PathValue) {
+				if num == 1 {
+					return errors.New("cannot convert argument to ValueType")
+				}
+				return fmt.Errorf("cannot convert argument %v to ValueType", i+1)
+			}
+		}
+		return nil
+	}
+}
+
+// numbers returns the nodes in jv and the numbers they contain. Returns
+// false if jv is not convertible to [spec.NodesType], if there are no nodes,
+// or if any node is not a number.
+func numbers(jv spec.JSONPathValue) (spec.NodesType, []float64, bool) {
+	nodes, err := spec.NodesFromErr(jv)
+	if err != nil || len(nodes) == 0 {
+		return nil, nil, false
+	}
+	nums := make([]float64, len(nodes))
+	for i, node := range nodes {
+		num, ok := spec.ToFloat64(node)
+		if !ok {
+			return nil, nil, false
+		}
+		nums[i] = num
+	}
+	return nodes, nums, true
+}
+
+// extremeFunc returns the evaluator for min() when sign is -1 and for max()
+// when sign is 1. The evaluator returns the smallest or largest number in
+// the nodes in jv[0] as originally typed.
+func extremeFunc(sign int) registry.Evaluator {
+	return func(jv []spec.JSONPathValue) spec.JSONPathValue {
+		nodes, nums, ok := numbers(jv[0])
+		if !ok {
+			return nil
+		}
+		idx := 0
+		for i, num := range nums {
+			if (sign < 0 && num < nums[idx]) || (sign > 0 && num > nums[idx]) {
+				idx = i
+			}
+		}
+		return spec.Value(nodes[idx])
+	}
+}
+
+// sumFunc returns the sum of the numbers in the nodes in jv[0] as a
+// float64.
+func sumFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
+	_, nums, ok := numbers(jv[0])
+	if !ok {
+		return nil
+	}
+	sum := 0.0
+	for _, num := range nums {
+		sum += num
+	}
+	return spec.Value(sum)
+}
+
+// avgFunc returns the mean of the numbers in the nodes in jv[0] as a
+// float64.
+func avgFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
+	_, nums, ok := numbers(jv[0])
+	if !ok {
+		return nil
+	}
+	sum := 0.0
+	for _, num := range nums {
+		sum += num
+	}
+	return spec.Value(sum / float64(len(nums)))
+}
+
+// object returns the object in jv, or false if jv does not contain an
+// object.
+func object(jv spec.JSONPathValue) (map[string]any, bool) {
+	if v, err := spec.ValueFromErr(jv); err == nil && v != nil {
+		switch obj := v.Value().(type) {
+		case map[string]any:
+			return obj, true
+		case spec.OrderedMap:
+			return maps.Collect(obj.All()), true
+		}
+	}
+	return nil, false
+}
+
+// keysFunc returns the member names of the object in jv[0] as a sorted
+// array.
+func keysFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
+	obj, ok := object(jv[0])
+	if !ok {
+		return nil
+	}
+	keys := make([]any, 0, len(obj))
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		keys = append(keys, key)
+	}
+	return spec.Value(keys)
+}
+
+// valuesFunc returns the member values of the object in jv[0] as an array
+// sorted by member name.
+func valuesFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
+	obj, ok := object(jv[0])
+	if !ok {
+		return nil
+	}
+	vals := make([]any, 0, len(obj))
+	for _, key := range slices.Sorted(maps.Keys(obj)) {
+		vals = append(vals, obj[key])
+	}
+	return spec.Value(vals)
+}
+
+// typeFunc returns the name of the JSON type of the value in jv[0].
+func typeFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
+	v, err := spec.ValueFromErr(jv[0])
+	if err != nil || v == nil {
+		return nil
+	}
+	switch val := v.Value().(type) {
+	case nil:
+		return spec.Value("null")
+	case bool:
+		return spec.Value("boolean")
+	case string:
+		return spec.Value("string")
+	case []any, spec.Array:
+		return spec.Value("array")
+	case map[string]any, spec.OrderedMap:
+		return spec.Value("object")
+	default:
+		if _, ok := spec.ToFloat64(val); ok {
+			return spec.Value("number")
+		}
+		return nil
+	}
+}
+
+// stringTest returns an evaluator that returns the result of test for the
+// values in jv[0] and jv[1], or false if either is not a string.
+func stringTest(test func(str, sub string) bool) registry.Evaluator {
+	return func(jv []spec.JSONPathValue) spec.JSONPathValue {
+		str, ok := stringValue(jv[0])
+		if !ok {
+			return spec.LogicalFalse
+		}
+		sub, ok := stringValue(jv[1])
+		if !ok {
+			return spec.LogicalFalse
+		}
+		return spec.LogicalFrom(test(str, sub))
+	}
+}
+
+// stringValue returns the string in jv, or false if jv does not contain a
+// string.
+func stringValue(jv spec.JSONPathValue) (string, bool) {
+	if v, err := spec.ValueFromErr(jv); err == nil && v != nil {
+		str, ok := v.Value().(string)
+		return str, ok
+	}
+	return "", false
+}
+
+// containsFunc returns true if jv[0] is a string that contains the string
+// in jv[1], or an array that contains a value equal to jv[1] according to
+// [spec.EqualValues].
+func containsFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
+	v, err := spec.ValueFromErr(jv[0])
+	if err != nil || v == nil {
+		return spec.LogicalFalse
+	}
+	item, err := spec.ValueFromErr(jv[1])
+	if err != nil || item == nil {
+		return spec.LogicalFalse
+	}
+	switch val := v.Value().(type) {
+	case string:
+		sub, ok := item.Value().(string)
+		return spec.LogicalFrom(ok && strings.Contains(val, sub))
+	case []any:
+		return spec.LogicalFrom(slices.ContainsFunc(val, func(elem any) bool {
+			return spec.EqualValues(elem, item.Value())
+		}))
+	case spec.Array:
+		for i := range val.Len() {
+			if spec.EqualValues(val.Index(i), item.Value()) {
+				return spec.LogicalTrue
+			}
+		}
+		return spec.LogicalFalse
+	default:
+		return spec.LogicalFalse
+	}
+}
@@ -0,0 +1,27 @@
+package funcs_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/funcs"
+)
+
+// Register the extra functions in a parser and use them in a query.
+func Example() {
+	parser := jsonpath.NewParser()
+	if err := funcs.RegisterAll(parser.Registry()); err != nil {
+		log.Fatal(err)
+	}
+
+	orders := []any{
+		map[string]any{"id": "a1", "items": []any{5.0, 20.0}},
+		map[string]any{"id": "b2", "items": []any{15.0, 30.0}},
+		map[string]any{"id": "a3", "items": []any{50.0}},
+	}
+
+	p := parser.MustParse(`$[?starts_with(@.id, "a") && sum(@.items[*]) > 30].id`)
+	fmt.Printf("%v\n", p.Select(orders))
+	// Output: [a3]
+}
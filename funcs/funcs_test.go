@@ -0,0 +1,301 @@
+package funcs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/registry/registrytest"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestRegisterAll(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	names := []string{}
+	for _, fn := range Functions() {
+		names = append(names, fn.Name())
+	}
+	a.IsIncreasing(names)
+
+	reg := registry.NewEmpty()
+	r.NoError(RegisterAll(reg))
+	a.Equal(names, reg.Names())
+
+	reg = registry.New()
+	r.NoError(RegisterAll(reg))
+	a.Len(reg.Names(), len(names)+5)
+
+	err := RegisterAll(reg)
+	r.ErrorIs(err, registry.ErrRegister)
+	r.EqualError(err, "funcs: register: Register called twice for function avg")
+}
+
+// get returns the function named name from Functions.
+func get(t *testing.T, name string) *registry.Function {
+	t.Helper()
+	reg := registry.NewEmpty()
+	require.NoError(t, RegisterAll(reg))
+	fn := reg.Get(name)
+	require.NotNil(t, fn)
+	return fn
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	nodes := spec.FilterQuery(spec.Query(false, []*spec.Segment{spec.Child(spec.Wildcard())}))
+	for _, name := range []string{"min", "max", "sum", "avg"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			registrytest.RunValidation(t, get(t, name), []registrytest.ValidationCase{
+				{Name: "nodes", Args: []spec.FunctionExprArg{nodes}},
+				{Name: "singular", Args: []spec.FunctionExprArg{&spec.SingularQueryExpr{}}},
+				{Name: "none", Args: []spec.FunctionExprArg{}, Err: "expected 1 argument but found 0"},
+				{Name: "two", Args: []spec.FunctionExprArg{nodes, nodes}, Err: "expected 1 argument but found 2"},
+				{Name: "literal", Args: []spec.FunctionExprArg{spec.Literal(1)}, Err: "cannot convert argument to PathNodes"},
+			})
+		})
+	}
+
+	for _, name := range []string{"keys", "values", "type"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			registrytest.RunValidation(t, get(t, name), []registrytest.ValidationCase{
+				{Name: "literal", Args: []spec.FunctionExprArg{spec.Literal(1)}},
+				{Name: "singular", Args: []spec.FunctionExprArg{&spec.SingularQueryExpr{}}},
+				{Name: "none", Args: []spec.FunctionExprArg{}, Err: "expected 1 argument but found 0"},
+				{Name: "nodes", Args: []spec.FunctionExprArg{nodes}, Err: "cannot convert argument to ValueType"},
+			})
+		})
+	}
+
+	for _, name := range []string{"starts_with", "ends_with", "contains"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			registrytest.RunValidation(t, get(t, name), []registrytest.ValidationCase{
+				{Name: "literals", Args: []spec.FunctionExprArg{spec.Literal("a"), spec.Literal("b")}},
+				{Name: "one", Args: []spec.FunctionExprArg{spec.Literal("a")}, Err: "expected 2 arguments but found 1"},
+				{
+					Name: "nodes",
+					Args: []spec.FunctionExprArg{spec.Literal("a"), nodes},
+					Err:  "cannot convert argument 2 to ValueType",
+				},
+			})
+		})
+	}
+}
+
+func TestEvaluation(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		cases []registrytest.EvaluationCase
+	}{
+		{
+			name: "min",
+			cases: []registrytest.EvaluationCase{
+				{Name: "floats", Args: []spec.JSONPathValue{spec.NodesType{3.0, 1.5, 2.0}}, Exp: spec.Value(1.5)},
+				{Name: "mixed", Args: []spec.JSONPathValue{spec.NodesType{3.0, int64(-1), 2}}, Exp: spec.Value(int64(-1))},
+				{Name: "one", Args: []spec.JSONPathValue{spec.NodesType{7.0}}, Exp: spec.Value(7.0)},
+				{Name: "empty", Args: []spec.JSONPathValue{spec.NodesType{}}},
+				{Name: "not_number", Args: []spec.JSONPathValue{spec.NodesType{1.0, "2"}}},
+				{Name: "array", Args: []spec.JSONPathValue{spec.NodesType{[]any{1.0}}}},
+				{Name: "not_nodes", Args: []spec.JSONPathValue{spec.LogicalTrue}},
+			},
+		},
+		{
+			name: "max",
+			cases: []registrytest.EvaluationCase{
+				{Name: "floats", Args: []spec.JSONPathValue{spec.NodesType{3.0, 1.5, 4.0}}, Exp: spec.Value(4.0)},
+				{Name: "ties", Args: []spec.JSONPathValue{spec.NodesType{3, 3.0}}, Exp: spec.Value(3)},
+				{Name: "empty", Args: []spec.JSONPathValue{spec.NodesType{}}},
+				{Name: "null", Args: []spec.JSONPathValue{spec.NodesType{nil}}},
+			},
+		},
+		{
+			name: "sum",
+			cases: []registrytest.EvaluationCase{
+				{Name: "numbers", Args: []spec.JSONPathValue{spec.NodesType{1.5, 2, uint8(3)}}, Exp: spec.Value(6.5)},
+				{Name: "json_numbers", Args: []spec.JSONPathValue{spec.NodesType{json.Number("1.5"), json.Number("2")}}, Exp: spec.Value(3.5)},
+				{Name: "invalid_json_number", Args: []spec.JSONPathValue{spec.NodesType{json.Number("x")}}},
+				{Name: "empty", Args: []spec.JSONPathValue{spec.NodesType{}}},
+				{Name: "not_number", Args: []spec.JSONPathValue{spec.NodesType{1.0, true}}},
+				{Name: "not_nodes", Args: []spec.JSONPathValue{spec.LogicalFalse}},
+			},
+		},
+		{
+			name: "avg",
+			cases: []registrytest.EvaluationCase{
+				{Name: "numbers", Args: []spec.JSONPathValue{spec.NodesType{1.0, 2.0, 6}}, Exp: spec.Value(3.0)},
+				{Name: "empty", Args: []spec.JSONPathValue{spec.NodesType{}}},
+				{Name: "not_number", Args: []spec.JSONPathValue{spec.NodesType{map[string]any{}}}},
+			},
+		},
+		{
+			name: "keys",
+			cases: []registrytest.EvaluationCase{
+				{
+					Name: "object",
+					Args: []spec.JSONPathValue{spec.Value(map[string]any{"b": 1, "a": 2, "c": 3})},
+					Exp:  spec.Value([]any{"a", "b", "c"}),
+				},
+				{Name: "empty", Args: []spec.JSONPathValue{spec.Value(map[string]any{})}, Exp: spec.Value([]any{})},
+				{Name: "ordered", Args: []spec.JSONPathValue{spec.Value(ordered("b", 1, "a", 2))}, Exp: spec.Value([]any{"a", "b"})},
+				{Name: "array", Args: []spec.JSONPathValue{spec.Value([]any{"a"})}},
+				{Name: "nothing", Args: []spec.JSONPathValue{nil}},
+				{Name: "not_value", Args: []spec.JSONPathValue{spec.LogicalTrue}},
+			},
+		},
+		{
+			name: "values",
+			cases: []registrytest.EvaluationCase{
+				{
+					Name: "object",
+					Args: []spec.JSONPathValue{spec.Value(map[string]any{"b": 1, "a": "x", "c": nil})},
+					Exp:  spec.Value([]any{"x", 1, nil}),
+				},
+				{Name: "empty", Args: []spec.JSONPathValue{spec.Value(map[string]any{})}, Exp: spec.Value([]any{})},
+				{Name: "string", Args: []spec.JSONPathValue{spec.Value("a")}},
+				{Name: "nothing", Args: []spec.JSONPathValue{nil}},
+			},
+		},
+		{
+			name: "type",
+			cases: []registrytest.EvaluationCase{
+				{Name: "null", Args: []spec.JSONPathValue{spec.Value(nil)}, Exp: spec.Value("null")},
+				{Name: "true", Args: []spec.JSONPathValue{spec.Value(true)}, Exp: spec.Value("boolean")},
+				{Name: "float", Args: []spec.JSONPathValue{spec.Value(1.5)}, Exp: spec.Value("number")},
+				{Name: "int", Args: []spec.JSONPathValue{spec.Value(int64(1))}, Exp: spec.Value("number")},
+				{Name: "json_number", Args: []spec.JSONPathValue{spec.Value(json.Number("1e400"))}, Exp: spec.Value("number")},
+				{Name: "string", Args: []spec.JSONPathValue{spec.Value("x")}, Exp: spec.Value("string")},
+				{Name: "array", Args: []spec.JSONPathValue{spec.Value([]any{})}, Exp: spec.Value("array")},
+				{Name: "object", Args: []spec.JSONPathValue{spec.Value(map[string]any{})}, Exp: spec.Value("object")},
+				{Name: "ordered", Args: []spec.JSONPathValue{spec.Value(ordered())}, Exp: spec.Value("object")},
+				{Name: "unknown", Args: []spec.JSONPathValue{spec.Value(struct{}{})}},
+				{Name: "nothing", Args: []spec.JSONPathValue{nil}},
+				{Name: "not_value", Args: []spec.JSONPathValue{spec.NodesType{"x"}}},
+			},
+		},
+		{
+			name: "starts_with",
+			cases: []registrytest.EvaluationCase{
+				{Name: "match", Args: []spec.JSONPathValue{spec.Value("hello"), spec.Value("he")}, Exp: spec.LogicalTrue},
+				{Name: "no_match", Args: []spec.JSONPathValue{spec.Value("hello"), spec.Value("lo")}, Exp: spec.LogicalFalse},
+				{Name: "empty", Args: []spec.JSONPathValue{spec.Value("hello"), spec.Value("")}, Exp: spec.LogicalTrue},
+				{Name: "not_string", Args: []spec.JSONPathValue{spec.Value(12), spec.Value("1")}, Exp: spec.LogicalFalse},
+				{Name: "not_prefix", Args: []spec.JSONPathValue{spec.Value("12"), spec.Value(1)}, Exp: spec.LogicalFalse},
+				{Name: "nothing", Args: []spec.JSONPathValue{nil, spec.Value("")}, Exp: spec.LogicalFalse},
+			},
+		},
+		{
+			name: "ends_with",
+			cases: []registrytest.EvaluationCase{
+				{Name: "match", Args: []spec.JSONPathValue{spec.Value("hello"), spec.Value("lo")}, Exp: spec.LogicalTrue},
+				{Name: "no_match", Args: []spec.JSONPathValue{spec.Value("hello"), spec.Value("he")}, Exp: spec.LogicalFalse},
+				{Name: "nothing", Args: []spec.JSONPathValue{spec.Value("hello"), nil}, Exp: spec.LogicalFalse},
+			},
+		},
+		{
+			name: "contains",
+			cases: []registrytest.EvaluationCase{
+				{Name: "substring", Args: []spec.JSONPathValue{spec.Value("hello"), spec.Value("ell")}, Exp: spec.LogicalTrue},
+				{Name: "no_substring", Args: []spec.JSONPathValue{spec.Value("hello"), spec.Value("x")}, Exp: spec.LogicalFalse},
+				{Name: "string_number", Args: []spec.JSONPathValue{spec.Value("a1"), spec.Value(1)}, Exp: spec.LogicalFalse},
+				{
+					Name: "element",
+					Args: []spec.JSONPathValue{spec.Value([]any{"a", 2.0}), spec.Value(int64(2))},
+					Exp:  spec.LogicalTrue,
+				},
+				{
+					Name: "object_element",
+					Args: []spec.JSONPathValue{spec.Value([]any{map[string]any{"a": 1.0}}), spec.Value(map[string]any{"a": 1.0})},
+					Exp:  spec.LogicalTrue,
+				},
+				{
+					Name: "object_numeric_types",
+					Args: []spec.JSONPathValue{spec.Value([]any{map[string]any{"a": []any{1.0}}}), spec.Value(map[string]any{"a": []any{int64(1)}})},
+					Exp:  spec.LogicalTrue,
+				},
+				{
+					Name: "ordered_object_element",
+					Args: []spec.JSONPathValue{spec.Value([]any{ordered("b", 2, "a", 1)}), spec.Value(map[string]any{"a": 1.0, "b": 2.0})},
+					Exp:  spec.LogicalTrue,
+				},
+				{
+					Name: "json_number_element",
+					Args: []spec.JSONPathValue{spec.Value([]any{"a", json.Number("9007199254740993")}), spec.Value(int64(9007199254740993))},
+					Exp:  spec.LogicalTrue,
+				},
+				{
+					Name: "json_number_inexact",
+					Args: []spec.JSONPathValue{spec.Value([]any{json.Number("9007199254740993")}), spec.Value(float64(9007199254740992))},
+					Exp:  spec.LogicalFalse,
+				},
+				{Name: "no_element", Args: []spec.JSONPathValue{spec.Value([]any{"a", 2.0}), spec.Value("2")}, Exp: spec.LogicalFalse},
+				{Name: "object", Args: []spec.JSONPathValue{spec.Value(map[string]any{"a": 1}), spec.Value("a")}, Exp: spec.LogicalFalse},
+				{Name: "nothing", Args: []spec.JSONPathValue{nil, spec.Value("a")}, Exp: spec.LogicalFalse},
+				{Name: "not_value", Args: []spec.JSONPathValue{spec.Value([]any{"a"}), spec.LogicalTrue}, Exp: spec.LogicalFalse},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			registrytest.RunEvaluation(t, get(t, tc.name), tc.cases)
+		})
+	}
+}
+
+func TestQueries(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+	require.NoError(t, RegisterAll(reg))
+
+	input := []any{
+		map[string]any{"name": "alpha", "tags": []any{"x", "y"}, "scores": []any{1.0, 5.0, 3.0}},
+		map[string]any{"name": "beta", "tags": []any{"y"}, "scores": []any{2.0, 2.0}},
+		map[string]any{"name": "gamma", "tags": "x", "scores": []any{}},
+	}
+
+	registrytest.RunQueries(t, reg, []registrytest.QueryCase{
+		{Name: "min", Query: "$[?min(@.scores[*]) == 1].name", Input: input, Exp: []any{"alpha"}},
+		{Name: "max", Query: "$[?max(@.scores[*]) > 2].name", Input: input, Exp: []any{"alpha"}},
+		{Name: "sum", Query: "$[?sum(@.scores[*]) == 4].name", Input: input, Exp: []any{"beta"}},
+		{Name: "avg", Query: "$[?avg(@.scores[*]) == 3].name", Input: input, Exp: []any{"alpha"}},
+		{
+			Name:  "keys",
+			Query: `$[?keys(@) == $[0].k].name`,
+			Input: []any{map[string]any{"k": []any{"k", "name"}, "name": "x"}, map[string]any{"name": "y"}},
+			Exp:   []any{"x"},
+		},
+		{Name: "type", Query: `$[?type(@.tags) == "string"].name`, Input: input, Exp: []any{"gamma"}},
+		{Name: "starts_with", Query: `$[?starts_with(@.name, "al")].name`, Input: input, Exp: []any{"alpha"}},
+		{Name: "ends_with", Query: `$[?ends_with(@.name, "a")].name`, Input: input, Exp: []any{"alpha", "beta", "gamma"}},
+		{Name: "contains", Query: `$[?contains(@.tags, "x")].name`, Input: input, Exp: []any{"alpha", "gamma"}},
+		{
+			Name:  "compare_logical",
+			Query: `$[?contains(@.tags, "x") == true]`,
+			Err:   "jsonpath: unexpected '=' at position 26",
+		},
+		{
+			Name:  "nodes_arg",
+			Query: `$[?keys(@.*) == 1]`,
+			Err:   "jsonpath: function keys() cannot convert argument to ValueType at position 8",
+		},
+	})
+}
+
+// ordered returns a *spec.OrderedObject with members, alternating names and
+// values.
+func ordered(members ...any) *spec.OrderedObject {
+	obj := &spec.OrderedObject{}
+	for i := 0; i < len(members); i += 2 {
+		obj.Set(members[i].(string), members[i+1])
+	}
+	return obj
+}
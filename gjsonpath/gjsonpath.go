@@ -0,0 +1,213 @@
+// Package gjsonpath evaluates JSONPath queries against [gjson] results and
+// translates JSONPath normalized paths to gjson path syntax, to ease
+// incremental migration from gjson and [sjson] to JSONPath. Use [Select] to
+// run a [jsonpath.Path] against a gjson.Result and get back the Result and
+// gjson path of each node it selects, and [Path] to convert normalized
+// paths to gjson paths for use with gjson.Get or sjson.Set:
+//
+//	for _, m := range gjsonpath.Select(path, gjson.Parse(raw)) {
+//		raw, _ = sjson.Set(raw, m.Path, "redacted")
+//	}
+//
+// Queries walk the Result without decoding it, adapting objects and arrays
+// only as they descend into them, and select object members in the order
+// of the JSON source. Like gjson, Select does not validate the JSON; use
+// gjson.Valid to validate it first. Filter expressions compare numbers by
+// their JSON source text, so that they retain the precision of integers
+// too large for float64.
+//
+// [gjson]: https://github.com/tidwall/gjson
+// [sjson]: https://github.com/tidwall/sjson
+package gjsonpath
+
+import (
+	"encoding/json"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"github.com/tidwall/gjson"
+)
+
+// Match represents a node selected by [Select].
+type Match struct {
+	// Path is the gjson path of the node, as returned by [Path].
+	Path string
+
+	// Value is the gjson.Result of the node.
+	Value gjson.Result
+}
+
+// Select returns a Match for each node p selects from res, in the order p
+// selects them.
+func Select(p *jsonpath.Path, res gjson.Result) []Match {
+	nodes := p.SelectLocated(adapt(res))
+	matches := make([]Match, len(nodes))
+	for i, n := range nodes {
+		matches[i] = Match{Path: Path(n.Path), Value: unwrap(n.Node)}
+	}
+	return matches
+}
+
+// object adapts a gjson.Result containing a JSON object to
+// [spec.OrderedMap]. Get returns the first of members with duplicate
+// names, while All yields them all.
+type object struct{ res gjson.Result }
+
+// Len returns the number of members in o.
+func (o object) Len() int {
+	n := 0
+	o.res.ForEach(func(_, _ gjson.Result) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// Get returns the adapted value of the first member of o named name.
+func (o object) Get(name string) (any, bool) {
+	var val any
+	found := false
+	o.res.ForEach(func(key, v gjson.Result) bool {
+		if key.Str == name {
+			val, found = adapt(v), true
+			return false
+		}
+		return true
+	})
+	return val, found
+}
+
+// All returns an iterator over the names and adapted values of the members
+// of o, in order.
+func (o object) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		o.res.ForEach(func(key, v gjson.Result) bool {
+			return yield(key.Str, adapt(v))
+		})
+	}
+}
+
+// array adapts a gjson.Result containing a JSON array to [spec.Array].
+type array struct {
+	res   gjson.Result
+	elems []gjson.Result
+}
+
+// Len returns the number of elements in a.
+func (a array) Len() int { return len(a.elems) }
+
+// Index returns the adapted value of the element of a at index i.
+func (a array) Index(i int) any { return adapt(a.elems[i]) }
+
+// scalar adapts a gjson.Result containing a JSON scalar to [spec.Adapter].
+type scalar struct{ res gjson.Result }
+
+// Adapt returns the value of s: a string, bool, json.Number, or nil.
+func (s scalar) Adapt() any {
+	switch s.res.Type {
+	case gjson.String:
+		return s.res.Str
+	case gjson.True:
+		return true
+	case gjson.False:
+		return false
+	case gjson.Number:
+		if s.res.Raw == "" {
+			return s.res.Num
+		}
+		return json.Number(s.res.Raw)
+	default:
+		return nil
+	}
+}
+
+// adapt adapts res for selection: objects to [spec.OrderedMap] values,
+// arrays to [spec.Array] values, and scalars to [spec.Adapter] values.
+func adapt(res gjson.Result) any {
+	switch {
+	case res.IsObject():
+		return object{res}
+	case res.IsArray():
+		return array{res, res.Array()}
+	default:
+		return scalar{res}
+	}
+}
+
+// unwrap returns the gjson.Result of val, as adapted by [adapt].
+func unwrap(val any) gjson.Result {
+	switch v := val.(type) {
+	case object:
+		return v.res
+	case array:
+		return v.res
+	case scalar:
+		return v.res
+	default:
+		return gjson.Result{}
+	}
+}
+
+// The adapted types implement the data model interfaces.
+var (
+	_ spec.OrderedMap = object{}
+	_ spec.Array      = array{}
+	_ spec.Adapter    = scalar{}
+)
+
+// Path returns the gjson path equivalent to np, such as store.book.0.title
+// for $['store']['book'][0]['title'], for use with gjson.Get and sjson.Set.
+// Escapes characters in names that gjson treats as path syntax, such as
+// dots and wildcards, with a backslash. Returns an empty string for the
+// root node, for which gjson and sjson have no path.
+//
+// gjson cannot select members with empty names, and treats names
+// consisting of digits as array indexes when setting values in missing
+// containers with sjson.
+func Path(np spec.NormalizedPath) string {
+	buf := new(strings.Builder)
+	for i, e := range np {
+		if i > 0 {
+			buf.WriteByte('.')
+		}
+		switch e := e.(type) {
+		case spec.Name:
+			writeEscaped(buf, string(e))
+		case spec.Index:
+			buf.WriteString(strconv.FormatInt(int64(e), 10))
+		}
+	}
+	return buf.String()
+}
+
+// Escape escapes the characters in name that gjson treats as path syntax,
+// so that it selects an object member by name as a single component of a
+// gjson path.
+func Escape(name string) string {
+	buf := new(strings.Builder)
+	writeEscaped(buf, name)
+	return buf.String()
+}
+
+// writeEscaped writes name to buf, preceding each character that is not
+// safe in a gjson path component with a backslash.
+func writeEscaped(buf *strings.Builder, name string) {
+	for i := range len(name) {
+		if !isSafe(name[i]) {
+			buf.WriteByte('\\')
+		}
+		buf.WriteByte(name[i])
+	}
+}
+
+// isSafe returns true if gjson does not treat c as path syntax: letters,
+// digits, the underscore, hyphen, and colon, control characters and
+// spaces, and non-ASCII bytes.
+func isSafe(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+		(c >= '0' && c <= '9') || c <= ' ' || c > '~' ||
+		c == '_' || c == '-' || c == ':'
+}
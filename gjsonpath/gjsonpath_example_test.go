@@ -0,0 +1,29 @@
+package gjsonpath_test
+
+import (
+	"fmt"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/gjsonpath"
+	"github.com/theory/jsonpath/spec"
+	"github.com/tidwall/gjson"
+)
+
+func ExampleSelect() {
+	res := gjson.Parse(`{"store": {"book": [
+		{"title": "Moby Dick", "price": 8.99},
+		{"title": "Sword of Honour", "price": 12.99}
+	]}}`)
+
+	p := jsonpath.MustParse(`$.store.book[?@.price < 10].title`)
+	for _, m := range gjsonpath.Select(p, res) {
+		fmt.Printf("%v: %v\n", m.Path, m.Value.String())
+	}
+	// Output: store.book.0.title: Moby Dick
+}
+
+func ExamplePath() {
+	np := spec.NormalizedPath{spec.Name("config"), spec.Name("app.name"), spec.Index(1)}
+	fmt.Println(gjsonpath.Path(np))
+	// Output: config.app\.name.1
+}
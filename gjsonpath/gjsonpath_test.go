@@ -0,0 +1,147 @@
+package gjsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"github.com/tidwall/gjson"
+)
+
+func TestPath(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		path spec.NormalizedPath
+		exp  string
+	}{
+		{"root", spec.NormalizedPath{}, ""},
+		{"name", spec.NormalizedPath{spec.Name("store")}, "store"},
+		{"index", spec.NormalizedPath{spec.Index(2)}, "2"},
+		{
+			name: "names_and_index",
+			path: spec.NormalizedPath{spec.Name("store"), spec.Name("book"), spec.Index(0), spec.Name("title")},
+			exp:  "store.book.0.title",
+		},
+		{"dot", spec.NormalizedPath{spec.Name("a.b"), spec.Name("c")}, `a\.b.c`},
+		{"syntax", spec.NormalizedPath{spec.Name(`*?|#@!=<>%\`)}, `\*\?\|\#\@\!\=\<\>\%\\`},
+		{"safe", spec.NormalizedPath{spec.Name("a_b-c:d e")}, "a_b-c:d e"},
+		{"unicode", spec.NormalizedPath{spec.Name("日本.x")}, `日本\.x`},
+		{"digits", spec.NormalizedPath{spec.Name("0"), spec.Index(0)}, "0.0"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.exp, Path(tc.path))
+			if len(tc.path) == 1 {
+				if name, ok := tc.path[0].(spec.Name); ok {
+					a.Equal(tc.exp, Escape(string(name)))
+				}
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	res := gjson.Parse(`{
+		"store": {
+			"book": [
+				{"title": "Moby Dick", "price": 8.99},
+				{"title": "Sword of Honour", "price": 12.99}
+			],
+			"a.b": {"z": 1, "y": 2, "x": 3},
+			"flags": [true, false, null],
+			"big": 9007199254740993
+		}
+	}`)
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  []string
+	}{
+		{
+			name: "root",
+			path: "$",
+			exp:  []string{""},
+		},
+		{
+			name: "titles",
+			path: "$.store.book[*].title",
+			exp:  []string{"store.book.0.title", "store.book.1.title"},
+		},
+		{
+			name: "filter",
+			path: "$..book[?@.price > 10].price",
+			exp:  []string{"store.book.1.price"},
+		},
+		{
+			name: "escaped_in_order",
+			path: `$.store["a.b"].*`,
+			exp:  []string{`store.a\.b.z`, `store.a\.b.y`, `store.a\.b.x`},
+		},
+		{
+			name: "scalars",
+			path: "$.store.flags[?@ == true || @ == false || @ == null]",
+			exp:  []string{"store.flags.0", "store.flags.1", "store.flags.2"},
+		},
+		{
+			name: "big_number",
+			path: "$.store[?@ == 9007199254740993]",
+			exp:  []string{"store.big"},
+		},
+		{
+			name: "big_number_precision",
+			path: "$.store[?@ == 9007199254740992]",
+			exp:  []string{},
+		},
+		{
+			name: "length",
+			path: "$.store[?length(@) == 2]",
+			exp:  []string{"store.book"},
+		},
+		{
+			name: "none",
+			path: "$.nope",
+			exp:  []string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			matches := Select(jsonpath.MustParse(tc.path), res)
+			a.Len(matches, len(tc.exp))
+			for i, m := range matches {
+				a.Equal(tc.exp[i], m.Path)
+				// Each Value is the Result gjson gets for the path.
+				if m.Path == "" {
+					a.Equal(res, m.Value)
+				} else {
+					a.Equal(res.Get(m.Path), m.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectDuplicates(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Like gjson.Get, name selectors select the first of duplicate members,
+	// while wildcards select them all.
+	res := gjson.Parse(`{"a": 1, "a": 2}`)
+	matches := Select(jsonpath.MustParse("$.a"), res)
+	a.Len(matches, 1)
+	a.Equal(res.Get("a"), matches[0].Value)
+
+	matches = Select(jsonpath.MustParse("$.*"), res)
+	a.Len(matches, 2)
+	a.Equal(int64(1), matches[0].Value.Int())
+	a.Equal(int64(2), matches[1].Value.Int())
+}
@@ -0,0 +1,20 @@
+module github.com/theory/jsonpath/gjsonpath
+
+go 1.23
+
+replace github.com/theory/jsonpath => ../
+
+require (
+	github.com/stretchr/testify v1.10.0
+	github.com/theory/jsonpath v0.0.0-00010101000000-000000000000
+	github.com/tidwall/gjson v1.18.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -3,22 +3,69 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/parser"
 )
 
-func main() {
-	// Parse a jsonpath query.
-	p, _ := jsonpath.Parse(`$.foo`)
+// Options for query.
+const (
+	// optLocated selects located nodes, each with its normalized path.
+	optLocated = 1 << iota
+)
+
+// queryError is the structured error returned by query. Position is the
+// one-based byte offset of a parse error in the query, as reported by
+// [parser.ParseError.Position], so that a playground can highlight it, and
+// zero for other errors.
+type queryError struct {
+	Error    string `json:"error"`
+	Position int    `json:"position"`
+}
+
+// query parses path, unmarshals the JSON in input, and returns the nodes
+// path selects from it, or the located nodes if opts includes optLocated.
+// Returns a *queryError if path or input is invalid.
+func query(path, input string, opts int) any {
+	p, err := jsonpath.Parse(path)
+	if err != nil {
+		var perr *parser.ParseError
+		if errors.As(err, &perr) {
+			return &queryError{perr.Message(), perr.Position()}
+		}
+		return &queryError{Error: err.Error()}
+	}
 
-	// Select values from unmarshaled JSON input.
-	result := p.Select([]byte(`{"foo": "bar"}`))
+	var doc any
+	if err := json.Unmarshal([]byte(input), &doc); err != nil {
+		return &queryError{Error: err.Error()}
+	}
+
+	if opts&optLocated != 0 {
+		return p.SelectLocated(doc)
+	}
+	return p.Select(doc)
+}
+
+func main() {
+	for _, tc := range []struct {
+		path string
+		opts int
+	}{
+		{`$.foo`, 0},
+		{`$.foo`, optLocated},
+		{`$.foo[`, 0},
+	} {
+		// Select values from unmarshaled JSON input.
+		result := query(tc.path, `{"foo": "bar"}`, tc.opts)
 
-	// Show the result.
-	//nolint:errchkjson
-	items, _ := json.Marshal(result)
+		// Show the result.
+		//nolint:errchkjson
+		items, _ := json.Marshal(result)
 
-	//nolint:forbidigo
-	fmt.Printf("%s\n", items)
+		//nolint:forbidigo
+		fmt.Printf("%s\n", items)
+	}
 }
@@ -1,10 +1,59 @@
 package main
 
 import (
+	"encoding/json"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMain(t *testing.T) {
 	t.Parallel()
 	main()
 }
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		input string
+		opts  int
+		exp   string
+	}{
+		{
+			name:  "select",
+			path:  `$.foo`,
+			input: `{"foo": "bar"}`,
+			exp:   `["bar"]`,
+		},
+		{
+			name:  "located",
+			path:  `$.foo`,
+			input: `{"foo": "bar"}`,
+			opts:  optLocated,
+			exp:   `[{"node": "bar", "path": "$['foo']"}]`,
+		},
+		{
+			name:  "parse_error",
+			path:  `$.foo[`,
+			input: `{"foo": "bar"}`,
+			exp:   `{"error": "unexpected eof", "position": 7}`,
+		},
+		{
+			name:  "invalid_json",
+			path:  `$.foo`,
+			input: `{"foo"`,
+			exp:   `{"error": "unexpected end of JSON input", "position": 0}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			res, err := json.Marshal(query(tc.path, tc.input, tc.opts))
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.exp, string(res))
+		})
+	}
+}
@@ -0,0 +1,17 @@
+// Package jsonv2 evaluates JSONPath queries against [jsontext.Value]
+// documents from the encoding/json/v2 packages. It requires Go 1.27 or
+// later with the jsonv2 experiment enabled, as it is by default, which
+// defines [json.RawMessage] as an alias for jsontext.Value. The package is
+// also empty when built with the jsonpath_minimal build tag, which omits the
+// decoding of json.RawMessage values.
+//
+// Queries decode a jsontext.Value lazily, only as far as they descend into
+// it, and select object members in the order they appear in the document,
+// so ordering-sensitive applications can rely on the order of the results
+// of queries such as $.* and $..name. Values decoded into any by
+// encoding/json/v2 need no integration: they use the same map[string]any
+// and []any representation as encoding/json, but, as Go maps, have no
+// member order.
+//
+// [jsontext.Value]: https://pkg.go.dev/encoding/json/jsontext#Value
+package jsonv2
@@ -0,0 +1,61 @@
+//go:build go1.27 && goexperiment.jsonv2 && !jsonpath_minimal
+
+package jsonv2
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/json/jsontext"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/theory/jsonpath"
+)
+
+// ErrInvalid errors are returned by [Select] and [SelectLocated] for
+// documents that are not valid JSON.
+var ErrInvalid = errors.New("jsonv2: invalid JSON")
+
+// Queries select from a jsontext.Value as they do from a json.RawMessage,
+// so the two must be the same type.
+var _ json.RawMessage = jsontext.Value(nil)
+
+// Select validates doc and returns the values that p selects from it.
+// Decodes only the parts of doc that p descends into, and selects object
+// members in the order they appear in doc. Selected objects and arrays
+// that p does not descend into remain jsontext.Value values. Returns an
+// [ErrInvalid] error if doc is not valid JSON.
+func Select(p *jsonpath.Path, doc jsontext.Value) (jsonpath.NodeList, error) {
+	if err := validate(doc); err != nil {
+		return nil, err
+	}
+	return p.Select(doc), nil
+}
+
+// SelectLocated validates doc and returns the values that p selects from
+// it, together with their normalized paths, in the same order as [Select].
+// Returns an [ErrInvalid] error if doc is not valid JSON.
+func SelectLocated(p *jsonpath.Path, doc jsontext.Value) (jsonpath.LocatedNodeList, error) {
+	if err := validate(doc); err != nil {
+		return nil, err
+	}
+	return p.SelectLocated(doc), nil
+}
+
+// validate returns an [ErrInvalid] error if doc is not a single valid JSON
+// value, as defined by RFC 7493, which disallows duplicate object member
+// names.
+func validate(doc jsontext.Value) error {
+	dec := jsontext.NewDecoder(bytes.NewReader(doc))
+	if err := dec.SkipValue(); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if _, err := dec.ReadToken(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: unexpected data after top-level value", ErrInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+//go:build go1.27 && goexperiment.jsonv2 && !jsonpath_minimal
+
+package jsonv2_test
+
+import (
+	"encoding/json/jsontext"
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/jsonv2"
+)
+
+func ExampleSelect() {
+	doc := jsontext.Value(`{"zebra": 3, "apple": 1, "mango": 2}`)
+	nodes, err := jsonv2.Select(jsonpath.MustParse("$.*"), doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(nodes)
+	// Output: [3 1 2]
+}
+
+func ExampleSelectLocated() {
+	doc := jsontext.Value(`{"b": {"id": 1}, "a": {"id": 2}}`)
+	nodes, err := jsonv2.SelectLocated(jsonpath.MustParse("$..id"), doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, n := range nodes {
+		fmt.Printf("%v: %v\n", n.Path, n.Node)
+	}
+	// Output:
+	// $['b']['id']: 1
+	// $['a']['id']: 2
+}
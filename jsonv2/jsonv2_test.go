@@ -0,0 +1,113 @@
+//go:build go1.27 && goexperiment.jsonv2 && !jsonpath_minimal
+
+package jsonv2
+
+import (
+	"encoding/json/jsontext"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	doc := jsontext.Value(`{
+		"z": {"name": "last", "n": 3},
+		"a": {"name": "first", "n": 1},
+		"m": {"name": "middle", "n": 2, "kids": {"y": {"name": "y"}, "b": {"name": "b"}}}
+	}`)
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		exp   jsonpath.NodeList
+		paths []string
+	}{
+		{
+			name:  "wildcard_name",
+			path:  "$.*.name",
+			exp:   jsonpath.NodeList{"last", "first", "middle"},
+			paths: []string{`$['z']['name']`, `$['a']['name']`, `$['m']['name']`},
+		},
+		{
+			name:  "filter",
+			path:  "$[?@.n > 1].name",
+			exp:   jsonpath.NodeList{"last", "middle"},
+			paths: []string{`$['z']['name']`, `$['m']['name']`},
+		},
+		{
+			name:  "descendant",
+			path:  "$..name",
+			exp:   jsonpath.NodeList{"last", "first", "middle", "y", "b"},
+			paths: []string{`$['z']['name']`, `$['a']['name']`, `$['m']['name']`, `$['m']['kids']['y']['name']`, `$['m']['kids']['b']['name']`},
+		},
+		{
+			name:  "undecoded",
+			path:  "$.a",
+			exp:   jsonpath.NodeList{jsontext.Value(`{"name": "first", "n": 1}`)},
+			paths: []string{`$['a']`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+			p := jsonpath.MustParse(tc.path)
+
+			nodes, err := Select(p, doc)
+			r.NoError(err)
+			a.Equal(tc.exp, nodes)
+
+			located, err := SelectLocated(p, doc)
+			r.NoError(err)
+			paths := make([]string, len(located))
+			for i, n := range located {
+				paths[i] = n.Path.String()
+			}
+			a.Equal(tc.paths, paths)
+		})
+	}
+}
+
+func TestSelectInvalid(t *testing.T) {
+	t.Parallel()
+	p := jsonpath.MustParse("$.a")
+
+	for _, tc := range []struct {
+		name string
+		doc  string
+	}{
+		{"empty", ""},
+		{"truncated", `{"a": 1`},
+		{"trailing", `{"a": 1} 2`},
+		{"duplicate", `{"a": 1, "a": 2}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			nodes, err := Select(p, jsontext.Value(tc.doc))
+			a.ErrorIs(err, ErrInvalid)
+			a.Nil(nodes)
+
+			located, err := SelectLocated(p, jsontext.Value(tc.doc))
+			a.ErrorIs(err, ErrInvalid)
+			a.Nil(located)
+		})
+	}
+}
+
+func TestLocatedNode(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	located, err := SelectLocated(jsonpath.MustParse("$[1]"), jsontext.Value(`[0, [1]]`))
+	r.NoError(err)
+	r.Equal(jsonpath.LocatedNodeList{
+		{Path: spec.NormalizedPath{spec.Index(1)}, Node: jsontext.Value(`[1]`)},
+	}, located)
+}
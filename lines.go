@@ -0,0 +1,61 @@
+package jsonpath
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrJSONLine errors are returned by [EachLine] and [EachLineLocated] for
+// lines that do not contain a single valid JSON value.
+var ErrJSONLine = errors.New("jsonpath: invalid JSON line")
+
+// EachLine applies p to each line of newline-delimited JSON read from r, as
+// produced by log processors and described by [JSON Lines], and passes the
+// values it selects from each line to fn. Skips blank lines, but otherwise
+// calls fn once for every line, even if p selects nothing from it. Stops and
+// returns the error if fn returns an error. Returns an [ErrJSONLine] error
+// for a line that does not contain a single valid JSON value, or an error if
+// reading from r fails.
+//
+// [JSON Lines]: https://jsonlines.org
+func EachLine(r io.Reader, p *Path, fn func(result []any) error) error {
+	return eachLine(r, func(doc any) error { return fn(p.Select(doc)) })
+}
+
+// EachLineLocated is like [EachLine], but passes fn the nodes p selects from
+// each line as a [LocatedNodeList], so that fn can tell where in each line
+// they came from.
+func EachLineLocated(r io.Reader, p *Path, fn func(result LocatedNodeList) error) error {
+	return eachLine(r, func(doc any) error { return fn(p.SelectLocated(doc)) })
+}
+
+// eachLine decodes the JSON value on each non-blank line read from r and
+// passes it to fn.
+func eachLine(r io.Reader, fn func(doc any) error) error {
+	buf := bufio.NewReader(r)
+	for num := 1; ; num++ {
+		line, err := buf.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("jsonpath: cannot read line %d: %w", num, err)
+		}
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			var doc any
+			if err := json.Unmarshal(line, &doc); err != nil {
+				return fmt.Errorf("%w %d: %w", ErrJSONLine, num, err)
+			}
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+
+		if err != nil {
+			// EOF.
+			return nil
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package jsonpath
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEachLine(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		input string
+		exp   [][]any
+		paths [][]string
+		err   string
+	}{
+		{
+			name:  "empty",
+			path:  `$`,
+			exp:   [][]any{},
+			paths: [][]string{},
+		},
+		{
+			name:  "lines",
+			path:  `$.level`,
+			input: "{\"level\": \"info\"}\n{\"level\": \"error\"}\n",
+			exp:   [][]any{{"info"}, {"error"}},
+			paths: [][]string{{`$['level']`}, {`$['level']`}},
+		},
+		{
+			name:  "no_trailing_newline",
+			path:  `$[*]`,
+			input: "[1, 2]\n[3]",
+			exp:   [][]any{{1.0, 2.0}, {3.0}},
+			paths: [][]string{{`$[0]`, `$[1]`}, {`$[0]`}},
+		},
+		{
+			name:  "no_matches",
+			path:  `$.msg`,
+			input: "{\"msg\": \"hi\"}\n{}\n{\"msg\": null}\n",
+			exp:   [][]any{{"hi"}, {}, {nil}},
+			paths: [][]string{{`$['msg']`}, {}, {`$['msg']`}},
+		},
+		{
+			name:  "blank_lines",
+			path:  `$`,
+			input: "\n1\n  \n\t\r\n2\r\n\n",
+			exp:   [][]any{{1.0}, {2.0}},
+			paths: [][]string{{`$`}, {`$`}},
+		},
+		{
+			name:  "invalid_json",
+			path:  `$`,
+			input: "1\n\n{\"a\": \n",
+			exp:   [][]any{{1.0}},
+			paths: [][]string{{`$`}},
+			err:   "jsonpath: invalid JSON line 3: unexpected end of JSON input",
+		},
+		{
+			name:  "multiple_values",
+			path:  `$`,
+			input: "{} {}\n",
+			exp:   [][]any{},
+			paths: [][]string{},
+			err:   "jsonpath: invalid JSON line 1: invalid character '{' after top-level value",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+
+			res := [][]any{}
+			err := EachLine(strings.NewReader(tc.input), p, func(result []any) error {
+				res = append(res, result)
+				return nil
+			})
+			a.Equal(tc.exp, res)
+
+			paths := [][]string{}
+			locErr := EachLineLocated(strings.NewReader(tc.input), p, func(result LocatedNodeList) error {
+				line := []string{}
+				for path := range result.Paths() {
+					line = append(line, path.String())
+				}
+				paths = append(paths, line)
+				return nil
+			})
+			a.Equal(tc.paths, paths)
+
+			if tc.err == "" {
+				require.NoError(t, err)
+				require.NoError(t, locErr)
+			} else {
+				require.ErrorIs(t, err, ErrJSONLine)
+				a.EqualError(err, tc.err)
+				require.ErrorIs(t, locErr, ErrJSONLine)
+				a.EqualError(locErr, tc.err)
+			}
+		})
+	}
+}
+
+func TestEachLineErrors(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := MustParse(`$`)
+
+	// Stop at an error returned by fn.
+	errStop := errors.New("stop")
+	calls := 0
+	err := EachLine(strings.NewReader("1\n2\n3\n"), p, func([]any) error {
+		calls++
+		if calls == 2 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	a.Equal(2, calls)
+
+	err = EachLineLocated(strings.NewReader("1\n2\n"), p, func(LocatedNodeList) error { return errStop })
+	require.ErrorIs(t, err, errStop)
+
+	// Handle data returned with io.EOF, and return read errors.
+	errRead := errors.New("read failed")
+	res := []any{}
+	err = EachLine(iotest.DataErrReader(strings.NewReader("1\n2")), p, func(result []any) error {
+		res = append(res, result...)
+		return nil
+	})
+	require.NoError(t, err)
+	a.Equal([]any{1.0, 2.0}, res)
+
+	err = EachLine(iotest.ErrReader(errRead), p, func([]any) error { return nil })
+	require.ErrorIs(t, err, errRead)
+	a.EqualError(err, "jsonpath: cannot read line 1: read failed")
+
+	res = []any{}
+	input := io.MultiReader(strings.NewReader("1\n2"), iotest.ErrReader(errRead))
+	err = EachLine(input, p, func(result []any) error {
+		res = append(res, result...)
+		return nil
+	})
+	require.ErrorIs(t, err, errRead)
+	a.EqualError(err, "jsonpath: cannot read line 2: read failed")
+	a.Equal([]any{1.0}, res)
+}
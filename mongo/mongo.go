@@ -0,0 +1,392 @@
+// Package mongo translates simple JSONPath queries into MongoDB projection
+// documents and query filters. Applications that store JSON documents in
+// MongoDB can use the translation to pre-filter documents in the database,
+// then evaluate the full query against the documents it returns.
+//
+// Translation is best-effort and conservative: a [Translation] always
+// matches a superset of the documents from which the query selects values,
+// so applications must still evaluate the query with
+// [github.com/theory/jsonpath.Path.Select], or, for documents decoded as
+// bson.D or bson.M, [github.com/theory/jsonpath/bsonpath.Select]. Parts of
+// a query that cannot be translated are reported in
+// [Translation.Unsupported] and do not narrow the match.
+//
+// The package generates plain maps rather than depending on a MongoDB
+// driver; pass them wherever the driver expects a document, such as
+// bson.M.
+package mongo
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+// Translation describes the MongoDB equivalents of a JSONPath query.
+type Translation struct {
+	// Projection includes the fields from which the query may select
+	// values. Nil when the query may select from the entire document.
+	Projection map[string]any
+
+	// Match is a query filter, suitable for a $match stage or a find
+	// command, that matches a superset of the documents from which the
+	// query selects values. Empty when no part of the query translates.
+	Match map[string]any
+
+	// Unsupported reports the parts of the query that could not be
+	// translated and must be evaluated by the application.
+	Unsupported []Unsupported
+}
+
+// Complete returns true if t translates the entire query, in which case
+// there are no [Unsupported] parts to report. Applications should still
+// evaluate the query, because MongoDB semantics for arrays and missing
+// fields differ from those of JSONPath.
+func (t *Translation) Complete() bool {
+	return len(t.Unsupported) == 0
+}
+
+// Unsupported describes a part of a query that could not be translated.
+type Unsupported struct {
+	// Expr is the string representation of the untranslated segment or
+	// filter expression.
+	Expr string
+
+	// Reason explains why Expr could not be translated.
+	Reason string
+}
+
+// String returns a string representation of u.
+func (u Unsupported) String() string {
+	return u.Expr + ": " + u.Reason
+}
+
+// Translate translates p into a MongoDB projection and query filter. It
+// supports queries that start with a sequence of child name segments, such
+// as $.store.book, optionally followed by a filter segment. Supported
+// filter expressions consist of && and || operations on existence tests
+// and comparisons between relative singular queries and literals, such as
+// @.price < 10.
+//
+// The translation of a filter segment matches documents in which the named
+// field is an array with at least one element that satisfies the filter,
+// or in which it is an object, because JSONPath filters select from object
+// members as well as array elements.
+func Translate(p *jsonpath.Path) *Translation {
+	t := &Translation{Match: map[string]any{}}
+	segs := p.Query().Segments()
+
+	// Collect the leading field names.
+	fields := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		name, ok := fieldName(seg)
+		if !ok {
+			break
+		}
+		fields = append(fields, name)
+	}
+	segs = segs[len(fields):]
+
+	path := strings.Join(fields, ".")
+	if path != "" {
+		t.Projection = map[string]any{path: 1}
+		t.Match[path] = map[string]any{"$exists": true}
+	}
+
+	if len(segs) == 0 {
+		return t
+	}
+
+	// Translate a filter segment that follows the field names.
+	if f, ok := filterSelector(segs[0]); ok {
+		segs = segs[1:]
+		if path == "" {
+			t.unsupported(f.String(), "filters on the root document not supported")
+		} else if cond, ok := t.or(f.LogicalOr); ok {
+			t.Match = map[string]any{"$or": []any{
+				map[string]any{path: map[string]any{"$elemMatch": cond}},
+				map[string]any{path: map[string]any{"$type": "object"}},
+			}}
+		}
+	}
+
+	// Report the remaining segments.
+	for _, seg := range segs {
+		t.unsupported(seg.String(), segmentReason(seg))
+	}
+
+	return t
+}
+
+// unsupported records an untranslated expression.
+func (t *Translation) unsupported(expr, reason string) {
+	t.Unsupported = append(t.Unsupported, Unsupported{Expr: expr, Reason: reason})
+}
+
+// or translates lo into a query filter. Returns false if any of its
+// operands cannot be translated.
+func (t *Translation) or(lo spec.LogicalOr) (map[string]any, bool) {
+	if len(lo) == 1 {
+		return t.and(lo[0])
+	}
+
+	conds := make([]any, 0, len(lo))
+	for _, la := range lo {
+		cond, ok := t.and(la)
+		if !ok {
+			return nil, false
+		}
+		conds = append(conds, cond)
+	}
+	return map[string]any{"$or": conds}, true
+}
+
+// and translates la into a query filter. Omits operands that cannot be
+// translated, which only broadens the match. Returns false if none of its
+// operands can be translated.
+func (t *Translation) and(la spec.LogicalAnd) (map[string]any, bool) {
+	conds := make([]any, 0, len(la))
+	for _, expr := range la {
+		if cond, ok := t.expr(expr); ok {
+			conds = append(conds, cond)
+		}
+	}
+
+	switch len(conds) {
+	case 0:
+		return nil, false
+	case 1:
+		cond, _ := conds[0].(map[string]any)
+		return cond, true
+	default:
+		return map[string]any{"$and": conds}, true
+	}
+}
+
+// expr translates expr into a query filter. Returns false if expr cannot
+// be translated.
+func (t *Translation) expr(expr spec.BasicExpr) (map[string]any, bool) {
+	switch expr := expr.(type) {
+	case *spec.ParenExpr:
+		return t.or(expr.LogicalOr)
+	case *spec.ComparisonExpr:
+		return t.comparison(expr)
+	case *spec.MembershipExpr:
+		return t.membership(expr)
+	case *spec.ExistExpr:
+		if expr.IsRoot() {
+			t.unsupported(exprString(expr), "root queries not supported")
+			return nil, false
+		}
+		path, ok := queryPath(expr.PathQuery)
+		if !ok {
+			t.unsupported(exprString(expr), "only existence of singular queries supported")
+			return nil, false
+		}
+		return map[string]any{path: map[string]any{"$exists": true}}, true
+	case *spec.NotParenExpr, *spec.NonExistExpr, spec.NonExistExpr:
+		t.unsupported(exprString(expr), "negation not supported")
+		return nil, false
+	case *spec.FunctionExpr, spec.NotFuncExpr:
+		t.unsupported(exprString(expr), "function expressions not supported")
+		return nil, false
+	}
+
+	t.unsupported(exprString(expr), "expression not supported")
+	return nil, false
+}
+
+// compOps maps JSONPath comparison operators to MongoDB query operators.
+// Omits != because JSONPath considers it true for array elements that are
+// not objects, which MongoDB's $elemMatch never matches.
+//
+//nolint:gochecknoglobals
+var compOps = map[spec.CompOp]string{
+	spec.EqualTo:            "$eq",
+	spec.LessThan:           "$lt",
+	spec.GreaterThan:        "$gt",
+	spec.LessThanEqualTo:    "$lte",
+	spec.GreaterThanEqualTo: "$gte",
+}
+
+// flipped maps comparison operators to their equivalents when swapping the
+// operands.
+//
+//nolint:gochecknoglobals
+var flipped = map[spec.CompOp]spec.CompOp{
+	spec.EqualTo:            spec.EqualTo,
+	spec.NotEqualTo:         spec.NotEqualTo,
+	spec.LessThan:           spec.GreaterThan,
+	spec.GreaterThan:        spec.LessThan,
+	spec.LessThanEqualTo:    spec.GreaterThanEqualTo,
+	spec.GreaterThanEqualTo: spec.LessThanEqualTo,
+}
+
+// comparison translates ce into a query filter. Returns false unless ce
+// compares a relative singular query to a literal.
+func (t *Translation) comparison(ce *spec.ComparisonExpr) (map[string]any, bool) {
+	query, qok := ce.Left.(*spec.SingularQueryExpr)
+	lit, lok := ce.Right.(*spec.LiteralArg)
+	op := ce.Op
+	if !qok || !lok {
+		query, qok = ce.Right.(*spec.SingularQueryExpr)
+		lit, lok = ce.Left.(*spec.LiteralArg)
+		op = flipped[op]
+	}
+
+	if !qok || !lok {
+		t.unsupported(exprString(ce), "only comparisons of queries to literals supported")
+		return nil, false
+	}
+
+	if query.IsRoot() {
+		t.unsupported(exprString(ce), "root queries not supported")
+		return nil, false
+	}
+
+	path, ok := selectorPath(query.Selectors())
+	if !ok {
+		t.unsupported(exprString(ce), "query not representable as a field path")
+		return nil, false
+	}
+
+	mop, ok := compOps[op]
+	if !ok {
+		t.unsupported(exprString(ce), "operator "+op.String()+" not supported")
+		return nil, false
+	}
+
+	return map[string]any{path: map[string]any{mop: lit.Value()}}, true
+}
+
+// membership translates me into a query filter. Returns false unless me
+// tests the membership of a relative singular query in an array literal.
+func (t *Translation) membership(me *spec.MembershipExpr) (map[string]any, bool) {
+	query, qok := me.Left.(*spec.SingularQueryExpr)
+	lit, lok := me.Right.(*spec.LiteralArg)
+	if !qok || !lok {
+		t.unsupported(exprString(me), "only membership of queries in array literals supported")
+		return nil, false
+	}
+	arr, ok := lit.Value().([]any)
+	if !ok {
+		t.unsupported(exprString(me), "only membership of queries in array literals supported")
+		return nil, false
+	}
+
+	if query.IsRoot() {
+		t.unsupported(exprString(me), "root queries not supported")
+		return nil, false
+	}
+
+	path, ok := selectorPath(query.Selectors())
+	if !ok {
+		t.unsupported(exprString(me), "query not representable as a field path")
+		return nil, false
+	}
+
+	return map[string]any{path: map[string]any{"$in": arr}}, true
+}
+
+// fieldName returns the name selected by seg if it's a child segment with a
+// single name selector that's valid as a MongoDB field name.
+func fieldName(seg *spec.Segment) (string, bool) {
+	sels := seg.Selectors()
+	if seg.IsDescendant() || len(sels) != 1 {
+		return "", false
+	}
+	name, ok := sels[0].(spec.Name)
+	if !ok || !validField(string(name)) {
+		return "", false
+	}
+	return string(name), true
+}
+
+// filterSelector returns the filter selected by seg if it's a child segment
+// with a single filter selector.
+func filterSelector(seg *spec.Segment) (*spec.FilterSelector, bool) {
+	sels := seg.Selectors()
+	if seg.IsDescendant() || len(sels) != 1 {
+		return nil, false
+	}
+	f, ok := sels[0].(*spec.FilterSelector)
+	return f, ok
+}
+
+// queryPath returns the MongoDB field path for q if it's a relative
+// singular query.
+func queryPath(q *spec.PathQuery) (string, bool) {
+	segs := q.Segments()
+	sels := make([]spec.Selector, 0, len(segs))
+	for _, seg := range segs {
+		if seg.IsDescendant() || len(seg.Selectors()) != 1 {
+			return "", false
+		}
+		sels = append(sels, seg.Selectors()[0])
+	}
+	return selectorPath(sels)
+}
+
+// selectorPath returns the MongoDB field path for sels, which must be
+// names valid as field names or non-negative indexes.
+func selectorPath(sels []spec.Selector) (string, bool) {
+	if len(sels) == 0 {
+		return "", false
+	}
+
+	parts := make([]string, len(sels))
+	for i, sel := range sels {
+		switch sel := sel.(type) {
+		case spec.Name:
+			if !validField(string(sel)) {
+				return "", false
+			}
+			parts[i] = string(sel)
+		case spec.Index:
+			if sel < 0 {
+				return "", false
+			}
+			parts[i] = strconv.Itoa(int(sel))
+		default:
+			return "", false
+		}
+	}
+	return strings.Join(parts, "."), true
+}
+
+// validField returns true if name can be used in a MongoDB field path.
+func validField(name string) bool {
+	return name != "" && !strings.HasPrefix(name, "$") && !strings.ContainsAny(name, ".\x00")
+}
+
+// segmentReason returns the reason seg cannot be translated.
+func segmentReason(seg *spec.Segment) string {
+	switch {
+	case seg.IsDescendant():
+		return "descendant segments not supported"
+	case len(seg.Selectors()) > 1:
+		return "multiple selectors not supported"
+	}
+
+	switch seg.Selectors()[0].(type) {
+	case spec.Name:
+		return "names supported only before other selectors"
+	case spec.Index:
+		return "index selectors not supported"
+	case spec.SliceSelector:
+		return "slice selectors not supported"
+	case spec.WildcardSelector:
+		return "wildcard selectors not supported"
+	default:
+		return "filters supported only after names"
+	}
+}
+
+// exprString returns the string representation of expr. Filter expressions
+// don't implement fmt.Stringer, so it formats expr as a filter selector and
+// strips the leading ?.
+func exprString(expr spec.BasicExpr) string {
+	return strings.TrimPrefix(spec.Filter(spec.LogicalOr{{expr}}).String(), "?")
+}
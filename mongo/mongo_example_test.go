@@ -0,0 +1,26 @@
+package mongo_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/mongo"
+)
+
+func ExampleTranslate() {
+	p := jsonpath.MustParse(`$.store.book[?@.price < 10].title`)
+	t := mongo.Translate(p)
+
+	proj, _ := json.Marshal(t.Projection)
+	match, _ := json.Marshal(t.Match)
+	fmt.Printf("projection: %s\n", proj)
+	fmt.Printf("match: %s\n", match)
+	for _, u := range t.Unsupported {
+		fmt.Printf("unsupported: %v\n", u)
+	}
+	// Output:
+	// projection: {"store.book":1}
+	// match: {"$or":[{"store.book":{"$elemMatch":{"price":{"$lt":10}}}},{"store.book":{"$type":"object"}}]}
+	// unsupported: ["title"]: names supported only before other selectors
+}
@@ -0,0 +1,245 @@
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath"
+)
+
+func TestTranslate(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	exists := map[string]any{"$exists": true}
+	elemMatch := func(path string, cond map[string]any) map[string]any {
+		return map[string]any{"$or": []any{
+			map[string]any{path: map[string]any{"$elemMatch": cond}},
+			map[string]any{path: map[string]any{"$type": "object"}},
+		}}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		proj  map[string]any
+		match map[string]any
+		unsup []Unsupported
+	}{
+		{
+			name:  "root",
+			path:  "$",
+			match: map[string]any{},
+		},
+		{
+			name:  "one_name",
+			path:  "$.store",
+			proj:  map[string]any{"store": 1},
+			match: map[string]any{"store": exists},
+		},
+		{
+			name:  "two_names",
+			path:  "$.store.book",
+			proj:  map[string]any{"store.book": 1},
+			match: map[string]any{"store.book": exists},
+		},
+		{
+			name:  "names_then_wildcard",
+			path:  "$.store.book[*].author",
+			proj:  map[string]any{"store.book": 1},
+			match: map[string]any{"store.book": exists},
+			unsup: []Unsupported{
+				{`[*]`, "wildcard selectors not supported"},
+				{`["author"]`, "names supported only before other selectors"},
+			},
+		},
+		{
+			name:  "dotted_name",
+			path:  `$["a.b"].c`,
+			match: map[string]any{},
+			unsup: []Unsupported{
+				{`["a.b"]`, "names supported only before other selectors"},
+				{`["c"]`, "names supported only before other selectors"},
+			},
+		},
+		{
+			name:  "descendant",
+			path:  "$.a..b",
+			proj:  map[string]any{"a": 1},
+			match: map[string]any{"a": exists},
+			unsup: []Unsupported{{`..["b"]`, "descendant segments not supported"}},
+		},
+		{
+			name:  "union",
+			path:  "$.a[0,1]",
+			proj:  map[string]any{"a": 1},
+			match: map[string]any{"a": exists},
+			unsup: []Unsupported{{`[0,1]`, "multiple selectors not supported"}},
+		},
+		{
+			name:  "index_and_slice",
+			path:  "$.a[0][1:]",
+			proj:  map[string]any{"a": 1},
+			match: map[string]any{"a": exists},
+			unsup: []Unsupported{
+				{`[0]`, "index selectors not supported"},
+				{`[1:]`, "slice selectors not supported"},
+			},
+		},
+		{
+			name:  "comparison",
+			path:  "$.store.book[?@.price < 10].title",
+			proj:  map[string]any{"store.book": 1},
+			match: elemMatch("store.book", map[string]any{"price": map[string]any{"$lt": int64(10)}}),
+			unsup: []Unsupported{{`["title"]`, "names supported only before other selectors"}},
+		},
+		{
+			name:  "flipped_comparison",
+			path:  "$.a[?10 <= @.b[0].c]",
+			proj:  map[string]any{"a": 1},
+			match: elemMatch("a", map[string]any{"b.0.c": map[string]any{"$gte": int64(10)}}),
+		},
+		{
+			name: "comparison_ops",
+			path: `$.a[?@.a=="x"&&@.b>1.5&&@.c>=true&&@.d<null&&@.e<=false]`,
+			proj: map[string]any{"a": 1},
+			match: elemMatch("a", map[string]any{"$and": []any{
+				map[string]any{"a": map[string]any{"$eq": "x"}},
+				map[string]any{"b": map[string]any{"$gt": 1.5}},
+				map[string]any{"c": map[string]any{"$gte": true}},
+				map[string]any{"d": map[string]any{"$lt": nil}},
+				map[string]any{"e": map[string]any{"$lte": false}},
+			}}),
+		},
+		{
+			name: "existence_or",
+			path: `$.a[?@.x || (@.y && @.z == 1)]`,
+			proj: map[string]any{"a": 1},
+			match: elemMatch("a", map[string]any{"$or": []any{
+				map[string]any{"x": exists},
+				map[string]any{"$and": []any{
+					map[string]any{"y": exists},
+					map[string]any{"z": map[string]any{"$eq": int64(1)}},
+				}},
+			}}),
+		},
+		{
+			name:  "and_drops_unsupported",
+			path:  `$.a[?@.x&&@.z!=1&&!@.y]`,
+			proj:  map[string]any{"a": 1},
+			match: elemMatch("a", map[string]any{"x": exists}),
+			unsup: []Unsupported{
+				{`@["z"] != 1`, "operator != not supported"},
+				{`!@["y"]`, "negation not supported"},
+			},
+		},
+		{
+			name:  "or_with_unsupported",
+			path:  `$.a[?@.x || !(@.y) || @.z]`,
+			proj:  map[string]any{"a": 1},
+			match: map[string]any{"a": exists},
+			unsup: []Unsupported{{`!(@["y"])`, "negation not supported"}},
+		},
+		{
+			name:  "unsupported_filter_exprs",
+			path:  `$.a[?$.q&&@..r&&length(@.x)>1&&@.y==$.z&&@==1&&@[-1]==1&&@.s==@.t]`,
+			proj:  map[string]any{"a": 1},
+			match: map[string]any{"a": exists},
+			unsup: []Unsupported{
+				{`$["q"]`, "root queries not supported"},
+				{`@..["r"]`, "only existence of singular queries supported"},
+				{`length(@["x"]) > 1`, "only comparisons of queries to literals supported"},
+				{`@["y"] == $["z"]`, "only comparisons of queries to literals supported"},
+				{`@ == 1`, "query not representable as a field path"},
+				{`@[-1] == 1`, "query not representable as a field path"},
+				{`@["s"] == @["t"]`, "only comparisons of queries to literals supported"},
+			},
+		},
+		{
+			name:  "function",
+			path:  `$.a[?match(@.x, "y")]`,
+			proj:  map[string]any{"a": 1},
+			match: map[string]any{"a": exists},
+			unsup: []Unsupported{{`match(@["x"], "y")`, "function expressions not supported"}},
+		},
+		{
+			name:  "root_filter",
+			path:  `$[?@.x]`,
+			match: map[string]any{},
+			unsup: []Unsupported{{`?@["x"]`, "filters on the root document not supported"}},
+		},
+		{
+			name:  "second_filter",
+			path:  `$.a[?@.x][?@.y]`,
+			proj:  map[string]any{"a": 1},
+			match: elemMatch("a", map[string]any{"x": exists}),
+			unsup: []Unsupported{{`[?@["y"]]`, "filters supported only after names"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tr := Translate(jsonpath.MustParse(tc.path))
+			a.Equal(tc.proj, tr.Projection)
+			a.Equal(tc.match, tr.Match)
+			a.Equal(tc.unsup, tr.Unsupported)
+			a.Equal(len(tc.unsup) == 0, tr.Complete())
+		})
+	}
+}
+
+func TestTranslateMembership(t *testing.T) {
+	t.Parallel()
+
+	parser := jsonpath.NewParser(jsonpath.WithMembership())
+	for _, tc := range []struct {
+		name  string
+		path  string
+		match map[string]any
+		unsup []Unsupported
+	}{
+		{
+			name: "array_literal",
+			path: `$.a[?@.status in ["active", "pending"]]`,
+			match: map[string]any{"$or": []any{
+				map[string]any{"a": map[string]any{"$elemMatch": map[string]any{
+					"status": map[string]any{"$in": []any{"active", "pending"}},
+				}}},
+				map[string]any{"a": map[string]any{"$type": "object"}},
+			}},
+		},
+		{
+			name:  "query_array",
+			path:  `$.a[?@.status in $.allowed]`,
+			match: map[string]any{"a": map[string]any{"$exists": true}},
+			unsup: []Unsupported{{`@["status"] in $["allowed"]`, "only membership of queries in array literals supported"}},
+		},
+		{
+			name:  "root_query",
+			path:  `$.a[?$.status in [1]]`,
+			match: map[string]any{"a": map[string]any{"$exists": true}},
+			unsup: []Unsupported{{`$["status"] in [1]`, "root queries not supported"}},
+		},
+		{
+			name:  "index_query",
+			path:  `$.a[?@[-1] in [1]]`,
+			match: map[string]any{"a": map[string]any{"$exists": true}},
+			unsup: []Unsupported{{`@[-1] in [1]`, "query not representable as a field path"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			tr := Translate(parser.MustParse(tc.path))
+			a.Equal(map[string]any{"a": 1}, tr.Projection)
+			a.Equal(tc.match, tr.Match)
+			a.Equal(tc.unsup, tr.Unsupported)
+		})
+	}
+}
+
+func TestUnsupportedString(t *testing.T) {
+	t.Parallel()
+	u := Unsupported{Expr: "[*]", Reason: "wildcard selectors not supported"}
+	assert.Equal(t, "[*]: wildcard selectors not supported", u.String())
+}
@@ -0,0 +1,291 @@
+package jsonpath
+
+import (
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// MultiPath evaluates several JSONPath queries together in a single
+// traversal of their input, rather than one traversal for each query. Each
+// node of the input is visited at most once, no matter how many queries
+// select from it, so that evaluating many queries against a large document,
+// such as hundreds of extraction rules, does not walk the document hundreds
+// of times.
+//
+// MultiPath also compiles the queries into a trie of their segments, so that
+// it evaluates segments shared by the prefixes of several queries, such as
+// $.store.book[*] in $.store.book[*].title and $.store.book[*].price, once
+// for all of them. Segments are shared when their string representations
+// are the same, so combine only queries parsed with the same function
+// registry. Use [MultiPath.Stats] to see how many segments the queries
+// share.
+//
+// Create a MultiPath with [NewMultiPath]. A MultiPath is safe for concurrent
+// use.
+type MultiPath struct {
+	paths []*Path
+	trie  *trieNode
+}
+
+// trieNode is a node in the trie of the segments of the queries in a
+// MultiPath. The root node represents the root of the input, and every other
+// node represents the evaluation of seg against the nodes represented by
+// its parent.
+type trieNode struct {
+	// seg is the segment evaluated to reach this node, nil for the root.
+	seg *spec.Segment
+
+	// key is the string representation of seg.
+	key string
+
+	// ends lists the indexes of the queries whose final segment is seg.
+	ends []int
+
+	// uses is the number of queries that include seg.
+	uses int
+
+	// children lists the nodes for the segments that follow seg.
+	children []*trieNode
+}
+
+// NewMultiPath creates a new [MultiPath] that evaluates paths.
+func NewMultiPath(paths ...*Path) *MultiPath {
+	root := &trieNode{}
+	for i, p := range paths {
+		node := root
+		node.uses++
+		for _, seg := range p.q.Segments() {
+			node = node.child(seg)
+			node.uses++
+		}
+		node.ends = append(node.ends, i)
+	}
+	return &MultiPath{paths: slices.Clone(paths), trie: root}
+}
+
+// child returns the child of n for seg, adding it if n has none.
+func (n *trieNode) child(seg *spec.Segment) *trieNode {
+	key := seg.String()
+	for _, c := range n.children {
+		if c.key == key {
+			return c
+		}
+	}
+	c := &trieNode{seg: seg, key: key}
+	n.children = append(n.children, c)
+	return c
+}
+
+// MultiPathStats describes how the queries in a [MultiPath] share segments,
+// as returned by [MultiPath.Stats].
+type MultiPathStats struct {
+	// Queries is the number of queries.
+	Queries int
+
+	// Segments is the total number of segments in all of the queries, the
+	// number evaluated if the queries shared none.
+	Segments int
+
+	// Shared is the number of distinct segments that more than one query
+	// shares as part of a common prefix.
+	Shared int
+
+	// Unique is the number of segments that belong to a single query.
+	Unique int
+}
+
+// Evaluated returns the number of segments a [MultiPath] evaluates, the
+// sum of Shared and Unique.
+func (s MultiPathStats) Evaluated() int { return s.Shared + s.Unique }
+
+// Stats returns statistics describing how the queries in m share segments.
+func (m *MultiPath) Stats() MultiPathStats {
+	stats := MultiPathStats{Queries: len(m.paths)}
+	for _, p := range m.paths {
+		stats.Segments += len(p.q.Segments())
+	}
+	var count func(n *trieNode)
+	count = func(n *trieNode) {
+		for _, c := range n.children {
+			if c.uses > 1 {
+				stats.Shared++
+			} else {
+				stats.Unique++
+			}
+			count(c)
+		}
+	}
+	count(m.trie)
+	return stats
+}
+
+// Paths returns the paths m evaluates.
+func (m *MultiPath) Paths() []*Path {
+	return slices.Clone(m.paths)
+}
+
+// Select returns a map of each path in m to the values it selects from
+// input, in the same order as [Path.Select]. The map contains an entry for
+// every path in m, including those that select nothing.
+func (m *MultiPath) Select(input any) map[*Path]NodeList {
+	located := m.SelectLocated(input)
+	res := make(map[*Path]NodeList, len(located))
+	for p, nodes := range located {
+		list := make(NodeList, len(nodes))
+		for i, n := range nodes {
+			list[i] = n.Node
+		}
+		res[p] = list
+	}
+	return res
+}
+
+// SelectLocated returns a map of each path in m to the values it selects
+// from input as [spec.LocatedNode] structs, in the same order as
+// [Path.SelectLocated]. The map contains an entry for every path in m,
+// including those that select nothing.
+func (m *MultiPath) SelectLocated(input any) map[*Path]LocatedNodeList {
+	e := &multiEval{
+		root:    input,
+		results: make([][]multiResult, len(m.paths)),
+	}
+	e.visit(input, spec.NormalizedPath{}, []continuation{{node: m.trie}})
+
+	res := make(map[*Path]LocatedNodeList, len(m.paths))
+	for i, p := range m.paths {
+		results := e.results[i]
+		slices.SortFunc(results, func(a, b multiResult) int {
+			return slices.Compare(a.key, b.key)
+		})
+		list := make(LocatedNodeList, len(results))
+		for j, r := range results {
+			list[j] = r.node
+		}
+		res[p] = list
+	}
+	return res
+}
+
+// continuation records the progress of queries to a node of the input.
+// When descend is false, the queries have reached the node by evaluating
+// node.seg, and proceed by evaluating the segments of node.children. When
+// descend is true, the node is a descendant of a node from which a
+// descendant segment selects, and the queries proceed by evaluating
+// node.seg against it, too.
+//
+// The sort key orders the results of a query as [Path.SelectLocated] does.
+// Each segment appends to it the index of the selector that selected the
+// node and the position of the node among those selected by that selector.
+// Descendant segments first append the position plus one of each child on
+// the path to the descendant from which the selector selected it, then a
+// zero, so that nodes selected from a node precede those selected from its
+// descendants.
+type continuation struct {
+	node    *trieNode
+	descend bool
+	key     []int
+}
+
+// multiResult pairs a node selected by a query with its sort key.
+type multiResult struct {
+	key  []int
+	node *spec.LocatedNode
+}
+
+// multiEval evaluates the queries of a MultiPath against a single input.
+type multiEval struct {
+	root    any
+	results [][]multiResult
+}
+
+// childConts collects the continuations that proceed to a child node.
+type childConts struct {
+	node  *spec.LocatedNode
+	conts []continuation
+}
+
+// visit evaluates conts against node, located at path, recording the
+// results of the queries that end at the trie nodes of the continuations,
+// then visits each child of node to which any continuation proceeds, once,
+// with all of those continuations.
+func (e *multiEval) visit(node any, path spec.NormalizedPath, conts []continuation) {
+	var (
+		children map[spec.NormalSelector]*childConts
+		order    []spec.NormalSelector
+		all      []*spec.LocatedNode
+	)
+	proceed := func(child *spec.LocatedNode, c continuation) {
+		if children == nil {
+			children = map[spec.NormalSelector]*childConts{}
+		}
+		sel := child.Path[len(child.Path)-1]
+		cc, ok := children[sel]
+		if !ok {
+			cc = &childConts{node: child}
+			children[sel] = cc
+			order = append(order, sel)
+		}
+		cc.conts = append(cc.conts, c)
+	}
+
+	for _, c := range conts {
+		if c.descend {
+			all = e.evalSegment(c.node, node, path, c.key, all, proceed)
+			continue
+		}
+		for _, q := range c.node.ends {
+			e.results[q] = append(e.results[q], multiResult{
+				key:  c.key,
+				node: &spec.LocatedNode{Path: slices.Clone(path), Node: node},
+			})
+		}
+		for _, child := range c.node.children {
+			all = e.evalSegment(child, node, path, c.key, all, proceed)
+		}
+	}
+
+	for _, sel := range order {
+		cc := children[sel]
+		e.visit(cc.node.Node, cc.node.Path, cc.conts)
+	}
+}
+
+// evalSegment evaluates n.seg against node, located at path and reached
+// with sort key key, passing each node it selects and, for descendant
+// segments, each child of node to proceed with its continuation. Pass all
+// as the children of node if already selected, or nil; evalSegment returns
+// it for reuse.
+func (e *multiEval) evalSegment(
+	n *trieNode,
+	node any,
+	path spec.NormalizedPath,
+	key []int,
+	all []*spec.LocatedNode,
+	proceed func(child *spec.LocatedNode, c continuation),
+) []*spec.LocatedNode {
+	prefix := key
+	if n.seg.IsDescendant() {
+		prefix = appendKey(key, 0)
+	}
+	for si, sel := range n.seg.Selectors() {
+		for ci, child := range sel.SelectLocated(node, e.root, path) {
+			proceed(child, continuation{node: n, key: appendKey(prefix, si, ci)})
+		}
+	}
+
+	if n.seg.IsDescendant() {
+		if all == nil {
+			all = spec.Wildcard().SelectLocated(node, e.root, path)
+		}
+		for ri, child := range all {
+			proceed(child, continuation{node: n, descend: true, key: appendKey(key, ri+1)})
+		}
+	}
+	return all
+}
+
+// appendKey returns a new sort key consisting of key followed by vals.
+func appendKey(key []int, vals ...int) []int {
+	return append(append(make([]int, 0, len(key)+len(vals)), key...), vals...)
+}
@@ -0,0 +1,143 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/examples"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestMultiPath(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	src := []byte(`{
+		"store": {
+			"book": [
+				{"title": "Sayings", "price": 8.95, "tags": ["a", "b"]},
+				{"title": "Sword", "price": 12.99, "isbn": "0-553"},
+				{"title": "Moby", "price": 8.99, "tags": ["c"]}
+			],
+			"bicycle": {"color": "red", "price": 399}
+		},
+		"limit": 10,
+		"tags": ["x", ["y", "z"]]
+	}`)
+	ordered, err := UnmarshalOrdered(src)
+	r.NoError(err)
+
+	queries := []string{
+		"$",
+		"$.store",
+		"$.store.book[*].title",
+		"$.store.book[2,0,1].price",
+		"$.store.book[::-1].title",
+		"$.store.book[0,0]",
+		"$..price",
+		"$..tags",
+		"$..tags[*]",
+		"$..*",
+		"$..[1,0]",
+		"$..book..*",
+		"$..book[?@.price < $.limit].title",
+		"$.store[*][?@.tags]",
+		"$.store.book[?@.price > 10]['isbn', 'title']",
+		"$.nope..x",
+		"$.limit.x",
+	}
+	paths := make([]*Path, len(queries))
+	for i, q := range queries {
+		paths[i] = MustParse(q)
+	}
+	multi := NewMultiPath(paths...)
+	a.Equal(paths, multi.Paths())
+
+	for _, input := range []struct {
+		name string
+		doc  any
+	}{
+		{"ordered", ordered},
+		{"raw", json.RawMessage(src)},
+	} {
+		located := multi.SelectLocated(input.doc)
+		selected := multi.Select(input.doc)
+		a.Len(located, len(paths))
+		a.Len(selected, len(paths))
+		for _, p := range paths {
+			a.Equal(p.SelectLocated(input.doc), located[p], "%v: %v", input.name, p)
+			a.Equal(p.Select(input.doc), selected[p], "%v: %v", input.name, p)
+		}
+	}
+
+	// Every path has an entry, even when it selects nothing.
+	nope := multi.Select(ordered)[paths[len(paths)-1]]
+	a.NotNil(nope)
+	a.Empty(nope)
+
+	// Paths evaluate separately even when the same query appears twice.
+	dupe := NewMultiPath(MustParse("$.limit"), MustParse("$.limit"))
+	dupes := dupe.Select(ordered)
+	a.Len(dupes, 2)
+	for _, nodes := range dupes {
+		a.Equal(NodeList{float64(10)}, nodes)
+	}
+
+	// Located nodes record their normalized paths.
+	cheap := MustParse(`$..book[?@.price < 9].title`)
+	a.Equal(
+		LocatedNodeList{
+			{Path: spec.NormalizedPath{spec.Name("store"), spec.Name("book"), spec.Index(0), spec.Name("title")}, Node: "Sayings"},
+			{Path: spec.NormalizedPath{spec.Name("store"), spec.Name("book"), spec.Index(2), spec.Name("title")}, Node: "Moby"},
+		},
+		NewMultiPath(cheap).SelectLocated(ordered)[cheap],
+	)
+
+	// An empty MultiPath selects nothing.
+	a.Empty(NewMultiPath().Select(ordered))
+}
+
+func TestMultiPathStats(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Count the calls to a filter function.
+	calls := 0
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"counted",
+		spec.FuncLogical,
+		func([]spec.FunctionExprArg) error { return nil },
+		func([]spec.JSONPathValue) spec.JSONPathValue {
+			calls++
+			return spec.LogicalTrue
+		},
+	))
+	parser := NewParser(WithRegistry(reg))
+
+	paths := []*Path{
+		parser.MustParse("$.store.book[?counted(@)].title"),
+		parser.MustParse("$.store.book[?counted(@)].price"),
+		parser.MustParse("$.store.bicycle"),
+		parser.MustParse("$..price"),
+		parser.MustParse("$"),
+	}
+	multi := NewMultiPath(paths...)
+	stats := multi.Stats()
+	a.Equal(MultiPathStats{Queries: 5, Segments: 11, Shared: 3, Unique: 4}, stats)
+	a.Equal(7, stats.Evaluated())
+	a.Equal(MultiPathStats{}, NewMultiPath().Stats())
+
+	// The shared filter runs once for each book.
+	doc := examples.Bookstore()
+	results := multi.Select(doc)
+	a.Equal(4, calls)
+	for _, p := range paths {
+		a.ElementsMatch(p.Select(doc), results[p], "%v", p)
+	}
+}
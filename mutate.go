@@ -0,0 +1,238 @@
+package jsonpath
+
+import (
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Set replaces each value that p selects from input with value and returns
+// the modified input. Modifies the objects and arrays in input in place,
+// except when p selects the root node ($), in which case it returns value
+// without modifying input. Every selected node receives the same value, so
+// pass a fresh copy of mutable values or use [Path.SetFunc]. Ignores nodes
+// within other selected nodes, which the replacement of the enclosing node
+// supersedes. Modifies objects that are map[string]any or *[OrderedObject]
+// values and arrays that are []any values, and ignores nodes within values
+// it cannot modify, such as json.RawMessage values.
+func (p *Path) Set(input, value any) any {
+	return p.SetFunc(input, func(*spec.LocatedNode) any { return value })
+}
+
+// SetFunc replaces each value that p selects from input with the value
+// returned by passing its [spec.LocatedNode] to fn, and returns the modified
+// input. Otherwise identical to [Path.Set].
+func (p *Path) SetFunc(input any, fn func(node *spec.LocatedNode) any) any {
+	return mutate(p.SelectLocated(input), input, fn, setChild)
+}
+
+// Transform replaces each value that p selects from input with the value
+// returned by passing it to fn, and returns the modified input. Unlike
+// [Path.SetFunc], it passes every selected value to fn, including those
+// within other selected values, in descending normalized path order, so
+// that fn receives each value after the values it contains have been
+// replaced. Thus $..price converts every price in a document, and $..*
+// rewrites every node from the leaves up. Passes each node to fn only once,
+// even if p selects it more than once.
+//
+// Stops at the first error returned by fn and returns it, in which case
+// input may be partially modified. Otherwise modifies input in place as
+// described for [Path.Set].
+func (p *Path) Transform(input any, fn func(node any) (any, error)) (any, error) {
+	nodes := p.SelectLocated(input).Deduplicate()
+	nodes.Sort()
+	slices.Reverse(nodes)
+	for _, n := range nodes {
+		node, ok := n.Path.Resolve(input)
+		if !ok {
+			continue
+		}
+		val, err := fn(node)
+		if err != nil {
+			return nil, err
+		}
+		if len(n.Path) == 0 {
+			// The root node sorts last.
+			return val, nil
+		}
+		input = mutateAt(input, n.Path, val, setChild)
+	}
+	return input, nil
+}
+
+// setChild sets the member or element of parent identified by sel to val
+// and returns parent. Ignores parents that are not map[string]any,
+// [*spec.OrderedObject], or []any values.
+func setChild(parent any, sel spec.NormalSelector, val any) any {
+	switch sel := sel.(type) {
+	case spec.Name:
+		setMember(parent, string(sel), val)
+	case spec.Index:
+		if arr, ok := parent.([]any); ok {
+			arr[sel] = val
+		}
+	}
+	return parent
+}
+
+// Delete removes each value that p selects from input and returns the
+// modified input. Removes object members from input in place, but removing
+// array elements shortens the arrays, so always use the return value, which
+// contains the shortened arrays. Returns nil when p selects the root node
+// ($). Ignores nodes within other selected nodes, which the removal of the
+// enclosing node removes, too.
+func (p *Path) Delete(input any) any {
+	return mutate(p.SelectLocated(input), input, nil, func(parent any, sel spec.NormalSelector, _ any) any {
+		switch sel := sel.(type) {
+		case spec.Name:
+			switch obj := parent.(type) {
+			case map[string]any:
+				delete(obj, string(sel))
+			case *spec.OrderedObject:
+				obj.Delete(string(sel))
+			}
+		case spec.Index:
+			if arr, ok := parent.([]any); ok {
+				return slices.Delete(arr, int(sel), int(sel)+1)
+			}
+		}
+		return parent
+	})
+}
+
+// Insert inserts value before each array element that p selects from input
+// and returns the modified input. For selected object members, it replaces
+// the member's value, and when p selects the root node ($), it returns value
+// without modifying input, following the semantics of the [RFC 6902] add
+// operation. Inserting into arrays lengthens them, so always use the return
+// value. Like [Path.Set], every location receives the same value. Ignores
+// nodes within other selected nodes.
+//
+// [RFC 6902]: https://www.rfc-editor.org/rfc/rfc6902#section-4.1
+func (p *Path) Insert(input, value any) any {
+	fn := func(*spec.LocatedNode) any { return value }
+	return mutate(p.SelectLocated(input), input, fn, func(parent any, sel spec.NormalSelector, val any) any {
+		switch sel := sel.(type) {
+		case spec.Name:
+			setMember(parent, string(sel), val)
+		case spec.Index:
+			if arr, ok := parent.([]any); ok {
+				return slices.Insert(arr, int(sel), val)
+			}
+		}
+		return parent
+	})
+}
+
+// Redact replaces each value that any of paths selects from doc with
+// replacement and returns the modified doc, such as to mask personal data
+// in log records with a list of rules like $..password and $..ssn. The
+// queries select from doc in a single traversal, as with [MultiPath],
+// before Redact replaces any values, so that the results of one query do
+// not affect the others. Redact then replaces the selected values in a
+// single pass, once each, no matter how many queries select them, and
+// ignores values within other selected values, which the replacement of
+// the enclosing value supersedes. Modifies doc in place as described for
+// [Path.Set], and returns replacement if any path selects the root node
+// ($).
+func Redact(doc any, paths []*Path, replacement any) any {
+	var nodes LocatedNodeList
+	for _, selected := range NewMultiPath(paths...).SelectLocated(doc) {
+		nodes = append(nodes, selected...)
+	}
+	return mutate(nodes, doc, func(*spec.LocatedNode) any { return replacement }, setChild)
+}
+
+// mutate modifies input at the location of each node in nodes and returns
+// the modified input. For each node, it passes the node's parent, the final
+// selector in its normalized path, and the result of passing the node to fn
+// (or nil if fn is nil) to op, and stores the value op returns in place of
+// the parent. Modifies the nodes in descending normalized path order, so
+// that changes to array lengths leave the locations of the remaining nodes
+// intact, and ignores duplicate nodes and nodes within other nodes.
+func mutate(
+	nodes LocatedNodeList,
+	input any,
+	fn func(node *spec.LocatedNode) any,
+	op func(parent any, sel spec.NormalSelector, val any) any,
+) any {
+	for _, n := range mutationTargets(nodes) {
+		var val any
+		if fn != nil {
+			val = fn(n)
+		}
+		if len(n.Path) == 0 {
+			// The root node contains all other nodes, so it's the only target.
+			return val
+		}
+		input = mutateAt(input, n.Path, val, op)
+	}
+	return input
+}
+
+// mutateAt descends from val along path to the parent of the node it
+// identifies, passes the parent, the final selector in path, and v to op,
+// and stores the result in place of the parent. Returns the modified val.
+func mutateAt(
+	val any,
+	path spec.NormalizedPath,
+	v any,
+	op func(parent any, sel spec.NormalSelector, val any) any,
+) any {
+	if len(path) == 1 {
+		return op(val, path[0], v)
+	}
+
+	switch sel := path[0].(type) {
+	case spec.Name:
+		switch obj := val.(type) {
+		case map[string]any:
+			obj[string(sel)] = mutateAt(obj[string(sel)], path[1:], v, op)
+		case *spec.OrderedObject:
+			child, _ := obj.Get(string(sel))
+			obj.Set(string(sel), mutateAt(child, path[1:], v, op))
+		}
+	case spec.Index:
+		if arr, ok := val.([]any); ok {
+			arr[sel] = mutateAt(arr[sel], path[1:], v, op)
+		}
+	}
+	return val
+}
+
+// setMember sets the member of obj, a map[string]any or
+// [*spec.OrderedObject], named name to val.
+func setMember(obj any, name string, val any) {
+	switch obj := obj.(type) {
+	case map[string]any:
+		obj[name] = val
+	case *spec.OrderedObject:
+		obj.Set(name, val)
+	}
+}
+
+// mutationTargets returns the nodes for mutate to modify: a copy of nodes,
+// without duplicates or nodes within other nodes, sorted by descending
+// normalized path.
+func mutationTargets(nodes LocatedNodeList) LocatedNodeList {
+	sorted := nodes.Clone()
+	sorted.Sort()
+
+	// Ascending order sorts nodes within another node immediately after it.
+	res := sorted[:0]
+	for _, n := range sorted {
+		if len(res) > 0 && hasPathPrefix(n.Path, res[len(res)-1].Path) {
+			continue
+		}
+		res = append(res, n)
+	}
+
+	slices.Reverse(res)
+	return res
+}
+
+// hasPathPrefix returns true if path starts with prefix, including when
+// they're equal.
+func hasPathPrefix(path, prefix spec.NormalizedPath) bool {
+	return len(path) >= len(prefix) && slices.Equal(path[:len(prefix)], prefix)
+}
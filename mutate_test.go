@@ -0,0 +1,450 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath/spec"
+)
+
+func mutateDoc() any {
+	return map[string]any{
+		"a": []any{1, 2, 3, 4},
+		"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  any
+	}{
+		{
+			name: "nothing",
+			path: `$.nonesuch`,
+			exp:  mutateDoc(),
+		},
+		{
+			name: "member",
+			path: `$.o.x`,
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x": "hi", "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "indexes",
+			path: `$.a[0,-1,0]`,
+			exp: map[string]any{
+				"a": []any{"hi", 2, 3, "hi"},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "filter",
+			path: `$.a[?@ > 2]`,
+			exp: map[string]any{
+				"a": []any{1, 2, "hi", "hi"},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "nested",
+			path: `$.o..*`,
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x": "hi", "y": "hi"},
+			},
+		},
+		{
+			name: "root",
+			path: `$`,
+			exp:  "hi",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(tc.exp, MustParse(tc.path).Set(mutateDoc(), "hi"))
+		})
+	}
+}
+
+func TestSetFunc(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	doc := mutateDoc()
+	res := MustParse(`$..[?@ > 1]`).SetFunc(doc, func(n *spec.LocatedNode) any {
+		return n.Node.(int) * 10
+	})
+	a.Equal(map[string]any{
+		"a": []any{1, 20, 30, 40},
+		"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+	}, res)
+
+	// Modifies input in place.
+	a.Equal(res, doc)
+}
+
+func TestTransform(t *testing.T) {
+	t.Parallel()
+
+	double := func(node any) (any, error) {
+		if n, ok := node.(int); ok {
+			return n * 2, nil
+		}
+		return node, nil
+	}
+	// wrap wraps each value in an array, so that the results show the order
+	// in which Transform passes nested nodes to it.
+	wrap := func(node any) (any, error) { return []any{"w", node}, nil }
+	errOops := errors.New("oops")
+
+	for _, tc := range []struct {
+		name string
+		path string
+		fn   func(node any) (any, error)
+		exp  any
+		err  error
+	}{
+		{
+			name: "nothing",
+			path: `$.nonesuch`,
+			fn:   double,
+			exp:  mutateDoc(),
+		},
+		{
+			name: "descendants",
+			path: `$..[?@ > 2]`,
+			fn:   double,
+			exp: map[string]any{
+				"a": []any{1, 2, 6, 8},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "duplicates",
+			path: `$.a[0,0,-4]`,
+			fn:   double,
+			exp: map[string]any{
+				"a": []any{2, 2, 3, 4},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "nested",
+			path: `$.o..*`,
+			fn:   wrap,
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{
+					"x": []any{"w", true},
+					"y": []any{"w", []any{[]any{"w", "p"}, []any{"w", "q"}}},
+				},
+			},
+		},
+		{
+			name: "object",
+			path: `$..o`,
+			fn:   func(any) (any, error) { return "redacted", nil },
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": "redacted",
+			},
+		},
+		{
+			name: "array",
+			path: `$..y`,
+			fn:   wrap,
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x": true, "y": []any{"w", []any{"p", "q"}}},
+			},
+		},
+		{
+			name: "union",
+			path: `$["a", "o"][0]`,
+			fn:   wrap,
+			exp: map[string]any{
+				"a": []any{[]any{"w", 1}, 2, 3, 4},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "error",
+			path: `$.a[*]`,
+			fn: func(node any) (any, error) {
+				if node == 2 {
+					return nil, errOops
+				}
+				return node, nil
+			},
+			err: errOops,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			doc := mutateDoc()
+			res, err := MustParse(tc.path).Transform(doc, tc.fn)
+			if tc.err != nil {
+				a.ErrorIs(err, tc.err)
+				a.Nil(res)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tc.exp, res)
+			a.Equal(tc.exp, doc)
+		})
+	}
+
+	t.Run("root_last", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		res, err := MustParse(`$..*`).Transform([]any{1, []any{2}}, wrap)
+		a.NoError(err)
+		a.Equal([]any{[]any{"w", 1}, []any{"w", []any{[]any{"w", 2}}}}, res)
+
+		res, err = MustParse(`$`).Transform([]any{1}, wrap)
+		a.NoError(err)
+		a.Equal([]any{"w", []any{1}}, res)
+	})
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		paths []string
+		exp   any
+	}{
+		{
+			name: "no_paths",
+			exp:  mutateDoc(),
+		},
+		{
+			name:  "nothing",
+			paths: []string{`$.nonesuch`, `$..nope`},
+			exp:   mutateDoc(),
+		},
+		{
+			name:  "several",
+			paths: []string{`$.o.x`, `$.a[1]`},
+			exp: map[string]any{
+				"a": []any{1, "***", 3, 4},
+				"o": map[string]any{"x": "***", "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name:  "overlapping",
+			paths: []string{`$.a[?@ > 2]`, `$.a[-1]`, `$..[3]`},
+			exp: map[string]any{
+				"a": []any{1, 2, "***", "***"},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name:  "nested",
+			paths: []string{`$.o.y[0]`, `$.o`, `$..y`},
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": "***",
+			},
+		},
+		{
+			name:  "independent",
+			paths: []string{`$.o.y`, `$.o[?@ == "***"]`},
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x": true, "y": "***"},
+			},
+		},
+		{
+			name:  "root",
+			paths: []string{`$.a`, `$`},
+			exp:   "***",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			paths := make([]*Path, len(tc.paths))
+			for i, p := range tc.paths {
+				paths[i] = MustParse(p)
+			}
+			a.Equal(tc.exp, Redact(mutateDoc(), paths, "***"))
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  any
+	}{
+		{
+			name: "nothing",
+			path: `$.nonesuch`,
+			exp:  mutateDoc(),
+		},
+		{
+			name: "member",
+			path: `$.o.x`,
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "indexes",
+			path: `$.a[0,2,0]`,
+			exp: map[string]any{
+				"a": []any{2, 4},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "filter",
+			path: `$.a[?@ > 1]`,
+			exp: map[string]any{
+				"a": []any{1},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "nested_array",
+			path: `$.o.y[0]`,
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x": true, "y": []any{"q"}},
+			},
+		},
+		{
+			name: "descendants",
+			path: `$..*`,
+			exp:  map[string]any{},
+		},
+		{
+			name: "root",
+			path: `$`,
+			exp:  nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(tc.exp, MustParse(tc.path).Delete(mutateDoc()))
+		})
+	}
+
+	t.Run("root_array", func(t *testing.T) {
+		t.Parallel()
+		a.Equal([]any{"b"}, MustParse(`$[0,2]`).Delete([]any{"a", "b", "c"}))
+	})
+}
+
+func TestInsert(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  any
+	}{
+		{
+			name: "nothing",
+			path: `$.nonesuch`,
+			exp:  mutateDoc(),
+		},
+		{
+			name: "indexes",
+			path: `$.a[1,3,1]`,
+			exp: map[string]any{
+				"a": []any{1, "hi", 2, 3, "hi", 4},
+				"o": map[string]any{"x": true, "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "nested_array",
+			path: `$.o.y[-1]`,
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x": true, "y": []any{"p", "hi", "q"}},
+			},
+		},
+		{
+			name: "member",
+			path: `$.o.x`,
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x": "hi", "y": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "root",
+			path: `$`,
+			exp:  "hi",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(tc.exp, MustParse(tc.path).Insert(mutateDoc(), "hi"))
+		})
+	}
+
+	t.Run("root_array", func(t *testing.T) {
+		t.Parallel()
+		a.Equal([]any{"x", "a", "b"}, MustParse(`$[0]`).Insert([]any{"a", "b"}, "x"))
+	})
+}
+
+// orderedObject returns a *spec.OrderedObject with members, alternating
+// names and values.
+func orderedObject(members ...any) *spec.OrderedObject {
+	obj := &spec.OrderedObject{}
+	for i := 0; i < len(members); i += 2 {
+		obj.Set(members[i].(string), members[i+1])
+	}
+	return obj
+}
+
+func TestMutateOrdered(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	doc := func() any {
+		return orderedObject("z", orderedObject("x", 1, "y", 2), "a", []any{orderedObject("x", 3)})
+	}
+	toJSON := func(val any) string {
+		js, err := json.Marshal(val)
+		a.NoError(err)
+		return string(js)
+	}
+
+	a.Equal(`{"z":{"x":"hi","y":2},"a":[{"x":"hi"}]}`, toJSON(MustParse(`$..x`).Set(doc(), "hi")))
+	a.Equal(`{"z":{"y":2},"a":[{}]}`, toJSON(MustParse(`$..x`).Delete(doc())))
+	a.Equal(`{"z":{"x":1,"y":0},"a":[{"x":3}]}`, toJSON(MustParse(`$.z.y`).Insert(doc(), 0)))
+	a.Equal(`{"z":{"x":1,"y":2},"a":[{"x":3,"y":4}]}`, toJSON(MustParse(`$.a[0]`).SetFunc(doc(), func(n *spec.LocatedNode) any {
+		obj, _ := n.Node.(*spec.OrderedObject)
+		obj.Set("y", 4)
+		return obj
+	})))
+
+	// Ignore nodes in values that cannot be modified.
+	raw := map[string]any{"r": json.RawMessage(`{"x": 1}`), "s": json.RawMessage(`[[1]]`)}
+	a.Equal(raw, MustParse(`$.r.x`).Set(raw, 2))
+	a.Equal(raw, MustParse(`$.r.x`).Delete(raw))
+	a.Equal(raw, MustParse(`$.s[0][0]`).Set(raw, 2))
+	a.Equal(raw, MustParse(`$.s[0]`).Delete(raw))
+	a.Equal(raw, MustParse(`$.s[0]`).Insert(raw, 2))
+}
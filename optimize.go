@@ -0,0 +1,263 @@
+package jsonpath
+
+import (
+	"math"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Optimize returns a Path with a query rewritten for faster evaluation. The
+// returned Path selects the same nodes as p from any input, but its string
+// representation may differ. Optimize applies these rewrites:
+//
+//   - Filter expressions that compare literals, such as 1 == 1, and
+//     existence tests of queries without segments, such as @, fold into
+//     constants, as do the logical expressions that contain them. Filters
+//     that are always true become wildcards, and filters that are always
+//     false are removed.
+//   - Slices that can select no indexes, such as [1:1] or [::0], are
+//     removed, and slices that select a single index, such as [2:3], become
+//     index selectors.
+//   - A query with a segment left with no selectors can never select a
+//     node, and becomes $[:0] or, for a relative query, @[:0].
+//
+// Queries that consist solely of name and index selectors after rewriting
+// select nodes with direct lookups rather than by evaluating each segment.
+// Optimize does not remove selectors that select the same nodes as others
+// in a segment, such as the name in [*,"a"], because RFC 9535 requires the
+// segment to select those nodes more than once.
+func (p *Path) Optimize() *Path {
+	q, ok := optimizeQuery(p.q)
+	if !ok {
+		q = spec.Query(p.q.IsRoot(), []*spec.Segment{spec.Child(spec.Slice(0, 0))})
+	}
+	return New(q)
+}
+
+// truth represents the result of folding a logical expression.
+type truth uint8
+
+const (
+	// truthUnknown indicates an expression with a result that depends on
+	// the node it tests.
+	truthUnknown truth = iota
+
+	// truthTrue indicates an expression that is true for every node.
+	truthTrue
+
+	// truthFalse indicates an expression that is false for every node.
+	truthFalse
+)
+
+// not returns the negation of t.
+func (t truth) not() truth {
+	switch t {
+	case truthTrue:
+		return truthFalse
+	case truthFalse:
+		return truthTrue
+	default:
+		return truthUnknown
+	}
+}
+
+// optimizeQuery returns an optimized copy of q. Returns false if q can never
+// select a node.
+func optimizeQuery(q *spec.PathQuery) (*spec.PathQuery, bool) {
+	segs := make([]*spec.Segment, len(q.Segments()))
+	for i, seg := range q.Segments() {
+		sels := optimizeSelectors(seg.Selectors())
+		if len(sels) == 0 {
+			return nil, false
+		}
+		if seg.IsDescendant() {
+			segs[i] = spec.Descendant(sels...)
+		} else {
+			segs[i] = spec.Child(sels...)
+		}
+	}
+	return spec.Query(q.IsRoot(), segs), true
+}
+
+// optimizeSelectors returns optimized copies of sels, omitting those that
+// can never select a node.
+func optimizeSelectors(sels []spec.Selector) []spec.Selector {
+	res := make([]spec.Selector, 0, len(sels))
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case spec.SliceSelector:
+			if emptySlice(sel) {
+				continue
+			}
+			if idx, ok := sliceIndex(sel); ok {
+				res = append(res, idx)
+				continue
+			}
+			res = append(res, sel)
+		case *spec.FilterSelector:
+			lo, t := optimizeOr(sel.LogicalOr)
+			switch t {
+			case truthTrue:
+				res = append(res, spec.Wildcard())
+			case truthFalse:
+				continue
+			default:
+				res = append(res, spec.Filter(lo))
+			}
+		default:
+			res = append(res, sel)
+		}
+	}
+	return res
+}
+
+// emptySlice returns true if s selects no indexes from an array of any
+// length. Start and end indexes of different signs depend on the length of
+// the array, and so may select indexes.
+func emptySlice(s spec.SliceSelector) bool {
+	start, end := s.Start(), s.End()
+	switch {
+	case s.Step() == 0:
+		return true
+	case (start < 0) != (end < 0):
+		return false
+	case s.Step() > 0:
+		return start >= end
+	default:
+		return start <= end
+	}
+}
+
+// sliceIndex returns the index selector equivalent to s if s selects a
+// single index from an array of any length long enough to contain it.
+func sliceIndex(s spec.SliceSelector) (spec.Index, bool) {
+	start, end := s.Start(), s.End()
+	switch {
+	case s.Step() <= 0:
+		return 0, false
+	case start >= 0 && end == start+1:
+		return spec.Index(start), true
+	case start < 0 && (end == start+1 && end < 0 || start == -1 && end == math.MaxInt):
+		return spec.Index(start), true
+	default:
+		return 0, false
+	}
+}
+
+// optimizeOr returns an optimized copy of lo and whether it's constant.
+// Returns a nil LogicalOr when it's constant.
+func optimizeOr(lo spec.LogicalOr) (spec.LogicalOr, truth) {
+	res := make(spec.LogicalOr, 0, len(lo))
+	for _, la := range lo {
+		and, t := optimizeAnd(la)
+		switch t {
+		case truthTrue:
+			return nil, truthTrue
+		case truthFalse:
+			continue
+		default:
+			res = append(res, and)
+		}
+	}
+	if len(res) == 0 {
+		return nil, truthFalse
+	}
+	return res, truthUnknown
+}
+
+// optimizeAnd returns an optimized copy of la and whether it's constant.
+// Returns a nil LogicalAnd when it's constant.
+func optimizeAnd(la spec.LogicalAnd) (spec.LogicalAnd, truth) {
+	res := make(spec.LogicalAnd, 0, len(la))
+	for _, expr := range la {
+		e, t := optimizeExpr(expr)
+		switch t {
+		case truthFalse:
+			return nil, truthFalse
+		case truthTrue:
+			continue
+		default:
+			res = append(res, e)
+		}
+	}
+	if len(res) == 0 {
+		return nil, truthTrue
+	}
+	return res, truthUnknown
+}
+
+// optimizeExpr returns an optimized copy of expr and whether it's constant.
+// Returns a nil BasicExpr when it's constant.
+func optimizeExpr(expr spec.BasicExpr) (spec.BasicExpr, truth) {
+	switch expr := expr.(type) {
+	case *spec.ParenExpr:
+		lo, t := optimizeOr(expr.Inner())
+		switch {
+		case t != truthUnknown:
+			return nil, t
+		case len(lo) == 1 && len(lo[0]) == 1:
+			// Parentheses around a single expression are redundant.
+			return lo[0][0], truthUnknown
+		default:
+			return spec.Paren(lo), truthUnknown
+		}
+	case *spec.NotParenExpr:
+		lo, t := optimizeOr(expr.Inner())
+		if t != truthUnknown {
+			return nil, t.not()
+		}
+		return spec.NotParen(lo), truthUnknown
+	case *spec.ComparisonExpr:
+		_, lLit := expr.Left.(*spec.LiteralArg)
+		_, rLit := expr.Right.(*spec.LiteralArg)
+		if lLit && rLit {
+			// The comparison has the same result for every node.
+			if spec.Filter(spec.LogicalOr{{expr}}).Eval(nil, nil) {
+				return nil, truthTrue
+			}
+			return nil, truthFalse
+		}
+		return expr, truthUnknown
+	case *spec.MembershipExpr:
+		_, lLit := expr.Left.(*spec.LiteralArg)
+		_, rLit := expr.Right.(*spec.LiteralArg)
+		if lLit && rLit {
+			// The membership test has the same result for every node.
+			if spec.Filter(spec.LogicalOr{{expr}}).Eval(nil, nil) {
+				return nil, truthTrue
+			}
+			return nil, truthFalse
+		}
+		return expr, truthUnknown
+	case *spec.ExistExpr:
+		q, t := optimizeExistence(expr.PathQuery)
+		if t != truthUnknown {
+			return nil, t
+		}
+		return spec.Existence(q), truthUnknown
+	case *spec.NonExistExpr:
+		q, t := optimizeExistence(expr.PathQuery)
+		if t != truthUnknown {
+			return nil, t.not()
+		}
+		return spec.Nonexistence(q), truthUnknown
+	default:
+		// Function expressions may not be deterministic.
+		return expr, truthUnknown
+	}
+}
+
+// optimizeExistence returns an optimized copy of q, the query of an
+// existence test, and whether the test is constant: true if q has no
+// segments, and so selects the current or root node, or false if q can
+// never select a node.
+func optimizeExistence(q *spec.PathQuery) (*spec.PathQuery, truth) {
+	if len(q.Segments()) == 0 {
+		return nil, truthTrue
+	}
+	q, ok := optimizeQuery(q)
+	if !ok {
+		return nil, truthFalse
+	}
+	return q, truthUnknown
+}
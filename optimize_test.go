@@ -0,0 +1,260 @@
+package jsonpath
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/examples"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestOptimize(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	doc := map[string]any{
+		"a": []any{1, 2, 3, 4, 5},
+		"o": map[string]any{"x": []any{"p", "q"}, "y": map[string]any{"a": true}},
+	}
+	relative := NewParser(WithRelative())
+
+	for _, tc := range []struct {
+		name    string
+		path    *Path
+		exp     string
+		lookups []spec.Selector
+	}{
+		{
+			name: "unchanged",
+			path: MustParse(`$..a[*, 1:3, ?@ > 1]`),
+			exp:  `$..["a"][*,1:3,?@ > 1]`,
+		},
+		{
+			name: "duplicates",
+			path: MustParse(`$.o[*,"x"]`),
+			exp:  `$["o"][*,"x"]`,
+		},
+		{
+			name:    "slice_index",
+			path:    MustParse(`$.a[1:2]`),
+			exp:     `$["a"][1]`,
+			lookups: []spec.Selector{spec.Name("a"), spec.Index(1)},
+		},
+		{
+			name:    "slice_step_index",
+			path:    MustParse(`$.a[3:4:2]`),
+			exp:     `$["a"][3]`,
+			lookups: []spec.Selector{spec.Name("a"), spec.Index(3)},
+		},
+		{
+			name: "negative_slice_index",
+			path: MustParse(`$.a[-2:-1,-1:]`),
+			exp:  `$["a"][-2,-1]`,
+		},
+		{
+			name: "mixed_sign_slice",
+			path: MustParse(`$.a[-1:0,2:-1]`),
+			exp:  `$["a"][-1:0,2:-1]`,
+		},
+		{
+			name:    "empty_slices",
+			path:    MustParse(`$.a[1:1,::0,3:1,-1:-3,1:3:-1,0]`),
+			exp:     `$["a"][0]`,
+			lookups: []spec.Selector{spec.Name("a"), spec.Index(0)},
+		},
+		{
+			name: "true_filter",
+			path: MustParse(`$.a[?1 == 1]`),
+			exp:  `$["a"][*]`,
+		},
+		{
+			name: "true_membership",
+			path: NewParser(WithMembership()).MustParse(`$.a[?1 in [2, 1.0]]`),
+			exp:  `$["a"][*]`,
+		},
+		{
+			name:    "false_membership",
+			path:    NewParser(WithMembership()).MustParse(`$.a[?1 in [], 0]`),
+			exp:     `$["a"][0]`,
+			lookups: []spec.Selector{spec.Name("a"), spec.Index(0)},
+		},
+		{
+			name:    "false_filter",
+			path:    MustParse(`$.a[?1 == 2, 0]`),
+			exp:     `$["a"][0]`,
+			lookups: []spec.Selector{spec.Name("a"), spec.Index(0)},
+		},
+		{
+			name: "current_exists",
+			path: MustParse(`$.a[?@]`),
+			exp:  `$["a"][*]`,
+		},
+		{
+			name:    "current_not_exists",
+			path:    MustParse(`$.a[?!@, 1]`),
+			exp:     `$["a"][1]`,
+			lookups: []spec.Selector{spec.Name("a"), spec.Index(1)},
+		},
+		{
+			name: "and_true",
+			path: MustParse(`$.a[?@ > 2 && "x" == "x"]`),
+			exp:  `$["a"][?@ > 2]`,
+		},
+		{
+			name: "or_false",
+			path: MustParse(`$.a[?1 > 2 || @ > 2]`),
+			exp:  `$["a"][?@ > 2]`,
+		},
+		{
+			name: "or_true",
+			path: MustParse(`$.a[?@ > 2 || 1 < 2]`),
+			exp:  `$["a"][*]`,
+		},
+		{
+			name: "redundant_paren",
+			path: MustParse(`$.a[?(@ > 2)]`),
+			exp:  `$["a"][?@ > 2]`,
+		},
+		{
+			name: "paren",
+			path: MustParse(`$.a[?(@ > 2 || @ < 1 || true == false) && @ != 4]`),
+			exp:  `$["a"][?(@ > 2 || @ < 1) && @ != 4]`,
+		},
+		{
+			name: "not_paren",
+			path: MustParse(`$.a[?!(@ > 2 || null == null)]`),
+			exp:  `$[:0]`,
+		},
+		{
+			name: "not_paren_unknown",
+			path: MustParse(`$.a[?!(@ > 2 && 1 == 1)]`),
+			exp:  `$["a"][?!(@ > 2)]`,
+		},
+		{
+			name: "never_matching_subquery",
+			path: MustParse(`$..[?@.x[1:1]]`),
+			exp:  `$[:0]`,
+		},
+		{
+			name: "never_matching_nonexistence",
+			path: MustParse(`$.o[?!@.x[?1 == 0]]`),
+			exp:  `$["o"][*]`,
+		},
+		{
+			name: "subquery",
+			path: MustParse(`$.o[?@.x[0:1]]`),
+			exp:  `$["o"][?@["x"][0]]`,
+		},
+		{
+			name: "function",
+			path: MustParse(`$.o[?length(@) == 2 && 1 == 1]`),
+			exp:  `$["o"][?length(@) == 2]`,
+		},
+		{
+			name: "descendant_never",
+			path: MustParse(`$..*[?@.a && 1 == 2]`),
+			exp:  `$[:0]`,
+		},
+		{
+			name: "relative_never",
+			path: relative.MustParse(`@.a[?false == true]`),
+			exp:  `@[:0]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			opt := tc.path.Optimize()
+			a.Equal(tc.exp, opt.String())
+			a.Equal(tc.lookups, opt.lookups)
+			a.ElementsMatch(tc.path.Select(doc), opt.Select(doc))
+			a.ElementsMatch(
+				slices.Collect(tc.path.SelectLocated(doc).Paths()),
+				slices.Collect(opt.SelectLocated(doc).Paths()),
+			)
+
+			// The optimized query must parse to the same query.
+			reparsed, err := relative.Parse(opt.String())
+			require.NoError(t, err)
+			a.Equal(opt.String(), reparsed.String())
+		})
+	}
+}
+
+func TestOptimizeSlices(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Compare optimized slices with the originals for arrays of every length
+	// up to a few more than the slice bounds.
+	vals := []any{nil, -4, -3, -2, -1, 0, 1, 2, 3, 4}
+	arrays := make([][]any, 7)
+	for i := range arrays {
+		arrays[i] = make([]any, i)
+		for j := range i {
+			arrays[i][j] = j
+		}
+	}
+
+	for _, start := range vals {
+		for _, end := range vals {
+			for _, step := range []any{nil, -2, -1, 0, 1, 2} {
+				slice := spec.Slice(start, end, step)
+				p := New(spec.Query(true, []*spec.Segment{spec.Child(slice)}))
+				opt := p.Optimize()
+				for _, arr := range arrays {
+					a.Equal(p.Select(arr), opt.Select(arr), "%v => %v on %v", p, opt, arr)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkOptimize(b *testing.B) {
+	for _, bc := range []struct {
+		name  string
+		doc   string
+		query string
+	}{
+		{
+			name:  "constant_filter",
+			doc:   "geojson",
+			query: `$..[?@.type == "Point" || 1 == 1]`,
+		},
+		{
+			name:  "folded_filter",
+			doc:   "bookstore",
+			query: `$..*[?(@.price < 10 && "a" == "a") || 1 > 2]`,
+		},
+		{
+			name:  "slice_index",
+			doc:   "geojson",
+			query: `$..coordinates[0:1]`,
+		},
+		{
+			name:  "never_matches",
+			doc:   "geojson",
+			query: `$..*[?@.type && 1 == 0]`,
+		},
+	} {
+		doc, err := examples.Document(bc.doc)
+		require.NoError(b, err)
+		p := MustParse(bc.query)
+		opt := p.Optimize()
+
+		b.Run(bc.name+"/parsed", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = p.Select(doc)
+			}
+		})
+
+		b.Run(bc.name+"/optimized", func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				_ = opt.Select(doc)
+			}
+		})
+	}
+}
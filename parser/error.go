@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// ParseError describes a failure to parse a JSONPath query. [Parse] returns
+// all errors as *ParseError values, which unwrap to [ErrSyntax],
+// [ErrSemantic], or [ErrRelative], and therefore to [ErrPathParse]. Use
+// [errors.As] to access the position of the error in the query, e.g., to
+// highlight it in an editor:
+//
+//	var perr *parser.ParseError
+//	if errors.As(err, &perr) {
+//		highlight(perr.Position(), perr.Token())
+//	}
+type ParseError struct {
+	// class is ErrSyntax, ErrSemantic, or ErrRelative.
+	class error
+
+	// msg describes the error.
+	msg string
+
+	// pos is the zero-based byte offset of the error in the query, or -1
+	// for errors without a position.
+	pos int
+
+	// token is the text of the token at pos.
+	token string
+
+	// expected lists the tokens the parser expected at pos.
+	expected []string
+
+	// hint follows the position in the error message.
+	hint string
+}
+
+// newParseError creates and returns a ParseError of class for msg at tok's
+// position.
+func newParseError(class error, tok token, msg string, expected []string) *ParseError {
+	return &ParseError{
+		class:    class,
+		msg:      msg,
+		pos:      tok.pos,
+		token:    tokenText(tok),
+		expected: expected,
+	}
+}
+
+// Error returns the error message, including the position of the error.
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("%v: %v", e.class, e.msg)
+	if e.pos >= 0 {
+		msg += fmt.Sprintf(" at position %v", e.pos+1)
+	}
+	if e.hint != "" {
+		msg += ": " + e.hint
+	}
+	return msg
+}
+
+// Unwrap returns the class of e: [ErrSyntax], [ErrSemantic], or
+// [ErrRelative].
+func (e *ParseError) Unwrap() error {
+	return e.class
+}
+
+// Message returns the description of the error, without the "jsonpath"
+// prefix or position.
+func (e *ParseError) Message() string {
+	return e.msg
+}
+
+// Position returns the one-based byte offset of the error in the query, as
+// reported by [ParseError.Error]. Returns 0 for errors that apply to the
+// query as a whole, such as an empty query.
+func (e *ParseError) Position() int {
+	return e.pos + 1
+}
+
+// Token returns the text of the token at the position of the error, such
+// as "[", "foo", or "42". Returns string literals quoted as by [spec.Quote],
+// invalid escapes in string literals as written, such as `\x`, and an empty
+// string at the end of the query and for other tokens that could not be
+// scanned, such as unterminated strings.
+func (e *ParseError) Token() string {
+	return e.token
+}
+
+// Expected returns descriptions of the tokens the parser expected at the
+// position of the error, such as "]", "identifier", "string", or "integer",
+// or nil if the error is not due to an unexpected token, including lexical
+// errors such as invalid escapes.
+func (e *ParseError) Expected() []string {
+	return slices.Clone(e.expected)
+}
+
+// tokenText returns the text of tok for [ParseError.Token].
+func tokenText(tok token) string {
+	switch tok.tok {
+	case invalid, eof:
+		return ""
+	case goString:
+		return spec.Quote(tok.val)
+	case identifier, integer, number, blankSpace, boolTrue, boolFalse, jsonNull:
+		return tok.val
+	default:
+		return string(tok.tok)
+	}
+}
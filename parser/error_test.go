@@ -0,0 +1,238 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/registry"
+)
+
+func TestParseError(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name     string
+		path     string
+		opts     []Option
+		class    error
+		msg      string
+		pos      int
+		token    string
+		expected []string
+		err      string
+	}{
+		{
+			name:     "empty",
+			path:     "",
+			class:    ErrSyntax,
+			msg:      "unexpected end of input",
+			expected: []string{"$"},
+			err:      "jsonpath: unexpected end of input",
+		},
+		{
+			name:     "empty_relative",
+			path:     "",
+			opts:     []Option{WithRelative()},
+			class:    ErrSyntax,
+			msg:      "unexpected end of input",
+			expected: []string{"$", "@"},
+			err:      "jsonpath: unexpected end of input",
+		},
+		{
+			name:     "no_root",
+			path:     "x",
+			class:    ErrSyntax,
+			msg:      "unexpected identifier",
+			pos:      1,
+			token:    "x",
+			expected: []string{"$"},
+			err:      "jsonpath: unexpected identifier at position 1",
+		},
+		{
+			name:     "relative",
+			path:     "@.x",
+			class:    ErrRelative,
+			msg:      "unexpected '@'",
+			pos:      1,
+			token:    "@",
+			expected: []string{"$"},
+			err:      "jsonpath: unexpected '@' at position 1: relative queries are valid only in filter expressions unless parsed with the WithRelative option",
+		},
+		{
+			name:     "trailing",
+			path:     "$.a x",
+			class:    ErrSyntax,
+			msg:      "unexpected blank space",
+			pos:      4,
+			token:    " ",
+			expected: []string{".", "[", "end of input"},
+			err:      "jsonpath: unexpected blank space at position 4",
+		},
+		{
+			name:     "unclosed_bracket",
+			path:     "$[1",
+			class:    ErrSyntax,
+			msg:      "unexpected eof",
+			pos:      4,
+			expected: []string{",", "]"},
+			err:      "jsonpath: unexpected eof at position 4",
+		},
+		{
+			name:     "bad_selector",
+			path:     `$[{]`,
+			class:    ErrSyntax,
+			msg:      "unexpected '{'",
+			pos:      3,
+			token:    "{",
+			expected: []string{"?", "*", "string", "integer", ":"},
+			err:      "jsonpath: unexpected '{' at position 3",
+		},
+		{
+			name:     "dot_string",
+			path:     `$."x"`,
+			class:    ErrSyntax,
+			msg:      "unexpected string",
+			pos:      3,
+			token:    `"x"`,
+			expected: []string{"identifier", "*"},
+			err:      "jsonpath: unexpected string at position 3",
+		},
+		{
+			name:  "unterminated_string",
+			path:  `$["x`,
+			class: ErrSyntax,
+			msg:   "unterminated string literal",
+			pos:   5,
+			err:   "jsonpath: unterminated string literal at position 5",
+		},
+		{
+			name:  "invalid_escape",
+			path:  `$['\x']`,
+			class: ErrSyntax,
+			msg:   "invalid escape after backslash",
+			pos:   5,
+			token: `\x`,
+			err:   "jsonpath: invalid escape after backslash at position 5",
+		},
+		{
+			name:  "invalid_unicode_escape",
+			path:  `$[?@ == "a\uZZ"]`,
+			class: ErrSyntax,
+			msg:   "invalid escape after backslash",
+			pos:   13,
+			token: `\uZ`,
+			err:   "jsonpath: invalid escape after backslash at position 13",
+		},
+		{
+			name:  "invalid_number",
+			path:  `$[01]`,
+			class: ErrSyntax,
+			msg:   "invalid number literal",
+			pos:   3,
+			err:   "jsonpath: invalid number literal at position 3",
+		},
+		{
+			name:     "bad_comparison",
+			path:     `$[?@.x = 1]`,
+			class:    ErrSyntax,
+			msg:      "invalid comparison operator",
+			pos:      8,
+			token:    "=",
+			expected: []string{"==", "!=", "<", "<=", ">", ">="},
+			err:      "jsonpath: invalid comparison operator at position 8",
+		},
+		{
+			name:     "missing_comparison",
+			path:     `$[?length(@)]`,
+			class:    ErrSemantic,
+			msg:      "missing comparison to function result",
+			pos:      13,
+			token:    "]",
+			expected: []string{"==", "!=", "<", "<=", ">", ">="},
+			err:      "jsonpath: missing comparison to function result at position 13",
+		},
+		{
+			name:  "unknown_function",
+			path:  `$[?nope(@)]`,
+			class: ErrSemantic,
+			msg:   "unknown function nope()",
+			pos:   4,
+			token: "nope",
+			err:   "jsonpath: unknown function nope() at position 4",
+		},
+		{
+			name:     "single_pipe",
+			path:     `$[?@.a | @.b]`,
+			class:    ErrSyntax,
+			msg:      "expected '|' but found blank space",
+			pos:      9,
+			token:    " ",
+			expected: []string{"|"},
+			err:      "jsonpath: expected '|' but found blank space at position 9",
+		},
+		{
+			name:     "bad_comparable",
+			path:     `$[?@.a == [1]]`,
+			class:    ErrSyntax,
+			msg:      "unexpected '['",
+			pos:      11,
+			token:    "[",
+			expected: []string{"@", "$", "function", "string", "integer", "number", "true", "false", "null"},
+			err:      "jsonpath: unexpected '[' at position 11",
+		},
+		{
+			name:     "singular_wildcard",
+			path:     `$[?@.a == $[*]]`,
+			class:    ErrSyntax,
+			msg:      "unexpected '*'",
+			pos:      13,
+			token:    "*",
+			expected: []string{"string", "integer"},
+			err:      "jsonpath: unexpected '*' at position 13",
+		},
+		{
+			name:  "out_of_range",
+			path:  `$[9007199254740992]`,
+			class: ErrSemantic,
+			msg:   `cannot parse "9007199254740992", value out of range`,
+			pos:   3,
+			token: "9007199254740992",
+			err:   `jsonpath: cannot parse "9007199254740992", value out of range at position 3`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			_, err := Parse(reg, tc.path, tc.opts...)
+			r.EqualError(err, tc.err)
+			r.ErrorIs(err, tc.class)
+			r.ErrorIs(err, ErrPathParse)
+
+			var perr *ParseError
+			r.True(errors.As(err, &perr))
+			a.Equal(tc.class, perr.Unwrap())
+			a.Equal(tc.msg, perr.Message())
+			a.Equal(tc.pos, perr.Position())
+			a.Equal(tc.token, perr.Token())
+			a.Equal(tc.expected, perr.Expected())
+		})
+	}
+}
+
+func TestParseErrorExpectedCopy(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	_, err := Parse(registry.New(), "$[1")
+	var perr *ParseError
+	r.True(errors.As(err, &perr))
+	exp := perr.Expected()
+	exp[0] = "x"
+	a.Equal([]string{",", "]"}, perr.Expected())
+}
@@ -80,12 +80,13 @@ func (tok token) String() string {
 	return fmt.Sprintf("Token{%v, %q, %v}", tok.name(), tok.val, tok.pos)
 }
 
-// err returns an error for invalid tokens and nil for all other tokens.
+// err returns an error for invalid tokens and nil for all other tokens. The
+// error is formatted by [makeError] like all other syntax errors.
 func (tok token) err() error {
 	if tok.tok != invalid {
 		return nil
 	}
-	return fmt.Errorf("%w: %v at %v", ErrPathParse, tok.val, tok.pos)
+	return makeError(tok, tok.val)
 }
 
 // errToken creates and returns an error token.
@@ -120,11 +121,16 @@ type lexer struct {
 
 	// Last scanned token.
 	prev token
+
+	// errPos and errText are the position and text of the invalid escape
+	// that stopped the lexer, if any, for [ParseError.Token].
+	errPos  int
+	errText string
 }
 
 // newLexer creates a new lexer for the given input.
 func newLexer(buf string) *lexer {
-	lex := lexer{buf, -1, 0, 0, token{}}
+	lex := lexer{buf: buf, r: -1}
 
 	// Prime the lexer by calling .next
 	lex.next()
@@ -180,6 +186,13 @@ func (lex *lexer) next() rune {
 	return lex.r
 }
 
+// skip advances the lexer's internal state by n bytes from the position of
+// the current rune.
+func (lex *lexer) skip(n int) {
+	lex.nextPos = lex.rPos + n
+	lex.next()
+}
+
 // peek returns the next byte in the stream (the one after lex.r).
 // Note: a single byte is peeked at - if there's a rune longer than a byte
 // there, only its first byte is returned. Returns eof if there is no next
@@ -235,13 +248,17 @@ func (lex *lexer) peekPastBlankSpace() rune {
 
 // scanIdentifier scans an identifier, including shorthand names and
 // constants. lex.r should be the first rune in the identifier, and
-// isIdentRune(lex.r, 0) should have already returned true.
+// isIdentRune(lex.r, 0) should have already returned true, or it should be
+// $ followed by an identifier rune.
 func (lex *lexer) scanIdentifier() token {
 	buf := new(strings.Builder)
 	startPos := lex.rPos
 	escaped := false
 
-	// Scan the identifier as long as we have legit identifier runes.
+	// Scan the first rune, which may be $, and then as long as we have
+	// legit identifier runes.
+	buf.WriteRune(lex.r)
+	lex.next()
 	for isIdentRune(lex.r, 1) {
 		buf.WriteRune(lex.r)
 		lex.next()
@@ -383,9 +400,11 @@ NEXT:
 			buf.WriteRune(lex.r)
 			lex.next()
 		case lex.r == '\\':
+			escPos := lex.rPos
 			if !lex.writeEscape(q, buf) {
 				pos := lex.rPos
 				lex.next()
+				lex.errPos, lex.errText = pos, lex.buf[escPos:lex.rPos]
 				return lex.errToken(pos, "invalid escape after backslash")
 			}
 		default:
@@ -275,6 +275,11 @@ func TestScanIdentifier(t *testing.T) {
 			in:   `n\u0075ll`,
 			tok:  token{identifier, "n", 0},
 		},
+		{
+			name: "dollar",
+			in:   "$foo.bar",
+			tok:  token{identifier, "$foo", 0},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
@@ -776,7 +781,7 @@ func TestToken(t *testing.T) {
 			id:   "invalid",
 			tok:  token{invalid, "oops", 12},
 			str:  `Token{invalid, "oops", 12}`,
-			err:  "jsonpath: oops at 12",
+			err:  "jsonpath: oops at position 13",
 		},
 		{
 			name: "eof",
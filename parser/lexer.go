@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"iter"
+	"unicode/utf8"
+)
+
+// TokenKind identifies the kind of a [Token].
+type TokenKind int
+
+const (
+	// TokenInvalid tokens are lexical errors, such as unterminated strings
+	// and malformed numbers. Their values are error messages.
+	TokenInvalid TokenKind = iota
+
+	// TokenEOF marks the end of the query.
+	TokenEOF
+
+	// TokenBlankSpace tokens are runs of spaces, tabs, newlines, and
+	// carriage returns.
+	TokenBlankSpace
+
+	// TokenIdentifier tokens are member name shorthands, function names,
+	// and keywords such as in.
+	TokenIdentifier
+
+	// TokenString tokens are single- or double-quoted strings. Their values
+	// are the decoded strings.
+	TokenString
+
+	// TokenInteger tokens are integers, such as indexes and slice bounds.
+	TokenInteger
+
+	// TokenNumber tokens are numbers with fractions or exponents.
+	TokenNumber
+
+	// TokenTrue tokens are the literal true.
+	TokenTrue
+
+	// TokenFalse tokens are the literal false.
+	TokenFalse
+
+	// TokenNull tokens are the literal null.
+	TokenNull
+
+	// TokenSymbol tokens are punctuation and operators, such as $, @, [,
+	// .., ?, ==, and &&.
+	TokenSymbol
+)
+
+// String returns the name of k.
+func (k TokenKind) String() string {
+	switch k {
+	case TokenInvalid:
+		return "invalid"
+	case TokenEOF:
+		return "eof"
+	case TokenBlankSpace:
+		return "blank space"
+	case TokenIdentifier:
+		return "identifier"
+	case TokenString:
+		return "string"
+	case TokenInteger:
+		return "integer"
+	case TokenNumber:
+		return "number"
+	case TokenTrue:
+		return "true"
+	case TokenFalse:
+		return "false"
+	case TokenNull:
+		return "null"
+	case TokenSymbol:
+		return "symbol"
+	default:
+		return "unknown"
+	}
+}
+
+// Token is a lexical token scanned from a JSONPath query by a [Lexer].
+type Token struct {
+	// Kind identifies the kind of token.
+	Kind TokenKind
+
+	// Text is the text of the token in the query.
+	Text string
+
+	// Value is the decoded value of a [TokenString], the error message of
+	// a [TokenInvalid], and equal to Text for all other kinds of tokens.
+	Value string
+
+	// Pos is the zero-based byte offset of the start of the token in the
+	// query. Note that [ParseError.Position] is one-based.
+	Pos int
+
+	// End is the zero-based byte offset of the end of the token in the
+	// query, so that Text is query[Pos:End].
+	End int
+}
+
+// symbols lists the two-rune symbols that Lexer scans as single tokens.
+//
+//nolint:gochecknoglobals
+var symbols = []string{"..", "==", "!=", "<=", ">=", "&&", "||"}
+
+// Lexer scans an RFC 9535 JSONPath query into [Token] values, for use by
+// syntax highlighters and editor tooling. It scans the query incrementally,
+// without parsing it, so it scans invalid queries as far as it can, and
+// does not validate token sequences. Unlike [Parse], it scans member name
+// shorthands and keywords as identifiers regardless of context, and a
+// hyphen followed by a digit as part of a number, even where the parser
+// would parse the hyphen as the subtraction operator. After a
+// [TokenInvalid], it resumes scanning after the text of the invalid token.
+type Lexer struct {
+	lex *lexer
+}
+
+// NewLexer creates a new Lexer that scans query.
+func NewLexer(query string) *Lexer {
+	return &Lexer{newLexer(query)}
+}
+
+// Next scans and returns the next token. Returns a [TokenEOF] token at the
+// end of the query, and for every subsequent call.
+func (l *Lexer) Next() Token {
+	lex := l.lex
+	start := lex.rPos
+	switch {
+	case lex.r < 0:
+		return Token{Kind: TokenEOF, Pos: start, End: start}
+	case lex.r == '-' && !isDigit(lex.peek()):
+		// Subtraction operator rather than an invalid number.
+		lex.next()
+		return l.token(TokenSymbol, "", start)
+	}
+
+	tok := lex.scan()
+	switch tok.tok {
+	case invalid:
+		// Resume after the invalid text, but at least one rune later.
+		end := lex.rPos
+		if end <= start {
+			_, w := utf8.DecodeRuneInString(lex.buf[start:])
+			end = start + w
+		}
+		lex.skip(end - lex.rPos)
+		return l.token(TokenInvalid, tok.val, start)
+	case identifier:
+		return l.token(TokenIdentifier, "", start)
+	case goString:
+		return l.token(TokenString, tok.val, start)
+	case integer:
+		return l.token(TokenInteger, "", start)
+	case number:
+		return l.token(TokenNumber, "", start)
+	case blankSpace:
+		return l.token(TokenBlankSpace, "", start)
+	case boolTrue:
+		return l.token(TokenTrue, "", start)
+	case boolFalse:
+		return l.token(TokenFalse, "", start)
+	case jsonNull:
+		return l.token(TokenNull, "", start)
+	}
+
+	// Symbol; scan a second rune for two-rune symbols.
+	if lex.r >= 0 && lex.r < utf8.RuneSelf {
+		pair := string([]byte{byte(tok.tok), byte(lex.r)})
+		for _, sym := range symbols {
+			if pair == sym {
+				lex.next()
+				break
+			}
+		}
+	}
+	return l.token(TokenSymbol, "", start)
+}
+
+// token returns a Token of kind for the text of the query from start to the
+// current position, with value, or the text if value is empty.
+func (l *Lexer) token(kind TokenKind, value string, start int) Token {
+	text := l.lex.buf[start:l.lex.rPos]
+	if value == "" && kind != TokenString {
+		value = text
+	}
+	return Token{Kind: kind, Text: text, Value: value, Pos: start, End: l.lex.rPos}
+}
+
+// All returns an iterator over the remaining tokens, stopping before the
+// [TokenEOF].
+func (l *Lexer) All() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for tok := l.Next(); tok.Kind != TokenEOF; tok = l.Next() {
+			if !yield(tok) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexer(t *testing.T) {
+	t.Parallel()
+
+	// tok creates a Token with Value equal to text.
+	tok := func(kind TokenKind, text string, pos int) Token {
+		return Token{kind, text, text, pos, pos + len(text)}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		query  string
+		tokens []Token
+	}{
+		{
+			name:   "empty",
+			query:  "",
+			tokens: nil,
+		},
+		{
+			name:  "dotted",
+			query: "$.store..book[0]",
+			tokens: []Token{
+				tok(TokenSymbol, "$", 0),
+				tok(TokenSymbol, ".", 1),
+				tok(TokenIdentifier, "store", 2),
+				tok(TokenSymbol, "..", 7),
+				tok(TokenIdentifier, "book", 9),
+				tok(TokenSymbol, "[", 13),
+				tok(TokenInteger, "0", 14),
+				tok(TokenSymbol, "]", 15),
+			},
+		},
+		{
+			name:  "selectors",
+			query: `$[ 'a', "b\n", -1, 1:-2:3, *]`,
+			tokens: []Token{
+				tok(TokenSymbol, "$", 0),
+				tok(TokenSymbol, "[", 1),
+				tok(TokenBlankSpace, " ", 2),
+				{TokenString, `'a'`, "a", 3, 6},
+				tok(TokenSymbol, ",", 6),
+				tok(TokenBlankSpace, " ", 7),
+				{TokenString, `"b\n"`, "b\n", 8, 13},
+				tok(TokenSymbol, ",", 13),
+				tok(TokenBlankSpace, " ", 14),
+				tok(TokenInteger, "-1", 15),
+				tok(TokenSymbol, ",", 17),
+				tok(TokenBlankSpace, " ", 18),
+				tok(TokenInteger, "1", 19),
+				tok(TokenSymbol, ":", 20),
+				tok(TokenInteger, "-2", 21),
+				tok(TokenSymbol, ":", 23),
+				tok(TokenInteger, "3", 24),
+				tok(TokenSymbol, ",", 25),
+				tok(TokenBlankSpace, " ", 26),
+				tok(TokenSymbol, "*", 27),
+				tok(TokenSymbol, "]", 28),
+			},
+		},
+		{
+			name:  "filter",
+			query: `$[?@.a>=1.5e2&&!(@.b!=null)||length(@.c)==true]`,
+			tokens: []Token{
+				tok(TokenSymbol, "$", 0),
+				tok(TokenSymbol, "[", 1),
+				tok(TokenSymbol, "?", 2),
+				tok(TokenSymbol, "@", 3),
+				tok(TokenSymbol, ".", 4),
+				tok(TokenIdentifier, "a", 5),
+				tok(TokenSymbol, ">=", 6),
+				tok(TokenNumber, "1.5e2", 8),
+				tok(TokenSymbol, "&&", 13),
+				tok(TokenSymbol, "!", 15),
+				tok(TokenSymbol, "(", 16),
+				tok(TokenSymbol, "@", 17),
+				tok(TokenSymbol, ".", 18),
+				tok(TokenIdentifier, "b", 19),
+				tok(TokenSymbol, "!=", 20),
+				tok(TokenNull, "null", 22),
+				tok(TokenSymbol, ")", 26),
+				tok(TokenSymbol, "||", 27),
+				tok(TokenIdentifier, "length", 29),
+				tok(TokenSymbol, "(", 35),
+				tok(TokenSymbol, "@", 36),
+				tok(TokenSymbol, ".", 37),
+				tok(TokenIdentifier, "c", 38),
+				tok(TokenSymbol, ")", 39),
+				tok(TokenSymbol, "==", 40),
+				tok(TokenTrue, "true", 42),
+				tok(TokenSymbol, "]", 46),
+			},
+		},
+		{
+			name:  "arithmetic",
+			query: `@.a - @.b<false`,
+			tokens: []Token{
+				tok(TokenSymbol, "@", 0),
+				tok(TokenSymbol, ".", 1),
+				tok(TokenIdentifier, "a", 2),
+				tok(TokenBlankSpace, " ", 3),
+				tok(TokenSymbol, "-", 4),
+				tok(TokenBlankSpace, " ", 5),
+				tok(TokenSymbol, "@", 6),
+				tok(TokenSymbol, ".", 7),
+				tok(TokenIdentifier, "b", 8),
+				tok(TokenSymbol, "<", 9),
+				tok(TokenFalse, "false", 10),
+			},
+		},
+		{
+			name:  "non_ascii",
+			query: "$.été[#]",
+			tokens: []Token{
+				tok(TokenSymbol, "$", 0),
+				tok(TokenSymbol, ".", 1),
+				tok(TokenIdentifier, "été", 2),
+				tok(TokenSymbol, "[", 7),
+				tok(TokenSymbol, "#", 8),
+				tok(TokenSymbol, "]", 9),
+			},
+		},
+		{
+			name:  "invalid_number",
+			query: "$[01]",
+			tokens: []Token{
+				tok(TokenSymbol, "$", 0),
+				tok(TokenSymbol, "[", 1),
+				{TokenInvalid, "0", "invalid number literal", 2, 3},
+				tok(TokenInteger, "1", 3),
+				tok(TokenSymbol, "]", 4),
+			},
+		},
+		{
+			name:  "invalid_escape",
+			query: `$['a\x'].b`,
+			tokens: []Token{
+				tok(TokenSymbol, "$", 0),
+				tok(TokenSymbol, "[", 1),
+				{TokenInvalid, `'a\x`, "invalid escape after backslash", 2, 6},
+				{TokenInvalid, `'].b`, "unterminated string literal", 6, 10},
+			},
+		},
+		{
+			name:  "unterminated_string",
+			query: `$["a`,
+			tokens: []Token{
+				tok(TokenSymbol, "$", 0),
+				tok(TokenSymbol, "[", 1),
+				{TokenInvalid, `"a`, "unterminated string literal", 2, 4},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			lex := NewLexer(tc.query)
+			a.Equal(tc.tokens, slices.Collect(lex.All()))
+			for _, tok := range tc.tokens {
+				a.Equal(tok.Text, tc.query[tok.Pos:tok.End])
+			}
+
+			// Next returns EOF at the end and every subsequent call.
+			eof := Token{Kind: TokenEOF, Pos: len(tc.query), End: len(tc.query)}
+			a.Equal(eof, lex.Next())
+			a.Equal(eof, lex.Next())
+		})
+	}
+}
+
+func TestTokenKind(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for kind, name := range map[TokenKind]string{
+		TokenInvalid:    "invalid",
+		TokenEOF:        "eof",
+		TokenBlankSpace: "blank space",
+		TokenIdentifier: "identifier",
+		TokenString:     "string",
+		TokenInteger:    "integer",
+		TokenNumber:     "number",
+		TokenTrue:       "true",
+		TokenFalse:      "false",
+		TokenNull:       "null",
+		TokenSymbol:     "symbol",
+		TokenKind(99):   "unknown",
+	} {
+		a.Equal(name, kind.String())
+	}
+}
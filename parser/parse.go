@@ -7,58 +7,343 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/theory/jsonpath/registry"
 	"github.com/theory/jsonpath/spec"
 )
 
-// ErrPathParse errors are returned for path parse errors.
+// ErrPathParse errors are returned for path parse errors. All parse errors
+// wrap ErrPathParse, so use [errors.Is] with [ErrSyntax] or [ErrSemantic] to
+// distinguish the class of error.
 var ErrPathParse = errors.New("jsonpath")
 
-func makeError(tok token, msg string) error {
-	return fmt.Errorf("%w: %v at position %v", ErrPathParse, msg, tok.pos+1)
+// ErrSyntax errors are returned for syntax errors, such as unexpected tokens
+// or unterminated strings. ErrSyntax wraps [ErrPathParse].
+var ErrSyntax = fmt.Errorf("%w", ErrPathParse)
+
+// ErrSemantic errors are returned for syntactically well-formed queries that
+// are nevertheless invalid, such as unknown functions, function argument
+// count and type errors, and integers out of range. ErrSemantic wraps
+// [ErrPathParse].
+var ErrSemantic = fmt.Errorf("%w", ErrPathParse)
+
+// ErrRelative errors are returned for queries that start with @ outside a
+// filter expression when the parser is not configured with [WithRelative].
+// ErrRelative wraps [ErrSyntax].
+var ErrRelative = fmt.Errorf("%w", ErrSyntax)
+
+// ErrLimit errors are returned for queries that exceed the structural limits
+// configured by [WithMaxDepth] and [WithMaxSelectors]. ErrLimit wraps
+// [ErrSemantic].
+var ErrLimit = fmt.Errorf("%w", ErrSemantic)
+
+// makeError creates and returns an [ErrSyntax] [*ParseError] for msg at
+// tok's position, optionally listing the expected tokens.
+func makeError(tok token, msg string, expected ...string) error {
+	return newParseError(ErrSyntax, tok, msg, expected)
+}
+
+// makeSemanticError creates and returns an [ErrSemantic] [*ParseError] for
+// msg at tok's position.
+func makeSemanticError(tok token, msg string, expected ...string) error {
+	return newParseError(ErrSemantic, tok, msg, expected)
 }
 
-// unexpected creates and returns an error for an unexpected token. For
-// invalid tokens, the error will be as returned by the lexer. Otherwise, the
-// error will "unexpected: $name".
-func unexpected(tok token) error {
+// unexpected creates and returns an error for an unexpected token, listing
+// the expected tokens. For invalid tokens, the error will be as returned by
+// the lexer, without the expected tokens, since the lexer rather than the
+// parser failed. Otherwise, the error will "unexpected: $name".
+func unexpected(tok token, expected ...string) error {
 	if tok.tok == invalid {
 		// Lex error message in the token value.
 		return makeError(tok, tok.val)
 	}
-	return makeError(tok, "unexpected "+tok.name())
+	return makeError(tok, "unexpected "+tok.name(), expected...)
+}
+
+// Descriptions of the tokens expected at various points in a query, as
+// returned by [ParseError.Expected].
+var (
+	selectorTokens   = []string{"?", "*", "string", "integer", ":"}
+	basicExprTokens  = []string{"!", "(", "@", "$", "function", "string", "integer", "number", "true", "false", "null"}
+	argTokens        = []string{"@", "$", "function", "string", "integer", "number", "true", "false", "null", "!", "(", ")"}
+	comparableTokens = []string{"@", "$", "function", "string", "integer", "number", "true", "false", "null"}
+	compOpTokens     = []string{"==", "!=", "<", "<=", ">", ">="}
+	jsonValueTokens  = []string{"string", "integer", "number", "true", "false", "null", "[", "{"}
+)
+
+// startTokens returns the tokens expected at the start of a query: $, or $
+// and @ if relative.
+func startTokens(relative bool) []string {
+	if relative {
+		return []string{"$", "@"}
+	}
+	return []string{"$"}
 }
 
 type parser struct {
 	lex *lexer
 	reg *registry.Registry
+
+	// positions indicates whether to record selector positions.
+	positions bool
+
+	// relative indicates whether to allow relative top-level queries.
+	relative bool
+
+	// lenient indicates whether to accept legacy, non-RFC 9535 syntax.
+	lenient bool
+
+	// composite indicates whether to accept array and object literals in
+	// comparisons.
+	composite bool
+
+	// arithmetic indicates whether to accept arithmetic expressions in
+	// comparisons.
+	arithmetic bool
+
+	// membership indicates whether to accept membership tests with the in
+	// operator.
+	membership bool
+
+	// selectors maps the prefix runes of custom selectors to their parsers.
+	selectors map[rune]SelectorParser
+
+	// maxDepth is the maximum nesting depth of expressions, or 0 for no
+	// limit.
+	maxDepth int
+
+	// maxSelectors is the maximum number of selectors, or 0 for no limit.
+	maxSelectors int
+
+	// depth is the nesting depth of the expression being parsed.
+	depth int
+
+	// numSelectors is the number of selectors parsed so far.
+	numSelectors int
+
+	// dotLength is true when the most recently parsed query ended with the
+	// .length pseudo-property.
+	dotLength bool
+}
+
+// Option defines a parser option.
+type Option func(*parser)
+
+// WithPositions configures the parser to record the positions of selectors
+// in the query string where supported, e.g., via [spec.WildcardAt], for use
+// in diagnostics. Position metadata does not affect query evaluation.
+func WithPositions() Option {
+	return func(p *parser) { p.positions = true }
+}
+
+// WithRelative configures the parser to accept top-level relative queries
+// that start with @, for evaluation against a current node, e.g., by
+// template engines that evaluate snippets relative to a context value. By
+// default, and as required by RFC 9535, @ is valid only within filter
+// expressions.
+func WithRelative() Option {
+	return func(p *parser) { p.relative = true }
+}
+
+// WithLenient configures the parser to accept common constructs from the
+// original [Goessner JSONPath] that RFC 9535 does not allow, to ease the
+// migration of queries written for other implementations:
+//
+//   - Unquoted names in bracket notation, e.g., $[foo], parse as name
+//     selectors, as for $['foo'].
+//   - A single equals sign compares for equality, e.g., @.x = 1 parses as
+//     @.x == 1.
+//   - The .length pseudo-property at the end of a singular query compared in
+//     a filter expression parses as a call to the length() function, e.g.,
+//     @.tags.length > 2 parses as length(@.tags) > 2.
+//
+// Parenthesized filter expressions, e.g., $[?(@.x)], are valid RFC 9535
+// syntax and need no lenient mode. Lenient parsing produces the same parse
+// tree as the equivalent RFC 9535 query, so that the String method of the
+// resulting query returns RFC 9535 syntax.
+//
+// [Goessner JSONPath]: https://goessner.net/articles/JsonPath/
+func WithLenient() Option {
+	return func(p *parser) { p.lenient = true }
+}
+
+// WithCompositeLiterals configures the parser to accept JSON array and
+// object literals as the operands of comparisons in filter expressions,
+// such as @.tags == ["a", "b"] and @.point != {"x": 0, "y": 0}, which RFC
+// 9535 does not allow. Array and object literals may contain any literals,
+// including nested arrays and objects, and compare deeply equal to array
+// and object values. Names and strings may use single or double quotation
+// marks, as elsewhere in JSONPath.
+func WithCompositeLiterals() Option {
+	return func(p *parser) { p.composite = true }
+}
+
+// WithArithmetic configures the parser to accept arithmetic expressions as
+// the operands of comparisons in filter expressions, such as
+// @.price * @.qty > 100 and @.a + 1 == @.b, which RFC 9535 does not allow.
+// Supports the binary operators +, -, *, /, and %, where *, /, and % bind
+// more tightly than + and -, operators of the same precedence associate to
+// the left, and parentheses group operations, as in (@.a + 1) * 2. The
+// operands may be any comparable values: literals, singular queries, and
+// function expressions that return values. Arithmetic on values other than
+// numbers produces Nothing; see [spec.ArithmeticExpr] for the numeric
+// coercion rules.
+func WithArithmetic() Option {
+	return func(p *parser) { p.arithmetic = true }
+}
+
+// WithMembership configures the parser to accept membership tests with the
+// in operator in filter expressions, such as @.status in ["active",
+// "pending"], which RFC 9535 does not allow. The left operand may be any
+// comparable value, and the right operand an array literal, which may
+// contain any JSON values, or a singular query or function expression that
+// produces an array. The test is true if the left value equals an element
+// of the array, and parses into a [spec.MembershipExpr].
+func WithMembership() Option {
+	return func(p *parser) { p.membership = true }
+}
+
+// WithMaxDepth configures the parser to reject queries that nest filter
+// selectors, parenthesized expressions, function expressions, and array and
+// object literals more than depth levels deep, as in $[?(@[?@.a])], which
+// nests three levels deep, with an [ErrLimit] error. Use WithMaxDepth to
+// reject pathological queries from untrusted sources before parsing them in
+// full. A depth less than 1 imposes no limit.
+func WithMaxDepth(depth int) Option {
+	return func(p *parser) { p.maxDepth = max(depth, 0) }
+}
+
+// WithMaxSelectors configures the parser to reject queries with more than
+// count selectors, including the selectors of queries in filter
+// expressions, with an [ErrLimit] error. Use WithMaxSelectors to reject
+// costly queries from untrusted sources before parsing them in full. A
+// count less than 1 imposes no limit.
+func WithMaxSelectors(count int) Option {
+	return func(p *parser) { p.maxSelectors = max(count, 0) }
+}
+
+// enter records entry into a nested expression that starts with tok, and
+// returns an [ErrLimit] error if it exceeds p.maxDepth. Call leave on
+// leaving the expression if enter returns no error.
+func (p *parser) enter(tok token) error {
+	if p.maxDepth > 0 && p.depth >= p.maxDepth {
+		return newParseError(ErrLimit, tok, fmt.Sprintf(
+			"query exceeds maximum nesting depth of %d", p.maxDepth,
+		), nil)
+	}
+	p.depth++
+	return nil
+}
+
+// leave records leaving a nested expression entered by enter.
+func (p *parser) leave() {
+	p.depth--
+}
+
+// countSelector counts a selector that starts at pos, and returns an
+// [ErrLimit] error if the count exceeds p.maxSelectors.
+func (p *parser) countSelector(pos int) error {
+	p.numSelectors++
+	if p.maxSelectors > 0 && p.numSelectors > p.maxSelectors {
+		tok := token{p.lex.r, "", pos}
+		return newParseError(ErrLimit, tok, fmt.Sprintf(
+			"query exceeds maximum of %d selectors", p.maxSelectors,
+		), nil)
+	}
+	return nil
+}
+
+// SelectorParser parses a custom selector from src, the rest of the query
+// string starting at the selector's prefix rune. It returns the selector and
+// the number of bytes of src it consumed, which must include the prefix
+// rune. The parser resumes at the first unconsumed byte, where it expects
+// optional blank space followed by a comma or closing bracket. Errors
+// returned by a SelectorParser become [ErrSyntax] errors at the position of
+// the prefix rune.
+type SelectorParser func(src string) (spec.Selector, int, error)
+
+// WithSelector configures the parser to pass bracketed selectors that start
+// with prefix to parse, to support custom selectors implemented outside this
+// module, such as $[#] to select the names of object members. See
+// [spec.Selector] for the requirements of custom selectors. Panics if prefix
+// starts a standard selector or blank space, or if prefix is a comma, a
+// square bracket, or a rune valid in member name shorthands.
+func WithSelector(prefix rune, parse SelectorParser) Option {
+	if reservedSelectorPrefix(prefix) {
+		panic(fmt.Sprintf("jsonpath: reserved selector prefix %q", prefix))
+	}
+	return func(p *parser) {
+		if p.selectors == nil {
+			p.selectors = map[rune]SelectorParser{}
+		}
+		p.selectors[prefix] = parse
+	}
+}
+
+// reservedSelectorPrefix returns true if r cannot prefix a custom selector
+// because it starts standard syntax within a bracketed selection.
+func reservedSelectorPrefix(r rune) bool {
+	switch r {
+	case '?', '*', ':', '-', '\'', '"', ',', '[', ']', '$', '@', '\t', '\n', '\r', ' ':
+		return true
+	}
+	return r < 0 || isDigit(r) || isIdentRune(r, 0)
 }
 
 // Parse parses path, a JSON Path query string, into a PathQuery. Returns a
-// PathParseError on parse failure.
-func Parse(reg *registry.Registry, path string) (*spec.PathQuery, error) {
-	lex := newLexer(path)
-	tok := lex.scan()
-	p := parser{lex, reg}
+// [*ParseError] on parse failure.
+func Parse(reg *registry.Registry, path string, opt ...Option) (*spec.PathQuery, error) {
+	p := parser{lex: newLexer(path), reg: reg}
+	for _, o := range opt {
+		o(&p)
+	}
 
-	switch tok.tok {
-	case '$':
-		// All path queries must start with $.
-		q, err := p.parseQuery(true)
+	q, err := p.parsePath()
+	if err != nil {
+		// Report the text of an invalid escape as the token of its error.
+		var perr *ParseError
+		if p.lex.errText != "" && errors.As(err, &perr) && perr.pos == p.lex.errPos {
+			perr.token = p.lex.errText
+		}
+		return nil, err
+	}
+	return q, nil
+}
+
+// parsePath parses a complete query from p.lex.
+func (p *parser) parsePath() (*spec.PathQuery, error) {
+	lex := p.lex
+	tok := lex.scan()
+	switch {
+	case tok.tok == '$' || (tok.tok == '@' && p.relative):
+		// All path queries must start with $, or @ when relative.
+		q, err := p.parseQuery(tok.tok == '$')
 		if err != nil {
 			return nil, err
 		}
 		// Should have scanned to the end of input.
 		if lex.r != eof {
-			return nil, unexpected(lex.scan())
+			return nil, unexpected(lex.scan(), ".", "[", "end of input")
 		}
 		return q, nil
-	case eof:
+	case tok.tok == eof:
 		// The token contained nothing.
-		return nil, fmt.Errorf("%w: unexpected end of input", ErrPathParse)
+		return nil, &ParseError{
+			class:    ErrSyntax,
+			msg:      "unexpected end of input",
+			pos:      -1,
+			expected: startTokens(p.relative),
+		}
+	case tok.tok == '@':
+		// A common mistake: explain how to parse relative queries.
+		err := newParseError(ErrRelative, tok, "unexpected '@'", startTokens(false))
+		err.hint = "relative queries are valid only in filter expressions unless parsed with the WithRelative option"
+		return nil, err
 	default:
-		return nil, unexpected(tok)
+		return nil, unexpected(tok, startTokens(p.relative)...)
 	}
 }
 
@@ -67,9 +352,11 @@ func Parse(reg *registry.Registry, path string) (*spec.PathQuery, error) {
 func (p *parser) parseQuery(root bool) (*spec.PathQuery, error) {
 	segs := []*spec.Segment{}
 	lex := p.lex
+	dotLength := false
 	for {
 		switch {
 		case lex.r == '[':
+			dotLength = false
 			// Start of segment; scan selectors
 			lex.scan()
 			selectors, err := p.parseSelectors()
@@ -88,14 +375,19 @@ func (p *parser) parseQuery(root bool) (*spec.PathQuery, error) {
 					return nil, err
 				}
 				segs = append(segs, seg)
+				dotLength = false
 				continue
 			}
 			// Child segment with a name or wildcard selector.
-			sel, err := parseNameOrWildcard(lex)
+			if err := p.countSelector(lex.rPos); err != nil {
+				return nil, err
+			}
+			sel, err := p.parseNameOrWildcard()
 			if err != nil {
 				return nil, err
 			}
 			segs = append(segs, spec.Child(sel))
+			dotLength = sel == spec.Name("length")
 		case lex.isBlankSpace(lex.r):
 			switch lex.peekPastBlankSpace() {
 			case '.', '[':
@@ -105,6 +397,7 @@ func (p *parser) parseQuery(root bool) (*spec.PathQuery, error) {
 			fallthrough
 		default:
 			// Done parsing.
+			p.dotLength = dotLength
 			return spec.Query(root, segs), nil
 		}
 	}
@@ -112,20 +405,34 @@ func (p *parser) parseQuery(root bool) (*spec.PathQuery, error) {
 
 // parseNameOrWildcard parses a name or '*' wildcard selector. Returns the
 // parsed Selector.
-func parseNameOrWildcard(lex *lexer) (spec.Selector, error) {
-	switch tok := lex.scan(); tok.tok {
+func (p *parser) parseNameOrWildcard() (spec.Selector, error) {
+	switch tok := p.lex.scan(); tok.tok {
 	case identifier:
 		return spec.Name(tok.val), nil
 	case '*':
-		return spec.Wildcard, nil
+		return p.wildcard(tok), nil
 	default:
-		return nil, unexpected(tok)
+		return nil, unexpected(tok, "identifier", "*")
 	}
 }
 
+// wildcard returns a wildcard selector for tok, recording its position if
+// p.positions is true.
+func (p *parser) wildcard(tok token) spec.WildcardSelector {
+	if p.positions {
+		return spec.WildcardAt(tok.pos + 1)
+	}
+	return spec.Wildcard()
+}
+
 // parseDescendant parses a ".." descendant segment, which may be a bracketed
 // segment or a wildcard or name selector segment. Returns the parsed Segment.
 func (p *parser) parseDescendant() (*spec.Segment, error) {
+	if p.lex.r != '[' {
+		if err := p.countSelector(p.lex.rPos); err != nil {
+			return nil, err
+		}
+	}
 	switch tok := p.lex.scan(); tok.tok {
 	case '[':
 		// Start of segment; scan selectors
@@ -137,20 +444,22 @@ func (p *parser) parseDescendant() (*spec.Segment, error) {
 	case identifier:
 		return spec.Descendant(spec.Name(tok.val)), nil
 	case '*':
-		return spec.Descendant(spec.Wildcard), nil
+		return spec.Descendant(p.wildcard(tok)), nil
 	default:
-		return nil, unexpected(tok)
+		return nil, unexpected(tok, "[", "identifier", "*")
 	}
 }
 
-// makeNumErr converts strconv.NumErrors to jsonpath errors.
+// makeNumErr converts strconv.NumErrors to jsonpath errors. Range errors
+// are [ErrSemantic] errors; all others are [ErrSyntax] errors.
 func makeNumErr(tok token, err error) error {
 	var numError *strconv.NumError
 	if errors.As(err, &numError) {
-		return makeError(tok, fmt.Sprintf(
-			"cannot parse %q, %v",
-			numError.Num, numError.Err.Error(),
-		))
+		msg := fmt.Sprintf("cannot parse %q, %v", numError.Num, numError.Err.Error())
+		if errors.Is(numError.Err, strconv.ErrRange) {
+			return makeSemanticError(tok, msg)
+		}
+		return makeError(tok, msg)
 	}
 	return makeError(tok, err.Error())
 }
@@ -161,47 +470,15 @@ func (p *parser) parseSelectors() ([]spec.Selector, error) {
 	selectors := []spec.Selector{}
 	lex := p.lex
 	for {
-		switch tok := lex.scan(); tok.tok {
-		case '?':
-			filter, err := p.parseFilter()
-			if err != nil {
-				return nil, err
-			}
-			selectors = append(selectors, filter)
-		case '*':
-			selectors = append(selectors, spec.Wildcard)
-		case goString:
-			selectors = append(selectors, spec.Name(tok.val))
-		case integer:
-			// Index or slice?
-			if lex.skipBlankSpace() == ':' {
-				// Slice.
-				slice, err := parseSlice(lex, tok)
-				if err != nil {
-					return nil, err
-				}
-				selectors = append(selectors, slice)
-			} else {
-				// Index.
-				idx, err := parsePathInt(tok)
-				if err != nil {
-					return nil, err
-				}
-				selectors = append(selectors, spec.Index(idx))
-			}
-		case ':':
-			// Slice.
-			slice, err := parseSlice(lex, tok)
-			if err != nil {
-				return nil, err
-			}
-			selectors = append(selectors, slice)
-		case blankSpace:
-			// Skip.
-			continue
-		default:
-			return nil, unexpected(tok)
+		lex.skipBlankSpace()
+		if err := p.countSelector(lex.rPos); err != nil {
+			return nil, err
+		}
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
 		}
+		selectors = append(selectors, sel)
 
 		// Successfully parsed a selector. What's next?
 		switch lex.skipBlankSpace() {
@@ -214,11 +491,73 @@ func (p *parser) parseSelectors() ([]spec.Selector, error) {
 			return selectors, nil
 		default:
 			// Anything else is an error.
-			return nil, unexpected(lex.scan())
+			return nil, unexpected(lex.scan(), ",", "]")
+		}
+	}
+}
+
+// parseSelector parses a single selector from a bracket segment, starting at
+// the current rune, which should not be blank space. Dispatches to the
+// SelectorParser registered for the current rune, if any.
+func (p *parser) parseSelector() (spec.Selector, error) {
+	lex := p.lex
+	if parse, ok := p.selectors[lex.r]; ok {
+		return p.parseCustomSelector(parse)
+	}
+
+	switch tok := lex.scan(); tok.tok {
+	case '?':
+		return p.parseFilter()
+	case '*':
+		return p.wildcard(tok), nil
+	case goString:
+		return spec.Name(tok.val), nil
+	case identifier, boolTrue, boolFalse, jsonNull:
+		// Unquoted name.
+		if !p.lenient {
+			return nil, unexpected(tok, selectorTokens...)
+		}
+		return spec.Name(tok.val), nil
+	case integer:
+		// Index or slice?
+		if lex.skipBlankSpace() == ':' {
+			// Slice.
+			return parseSlice(lex, tok)
 		}
+		// Index.
+		idx, err := parsePathInt(tok)
+		if err != nil {
+			return nil, err
+		}
+		return spec.Index(idx), nil
+	case ':':
+		// Slice.
+		return parseSlice(lex, tok)
+	default:
+		return nil, unexpected(tok, selectorTokens...)
 	}
 }
 
+// parseCustomSelector passes the rest of the query, starting at the current
+// rune, to parse, and advances the lexer past the bytes it consumed.
+func (p *parser) parseCustomSelector(parse SelectorParser) (spec.Selector, error) {
+	lex := p.lex
+	tok := token{lex.r, "", lex.rPos}
+	src := lex.buf[lex.rPos:]
+	sel, n, err := parse(src)
+	if err != nil {
+		return nil, makeError(tok, err.Error())
+	}
+	if sel == nil || n < 1 || n > len(src) {
+		return nil, makeError(tok, fmt.Sprintf(
+			"invalid %q selector: parser consumed %d of %d bytes",
+			tok.tok, n, len(src),
+		))
+	}
+	lex.skip(n)
+	return sel, nil
+}
+
 // parsePathInt parses an integer as used in index values and steps, which must be
 // within the interval [-(253)+1, (253)-1].
 func parsePathInt(tok token) (int64, error) {
@@ -236,7 +575,7 @@ func parsePathInt(tok token) (int64, error) {
 		maxVal = 1<<53 - 1
 	)
 	if idx > maxVal || idx < minVal {
-		return 0, makeError(tok, fmt.Sprintf(
+		return 0, makeSemanticError(tok, fmt.Sprintf(
 			"cannot parse %q, value out of range",
 			tok.val,
 		))
@@ -265,25 +604,33 @@ func parseSlice(lex *lexer, tok token) (spec.SliceSelector, error) {
 			args[i] = int(num)
 		default:
 			// Nothing else allowed.
-			return spec.SliceSelector{}, unexpected(tok)
+			return spec.SliceSelector{}, unexpected(tok, ":", "integer")
 		}
 
 		// What's next?
 		next := lex.skipBlankSpace()
 		if next == ']' || next == ',' {
 			// We've reached the end.
-			return spec.Slice(args[0], args[1], args[2]), nil
+			slice, err := spec.TrySlice(args[0], args[1], args[2])
+			if err != nil {
+				return slice, makeError(tok, err.Error())
+			}
+			return slice, nil
 		}
 		tok = lex.scan()
 	}
 
 	// Never found the end of the slice.
-	return spec.SliceSelector{}, unexpected(tok)
+	return spec.SliceSelector{}, unexpected(tok, "]", ",")
 }
 
 // parseFilter parses a [Filter] from Lex. A [Filter] consists of a single
 // [LogicalOrExpr] (logical-or-expr).
 func (p *parser) parseFilter() (*spec.FilterSelector, error) {
+	if err := p.enter(p.lex.prev); err != nil {
+		return nil, err
+	}
+	defer p.leave()
 	lor, err := p.parseLogicalOrExpr()
 	if err != nil {
 		return nil, err
@@ -311,7 +658,7 @@ func (p *parser) parseLogicalOrExpr() (spec.LogicalOr, error) {
 		lex.scan()
 		next := lex.scan()
 		if next.tok != '|' {
-			return nil, makeError(next, fmt.Sprintf("expected '|' but found %v", next.name()))
+			return nil, makeError(next, fmt.Sprintf("expected '|' but found %v", next.name()), "|")
 		}
 		land, err := p.parseLogicalAndExpr()
 		if err != nil {
@@ -341,7 +688,7 @@ func (p *parser) parseLogicalAndExpr() (spec.LogicalAnd, error) {
 		lex.scan()
 		next := lex.scan()
 		if next.tok != '&' {
-			return nil, makeError(next, fmt.Sprintf("expected '&' but found %v", next.name()))
+			return nil, makeError(next, fmt.Sprintf("expected '&' but found %v", next.name()), "&")
 		}
 		expr, err := p.parseBasicExpr()
 		if err != nil {
@@ -383,6 +730,10 @@ func (p *parser) parseBasicExpr() (spec.BasicExpr, error) {
 		// test-expr or comparison-expr
 		return p.parseNonExistExpr(next)
 	case '(':
+		if left := p.tryParenArith(tok); left != nil {
+			// comparison-expr
+			return p.parseComparison(left)
+		}
 		return p.parseParenExpr()
 	case goString, integer, number, boolFalse, boolTrue, jsonNull:
 		// comparison-expr
@@ -390,7 +741,17 @@ func (p *parser) parseBasicExpr() (spec.BasicExpr, error) {
 		if err != nil {
 			return nil, err
 		}
-		return p.parseComparableExpr(left)
+		return p.parseComparison(left)
+	case '[', '{':
+		if !p.composite {
+			break
+		}
+		// comparison-expr
+		left, err := p.parseCompositeLiteral(tok)
+		if err != nil {
+			return nil, err
+		}
+		return p.parseComparison(left)
 	case identifier:
 		if lex.r == '(' {
 			return p.parseFunctionFilterExpr(tok)
@@ -402,16 +763,23 @@ func (p *parser) parseBasicExpr() (spec.BasicExpr, error) {
 		}
 
 		if sing := q.Singular(); sing != nil {
-			switch lex.skipBlankSpace() {
+			switch next := lex.skipBlankSpace(); {
 			// comparison-expr
-			case '=', '!', '<', '>':
-				return p.parseComparableExpr(sing)
+			case next == '=', next == '!', next == '<', next == '>', p.isArithOp(next), p.atIn():
+				if p.lenient && p.dotLength {
+					f, err := p.pseudoLength(tok, sing)
+					if err != nil {
+						return nil, err
+					}
+					return p.parseComparison(f)
+				}
+				return p.parseComparison(sing)
 			}
 		}
 		return spec.Existence(q), nil
 	}
 
-	return nil, unexpected(tok)
+	return nil, unexpected(tok, basicExprTokens...)
 }
 
 // parseFunctionFilterExpr parses a [BasicExpr] (basic-expr) that starts with
@@ -431,13 +799,13 @@ func (p *parser) parseFunctionFilterExpr(ident token) (spec.BasicExpr, error) {
 		return f, nil
 	}
 
-	switch p.lex.skipBlankSpace() {
-	case '=', '!', '<', '>':
+	switch next := p.lex.skipBlankSpace(); {
+	case next == '=', next == '!', next == '<', next == '>', p.isArithOp(next), p.atIn():
 		// comparison-expr
-		return p.parseComparableExpr(f)
+		return p.parseComparison(f)
 	}
 
-	return nil, makeError(p.lex.scan(), "missing comparison to function result")
+	return nil, makeSemanticError(p.lex.scan(), "missing comparison to function result", compOpTokens...)
 }
 
 // parseNonExistExpr parses a [spec.NonExistExpr] (non-existence) from lex.
@@ -463,6 +831,10 @@ func (p *parser) parseFilterQuery(tok token) (*spec.PathQuery, error) {
 // return the next token after '(' from scan(). Returns an error if the
 // expression does not end with a closing ')'.
 func (p *parser) parseInnerParenExpr() (spec.LogicalOr, error) {
+	if err := p.enter(p.lex.prev); err != nil {
+		return nil, err
+	}
+	defer p.leave()
 	expr, err := p.parseLogicalOrExpr()
 	if err != nil {
 		return nil, err
@@ -472,7 +844,7 @@ func (p *parser) parseInnerParenExpr() (spec.LogicalOr, error) {
 	next := p.lex.scan()
 	if next.tok != ')' {
 		return nil, makeError(
-			next, fmt.Sprintf("expected ')' but found %v", next.name()),
+			next, fmt.Sprintf("expected ')' but found %v", next.name()), ")",
 		)
 	}
 
@@ -509,17 +881,46 @@ func (p *parser) parseNotParenExpr() (*spec.NotParenExpr, error) {
 func (p *parser) parseFunction(tok token) (*spec.FunctionExpr, error) {
 	function := p.reg.Get(tok.val)
 	if function == nil {
-		return nil, makeError(tok, fmt.Sprintf("unknown function %v()", tok.val))
+		return nil, makeSemanticError(tok, fmt.Sprintf("unknown function %v()", tok.val))
+	}
+
+	if err := p.enter(tok); err != nil {
+		return nil, err
 	}
+	defer p.leave()
 
 	paren := p.lex.scan() // Drop (
-	args, err := p.parseFunctionArgs()
+	args, starts, err := p.parseFunctionArgs()
 	if err != nil {
 		return nil, err
 	}
 
 	if err := function.Validate(args); err != nil {
-		return nil, makeError(paren, fmt.Sprintf("function %v() %v", tok.val, err.Error()))
+		// Report errors for specific arguments at their positions.
+		at := paren
+		var argErr *registry.ArgError
+		if errors.As(err, &argErr) && argErr.Index >= 0 && argErr.Index < len(starts) {
+			at = starts[argErr.Index]
+		}
+		return nil, makeSemanticError(at, fmt.Sprintf("function %v() %v", tok.val, err.Error()))
+	}
+
+	return spec.Function(function, args), nil
+}
+
+// pseudoLength converts sq, a singular query starting at tok and ending with
+// the .length pseudo-property, to a call to the length() function with the
+// rest of sq as its argument. Used only by lenient parsers.
+func (p *parser) pseudoLength(tok token, sq *spec.SingularQueryExpr) (*spec.FunctionExpr, error) {
+	function := p.reg.Get("length")
+	if function == nil {
+		return nil, makeSemanticError(tok, "unknown function length()")
+	}
+
+	sels := sq.Selectors()
+	args := []spec.FunctionExprArg{spec.SingularQuery(sq.IsRoot(), sels[:len(sels)-1])}
+	if err := function.Validate(args); err != nil {
+		return nil, makeSemanticError(tok, "function length() "+err.Error())
 	}
 
 	return spec.Function(function, args), nil
@@ -527,35 +928,41 @@ func (p *parser) parseFunction(tok token) (*spec.FunctionExpr, error) {
 
 // parseFunctionArgs parses the comma-delimited arguments to a function from
 // lex. Arguments may be one of literal, filter-query (including
-// singular-query), logical-expr, or function-expr.
-func (p *parser) parseFunctionArgs() ([]spec.FunctionExprArg, error) {
+// singular-query), logical-expr, or function-expr. Also returns the first
+// token of each argument, for reporting errors at its position.
+func (p *parser) parseFunctionArgs() ([]spec.FunctionExprArg, []token, error) {
 	res := []spec.FunctionExprArg{}
+	starts := []token{}
 	lex := p.lex
 	for {
-		switch tok := p.lex.scan(); tok.tok {
+		tok := p.lex.scan()
+		if tok.tok != blankSpace && tok.tok != ')' {
+			starts = append(starts, tok)
+		}
+		switch tok.tok {
 		case goString, integer, number, boolFalse, boolTrue, jsonNull:
 			// literal
 			val, err := parseLiteral(tok)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			res = append(res, val)
 		case '@', '$':
 			// filter-query
 			q, err := p.parseFilterQuery(tok)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			res = append(res, q.Expression())
 		case identifier:
 			// function-expr
 			if p.lex.skipBlankSpace() != '(' {
-				return nil, unexpected(tok)
+				return nil, nil, unexpected(tok, argTokens...)
 			}
 			f, err := p.parseFunction(tok)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			res = append(res, f)
 		case blankSpace:
@@ -563,11 +970,11 @@ func (p *parser) parseFunctionArgs() ([]spec.FunctionExprArg, error) {
 			continue
 		case ')':
 			// All done.
-			return res, nil
+			return res, starts, nil
 		case '!', '(':
 			ors, err := p.parseLogicalOrExpr()
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 			res = append(res, ors)
 		}
@@ -580,10 +987,10 @@ func (p *parser) parseFunctionArgs() ([]spec.FunctionExprArg, error) {
 		case ')':
 			// Consume and return.
 			lex.scan()
-			return res, nil
+			return res, starts, nil
 		default:
 			// Anything else is an error.
-			return nil, unexpected(lex.scan())
+			return nil, nil, unexpected(lex.scan(), ",", ")")
 		}
 	}
 }
@@ -618,13 +1025,120 @@ func parseLiteral(tok token) (*spec.LiteralArg, error) {
 	}
 }
 
+// parseCompositeLiteral parses the array or object literal that starts
+// with tok, which must be '[' or '{', into a [spec.LiteralArg]. Requires
+// [WithCompositeLiterals].
+func (p *parser) parseCompositeLiteral(tok token) (*spec.LiteralArg, error) {
+	val, err := p.parseJSONValue(tok)
+	if err != nil {
+		return nil, err
+	}
+	return spec.Literal(val), nil
+}
+
+// parseJSONValue parses the literal, array, or object that starts with tok
+// into a native Go value: a []any for an array, a map[string]any for an
+// object, or a value as returned by [parseLiteral].
+func (p *parser) parseJSONValue(tok token) (any, error) {
+	switch tok.tok {
+	case goString, integer, number, boolFalse, boolTrue, jsonNull:
+		lit, err := parseLiteral(tok)
+		if err != nil {
+			return nil, err
+		}
+		return lit.Value(), nil
+	case '[':
+		return p.parseJSONArray()
+	case '{':
+		return p.parseJSONObject()
+	default:
+		return nil, unexpected(tok, jsonValueTokens...)
+	}
+}
+
+// parseJSONArray parses the elements of an array literal from lex, which
+// should be positioned after the opening '['.
+func (p *parser) parseJSONArray() ([]any, error) {
+	if err := p.enter(p.lex.prev); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	lex := p.lex
+	arr := []any{}
+	if lex.skipBlankSpace() == ']' {
+		lex.scan()
+		return arr, nil
+	}
+	for {
+		lex.skipBlankSpace()
+		val, err := p.parseJSONValue(lex.scan())
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, val)
+
+		lex.skipBlankSpace()
+		switch next := lex.scan(); next.tok {
+		case ',':
+			continue
+		case ']':
+			return arr, nil
+		default:
+			return nil, unexpected(next, ",", "]")
+		}
+	}
+}
+
+// parseJSONObject parses the members of an object literal from lex, which
+// should be positioned after the opening '{'. As when decoding JSON, the
+// last of duplicate names wins.
+func (p *parser) parseJSONObject() (map[string]any, error) {
+	if err := p.enter(p.lex.prev); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	lex := p.lex
+	obj := map[string]any{}
+	if lex.skipBlankSpace() == '}' {
+		lex.scan()
+		return obj, nil
+	}
+	for {
+		lex.skipBlankSpace()
+		name := lex.scan()
+		if name.tok != goString {
+			return nil, unexpected(name, "string")
+		}
+		lex.skipBlankSpace()
+		if colon := lex.scan(); colon.tok != ':' {
+			return nil, unexpected(colon, ":")
+		}
+		lex.skipBlankSpace()
+		val, err := p.parseJSONValue(lex.scan())
+		if err != nil {
+			return nil, err
+		}
+		obj[name.val] = val
+
+		lex.skipBlankSpace()
+		switch next := lex.scan(); next.tok {
+		case ',':
+			continue
+		case '}':
+			return obj, nil
+		default:
+			return nil, unexpected(next, ",", "}")
+		}
+	}
+}
+
 // parseComparableExpr parses a [ComparisonExpr] (comparison-expr) from lex.
 func (p *parser) parseComparableExpr(left spec.CompVal) (*spec.ComparisonExpr, error) {
 	// Skip blank space.
 	lex := p.lex
 	lex.skipBlankSpace()
 
-	op, err := parseCompOp(lex)
+	op, err := p.parseCompOp()
 	if err != nil {
 		return nil, err
 	}
@@ -640,35 +1154,247 @@ func (p *parser) parseComparableExpr(left spec.CompVal) (*spec.ComparisonExpr, e
 	return spec.Comparison(left, op, right), nil
 }
 
-// parseComparableVal parses a [CompVal] (comparable) from lex.
+// parseComparableVal parses a [CompVal] (comparable) from lex, including
+// an arithmetic expression if p accepts arithmetic.
 func (p *parser) parseComparableVal(tok token) (spec.CompVal, error) {
+	val, err := p.parseOperand(tok)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseArithExpr(val)
+}
+
+// parseOperand parses a single [CompVal] (comparable) from lex, or a
+// parenthesized arithmetic expression if p accepts arithmetic.
+func (p *parser) parseOperand(tok token) (spec.CompVal, error) {
 	switch tok.tok {
 	case goString, integer, number, boolFalse, boolTrue, jsonNull:
 		// literal
 		return parseLiteral(tok)
+	case '[', '{':
+		if !p.composite {
+			return nil, unexpected(tok, comparableTokens...)
+		}
+		return p.parseCompositeLiteral(tok)
 	case '@', '$':
 		// singular-query
-		return parseSingularQuery(tok, p.lex)
+		sing, err := p.parseSingularQuery(tok)
+		if err != nil {
+			return nil, err
+		}
+		if p.lenient && p.dotLength {
+			return p.pseudoLength(tok, sing)
+		}
+		return sing, nil
 	case identifier:
 		// function-expr
 		if p.lex.r != '(' {
-			return nil, unexpected(tok)
+			return nil, unexpected(tok, comparableTokens...)
 		}
 		f, err := p.parseFunction(tok)
 		if err != nil {
 			return nil, err
 		}
 		if f.ResultType() == spec.FuncLogical {
-			return nil, makeError(tok, "cannot compare result of logical function")
+			return nil, makeSemanticError(tok, "cannot compare result of logical function")
 		}
 		return f, nil
+	case '(':
+		if !p.arithmetic {
+			return nil, unexpected(tok, comparableTokens...)
+		}
+		return p.parseParenArith()
 	default:
-		return nil, unexpected(tok)
+		return nil, unexpected(tok, comparableTokens...)
+	}
+}
+
+// parseComparison parses the rest of an arithmetic expression that starts
+// with left, if p accepts arithmetic, followed by the rest of a
+// [spec.ComparisonExpr] (comparison-expr), or of a [spec.MembershipExpr] if
+// p accepts membership tests and the in operator follows.
+func (p *parser) parseComparison(left spec.CompVal) (spec.BasicExpr, error) {
+	left, err := p.parseArithExpr(left)
+	if err != nil {
+		return nil, err
+	}
+	if p.atIn() {
+		return p.parseMembershipExpr(left)
 	}
+	return p.parseComparableExpr(left)
 }
 
-// parseCompOp pares a [CompOp] (comparison-op) from lex.
-func parseCompOp(lex *lexer) (spec.CompOp, error) {
+// atIn skips blank space and returns true if p accepts membership tests and
+// lex.r starts the in operator.
+func (p *parser) atIn() bool {
+	if !p.membership {
+		return false
+	}
+	lex := p.lex
+	lex.skipBlankSpace()
+	rest := lex.buf[lex.rPos:]
+	if !strings.HasPrefix(rest, "in") {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(rest[2:])
+	return !isIdentRune(r, 1)
+}
+
+// parseMembershipExpr parses the in operator at lex.r and the array
+// operand that follows it, and returns a [spec.MembershipExpr] that tests
+// whether left is a member of the array.
+func (p *parser) parseMembershipExpr(left spec.CompVal) (*spec.MembershipExpr, error) {
+	lex := p.lex
+	lex.scan() // Drop in
+	lex.skipBlankSpace()
+
+	var right spec.CompVal
+	var err error
+	switch tok := lex.scan(); tok.tok {
+	case '[':
+		right, err = p.parseCompositeLiteral(tok)
+	case '@', '$', identifier, '(':
+		right, err = p.parseComparableVal(tok)
+	default:
+		err = unexpected(tok, "[", "@", "$", "function")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return spec.Membership(left, right), nil
+}
+
+// parseArithExpr parses the rest of an arithmetic expression that starts
+// with left, a sum of terms joined by + or -. Returns left unchanged if p
+// does not accept arithmetic or no arithmetic operator follows left.
+func (p *parser) parseArithExpr(left spec.CompVal) (spec.CompVal, error) {
+	left, err := p.parseArithTerm(left)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekArithOp()
+		if !ok || (op != spec.Add && op != spec.Subtract) {
+			return left, nil
+		}
+		right, err := p.parseArithOperand()
+		if err != nil {
+			return nil, err
+		}
+		if right, err = p.parseArithTerm(right); err != nil {
+			return nil, err
+		}
+		left = spec.Arithmetic(left, op, right)
+	}
+}
+
+// parseArithTerm parses the rest of an arithmetic term that starts with
+// left, a product of operands joined by *, /, or %.
+func (p *parser) parseArithTerm(left spec.CompVal) (spec.CompVal, error) {
+	for {
+		op, ok := p.peekArithOp()
+		if !ok || (op != spec.Multiply && op != spec.Divide && op != spec.Modulo) {
+			return left, nil
+		}
+		right, err := p.parseArithOperand()
+		if err != nil {
+			return nil, err
+		}
+		left = spec.Arithmetic(left, op, right)
+	}
+}
+
+// parseArithOperand consumes the arithmetic operator at lex.r and parses
+// the operand that follows it.
+func (p *parser) parseArithOperand() (spec.CompVal, error) {
+	lex := p.lex
+	// Consume the operator with next() rather than scan(), which would scan
+	// - as the start of a negative number.
+	lex.next()
+	lex.skipBlankSpace()
+	return p.parseOperand(lex.scan())
+}
+
+// peekArithOp skips blank space and returns the arithmetic operator at
+// lex.r, without consuming it. Returns false if p does not accept
+// arithmetic or lex.r is not an arithmetic operator.
+func (p *parser) peekArithOp() (spec.ArithOp, bool) {
+	if !p.arithmetic {
+		return 0, false
+	}
+	switch p.lex.skipBlankSpace() {
+	case '+':
+		return spec.Add, true
+	case '-':
+		return spec.Subtract, true
+	case '*':
+		return spec.Multiply, true
+	case '/':
+		return spec.Divide, true
+	case '%':
+		return spec.Modulo, true
+	default:
+		return 0, false
+	}
+}
+
+// isArithOp returns true if p accepts arithmetic and r is an arithmetic
+// operator.
+func (p *parser) isArithOp(r rune) bool {
+	return p.arithmetic && strings.ContainsRune("+-*/%", r)
+}
+
+// parseParenArith parses a parenthesized arithmetic expression from lex,
+// which should return the next token after '(' from scan().
+func (p *parser) parseParenArith() (spec.CompVal, error) {
+	if err := p.enter(p.lex.prev); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	lex := p.lex
+	lex.skipBlankSpace()
+	val, err := p.parseComparableVal(lex.scan())
+	if err != nil {
+		return nil, err
+	}
+	lex.skipBlankSpace()
+	if next := lex.scan(); next.tok != ')' {
+		return nil, makeError(
+			next, fmt.Sprintf("expected ')' but found %v", next.name()), ")",
+		)
+	}
+	return val, nil
+}
+
+// tryParenArith attempts to parse the parenthesized expression started by
+// tok, a '(' token, as an arithmetic expression followed by a comparison
+// operator or the in operator, as in (@.a + 1) * 2 > 3, and returns the
+// arithmetic expression. Otherwise it restores lex and returns nil, so that
+// the caller can parse the parenthesized expression as a logical
+// expression (paren-expr).
+func (p *parser) tryParenArith(tok token) spec.CompVal {
+	if !p.arithmetic {
+		return nil
+	}
+	saved, numSelectors := *p.lex, p.numSelectors
+	val, err := p.parseOperand(tok)
+	if err == nil {
+		val, err = p.parseArithExpr(val)
+	}
+	if err == nil {
+		switch next := p.lex.skipBlankSpace(); {
+		case next == '=', next == '!', next == '<', next == '>', p.atIn():
+			return val
+		}
+	}
+	*p.lex, p.numSelectors = saved, numSelectors
+	return nil
+}
+
+// parseCompOp pares a [CompOp] (comparison-op) from lex. Lenient parsers
+// also accept a single '=' for [spec.EqualTo].
+func (p *parser) parseCompOp() (spec.CompOp, error) {
+	lex := p.lex
 	tok := lex.scan()
 	switch tok.tok {
 	case '=':
@@ -676,6 +1402,9 @@ func parseCompOp(lex *lexer) (spec.CompOp, error) {
 			lex.scan()
 			return spec.EqualTo, nil
 		}
+		if p.lenient {
+			return spec.EqualTo, nil
+		}
 	case '!':
 		if lex.r == '=' {
 			lex.scan()
@@ -695,22 +1424,34 @@ func parseCompOp(lex *lexer) (spec.CompOp, error) {
 		return spec.GreaterThan, nil
 	}
 
-	return 0, makeError(tok, "invalid comparison operator")
+	return 0, makeError(tok, "invalid comparison operator", compOpTokens...)
 }
 
 // parseSingularQuery parses a [spec.SingularQueryExpr] (singular-query) from
 // lex. A singular query consists only of single-selector nodes.
-func parseSingularQuery(startToken token, lex *lexer) (*spec.SingularQueryExpr, error) {
+func (p *parser) parseSingularQuery(startToken token) (*spec.SingularQueryExpr, error) {
 	selectors := []spec.Selector{}
+	lex := p.lex
+	p.dotLength = false
 	for {
 		switch lex.r {
 		case '[':
 			// Index or name selector.
+			p.dotLength = false
 			lex.skipBlankSpace()
 			lex.scan()
+			if err := p.countSelector(lex.rPos); err != nil {
+				return nil, err
+			}
 			switch tok := lex.scan(); tok.tok {
 			case goString:
 				selectors = append(selectors, spec.Name(tok.val))
+			case identifier, boolTrue, boolFalse, jsonNull:
+				// Unquoted name.
+				if !p.lenient {
+					return nil, unexpected(tok, "string", "integer")
+				}
+				selectors = append(selectors, spec.Name(tok.val))
 			case integer:
 				idx, err := parsePathInt(tok)
 				if err != nil {
@@ -718,22 +1459,26 @@ func parseSingularQuery(startToken token, lex *lexer) (*spec.SingularQueryExpr,
 				}
 				selectors = append(selectors, spec.Index(idx))
 			default:
-				return nil, unexpected(tok)
+				return nil, unexpected(tok, "string", "integer")
 			}
 			// Look for closing bracket.
 			lex.skipBlankSpace()
 			tok := lex.scan()
 			if tok.tok != ']' {
-				return nil, unexpected(tok)
+				return nil, unexpected(tok, "]")
 			}
 		case '.':
 			// Start of a name selector.
 			lex.scan()
+			if err := p.countSelector(lex.rPos); err != nil {
+				return nil, err
+			}
 			tok := lex.scan()
 			if tok.tok != identifier {
-				return nil, unexpected(tok)
+				return nil, unexpected(tok, "identifier")
 			}
 			selectors = append(selectors, spec.Name(tok.val))
+			p.dotLength = tok.val == "length"
 		default:
 			// Done parsing.
 			return spec.SingularQuery(startToken.tok == '$', selectors), nil
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"testing"
 
@@ -22,6 +23,993 @@ func TestParseRoot(t *testing.T) {
 	a.Empty(q.Segments())
 }
 
+func TestParseWithPositions(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	reg := registry.New()
+
+	q, err := Parse(reg, "$.*[*]..*", WithPositions())
+	r.NoError(err)
+	a.Equal(
+		spec.Query(true, []*spec.Segment{
+			spec.Child(spec.WildcardAt(3)),
+			spec.Child(spec.WildcardAt(5)),
+			spec.Descendant(spec.WildcardAt(9)),
+		}),
+		q,
+	)
+
+	// Same string and selection as without positions.
+	plain, err := Parse(reg, "$.*[*]..*")
+	r.NoError(err)
+	a.Equal(plain.String(), q.String())
+	for i, seg := range q.Segments() {
+		sel := seg.Selectors()[0]
+		w, ok := sel.(spec.WildcardSelector)
+		r.True(ok)
+		a.True(w.Equal(plain.Segments()[i].Selectors()[0]))
+	}
+	input := []any{[]any{1, 2}, []any{3}}
+	a.Equal(plain.Select(nil, input), q.Select(nil, input))
+}
+
+func TestParseWithRelative(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  *spec.PathQuery
+		err  string
+	}{
+		{
+			name: "current",
+			path: "@",
+			exp:  spec.Query(false, []*spec.Segment{}),
+		},
+		{
+			name: "relative_name",
+			path: "@.x[0]",
+			exp: spec.Query(false, []*spec.Segment{
+				spec.Child(spec.Name("x")),
+				spec.Child(spec.Index(0)),
+			}),
+		},
+		{
+			name: "relative_filter",
+			path: "@[?@.x == $.y]",
+			exp: spec.Query(false, []*spec.Segment{
+				spec.Child(spec.Filter(spec.LogicalOr{spec.LogicalAnd{
+					spec.Comparison(
+						spec.SingularQuery(false, []spec.Selector{spec.Name("x")}),
+						spec.EqualTo,
+						spec.SingularQuery(true, []spec.Selector{spec.Name("y")}),
+					),
+				}})),
+			}),
+		},
+		{
+			name: "root_still_valid",
+			path: "$.x",
+			exp:  spec.Query(true, []*spec.Segment{spec.Child(spec.Name("x"))}),
+		},
+		{
+			name: "trailing_garbage",
+			path: "@.x x",
+			err:  "jsonpath: unexpected blank space at position 4",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			q, err := Parse(reg, tc.path, WithRelative())
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				return
+			}
+			r.NoError(err)
+			a.Equal(tc.exp, q)
+
+			// Strict mode rejects relative queries.
+			if tc.path[0] == '@' {
+				_, err = Parse(reg, tc.path)
+				r.EqualError(err, "jsonpath: unexpected '@' at position 1: relative queries are valid only in filter expressions unless parsed with the WithRelative option")
+				r.ErrorIs(err, ErrRelative)
+			}
+		})
+	}
+}
+
+func TestParseWithLenient(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		rfc    string
+		strict string
+		err    string
+	}{
+		{
+			name:   "unquoted_name",
+			path:   "$[foo]",
+			rfc:    `$["foo"]`,
+			strict: "jsonpath: unexpected identifier at position 3",
+		},
+		{
+			name:   "unquoted_names",
+			path:   "$[foo, 'bar', 1, true].x[null]",
+			rfc:    `$["foo","bar",1,"true"]["x"]["null"]`,
+			strict: "jsonpath: unexpected identifier at position 3",
+		},
+		{
+			name:   "unquoted_descendant",
+			path:   "$..[foo]",
+			rfc:    `$..["foo"]`,
+			strict: "jsonpath: unexpected identifier at position 5",
+		},
+		{
+			name:   "unquoted_in_filter",
+			path:   "$[?@[foo] == $[bar][baz]]",
+			rfc:    `$[?@["foo"] == $["bar"]["baz"]]`,
+			strict: "jsonpath: unexpected identifier at position 6",
+		},
+		{
+			name:   "single_equals",
+			path:   "$[?@.x = 1]",
+			rfc:    `$[?@["x"] == 1]`,
+			strict: "jsonpath: invalid comparison operator at position 8",
+		},
+		{
+			name:   "single_equals_no_space",
+			path:   "$[?@.x='y' && 2=@.z]",
+			rfc:    `$[?@["x"] == "y" && 2 == @["z"]]`,
+			strict: "jsonpath: invalid comparison operator at position 7",
+		},
+		{
+			name: "double_equals",
+			path: "$[?@.x == 1]",
+			rfc:  `$[?@["x"] == 1]`,
+		},
+		{
+			name: "paren_filter",
+			path: "$[?(@.x == 1)]",
+			rfc:  `$[?(@["x"] == 1)]`,
+		},
+		{
+			name:   "paren_filter_single_equals",
+			path:   "$[?(@.x = 1)]",
+			rfc:    `$[?(@["x"] == 1)]`,
+			strict: "jsonpath: invalid comparison operator at position 9",
+		},
+		{
+			name: "length",
+			path: "$[?(@.length > 2)]",
+			rfc:  `$[?(length(@) > 2)]`,
+		},
+		{
+			name: "length_of_member",
+			path: "$..book[?@.tags.length >= $.min.length]",
+			rfc:  `$..["book"][?length(@["tags"]) >= length($["min"])]`,
+		},
+		{
+			name:   "length_after_unquoted",
+			path:   "$[?@[tags].length != 0]",
+			rfc:    `$[?length(@["tags"]) != 0]`,
+			strict: "jsonpath: unexpected identifier at position 6",
+		},
+		{
+			name: "length_name",
+			path: "$[?@['length'] == 1 && @.length.x == 2]",
+			rfc:  `$[?@["length"] == 1 && @["length"]["x"] == 2]`,
+		},
+		{
+			name: "length_existence",
+			path: "$[?@.length]",
+			rfc:  `$[?@["length"]]`,
+		},
+		{
+			name: "length_query",
+			path: "$.a.length",
+			rfc:  `$["a"]["length"]`,
+		},
+		{
+			name:   "unquoted_not_shorthand",
+			path:   "$[foo-bar]",
+			strict: "jsonpath: unexpected identifier at position 3",
+			err:    "jsonpath: invalid number literal at position 6",
+		},
+		{
+			name:   "assignment_only",
+			path:   "$[?@.x =]",
+			strict: "jsonpath: invalid comparison operator at position 8",
+			err:    "jsonpath: unexpected ']' at position 9",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			q, err := Parse(reg, tc.path, WithLenient())
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrSyntax)
+			} else {
+				r.NoError(err)
+				a.Equal(tc.rfc, q.String())
+
+				// Should produce the same tree as the RFC 9535 query.
+				exp, err := Parse(reg, tc.rfc)
+				r.NoError(err)
+				a.Equal(exp, q)
+			}
+
+			// Strict mode should reject lenient syntax.
+			_, err = Parse(reg, tc.path)
+			if tc.strict == "" {
+				r.NoError(err)
+			} else {
+				r.EqualError(err, tc.strict)
+			}
+		})
+	}
+}
+
+func TestParseWithArithmetic(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		str    string
+		strict string
+		err    string
+	}{
+		{
+			name:   "multiply_queries",
+			path:   "$[?@.price * @.qty > 100]",
+			str:    `$[?@["price"] * @["qty"] > 100]`,
+			strict: "jsonpath: unexpected '*' at position 12",
+		},
+		{
+			name:   "add_literal",
+			path:   "$[?@.a + 1 == @.b]",
+			str:    `$[?@["a"] + 1 == @["b"]]`,
+			strict: "jsonpath: unexpected '+' at position 8",
+		},
+		{
+			name:   "no_spaces",
+			path:   "$[?@.a-1==$.b/2]",
+			str:    `$[?@["a"] - 1 == $["b"] / 2]`,
+			strict: "jsonpath: unexpected integer at position 7",
+		},
+		{
+			name:   "negative_operand",
+			path:   "$[?@.a - -1 < 0]",
+			str:    `$[?@["a"] - -1 < 0]`,
+			strict: "jsonpath: invalid number literal at position 8",
+		},
+		{
+			name:   "precedence",
+			path:   "$[?1 + 2 * 3 - 4 / 2 % 3 == @]",
+			str:    "$[?1 + 2 * 3 - 4 / 2 % 3 == @]",
+			strict: "jsonpath: invalid comparison operator at position 6",
+		},
+		{
+			name:   "left_paren",
+			path:   "$[?(@.a + 1) * 2 >= 3]",
+			str:    `$[?(@["a"] + 1) * 2 >= 3]`,
+			strict: "jsonpath: expected ')' but found '+' at position 9",
+		},
+		{
+			name:   "right_paren",
+			path:   "$[?@.x == 1 - ( 2 - @.y )]",
+			str:    `$[?@["x"] == 1 - (2 - @["y"])]`,
+			strict: "jsonpath: invalid number literal at position 13",
+		},
+		{
+			name:   "nested_parens",
+			path:   "$[?((@.a)) % 2 == 0]",
+			str:    `$[?@["a"] % 2 == 0]`,
+			strict: "jsonpath: unexpected '%' at position 12",
+		},
+		{
+			name:   "functions",
+			path:   "$[?length(@.x) * 2 > count(@.y[*]) + 1]",
+			str:    `$[?length(@["x"]) * 2 > count(@["y"][*]) + 1]`,
+			strict: "jsonpath: missing comparison to function result at position 16",
+		},
+		{
+			name: "logical_paren",
+			path: "$[?(@.a == 1) && (@.b)]",
+			str:  `$[?(@["a"] == 1) && (@["b"])]`,
+		},
+		{
+			name:   "missing_operand",
+			path:   "$[?@.a + > 2]",
+			strict: "jsonpath: unexpected '+' at position 8",
+			err:    "jsonpath: unexpected '>' at position 10",
+		},
+		{
+			name:   "missing_comparison",
+			path:   "$[?@.a + 1]",
+			strict: "jsonpath: unexpected '+' at position 8",
+			err:    "jsonpath: invalid comparison operator at position 11",
+		},
+		{
+			name:   "logical_function_operand",
+			path:   "$[?@.a + match(@.b, 'x') == 1]",
+			strict: "jsonpath: unexpected '+' at position 8",
+			err:    "jsonpath: cannot compare result of logical function at position 10",
+		},
+		{
+			name:   "non_singular_operand",
+			path:   "$[?1 + @.a[*] == 1]",
+			strict: "jsonpath: invalid comparison operator at position 6",
+			err:    "jsonpath: unexpected '*' at position 12",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			q, err := Parse(reg, tc.path, WithArithmetic())
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrPathParse)
+			} else {
+				r.NoError(err)
+				a.Equal(tc.str, q.String())
+
+				// The string representation parses to the same query.
+				q2, err := Parse(reg, tc.str, WithArithmetic())
+				r.NoError(err)
+				a.Equal(q, q2)
+			}
+
+			// Strict mode should reject arithmetic.
+			q, err = Parse(reg, tc.path)
+			if tc.strict == "" {
+				r.NoError(err)
+				a.Equal(tc.str, q.String())
+				return
+			}
+			r.EqualError(err, tc.strict)
+		})
+	}
+}
+
+func TestParseWithMembership(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		opts   []Option
+		str    string
+		strict string
+		err    string
+	}{
+		{
+			name:   "array_literal",
+			path:   `$[?@.status in ["active", 'pending']]`,
+			str:    `$[?@["status"] in ["active","pending"]]`,
+			strict: "jsonpath: unexpected identifier at position 13",
+		},
+		{
+			name:   "nested_literals",
+			path:   `$[?@ in [[1, 2], {"a": null}, true]]`,
+			str:    `$[?@ in [[1,2],{"a":null},true]]`,
+			strict: "jsonpath: unexpected identifier at position 6",
+		},
+		{
+			name:   "literal_left",
+			path:   `$[?"x" in @.tags]`,
+			str:    `$[?"x" in @["tags"]]`,
+			strict: "jsonpath: invalid comparison operator at position 8",
+		},
+		{
+			name:   "root_query",
+			path:   `$[?@.id in $.allowed]`,
+			str:    `$[?@["id"] in $["allowed"]]`,
+			strict: "jsonpath: unexpected identifier at position 9",
+		},
+		{
+			name:   "function",
+			path:   `$[?length(@.x) in value($.sizes)]`,
+			str:    `$[?length(@["x"]) in value($["sizes"])]`,
+			strict: "jsonpath: missing comparison to function result at position 16",
+		},
+		{
+			name:   "no_blank_space",
+			path:   `$[?@.a in[1]]`,
+			str:    `$[?@["a"] in [1]]`,
+			strict: "jsonpath: unexpected identifier at position 8",
+		},
+		{
+			name:   "logical",
+			path:   `$[?@.a in [1] && !(@.b in [2]) || @.c]`,
+			str:    `$[?@["a"] in [1] && !(@["b"] in [2]) || @["c"]]`,
+			strict: "jsonpath: unexpected identifier at position 8",
+		},
+		{
+			name:   "arithmetic",
+			path:   `$[?(@.a + 1) * 2 in [4, 6]]`,
+			opts:   []Option{WithArithmetic()},
+			str:    `$[?(@["a"] + 1) * 2 in [4,6]]`,
+			strict: "jsonpath: invalid comparison operator at position 12",
+		},
+		{
+			name:   "name_starting_with_in",
+			path:   `$[?@.a index]`,
+			strict: "jsonpath: unexpected identifier at position 8",
+			err:    "jsonpath: unexpected identifier at position 8",
+		},
+		{
+			name:   "missing_array",
+			path:   `$[?@.a in]`,
+			strict: "jsonpath: unexpected identifier at position 8",
+			err:    "jsonpath: unexpected ']' at position 10",
+		},
+		{
+			name:   "string_literal",
+			path:   `$[?@.a in "abc"]`,
+			strict: "jsonpath: unexpected identifier at position 8",
+			err:    "jsonpath: unexpected string at position 11",
+		},
+		{
+			name:   "non_singular",
+			path:   `$[?@.a in @.b[*]]`,
+			strict: "jsonpath: unexpected identifier at position 8",
+			err:    "jsonpath: unexpected '*' at position 15",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			opts := append([]Option{WithMembership()}, tc.opts...)
+			q, err := Parse(reg, tc.path, opts...)
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrPathParse)
+			} else {
+				r.NoError(err)
+				a.Equal(tc.str, q.String())
+
+				// The string representation parses to the same query.
+				q2, err := Parse(reg, tc.str, opts...)
+				r.NoError(err)
+				a.Equal(q, q2)
+			}
+
+			// Strict mode should reject membership tests.
+			_, err = Parse(reg, tc.path, tc.opts...)
+			r.EqualError(err, tc.strict)
+		})
+	}
+}
+
+func TestParseWithCompositeLiterals(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		lit    any
+		str    string
+		strict string
+		err    string
+	}{
+		{
+			name:   "array",
+			path:   `$[?@.tags == ["a", 'b']]`,
+			lit:    []any{"a", "b"},
+			str:    `$[?@["tags"] == ["a","b"]]`,
+			strict: "jsonpath: unexpected '[' at position 14",
+		},
+		{
+			name:   "empty_array",
+			path:   `$[?@.tags!=[ ]]`,
+			lit:    []any{},
+			str:    `$[?@["tags"] != []]`,
+			strict: "jsonpath: unexpected '[' at position 12",
+		},
+		{
+			name:   "object",
+			path:   `$[?{"y": 1.5, 'x': [0, true, null, {}]} == @.p]`,
+			lit:    map[string]any{"x": []any{int64(0), true, nil, map[string]any{}}, "y": 1.5},
+			str:    `$[?{"x":[0,true,null,{}],"y":1.5} == @["p"]]`,
+			strict: "jsonpath: unexpected '{' at position 4",
+		},
+		{
+			name:   "empty_object",
+			path:   "$[?{} == $.x]",
+			lit:    map[string]any{},
+			str:    `$[?{} == $["x"]]`,
+			strict: "jsonpath: unexpected '{' at position 4",
+		},
+		{
+			name:   "duplicate_names",
+			path:   `$[?@ == {"a": 1, "a": 2}]`,
+			lit:    map[string]any{"a": int64(2)},
+			str:    `$[?@ == {"a":2}]`,
+			strict: "jsonpath: unexpected '{' at position 9",
+		},
+		{
+			name:   "nested_arrays",
+			path:   "$[?@ == [[1], [[2]]]]",
+			lit:    []any{[]any{int64(1)}, []any{[]any{int64(2)}}},
+			str:    "$[?@ == [[1],[[2]]]]",
+			strict: "jsonpath: unexpected '[' at position 9",
+		},
+		{
+			name:   "unclosed_array",
+			path:   "$[?@ == [1, 2]",
+			strict: "jsonpath: unexpected '[' at position 9",
+			err:    "jsonpath: unexpected eof at position 15",
+		},
+		{
+			name:   "array_missing_comma",
+			path:   "$[?@ == [1 2]]",
+			strict: "jsonpath: unexpected '[' at position 9",
+			err:    "jsonpath: unexpected integer at position 12",
+		},
+		{
+			name:   "array_trailing_comma",
+			path:   "$[?@ == [1,]]",
+			strict: "jsonpath: unexpected '[' at position 9",
+			err:    "jsonpath: unexpected ']' at position 12",
+		},
+		{
+			name:   "array_query",
+			path:   "$[?@ == [@.x]]",
+			strict: "jsonpath: unexpected '[' at position 9",
+			err:    "jsonpath: unexpected '@' at position 10",
+		},
+		{
+			name:   "object_unquoted_name",
+			path:   "$[?@ == {a: 1}]",
+			strict: "jsonpath: unexpected '{' at position 9",
+			err:    "jsonpath: unexpected identifier at position 10",
+		},
+		{
+			name:   "object_missing_colon",
+			path:   `$[?@ == {"a" 1}]`,
+			strict: "jsonpath: unexpected '{' at position 9",
+			err:    "jsonpath: unexpected integer at position 14",
+		},
+		{
+			name:   "object_missing_comma",
+			path:   `$[?@ == {"a": 1 "b": 2}]`,
+			strict: "jsonpath: unexpected '{' at position 9",
+			err:    "jsonpath: unexpected string at position 17",
+		},
+		{
+			name:   "invalid_number",
+			path:   "$[?@ == [1e999]]",
+			strict: "jsonpath: unexpected '[' at position 9",
+			err:    "jsonpath: cannot parse \"1e999\", value out of range at position 10",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			q, err := Parse(reg, tc.path, WithCompositeLiterals())
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrPathParse)
+			} else {
+				r.NoError(err)
+				a.Equal(tc.str, q.String())
+
+				// Find the literal in the comparison.
+				filter, ok := q.Segments()[0].Selectors()[0].(*spec.FilterSelector)
+				r.True(ok)
+				cmp, ok := filter.LogicalOr[0][0].(*spec.ComparisonExpr)
+				r.True(ok)
+				lit, ok := cmp.Right.(*spec.LiteralArg)
+				if !ok {
+					lit, ok = cmp.Left.(*spec.LiteralArg)
+				}
+				r.True(ok)
+				a.Equal(tc.lit, lit.Value())
+
+				// The string representation parses to the same query.
+				q2, err := Parse(reg, tc.str, WithCompositeLiterals())
+				r.NoError(err)
+				a.Equal(q, q2)
+			}
+
+			// Strict mode should reject composite literals.
+			_, err = Parse(reg, tc.path)
+			r.EqualError(err, tc.strict)
+		})
+	}
+}
+
+// TestParseErrorClasses locks the message and class of each kind of parse
+// error, on which callers may depend to match errors.
+// patternSelector is a custom selector, ~"pattern", for testing
+// WithSelector. It selects nothing, as these tests only parse.
+type patternSelector string
+
+func (ps patternSelector) String() string     { return "~" + strconv.Quote(string(ps)) }
+func (patternSelector) Select(_, _ any) []any { return nil }
+func (patternSelector) SelectLocated(_, _ any, _ spec.NormalizedPath) []*spec.LocatedNode {
+	return nil
+}
+
+// parsePattern parses a patternSelector from src.
+func parsePattern(src string) (spec.Selector, int, error) {
+	str, err := strconv.QuotedPrefix(src[1:])
+	if err != nil {
+		return nil, 0, errors.New("invalid pattern selector")
+	}
+	pat, _ := strconv.Unquote(str)
+	return patternSelector(pat), len(str) + 1, nil
+}
+
+func TestParseWithSelector(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+	opts := []Option{
+		WithSelector('~', parsePattern),
+		WithSelector('!', func(string) (spec.Selector, int, error) {
+			return patternSelector(""), 0, nil
+		}),
+	}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		str  string
+		err  string
+	}{
+		{
+			name: "pattern",
+			path: `$[~"^a"]`,
+			str:  `$[~"^a"]`,
+		},
+		{
+			name: "blank_space",
+			path: `$[ ~"a" , 0 ]`,
+			str:  `$[~"a",0]`,
+		},
+		{
+			name: "descendant",
+			path: `$..[~"a",~"b"].x`,
+			str:  `$..[~"a",~"b"]["x"]`,
+		},
+		{
+			name: "filter_query",
+			path: `$[?@[~"a"]]`,
+			str:  `$[?@[~"a"]]`,
+		},
+		{
+			name: "not_singular",
+			path: `$[?@[~"a"] == 1]`,
+			err:  "jsonpath: unexpected '=' at position 12",
+		},
+		{
+			name: "parse_error",
+			path: `$[~x]`,
+			err:  "jsonpath: invalid pattern selector at position 3",
+		},
+		{
+			name: "unconsumed",
+			path: `$[~"a"x]`,
+			err:  "jsonpath: unexpected identifier at position 7",
+		},
+		{
+			name: "zero_length",
+			path: `$[!]`,
+			err:  "jsonpath: invalid '!' selector: parser consumed 0 of 2 bytes at position 3",
+		},
+		{
+			name: "unregistered",
+			path: `$[#]`,
+			err:  "jsonpath: unexpected '#' at position 3",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			q, err := Parse(reg, tc.path, opts...)
+			if tc.err != "" {
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrSyntax)
+				return
+			}
+			r.NoError(err)
+			a.Equal(tc.str, q.String())
+
+			// The string representation parses to the same query.
+			q2, err := Parse(reg, tc.str, opts...)
+			r.NoError(err)
+			a.Equal(q, q2)
+
+			// Parsing fails without the option.
+			_, err = Parse(reg, tc.path)
+			r.ErrorIs(err, ErrSyntax)
+		})
+	}
+
+	// Reserved prefixes panic.
+	for _, prefix := range []rune{'?', '*', ':', '-', '0', '\'', '"', ',', '[', ']', '$', '@', ' ', 'a', '_', 0x80} {
+		assert.PanicsWithValue(
+			t, fmt.Sprintf("jsonpath: reserved selector prefix %q", prefix),
+			func() { WithSelector(prefix, parsePattern) }, string(prefix),
+		)
+	}
+}
+
+func TestParseWithLimits(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name string
+		path string
+		opts []Option
+		err  string
+	}{
+		{
+			name: "depth_filters",
+			path: `$[?@[?@[?@.a]]]`,
+			opts: []Option{WithMaxDepth(3)},
+		},
+		{
+			name: "depth_filters_exceeded",
+			path: `$[?@[?@[?@.a]]]`,
+			opts: []Option{WithMaxDepth(2)},
+			err:  "jsonpath: query exceeds maximum nesting depth of 2 at position 9",
+		},
+		{
+			name: "depth_parens",
+			path: `$[?(!(@.a)) && (@.b)]`,
+			opts: []Option{WithMaxDepth(3)},
+		},
+		{
+			name: "depth_parens_exceeded",
+			path: `$[?(!(@.a)) && (@.b)]`,
+			opts: []Option{WithMaxDepth(2)},
+			err:  "jsonpath: query exceeds maximum nesting depth of 2 at position 6",
+		},
+		{
+			name: "depth_functions",
+			path: `$[?length(value(@.a)) == 1]`,
+			opts: []Option{WithMaxDepth(2)},
+			err:  "jsonpath: query exceeds maximum nesting depth of 2 at position 11",
+		},
+		{
+			name: "depth_arithmetic",
+			path: `$[?((@.a + 1) * 2) == 1]`,
+			opts: []Option{WithArithmetic(), WithMaxDepth(2)},
+			err:  "jsonpath: query exceeds maximum nesting depth of 2 at position 5",
+		},
+		{
+			name: "depth_literals",
+			path: `$[?@.a == [[1]]]`,
+			opts: []Option{WithCompositeLiterals(), WithMaxDepth(2)},
+			err:  "jsonpath: query exceeds maximum nesting depth of 2 at position 12",
+		},
+		{
+			name: "depth_no_limit",
+			path: `$[?@[?@[?@.a]]]`,
+			opts: []Option{WithMaxDepth(0)},
+		},
+		{
+			name: "selectors",
+			path: `$.a[1, 2]..b[?@.c == $["d"]]..*`,
+			opts: []Option{WithMaxSelectors(8)},
+		},
+		{
+			name: "selectors_exceeded",
+			path: `$.a[1, 2]..b[?@.c == $["d"]]..*`,
+			opts: []Option{WithMaxSelectors(7)},
+			err:  "jsonpath: query exceeds maximum of 7 selectors at position 31",
+		},
+		{
+			name: "selectors_bracket",
+			path: `$.a[1, 2]`,
+			opts: []Option{WithMaxSelectors(2)},
+			err:  "jsonpath: query exceeds maximum of 2 selectors at position 8",
+		},
+		{
+			name: "selectors_dot",
+			path: `$.a.b`,
+			opts: []Option{WithMaxSelectors(1)},
+			err:  "jsonpath: query exceeds maximum of 1 selectors at position 5",
+		},
+		{
+			name: "selectors_descendant",
+			path: `$.a..b`,
+			opts: []Option{WithMaxSelectors(1)},
+			err:  "jsonpath: query exceeds maximum of 1 selectors at position 6",
+		},
+		{
+			name: "selectors_filter_query",
+			path: `$[?@.a]`,
+			opts: []Option{WithMaxSelectors(1)},
+			err:  "jsonpath: query exceeds maximum of 1 selectors at position 6",
+		},
+		{
+			name: "selectors_singular_query",
+			path: `$[?@["a"] == 1]`,
+			opts: []Option{WithMaxSelectors(1)},
+			err:  "jsonpath: query exceeds maximum of 1 selectors at position 6",
+		},
+		{
+			name: "selectors_arithmetic",
+			path: `$[?(@.a + 1) == @.b]`,
+			opts: []Option{WithArithmetic(), WithMaxSelectors(3)},
+		},
+		{
+			name: "selectors_arithmetic_backtrack",
+			path: `$[?(@.a && @.b) || (@.c)]`,
+			opts: []Option{WithArithmetic(), WithMaxSelectors(4)},
+		},
+		{
+			name: "selectors_no_limit",
+			path: `$.a[1, 2]`,
+			opts: []Option{WithMaxSelectors(-1)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+
+			_, err := Parse(reg, tc.path, tc.opts...)
+			if tc.err == "" {
+				r.NoError(err)
+				return
+			}
+			r.EqualError(err, tc.err)
+			r.ErrorIs(err, ErrLimit)
+			r.ErrorIs(err, ErrSemantic)
+		})
+	}
+}
+
+func TestParseErrorClasses(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name     string
+		path     string
+		err      string
+		semantic bool
+		relative bool
+	}{
+		{name: "empty", path: "", err: `jsonpath: unexpected end of input`},
+		{name: "relative", path: "@.x", relative: true, err: `jsonpath: unexpected '@' at position 1: relative queries are valid only in filter expressions unless parsed with the WithRelative option`},
+		{name: "no_root", path: "x", err: `jsonpath: unexpected identifier at position 1`},
+		{name: "unexpected_token", path: "$.==12", err: `jsonpath: unexpected '=' at position 3`},
+		{name: "unclosed_bracket", path: "$[1", err: `jsonpath: unexpected eof at position 4`},
+		{name: "bad_string", path: `$["x]`, err: `jsonpath: unterminated string literal at position 6`},
+		{name: "bad_comparison", path: "$[?@.x=1]", err: `jsonpath: invalid comparison operator at position 7`},
+		{name: "negative_zero", path: "$[-0]", err: `jsonpath: invalid integer path value "-0" at position 3`},
+		{name: "unknown_function", path: "$[?nope(@)]", semantic: true, err: `jsonpath: unknown function nope() at position 4`},
+		{name: "function_arity", path: "$[?length(@, 1) == 1]", semantic: true, err: `jsonpath: function length() expected 1 argument but found 2 at position 10`},
+		{name: "function_type", path: "$[?length(@.*) == 1]", semantic: true, err: `jsonpath: function length() cannot convert argument to ValueType at position 10`},
+		{name: "missing_comparison", path: "$[?length(@)]", semantic: true, err: `jsonpath: missing comparison to function result at position 13`},
+		{name: "compare_logical", path: "$[?1 == match(@, 'x')]", semantic: true, err: `jsonpath: cannot compare result of logical function at position 9`},
+		{name: "index_range", path: "$[9007199254740992]", semantic: true, err: `jsonpath: cannot parse "9007199254740992", value out of range at position 3`},
+		{name: "number_range", path: "$[?@.x == 99e+1234]", semantic: true, err: `jsonpath: cannot parse "99e+1234", value out of range at position 11`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := Parse(reg, tc.path)
+			r.EqualError(err, tc.err)
+			r.ErrorIs(err, ErrPathParse)
+			if tc.semantic {
+				r.ErrorIs(err, ErrSemantic)
+				r.NotErrorIs(err, ErrSyntax)
+			} else {
+				r.ErrorIs(err, ErrSyntax)
+				r.NotErrorIs(err, ErrSemantic)
+			}
+			if tc.relative {
+				r.ErrorIs(err, ErrRelative)
+			} else {
+				r.NotErrorIs(err, ErrRelative)
+			}
+		})
+	}
+}
+
+func TestParseFunctionParams(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+	require.NoError(t, reg.Register(
+		"pick",
+		spec.FuncValue,
+		registry.Params(registry.Value, registry.Optional(registry.Value), registry.Variadic(registry.Nodes)),
+		func([]spec.JSONPathValue) spec.JSONPathValue { return nil },
+	))
+	require.NoError(t, reg.Register(
+		"one",
+		spec.FuncLogical,
+		registry.Params(registry.Logical),
+		func([]spec.JSONPathValue) spec.JSONPathValue { return spec.LogicalTrue },
+	))
+
+	for _, tc := range []struct {
+		name string
+		path string
+		err  string
+	}{
+		{name: "required", path: `$[?pick(@.x) == 1]`},
+		{name: "logical", path: `$[?one(@.*)]`},
+		{
+			name: "extra",
+			path: `$[?one(@.x, @.y, @.z)]`,
+			err:  `jsonpath: function one() expected 1 argument but found 3 at position 13`,
+		},
+		{name: "optional", path: `$[?pick(@.x, "y") == 1]`},
+		{name: "variadic", path: `$[?pick(@.x, "y", @.*, $..z) == 1]`},
+		{
+			name: "missing",
+			path: `$[?pick() == 1]`,
+			err:  `jsonpath: function pick() expected at least 1 argument but found 0 at position 8`,
+		},
+		{
+			name: "bad_first",
+			path: `$[?pick(@.*) == 1]`,
+			err:  `jsonpath: function pick() cannot convert argument 1 to ValueType at position 9`,
+		},
+		{
+			name: "bad_optional",
+			path: `$[?pick(1, @.*) == 1]`,
+			err:  `jsonpath: function pick() cannot convert argument 2 to ValueType at position 12`,
+		},
+		{
+			name: "bad_variadic",
+			path: `$[?pick(1, 2, @.x,  3) == 1]`,
+			err:  `jsonpath: function pick() cannot convert argument 4 to NodesType at position 21`,
+		},
+		{name: "pattern", path: `$[?match(@.x, "[a-z]+")]`},
+		{
+			name: "bad_pattern",
+			path: `$[?match(@.x, "[a-z]+\\d")]`,
+			err:  `jsonpath: function match() i-regexp: invalid escape \d (offset 7) at position 15`,
+		},
+		{
+			name: "bad_search_pattern",
+			path: `$[?search(@.x, "(a")]`,
+			err:  `jsonpath: function search() i-regexp: missing closing parenthesis (offset 3) at position 16`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := require.New(t)
+			_, err := Parse(reg, tc.path)
+			if tc.err == "" {
+				r.NoError(err)
+				return
+			}
+			r.EqualError(err, tc.err)
+			r.ErrorIs(err, ErrSemantic)
+		})
+	}
+}
+
 func TestParseSimple(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -83,14 +1071,14 @@ func TestParseSimple(t *testing.T) {
 		{
 			name: "wildcard",
 			path: "$.*",
-			exp:  spec.Query(true, []*spec.Segment{spec.Child(spec.Wildcard)}),
+			exp:  spec.Query(true, []*spec.Segment{spec.Child(spec.Wildcard())}),
 		},
 		{
 			name: "wildcard_wildcard",
 			path: "$.*.*",
 			exp: spec.Query(true, []*spec.Segment{
-				spec.Child(spec.Wildcard),
-				spec.Child(spec.Wildcard),
+				spec.Child(spec.Wildcard()),
+				spec.Child(spec.Wildcard()),
 			}),
 		},
 		{
@@ -98,7 +1086,7 @@ func TestParseSimple(t *testing.T) {
 			path: "$.x.*",
 			exp: spec.Query(true, []*spec.Segment{
 				spec.Child(spec.Name("x")),
-				spec.Child(spec.Wildcard),
+				spec.Child(spec.Wildcard()),
 			}),
 		},
 		{
@@ -117,21 +1105,21 @@ func TestParseSimple(t *testing.T) {
 		{
 			name: "desc_wildcard",
 			path: "$..*",
-			exp:  spec.Query(true, []*spec.Segment{spec.Descendant(spec.Wildcard)}),
+			exp:  spec.Query(true, []*spec.Segment{spec.Descendant(spec.Wildcard())}),
 		},
 		{
 			name: "desc_wildcard_2x",
 			path: "$..*..*",
 			exp: spec.Query(true, []*spec.Segment{
-				spec.Descendant(spec.Wildcard),
-				spec.Descendant(spec.Wildcard),
+				spec.Descendant(spec.Wildcard()),
+				spec.Descendant(spec.Wildcard()),
 			}),
 		},
 		{
 			name: "desc_wildcard_name",
 			path: "$..*.xyz",
 			exp: spec.Query(true, []*spec.Segment{
-				spec.Descendant(spec.Wildcard),
+				spec.Descendant(spec.Wildcard()),
 				spec.Child(spec.Name("xyz")),
 			}),
 		},
@@ -139,7 +1127,7 @@ func TestParseSimple(t *testing.T) {
 			name: "wildcard_desc_name",
 			path: "$.*..xyz",
 			exp: spec.Query(true, []*spec.Segment{
-				spec.Child(spec.Wildcard),
+				spec.Child(spec.Wildcard()),
 				spec.Descendant(spec.Name("xyz")),
 			}),
 		},
@@ -999,14 +1987,14 @@ func TestParseSelectors(t *testing.T) {
 			name: "slice_wildcard",
 			path: `$[:,   *]`,
 			exp: spec.Query(true, []*spec.Segment{
-				spec.Child(spec.Slice(), spec.Wildcard),
+				spec.Child(spec.Slice(), spec.Wildcard()),
 			}),
 		},
 		{
 			name: "wildcard_slice",
 			path: `$[  *,  :   ]`,
 			exp: spec.Query(true, []*spec.Segment{
-				spec.Child(spec.Wildcard, spec.Slice()),
+				spec.Child(spec.Wildcard(), spec.Slice()),
 			}),
 		},
 		{
@@ -1037,7 +2025,7 @@ func TestParseSelectors(t *testing.T) {
 				spec.Index(3),
 				spec.Name("🦀"),
 				spec.Slice(nil, 3),
-				spec.Wildcard,
+				spec.Wildcard(),
 			)}),
 		},
 		{
@@ -1157,7 +2145,7 @@ func TestParseSelectors(t *testing.T) {
 		{
 			name: "wildcard_tab",
 			path: "$[*\t]",
-			exp:  spec.Query(true, []*spec.Segment{spec.Child(spec.Wildcard)}),
+			exp:  spec.Query(true, []*spec.Segment{spec.Child(spec.Wildcard())}),
 		},
 		{
 			name: "slice_newline",
@@ -1185,7 +2173,7 @@ func TestParseSelectors(t *testing.T) {
 			exp: spec.Query(true, []*spec.Segment{spec.Descendant(
 				spec.Name("hi"),
 				spec.Index(2),
-				spec.Wildcard,
+				spec.Wildcard(),
 				spec.Slice(4, 5),
 			)}),
 		},
@@ -1215,7 +2203,7 @@ func TestParseSelectors(t *testing.T) {
 			exp: spec.Query(true, []*spec.Segment{
 				spec.Child(spec.Name("names")),
 				spec.Child(spec.Name("first_name")),
-				spec.Descendant(spec.Wildcard),
+				spec.Descendant(spec.Wildcard()),
 			}),
 		},
 		{
@@ -1251,7 +2239,7 @@ func TestParseSelectors(t *testing.T) {
 			name: "default_slice_wildcard_segment",
 			path: `$[::,*]`,
 			exp: spec.Query(true, []*spec.Segment{
-				spec.Child(spec.Slice(), spec.Wildcard),
+				spec.Child(spec.Slice(), spec.Wildcard()),
 			}),
 		},
 		{
@@ -1431,3 +2419,41 @@ func TestParseLiteral(t *testing.T) {
 		})
 	}
 }
+
+func TestParseQuotedRoundTrip(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	reg := registry.New()
+
+	// Every ASCII character, plus some beyond.
+	names := []string{`'"\/`, "foø   \U0001D11E", "\x7f\u0080"}
+	for c := range rune(0x80) {
+		names = append(names, "<"+string(c)+">")
+	}
+
+	for _, name := range names {
+		exp := spec.Query(true, []*spec.Segment{spec.Child(spec.Name(name))})
+
+		// Name selector string.
+		q, err := Parse(reg, "$["+spec.Name(name).String()+"]")
+		r.NoError(err, "%q", name)
+		a.Equal(exp, q, "%q", name)
+		a.Equal(exp.String(), q.String(), "%q", name)
+
+		// Normalized path.
+		q, err = Parse(reg, spec.NormalizedPath{spec.Name(name)}.String())
+		r.NoError(err, "%q", name)
+		a.Equal(exp, q, "%q", name)
+
+		// String literal.
+		filter := spec.Query(true, []*spec.Segment{spec.Child(spec.Filter(spec.LogicalOr{
+			spec.LogicalAnd{spec.Comparison(
+				spec.SingularQuery(false, []spec.Selector{}), spec.EqualTo, spec.Literal(name),
+			)},
+		}))})
+		q, err = Parse(reg, filter.String())
+		r.NoError(err, "%q", name)
+		a.Equal(filter, q, "%q", name)
+	}
+}
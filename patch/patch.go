@@ -0,0 +1,141 @@
+// Package patch generates [RFC 6902] JSON Patch documents that modify the
+// nodes selected by JSONPath queries, so that queries can drive document
+// mutations. Pass the results of
+// [github.com/theory/jsonpath.Path.SelectLocated] to [Remove], [Replace], or
+// [Add] and apply the resulting [Patch] with any RFC 6902 implementation.
+//
+// Each operation targets the [RFC 6901] JSON Pointer equivalent of a node's
+// normalized path, as returned by [spec.NormalizedPath.Pointer]. Because
+// operations apply in sequence, each function orders its operations so that
+// none changes the location targeted by a later one.
+//
+// [RFC 6902]: https://www.rfc-editor.org/rfc/rfc6902
+// [RFC 6901]: https://www.rfc-editor.org/rfc/rfc6901
+package patch
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// JSON Patch operations.
+const (
+	// OpAdd adds a value to an object or inserts it into an array.
+	OpAdd = "add"
+
+	// OpRemove removes a value.
+	OpRemove = "remove"
+
+	// OpReplace replaces a value.
+	OpReplace = "replace"
+)
+
+// Operation represents a single JSON Patch operation.
+type Operation struct {
+	// Op is the operation to perform, one of [OpAdd], [OpRemove], or
+	// [OpReplace].
+	Op string
+
+	// Path is the JSON Pointer to the target location.
+	Path string
+
+	// Value is the value to add or replace with. Ignored by [OpRemove].
+	Value any
+}
+
+// MarshalJSON marshals op into a JSON Patch operation object. Includes the
+// value member for all operations but [OpRemove], even when Value is nil.
+// Implements [json.Marshaler].
+func (op Operation) MarshalJSON() ([]byte, error) {
+	if op.Op == OpRemove {
+		//nolint:wrapcheck
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+
+	//nolint:wrapcheck
+	return json.Marshal(struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{op.Op, op.Path, op.Value})
+}
+
+// Patch represents a JSON Patch document, a sequence of operations.
+type Patch []Operation
+
+// Remove returns a Patch that removes nodes from the document from which
+// they were selected. Removes array elements in descending index order so
+// that each removal leaves the indexes of the remaining elements intact.
+// Ignores duplicate nodes and nodes within other nodes, which the removal
+// of the enclosing node removes, too. Most implementations reject removal
+// of the root node, selected by $.
+func Remove(nodes []*spec.LocatedNode) Patch {
+	targets := targets(nodes)
+	patch := make(Patch, len(targets))
+	for i, n := range targets {
+		patch[i] = Operation{Op: OpRemove, Path: n.Path.Pointer()}
+	}
+	return patch
+}
+
+// Replace returns a Patch that replaces each node in the document from
+// which it was selected with the value returned by passing it to fn. Ignores
+// duplicate nodes and nodes within other nodes, the replacement of which
+// the replacement of the enclosing node would supersede.
+func Replace(nodes []*spec.LocatedNode, fn func(node *spec.LocatedNode) any) Patch {
+	targets := targets(nodes)
+	patch := make(Patch, len(targets))
+	for i, n := range targets {
+		patch[i] = Operation{Op: OpReplace, Path: n.Path.Pointer(), Value: fn(n)}
+	}
+	return patch
+}
+
+// Add returns a Patch that adds the value returned by passing each node to
+// fn at the location of the node in the document from which it was
+// selected. For object members, the value replaces the member's value; for
+// array elements, it's inserted before the element. Inserts into arrays in
+// descending index order so that each insertion leaves the indexes of the
+// preceding elements intact. Ignores duplicate nodes and nodes within other
+// nodes.
+func Add(nodes []*spec.LocatedNode, fn func(node *spec.LocatedNode) any) Patch {
+	targets := targets(nodes)
+	patch := make(Patch, len(targets))
+	for i, n := range targets {
+		patch[i] = Operation{Op: OpAdd, Path: n.Path.Pointer(), Value: fn(n)}
+	}
+	return patch
+}
+
+// targets returns the nodes to target with operations: a copy of nodes,
+// without duplicates or nodes within other nodes, sorted by descending
+// normalized path.
+func targets(nodes []*spec.LocatedNode) []*spec.LocatedNode {
+	sorted := slices.Clone(nodes)
+	slices.SortFunc(sorted, func(a, b *spec.LocatedNode) int {
+		return a.Path.Compare(b.Path)
+	})
+
+	// Ascending order sorts nodes within another node immediately after it.
+	res := make([]*spec.LocatedNode, 0, len(sorted))
+	for _, n := range sorted {
+		if len(res) > 0 && hasPrefix(n.Path, res[len(res)-1].Path) {
+			continue
+		}
+		res = append(res, n)
+	}
+
+	slices.Reverse(res)
+	return res
+}
+
+// hasPrefix returns true if path starts with prefix, including when they're
+// equal.
+func hasPrefix(path, prefix spec.NormalizedPath) bool {
+	return len(path) >= len(prefix) && slices.Equal(path[:len(prefix)], prefix)
+}
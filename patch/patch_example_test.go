@@ -0,0 +1,39 @@
+package patch_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/examples"
+	"github.com/theory/jsonpath/patch"
+	"github.com/theory/jsonpath/spec"
+)
+
+func ExampleRemove() {
+	p := jsonpath.MustParse(`$.store.book[?@.price > 10]`)
+	ops := patch.Remove(p.SelectLocated(examples.Bookstore()))
+
+	js, _ := json.Marshal(ops)
+	fmt.Printf("%s\n", js)
+	// Output:
+	// [{"op":"remove","path":"/store/book/3"},{"op":"remove","path":"/store/book/1"}]
+}
+
+func ExampleReplace() {
+	p := jsonpath.MustParse(`$.store.book[*].price`)
+	ops := patch.Replace(p.SelectLocated(examples.Bookstore()), func(n *spec.LocatedNode) any {
+		// Apply a 10% discount.
+		price, _ := n.Node.(float64)
+		return float64(int(price*90)) / 100
+	})
+
+	for _, op := range ops {
+		fmt.Printf("%v %v %v\n", op.Op, op.Path, op.Value)
+	}
+	// Output:
+	// replace /store/book/3/price 20.69
+	// replace /store/book/2/price 8.09
+	// replace /store/book/1/price 11.69
+	// replace /store/book/0/price 8.05
+}
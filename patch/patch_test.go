@@ -0,0 +1,262 @@
+package patch
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+)
+
+func testDoc() any {
+	return map[string]any{
+		"a": []any{1, 2, 3, 4},
+		"o": map[string]any{"x/y": true, "m~n": []any{"p", "q"}},
+	}
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		patch Patch
+		exp   any
+	}{
+		{
+			name:  "nothing",
+			path:  `$.nonesuch`,
+			patch: Patch{},
+			exp:   testDoc(),
+		},
+		{
+			name: "indexes",
+			path: `$.a[0,2,0]`,
+			patch: Patch{
+				{Op: OpRemove, Path: "/a/2"},
+				{Op: OpRemove, Path: "/a/0"},
+			},
+			exp: map[string]any{
+				"a": []any{2, 4},
+				"o": map[string]any{"x/y": true, "m~n": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "filter",
+			path: `$.a[?@ > 1]`,
+			patch: Patch{
+				{Op: OpRemove, Path: "/a/3"},
+				{Op: OpRemove, Path: "/a/2"},
+				{Op: OpRemove, Path: "/a/1"},
+			},
+			exp: map[string]any{
+				"a": []any{1},
+				"o": map[string]any{"x/y": true, "m~n": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "escaped_names",
+			path: `$.o.*`,
+			patch: Patch{
+				{Op: OpRemove, Path: "/o/x~1y"},
+				{Op: OpRemove, Path: "/o/m~0n"},
+			},
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{},
+			},
+		},
+		{
+			name: "nested",
+			path: `$..*`,
+			patch: Patch{
+				{Op: OpRemove, Path: "/o"},
+				{Op: OpRemove, Path: "/a"},
+			},
+			exp: map[string]any{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			doc := testDoc()
+			patch := Remove(jsonpath.MustParse(tc.path).SelectLocated(doc))
+			a.Equal(tc.patch, patch)
+			a.Equal(tc.exp, apply(doc, patch))
+		})
+	}
+}
+
+func TestReplace(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	double := func(n *spec.LocatedNode) any {
+		if num, ok := n.Node.(int); ok {
+			return num * 2
+		}
+		return nil
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		patch Patch
+		exp   any
+	}{
+		{
+			name: "indexes",
+			path: `$.a[-1,1]`,
+			patch: Patch{
+				{Op: OpReplace, Path: "/a/3", Value: 8},
+				{Op: OpReplace, Path: "/a/1", Value: 4},
+			},
+			exp: map[string]any{
+				"a": []any{1, 4, 3, 8},
+				"o": map[string]any{"x/y": true, "m~n": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "nested",
+			path: `$.o..*`,
+			patch: Patch{
+				{Op: OpReplace, Path: "/o/x~1y", Value: nil},
+				{Op: OpReplace, Path: "/o/m~0n", Value: nil},
+			},
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x/y": nil, "m~n": nil},
+			},
+		},
+		{
+			name:  "root",
+			path:  `$`,
+			patch: Patch{{Op: OpReplace, Path: "", Value: nil}},
+			exp:   nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			doc := testDoc()
+			patch := Replace(jsonpath.MustParse(tc.path).SelectLocated(doc), double)
+			a.Equal(tc.patch, patch)
+			a.Equal(tc.exp, apply(doc, patch))
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	mark := func(n *spec.LocatedNode) any { return "before " + n.Path.String() }
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		patch Patch
+		exp   any
+	}{
+		{
+			name: "insert",
+			path: `$.a[1,3]`,
+			patch: Patch{
+				{Op: OpAdd, Path: "/a/3", Value: "before $['a'][3]"},
+				{Op: OpAdd, Path: "/a/1", Value: "before $['a'][1]"},
+			},
+			exp: map[string]any{
+				"a": []any{1, "before $['a'][1]", 2, 3, "before $['a'][3]", 4},
+				"o": map[string]any{"x/y": true, "m~n": []any{"p", "q"}},
+			},
+		},
+		{
+			name: "member",
+			path: `$.o["x/y"]`,
+			patch: Patch{
+				{Op: OpAdd, Path: "/o/x~1y", Value: "before $['o']['x/y']"},
+			},
+			exp: map[string]any{
+				"a": []any{1, 2, 3, 4},
+				"o": map[string]any{"x/y": "before $['o']['x/y']", "m~n": []any{"p", "q"}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			doc := testDoc()
+			patch := Add(jsonpath.MustParse(tc.path).SelectLocated(doc), mark)
+			a.Equal(tc.patch, patch)
+			a.Equal(tc.exp, apply(doc, patch))
+		})
+	}
+}
+
+func TestOperationMarshalJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	js, err := json.Marshal(Patch{
+		{Op: OpRemove, Path: "/a/0", Value: 1},
+		{Op: OpReplace, Path: "/b", Value: nil},
+		{Op: OpAdd, Path: "/c/-", Value: []any{"x"}},
+	})
+	r.NoError(err)
+	a.JSONEq(`[
+		{"op": "remove", "path": "/a/0"},
+		{"op": "replace", "path": "/b", "value": null},
+		{"op": "add", "path": "/c/-", "value": ["x"]}
+	]`, string(js))
+}
+
+// apply applies patch to doc and returns the result, implementing just
+// enough of RFC 6902 to verify the operations generated by the package.
+func apply(doc any, patch Patch) any {
+	for _, op := range patch {
+		var tokens []string
+		if op.Path != "" {
+			tokens = strings.Split(op.Path[1:], "/")
+		}
+		doc = applyOp(doc, tokens, op)
+	}
+	return doc
+}
+
+// applyOp applies op to the value identified by tokens within val and
+// returns the result.
+func applyOp(val any, tokens []string, op Operation) any {
+	if len(tokens) == 0 {
+		return op.Value
+	}
+
+	tok := strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[0])
+	switch v := val.(type) {
+	case map[string]any:
+		switch {
+		case len(tokens) > 1:
+			v[tok] = applyOp(v[tok], tokens[1:], op)
+		case op.Op == OpRemove:
+			delete(v, tok)
+		default:
+			v[tok] = op.Value
+		}
+	case []any:
+		idx, _ := strconv.Atoi(tok)
+		switch {
+		case len(tokens) > 1:
+			v[idx] = applyOp(v[idx], tokens[1:], op)
+		case op.Op == OpRemove:
+			return append(v[:idx], v[idx+1:]...)
+		case op.Op == OpAdd:
+			return append(v[:idx], append([]any{op.Value}, v[idx:]...)...)
+		default:
+			v[idx] = op.Value
+		}
+	}
+	return val
+}
@@ -1,7 +1,29 @@
 // Package jsonpath implements RFC 9535 JSONPath query expressions.
+//
+// This package provides the high-level API for parsing and evaluating
+// queries. It delegates parsing to package
+// [github.com/theory/jsonpath/parser], which parses queries into the parse
+// trees defined by package [github.com/theory/jsonpath/spec], which in turn
+// implements query evaluation. Package
+// [github.com/theory/jsonpath/registry] provides the functions available to
+// queries. All share a single implementation, so queries parse, evaluate,
+// and fail with the same errors regardless of the package used.
+//
+// # Build Tags
+//
+// Build with the jsonpath_minimal build tag to omit the features that
+// depend on [encoding/json] decoding and reflection, which shrinks binaries
+// such as WASM apps that don't otherwise use them. With the tag,
+// [WithStructSupport] returns an [ErrUnsupportedValue] error for any input,
+// and queries don't decode [json.RawMessage] values, but treat them as
+// values with no JSON representation, which selectors never descend into
+// and filters never match.
 package jsonpath
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"iter"
 	"slices"
 
@@ -10,19 +32,172 @@ import (
 	"github.com/theory/jsonpath/spec"
 )
 
-// ErrPathParse errors are returned for path parse errors.
+// ErrPathParse errors are returned for path parse errors. All parse errors
+// wrap ErrPathParse; use [errors.Is] with [ErrSyntax] or [ErrSemantic] to
+// distinguish the class of error.
 var ErrPathParse = parser.ErrPathParse
 
+// ErrSyntax errors are returned for query syntax errors. ErrSyntax wraps
+// [ErrPathParse].
+var ErrSyntax = parser.ErrSyntax
+
+// ErrSemantic errors are returned for queries that are well-formed but
+// invalid, such as those calling unknown functions or passing invalid
+// function arguments. ErrSemantic wraps [ErrPathParse].
+var ErrSemantic = parser.ErrSemantic
+
+// ErrRelative errors are returned for queries that start with @ outside a
+// filter expression when the parser is not configured with [WithRelative].
+// ErrRelative wraps [ErrSyntax].
+var ErrRelative = parser.ErrRelative
+
+// ErrLimit errors are returned for queries that exceed the limits configured
+// by [WithMaxDepth] and [WithMaxSelectors]. ErrLimit wraps [ErrSemantic].
+var ErrLimit = parser.ErrLimit
+
+// ParseError describes a failure to parse a query. All errors returned by
+// [Parse] and [Parser.Parse] are *ParseError values; use [errors.As] to
+// access the position of the error and the tokens expected there.
+type ParseError = parser.ParseError
+
+// OrderedMap is implemented by JSON object types that preserve the order of
+// their members. Queries select the members of an OrderedMap in order,
+// rather than in the random order of a map[string]any, for deterministic
+// results.
+type OrderedMap = spec.OrderedMap
+
+// Array is implemented by JSON array types other than []any, such as the
+// sequences of a custom data model. Queries select the elements of an Array
+// as they do those of a []any.
+type Array = spec.Array
+
+// Adapter is implemented by values of custom data models, such as node
+// types that may be objects, arrays, or scalars, that adapt themselves to
+// the JSON data model as queries select from them. Together with
+// [OrderedMap] and [Array], it allows queries to select from custom data
+// models without first converting whole documents to maps and slices.
+type Adapter = spec.Adapter
+
+// OrderedObject is an [OrderedMap] that stores members in the order in
+// which they're first set. Use [UnmarshalOrdered] to decode JSON into
+// OrderedObject values.
+type OrderedObject = spec.OrderedObject
+
+// UnmarshalOrdered decodes JSON data into an any value for querying, like
+// [json.Unmarshal], except that it decodes objects as *[OrderedObject]
+// values, so that queries such as $.* and $..name select object members in
+// the order they appear in data.
+func UnmarshalOrdered(data []byte) (any, error) {
+	//nolint:wrapcheck
+	return spec.UnmarshalOrdered(data)
+}
+
 // Path represents a [RFC 9535] JSONPath query.
 //
 // [RFC 9535]: https://www.rfc-editor.org/rfc/rfc9535.html
 type Path struct {
 	q *spec.PathQuery
+
+	// lookups lists the selectors of q if it consists solely of child
+	// segments with a single name or index selector, such as $.a.b[0], and
+	// is nil otherwise. See selectLookups.
+	lookups []spec.Selector
+}
+
+// Querier defines the interface for evaluating JSONPath queries, as
+// implemented by [*Path]. Accept a Querier rather than a [*Path] to allow
+// mock queries in unit tests or to decorate queries with instrumentation.
+type Querier interface {
+	// Select returns the values that the query selects from input.
+	Select(input any) NodeList
+
+	// SelectLocated returns the values that the query selects from input as
+	// [spec.LocatedNode] structs that pair the values with their normalized
+	// paths.
+	SelectLocated(input any) LocatedNodeList
+
+	// String returns a string representation of the query.
+	String() string
 }
 
 // New creates and returns a new Path consisting of q.
 func New(q *spec.PathQuery) *Path {
-	return &Path{q: q}
+	return &Path{q: q, lookups: lookupChain(q)}
+}
+
+// lookupChain returns the selectors of q if q consists solely of child
+// segments with a single name or index selector, and nil otherwise.
+func lookupChain(q *spec.PathQuery) []spec.Selector {
+	segs := q.Segments()
+	for _, seg := range segs {
+		if seg.IsDescendant() || len(seg.Selectors()) != 1 {
+			return nil
+		}
+		switch seg.Selectors()[0].(type) {
+		case spec.Name, spec.Index:
+		default:
+			return nil
+		}
+	}
+
+	lookups := make([]spec.Selector, len(segs))
+	for i, seg := range segs {
+		lookups[i] = seg.Selectors()[0]
+	}
+	return lookups
+}
+
+// selectLookups selects lookups from input with a loop of map and slice
+// lookups, avoiding the overhead of evaluating segments for queries such as
+// $.a.b[0], common in configuration lookups. Returns the same result as
+// selecting the equivalent query.
+func selectLookups(lookups []spec.Selector, input any) NodeList {
+	if val, ok := lookup(lookups, input); ok {
+		return NodeList{val}
+	}
+	return NodeList{}
+}
+
+// lookup returns the value that lookups select from input and true, or
+// false if they select nothing. Allocates no memory to look up values in
+// map[string]any and []any values.
+func lookup(lookups []spec.Selector, input any) (any, bool) {
+	for _, sel := range lookups {
+		switch input.(type) {
+		case json.RawMessage, spec.OrderedMap, spec.Array, spec.Adapter:
+			// Let the selector decode or look it up.
+			res := sel.Select(input, nil)
+			if len(res) == 0 {
+				return nil, false
+			}
+			input = res[0]
+			continue
+		}
+		switch sel := sel.(type) {
+		case spec.Name:
+			obj, ok := input.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if input, ok = obj[string(sel)]; !ok {
+				return nil, false
+			}
+		case spec.Index:
+			arr, ok := input.([]any)
+			if !ok {
+				return nil, false
+			}
+			idx := int(sel)
+			if idx < 0 {
+				idx += len(arr)
+			}
+			if idx < 0 || idx >= len(arr) {
+				return nil, false
+			}
+			input = arr[idx]
+		}
+	}
+	return input, true
 }
 
 // Parse parses path, a JSONPath query string, into a Path. Returns an
@@ -37,19 +212,98 @@ func MustParse(path string) *Path {
 	return NewParser().MustParse(path)
 }
 
+// ParseLenient parses path, a JSONPath query string that may use common
+// syntax from the original Goessner JSONPath that RFC 9535 does not allow,
+// into a Path. See [WithLenient] for the syntax it accepts. Returns an
+// ErrPathParse on parse failure.
+func ParseLenient(path string) (*Path, error) {
+	return NewParser(WithLenient()).Parse(path)
+}
+
 // String returns a string representation of p.
 func (p *Path) String() string {
 	return p.q.String()
 }
 
+// Format returns a string representation of p formatted according to opt,
+// such as with single quotation marks or dot notation, as described by
+// [spec.PathQuery.Format]. Parsing the result returns an equivalent Path.
+func (p *Path) Format(opt ...spec.FormatOption) string {
+	return p.q.Format(opt...)
+}
+
 // Query returns p's root Query.
 func (p *Path) Query() *spec.PathQuery {
 	return p.q
 }
 
+// Append returns a new Path that consists of p's query followed by
+// segments, leaving p unchanged. Useful to compose a compiled path with
+// segments built with [spec.Child] and [spec.Descendant] or a [Builder]:
+//
+//	books := jsonpath.MustParse("$.store.book")
+//	titles := books.Append(spec.Child(spec.Wildcard()), spec.Child(spec.Name("title")))
+func (p *Path) Append(segments ...*spec.Segment) *Path {
+	return New(spec.Query(p.q.IsRoot(), append(slices.Clip(p.q.Segments()), segments...)))
+}
+
+// MarshalBinary encodes p as a JSON abstract syntax tree, as produced by
+// [spec.EncodeAST], so that it can be cached or sent to another process and
+// reconstructed with [Path.UnmarshalBinary] or [Parser.ParseBinary] without
+// parsing the query string. Implements [encoding.BinaryMarshaler].
+//
+//nolint:wrapcheck
+func (p *Path) MarshalBinary() ([]byte, error) {
+	return spec.EncodeAST(p.q)
+}
+
+// UnmarshalBinary decodes data, an abstract syntax tree produced by
+// [Path.MarshalBinary], into p, resolving functions from the RFC 9535
+// functions in [registry.New]. Use [Parser.ParseBinary] to resolve function
+// extensions. Returns a [spec.ErrAST] error if data is not a valid AST.
+// Implements [encoding.BinaryUnmarshaler].
+func (p *Path) UnmarshalBinary(data []byte) error {
+	path, err := NewParser().ParseBinary(data)
+	if err != nil {
+		return err
+	}
+	*p = *path
+	return nil
+}
+
 // Select returns the values that JSONPath query p selects from input.
+// For relative queries parsed with [WithRelative], input is also the current
+// node.
+//
+// Input may contain [json.RawMessage] values, which Select decodes lazily,
+// only when a selector descends into them, and then only one level deep.
+// This avoids decoding large blobs that the query never visits. Selected
+// objects and arrays that the query does not descend into remain
+// json.RawMessage values. Unlike map[string]any objects, json.RawMessage
+// objects have an order, so wildcard, filter, and descendant selectors
+// select their members in the order they appear in the JSON. To select the
+// members of map[string]any objects in a deterministic order, use
+// [Path.SelectWith] with [WithSortedNames]. The jsonpath_minimal build tag
+// omits json.RawMessage decoding, as described in the package documentation.
 func (p *Path) Select(input any) NodeList {
-	return p.q.Select(nil, input)
+	if p.lookups != nil {
+		return selectLookups(p.lookups, input)
+	}
+	return p.q.Select(input, input)
+}
+
+// SelectRelative returns the values that JSONPath query p selects relative
+// to current. Relative queries, those that start with @ and parsed with
+// [WithRelative], select from current, while absolute queries, those that
+// start with $, select from root.
+func (p *Path) SelectRelative(current, root any) NodeList {
+	if p.lookups != nil {
+		if p.q.IsRoot() {
+			return selectLookups(p.lookups, root)
+		}
+		return selectLookups(p.lookups, current)
+	}
+	return p.q.Select(current, root)
 }
 
 // SelectLocated returns the values that JSONPath query p selects from input
@@ -59,12 +313,290 @@ func (p *Path) Select(input any) NodeList {
 //
 // [normalized paths]: https://www.rfc-editor.org/rfc/rfc9535#section-2.7
 func (p *Path) SelectLocated(input any) LocatedNodeList {
-	return p.q.SelectLocated(nil, input, spec.NormalizedPath{})
+	return p.q.SelectLocated(input, input, spec.NormalizedPath{})
+}
+
+// SelectParents returns the parents of the values that JSONPath query p
+// selects from input: the arrays and objects that contain them, in the order
+// p selects their first children. Returns each parent only once, no matter
+// how many of its children p selects, and omits the root node, which has no
+// parent. Useful to find the arrays and objects that contain matching
+// values anywhere in input, such as those that contain the string "red",
+// the parents of the values selected by $..[?@ == "red"]. See also
+// [Path.SelectParentsLocated].
+func (p *Path) SelectParents(input any) NodeList {
+	parents := p.SelectParentsLocated(input)
+	res := make(NodeList, len(parents))
+	for i, n := range parents {
+		res[i] = n.Node
+	}
+	return res
+}
+
+// SelectKeys returns the member names and array indexes of the values that
+// JSONPath query p selects from input, in the same order as
+// [Path.SelectLocated]: a [spec.Name] for each object member and a
+// [spec.Index] for each array element, the last element of each node's
+// normalized path. Omits the root node, which has neither. Useful to get the
+// names of the members that match a filter, such as the names of the
+// members of $.config with the value true selected by
+// $.config[?@ == true], rather than their values:
+//
+//	for _, key := range p.SelectKeys(input) {
+//		if name, ok := key.(spec.Name); ok {
+//			fmt.Println(string(name))
+//		}
+//	}
+func (p *Path) SelectKeys(input any) []spec.NormalSelector {
+	nodes := p.SelectLocated(input)
+	keys := make([]spec.NormalSelector, 0, len(nodes))
+	for _, n := range nodes {
+		if len(n.Path) > 0 {
+			keys = append(keys, n.Path[len(n.Path)-1])
+		}
+	}
+	return keys
+}
+
+// SelectParentsLocated returns the parents of the values that JSONPath query
+// p selects from input as [spec.LocatedNode] structs that pair the parents
+// with their normalized paths. Otherwise identical to [Path.SelectParents].
+func (p *Path) SelectParentsLocated(input any) LocatedNodeList {
+	res := LocatedNodeList{}
+	seen := map[string]struct{}{}
+	for _, n := range p.SelectLocated(input) {
+		if len(n.Path) == 0 {
+			continue
+		}
+		path := slices.Clip(n.Path[:len(n.Path)-1])
+		key := path.String()
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		if parent, ok := path.Resolve(input); ok {
+			res = append(res, &spec.LocatedNode{Path: path, Node: parent})
+		}
+	}
+	return res
+}
+
+// All returns an iterator over the values that JSONPath query p selects
+// from input, in the same order as [Path.Select]. Evaluates the query
+// depth-first, yielding each value as soon as the final segment selects it,
+// so that breaking out of a loop over the iterator stops evaluation without
+// selecting the remaining values. Descendant segments, too, visit
+// descendants only until the loop breaks. Useful for processing only the
+// first matches of a query in a large document.
+func (p *Path) All(input any) iter.Seq[any] {
+	return func(yield func(any) bool) {
+		if p.lookups != nil {
+			if v, ok := lookup(p.lookups, input); ok {
+				yield(v)
+			}
+			return
+		}
+		yieldAll(p.q.Segments(), input, input, yield)
+	}
+}
+
+// AllLocated returns an iterator over the values that JSONPath query p
+// selects from input as [spec.LocatedNode] structs, in the same order as
+// [Path.SelectLocated]. Evaluates the query lazily, as described for
+// [Path.All].
+func (p *Path) AllLocated(input any) iter.Seq[*spec.LocatedNode] {
+	return func(yield func(*spec.LocatedNode) bool) {
+		yieldAllLocated(p.q.Segments(), input, input, spec.NormalizedPath{}, yield)
+	}
+}
+
+// First returns the first value that JSONPath query p selects from input,
+// in the same order as [Path.Select], and true, or false if p selects
+// nothing. Stops evaluation at the first match, as described for
+// [Path.All], so it's much faster than Select for queries that select many
+// nodes, such as descendant queries on large documents.
+func (p *Path) First(input any) (any, bool) {
+	for v := range p.All(input) {
+		return v, true
+	}
+	return nil, false
+}
+
+// IsSingular returns true if p is a singular query, one that consists
+// solely of child segments with a single name or index selector, such as
+// $.a.b[3].c, and therefore selects at most one node.
+func (p *Path) IsSingular() bool {
+	return p.lookups != nil
+}
+
+// SelectSingular returns the value that singular query p selects from input
+// and true, or false if p selects nothing. It selects the value with direct
+// map and slice lookups rather than by evaluating each segment, and
+// allocates no memory for map[string]any and []any values. If p is not
+// singular, as reported by [Path.IsSingular], SelectSingular returns the
+// first value p selects, as [Path.First] does.
+func (p *Path) SelectSingular(input any) (any, bool) {
+	if p.lookups == nil {
+		return p.First(input)
+	}
+	return lookup(p.lookups, input)
+}
+
+// Exists returns true if JSONPath query p selects at least one node from
+// input. Stops evaluation at the first match, as described for [Path.All].
+func (p *Path) Exists(input any) bool {
+	_, ok := p.First(input)
+	return ok
+}
+
+// Match returns true if JSONPath query p selects at least one node from
+// input, for routing documents to the queries they match. Unlike
+// [Path.Exists], it never yields values: it stops evaluation as soon as any
+// node matches, including while testing the children of a node against a
+// filter selector, and existence tests within filters, such as @.isbn,
+// stop the same way.
+func (p *Path) Match(input any) bool {
+	if p.lookups != nil {
+		_, ok := lookup(p.lookups, input)
+		return ok
+	}
+	return p.q.Exists(input, input)
+}
+
+// yieldAll passes the values that segs select from current to yield.
+// Evaluates segs depth-first so that it yields each value as soon as the
+// final segment selects it. Returns false if yield returns false.
+func yieldAll(segs []*spec.Segment, current, root any, yield func(any) bool) bool {
+	if len(segs) == 0 {
+		return yield(current)
+	}
+	seg := segs[0]
+	for _, sel := range seg.Selectors() {
+		for _, v := range sel.Select(current, root) {
+			if !yieldAll(segs[1:], v, root, yield) {
+				return false
+			}
+		}
+	}
+	if !seg.IsDescendant() {
+		return true
+	}
+
+	// Select from descendants one child at a time.
+	switch val := current.(type) {
+	case []any:
+		for _, v := range val {
+			if !yieldAll(segs, v, root, yield) {
+				return false
+			}
+		}
+	case map[string]any:
+		for _, v := range val {
+			if !yieldAll(segs, v, root, yield) {
+				return false
+			}
+		}
+	case json.RawMessage, spec.OrderedMap, spec.Array, spec.Adapter:
+		// Let the wildcard selector decode and order it.
+		for _, v := range spec.Wildcard().Select(val, nil) {
+			if !yieldAll(segs, v, root, yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// yieldAllLocated passes the nodes that segs select from current, located
+// at parent, to yield, as for yieldAll.
+func yieldAllLocated(
+	segs []*spec.Segment,
+	current, root any,
+	parent spec.NormalizedPath,
+	yield func(*spec.LocatedNode) bool,
+) bool {
+	if len(segs) == 0 {
+		return yield(&spec.LocatedNode{Node: current, Path: parent})
+	}
+	seg := segs[0]
+	for _, sel := range seg.Selectors() {
+		for _, n := range sel.SelectLocated(current, root, parent) {
+			if len(segs) == 1 {
+				if !yield(n) {
+					return false
+				}
+			} else if !yieldAllLocated(segs[1:], n.Node, root, n.Path, yield) {
+				return false
+			}
+		}
+	}
+	if !seg.IsDescendant() {
+		return true
+	}
+
+	// Select from descendants one child at a time.
+	switch val := current.(type) {
+	case []any:
+		for i, v := range val {
+			if !yieldAllLocated(segs, v, root, append(parent, spec.Index(i)), yield) {
+				return false
+			}
+		}
+	case map[string]any:
+		for k, v := range val {
+			if !yieldAllLocated(segs, v, root, append(parent, spec.Name(k)), yield) {
+				return false
+			}
+		}
+	case json.RawMessage, spec.OrderedMap, spec.Array, spec.Adapter:
+		// Let the wildcard selector decode and order it.
+		for _, n := range spec.Wildcard().SelectLocated(val, nil, parent) {
+			if !yieldAllLocated(segs, n.Node, root, n.Path, yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SelectChan sends the values that JSONPath query p selects from input to
+// the returned channel as [spec.LocatedNode] structs, in the same order as
+// [Path.SelectLocated], and closes the channel when done. The channel buffers
+// up to buffer nodes; once it's full, evaluation pauses until the receiver
+// catches up. Evaluation stops and the channel closes early when ctx is
+// done, so receivers that stop reading before the channel closes must cancel
+// ctx to release the evaluating goroutine.
+func (p *Path) SelectChan(ctx context.Context, input any, buffer int) <-chan *spec.LocatedNode {
+	ch := make(chan *spec.LocatedNode, buffer)
+	go func() {
+		defer close(ch)
+		for n := range p.AllLocated(input) {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case ch <- n:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
 }
 
 // Parser parses JSONPath strings into [*Path]s.
 type Parser struct {
-	reg *registry.Registry
+	reg  *registry.Registry
+	opts []parser.Option
+
+	// noDefaults indicates whether to omit the RFC 9535 functions when
+	// creating a registry.
+	noDefaults bool
+
+	// feat records the capabilities enabled by the options other than the
+	// registry's functions. When feat.Lenient is true, the last of opts is
+	// parser.WithLenient.
+	feat Features
 }
 
 // Option defines a parser option.
@@ -76,6 +608,118 @@ func WithRegistry(reg *registry.Registry) Option {
 	return func(p *Parser) { p.reg = reg }
 }
 
+// WithoutDefaultFunctions configures a Parser to start with an empty
+// function registry rather than one containing the RFC 9535 functions, so
+// that queries can use only the functions subsequently registered in
+// [Parser.Registry]. Useful for security-sensitive deployments, e.g., to
+// exclude the regular expression functions match() and search() while
+// keeping length(), count(), and value(). Ignored when combined with
+// [WithRegistry], which determines the functions itself.
+func WithoutDefaultFunctions() Option {
+	return func(p *Parser) { p.noDefaults = true }
+}
+
+// WithRelative configures a Parser to accept top-level relative queries that
+// start with @, for evaluation with [Path.SelectRelative]. Useful for
+// evaluating queries relative to a current node, as in template engines. By
+// default, and as required by RFC 9535, @ is valid only within filter
+// expressions.
+func WithRelative() Option {
+	return func(p *Parser) {
+		p.opts = append(p.opts, parser.WithRelative())
+		p.feat.Relative = true
+	}
+}
+
+// WithLenient configures a Parser to accept common constructs from the
+// original [Goessner JSONPath] that RFC 9535 does not allow: unquoted names
+// in brackets, e.g., $[foo]; single equals comparisons, e.g., @.x = 1; and
+// the .length pseudo-property compared in a filter expression, e.g.,
+// @.tags.length > 2, which parses as length(@.tags) > 2. Lenient queries
+// parse to the same [Path] as the equivalent RFC 9535 queries, so
+// [Path.String] returns RFC 9535 syntax. Useful for migrating queries
+// written for other implementations.
+//
+// [Goessner JSONPath]: https://goessner.net/articles/JsonPath/
+func WithLenient() Option {
+	return func(p *Parser) { p.feat.Lenient = true }
+}
+
+// WithCompositeLiterals configures a Parser to accept JSON array and object
+// literals in filter comparisons, such as @.tags == ["a", "b"], which RFC
+// 9535 does not allow. Arrays and objects compare equal when they're deeply
+// equal, with numbers compared by value regardless of their Go types.
+func WithCompositeLiterals() Option {
+	return func(p *Parser) {
+		p.opts = append(p.opts, parser.WithCompositeLiterals())
+		p.feat.CompositeLiterals = true
+	}
+}
+
+// WithArithmetic configures a Parser to accept the arithmetic operators +,
+// -, *, /, and % in filter comparisons, such as @.price * @.qty > 100,
+// which RFC 9535 does not allow. Arithmetic on numbers of different types
+// coerces them as described by [spec.ArithmeticExpr], while arithmetic on
+// other values produces no value, like a missing member.
+func WithArithmetic() Option {
+	return func(p *Parser) {
+		p.opts = append(p.opts, parser.WithArithmetic())
+		p.feat.Arithmetic = true
+	}
+}
+
+// WithMembership configures a Parser to accept membership tests with the
+// in operator in filter expressions, such as @.status in ["active",
+// "pending"], which RFC 9535 does not allow. A membership test is true when
+// the value on the left equals an element of the array on the right, which
+// may be an array literal or a singular query or function expression that
+// produces an array.
+func WithMembership() Option {
+	return func(p *Parser) {
+		p.opts = append(p.opts, parser.WithMembership())
+		p.feat.Membership = true
+	}
+}
+
+// WithSelector configures a Parser to parse bracketed selectors that start
+// with prefix, such as $[#], with parse, to support custom [spec.Selector]
+// implementations. Custom selectors evaluate like standard selectors, but
+// [Path.MarshalBinary] cannot encode them. Panics if prefix starts standard
+// JSONPath syntax; see [parser.WithSelector].
+func WithSelector(prefix rune, parse parser.SelectorParser) Option {
+	opt := parser.WithSelector(prefix, parse)
+	return func(p *Parser) {
+		p.opts = append(p.opts, opt)
+		if i, found := slices.BinarySearch(p.feat.Selectors, string(prefix)); !found {
+			p.feat.Selectors = slices.Insert(p.feat.Selectors, i, string(prefix))
+		}
+	}
+}
+
+// WithMaxDepth configures a Parser to reject queries that nest filter
+// selectors, parenthesized expressions, function expressions, and array and
+// object literals more than depth levels deep with an [ErrLimit] error.
+// Useful for rejecting pathological queries from untrusted sources while
+// parsing them, rather than walking them afterward. A depth less than 1
+// imposes no limit.
+func WithMaxDepth(depth int) Option {
+	return func(p *Parser) {
+		p.opts = append(p.opts, parser.WithMaxDepth(depth))
+		p.feat.MaxDepth = max(depth, 0)
+	}
+}
+
+// WithMaxSelectors configures a Parser to reject queries with more than
+// count selectors, including those of queries in filter expressions, with
+// an [ErrLimit] error. Pair with [Path.Complexity] to reject costly queries
+// from untrusted sources. A count less than 1 imposes no limit.
+func WithMaxSelectors(count int) Option {
+	return func(p *Parser) {
+		p.opts = append(p.opts, parser.WithMaxSelectors(count))
+		p.feat.MaxSelectors = max(count, 0)
+	}
+}
+
 // NewParser creates a new Parser configured by opt.
 func NewParser(opt ...Option) *Parser {
 	p := &Parser{}
@@ -83,19 +727,33 @@ func NewParser(opt ...Option) *Parser {
 		o(p)
 	}
 
-	if p.reg == nil {
+	switch {
+	case p.reg != nil:
+	case p.noDefaults:
+		p.reg = registry.NewEmpty()
+	default:
 		p.reg = registry.New()
 	}
 
+	if p.feat.Lenient {
+		p.opts = append(slices.Clip(p.opts), parser.WithLenient())
+	}
+
 	return p
 }
 
+// Registry returns the function registry used by c. Functions registered in
+// the registry become available to queries subsequently parsed by c.
+func (c *Parser) Registry() *registry.Registry {
+	return c.reg
+}
+
 // Parse parses path, a JSON Path query string, into a Path. Returns an
 // ErrPathParse on parse failure.
 //
 //nolint:wrapcheck
 func (c *Parser) Parse(path string) (*Path, error) {
-	q, err := parser.Parse(c.reg, path)
+	q, err := parser.Parse(c.reg, path, c.opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -105,11 +763,97 @@ func (c *Parser) Parse(path string) (*Path, error) {
 // MustParse parses path, a JSON Path query string, into a Path. Panics with
 // an ErrPathParse on parse failure.
 func (c *Parser) MustParse(path string) *Path {
-	q, err := parser.Parse(c.reg, path)
+	p, err := c.Parse(path)
 	if err != nil {
 		panic(err)
 	}
-	return New(q)
+	return p
+}
+
+// ParseBinary decodes data, an abstract syntax tree produced by
+// [Path.MarshalBinary], into a Path, resolving functions from c's
+// [registry.Registry] and validating their arguments as [Parser.Parse] does.
+// Returns a [spec.ErrAST] error if data is not a valid AST or uses functions
+// unknown to c.
+//
+//nolint:wrapcheck
+func (c *Parser) ParseBinary(data []byte) (*Path, error) {
+	q, err := spec.DecodeAST(data, c.resolve)
+	if err != nil {
+		return nil, err
+	}
+	return New(q), nil
+}
+
+// resolve returns the function named name from c's registry, or an error if
+// it does not exist or if args are not valid arguments to it. Used to
+// resolve functions in [Parser.ParseBinary].
+func (c *Parser) resolve(name string, args []spec.FunctionExprArg) (spec.PathFunction, error) {
+	fn := c.reg.Get(name)
+	if fn == nil {
+		return nil, fmt.Errorf("unknown function %v()", name)
+	}
+	if err := fn.Validate(args); err != nil {
+		return nil, fmt.Errorf("function %v() %w", name, err)
+	}
+	return fn, nil
+}
+
+// Features describes the JSONPath capabilities enabled in a [Parser], so
+// that services can advertise them to clients, and clients can check
+// whether a parser supports a query before sending it.
+type Features struct {
+	// Functions lists the names of the functions available to queries,
+	// including both RFC 9535 functions and function extensions, sorted by
+	// name.
+	Functions []string `json:"functions"`
+
+	// Arithmetic is true when queries may use arithmetic operators in
+	// comparisons, as enabled by [WithArithmetic].
+	Arithmetic bool `json:"arithmetic"`
+
+	// CompositeLiterals is true when queries may compare array and object
+	// literals, as enabled by [WithCompositeLiterals].
+	CompositeLiterals bool `json:"composite_literals"`
+
+	// Lenient is true when queries may use the Goessner JSONPath syntax
+	// enabled by [WithLenient].
+	Lenient bool `json:"lenient"`
+
+	// Membership is true when queries may test membership with the in
+	// operator, as enabled by [WithMembership].
+	Membership bool `json:"membership"`
+
+	// Relative is true when queries may start with @, as enabled by
+	// [WithRelative].
+	Relative bool `json:"relative"`
+
+	// Selectors lists the prefixes of the custom selectors configured by
+	// [WithSelector], sorted.
+	Selectors []string `json:"selectors,omitempty"`
+
+	// MaxDepth is the maximum nesting depth of expressions in queries, as
+	// configured by [WithMaxDepth], or 0 for no limit.
+	MaxDepth int `json:"max_depth"`
+
+	// MaxSelectors is the maximum number of selectors in queries, as
+	// configured by [WithMaxSelectors], or 0 for no limit.
+	MaxSelectors int `json:"max_selectors"`
+}
+
+// HasFunction returns true if f includes the function named name.
+func (f Features) HasFunction(name string) bool {
+	_, found := slices.BinarySearch(f.Functions, name)
+	return found
+}
+
+// Features returns the features enabled in c. Queries that use features not
+// enabled in c fail to parse.
+func (c *Parser) Features() Features {
+	feat := c.feat
+	feat.Functions = c.reg.Names()
+	feat.Selectors = slices.Clone(c.feat.Selectors)
+	return feat
 }
 
 // NodeList is a list of nodes selected by a JSONPath query. Each node
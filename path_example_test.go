@@ -1,12 +1,15 @@
 package jsonpath_test
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/examples"
 	"github.com/theory/jsonpath/registry"
 	"github.com/theory/jsonpath/spec"
 )
@@ -55,6 +58,58 @@ func ExamplePath_Select() {
 	// 5.99
 }
 
+func ExampleBind() {
+	// Declare the values to extract.
+	type Store struct {
+		Bicycle string   `jsonpath:"$.store.bicycle.color"`
+		Cheap   []string `jsonpath:"$.store.book[?@.price < 10].title"`
+	}
+
+	// Populate the struct from the bookstore.
+	var store Store
+	if err := jsonpath.Bind(bookstore(), &store); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%v bicycle\n", store.Bicycle)
+	fmt.Printf("%q\n", store.Cheap)
+	// Output:
+	// red bicycle
+	// ["Sayings of the Century" "Moby Dick"]
+}
+
+func ExamplePath_SelectInto() {
+	type Book struct {
+		Title string  `json:"title"`
+		Price float64 `json:"price"`
+	}
+
+	// Select a list of books into a slice.
+	var cheap []Book
+	p := jsonpath.MustParse("$.store.book[?@.price < 10]")
+	if err := p.SelectInto(bookstore(), &cheap); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", cheap)
+
+	// Select a singular query into a single value.
+	var first Book
+	p = jsonpath.MustParse("$.store.book[0]")
+	if err := p.SelectInto(bookstore(), &first); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%+v\n", first)
+
+	// Singular queries that select nothing return ErrNoMatch.
+	p = jsonpath.MustParse("$.store.book[99]")
+	err := p.SelectInto(bookstore(), &first)
+	fmt.Println(errors.Is(err, jsonpath.ErrNoMatch))
+	// Output:
+	// [{Title:Sayings of the Century Price:8.95} {Title:Moby Dick Price:8.99}]
+	// {Title:Sayings of the Century Price:8.95}
+	// true
+}
+
 func ExamplePath_SelectLocated() {
 	// Load some JSON.
 	menu := map[string]any{
@@ -78,6 +133,251 @@ func ExamplePath_SelectLocated() {
 	// $['apps']['salsa']: 5.99
 }
 
+// Select only the first two books that cost less than $10, stopping
+// evaluation once found.
+func ExamplePath_All() {
+	p := jsonpath.MustParse("$..book[?@.price < 10].title")
+	count := 0
+	for title := range p.All(examples.Bookstore()) {
+		fmt.Println(title)
+		if count++; count == 2 {
+			break
+		}
+	}
+
+	// Output:
+	// Sayings of the Century
+	// Moby Dick
+}
+
+// Find the first book by an author whose name contains "Tolkien", and check
+// whether any book lacks an ISBN, without selecting every book.
+func ExamplePath_First() {
+	store := examples.Bookstore()
+	p := jsonpath.MustParse(`$..book[?search(@.author, "Tolkien")].title`)
+	if title, ok := p.First(store); ok {
+		fmt.Println(title)
+	}
+
+	fmt.Println(jsonpath.MustParse("$..book[?!@.isbn]").Exists(store))
+
+	// Output:
+	// The Lord of the Rings
+	// true
+}
+
+// Look up a single value with a singular query.
+func ExamplePath_SelectSingular() {
+	store := examples.Bookstore()
+	p := jsonpath.MustParse(`$.store.book[2].author`)
+	fmt.Println(p.IsSingular())
+	if author, ok := p.SelectSingular(store); ok {
+		fmt.Println(author)
+	}
+
+	// Output:
+	// true
+	// Herman Melville
+}
+
+// Stream the titles of the books in a bookstore object through a channel.
+func ExamplePath_SelectChan() {
+	// Cancel the context to stop evaluation early.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Parse a JSONPath and stream the nodes it selects.
+	p := jsonpath.MustParse("$.store.book[*].title")
+	for node := range p.SelectChan(ctx, examples.Bookstore(), 2) {
+		fmt.Printf("%v: %v\n", node.Path, node.Node)
+	}
+
+	// Output:
+	// $['store']['book'][0]['title']: Sayings of the Century
+	// $['store']['book'][1]['title']: Sword of Honour
+	// $['store']['book'][2]['title']: Moby Dick
+	// $['store']['book'][3]['title']: The Lord of the Rings
+}
+
+// Modify the values that paths select from a bookstore object.
+func ExampleValidate() {
+	for _, path := range []string{`$.items[?@.price < true].name`, `$[?lenght(@.tags) > 1]`} {
+		report, err := jsonpath.Validate(path)
+		if err != nil {
+			fmt.Printf("%v\n", err)
+		}
+		for _, w := range report.Warnings {
+			fmt.Printf("%v: %v\n", w.Code, w.Message)
+		}
+	}
+	// Output:
+	// always-false: comparison @["price"] < true is false for every node
+	// jsonpath: unknown function lenght() at position 4
+	// unknown-function: unknown function lenght(); did you mean length()?
+}
+
+func ExamplePath_MarshalBinary() {
+	// Compile a query and serialize its syntax tree.
+	data, err := jsonpath.MustParse(`$.books[?@.price < 10].title`).MarshalBinary()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Reconstruct the query without parsing it.
+	var path jsonpath.Path
+	if err := path.UnmarshalBinary(data); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%v\n", path.String())
+
+	books := []any{
+		map[string]any{"title": "Moby Dick", "price": 8.99},
+		map[string]any{"title": "Sayings of the Century", "price": 12.99},
+	}
+	fmt.Printf("%v\n", path.Select(map[string]any{"books": books}))
+	// Output:
+	// $["books"][?@["price"] < 10]["title"]
+	// [Moby Dick]
+}
+
+func ExamplePath_Set() {
+	// Parse a JSONPath and set the price of books over 10.
+	p := jsonpath.MustParse(`$.store.book[?@.price > 10].price`)
+	store := p.Set(examples.Bookstore(), 9.99)
+
+	// Delete the bicycle.
+	store = jsonpath.MustParse(`$.store.bicycle`).Delete(store)
+
+	// Show the results.
+	fmt.Printf("%v\n", jsonpath.MustParse(`$.store.*`).Select(store))
+	fmt.Printf("%v\n", jsonpath.MustParse(`$..price`).Select(store))
+	// Output:
+	// [[map[author:Nigel Rees category:reference price:8.95 title:Sayings of the Century] map[author:Evelyn Waugh category:fiction price:9.99 title:Sword of Honour] map[author:Herman Melville category:fiction isbn:0-553-21311-3 price:8.99 title:Moby Dick] map[author:J. R. R. Tolkien category:fiction isbn:0-395-19395-8 price:9.99 title:The Lord of the Rings]]]
+	// [8.95 9.99 8.99 9.99]
+}
+
+// Select values from each line of newline-delimited JSON logs.
+// Disable the regular expression functions match() and search().
+func ExampleWithoutDefaultFunctions() {
+	parser := jsonpath.NewParser(jsonpath.WithoutDefaultFunctions())
+
+	// Register the other RFC 9535 functions.
+	defaults := registry.New()
+	for _, name := range []string{"length", "count", "value"} {
+		if err := parser.Registry().RegisterFunction(defaults.Get(name)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	fmt.Printf("%v\n", parser.Features().Functions)
+
+	_, err := parser.Parse(`$[?match(@.name, ".*")]`)
+	fmt.Printf("%v\n", err)
+	// Output:
+	// [count length value]
+	// jsonpath: unknown function match() at position 4
+}
+
+// Parse a query written with Goessner JSONPath syntax.
+func ExampleParseLenient() {
+	p, err := jsonpath.ParseLenient(`$.store.book[?(@.price = 8.95)][author]`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Show the equivalent RFC 9535 query and the values it selects.
+	fmt.Printf("%v\n", p)
+	fmt.Printf("%v\n", p.Select(bookstore()))
+	// Output:
+	// $["store"]["book"][?(@["price"] == 8.95)]["author"]
+	// [Nigel Rees]
+}
+
+func ExampleEachLine() {
+	logs := strings.NewReader(`{"level": "info", "msg": "started"}
+{"level": "error", "msg": "disk full"}
+{"level": "error", "msg": "shutting down"}
+`)
+
+	// Select the message from each line.
+	p := jsonpath.MustParse(`$.msg`)
+	err := jsonpath.EachLine(logs, p, func(result []any) error {
+		fmt.Printf("%v\n", result)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Output:
+	// [started]
+	// [disk full]
+	// [shutting down]
+}
+
+// Evaluate a request-scoped function with WithFunction.
+func ExampleWithFunction() {
+	// Register owned_by_user() to match nothing outside a request.
+	reg := registry.New()
+	err := reg.Register(
+		"owned_by_user",
+		spec.FuncLogical,
+		func(args []spec.FunctionExprArg) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected 1 argument but found %v", len(args))
+			}
+			return nil
+		},
+		func([]spec.JSONPathValue) spec.JSONPathValue { return spec.LogicalFalse },
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p := jsonpath.NewParser(jsonpath.WithRegistry(reg)).MustParse(
+		`$.docs[?owned_by_user(@.owner)].title`,
+	)
+
+	docs := map[string]any{"docs": []any{
+		map[string]any{"title": "Plan", "owner": "alice"},
+		map[string]any{"title": "Budget", "owner": "bob"},
+	}}
+
+	// Select the titles owned by the user making each request.
+	for _, user := range []string{"alice", "bob"} {
+		nodes, err := p.SelectWith(docs, jsonpath.WithFunction(
+			"owned_by_user",
+			func(args []spec.JSONPathValue) spec.JSONPathValue {
+				if v := spec.ValueFrom(args[0]); v != nil && v.Value() == user {
+					return spec.LogicalTrue
+				}
+				return spec.LogicalFalse
+			},
+		))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%v: %v\n", user, nodes)
+	}
+	// Output:
+	// alice: [Plan]
+	// bob: [Budget]
+}
+
+// Optimize queries with a single-element slice and a constant filter
+// expression.
+func ExamplePath_Optimize() {
+	p := jsonpath.MustParse(`$.store.book[1:2].title`).Optimize()
+	fmt.Printf("%v: %v\n", p, p.Select(examples.Bookstore()))
+
+	p = jsonpath.MustParse(`$.store.book[?@.price < 9 && 1 == 1].title`).Optimize()
+	fmt.Printf("%v: %v\n", p, p.Select(examples.Bookstore()))
+
+	// Queries that can never select a node reduce to an empty slice.
+	fmt.Printf("%v\n", jsonpath.MustParse(`$..book[?1 > 2]`).Optimize())
+	// Output:
+	// $["store"]["book"][1]["title"]: [Sword of Honour]
+	// $["store"]["book"][?@["price"] < 9]["title"]: [Sayings of the Century Moby Dick]
+	// $[:0]
+}
+
 func ExampleLocatedNodeList() {
 	// Load some JSON.
 	menu := map[string]any{
@@ -185,6 +485,43 @@ func ExampleLocatedNodeList_Clone() {
 }
 
 // Use the Parser to parse a collection of paths.
+// Decode JSON with UnmarshalOrdered to select object members in the order
+// they appear in the JSON, rather than in random map order.
+func ExampleUnmarshalOrdered() {
+	doc, err := jsonpath.UnmarshalOrdered([]byte(`{"zebra": 3, "apple": 1, "mango": 2}`))
+	if err != nil {
+		log.Fatal(err)
+	}
+	p := jsonpath.MustParse(`$.*`)
+	fmt.Println(p.Select(doc))
+	// Output: [3 1 2]
+}
+
+// Build a query that selects the titles of the books that cost less than 10.
+func ExampleQuery() {
+	price := jsonpath.Current().Child("price").Singular()
+	books := jsonpath.Query().Child("store").Child("book").Filter(
+		spec.Comparison(price, spec.LessThan, spec.Literal(10)),
+	)
+	p := books.Child("title").Path()
+	fmt.Println(p)
+	fmt.Println(p.Select(bookstore()))
+	// Output:
+	// $["store"]["book"][?@["price"] < 10]["title"]
+	// [Sayings of the Century Moby Dick]
+}
+
+// Compose a compiled path with more segments.
+func ExamplePath_Append() {
+	books := jsonpath.MustParse("$.store.book[*]")
+	authors := books.Append(spec.Child(spec.Name("author")))
+	fmt.Println(authors)
+	fmt.Println(authors.Select(bookstore()))
+	// Output:
+	// $["store"]["book"][*]["author"]
+	// [Nigel Rees Evelyn Waugh Herman Melville J. R. R. Tolkien]
+}
+
 func ExampleParser() {
 	// Create a new parser using the default function registry.
 	parser := jsonpath.NewParser()
@@ -295,47 +632,5 @@ func firstFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
 
 // bookstore returns an unmarshaled JSON object.
 func bookstore() any {
-	src := []byte(`{
-	  "store": {
-	    "book": [
-	    {
-	      "category": "reference",
-	      "author": "Nigel Rees",
-	      "title": "Sayings of the Century",
-	      "price": 8.95
-	    },
-	    {
-	      "category": "fiction",
-	      "author": "Evelyn Waugh",
-	      "title": "Sword of Honour",
-	      "price": 12.99
-	    },
-	    {
-	      "category": "fiction",
-	      "author": "Herman Melville",
-	      "title": "Moby Dick",
-	      "isbn": "0-553-21311-3",
-	      "price": 8.99
-	    },
-	    {
-	      "category": "fiction",
-	      "author": "J. R. R. Tolkien",
-	      "title": "The Lord of the Rings",
-	      "isbn": "0-395-19395-8",
-	      "price": 22.99
-	    }
-	    ],
-	    "bicycle": {
-	    "color": "red",
-	    "price": 399
-	    }
-	  }
-	}`)
-
-	// Parse the JSON.
-	var value any
-	if err := json.Unmarshal(src, &value); err != nil {
-		log.Fatal(err)
-	}
-	return value
+	return examples.Bookstore()
 }
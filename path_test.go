@@ -1,17 +1,27 @@
 package jsonpath
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"iter"
 	"path/filepath"
+	"regexp"
 	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/examples"
+	"github.com/theory/jsonpath/parser"
 	"github.com/theory/jsonpath/registry"
 	"github.com/theory/jsonpath/spec"
+	"github.com/theory/jsonpath/testsupport"
 )
 
 func book(idx int) spec.NormalizedPath {
@@ -197,124 +207,1562 @@ func specExampleJSON(t *testing.T) map[string]any {
 
 func TestParseCompliance(t *testing.T) {
 	t.Parallel()
+	cases, err := testsupport.LoadFile(
+		filepath.Join("jsonpath-compliance-test-suite", "cts.json"),
+	)
+	require.NoError(t, err, "run make submodules to check out the suite")
+	require.NotEmpty(t, cases)
+	runCorpus(t, cases)
+}
+
+func TestCorpus(t *testing.T) {
+	t.Parallel()
+	cases := testsupport.Embedded()
+	external, err := testsupport.External()
+	require.NoError(t, err)
+	runCorpus(t, append(cases, external...))
+}
+
+// runCorpus runs a parallel subtest for each of cases.
+func runCorpus(t *testing.T, cases []testsupport.Case) {
+	t.Helper()
 	a := assert.New(t)
 	r := require.New(t)
 	p := NewParser()
 
-	//nolint:tagliatelle
-	type testCase struct {
-		Name            string
-		Selector        string
-		Document        any
-		Result          NodeList
-		Results         []NodeList
-		InvalidSelector bool `json:"invalid_selector"`
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("test_%03d", i), func(t *testing.T) {
+			t.Parallel()
+			description := fmt.Sprintf("%v: %v: `%v`", tc.Source, tc.Name, tc.Selector)
+			p, err := p.Parse(tc.Selector)
+			if tc.InvalidSelector {
+				r.Error(err, description)
+				r.ErrorIs(err, ErrPathParse)
+				a.Nil(p, description)
+				return
+			}
+
+			r.NoError(err, description)
+			a.NotNil(p, description)
+
+			res := p.Select(tc.Document)
+			switch {
+			case tc.Result != nil:
+				a.Equal(NodeList(tc.Result), res, description)
+			case tc.Results != nil:
+				results := make([]NodeList, len(tc.Results))
+				for i, r := range tc.Results {
+					results[i] = r
+				}
+				a.Contains(results, res, description)
+			}
+		})
 	}
+}
 
-	rawJSON, err := os.ReadFile(
-		filepath.Join("jsonpath-compliance-test-suite", "cts.json"),
+func TestParser(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name string
+		path string
+		reg  *registry.Registry
+		exp  *Path
+		err  string
+	}{
+		{
+			name: "root",
+			path: "$",
+			exp:  MustParse("$"),
+		},
+		{
+			name: "root_reg",
+			path: "$",
+			reg:  reg,
+			exp:  MustParse("$"),
+		},
+		{
+			name: "parse_error",
+			path: "lol",
+			err:  "jsonpath: unexpected identifier at position 1",
+		},
+		{
+			name: "semantic_error",
+			path: "$[?nope()]",
+			err:  "jsonpath: unknown function nope() at position 4",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Construct a parser.
+			var parser *Parser
+			if tc.reg == nil {
+				parser = NewParser()
+			} else {
+				parser = NewParser(WithRegistry(tc.reg))
+				a.Equal(tc.reg, parser.reg)
+			}
+
+			// Test Parse and MustParse methods.
+			p, err := parser.Parse(tc.path)
+			if tc.err == "" {
+				r.NoError(err)
+				a.Equal(tc.exp, p)
+				a.Equal(tc.exp, parser.MustParse(tc.path))
+			} else {
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrPathParse)
+				a.PanicsWithError(tc.err, func() { parser.MustParse(tc.path) })
+			}
+
+			if tc.reg == nil {
+				// Test Parse and MustParse functions.
+				if tc.err == "" {
+					r.NoError(err)
+					a.Equal(tc.exp, p)
+					a.Equal(tc.exp, parser.MustParse(tc.path))
+				} else {
+					r.EqualError(err, tc.err)
+					r.ErrorIs(err, ErrPathParse)
+					a.PanicsWithError(tc.err, func() { parser.MustParse(tc.path) })
+				}
+			}
+		})
+	}
+}
+
+// TestParseErrors ensures that Parse, Parser.Parse, MustParse, and the
+// parser package produce identical errors, so that callers can match the
+// same messages and classes regardless of the API they use.
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	reg := registry.New()
+
+	for _, tc := range []struct {
+		name string
+		path string
+		err  string
+		is   error
+	}{
+		{
+			name: "empty",
+			path: "",
+			err:  "jsonpath: unexpected end of input",
+			is:   ErrSyntax,
+		},
+		{
+			name: "relative",
+			path: "@.x",
+			err:  "jsonpath: unexpected '@' at position 1: relative queries are valid only in filter expressions unless parsed with the WithRelative option",
+			is:   ErrRelative,
+		},
+		{
+			name: "unexpected_token",
+			path: "$.==12",
+			err:  "jsonpath: unexpected '=' at position 3",
+			is:   ErrSyntax,
+		},
+		{
+			name: "lex_error",
+			path: `$["x]`,
+			err:  "jsonpath: unterminated string literal at position 6",
+			is:   ErrSyntax,
+		},
+		{
+			name: "unknown_function",
+			path: "$[?nope(@)]",
+			err:  "jsonpath: unknown function nope() at position 4",
+			is:   ErrSemantic,
+		},
+		{
+			name: "function_args",
+			path: "$[?length(@, 1) == 1]",
+			err:  "jsonpath: function length() expected 1 argument but found 2 at position 10",
+			is:   ErrSemantic,
+		},
+		{
+			name: "out_of_range",
+			path: "$[9007199254740992]",
+			err:  `jsonpath: cannot parse "9007199254740992", value out of range at position 3`,
+			is:   ErrSemantic,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := parser.Parse(reg, tc.path)
+			r.EqualError(err, tc.err)
+			r.ErrorIs(err, tc.is)
+
+			for _, parse := range []func(string) (*Path, error){
+				Parse,
+				NewParser().Parse,
+				NewParser(WithRegistry(reg)).Parse,
+			} {
+				_, err := parse(tc.path)
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, tc.is)
+				r.ErrorIs(err, ErrPathParse)
+			}
+			a.PanicsWithError(tc.err, func() { MustParse(tc.path) })
+		})
+	}
+}
+
+func TestParseLenient(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	input := map[string]any{"a": []any{
+		map[string]any{"x": 1.0, "tags": []any{"a", "b", "c"}},
+		map[string]any{"x": 2.0, "tags": []any{"a"}},
+	}}
+
+	for _, path := range []string{
+		"$[a][?(@.x = 1)]",
+		"$.a[?@[tags].length > 2]",
+	} {
+		_, err := Parse(path)
+		r.ErrorIs(err, ErrSyntax)
+
+		p, err := ParseLenient(path)
+		r.NoError(err)
+		a.Equal([]any{input["a"].([]any)[0]}, []any(p.Select(input)))
+
+		// String() returns RFC 9535 syntax.
+		p2, err := Parse(p.String())
+		r.NoError(err)
+		a.Equal(p.String(), p2.String())
+		a.Equal(p.String(), NewParser(WithLenient()).MustParse(path).String())
+	}
+}
+
+func TestParseCompositeLiterals(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	items := []any{
+		map[string]any{"id": 1, "tags": []any{"a", "b"}, "p": map[string]any{"x": 0, "y": 1}},
+		map[string]any{"id": 2, "tags": []any{"b", "a"}, "p": map[string]any{"x": 0.0, "y": 1.0}},
+		map[string]any{"id": 3, "tags": []any{"a", "b"}, "p": map[string]any{"x": 1}},
+	}
+	input := map[string]any{"items": items, "want": []any{"a", "b"}}
+
+	_, err := Parse(`$.items[?@.tags == ["a", "b"]]`)
+	r.ErrorIs(err, ErrSyntax)
+
+	parser := NewParser(WithCompositeLiterals(), WithLenient())
+	for _, tc := range []struct {
+		path string
+		exp  []any
+	}{
+		{`$.items[?@.tags == ["a", "b"]]`, []any{items[0], items[2]}},
+		{`$.items[?@.tags != ['a', 'b']]`, []any{items[1]}},
+		{`$.items[?@.tags == $.want]`, []any{items[0], items[2]}},
+		{`$.items[?{"y": 1, "x": 0} == @.p].id`, []any{1, 2}},
+		{`$.items[?@.p >= {"x": 0, "y": 1}].id`, []any{1, 2}},
+		{`$.items[?@.p > {"x": 0, "y": 1}].id`, []any{}},
+		{`$.items[?@.tags = ["b", "a"]].id`, []any{2}},
+	} {
+		p, err := parser.Parse(tc.path)
+		r.NoError(err, tc.path)
+		a.Equal(tc.exp, []any(p.Select(input)), tc.path)
+	}
+}
+
+func TestParseArithmetic(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	items := []any{
+		map[string]any{"id": "a", "price": 9.5, "qty": 12, "min": 1},
+		map[string]any{"id": "b", "price": 30, "qty": 3, "min": 90},
+		map[string]any{"id": "c", "price": 25, "qty": json.Number("5"), "min": 2},
+		map[string]any{"id": "d", "price": "30", "qty": 4, "min": 0},
+	}
+	input := map[string]any{"items": items, "limit": 100}
+
+	_, err := Parse(`$.items[?@.price * @.qty > 100]`)
+	r.ErrorIs(err, ErrSyntax)
+
+	parser := NewParser(WithArithmetic())
+	for _, tc := range []struct {
+		path string
+		exp  []any
+	}{
+		{`$.items[?@.price * @.qty > 100].id`, []any{"a", "c"}},
+		{`$.items[?@.price * @.qty > $.limit].id`, []any{"a", "c"}},
+		{`$.items[?@.price * @.qty == 90].id`, []any{"b"}},
+		{`$.items[?@.qty % 2 == 0].id`, []any{"a", "d"}},
+		{`$.items[?(@.qty - @.min) * 10 <= 20].id`, []any{"b"}},
+		{`$.items[?@.price / @.min == 15].id`, []any{}},
+		{`$.items[?@.min == @.price * 3].id`, []any{"b"}},
+		{`$.items[?@.qty / 0 == @.nope].id`, []any{"a", "b", "c", "d"}},
+		{`$.items[?length(@.id) + 1 == 2].id`, []any{"a", "b", "c", "d"}},
+	} {
+		p, err := parser.Parse(tc.path)
+		r.NoError(err, tc.path)
+		a.Equal(tc.exp, []any(p.Select(input)), tc.path)
+
+		// Round-trips through binary encoding.
+		data, err := p.MarshalBinary()
+		r.NoError(err)
+		p2, err := parser.ParseBinary(data)
+		r.NoError(err)
+		a.Equal(p.String(), p2.String())
+	}
+}
+
+func TestParseMembership(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	items := []any{
+		map[string]any{"id": 1, "status": "active", "tags": []any{"a"}},
+		map[string]any{"id": 2, "status": "closed", "tags": []any{"b"}},
+		map[string]any{"id": 3, "status": "pending"},
+		map[string]any{"id": 4.0, "status": []any{"active"}},
+	}
+	input := map[string]any{"items": items, "allowed": []any{1, 4}}
+
+	_, err := Parse(`$.items[?@.status in ["active", "pending"]]`)
+	r.ErrorIs(err, ErrSyntax)
+
+	parser := NewParser(WithMembership())
+	for _, tc := range []struct {
+		path string
+		exp  []any
+	}{
+		{`$.items[?@.status in ["active", "pending"]].id`, []any{1, 3}},
+		{`$.items[?@.status in [["active"]]].id`, []any{4.0}},
+		{`$.items[?@.id in $.allowed].status`, []any{"active", []any{"active"}}},
+		{`$.items[?!(@.id in $.allowed)].id`, []any{2, 3}},
+		{`$.items[?"a" in @.tags].id`, []any{1}},
+		{`$.items[?@.nope in [null]].id`, []any{}},
+		{`$.items[?@.status in $.nope].id`, []any{}},
+	} {
+		p, err := parser.Parse(tc.path)
+		r.NoError(err, tc.path)
+		a.Equal(tc.exp, []any(p.Select(input)), tc.path)
+
+		// Round-trips through binary encoding.
+		data, err := p.MarshalBinary()
+		r.NoError(err)
+		p2, err := parser.ParseBinary(data)
+		r.NoError(err)
+		a.Equal(p.String(), p2.String())
+	}
+}
+
+// nameMatchSelector is a custom selector, ~"pattern", that selects the
+// members of an object with names matching a regular expression.
+type nameMatchSelector struct{ re *regexp.Regexp }
+
+func (s nameMatchSelector) String() string { return "~" + strconv.Quote(s.re.String()) }
+
+func (s nameMatchSelector) Select(current, _ any) []any {
+	res := []any{}
+	if obj, ok := current.(map[string]any); ok {
+		for name, val := range obj {
+			if s.re.MatchString(name) {
+				res = append(res, val)
+			}
+		}
+	}
+	return res
+}
+
+func (s nameMatchSelector) SelectLocated(current, _ any, parent spec.NormalizedPath) []*spec.LocatedNode {
+	res := []*spec.LocatedNode{}
+	if obj, ok := current.(map[string]any); ok {
+		for name, val := range obj {
+			if s.re.MatchString(name) {
+				path := append(slices.Clone(parent), spec.Name(name))
+				res = append(res, &spec.LocatedNode{Node: val, Path: path})
+			}
+		}
+	}
+	return res
+}
+
+// parseNameMatch parses a nameMatchSelector from src.
+func parseNameMatch(src string) (spec.Selector, int, error) {
+	str, err := strconv.QuotedPrefix(src[1:])
+	if err != nil {
+		return nil, 0, errors.New("invalid name pattern")
+	}
+	pat, _ := strconv.Unquote(str)
+	re, err := regexp.Compile(pat)
+	if err != nil {
+		return nil, 0, err
+	}
+	return nameMatchSelector{re}, len(str) + 1, nil
+}
+
+func TestWithSelector(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	input := map[string]any{
+		"a1":    map[string]any{"x": 1},
+		"a2":    map[string]any{"x": 2},
+		"b1":    map[string]any{"x": 3},
+		"items": []any{map[string]any{"a3": 4, "c": 5}},
+	}
+	parser := NewParser(WithSelector('~', parseNameMatch))
+
+	_, err := Parse(`$[~"^a"]`)
+	r.ErrorIs(err, ErrSyntax)
+	_, err = parser.Parse(`$[~"("]`)
+	r.ErrorIs(err, ErrSyntax)
+
+	p := parser.MustParse(`$[~"^a\\d$"].x`)
+	a.Equal(`$[~"^a\\d$"]["x"]`, p.String())
+	a.ElementsMatch([]any{1, 2}, []any(p.Select(input)))
+	a.ElementsMatch(
+		[]string{`$['a1']['x']`, `$['a2']['x']`},
+		spec.NormalizedPaths(slices.Collect(p.SelectLocated(input).Paths())).Strings(),
 	)
+	a.True(p.Match(input))
+
+	// Descendant segments apply custom selectors to every node.
+	p = parser.MustParse(`$..[~"^a"]`)
+	a.ElementsMatch(
+		[]string{`$['a1']`, `$['a2']`, `$['items'][0]['a3']`},
+		spec.NormalizedPaths(slices.Collect(p.SelectLocated(input).Paths())).Strings(),
+	)
+
+	// Custom selectors in filter queries.
+	p = parser.MustParse(`$.items[?@[~"^c$"]]`)
+	a.Equal([]any{map[string]any{"a3": 4, "c": 5}}, []any(p.Select(input)))
+
+	// Binary encoding does not support custom selectors.
+	_, err = p.MarshalBinary()
+	r.ErrorIs(err, spec.ErrAST)
+}
+
+func TestParseLimits(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	parser := NewParser(WithMaxDepth(2), WithMaxSelectors(5))
+	p, err := parser.Parse(`$.a[?@.b[?@.c]]`)
 	r.NoError(err)
-	var ts struct{ Tests []testCase }
-	//nolint:musttag
-	if err := json.Unmarshal(rawJSON, &ts); err != nil {
-		t.Fatal(err)
+	a.Equal(`$["a"][?@["b"][?@["c"]]]`, p.String())
+
+	_, err = parser.Parse(`$.a[?@.b[?@[?@.c]]]`)
+	r.ErrorIs(err, ErrLimit)
+	r.EqualError(err, "jsonpath: query exceeds maximum nesting depth of 2 at position 13")
+
+	_, err = parser.Parse(`$.a.b.c.d.e.f`)
+	r.ErrorIs(err, ErrLimit)
+	r.EqualError(err, "jsonpath: query exceeds maximum of 5 selectors at position 13")
+	var perr *ParseError
+	r.ErrorAs(err, &perr)
+	a.Equal(13, perr.Position())
+}
+
+func TestSelectRelative(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	root := map[string]any{
+		"limit": 2,
+		"items": []any{
+			map[string]any{"n": 1, "tags": []any{"a"}},
+			map[string]any{"n": 3, "tags": []any{"b", "c"}},
+		},
+	}
+	items, _ := root["items"].([]any)
+	current := items[1]
+
+	// Strict parser rejects relative queries.
+	_, err := Parse("@.n")
+	r.EqualError(err, "jsonpath: unexpected '@' at position 1: relative queries are valid only in filter expressions unless parsed with the WithRelative option")
+	r.ErrorIs(err, ErrRelative)
+
+	parser := NewParser(WithRelative())
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  NodeList
+		self NodeList
+	}{
+		{
+			name: "current",
+			path: "@",
+			exp:  NodeList{current},
+			self: NodeList{root},
+		},
+		{
+			name: "relative_name",
+			path: "@.n",
+			exp:  NodeList{3},
+			self: NodeList{},
+		},
+		{
+			name: "relative_wildcard",
+			path: "@.tags[*]",
+			exp:  NodeList{"b", "c"},
+			self: NodeList{},
+		},
+		{
+			name: "filter_with_root",
+			path: "@[?@ == 'c' || $.limit == 2]",
+			exp:  NodeList{3, []any{"b", "c"}},
+			self: NodeList{2, items},
+		},
+		{
+			name: "absolute",
+			path: "$.limit",
+			exp:  NodeList{2},
+			self: NodeList{2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p, err := parser.Parse(tc.path)
+			r.NoError(err)
+			a.Equal(tc.path[:1], p.String()[:1])
+			a.ElementsMatch(tc.exp, p.SelectRelative(current, root))
+			a.ElementsMatch(tc.self, p.Select(root))
+			a.Len(p.SelectLocated(root), len(tc.self))
+		})
+	}
+}
+
+func TestSelectLookups(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	b := map[string]any{"c": 42, "n": nil}
+	doc := map[string]any{
+		"a": map[string]any{
+			"b": b,
+			"x": []any{1, 2},
+		},
 	}
+	relative := NewParser(WithRelative())
+
+	for _, tc := range []struct {
+		name    string
+		path    *Path
+		lookups []spec.Selector
+		exp     NodeList
+	}{
+		{
+			name:    "root",
+			path:    MustParse(`$`),
+			lookups: []spec.Selector{},
+			exp:     NodeList{doc},
+		},
+		{
+			name:    "one",
+			path:    MustParse(`$.a`),
+			lookups: []spec.Selector{spec.Name("a")},
+			exp:     NodeList{doc["a"]},
+		},
+		{
+			name:    "three",
+			path:    MustParse(`$.a.b["c"]`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Name("b"), spec.Name("c")},
+			exp:     NodeList{42},
+		},
+		{
+			name:    "null",
+			path:    MustParse(`$.a.b.n`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Name("b"), spec.Name("n")},
+			exp:     NodeList{nil},
+		},
+		{
+			name:    "missing",
+			path:    MustParse(`$.a.nonesuch.c`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Name("nonesuch"), spec.Name("c")},
+			exp:     NodeList{},
+		},
+		{
+			name:    "not_object",
+			path:    MustParse(`$.a.x.c`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Name("x"), spec.Name("c")},
+			exp:     NodeList{},
+		},
+		{
+			name:    "relative",
+			path:    relative.MustParse(`@.a.b`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Name("b")},
+			exp:     NodeList{b},
+		},
+		{
+			name:    "index",
+			path:    MustParse(`$.a.x[0]`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Name("x"), spec.Index(0)},
+			exp:     NodeList{1},
+		},
+		{
+			name:    "negative_index",
+			path:    MustParse(`$.a.x[-1]`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Name("x"), spec.Index(-1)},
+			exp:     NodeList{2},
+		},
+		{
+			name:    "index_out_of_range",
+			path:    MustParse(`$.a.x[-3]`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Name("x"), spec.Index(-3)},
+			exp:     NodeList{},
+		},
+		{
+			name:    "index_not_array",
+			path:    MustParse(`$.a[0]`),
+			lookups: []spec.Selector{spec.Name("a"), spec.Index(0)},
+			exp:     NodeList{},
+		},
+		{
+			name: "slice",
+			path: MustParse(`$.a.x[0:1]`),
+			exp:  NodeList{1},
+		},
+		{
+			name: "union",
+			path: MustParse(`$.a["b","x"]`),
+			exp:  NodeList{b, []any{1, 2}},
+		},
+		{
+			name: "descendant",
+			path: MustParse(`$..c`),
+			exp:  NodeList{42},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(tc.lookups, tc.path.lookups)
+			a.Equal(tc.exp, tc.path.Select(doc))
+			a.Equal(tc.exp, tc.path.SelectRelative(doc, doc))
+			a.Equal(tc.exp, NodeList(tc.path.q.Select(doc, doc)))
+			a.Equal(tc.lookups != nil, tc.path.IsSingular())
+			a.Equal(tc.path.q.Singular() != nil, tc.path.IsSingular())
+
+			// SelectSingular selects the first node.
+			val, ok := tc.path.SelectSingular(doc)
+			a.Equal(len(tc.exp) > 0, ok)
+			if ok {
+				a.Equal(tc.exp[0], val)
+			} else {
+				a.Nil(val)
+			}
+		})
+	}
+
+	// Relative and absolute lookup chains select from different nodes.
+	current := map[string]any{"b": 1}
+	a.Equal(NodeList{1}, relative.MustParse(`@.b`).SelectRelative(current, doc))
+	a.Equal(NodeList{}, MustParse(`$.b`).SelectRelative(current, doc))
+}
+
+func BenchmarkSelectLookups(b *testing.B) {
+	doc, err := examples.Document("pod")
+	require.NoError(b, err)
+	p := MustParse(`$.metadata.labels.app`)
+	require.NotNil(b, p.lookups)
+
+	b.Run("lookups", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_ = p.Select(doc)
+		}
+	})
+
+	b.Run("singular", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_, _ = p.SelectSingular(doc)
+		}
+	})
+
+	b.Run("segments", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_ = p.q.Select(doc, doc)
+		}
+	})
+}
+
+func TestSelectRawMessage(t *testing.T) {
+	t.Parallel()
+	skipRaw(t)
+
+	input := map[string]any{
+		"id":   json.RawMessage(`42`),
+		"meta": json.RawMessage(`{"name": "doc", "tags": ["x", "y"]}`),
+		"blob": json.RawMessage(`{"data": [1, 2, 3]}`),
+	}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  NodeList
+	}{
+		{
+			name: "lookup_scalar",
+			path: "$.meta.name",
+			exp:  NodeList{"doc"},
+		},
+		{
+			name: "lookup_index",
+			path: "$.meta.tags[1]",
+			exp:  NodeList{"y"},
+		},
+		{
+			name: "lookup_missing",
+			path: "$.meta.nope.x",
+			exp:  NodeList{},
+		},
+		{
+			name: "lookup_undecoded",
+			path: "$.blob",
+			exp:  NodeList{json.RawMessage(`{"data": [1, 2, 3]}`)},
+		},
+		{
+			name: "filter",
+			path: `$[?@.name == "doc"].tags[*]`,
+			exp:  NodeList{"x", "y"},
+		},
+		{
+			name: "raw_scalar",
+			path: `$[?@ == 42]`,
+			exp:  NodeList{json.RawMessage(`42`)},
+		},
+		{
+			name: "function",
+			path: `$[?length(@.data) == 3].data[-1]`,
+			exp:  NodeList{float64(3)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			p := MustParse(tc.path)
+			a.Equal(tc.exp, p.Select(input))
+			a.Equal(tc.exp, NodeList(p.q.Select(input, input)))
+			a.ElementsMatch(tc.exp, slices.Collect(p.SelectLocated(input).Nodes()))
+		})
+	}
+}
+
+func TestSelectJSONNumber(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	dec := json.NewDecoder(strings.NewReader(`[
+		{"id": "a", "count": 3, "price": 9.5},
+		{"id": "b", "count": 3.0, "price": 10},
+		{"id": "c", "count": 9007199254740993, "price": 1e400},
+		{"id": "d", "count": 18446744073709551617, "price": 0}
+	]`))
+	dec.UseNumber()
+	var input any
+	r.NoError(dec.Decode(&input))
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  NodeList
+	}{
+		{"equal_int", `$[?@.count == 3].id`, NodeList{"a", "b"}},
+		{"equal_float", `$[?@.count == 3.0].id`, NodeList{"a", "b"}},
+		{"less_than", `$[?@.price < 10].id`, NodeList{"a", "d"}},
+		{"greater_equal", `$[?@.price >= 10].id`, NodeList{"b", "c"}},
+		{"exact_int", `$[?@.count == 9007199254740993].id`, NodeList{"c"}},
+		{"inexact_int", `$[?@.count == 9007199254740992].id`, NodeList{}},
+		{"beyond_uint64", `$[?@.count > 9007199254740993].id`, NodeList{"d"}},
+		{"compare_nodes", `$[?@.count == $[0].count].id`, NodeList{"a", "b"}},
+		{"exists", `$[?@.price].id`, NodeList{"a", "b", "c", "d"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.exp, MustParse(tc.path).Select(input))
+		})
+	}
+}
+
+func TestSelectRegexMissingMember(t *testing.T) {
+	t.Parallel()
+	input := map[string]any{
+		"a":  []any{1, 2, 3, map[string]any{"b": "x"}},
+		"re": "x",
+	}
+	found := NodeList{map[string]any{"b": "x"}}
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  NodeList
+	}{
+		{"match", `$.a[?match(@.b, "x")]`, found},
+		{"search", `$.a[?search(@.b, "x")]`, found},
+		{"not_match", `$.a[?!match(@.b, "x")]`, NodeList{1, 2, 3}},
+		{"match_query_pattern", `$.a[?match(@.b, $.re)]`, found},
+		{"search_query_pattern", `$.a[?search(@.b, $.re)]`, found},
+		{"match_missing_pattern", `$.a[?match(@.b, $.nope)]`, NodeList{}},
+		{"search_missing_pattern", `$.a[?search(@.b, $.nope)]`, NodeList{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			p := MustParse(tc.path)
+			a.Equal(tc.exp, p.Select(input))
+			a.Len(p.SelectLocated(input), len(tc.exp))
+		})
+	}
+}
+
+func TestContextFunctions(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	reg := registry.New()
+	r.NoError(reg.RegisterContext(
+		"path", spec.FuncValue, registry.Params(),
+		func(ctx *spec.EvalContext, _ []spec.JSONPathValue) spec.JSONPathValue {
+			if ctx.Path == nil {
+				return nil
+			}
+			return spec.Value(ctx.Path.String())
+		},
+	))
+	r.NoError(reg.RegisterContext(
+		"is_last", spec.FuncLogical, registry.Params(),
+		func(ctx *spec.EvalContext, _ []spec.JSONPathValue) spec.JSONPathValue {
+			arr, ok := ctx.Parent.([]any)
+			return spec.LogicalFrom(ok && len(arr) > 0 && ctx.Current == arr[len(arr)-1])
+		},
+	))
+	parser := NewParser(WithRegistry(reg))
+	input := map[string]any{"a": []any{"x", "y", "z"}, "b": []any{"x"}}
+
+	p := parser.MustParse(`$..[?path() == "$['a'][1]"]`)
+	located, err := p.SelectLocatedWith(input)
+	r.NoError(err)
+	a.Equal(LocatedNodeList{{Node: "y", Path: spec.NormalizedPath{spec.Name("a"), spec.Index(1)}}}, located)
+	a.Equal(located, p.SelectLocated(input))
+	// Select does not track paths.
+	a.Empty(p.Select(input))
+
+	p = parser.MustParse(`$.*[?is_last()]`)
+	a.ElementsMatch([]any{"z", "x"}, p.Select(input))
+	a.Len(p.SelectLocated(input), 2)
+}
+
+func TestSelectOrdered(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	input, err := UnmarshalOrdered([]byte(`{"z": {"n": 1}, "a": {"n": 2, "k": [{"n": 3}]}, "m": {"n": 4}}`))
+	r.NoError(err)
+
+	for _, tc := range []struct {
+		path string
+		exp  NodeList
+	}{
+		{"$.a.k[0].n", NodeList{float64(3)}},
+		{"$.a.nope", NodeList{}},
+		{"$.*.n", NodeList{float64(1), float64(2), float64(4)}},
+		{"$..n", NodeList{float64(1), float64(2), float64(3), float64(4)}},
+		{"$[?@.n > 1].n", NodeList{float64(2), float64(4)}},
+		{"$[?length(@) == 2].n", NodeList{float64(2)}},
+	} {
+		p := MustParse(tc.path)
+		a.Equal(tc.exp, p.Select(input), tc.path)
+		res, err := p.SelectWith(input)
+		r.NoError(err)
+		a.Equal(tc.exp, res, tc.path)
+		located, err := p.SelectLocatedWith(input, WithMemoryBudget(1<<20))
+		r.NoError(err)
+		a.Equal(p.SelectLocated(input), located, tc.path)
+	}
+
+	_, err = UnmarshalOrdered([]byte(`{`))
+	r.Error(err)
+}
+
+// countingQuerier decorates a Querier to count evaluations.
+type countingQuerier struct {
+	Querier
+	count int
+}
+
+func (q *countingQuerier) Select(input any) NodeList {
+	q.count++
+	return q.Querier.Select(input)
+}
+
+func (q *countingQuerier) SelectLocated(input any) LocatedNodeList {
+	q.count++
+	return q.Querier.SelectLocated(input)
+}
+
+func TestQuerier(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var q Querier = MustParse("$.a[*]")
+	a.Equal("$[\"a\"][*]", q.String())
+
+	q = &countingQuerier{Querier: q}
+	input := map[string]any{"a": []any{1, 2}}
+	a.Equal(NodeList{1, 2}, q.Select(input))
+	a.Equal(LocatedNodeList{
+		{Path: spec.NormalizedPath{spec.Name("a"), spec.Index(0)}, Node: 1},
+		{Path: spec.NormalizedPath{spec.Name("a"), spec.Index(1)}, Node: 2},
+	}, q.SelectLocated(input))
+	a.Equal("$[\"a\"][*]", q.String())
+	a.Equal(2, q.(*countingQuerier).count)
+}
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	// Use ordered objects so that results have a deterministic order.
+	src, err := examples.JSON("bookstore")
+	require.NoError(t, err)
+	store, err := UnmarshalOrdered(src)
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		name string
+		path string
+	}{
+		{"root", "$"},
+		{"lookups", "$.store.bicycle.color"},
+		{"nothing", "$.nonesuch"},
+		{"wildcard", "$.store.book[*].title"},
+		{"descendant", "$..author"},
+		{"filter", "$..book[?@.price > 10]"},
+		{"union", "$.store.book[1,0].author"},
+		{"all", "$..*"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			p := MustParse(tc.path)
+			a.Equal([]any(p.Select(store)), append([]any{}, slices.Collect(p.All(store))...))
+			a.Equal(
+				[]*spec.LocatedNode(p.SelectLocated(store)),
+				append([]*spec.LocatedNode{}, slices.Collect(p.AllLocated(store))...),
+			)
+
+			// Stop early.
+			for range p.All(store) {
+				break
+			}
+			for range p.AllLocated(store) {
+				break
+			}
+		})
+	}
+
+	t.Run("raw", func(t *testing.T) {
+		t.Parallel()
+		skipRaw(t)
+		a := assert.New(t)
+		raw := json.RawMessage(`{"b": {"x": 1}, "a": [{"x": 2}, {"y": {"x": 3}}]}`)
+		p := MustParse("$..x")
+		a.Equal([]any(p.Select(raw)), slices.Collect(p.All(raw)))
+		a.Equal([]*spec.LocatedNode(p.SelectLocated(raw)), slices.Collect(p.AllLocated(raw)))
+	})
+
+	t.Run("lazy", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		r := require.New(t)
+
+		// Count calls to a filter function.
+		var calls atomic.Int32
+		reg := registry.New()
+		r.NoError(reg.Register(
+			"seen", spec.FuncLogical,
+			func([]spec.FunctionExprArg) error { return nil },
+			func([]spec.JSONPathValue) spec.JSONPathValue {
+				calls.Add(1)
+				return spec.LogicalTrue
+			},
+		))
+		p, err := NewParser(WithRegistry(reg)).Parse("$[*][?seen(@)]")
+		r.NoError(err)
+		doc := []any{[]any{1, 2}, []any{3, 4}, []any{5, 6}}
+
+		for v := range p.All(doc) {
+			a.Equal(1, v)
+			break
+		}
+		a.Equal(int32(2), calls.Load())
+
+		calls.Store(0)
+		for n := range p.AllLocated(doc) {
+			a.Equal("$[0][0]", n.Path.String())
+			break
+		}
+		a.Equal(int32(2), calls.Load())
+
+		calls.Store(0)
+		a.Len(slices.Collect(p.All(doc)), 6)
+		a.Equal(int32(6), calls.Load())
+	})
+}
 
-	for i, tc := range ts.Tests {
-		t.Run(fmt.Sprintf("test_%03d", i), func(t *testing.T) {
+func TestFirst(t *testing.T) {
+	t.Parallel()
+
+	store := examples.Bookstore()
+	raw := json.RawMessage(`{"b": {"x": 1}, "a": [{"x": 2}]}`)
+	for _, tc := range []struct {
+		name  string
+		path  string
+		input any
+		exp   any
+		found bool
+	}{
+		{"root", "$", store, store, true},
+		{"lookups", "$.store.bicycle.color", store, "red", true},
+		{"nothing", "$.nonesuch", store, nil, false},
+		{"index", "$.store.book[1].author", store, "Evelyn Waugh", true},
+		{"filter", "$.store.book[?@.price > 10].title", store, "Sword of Honour", true},
+		{"descendant", "$..book[2].isbn", store, "0-553-21311-3", true},
+		{"descendant_nothing", "$..nonesuch", store, nil, false},
+		{"raw", "$..x", raw, float64(1), true},
+		{"raw_index", "$..[0].x", raw, float64(2), true},
+		{"null", "$.a", map[string]any{"a": nil}, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			description := fmt.Sprintf("%v: `%v`", tc.Name, tc.Selector)
-			p, err := p.Parse(tc.Selector)
-			if tc.InvalidSelector {
-				r.Error(err, description)
-				r.ErrorIs(err, ErrPathParse)
-				a.Nil(p, description)
-				return
+			if _, ok := tc.input.(json.RawMessage); ok {
+				skipRaw(t)
 			}
+			a := assert.New(t)
+			p := MustParse(tc.path)
+			val, ok := p.First(tc.input)
+			a.Equal(tc.found, ok)
+			a.Equal(tc.exp, val)
+			a.Equal(tc.found, p.Exists(tc.input))
+			a.Equal(tc.found, p.Match(tc.input))
+		})
+	}
 
-			r.NoError(err, description)
-			a.NotNil(p, description)
+	t.Run("lazy_descendant", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		r := require.New(t)
 
-			res := p.Select(tc.Document)
-			switch {
-			case tc.Result != nil:
-				a.Equal(tc.Result, res, description)
-			case tc.Results != nil:
-				a.Contains(tc.Results, res, description)
-			}
+		// Count calls to a filter function.
+		var calls atomic.Int32
+		reg := registry.New()
+		r.NoError(reg.Register(
+			"seen", spec.FuncLogical,
+			func([]spec.FunctionExprArg) error { return nil },
+			func([]spec.JSONPathValue) spec.JSONPathValue {
+				calls.Add(1)
+				return spec.LogicalTrue
+			},
+		))
+		p, err := NewParser(WithRegistry(reg)).Parse("$..[?seen(@)]")
+		r.NoError(err)
+		doc := []any{[]any{1, 2}, []any{3, 4}}
+
+		a.True(p.Exists(doc))
+		a.Equal(int32(2), calls.Load())
+
+		// Match stops filtering at the first match.
+		calls.Store(0)
+		a.True(p.Match(doc))
+		a.Equal(int32(1), calls.Load())
+
+		calls.Store(0)
+		for n := range p.AllLocated(doc) {
+			a.Equal("$[0]", n.Path.String())
+			break
+		}
+		a.Equal(int32(2), calls.Load())
+
+		calls.Store(0)
+		a.Len(p.Select(doc), 6)
+		a.Equal(int32(6), calls.Load())
+	})
+}
+
+func TestSelectKeys(t *testing.T) {
+	t.Parallel()
+
+	store := examples.Bookstore()
+	ordered := &spec.OrderedObject{}
+	ordered.Set("debug", true)
+	ordered.Set("color", false)
+	ordered.Set("cache", true)
+	for _, tc := range []struct {
+		name  string
+		path  string
+		input any
+		exp   []spec.NormalSelector
+	}{
+		{
+			name:  "root",
+			path:  "$",
+			input: store,
+			exp:   []spec.NormalSelector{},
+		},
+		{
+			name:  "name",
+			path:  "$.store",
+			input: store,
+			exp:   []spec.NormalSelector{spec.Name("store")},
+		},
+		{
+			name:  "indexes",
+			path:  "$.store.book[?@.price < 10]",
+			input: store,
+			exp:   []spec.NormalSelector{spec.Index(0), spec.Index(2)},
+		},
+		{
+			name:  "filter_names",
+			path:  "$[?@ == true]",
+			input: ordered,
+			exp:   []spec.NormalSelector{spec.Name("debug"), spec.Name("cache")},
+		},
+		{
+			name:  "duplicates",
+			path:  "$.store.book[0,1].author",
+			input: store,
+			exp:   []spec.NormalSelector{spec.Name("author"), spec.Name("author")},
+		},
+		{
+			name:  "nothing",
+			path:  "$.nonesuch",
+			input: store,
+			exp:   []spec.NormalSelector{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, MustParse(tc.path).SelectKeys(tc.input))
 		})
 	}
 }
 
-func TestParser(t *testing.T) {
+func TestSelectParents(t *testing.T) {
 	t.Parallel()
-	a := assert.New(t)
-	r := require.New(t)
-	reg := registry.New()
 
+	store := examples.Bookstore()
+	books := MustParse("$.store.book").Select(store)[0]
+	bicycle := MustParse("$.store.bicycle").Select(store)[0]
+	raw := json.RawMessage(`{"a": {"x": 1, "y": 1}, "b": [1, 2]}`)
 	for _, tc := range []struct {
-		name string
-		path string
-		reg  *registry.Registry
-		exp  *Path
-		err  string
+		name  string
+		path  string
+		input any
+		exp   NodeList
+		paths []string
 	}{
 		{
-			name: "root",
-			path: "$",
-			exp:  MustParse("$"),
+			name:  "root",
+			path:  "$",
+			input: store,
+			exp:   NodeList{},
+			paths: []string{},
 		},
 		{
-			name: "root_reg",
-			path: "$",
-			reg:  reg,
-			exp:  MustParse("$"),
+			name:  "child",
+			path:  "$.store",
+			input: store,
+			exp:   NodeList{store},
+			paths: []string{"$"},
 		},
 		{
-			name: "parse_error",
-			path: "lol",
-			err:  "jsonpath: unexpected identifier at position 1",
+			name:  "filter",
+			path:  "$.store.book[*].price[?@ > 10]",
+			input: store,
+			exp:   NodeList{},
+			paths: []string{},
+		},
+		{
+			name:  "matching_values",
+			path:  `$..[?@ == "red" || @ == "Herman Melville"]`,
+			input: store,
+			exp:   NodeList{books.([]any)[2], bicycle},
+			paths: []string{"$['store']['book'][2]", "$['store']['bicycle']"},
+		},
+		{
+			name:  "deduplicate",
+			path:  `$.store.book[0].*`,
+			input: store,
+			exp:   MustParse("$.store.book[0]").Select(store),
+			paths: []string{"$['store']['book'][0]"},
+		},
+		{
+			name:  "each_parent",
+			path:  `$.store.book[*].author`,
+			input: store,
+			exp:   MustParse("$.store.book[*]").Select(store),
+			paths: []string{
+				"$['store']['book'][0]",
+				"$['store']['book'][1]",
+				"$['store']['book'][2]",
+				"$['store']['book'][3]",
+			},
+		},
+		{
+			name:  "array",
+			path:  `$.store.book[?@.price > 20]`,
+			input: store,
+			exp:   NodeList{books},
+			paths: []string{"$['store']['book']"},
+		},
+		{
+			name:  "raw",
+			path:  `$..[?@ == 1]`,
+			input: raw,
+			exp: NodeList{
+				json.RawMessage(`{"x": 1, "y": 1}`),
+				json.RawMessage(`[1, 2]`),
+			},
+			paths: []string{"$['a']", "$['b']"},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
+			if _, ok := tc.input.(json.RawMessage); ok {
+				skipRaw(t)
+			}
+			a := assert.New(t)
+			p := MustParse(tc.path)
+			nodes, paths := NodeList{}, []string{}
+			for _, n := range p.SelectParentsLocated(tc.input) {
+				nodes = append(nodes, n.Node)
+				paths = append(paths, n.Path.String())
+			}
+			// Map iteration order is random, so ignore the order.
+			a.ElementsMatch(tc.exp, p.SelectParents(tc.input))
+			a.ElementsMatch(tc.exp, nodes)
+			a.ElementsMatch(tc.paths, paths)
+		})
+	}
+}
 
-			// Construct a parser.
-			var parser *Parser
-			if tc.reg == nil {
-				parser = NewParser()
-			} else {
-				parser = NewParser(WithRegistry(tc.reg))
-				a.Equal(tc.reg, parser.reg)
+func TestSelectChan(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	store := examples.Bookstore()
+	for _, tc := range []struct {
+		name   string
+		path   string
+		buffer int
+	}{
+		{"root", "$", 0},
+		{"name", "$.store.bicycle", 0},
+		{"nothing", "$.nonesuch", 0},
+		{"wildcard", "$.store.book[*].title", 1},
+		{"descendant", "$..author", 0},
+		{"filter", "$..book[?@.price > 10]", 10},
+		{"all", "$..*", 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+			got := LocatedNodeList{}
+			for n := range p.SelectChan(context.Background(), store, tc.buffer) {
+				got = append(got, n)
 			}
+			exp := p.SelectLocated(store)
+			exp.Sort()
+			got.Sort()
+			a.Equal(exp, got)
+		})
+	}
 
-			// Test Parse and MustParse methods.
-			p, err := parser.Parse(tc.path)
-			if tc.err == "" {
-				r.NoError(err)
-				a.Equal(tc.exp, p)
-				a.Equal(tc.exp, parser.MustParse(tc.path))
-			} else {
-				r.EqualError(err, tc.err)
-				r.ErrorIs(err, ErrPathParse)
-				a.PanicsWithError(tc.err, func() { parser.MustParse(tc.path) })
+	t.Run("cancel", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := MustParse("$..*").SelectChan(ctx, store, 0)
+		a.NotNil(<-ch)
+		cancel()
+
+		// Drain any node sent while canceling; the channel must close.
+		count := 0
+		for range ch {
+			count++
+		}
+		a.LessOrEqual(count, 1)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, ok := <-MustParse("$").SelectChan(ctx, store, 1)
+		a.False(ok)
+	})
+}
+
+func TestParserFeatures(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Default registry.
+	feat := NewParser().Features()
+	a.Equal([]string{"count", "length", "match", "search", "value"}, feat.Functions)
+	a.True(feat.HasFunction("match"))
+	a.False(feat.HasFunction("first"))
+
+	// Custom registry.
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"first", spec.FuncValue,
+		func([]spec.FunctionExprArg) error { return nil },
+		func([]spec.JSONPathValue) spec.JSONPathValue { return nil },
+	))
+	feat = NewParser(WithRegistry(reg)).Features()
+	a.Equal([]string{"count", "first", "length", "match", "search", "value"}, feat.Functions)
+	a.True(feat.HasFunction("first"))
+
+	// Syntax extensions.
+	a.False(feat.Arithmetic)
+	a.False(feat.CompositeLiterals)
+	a.False(feat.Lenient)
+	a.False(feat.Membership)
+	a.False(feat.Relative)
+	a.Nil(feat.Selectors)
+	parse := func(string) (spec.Selector, int, error) { return spec.Wildcard(), 1, nil }
+	feat = NewParser(
+		WithArithmetic(),
+		WithCompositeLiterals(),
+		WithLenient(),
+		WithMembership(),
+		WithRelative(),
+		WithSelector('~', parse),
+		WithSelector('#', parse),
+		WithSelector('~', parse),
+	).Features()
+	a.True(feat.Arithmetic)
+	a.True(feat.CompositeLiterals)
+	a.True(feat.Lenient)
+	a.True(feat.Membership)
+	a.True(feat.Relative)
+	a.Equal([]string{"#", "~"}, feat.Selectors)
+
+	// Limits.
+	a.Zero(feat.MaxDepth)
+	a.Zero(feat.MaxSelectors)
+	feat = NewParser(WithMaxDepth(3), WithMaxSelectors(20)).Features()
+	a.Equal(3, feat.MaxDepth)
+	a.Equal(20, feat.MaxSelectors)
+	feat = NewParser(WithMaxDepth(-1), WithMaxSelectors(-1)).Features()
+	a.Zero(feat.MaxDepth)
+	a.Zero(feat.MaxSelectors)
+
+	// Encodes as JSON.
+	data, err := json.Marshal(NewParser(WithRelative()).Features())
+	r.NoError(err)
+	a.JSONEq(`{
+		"functions": ["count", "length", "match", "search", "value"],
+		"arithmetic": false,
+		"composite_literals": false,
+		"lenient": false,
+		"membership": false,
+		"relative": true,
+		"max_depth": 0,
+		"max_selectors": 0
+	}`, string(data))
+}
+
+func TestWithoutDefaultFunctions(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	parser := NewParser(WithoutDefaultFunctions())
+	a.Empty(parser.Features().Functions)
+	_, err := parser.Parse("$[?length(@) > 1]")
+	r.EqualError(err, "jsonpath: unknown function length() at position 4")
+	r.ErrorIs(err, ErrSemantic)
+
+	// Re-register selected default functions.
+	defaults := registry.New()
+	for _, name := range []string{"length", "count", "value"} {
+		r.NoError(parser.Registry().RegisterFunction(defaults.Get(name)))
+	}
+	a.Equal([]string{"count", "length", "value"}, parser.Features().Functions)
+	p, err := parser.Parse("$[?length(@) > 1]")
+	r.NoError(err)
+	a.Equal([]any{"abc"}, []any(p.Select([]any{"abc", "x"})))
+	_, err = parser.Parse("$[?match(@, 'a.*')]")
+	r.EqualError(err, "jsonpath: unknown function match() at position 4")
+
+	// Does not affect other parsers.
+	a.True(NewParser().Features().HasFunction("match"))
+	a.Len(defaults.Names(), 5)
+
+	// WithRegistry takes precedence.
+	reg := registry.New()
+	parser = NewParser(WithoutDefaultFunctions(), WithRegistry(reg))
+	a.Same(reg, parser.Registry())
+	a.True(parser.Features().HasFunction("match"))
+}
+
+func TestPathFormat(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	p := MustParse(`$.store['book'][?@.price < 10 && @["category"] == 'fiction']..author`)
+	a.Equal(p.String(), p.Format())
+	a.Equal(
+		`$['store']['book'][?@['price'] < 10 && @['category'] == 'fiction']..['author']`,
+		p.Format(spec.WithSingleQuotes()),
+	)
+	a.Equal(
+		`$.store.book[?@.price < 10 && @.category == "fiction"]..author`,
+		p.Format(spec.WithDotNotation()),
+	)
+
+	// Every formatting of every valid query in the corpus parses back to
+	// the same query.
+	parser := NewParser()
+	formats := [][]spec.FormatOption{
+		{spec.WithSingleQuotes()},
+		{spec.WithDotNotation()},
+		{spec.WithDotNotation(), spec.WithSingleQuotes()},
+	}
+	for _, c := range testsupport.Embedded() {
+		if c.InvalidSelector {
+			continue
+		}
+		p, err := parser.Parse(c.Selector)
+		r.NoError(err, c.Selector)
+		for _, opts := range formats {
+			str := p.Format(opts...)
+			p2, err := parser.Parse(str)
+			r.NoError(err, str)
+			a.Equal(p.String(), p2.String(), str)
+		}
+	}
+}
+
+func TestPathBinary(t *testing.T) {
+	t.Parallel()
+	r := require.New(t)
+
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"first", spec.FuncValue,
+		func(args []spec.FunctionExprArg) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected 1 argument but found %v", len(args))
+			}
+			return nil
+		},
+		func(args []spec.JSONPathValue) spec.JSONPathValue {
+			if nodes := spec.NodesFrom(args[0]); len(nodes) > 0 {
+				return spec.Value(nodes[0])
 			}
+			return nil
+		},
+	))
+	parser := NewParser(WithRegistry(reg))
+	input := map[string]any{
+		"a": []any{
+			map[string]any{"x": 1, "y": "one", "z": []any{1, 2}},
+			map[string]any{"x": 2.5, "y": "two", "z": []any{}},
+			map[string]any{"x": nil, "y": true},
+		},
+		"n": 2,
+	}
 
-			if tc.reg == nil {
-				// Test Parse and MustParse functions.
-				if tc.err == "" {
-					r.NoError(err)
-					a.Equal(tc.exp, p)
-					a.Equal(tc.exp, parser.MustParse(tc.path))
-				} else {
-					r.EqualError(err, tc.err)
-					r.ErrorIs(err, ErrPathParse)
-					a.PanicsWithError(tc.err, func() { parser.MustParse(tc.path) })
-				}
+	for _, tc := range []struct {
+		name  string
+		path  string
+		exp   []any
+		noExt bool
+	}{
+		{"root", "$", []any{input}, true},
+		{"lookups", "$.a[1].y", []any{"two"}, true},
+		{"wildcard", "$.a[*].y", []any{"one", "two", true}, true},
+		{"descendant", "$..z[0]", []any{1}, true},
+		{"slice", "$.a[::-2].y", []any{true, "one"}, true},
+		{"compare_int", "$.a[?@.x == 1].y", []any{"one"}, true},
+		{"compare_float", "$.a[?@.x > 2.0].y", []any{"two"}, true},
+		{"compare_root", "$.a[?@.x < $.n].y", []any{"one"}, true},
+		{"compare_null", "$.a[?@.x == null].y", []any{true}, true},
+		{"logical", "$.a[?@.z && !(@.x == 1) || @.y == true].y", []any{"two", true}, true},
+		{"exists", "$.a[?!@.z].y", []any{true}, true},
+		{"functions", "$.a[?length(@.z) > 0 && match(@.y, 'o.*')].y", []any{"one"}, true},
+		{"not_function", "$.a[?!search(@.y, 'w')].y", []any{"one", true}, true},
+		{"nodes_arg", "$.a[?count(@.*) == 2].y", []any{true}, true},
+		{"extension", "$.a[?first(@.z[*]) == 1].y", []any{"one"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			path := parser.MustParse(tc.path)
+			data, err := path.MarshalBinary()
+			r.NoError(err)
+
+			decoded, err := parser.ParseBinary(data)
+			r.NoError(err)
+			a.Equal(path.String(), decoded.String())
+			a.Equal(path.lookups, decoded.lookups)
+			a.Equal(tc.exp, []any(decoded.Select(input)))
+			a.Equal(path.SelectLocated(input), decoded.SelectLocated(input))
+
+			// UnmarshalBinary resolves only the default functions.
+			var unmarshaled Path
+			err = unmarshaled.UnmarshalBinary(data)
+			if !tc.noExt {
+				r.EqualError(err, "jsonpath: invalid AST: unknown function first()")
+				r.ErrorIs(err, spec.ErrAST)
+				return
 			}
+			r.NoError(err)
+			a.Equal(path.String(), unmarshaled.String())
+			a.Equal(tc.exp, []any(unmarshaled.Select(input)))
 		})
 	}
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+		r := require.New(t)
+
+		data, err := parser.MustParse("$[?first(@.*) == 1]").MarshalBinary()
+		r.NoError(err)
+		p, err := NewParser(WithoutDefaultFunctions()).ParseBinary(data)
+		a.Nil(p)
+		r.EqualError(err, "jsonpath: invalid AST: unknown function first()")
+
+		// Arguments are validated.
+		invalid := bytes.Replace(data, []byte(`"name":"first"`), []byte(`"name":"length"`), 1)
+		p, err = parser.ParseBinary(invalid)
+		a.Nil(p)
+		r.EqualError(err, "jsonpath: invalid AST: function length() cannot convert argument to ValueType")
+		r.ErrorIs(err, spec.ErrAST)
+
+		var path Path
+		r.EqualError(path.UnmarshalBinary([]byte("{}")), "jsonpath: invalid AST: unsupported version 0")
+	})
 }
 
 func norm(sel ...any) spec.NormalizedPath {
@@ -596,3 +2044,167 @@ func TestLocatedNodeListIterators(t *testing.T) {
 		slices.Collect(list.Paths()),
 	)
 }
+
+// modelNode is a node in a minimal custom data model that adapts itself to
+// the JSON data model: an object with ordered members, an array, or a
+// scalar.
+type modelNode struct {
+	names  []string
+	nodes  []*modelNode
+	array  bool
+	scalar any
+}
+
+// modelObj returns an object node with the members in kv, alternating
+// names and nodes.
+func modelObj(kv ...any) *modelNode {
+	n := &modelNode{names: []string{}}
+	for i := 0; i < len(kv); i += 2 {
+		n.names = append(n.names, kv[i].(string))
+		n.nodes = append(n.nodes, kv[i+1].(*modelNode))
+	}
+	return n
+}
+
+// modelArr returns an array node with elems.
+func modelArr(elems ...*modelNode) *modelNode {
+	return &modelNode{nodes: elems, array: true}
+}
+
+// modelVal returns a scalar node for val.
+func modelVal(val any) *modelNode { return &modelNode{scalar: val} }
+
+// Adapt returns n as an OrderedMap, Array, or scalar.
+func (n *modelNode) Adapt() any {
+	switch {
+	case n.array:
+		return modelArray{n}
+	case n.names != nil:
+		return modelObject{n}
+	default:
+		return n.scalar
+	}
+}
+
+// modelObject adapts a modelNode object to an OrderedMap.
+type modelObject struct{ n *modelNode }
+
+func (o modelObject) Len() int { return len(o.n.names) }
+
+func (o modelObject) Get(name string) (any, bool) {
+	if i := slices.Index(o.n.names, name); i >= 0 {
+		return o.n.nodes[i], true
+	}
+	return nil, false
+}
+
+func (o modelObject) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for i, name := range o.n.names {
+			if !yield(name, o.n.nodes[i]) {
+				return
+			}
+		}
+	}
+}
+
+// modelArray adapts a modelNode array to an Array.
+type modelArray struct{ n *modelNode }
+
+func (a modelArray) Len() int        { return len(a.n.nodes) }
+func (a modelArray) Index(i int) any { return a.n.nodes[i] }
+
+func TestDataModel(t *testing.T) {
+	t.Parallel()
+
+	doc := modelObj(
+		"store", modelObj(
+			"name", modelVal("shop"),
+			"book", modelArr(
+				modelObj("title", modelVal("A"), "price", modelVal(8), "tags", modelArr(modelVal("x"), modelVal("y"))),
+				modelObj("title", modelVal("B"), "price", modelVal(12.5), "tags", modelArr()),
+			),
+		),
+	)
+	books := doc.nodes[0].nodes[1]
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		exp   []any
+		paths []string
+	}{
+		{
+			name:  "names",
+			query: "$.store.name",
+			exp:   []any{doc.nodes[0].nodes[0]},
+			paths: []string{"$['store']['name']"},
+		},
+		{
+			name:  "index",
+			query: "$.store.book[-1].title",
+			exp:   []any{books.nodes[1].nodes[0]},
+			paths: []string{"$['store']['book'][1]['title']"},
+		},
+		{
+			name:  "slice",
+			query: "$.store.book[1:].price",
+			exp:   []any{books.nodes[1].nodes[1]},
+			paths: []string{"$['store']['book'][1]['price']"},
+		},
+		{
+			name:  "wildcard_in_order",
+			query: "$.store.*",
+			exp:   []any{doc.nodes[0].nodes[0], books},
+			paths: []string{"$['store']['name']", "$['store']['book']"},
+		},
+		{
+			name:  "descendants",
+			query: "$..tags[*]",
+			exp:   []any{books.nodes[0].nodes[2].nodes[0], books.nodes[0].nodes[2].nodes[1]},
+			paths: []string{"$['store']['book'][0]['tags'][0]", "$['store']['book'][0]['tags'][1]"},
+		},
+		{
+			name:  "compare_scalar",
+			query: "$.store.book[?@.price > 10].title",
+			exp:   []any{books.nodes[1].nodes[0]},
+			paths: []string{"$['store']['book'][1]['title']"},
+		},
+		{
+			name:  "compare_array",
+			query: `$.store.book[?@.tags == ["x", "y"]].title`,
+			exp:   []any{books.nodes[0].nodes[0]},
+			paths: []string{"$['store']['book'][0]['title']"},
+		},
+		{
+			name:  "membership",
+			query: `$.store.book[?@.title in ["A", "C"]].price`,
+			exp:   []any{books.nodes[0].nodes[1]},
+			paths: []string{"$['store']['book'][0]['price']"},
+		},
+		{
+			name:  "length",
+			query: "$.store.book[?length(@.tags) == 0 && length(@) == 3].title",
+			exp:   []any{books.nodes[1].nodes[0]},
+			paths: []string{"$['store']['book'][1]['title']"},
+		},
+		{
+			name:  "none",
+			query: "$.store.name[0]",
+			exp:   []any{},
+			paths: []string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := NewParser(WithCompositeLiterals(), WithMembership()).MustParse(tc.query)
+			a.Equal(NodeList(tc.exp), p.Select(doc))
+			located := p.SelectLocated(doc)
+			a.Equal(tc.exp, slices.AppendSeq([]any{}, located.Nodes()))
+			paths := slices.AppendSeq([]spec.NormalizedPath{}, located.Paths())
+			a.Equal(tc.paths, spec.NormalizedPaths(paths).Strings())
+		})
+	}
+}
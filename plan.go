@@ -0,0 +1,169 @@
+package jsonpath
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Hint describes the size of the nodes a query selects, for use by
+// [WithHints]. Derive hints from a schema or from statistics about the
+// documents to be queried, for example that the objects selected by
+// $.items[*] have about 50 members, or that the array selected by $.logs
+// has millions of elements.
+type Hint struct {
+	// Query selects the nodes the hint describes.
+	Query *Path
+
+	// Children is the approximate number of children of each node Query
+	// selects: elements for arrays and members for objects.
+	Children int
+}
+
+// WithHints configures [Path.SelectWith] and [Path.SelectLocatedWith] to plan
+// the evaluation of each segment of a query using hints about the size of
+// the nodes the segment selects from. A hint applies to a segment when its
+// Query is the same as the query consisting of the segments that precede
+// the segment; for example, a hint for $.logs applies to the filter segment
+// of $.logs[?@.level == "error"].
+//
+// Hints affect only how evaluation proceeds, never the nodes it selects.
+// Evaluation currently uses them to apply filter selectors to the elements
+// of arrays with thousands of elements concurrently, across GOMAXPROCS
+// goroutines, rather than one at a time. Filter expressions must therefore
+// be safe for concurrent use, as are all of those that use the standard
+// functions. Ignored when combined with [WithSegmentTimeout], which runs
+// selectors in goroutines of its own.
+func WithHints(hints ...Hint) SelectOption {
+	return func(c *evalConfig) { c.hints = append(c.hints, hints...) }
+}
+
+// strategy identifies a strategy for evaluating a segment.
+type strategy uint8
+
+const (
+	// strategySequential applies each selector of a segment to each node,
+	// one after another.
+	strategySequential strategy = iota
+
+	// strategyParallelFilter applies filter selectors to the elements of
+	// large arrays concurrently.
+	strategyParallelFilter
+)
+
+// parallelChildren is the minimum number of children for which evaluation
+// applies filter selectors concurrently, as estimated by a hint and as
+// found in an array. Smaller arrays don't amortize the cost of goroutines.
+const parallelChildren = 2048
+
+// plan returns the evaluation strategy for each segment of q based on e's
+// hints, or nil if e has no hints that apply.
+func (e *evaluator) plan(q *spec.PathQuery) []strategy {
+	if len(e.hints) == 0 || e.timeout > 0 {
+		return nil
+	}
+
+	hints := make(map[string]int, len(e.hints))
+	for _, h := range e.hints {
+		hints[h.Query.String()] = h.Children
+	}
+
+	var plan []strategy
+	segs := q.Segments()
+	for i, seg := range segs {
+		children, ok := hints[spec.Query(q.IsRoot(), segs[:i]).String()]
+		if !ok || children < parallelChildren || !hasFilter(seg) {
+			continue
+		}
+		if plan == nil {
+			plan = make([]strategy, len(segs))
+		}
+		plan[i] = strategyParallelFilter
+	}
+	return plan
+}
+
+// hasFilter returns true if seg has a filter selector.
+func hasFilter(seg *spec.Segment) bool {
+	for _, sel := range seg.Selectors() {
+		if _, ok := sel.(*spec.FilterSelector); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parallelFilter returns the elements of arr that sel selects if it's a
+// filter selector that e's strategy for the current segment applies to
+// large arrays concurrently, passing each element and its index to fn to
+// produce the returned values. Returns false if the strategy does not
+// apply, in which case the caller must select from arr sequentially.
+func parallelFilter[T any](
+	e *evaluator,
+	sel spec.Selector,
+	current any,
+	parent spec.NormalizedPath,
+	fn func(idx int, val any) T,
+) ([]T, bool) {
+	if e.strategy != strategyParallelFilter {
+		return nil, false
+	}
+	f, ok := sel.(*spec.FilterSelector)
+	if !ok {
+		return nil, false
+	}
+	arr, ok := current.([]any)
+	if !ok || len(arr) < parallelChildren {
+		return nil, false
+	}
+
+	matches, panicVal := e.filterChunks(f, arr)
+	if panicVal != nil {
+		if e.repanic {
+			panic(panicVal)
+		}
+		e.report(Warning{Selector: sel, Path: parent, Value: panicVal})
+		return nil, true
+	}
+
+	res := []T{}
+	for i, v := range arr {
+		if matches[i] {
+			res = append(res, fn(i, v))
+		}
+	}
+	return res, true
+}
+
+// filterChunks evaluates f on the elements of arr in GOMAXPROCS chunks
+// concurrently and reports which of them f selects. If f panics for any
+// element, returns the value passed to panic.
+func (e *evaluator) filterChunks(f *spec.FilterSelector, arr []any) ([]bool, any) {
+	workers := runtime.GOMAXPROCS(0)
+	size := (len(arr) + workers - 1) / workers
+	matches := make([]bool, len(arr))
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		panicVal any
+	)
+	for start := 0; start < len(arr); start += size {
+		end := min(start+size, len(arr))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if val := recover(); val != nil {
+					once.Do(func() { panicVal = val })
+				}
+			}()
+			for i := start; i < end; i++ {
+				matches[i] = f.Eval(arr[i], e.root)
+			}
+		}()
+	}
+	wg.Wait()
+	return matches, panicVal
+}
@@ -0,0 +1,198 @@
+package jsonpath
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	logs := Hint{Query: MustParse(`$.logs`), Children: 1_000_000}
+	items := Hint{Query: MustParse(`$.items[*]`), Children: 50}
+	huge := Hint{Query: MustParse(`$.items[*]`), Children: parallelChildren}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		opts  []SelectOption
+		exp   []strategy
+		strat strategy
+	}{
+		{
+			name: "no_hints",
+			path: `$.logs[?@.level == "error"]`,
+		},
+		{
+			name: "parallel",
+			path: `$.logs[?@.level == "error"]`,
+			opts: []SelectOption{WithHints(logs)},
+			exp:  []strategy{strategySequential, strategyParallelFilter},
+		},
+		{
+			name: "equivalent_query",
+			path: `$["logs"][?@.level == "error"].msg`,
+			opts: []SelectOption{WithHints(logs)},
+			exp:  []strategy{strategySequential, strategyParallelFilter, strategySequential},
+		},
+		{
+			name: "no_filter",
+			path: `$.logs[0]`,
+			opts: []SelectOption{WithHints(logs)},
+		},
+		{
+			name: "small",
+			path: `$.items[*][?@ > 1]`,
+			opts: []SelectOption{WithHints(logs, items)},
+		},
+		{
+			name: "multiple_hints",
+			path: `$.items[*][?@ > 1]`,
+			opts: []SelectOption{WithHints(logs), WithHints(huge)},
+			exp:  []strategy{strategySequential, strategySequential, strategyParallelFilter},
+		},
+		{
+			name: "other_prefix",
+			path: `$.items[?@.level == "error"]`,
+			opts: []SelectOption{WithHints(logs, huge)},
+		},
+		{
+			name: "timeout",
+			path: `$.logs[?@.level == "error"]`,
+			opts: []SelectOption{WithHints(logs), WithSegmentTimeout(time.Second)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			e, err := newEvaluator(nil, tc.opts)
+			require.NoError(t, err)
+			a.Equal(tc.exp, e.plan(MustParse(tc.path).q))
+		})
+	}
+}
+
+func TestWithHints(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	logs := make([]any, parallelChildren*3+7)
+	for i := range logs {
+		level := "info"
+		if i%5 == 0 {
+			level = "error"
+		}
+		logs[i] = map[string]any{"level": level, "id": float64(i)}
+	}
+	doc := map[string]any{"logs": logs, "small": logs[:10]}
+	hints := WithHints(
+		Hint{Query: MustParse(`$.logs`), Children: len(logs)},
+		Hint{Query: MustParse(`$.small`), Children: len(logs)},
+	)
+
+	for _, tc := range []struct {
+		name string
+		path string
+		size int
+	}{
+		{"parallel", `$.logs[?@.level == "error"]`, len(logs)/5 + 1},
+		{"union", `$.logs[1, ?@.id < 3, 0]`, 5},
+		{"none", `$.logs[?@.level == "debug"]`, 0},
+		{"small_array", `$.small[?@.level == "error"]`, 2},
+		{"not_array", `$[?@.level == "error"]`, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+
+			res, err := p.SelectWith(doc, hints)
+			r.NoError(err)
+			a.Len(res, tc.size)
+			a.Equal(p.Select(doc), res)
+
+			loc, err := p.SelectLocatedWith(doc, hints)
+			r.NoError(err)
+			a.Equal(p.SelectLocated(doc), loc)
+		})
+	}
+}
+
+func TestWithHintsPanic(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Register a function that panics on the value 42.
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"boom",
+		spec.FuncLogical,
+		func([]spec.FunctionExprArg) error { return nil },
+		func(args []spec.JSONPathValue) spec.JSONPathValue {
+			if v := spec.ValueFrom(args[0]); v != nil && v.Value() == 42 {
+				panic("boom")
+			}
+			return spec.LogicalTrue
+		},
+	))
+	p := NewParser(WithRegistry(reg)).MustParse(`$.a[?boom(@), 0]`)
+
+	arr := make([]any, parallelChildren)
+	for i := range arr {
+		arr[i] = i
+	}
+	doc := map[string]any{"a": arr}
+	hints := WithHints(Hint{Query: MustParse(`$.a`), Children: len(arr)})
+
+	var warns []string
+	warn := WithWarnings(func(w Warning) { warns = append(warns, w.String()) })
+	res, err := p.SelectWith(doc, hints, warn)
+	r.NoError(err)
+	a.Equal(NodeList{0}, res)
+
+	loc, err := p.SelectLocatedWith(doc, hints, warn)
+	r.NoError(err)
+	a.Equal([]any{0}, slices.Collect(loc.Nodes()))
+	a.Equal([]string{
+		"selector ?boom(@) panicked: boom",
+		"selector ?boom(@) panicked at $['a']: boom",
+	}, warns)
+
+	a.PanicsWithValue("boom", func() { _, _ = p.SelectWith(doc, hints, WithRepanic()) })
+	a.PanicsWithValue("boom", func() { _, _ = p.SelectLocatedWith(doc, hints, WithRepanic()) })
+}
+
+func BenchmarkWithHints(b *testing.B) {
+	logs := make([]any, 100_000)
+	for i := range logs {
+		logs[i] = map[string]any{
+			"level": []string{"debug", "info", "warn", "error"}[i%4],
+			"msg":   fmt.Sprintf("message %d", i),
+		}
+	}
+	doc := map[string]any{"logs": logs}
+	p := MustParse(`$.logs[?@.level == "error" && match(@.msg, "message [0-9]*7")]`)
+	hints := WithHints(Hint{Query: MustParse(`$.logs`), Children: len(logs)})
+
+	b.Run("sequential", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_, _ = p.SelectWith(doc)
+		}
+	})
+
+	b.Run("hints", func(b *testing.B) {
+		b.ReportAllocs()
+		for range b.N {
+			_, _ = p.SelectWith(doc, hints)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package registry
+
+import (
+	"container/list"
+	"regexp"
+	"sync"
+)
+
+// regexCacheSize is the maximum number of regular expressions compiled from
+// dynamic patterns, such as those selected by queries, that match() and
+// search() retain.
+const regexCacheSize = 256
+
+// regexes caches the regular expressions compiled for the dynamic patterns
+// passed to match() and search().
+//
+//nolint:gochecknoglobals
+var regexes = newRegexCache(regexCacheSize)
+
+// regexCache is a least-recently-used cache of regular expressions compiled
+// by compileRegex, safe for concurrent use. It caches nil for patterns that
+// fail to compile, so that it doesn't try to compile them again.
+type regexCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[regexKey]*list.Element
+}
+
+// regexKey identifies a regular expression in a regexCache.
+type regexKey struct {
+	pattern  string
+	anchored bool
+}
+
+// regexEntry is an entry in a regexCache.
+type regexEntry struct {
+	key regexKey
+	re  *regexp.Regexp
+}
+
+// newRegexCache creates a new regexCache that retains up to size regular
+// expressions.
+func newRegexCache(size int) *regexCache {
+	return &regexCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[regexKey]*list.Element, size),
+	}
+}
+
+// get returns the regular expression compiled from pattern and anchored by
+// compileRegex, compiling and caching it if c does not contain it. Evicts
+// the least recently used regular expression when c is full. Returns nil if
+// pattern fails to compile.
+func (c *regexCache) get(pattern string, anchored bool) *regexp.Regexp {
+	key := regexKey{pattern, anchored}
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*regexEntry).re
+	}
+	c.mu.Unlock()
+
+	// Compile without the lock, so that evaluations of other patterns need
+	// not wait; concurrent misses for the same pattern compile it twice.
+	re, _ := compileRegex(pattern, anchored)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*regexEntry).re
+	}
+	c.entries[key] = c.order.PushFront(&regexEntry{key, re})
+	if c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*regexEntry).key)
+	}
+	return re
+}
+
+// len returns the number of regular expressions in c.
+func (c *regexCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexCache(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	cache := newRegexCache(2)
+	a.Equal(0, cache.len())
+
+	// Cache compiled patterns.
+	x := cache.get("x", false)
+	a.NotNil(x)
+	a.Same(x, cache.get("x", false))
+	a.Equal(1, cache.len())
+
+	// Cache nil for invalid patterns.
+	a.Nil(cache.get(".[", false))
+	a.Equal(2, cache.len())
+
+	// Evict the least recently used pattern.
+	a.Same(x, cache.get("x", false))
+	y := cache.get("y", false)
+	a.NotNil(y)
+	a.Equal(2, cache.len())
+	a.Same(x, cache.get("x", false))
+	a.Same(y, cache.get("y", false))
+	a.NotContains(cache.entries, regexKey{".[", false})
+
+	// Evict x and compile it anew.
+	cache.get("z", false)
+	a.NotContains(cache.entries, regexKey{"x", false})
+	a.NotSame(x, cache.get("x", false))
+	a.Equal(2, cache.len())
+
+	// Cache anchored patterns separately.
+	anchored := cache.get("x", true)
+	a.NotSame(anchored, cache.get("x", false))
+	a.True(anchored.MatchString("x"))
+	a.False(anchored.MatchString("xx"))
+
+	// Support concurrent use.
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, p := range []string{"a", "b", "c", "x"} {
+				a.True(cache.get(p, false).MatchString(p), "%v: %v", i, p)
+			}
+		}()
+	}
+	wg.Wait()
+	a.LessOrEqual(cache.len(), 2)
+}
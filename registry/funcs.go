@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
-	"regexp/syntax"
 	"unicode/utf8"
 
 	"github.com/theory/jsonpath/spec"
@@ -27,18 +26,20 @@ func checkLengthArgs(fea []spec.FunctionExprArg) error {
 }
 
 // lengthFunc extracts the single argument passed in jv and returns its
-// length. Panics if jv[0] doesn't exist or is not convertible to [ValueType].
+// length.
 //
-//   - if jv[0] is nil, the result is nil
+//   - if jv[0] is nil or not convertible to [spec.ValueType], the result is
+//     nil
 //   - If jv[0] is a string, the result is the number of Unicode scalar values
 //     in the string.
-//   - If jv[0] is a []any, the result is the number of elements in the slice.
-//   - If jv[0] is an map[string]any, the result is the number of members in
-//     the map.
+//   - If jv[0] is a []any or [spec.Array], the result is the number of
+//     elements in the array.
+//   - If jv[0] is an map[string]any or [spec.OrderedMap], the result is the
+//     number of members in the object.
 //   - For any other value, the result is nil.
 func lengthFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
-	v := spec.ValueFrom(jv[0])
-	if v == nil {
+	v, err := spec.ValueFromErr(jv[0])
+	if err != nil || v == nil {
 		return nil
 	}
 	switch v := v.Value().(type) {
@@ -47,8 +48,12 @@ func lengthFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
 		return spec.Value(utf8.RuneCountInString(v))
 	case []any:
 		return spec.Value(len(v))
+	case spec.Array:
+		return spec.Value(v.Len())
 	case map[string]any:
 		return spec.Value(len(v))
+	case spec.OrderedMap:
+		return spec.Value(v.Len())
 	default:
 		return nil
 	}
@@ -72,10 +77,13 @@ func checkCountArgs(fea []spec.FunctionExprArg) error {
 
 // countFunc implements the [RFC 9535]-standard count function. The result is
 // a ValueType containing an unsigned integer for the number of nodes
-// in jv[0]. Panics if jv[0] doesn't exist or is not convertible to
-// [NodesType].
+// in jv[0], or nil if jv[0] is not convertible to [spec.NodesType].
 func countFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
-	return spec.Value(len(spec.NodesFrom(jv[0])))
+	nodes, err := spec.NodesFromErr(jv[0])
+	if err != nil {
+		return nil
+	}
+	return spec.Value(len(nodes))
 }
 
 // checkValueArgs checks the argument expressions to value() and returns an
@@ -94,14 +102,14 @@ func checkValueArgs(fea []spec.FunctionExprArg) error {
 	return nil
 }
 
-// valueFunc implements the [RFC 9535]-standard value function. Panics if
-// jv[0] doesn't exist or is not convertible to [NodesType]. Otherwise:
+// valueFunc implements the [RFC 9535]-standard value function.
 //
 //   - If jv[0] contains a single node, the result is the value of the node.
-//   - If jv[0] is empty or contains multiple nodes, the result is nil.
+//   - If jv[0] is empty, contains multiple nodes, or is not convertible to
+//     [spec.NodesType], the result is nil.
 func valueFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
-	nodes := spec.NodesFrom(jv[0])
-	if len(nodes) == 1 {
+	nodes, err := spec.NodesFromErr(jv[0])
+	if err == nil && len(nodes) == 1 {
 		return spec.Value(nodes[0])
 	}
 	return nil
@@ -109,7 +117,8 @@ func valueFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
 
 // checkMatchArgs checks the argument expressions to match() and returns an
 // error if there are not exactly two expressions that result in
-// [PathValue]-compatible values.
+// [PathValue]-compatible values, or an [*ArgError] wrapping an [ErrIRegexp]
+// error if the second is a literal string that is not a valid I-Regexp.
 func checkMatchArgs(fea []spec.FunctionExprArg) error {
 	const matchArgLen = 2
 	if len(fea) != matchArgLen {
@@ -123,28 +132,38 @@ func checkMatchArgs(fea []spec.FunctionExprArg) error {
 		}
 	}
 
-	return nil
+	return checkPattern(fea, true)
 }
 
 // matchFunc implements the [RFC 9535]-standard match function. If jv[0] and
-// jv[1] evaluate to strings, the second is compiled into a regular expression with
-// implied \A and \z anchors and used to match the first, returning LogicalTrue for
-// a match and LogicalFalse for no match. Returns LogicalFalse if either jv value
-// is not a string or if jv[1] fails to compile.
+// jv[1] evaluate to strings, the second is compiled from an I-Regexp
+// ([RFC 9485]) into a regular expression anchored to the whole string and
+// used to match the first, returning LogicalTrue for a match and
+// LogicalFalse for no match. Returns LogicalFalse if either jv value is not
+// a string or if jv[1] fails to compile. Caches the compiled regular
+// expression for reuse; see prepareMatch for literal patterns.
 func matchFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
-	if v, ok := spec.ValueFrom(jv[0]).Value().(string); ok {
-		if r, ok := spec.ValueFrom(jv[1]).Value().(string); ok {
-			if rc := compileRegex(`\A` + r + `\z`); rc != nil {
-				return spec.LogicalFrom(rc.MatchString(v))
-			}
-		}
+	if r, ok := stringValue(jv[1]); ok {
+		return regexFunc(regexes.get(r, true), jv)
 	}
 	return spec.LogicalFalse
 }
 
+// prepareMatch returns a match() evaluator that uses a regular expression
+// compiled once from a literal pattern in args[1], or nil if args[1] is not
+// a literal string.
+func prepareMatch(args []spec.FunctionExprArg) func([]spec.JSONPathValue) spec.JSONPathValue {
+	if r, ok := literalString(args, 1); ok {
+		re, _ := compileRegex(r, true)
+		return func(jv []spec.JSONPathValue) spec.JSONPathValue { return regexFunc(re, jv) }
+	}
+	return nil
+}
+
 // checkSearchArgs checks the argument expressions to search() and returns an
 // error if there are not exactly two expressions that result in
-// [PathValue]-compatible values.
+// [PathValue]-compatible values, or an [*ArgError] wrapping an [ErrIRegexp]
+// error if the second is a literal string that is not a valid I-Regexp.
 func checkSearchArgs(fea []spec.FunctionExprArg) error {
 	const searchArgLen = 2
 	if len(fea) != searchArgLen {
@@ -158,54 +177,77 @@ func checkSearchArgs(fea []spec.FunctionExprArg) error {
 		}
 	}
 
-	return nil
+	return checkPattern(fea, false)
 }
 
-// searchFunc implements the [RFC 9535]-standard search function. If both jv[0]
-// and jv[1] contain strings, the latter is compiled into a regular expression and used
-// to match the former, returning LogicalTrue for a match and LogicalFalse for no
-// match. Returns LogicalFalse if either value is not a string, or if jv[1]
-// fails to compile.
+// searchFunc implements the [RFC 9535]-standard search function. If both
+// jv[0] and jv[1] contain strings, the latter is compiled from an I-Regexp
+// ([RFC 9485]) into a regular expression and used to match the former,
+// returning LogicalTrue for a match and LogicalFalse for no match. Returns
+// LogicalFalse if either value is not a string, or if jv[1] fails to
+// compile. Caches the compiled regular expression for reuse; see
+// prepareSearch for literal patterns.
 func searchFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
-	if val, ok := spec.ValueFrom(jv[0]).Value().(string); ok {
-		if r, ok := spec.ValueFrom(jv[1]).Value().(string); ok {
-			if rc := compileRegex(r); rc != nil {
-				return spec.LogicalFrom(rc.MatchString(val))
-			}
-		}
+	if r, ok := stringValue(jv[1]); ok {
+		return regexFunc(regexes.get(r, false), jv)
 	}
 	return spec.LogicalFalse
 }
 
-// compileRegex compiles str into a regular expression or returns an error. To
-// comply with RFC 9485 regular expression semantics, all instances of "." are
-// replaced with "[^\n\r]". This sadly requires compiling the regex twice:
-// once to produce an AST to replace "." nodes, and a second time for the
-// final regex.
-func compileRegex(str string) *regexp.Regexp {
-	// First compile AST and replace "." with [^\n\r].
-	// https://www.rfc-editor.org/rfc/rfc9485.html#name-pcre-re2-and-ruby-regexps
-	r, err := syntax.Parse(str, syntax.Perl|syntax.DotNL)
-	if err != nil {
-		// Could use some way to log these errors rather than failing silently.
-		return nil
+// prepareSearch returns a search() evaluator that uses a regular expression
+// compiled once from a literal pattern in args[1], or nil if args[1] is not
+// a literal string.
+func prepareSearch(args []spec.FunctionExprArg) func([]spec.JSONPathValue) spec.JSONPathValue {
+	if r, ok := literalString(args, 1); ok {
+		re, _ := compileRegex(r, false)
+		return func(jv []spec.JSONPathValue) spec.JSONPathValue { return regexFunc(re, jv) }
 	}
-
-	replaceDot(r)
-	re, _ := regexp.Compile(r.String())
-	return re
+	return nil
 }
 
-//nolint:gochecknoglobals
-var clrf, _ = syntax.Parse("[^\n\r]", syntax.Perl)
+// checkPattern returns an [*ArgError] wrapping an [ErrIRegexp] error if
+// fea[1] is a literal string that is not a valid I-Regexp, anchored as for
+// match() if anchored is true.
+func checkPattern(fea []spec.FunctionExprArg, anchored bool) error {
+	if r, ok := literalString(fea, 1); ok {
+		if _, err := compileRegex(r, anchored); err != nil {
+			return &ArgError{1, err}
+		}
+	}
+	return nil
+}
 
-// replaceDot recurses re to replace all "." nodes with "[^\n\r]" nodes.
-func replaceDot(re *syntax.Regexp) {
-	if re.Op == syntax.OpAnyChar {
-		*re = *clrf
-	} else {
-		for _, re := range re.Sub {
-			replaceDot(re)
+// literalString returns the string value of args[i] and true if it's a
+// literal string, and false if it's not.
+func literalString(args []spec.FunctionExprArg, i int) (string, bool) {
+	if len(args) > i {
+		if lit, ok := args[i].(*spec.LiteralArg); ok {
+			str, ok := lit.Value().(string)
+			return str, ok
 		}
 	}
+	return "", false
+}
+
+// regexFunc returns LogicalTrue if jv[0] contains a string that re matches,
+// and LogicalFalse if it does not, if jv[0] is not a string or is nil, or if
+// re is nil.
+func regexFunc(re *regexp.Regexp, jv []spec.JSONPathValue) spec.JSONPathValue {
+	if re == nil {
+		return spec.LogicalFalse
+	}
+	if val, ok := stringValue(jv[0]); ok {
+		return spec.LogicalFrom(re.MatchString(val))
+	}
+	return spec.LogicalFalse
+}
+
+// stringValue returns the string in jv, or false if jv does not contain a
+// string, including when jv is nil because its argument selected no node.
+func stringValue(jv spec.JSONPathValue) (string, bool) {
+	if v, err := spec.ValueFromErr(jv); err == nil && v != nil {
+		str, ok := v.Value().(string)
+		return str, ok
+	}
+	return "", false
 }
@@ -17,7 +17,6 @@ func TestLengthFunc(t *testing.T) {
 		name string
 		vals []spec.JSONPathValue
 		exp  int
-		err  string
 	}{
 		{
 			name: "empty_string",
@@ -74,6 +73,16 @@ func TestLengthFunc(t *testing.T) {
 			})},
 			exp: 4,
 		},
+		{
+			name: "ordered_object",
+			vals: []spec.JSONPathValue{spec.Value(func() *spec.OrderedObject {
+				obj := &spec.OrderedObject{}
+				obj.Set("x", 1)
+				obj.Set("y", 2)
+				return obj
+			}())},
+			exp: 2,
+		},
 		{
 			name: "integer",
 			vals: []spec.JSONPathValue{spec.Value(42)},
@@ -97,17 +106,11 @@ func TestLengthFunc(t *testing.T) {
 		{
 			name: "not_value",
 			vals: []spec.JSONPathValue{spec.LogicalFalse},
-			err:  "unexpected argument of type spec.LogicalType",
+			exp:  -1,
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			if tc.err != "" {
-				a.PanicsWithValue(tc.err, func() {
-					lengthFunc(tc.vals)
-				})
-				return
-			}
 			res := lengthFunc(tc.vals)
 			if tc.exp < 0 {
 				a.Nil(res)
@@ -304,6 +307,19 @@ func TestCheckRegexFuncArgs(t *testing.T) {
 			},
 			err: "cannot convert argument 2 to PathNodes",
 		},
+		{
+			name: "invalid_literal_pattern",
+			expr: []spec.FunctionExprArg{spec.Literal("hi"), spec.Literal(`\d+`)},
+			err:  `i-regexp: invalid escape \d (offset 1)`,
+		},
+		{
+			name: "valid_literal_pattern",
+			expr: []spec.FunctionExprArg{spec.Literal("hi"), spec.Literal(`[0-9]+`)},
+		},
+		{
+			name: "non_string_literal_pattern",
+			expr: []spec.FunctionExprArg{spec.Literal("hi"), spec.Literal(42)},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
@@ -334,19 +350,14 @@ func TestCountFunc(t *testing.T) {
 		name string
 		vals []spec.JSONPathValue
 		exp  int
-		err  string
 	}{
-		{"empty", []spec.JSONPathValue{spec.NodesType([]any{})}, 0, ""},
-		{"one", []spec.JSONPathValue{spec.NodesType([]any{1})}, 1, ""},
-		{"three", []spec.JSONPathValue{spec.NodesType([]any{1, true, nil})}, 3, ""},
-		{"not_nodes", []spec.JSONPathValue{spec.LogicalTrue}, 0, "unexpected argument of type spec.LogicalType"},
+		{"empty", []spec.JSONPathValue{spec.NodesType([]any{})}, 0},
+		{"one", []spec.JSONPathValue{spec.NodesType([]any{1})}, 1},
+		{"three", []spec.JSONPathValue{spec.NodesType([]any{1, true, nil})}, 3},
+		{"not_nodes", []spec.JSONPathValue{spec.LogicalTrue}, -1},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			if tc.err != "" {
-				a.PanicsWithValue(tc.err, func() { countFunc(tc.vals) })
-				return
-			}
 			res := countFunc(tc.vals)
 			if tc.exp < 0 {
 				a.Nil(res)
@@ -365,21 +376,16 @@ func TestValueFunc(t *testing.T) {
 		name string
 		vals []spec.JSONPathValue
 		exp  spec.JSONPathValue
-		err  string
 	}{
-		{"empty", []spec.JSONPathValue{spec.NodesType([]any{})}, nil, ""},
-		{"one_int", []spec.JSONPathValue{spec.NodesType([]any{1})}, spec.Value(1), ""},
-		{"one_null", []spec.JSONPathValue{spec.NodesType([]any{nil})}, spec.Value(nil), ""},
-		{"one_string", []spec.JSONPathValue{spec.NodesType([]any{"x"})}, spec.Value("x"), ""},
-		{"three", []spec.JSONPathValue{spec.NodesType([]any{1, true, nil})}, nil, ""},
-		{"not_nodes", []spec.JSONPathValue{spec.LogicalFalse}, nil, "unexpected argument of type spec.LogicalType"},
+		{"empty", []spec.JSONPathValue{spec.NodesType([]any{})}, nil},
+		{"one_int", []spec.JSONPathValue{spec.NodesType([]any{1})}, spec.Value(1)},
+		{"one_null", []spec.JSONPathValue{spec.NodesType([]any{nil})}, spec.Value(nil)},
+		{"one_string", []spec.JSONPathValue{spec.NodesType([]any{"x"})}, spec.Value("x")},
+		{"three", []spec.JSONPathValue{spec.NodesType([]any{1, true, nil})}, nil},
+		{"not_nodes", []spec.JSONPathValue{spec.LogicalFalse}, nil},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			if tc.err != "" {
-				a.PanicsWithValue(tc.err, func() { valueFunc(tc.vals) })
-				return
-			}
 			a.Equal(tc.exp, valueFunc(tc.vals))
 		})
 	}
@@ -445,6 +451,13 @@ func TestRegexFuncs(t *testing.T) {
 			match:  false,
 			search: false,
 		},
+		{
+			name:   "nothing_input",
+			input:  nil,
+			regex:  spec.Value("."),
+			match:  false,
+			search: false,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
@@ -463,7 +476,6 @@ func TestExecRegexFuncs(t *testing.T) {
 		vals   []spec.JSONPathValue
 		match  bool
 		search bool
-		err    string
 	}{
 		{
 			name:   "dot",
@@ -472,25 +484,97 @@ func TestExecRegexFuncs(t *testing.T) {
 			search: true,
 		},
 		{
-			name: "first_not_value",
-			vals: []spec.JSONPathValue{spec.NodesType{}, spec.Value("x")},
-			err:  "unexpected argument of type spec.NodesType",
+			name:   "first_nothing",
+			vals:   []spec.JSONPathValue{nil, spec.Value("x")},
+			match:  false,
+			search: false,
+		},
+		{
+			name:   "second_nothing",
+			vals:   []spec.JSONPathValue{spec.Value("x"), nil},
+			match:  false,
+			search: false,
+		},
+		{
+			name:   "first_not_value",
+			vals:   []spec.JSONPathValue{spec.NodesType{}, spec.Value("x")},
+			match:  false,
+			search: false,
+		},
+		{
+			name:   "second_not_value",
+			vals:   []spec.JSONPathValue{spec.Value("x"), spec.LogicalFalse},
+			match:  false,
+			search: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(matchFunc(tc.vals), spec.LogicalFrom(tc.match))
+			a.Equal(searchFunc(tc.vals), spec.LogicalFrom(tc.search))
+		})
+	}
+}
+
+func TestPrepareRegexFuncs(t *testing.T) {
+	t.Parallel()
+	query := spec.SingularQuery(false, []spec.Selector{spec.Name("re")})
+
+	for _, tc := range []struct {
+		name     string
+		args     []spec.FunctionExprArg
+		prepared bool
+	}{
+		{
+			name:     "literal",
+			args:     []spec.FunctionExprArg{spec.Literal("x"), spec.Literal("a.c")},
+			prepared: true,
+		},
+		{
+			name:     "invalid_literal",
+			args:     []spec.FunctionExprArg{spec.Literal("x"), spec.Literal(".[")},
+			prepared: true,
+		},
+		{
+			name: "query",
+			args: []spec.FunctionExprArg{spec.Literal("x"), query},
+		},
+		{
+			name: "not_string",
+			args: []spec.FunctionExprArg{spec.Literal("x"), spec.Literal(1)},
 		},
 		{
-			name: "second_not_value",
-			vals: []spec.JSONPathValue{spec.Value("x"), spec.LogicalFalse},
-			err:  "unexpected argument of type spec.LogicalType",
+			name: "no_pattern",
+			args: []spec.FunctionExprArg{spec.Literal("x")},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			if tc.err == "" {
-				a.Equal(matchFunc(tc.vals), spec.LogicalFrom(tc.match))
-				a.Equal(searchFunc(tc.vals), spec.LogicalFrom(tc.search))
-			} else {
-				a.PanicsWithValue(tc.err, func() { matchFunc(tc.vals) })
-				a.PanicsWithValue(tc.err, func() { searchFunc(tc.vals) })
+			a := assert.New(t)
+			match, search := prepareMatch(tc.args), prepareSearch(tc.args)
+			if !tc.prepared {
+				a.Nil(match)
+				a.Nil(search)
+				return
+			}
+			a.NotNil(match)
+			a.NotNil(search)
+
+			// The prepared evaluators agree with the dynamic evaluators.
+			pattern := tc.args[1].(*spec.LiteralArg).Value()
+			for _, input := range []any{"abc", "xabcx", "a\nc", 1} {
+				args := []spec.JSONPathValue{spec.Value(input), spec.Value(pattern)}
+				a.Equal(matchFunc(args), match(args), "match %q", input)
+				a.Equal(searchFunc(args), search(args), "search %q", input)
 			}
 		})
 	}
+
+	// Registered functions prepare regular expressions, too.
+	reg := New()
+	for _, name := range []string{"match", "search"} {
+		fn := reg.Get(name)
+		assert.NotNil(t, fn.Prepare([]spec.FunctionExprArg{spec.Literal("x"), spec.Literal("x")}))
+	}
+	assert.Nil(t, reg.Get("length").Prepare([]spec.FunctionExprArg{spec.Literal("x")}))
 }
@@ -0,0 +1,427 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrIRegexp errors are returned by the validators of match() and search()
+// for literal patterns that are not valid I-Regexp ([RFC 9485]) regular
+// expressions.
+//
+// [RFC 9485]: https://www.rfc-editor.org/rfc/rfc9485.html
+var ErrIRegexp = errors.New("i-regexp")
+
+// maxRepeat is the maximum count in a range quantifier, such as {2,5},
+// supported by the [regexp] package.
+const maxRepeat = 1000
+
+// compileRegex compiles pattern, an I-Regexp, into a regular expression.
+// Anchors the expression to match entire strings if anchored is true, as
+// for match(), and otherwise to match substrings, as for search().
+func compileRegex(pattern string, anchored bool) (*regexp.Regexp, error) {
+	expr, err := translateIRegexp(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if anchored {
+		expr = `\A(?:` + expr + `)\z`
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIRegexp, err)
+	}
+	return re, nil
+}
+
+// translateIRegexp translates pattern, an I-Regexp, into the equivalent
+// [regexp] syntax. The translation:
+//
+//   - Replaces "." with [^\n\r], which matches any character but line
+//     breaks.
+//   - Escapes characters literal in I-Regexp but special in [regexp], such
+//     as "^" and "$".
+//   - Replaces groups with non-capturing groups.
+//   - Replaces the Unicode categories C and Cn, which [regexp] does not
+//     support or defines differently, with equivalent character classes.
+//
+// Returns an [ErrIRegexp] error for any syntax not defined by I-Regexp,
+// such as anchors, backreferences, lazy quantifiers, and escapes like \d
+// and \w.
+func translateIRegexp(pattern string) (string, error) {
+	p := &iregexpParser{src: []rune(pattern)}
+	if err := p.regexp(); err != nil {
+		return "", err
+	}
+	if p.pos < len(p.src) {
+		// Only an unmatched ")" stops p.regexp() before the end.
+		return "", p.errorf("unmatched %q", p.src[p.pos])
+	}
+	return p.buf.String(), nil
+}
+
+// iregexpParser parses an I-Regexp and writes its translation to buf.
+type iregexpParser struct {
+	src []rune
+	pos int
+	buf strings.Builder
+}
+
+// errorf returns an [ErrIRegexp] error describing a problem at the current
+// position, reported as the one-based offset of the character in the
+// pattern. Parsers of queries report the position in the query.
+func (p *iregexpParser) errorf(format string, a ...any) error {
+	return fmt.Errorf(
+		"%w: %v (offset %v)",
+		ErrIRegexp, fmt.Sprintf(format, a...), p.pos+1,
+	)
+}
+
+// peek returns the rune i runes past the current position, or -1 at the
+// end of the pattern.
+func (p *iregexpParser) peek(i int) rune {
+	if p.pos+i < len(p.src) {
+		return p.src[p.pos+i]
+	}
+	return -1
+}
+
+// accept advances past r and returns true if it's the current rune.
+func (p *iregexpParser) accept(r rune) bool {
+	if p.peek(0) == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// regexp parses branches separated by "|".
+func (p *iregexpParser) regexp() error {
+	for {
+		if err := p.branch(); err != nil {
+			return err
+		}
+		if !p.accept('|') {
+			return nil
+		}
+		p.buf.WriteByte('|')
+	}
+}
+
+// branch parses pieces up to a "|", ")", or the end of the pattern.
+func (p *iregexpParser) branch() error {
+	for r := p.peek(0); r != -1 && r != '|' && r != ')'; r = p.peek(0) {
+		if err := p.atom(); err != nil {
+			return err
+		}
+		if err := p.quantifier(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// atom parses a character, character class, or group.
+func (p *iregexpParser) atom() error {
+	switch r := p.peek(0); r {
+	case '(':
+		p.pos++
+		p.buf.WriteString("(?:")
+		if err := p.regexp(); err != nil {
+			return err
+		}
+		if !p.accept(')') {
+			return p.errorf("missing closing parenthesis")
+		}
+		p.buf.WriteByte(')')
+	case '.':
+		p.pos++
+		p.buf.WriteString(`[^\n\r]`)
+	case '[':
+		return p.classExpr()
+	case '\\':
+		if next := p.peek(1); next == 'p' || next == 'P' {
+			class, err := p.categoryEscape()
+			if err != nil {
+				return err
+			}
+			p.buf.WriteString("[" + class + "]")
+			return nil
+		}
+		c, err := p.singleCharEscape()
+		if err != nil {
+			return err
+		}
+		p.buf.WriteString(regexp.QuoteMeta(string(c)))
+	default:
+		if !isNormalChar(r) {
+			return p.errorf("unexpected %q", r)
+		}
+		p.pos++
+		p.buf.WriteString(regexp.QuoteMeta(string(r)))
+	}
+	return nil
+}
+
+// isNormalChar returns true if r matches itself in an I-Regexp.
+func isNormalChar(r rune) bool {
+	switch r {
+	case '(', ')', '*', '+', '.', '?', '[', '\\', ']', '{', '|', '}':
+		return false
+	}
+	return !isSurrogate(r)
+}
+
+// isSurrogate returns true if r is a UTF-16 surrogate code point, which
+// I-Regexp excludes.
+func isSurrogate(r rune) bool {
+	return r >= 0xD800 && r <= 0xDFFF
+}
+
+// quantifier parses an optional quantifier: *, +, ?, {n}, {n,}, or {n,m}.
+func (p *iregexpParser) quantifier() error {
+	switch r := p.peek(0); r {
+	case '*', '+', '?':
+		p.pos++
+		p.buf.WriteRune(r)
+	case '{':
+		p.pos++
+		lo, err := p.repeatCount()
+		if err != nil {
+			return err
+		}
+		p.buf.WriteString("{" + strconv.Itoa(lo))
+		if p.accept(',') {
+			p.buf.WriteByte(',')
+			if p.peek(0) != '}' {
+				hi, err := p.repeatCount()
+				if err != nil {
+					return err
+				}
+				if hi < lo {
+					return p.errorf("invalid repeat count {%v,%v}", lo, hi)
+				}
+				p.buf.WriteString(strconv.Itoa(hi))
+			}
+		}
+		if !p.accept('}') {
+			return p.errorf("missing closing brace")
+		}
+		p.buf.WriteByte('}')
+	}
+	return nil
+}
+
+// repeatCount parses the digits of a count in a range quantifier.
+func (p *iregexpParser) repeatCount() (int, error) {
+	start := p.pos
+	for r := p.peek(0); r >= '0' && r <= '9'; r = p.peek(0) {
+		p.pos++
+	}
+	if p.pos == start {
+		return 0, p.errorf("missing repeat count")
+	}
+	n, err := strconv.Atoi(string(p.src[start:p.pos]))
+	if err != nil || n > maxRepeat {
+		p.pos = start
+		return 0, p.errorf("repeat count exceeds %v", maxRepeat)
+	}
+	return n, nil
+}
+
+// singleCharEscape parses a backslash escape for a single character and
+// returns the character.
+func (p *iregexpParser) singleCharEscape() (rune, error) {
+	p.pos++ // backslash
+	switch r := p.peek(0); r {
+	case '(', ')', '*', '+', '-', '.', '?', '[', '\\', ']', '^', '{', '|', '}':
+		p.pos++
+		return r, nil
+	case 'n':
+		p.pos++
+		return '\n', nil
+	case 'r':
+		p.pos++
+		return '\r', nil
+	case 't':
+		p.pos++
+		return '\t', nil
+	case -1:
+		return 0, p.errorf("trailing backslash")
+	default:
+		p.pos--
+		return 0, p.errorf("invalid escape \\%c", r)
+	}
+}
+
+// categoryEscape parses \p{Name} or \P{Name} and returns the translation of
+// the category for use within a character class.
+func (p *iregexpParser) categoryEscape() (string, error) {
+	start := p.pos
+	negate := p.src[p.pos+1] == 'P'
+	p.pos += 2
+	if !p.accept('{') {
+		return "", p.errorf("missing opening brace")
+	}
+	nameStart := p.pos
+	for r := p.peek(0); r != '}'; r = p.peek(0) {
+		if r == -1 {
+			return "", p.errorf("missing closing brace")
+		}
+		p.pos++
+	}
+	name := string(p.src[nameStart:p.pos])
+	p.pos++ // }
+
+	class, ok := categoryClass(name, negate)
+	if !ok {
+		p.pos = start
+		return "", p.errorf("invalid Unicode category %q", name)
+	}
+	return class, nil
+}
+
+// categoryClass returns the translation of the Unicode category name,
+// negated if negate is true, for use within a character class, and false if
+// I-Regexp does not support name.
+func categoryClass(name string, negate bool) (string, bool) {
+	switch name {
+	case "L", "Ll", "Lm", "Lo", "Lt", "Lu",
+		"M", "Mc", "Me", "Mn",
+		"N", "Nd", "Nl", "No",
+		"P", "Pc", "Pd", "Pe", "Pf", "Pi", "Po", "Ps",
+		"S", "Sc", "Sk", "Sm", "So",
+		"Z", "Zl", "Zp", "Zs",
+		"Cc", "Cf", "Co":
+		if negate {
+			return `\P{` + name + `}`, true
+		}
+		return `\p{` + name + `}`, true
+	case "C":
+		// I-Regexp defines C as Cc, Cf, Cn, and Co, while versions of
+		// regexp define it with or without Cn and with Cs.
+		if negate {
+			return assignedClass, true
+		}
+		return `\p{Cc}\p{Cf}\p{Co}` + unassignedClass(), true
+	case "Cn":
+		// Older versions of regexp do not support Cn, unassigned code
+		// points.
+		if negate {
+			return assignedClass + `\p{Cc}\p{Cf}\p{Co}\p{Cs}`, true
+		}
+		return unassignedClass(), true
+	}
+	return "", false
+}
+
+// assignedClass matches assigned code points other than those in the
+// category C.
+const assignedClass = `\p{L}\p{M}\p{N}\p{P}\p{S}\p{Z}`
+
+// unassignedClass returns character class ranges for the code points not
+// assigned to any Unicode category, excluding surrogates.
+//
+//nolint:gochecknoglobals
+var unassignedClass = sync.OnceValue(func() string {
+	var buf strings.Builder
+	start := rune(-1)
+	for r := rune(0); r <= unicode.MaxRune+1; r++ {
+		// Check the subcategories of C, as some versions of Go include Cn
+		// in unicode.C.
+		if r <= unicode.MaxRune && !unicode.In(r, unicode.L, unicode.M, unicode.N,
+			unicode.P, unicode.S, unicode.Z, unicode.Cc, unicode.Cf, unicode.Co, unicode.Cs) {
+			if start < 0 {
+				start = r
+			}
+			continue
+		}
+		if start >= 0 {
+			fmt.Fprintf(&buf, `\x{%X}-\x{%X}`, start, r-1)
+			start = -1
+		}
+	}
+	return buf.String()
+})
+
+// classExpr parses a character class expression, such as [a-z] or [^\p{L}].
+func (p *iregexpParser) classExpr() error {
+	p.pos++ // [
+	var buf strings.Builder
+	buf.WriteByte('[')
+	if p.accept('^') {
+		buf.WriteByte('^')
+	}
+
+	for first := true; ; first = false {
+		switch r := p.peek(0); {
+		case r == -1:
+			return p.errorf("missing closing bracket")
+		case r == ']' && !first:
+			p.pos++
+			buf.WriteByte(']')
+			p.buf.WriteString(buf.String())
+			return nil
+		case r == '-' && (first || p.peek(1) == ']'):
+			// A leading or trailing hyphen is literal.
+			p.pos++
+			buf.WriteString(`\-`)
+		case r == '\\' && (p.peek(1) == 'p' || p.peek(1) == 'P'):
+			class, err := p.categoryEscape()
+			if err != nil {
+				return err
+			}
+			buf.WriteString(class)
+		default:
+			lo, err := p.classChar()
+			if err != nil {
+				return err
+			}
+			buf.WriteString(classLiteral(lo))
+			if p.peek(0) != '-' || p.peek(1) == ']' {
+				continue
+			}
+			p.pos++
+			hi, err := p.classChar()
+			if err != nil {
+				return err
+			}
+			if hi < lo {
+				return p.errorf("invalid range %c-%c", lo, hi)
+			}
+			buf.WriteString("-" + classLiteral(hi))
+		}
+	}
+}
+
+// classChar parses a character or single character escape in a character
+// class expression and returns the character.
+func (p *iregexpParser) classChar() (rune, error) {
+	switch r := p.peek(0); r {
+	case '\\':
+		return p.singleCharEscape()
+	case -1:
+		return 0, p.errorf("missing closing bracket")
+	case '-', '[', ']':
+		return 0, p.errorf("unexpected %q in character class", r)
+	default:
+		if isSurrogate(r) {
+			return 0, p.errorf("unexpected %q in character class", r)
+		}
+		p.pos++
+		return r, nil
+	}
+}
+
+// classLiteral returns r escaped for use within a character class.
+func classLiteral(r rune) string {
+	if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+		return string(r)
+	}
+	return fmt.Sprintf(`\x{%X}`, r)
+}
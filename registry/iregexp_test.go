@@ -0,0 +1,189 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestTranslateIRegexp(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		pattern string
+		exp     string
+		err     string
+	}{
+		// Valid patterns.
+		{name: "empty", pattern: "", exp: ""},
+		{name: "chars", pattern: "abc", exp: "abc"},
+		{name: "alternation", pattern: "a|b", exp: "a|b"},
+		{name: "empty_branch", pattern: "a|", exp: "a|"},
+		{name: "group", pattern: "(a|b)c", exp: "(?:a|b)c"},
+		{name: "empty_group", pattern: "()", exp: "(?:)"},
+		{name: "nested_groups", pattern: "((a)b)", exp: "(?:(?:a)b)"},
+		{name: "star", pattern: "a*", exp: "a*"},
+		{name: "plus", pattern: "a+", exp: "a+"},
+		{name: "optional", pattern: "a?", exp: "a?"},
+		{name: "exact_count", pattern: "a{2}", exp: "a{2}"},
+		{name: "min_count", pattern: "a{2,}", exp: "a{2,}"},
+		{name: "range_count", pattern: "a{2,3}", exp: "a{2,3}"},
+		{name: "zero_count", pattern: "a{0,0}", exp: "a{0,0}"},
+		{name: "max_count", pattern: "a{1000}", exp: "a{1000}"},
+		{name: "dot", pattern: ".", exp: `[^\n\r]`},
+		{name: "caret", pattern: "^a", exp: `\^a`},
+		{name: "dollar", pattern: "a$", exp: `a\$`},
+		{name: "special_literals", pattern: "a,-/:<=>@_`~", exp: "a,-/:<=>@_`~"},
+		{name: "non_ascii", pattern: "caf\u00e9\U0001F600", exp: "caf\u00e9\U0001F600"},
+		{name: "escapes", pattern: `\(\)\*\+\-\.\?\[\\\]\^\{\|\}`, exp: `\(\)\*\+-\.\?\[\\\]\^\{\|\}`},
+		{name: "control_escapes", pattern: `\n\r\t`, exp: "\n\r\t"},
+		{name: "category", pattern: `\p{L}`, exp: `[\p{L}]`},
+		{name: "subcategory", pattern: `\p{Lu}`, exp: `[\p{Lu}]`},
+		{name: "negated_category", pattern: `\P{Nd}`, exp: `[\P{Nd}]`},
+		{name: "other_category", pattern: `\P{C}`, exp: `[\p{L}\p{M}\p{N}\p{P}\p{S}\p{Z}]`},
+		{name: "assigned_category", pattern: `\P{Cn}`, exp: `[\p{L}\p{M}\p{N}\p{P}\p{S}\p{Z}\p{Cc}\p{Cf}\p{Co}\p{Cs}]`},
+		{name: "class", pattern: "[abc]", exp: "[abc]"},
+		{name: "negated_class", pattern: "[^abc]", exp: "[^abc]"},
+		{name: "class_range", pattern: "[a-z0-9]", exp: "[a-z0-9]"},
+		{name: "class_leading_hyphen", pattern: "[-a]", exp: `[\-a]`},
+		{name: "class_trailing_hyphen", pattern: "[a-]", exp: `[a\-]`},
+		{name: "class_hyphens", pattern: "[--]", exp: `[\-\-]`},
+		{name: "class_negated_hyphen", pattern: "[^-]", exp: `[^\-]`},
+		{name: "class_specials", pattern: "[.^$*(){}|?+]", exp: `[\x{2E}\x{5E}\x{24}\x{2A}\x{28}\x{29}\x{7B}\x{7D}\x{7C}\x{3F}\x{2B}]`},
+		{name: "class_escapes", pattern: `[\-\[\]\\\n]`, exp: `[\x{2D}\x{5B}\x{5D}\x{5C}\x{A}]`},
+		{name: "class_escape_range", pattern: `[\t-\r]`, exp: `[\x{9}-\x{D}]`},
+		{name: "class_category", pattern: `[\p{L}\P{Nd}_]`, exp: `[\p{L}\P{Nd}\x{5F}]`},
+		{name: "quantified_class", pattern: "[a-c]{2}", exp: "[a-c]{2}"},
+
+		// Invalid patterns.
+		{name: "open_paren", pattern: "(a", err: "missing closing parenthesis (offset 3)"},
+		{name: "close_paren", pattern: "a)", err: `unmatched ')' (offset 2)`},
+		{name: "leading_star", pattern: "*", err: `unexpected '*' (offset 1)`},
+		{name: "leading_plus", pattern: "+a", err: `unexpected '+' (offset 1)`},
+		{name: "leading_optional", pattern: "?", err: `unexpected '?' (offset 1)`},
+		{name: "double_quantifier", pattern: "a**", err: `unexpected '*' (offset 3)`},
+		{name: "lazy_quantifier", pattern: "a*?", err: `unexpected '?' (offset 3)`},
+		{name: "possessive_quantifier", pattern: "a++", err: `unexpected '+' (offset 3)`},
+		{name: "alternation_quantifier", pattern: "a|*", err: `unexpected '*' (offset 3)`},
+		{name: "non_capturing", pattern: "(?:a)", err: `unexpected '?' (offset 2)`},
+		{name: "flags", pattern: "(?i)a", err: `unexpected '?' (offset 2)`},
+		{name: "open_brace", pattern: "{", err: `unexpected '{' (offset 1)`},
+		{name: "close_brace", pattern: "a}", err: `unexpected '}' (offset 2)`},
+		{name: "close_bracket", pattern: "a]", err: `unexpected ']' (offset 2)`},
+		{name: "unclosed_count", pattern: "a{1", err: "missing closing brace (offset 4)"},
+		{name: "no_min_count", pattern: "a{,2}", err: "missing repeat count (offset 3)"},
+		{name: "no_count", pattern: "a{}", err: "missing repeat count (offset 3)"},
+		{name: "inverted_count", pattern: "a{3,2}", err: "invalid repeat count {3,2} (offset 6)"},
+		{name: "huge_count", pattern: "a{1001}", err: "repeat count exceeds 1000 (offset 3)"},
+		{name: "overflow_count", pattern: "a{99999999999999999999}", err: "repeat count exceeds 1000 (offset 3)"},
+		{name: "digit_escape", pattern: `\d`, err: `invalid escape \d (offset 1)`},
+		{name: "word_escape", pattern: `a\w`, err: `invalid escape \w (offset 2)`},
+		{name: "space_escape", pattern: `\s`, err: `invalid escape \s (offset 1)`},
+		{name: "boundary_escape", pattern: `\b`, err: `invalid escape \b (offset 1)`},
+		{name: "backreference", pattern: `(a)\1`, err: `invalid escape \1 (offset 4)`},
+		{name: "unicode_escape", pattern: `\u0041`, err: `invalid escape \u (offset 1)`},
+		{name: "start_anchor", pattern: `\A`, err: `invalid escape \A (offset 1)`},
+		{name: "trailing_backslash", pattern: `a\`, err: "trailing backslash (offset 3)"},
+		{name: "unknown_category", pattern: `\p{Xx}`, err: `invalid Unicode category "Xx" (offset 1)`},
+		{name: "script", pattern: `\p{Greek}`, err: `invalid Unicode category "Greek" (offset 1)`},
+		{name: "surrogate_category", pattern: `\p{Cs}`, err: `invalid Unicode category "Cs" (offset 1)`},
+		{name: "short_category", pattern: `\pL`, err: "missing opening brace (offset 3)"},
+		{name: "unclosed_category", pattern: `\p{L`, err: "missing closing brace (offset 5)"},
+		{name: "open_bracket", pattern: "[", err: "missing closing bracket (offset 2)"},
+		{name: "empty_class", pattern: "[]", err: `unexpected ']' in character class (offset 2)`},
+		{name: "empty_negated_class", pattern: "[^]", err: `unexpected ']' in character class (offset 3)`},
+		{name: "unclosed_class", pattern: "[a", err: "missing closing bracket (offset 3)"},
+		{name: "unclosed_range", pattern: "[a-", err: "missing closing bracket (offset 4)"},
+		{name: "nested_class", pattern: "[[a]]", err: `unexpected '[' in character class (offset 2)`},
+		{name: "inverted_range", pattern: "[z-a]", err: "invalid range z-a (offset 5)"},
+		{name: "range_to_category", pattern: `[a-\p{L}]`, err: `invalid escape \p (offset 4)`},
+		{name: "hyphen_range", pattern: "[--a]", err: `unexpected '-' in character class (offset 3)`},
+		{name: "class_digit_escape", pattern: `[\d]`, err: `invalid escape \d (offset 2)`},
+		{name: "subtraction", pattern: "[a-z-[aeiou]]", err: `unexpected '-' in character class (offset 5)`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			expr, err := translateIRegexp(tc.pattern)
+			if tc.err != "" {
+				a.Empty(expr)
+				a.EqualError(err, "i-regexp: "+tc.err)
+				a.ErrorIs(err, ErrIRegexp)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tc.exp, expr)
+		})
+	}
+}
+
+func TestIRegexpConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		pattern string
+		input   string
+		match   bool
+		search  bool
+	}{
+		{"literal", "abc", "abc", true, true},
+		{"literal_substring", "abc", "xabcx", false, true},
+		{"empty", "", "abc", false, true},
+		{"empty_empty", "", "", true, true},
+		{"dot", "a.c", "abc", true, true},
+		{"dot_newline", "a.c", "a\nc", false, false},
+		{"dot_carriage_return", "a.c", "a\rc", false, false},
+		{"dot_non_ascii", "a.c", "a\u00e9c", true, true},
+		{"alternation_anchored", "a|b", "ab", false, true},
+		{"alternation_second", "a|bc", "bc", true, true},
+		{"alternation_partial", "ab|c", "abc", false, true},
+		{"caret_literal", "^a", "^a", true, true},
+		{"caret_not_anchor", "^a", "a", false, false},
+		{"dollar_literal", "a$", "a$", true, true},
+		{"dollar_not_anchor", "a$", "a", false, false},
+		{"group_repeat", "(ab)+", "abab", true, true},
+		{"group_repeat_partial", "(ab)+", "ababa", false, true},
+		{"count", "[a-c]{2}", "bc", true, true},
+		{"count_too_many", "[a-c]{2}", "bcd", false, true},
+		{"count_range", "x{2,3}", "xxxx", false, true},
+		{"negated_class", "[^a-z]", "1", true, true},
+		{"negated_class_newline", "[^a-z]", "\n", true, true},
+		{"escaped_dot", `\.`, ".", true, true},
+		{"escaped_dot_char", `\.`, "a", false, false},
+		{"class_dot", "[.]", "a", false, false},
+		{"escaped_newline", `a\nb`, "a\nb", true, true},
+		{"letter", `\p{L}+`, "café", true, true},
+		{"uppercase", `\p{Lu}`, "A", true, true},
+		{"uppercase_lower", `\p{Lu}`, "a", false, false},
+		{"not_letter", `\P{L}`, "a", false, false},
+		{"not_letter_digit", `\P{L}`, "1", true, true},
+		{"decimal_digit", `\p{Nd}+`, "12\u0663", true, true},
+		{"space_separator", `\p{Zs}`, " ", true, true},
+		{"unassigned", `\p{Cn}`, "\u0378", true, true},
+		{"unassigned_assigned", `\p{Cn}`, "a", false, false},
+		{"assigned", `\P{Cn}`, "a", true, true},
+		{"assigned_unassigned", `\P{Cn}`, "\u0378", false, false},
+		{"other_control", `\p{C}`, "\t", true, true},
+		{"other_unassigned", `\p{C}`, "\u0378", true, true},
+		{"other_private", `\p{C}`, "\ue000", true, true},
+		{"other_letter", `\p{C}`, "a", false, false},
+		{"not_other", `\P{C}`, "a", true, true},
+		{"not_other_unassigned", `\P{C}`, "\u0378", false, false},
+		{"not_other_control", `\P{C}`, "\t", false, false},
+		{"class_categories", `[\p{Lu}\p{Nd}]+`, "A1B2", true, true},
+		{"negated_class_category", `[^\p{L}]`, "a", false, false},
+		{"class_unassigned", `[a\p{Cn}]+`, "a\u0378", true, true},
+		{"negated_class_unassigned", `[^\p{Cn}]`, "\u0378", false, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			args := []spec.JSONPathValue{spec.Value(tc.input), spec.Value(tc.pattern)}
+			a.Equal(spec.LogicalFrom(tc.match), matchFunc(args), "match")
+			a.Equal(spec.LogicalFrom(tc.search), searchFunc(args), "search")
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/theory/jsonpath/spec"
+)
+
+// Param declares the type of a function parameter for [Params]. Use
+// [Optional] and [Variadic] to modify a Param.
+type Param uint8
+
+const (
+	// Value declares a parameter that accepts arguments convertible to
+	// [spec.PathValue], such as literals, singular queries, and functions
+	// that return values.
+	Value = Param(spec.PathValue)
+
+	// Logical declares a parameter that accepts arguments convertible to
+	// [spec.PathLogical], such as logical expressions, filter queries, and
+	// functions that return logicals.
+	Logical = Param(spec.PathLogical)
+
+	// Nodes declares a parameter that accepts arguments convertible to
+	// [spec.PathNodes], such as filter queries and functions that return
+	// node lists.
+	Nodes = Param(spec.PathNodes)
+)
+
+const (
+	// optional marks an optional Param.
+	optional Param = 1 << 6
+
+	// variadic marks a variadic Param.
+	variadic Param = 1 << 7
+
+	// typeMask masks the flags of a Param to get its type.
+	typeMask = optional - 1
+)
+
+// Optional declares that the parameter of type p may be omitted. Optional
+// parameters must follow all required parameters.
+func Optional(p Param) Param { return p | optional }
+
+// Variadic declares that the parameter of type p accepts zero or more
+// arguments. A variadic parameter must be the last parameter, and may not
+// follow optional parameters.
+func Variadic(p Param) Param { return p | variadic }
+
+// pathType returns the type of arguments p accepts.
+func (p Param) pathType() spec.PathType { return spec.PathType(p & typeMask) }
+
+// ArgError errors are returned by validators created by [Params] for
+// arguments that are invalid or in excess of the declared parameters. The
+// parser reports the errors at the position of the argument at Index.
+type ArgError struct {
+	// Index is the zero-based index of the invalid argument.
+	Index int
+
+	// Err describes the problem with the argument.
+	Err error
+}
+
+// Error returns the message of e.Err.
+func (e *ArgError) Error() string { return e.Err.Error() }
+
+// Unwrap returns e.Err.
+func (e *ArgError) Unwrap() error { return e.Err }
+
+// Params returns a [Validator] that checks the arguments to a function
+// against the declared parameters, for use with [Registry.Register] and
+// [NewFunction] in place of a hand-written validator:
+//
+//	err := reg.Register(
+//		"first", spec.FuncValue,
+//		registry.Params(registry.Value, registry.Optional(registry.Value), registry.Variadic(registry.Nodes)),
+//		firstFunc,
+//	)
+//
+// The validator returns an error if there are fewer arguments than required
+// parameters, and an [*ArgError] for the first argument that is in excess of
+// the parameters or that cannot convert to the type of its parameter. The
+// evaluator receives only the arguments passed in the query, so it must
+// check the length of its args for omitted optional and variadic
+// arguments.
+//
+// Panics if a required parameter follows an optional or variadic
+// parameter, or if a variadic parameter is not the last parameter.
+func Params(params ...Param) Validator {
+	required := 0
+	for i, p := range params {
+		switch {
+		case p&variadic != 0 && i < len(params)-1:
+			panic(fmt.Sprintf("registry: variadic parameter %v is not last", i+1))
+		case p&(optional|variadic) == 0 && required < i:
+			panic(fmt.Sprintf("registry: required parameter %v follows optional parameter", i+1))
+		case p&(optional|variadic) == 0:
+			required++
+		}
+	}
+	maxArgs := len(params)
+	if maxArgs > 0 && params[maxArgs-1]&variadic != 0 {
+		maxArgs = -1
+	}
+
+	return func(args []spec.FunctionExprArg) error {
+		if len(args) < required {
+			return fmt.Errorf(
+				"expected %v but found %v",
+				countArgs(required, maxArgs != required, "least"), len(args),
+			)
+		}
+		if maxArgs >= 0 && len(args) > maxArgs {
+			return &ArgError{maxArgs, fmt.Errorf(
+				"expected %v but found %v",
+				countArgs(maxArgs, maxArgs != required, "most"), len(args),
+			)}
+		}
+		for i, arg := range args {
+			typ := params[min(i, len(params)-1)].pathType()
+			if !arg.ResultType().ConvertsTo(typ) {
+				return &ArgError{i, fmt.Errorf("cannot convert argument %v to %v", i+1, typ)}
+			}
+		}
+		return nil
+	}
+}
+
+// countArgs describes a count of n arguments for an error message,
+// qualified by "at" and bound, such as "at least", if ranged is true.
+func countArgs(n int, ranged bool, bound string) string {
+	desc := fmt.Sprintf("%v argument", n)
+	if n != 1 {
+		desc += "s"
+	}
+	if ranged {
+		return "at " + bound + " " + desc
+	}
+	return desc
+}
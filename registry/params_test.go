@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestParams(t *testing.T) {
+	t.Parallel()
+
+	lit := spec.Literal("x")
+	query := spec.FilterQuery(spec.Query(false, []*spec.Segment{spec.Child(spec.Wildcard())}))
+	logical := spec.LogicalOr{}
+
+	for _, tc := range []struct {
+		name   string
+		params []Param
+		args   []spec.FunctionExprArg
+		err    string
+		index  int
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:   "none_extra",
+			args:   []spec.FunctionExprArg{lit},
+			err:    "expected 0 arguments but found 1",
+			index:  0,
+			params: []Param{},
+		},
+		{
+			name:   "value",
+			params: []Param{Value},
+			args:   []spec.FunctionExprArg{lit},
+		},
+		{
+			name:   "value_missing",
+			params: []Param{Value},
+			err:    "expected 1 argument but found 0",
+			index:  -1,
+		},
+		{
+			name:   "value_extra",
+			params: []Param{Value},
+			args:   []spec.FunctionExprArg{lit, lit, lit},
+			err:    "expected 1 argument but found 3",
+			index:  1,
+		},
+		{
+			name:   "value_not_nodes",
+			params: []Param{Value},
+			args:   []spec.FunctionExprArg{query},
+			err:    "cannot convert argument 1 to ValueType",
+			index:  0,
+		},
+		{
+			name:   "logical_and_nodes",
+			params: []Param{Logical, Nodes},
+			args:   []spec.FunctionExprArg{logical, query},
+		},
+		{
+			name:   "nodes_not_literal",
+			params: []Param{Logical, Nodes},
+			args:   []spec.FunctionExprArg{query, lit},
+			err:    "cannot convert argument 2 to NodesType",
+			index:  1,
+		},
+		{
+			name:   "optional_omitted",
+			params: []Param{Value, Optional(Value)},
+			args:   []spec.FunctionExprArg{lit},
+		},
+		{
+			name:   "optional_passed",
+			params: []Param{Value, Optional(Value)},
+			args:   []spec.FunctionExprArg{lit, lit},
+		},
+		{
+			name:   "optional_wrong_type",
+			params: []Param{Value, Optional(Logical)},
+			args:   []spec.FunctionExprArg{lit, lit},
+			err:    "cannot convert argument 2 to LogicalType",
+			index:  1,
+		},
+		{
+			name:   "optional_missing_required",
+			params: []Param{Value, Optional(Value)},
+			err:    "expected at least 1 argument but found 0",
+			index:  -1,
+		},
+		{
+			name:   "optional_extra",
+			params: []Param{Value, Optional(Value), Optional(Value)},
+			args:   []spec.FunctionExprArg{lit, lit, lit, lit},
+			err:    "expected at most 3 arguments but found 4",
+			index:  3,
+		},
+		{
+			name:   "variadic_none",
+			params: []Param{Value, Variadic(Nodes)},
+			args:   []spec.FunctionExprArg{lit},
+		},
+		{
+			name:   "variadic_many",
+			params: []Param{Value, Variadic(Nodes)},
+			args:   []spec.FunctionExprArg{lit, query, query, query},
+		},
+		{
+			name:   "variadic_wrong_type",
+			params: []Param{Value, Variadic(Nodes)},
+			args:   []spec.FunctionExprArg{lit, query, lit},
+			err:    "cannot convert argument 3 to NodesType",
+			index:  2,
+		},
+		{
+			name:   "variadic_missing_required",
+			params: []Param{Value, Value, Variadic(Nodes)},
+			args:   []spec.FunctionExprArg{lit},
+			err:    "expected at least 2 arguments but found 1",
+			index:  -1,
+		},
+		{
+			name:   "optional_and_variadic",
+			params: []Param{Value, Optional(Value), Variadic(Nodes)},
+			args:   []spec.FunctionExprArg{lit, lit, query, query},
+		},
+		{
+			name:   "optional_and_variadic_wrong_type",
+			params: []Param{Value, Optional(Value), Variadic(Nodes)},
+			args:   []spec.FunctionExprArg{lit, lit, query, lit},
+			err:    "cannot convert argument 4 to NodesType",
+			index:  3,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			err := Params(tc.params...)(tc.args)
+			if tc.err == "" {
+				r.NoError(err)
+				return
+			}
+			r.EqualError(err, tc.err)
+			var argErr *ArgError
+			if tc.index < 0 {
+				a.False(errors.As(err, &argErr))
+			} else {
+				r.ErrorAs(err, &argErr)
+				a.Equal(tc.index, argErr.Index)
+				a.Equal(argErr.Err, errors.Unwrap(err))
+			}
+		})
+	}
+}
+
+func TestParamsPanics(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.PanicsWithValue("registry: variadic parameter 1 is not last", func() {
+		Params(Variadic(Value), Value)
+	})
+	a.PanicsWithValue("registry: required parameter 2 follows optional parameter", func() {
+		Params(Optional(Value), Value)
+	})
+	a.PanicsWithValue("registry: required parameter 3 follows optional parameter", func() {
+		Params(Value, Optional(Nodes), Logical)
+	})
+	a.NotPanics(func() { Params(Optional(Value), Variadic(Value)) })
+}
@@ -6,7 +6,10 @@ package registry
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/theory/jsonpath/spec"
 )
@@ -14,10 +17,19 @@ import (
 // Registry maintains a registry of JSONPath functions, including both
 // [RFC 9535]-required functions and function extensions.
 //
+// Registries are safe for concurrent use. Lookups read from an immutable
+// snapshot of the registered functions without locking, while [Register]
+// copies the snapshot, adds the new function, and atomically swaps in the
+// copy. Use [Registry.Clone] to derive a registry that extends a shared set
+// of functions without modifying it.
+//
 // [RFC 9535]: https://www.rfc-editor.org/rfc/rfc9535.html
 type Registry struct {
-	mu    sync.RWMutex
-	funcs map[string]*Function
+	// mu serializes writers.
+	mu sync.Mutex
+
+	// funcs points to the current immutable snapshot of the functions.
+	funcs atomic.Pointer[map[string]*Function]
 }
 
 // New returns a new [Registry] loaded with the [RFC 9535]-mandated functions:
@@ -35,41 +47,73 @@ type Registry struct {
 // [match]: https://www.rfc-editor.org/rfc/rfc9535.html#name-match-function-extension
 // [search]: https://www.rfc-editor.org/rfc/rfc9535.html#name-search-function-extension
 func New() *Registry {
-	return &Registry{
-		mu: sync.RWMutex{},
-		funcs: map[string]*Function{
-			"length": {
-				name:       "length",
-				resultType: spec.FuncValue,
-				validator:  checkLengthArgs,
-				evaluator:  lengthFunc,
-			},
-			"count": {
-				name:       "count",
-				resultType: spec.FuncValue,
-				validator:  checkCountArgs,
-				evaluator:  countFunc,
-			},
-			"value": {
-				name:       "value",
-				resultType: spec.FuncValue,
-				validator:  checkValueArgs,
-				evaluator:  valueFunc,
-			},
-			"match": {
-				name:       "match",
-				resultType: spec.FuncLogical,
-				validator:  checkMatchArgs,
-				evaluator:  matchFunc,
-			},
-			"search": {
-				name:       "search",
-				resultType: spec.FuncLogical,
-				validator:  checkSearchArgs,
-				evaluator:  searchFunc,
-			},
+	return newRegistry(map[string]*Function{
+		"length": {
+			name:       "length",
+			resultType: spec.FuncValue,
+			validator:  checkLengthArgs,
+			evaluator:  lengthFunc,
 		},
-	}
+		"count": {
+			name:       "count",
+			resultType: spec.FuncValue,
+			validator:  checkCountArgs,
+			evaluator:  countFunc,
+		},
+		"value": {
+			name:       "value",
+			resultType: spec.FuncValue,
+			validator:  checkValueArgs,
+			evaluator:  valueFunc,
+		},
+		"match": {
+			name:       "match",
+			resultType: spec.FuncLogical,
+			validator:  checkMatchArgs,
+			evaluator:  matchFunc,
+			preparer:   prepareMatch,
+		},
+		"search": {
+			name:       "search",
+			resultType: spec.FuncLogical,
+			validator:  checkSearchArgs,
+			evaluator:  searchFunc,
+			preparer:   prepareSearch,
+		},
+	})
+}
+
+// NewEmpty returns a new [Registry] that contains no functions, not even
+// those required by RFC 9535. Use it to restrict the functions available to
+// queries, e.g., to exclude the regular expression functions match() and
+// search() from untrusted queries. Use [Registry.RegisterFunction] to
+// register selected functions from a registry returned by [New]:
+//
+//	reg := registry.NewEmpty()
+//	defaults := registry.New()
+//	for _, name := range []string{"length", "count", "value"} {
+//		if err := reg.RegisterFunction(defaults.Get(name)); err != nil {
+//			return err
+//		}
+//	}
+func NewEmpty() *Registry {
+	return newRegistry(map[string]*Function{})
+}
+
+// newRegistry creates and returns a new Registry with funcs as its initial
+// snapshot. funcs must not be modified after passing it to newRegistry.
+func newRegistry(funcs map[string]*Function) *Registry {
+	r := &Registry{}
+	r.funcs.Store(&funcs)
+	return r
+}
+
+// Clone returns a new Registry containing all of the functions in r.
+// Functions subsequently registered in either registry will not appear in
+// the other. Cloning is cheap: the clone shares r's current snapshot until
+// one of them registers a new function.
+func (r *Registry) Clone() *Registry {
+	return newRegistry(*r.funcs.Load())
 }
 
 // Validator functions validate that the args expressions to a function can be
@@ -79,6 +123,11 @@ type Validator func(args []spec.FunctionExprArg) error
 // Evaluator functions execute a function against the values returned by args.
 type Evaluator func(args []spec.JSONPathValue) spec.JSONPathValue
 
+// ContextEvaluator functions execute a function against the values returned
+// by args in ctx, the context of the filter expression that calls the
+// function, such as the current node and its normalized path.
+type ContextEvaluator func(ctx *spec.EvalContext, args []spec.JSONPathValue) spec.JSONPathValue
+
 // ErrRegister errors are returned by [Register].
 var ErrRegister = errors.New("register")
 
@@ -97,27 +146,82 @@ func (r *Registry) Register(
 	if evaluator == nil {
 		return fmt.Errorf("%w: evaluator is nil", ErrRegister)
 	}
+	return r.register(NewFunction(name, resultType, validator, evaluator))
+}
 
+// RegisterContext registers a function extension by its name, like
+// [Registry.Register], but with an evaluator that also receives the
+// [spec.EvalContext] of the filter expression that calls it. Use it for
+// functions that depend on more than their arguments, such as a path()
+// function that returns the normalized path of the current node:
+//
+//	err := reg.RegisterContext(
+//		"path", spec.FuncValue, registry.Params(),
+//		func(ctx *spec.EvalContext, _ []spec.JSONPathValue) spec.JSONPathValue {
+//			if ctx.Path == nil {
+//				return nil
+//			}
+//			return spec.Value(ctx.Path.String())
+//		},
+//	)
+//
+// Returns an [ErrRegister] error if validator or evaluator is nil or if r
+// already contains name.
+func (r *Registry) RegisterContext(
+	name string,
+	resultType spec.FuncType,
+	validator Validator,
+	evaluator ContextEvaluator,
+) error {
+	if validator == nil {
+		return fmt.Errorf("%w: validator is nil", ErrRegister)
+	}
+	if evaluator == nil {
+		return fmt.Errorf("%w: evaluator is nil", ErrRegister)
+	}
+	return r.register(NewContextFunction(name, resultType, validator, evaluator))
+}
+
+// RegisterFunction registers fn by its name, e.g., a function returned by
+// [Registry.Get] from another registry or created by [NewFunction]. Returns
+// an [ErrRegister] error if fn is nil or if r already contains a function
+// with the same name.
+func (r *Registry) RegisterFunction(fn *Function) error {
+	if fn == nil {
+		return fmt.Errorf("%w: function is nil", ErrRegister)
+	}
+	return r.register(fn)
+}
+
+// register registers fn by its name. Returns an [ErrRegister] error if r
+// already contains a function with the same name.
+func (r *Registry) register(fn *Function) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	if _, dup := r.funcs[name]; dup {
+	funcs := *r.funcs.Load()
+	if _, dup := funcs[fn.name]; dup {
 		return fmt.Errorf(
 			"%w: Register called twice for function %v",
-			ErrRegister, name,
+			ErrRegister, fn.name,
 		)
 	}
 
-	r.funcs[name] = &Function{name, resultType, validator, evaluator}
+	// Copy on write.
+	funcs = maps.Clone(funcs)
+	funcs[fn.name] = fn
+	r.funcs.Store(&funcs)
 	return nil
 }
 
 // Get returns a reference to the registered function named name. Returns nil
 // if no function with that name has been registered.
 func (r *Registry) Get(name string) *Function {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	function := r.funcs[name]
-	return function
+	return (*r.funcs.Load())[name]
+}
+
+// Names returns the sorted names of all the functions registered in r.
+func (r *Registry) Names() []string {
+	return slices.Sorted(maps.Keys(*r.funcs.Load()))
 }
 
 // Function defines a JSONPath function. Use [Register] to register a new
@@ -137,6 +241,16 @@ type Function struct {
 	// evaluator executes the function against args and returns the result of
 	// type ResultType.
 	evaluator func(args []spec.JSONPathValue) spec.JSONPathValue
+
+	// contextEvaluator, if not nil, executes the function against args in
+	// the context of the filter expression that calls it, in place of
+	// evaluator.
+	contextEvaluator ContextEvaluator
+
+	// preparer, if not nil, prepares an evaluator for the args of a
+	// function expression, as for the literal patterns of match() and
+	// search().
+	preparer func(args []spec.FunctionExprArg) func([]spec.JSONPathValue) spec.JSONPathValue
 }
 
 // NewFunction creates a new JSONPath function extension. The parameters are:
@@ -154,7 +268,32 @@ func NewFunction(
 	evaluator func(args []spec.JSONPathValue,
 	) spec.JSONPathValue,
 ) *Function {
-	return &Function{name, resultType, validator, evaluator}
+	return &Function{
+		name:       name,
+		resultType: resultType,
+		validator:  validator,
+		evaluator:  evaluator,
+	}
+}
+
+// NewContextFunction creates a new JSONPath function extension like
+// [NewFunction], but with an evaluator that also receives the
+// [spec.EvalContext] of the filter expression that calls it.
+func NewContextFunction(
+	name string,
+	resultType spec.FuncType,
+	validator func(args []spec.FunctionExprArg) error,
+	evaluator ContextEvaluator,
+) *Function {
+	return &Function{
+		name:       name,
+		resultType: resultType,
+		validator:  validator,
+		evaluator: func(args []spec.JSONPathValue) spec.JSONPathValue {
+			return evaluator(&spec.EvalContext{}, args)
+		},
+		contextEvaluator: evaluator,
+	}
 }
 
 // Name returns the name of the function.
@@ -164,11 +303,39 @@ func (f *Function) Name() string { return f.name }
 func (f *Function) ResultType() spec.FuncType { return f.resultType }
 
 // Evaluate executes the function against args and returns the result of type
-// [ResultType].
+// [ResultType]. Functions created by [NewContextFunction] execute with an
+// empty [spec.EvalContext].
 func (f *Function) Evaluate(args []spec.JSONPathValue) spec.JSONPathValue {
 	return f.evaluator(args)
 }
 
+// UsesContext returns true if the function was created by
+// [NewContextFunction] or registered by [Registry.RegisterContext], so that
+// its evaluator receives the [spec.EvalContext] of the filter expression
+// that calls it. Defined by the [spec.ContextFunction] interface.
+func (f *Function) UsesContext() bool { return f.contextEvaluator != nil }
+
+// EvaluateContext executes the function against args in ctx and returns the
+// result of type [ResultType]. Functions that do not use their contexts
+// ignore ctx. Defined by the [spec.ContextFunction] interface.
+func (f *Function) EvaluateContext(ctx *spec.EvalContext, args []spec.JSONPathValue) spec.JSONPathValue {
+	if f.contextEvaluator != nil {
+		return f.contextEvaluator(ctx, args)
+	}
+	return f.evaluator(args)
+}
+
+// Prepare returns an evaluator specialized for args, such as the evaluators
+// that match() and search() return to execute a regular expression
+// compiled once from a literal pattern, or nil to execute [Function.Evaluate].
+// Defined by the [spec.PreparedFunction] interface.
+func (f *Function) Prepare(args []spec.FunctionExprArg) func([]spec.JSONPathValue) spec.JSONPathValue {
+	if f.preparer == nil {
+		return nil
+	}
+	return f.preparer(args)
+}
+
 // Validate executes at parse time to validate that all the args to the
 // function are compatible with the function.
 func (f *Function) Validate(args []spec.FunctionExprArg) error {
@@ -52,3 +52,37 @@ func firstFunc(jv []spec.JSONPathValue) spec.JSONPathValue {
 	}
 	return spec.Value(nodes[0])
 }
+
+// Use [registry.Params] to declare the parameters of a function rather than
+// write a validator. Here, coalesce() requires one value argument and
+// accepts any number of additional value arguments, and returns the first
+// that is not null or missing.
+func ExampleParams() {
+	reg := registry.New()
+	err := reg.Register(
+		"coalesce",
+		spec.FuncValue,
+		registry.Params(registry.Value, registry.Variadic(registry.Value)),
+		func(args []spec.JSONPathValue) spec.JSONPathValue {
+			for _, arg := range args {
+				if v := spec.ValueFrom(arg); v != nil && v.Value() != nil {
+					return v
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		log.Fatalf("Error %v", err)
+	}
+
+	fn := reg.Get("coalesce")
+	fmt.Println(fn.Validate([]spec.FunctionExprArg{spec.Literal(nil), spec.Literal(42)}))
+	fmt.Println(fn.Validate([]spec.FunctionExprArg{}))
+	res := fn.Evaluate([]spec.JSONPathValue{spec.Value(nil), spec.Value(42)})
+	fmt.Println(spec.ValueFrom(res).Value())
+	// Output:
+	// <nil>
+	// expected at least 1 argument but found 0
+	// 42
+}
@@ -2,6 +2,8 @@ package registry
 
 import (
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -61,7 +63,7 @@ func TestRegistry(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			reg := New()
-			a.Len(reg.funcs, 5)
+			a.Len(*reg.funcs.Load(), 5)
 
 			ft := reg.Get(tc.name)
 			a.NotNil(ft)
@@ -110,6 +112,112 @@ func TestRegisterErr(t *testing.T) {
 	}
 }
 
+func TestRegistryNames(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	reg := New()
+	a.Equal([]string{"count", "length", "match", "search", "value"}, reg.Names())
+
+	r.NoError(reg.Register(
+		"first", spec.FuncValue,
+		func([]spec.FunctionExprArg) error { return nil },
+		func([]spec.JSONPathValue) spec.JSONPathValue { return nil },
+	))
+	a.Equal([]string{"count", "first", "length", "match", "search", "value"}, reg.Names())
+}
+
+func TestRegistryClone(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	valid := func([]spec.FunctionExprArg) error { return nil }
+	eval := func([]spec.JSONPathValue) spec.JSONPathValue { return nil }
+
+	base := New()
+	clone := base.Clone()
+	a.Equal(base.Names(), clone.Names())
+
+	// Register in the clone only.
+	r.NoError(clone.Register("first", spec.FuncValue, valid, eval))
+	a.NotNil(clone.Get("first"))
+	a.Nil(base.Get("first"))
+
+	// Register in the base only.
+	r.NoError(base.Register("last", spec.FuncValue, valid, eval))
+	a.NotNil(base.Get("last"))
+	a.Nil(clone.Get("last"))
+
+	// Can register the same name independently.
+	r.NoError(base.Register("first", spec.FuncValue, valid, eval))
+	a.NotSame(base.Get("first"), clone.Get("first"))
+	a.Same(base.Get("length"), clone.Get("length"))
+}
+
+func TestRegistryNewEmpty(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	reg := NewEmpty()
+	a.Empty(reg.Names())
+	a.Nil(reg.Get("length"))
+
+	// Register selected default functions.
+	defaults := New()
+	for _, name := range []string{"length", "count", "value"} {
+		r.NoError(reg.RegisterFunction(defaults.Get(name)))
+		a.Same(defaults.Get(name), reg.Get(name))
+	}
+	a.Equal([]string{"count", "length", "value"}, reg.Names())
+	a.Nil(reg.Get("match"))
+	a.Len(defaults.Names(), 5)
+
+	// Register a new function.
+	fn := NewFunction(
+		"first", spec.FuncValue,
+		func([]spec.FunctionExprArg) error { return nil },
+		func([]spec.JSONPathValue) spec.JSONPathValue { return nil },
+	)
+	r.NoError(reg.RegisterFunction(fn))
+	a.Same(fn, reg.Get("first"))
+
+	// Handle errors.
+	err := reg.RegisterFunction(nil)
+	r.ErrorIs(err, ErrRegister)
+	r.EqualError(err, "register: function is nil")
+	err = reg.RegisterFunction(defaults.Get("length"))
+	r.ErrorIs(err, ErrRegister)
+	r.EqualError(err, "register: Register called twice for function length")
+}
+
+func TestRegistryConcurrency(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	reg := New()
+	valid := func([]spec.FunctionExprArg) error { return nil }
+	eval := func([]spec.JSONPathValue) spec.JSONPathValue { return nil }
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			a.NoError(reg.Register(fmt.Sprintf("fn%02d", i), spec.FuncValue, valid, eval))
+		}()
+		go func() {
+			defer wg.Done()
+			a.NotNil(reg.Get("length"))
+			a.GreaterOrEqual(len(reg.Names()), 5)
+		}()
+	}
+	wg.Wait()
+	a.Len(reg.Names(), 25)
+}
+
 func TestFunction(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -151,3 +259,40 @@ func TestFunction(t *testing.T) {
 		})
 	}
 }
+
+func TestContextFunction(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+	reg := NewEmpty()
+
+	valid := func([]spec.FunctionExprArg) error { return nil }
+	eval := func(ctx *spec.EvalContext, args []spec.JSONPathValue) spec.JSONPathValue {
+		return spec.Value([]any{ctx.Current, len(args)})
+	}
+
+	r.NoError(reg.RegisterContext("ctx", spec.FuncValue, valid, eval))
+	fn := reg.Get("ctx")
+	a.Equal("ctx", fn.Name())
+	a.Equal(spec.FuncValue, fn.ResultType())
+	a.True(fn.UsesContext())
+	a.Implements((*spec.ContextFunction)(nil), fn)
+	ctx := &spec.EvalContext{Current: "hi"}
+	a.Equal(spec.Value([]any{"hi", 1}), fn.EvaluateContext(ctx, []spec.JSONPathValue{nil}))
+	a.Equal(spec.Value([]any{nil, 0}), fn.Evaluate(nil))
+
+	// Functions without context ignore it.
+	length := New().Get("length")
+	a.False(length.UsesContext())
+	a.Equal(spec.Value(2), length.EvaluateContext(ctx, []spec.JSONPathValue{spec.Value("hi")}))
+
+	// Errors.
+	err := reg.RegisterContext("ctx", spec.FuncValue, valid, eval)
+	r.EqualError(err, "register: Register called twice for function ctx")
+	err = reg.RegisterContext("x", spec.FuncValue, nil, eval)
+	r.EqualError(err, "register: validator is nil")
+	err = reg.RegisterContext("x", spec.FuncValue, valid, nil)
+	r.EqualError(err, "register: evaluator is nil")
+	r.ErrorIs(err, ErrRegister)
+	a.Nil(reg.Get("x"))
+}
@@ -0,0 +1,152 @@
+// Package registrytest provides utilities for testing JSONPath function
+// extensions. Use it to exercise the validation, evaluation, and query
+// behavior of a custom [registry.Function] in the same way this module tests
+// the [RFC 9535]-standard functions.
+//
+// [RFC 9535]: https://www.rfc-editor.org/rfc/rfc9535.html
+package registrytest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/parser"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+// ValidationCase defines a test case for the parse-time validation of a
+// function's arguments.
+type ValidationCase struct {
+	// Name is the name of the test case, used as the subtest name.
+	Name string
+
+	// Args are the argument expressions to validate.
+	Args []spec.FunctionExprArg
+
+	// Err is the expected validation error message. Leave empty to expect
+	// validation to succeed.
+	Err string
+}
+
+// RunValidation runs each of cases as a subtest of t that passes the
+// case's Args to fn.Validate and checks the result.
+func RunValidation(t *testing.T, fn *registry.Function, cases []ValidationCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			err := fn.Validate(tc.Args)
+			if tc.Err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.Err)
+			}
+		})
+	}
+}
+
+// EvaluationCase defines a test case for the evaluation of a function.
+type EvaluationCase struct {
+	// Name is the name of the test case, used as the subtest name.
+	Name string
+
+	// Args are the values to pass to the function.
+	Args []spec.JSONPathValue
+
+	// Exp is the expected return value.
+	Exp spec.JSONPathValue
+}
+
+// RunEvaluation runs each of cases as a subtest of t that passes the case's
+// Args to fn.Evaluate and compares the result to the case's Exp value. It
+// also checks that non-nil results are of the type fn.ResultType declares.
+func RunEvaluation(t *testing.T, fn *registry.Function, cases []EvaluationCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			res := fn.Evaluate(tc.Args)
+			assert.Equal(t, tc.Exp, res)
+			if res != nil {
+				assert.True(
+					t, res.FuncType().ConvertsTo(res.PathType()),
+					"result type %v does not convert to %v", res.FuncType(), res.PathType(),
+				)
+				assert.Equal(t, fn.ResultType() == spec.FuncLogical, res.PathType() == spec.PathLogical)
+			}
+		})
+	}
+}
+
+// PanicCase defines a test case for a function that panics when passed
+// values it cannot convert, as is the case for functions that use
+// [spec.NodesFrom], [spec.ValueFrom], or [spec.LogicalFrom].
+type PanicCase struct {
+	// Name is the name of the test case, used as the subtest name.
+	Name string
+
+	// Args are the values to pass to the function.
+	Args []spec.JSONPathValue
+
+	// Panic is the expected panic value.
+	Panic any
+}
+
+// RunPanics runs each of cases as a subtest of t that passes the case's Args
+// to fn.Evaluate and asserts that it panics with the case's Panic value.
+func RunPanics(t *testing.T, fn *registry.Function, cases []PanicCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			assert.PanicsWithValue(t, tc.Panic, func() { fn.Evaluate(tc.Args) })
+		})
+	}
+}
+
+// QueryCase defines an end-to-end test case for a function used in a
+// JSONPath query.
+type QueryCase struct {
+	// Name is the name of the test case, used as the subtest name.
+	Name string
+
+	// Query is the JSONPath query to parse.
+	Query string
+
+	// Input is the JSON value to select from.
+	Input any
+
+	// Exp is the expected list of selected nodes.
+	Exp []any
+
+	// Err is the expected parse error message. Leave empty to expect
+	// parsing to succeed.
+	Err string
+}
+
+// RunQueries runs each of cases as a subtest of t that parses the case's
+// Query with reg, selects from its Input, and compares the results to its
+// Exp value. Pass a registry containing the function extension under test.
+func RunQueries(t *testing.T, reg *registry.Registry, cases []QueryCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			q, err := parser.Parse(reg, tc.Query)
+			if tc.Err != "" {
+				require.EqualError(t, err, tc.Err)
+				require.ErrorIs(t, err, parser.ErrPathParse)
+				return
+			}
+			require.NoError(t, err)
+			res := q.Select(nil, tc.Input)
+			if tc.Exp == nil {
+				assert.Empty(t, res)
+			} else {
+				assert.Equal(t, tc.Exp, res)
+			}
+		})
+	}
+}
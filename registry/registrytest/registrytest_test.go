@@ -0,0 +1,94 @@
+package registrytest
+
+import (
+	"testing"
+
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestRunValidation(t *testing.T) {
+	t.Parallel()
+	RunValidation(t, registry.New().Get("length"), []ValidationCase{
+		{
+			Name: "literal",
+			Args: []spec.FunctionExprArg{spec.Literal("hi")},
+		},
+		{
+			Name: "no_args",
+			Args: []spec.FunctionExprArg{},
+			Err:  "expected 1 argument but found 0",
+		},
+		{
+			Name: "logical",
+			Args: []spec.FunctionExprArg{spec.LogicalOr{}},
+			Err:  "cannot convert argument to ValueType",
+		},
+	})
+}
+
+func TestRunEvaluation(t *testing.T) {
+	t.Parallel()
+	RunEvaluation(t, registry.New().Get("count"), []EvaluationCase{
+		{
+			Name: "two_nodes",
+			Args: []spec.JSONPathValue{spec.NodesType{1, 2}},
+			Exp:  spec.Value(2),
+		},
+		{
+			Name: "no_nodes",
+			Args: []spec.JSONPathValue{spec.NodesType{}},
+			Exp:  spec.Value(0),
+		},
+	})
+	RunEvaluation(t, registry.New().Get("match"), []EvaluationCase{
+		{
+			Name: "match",
+			Args: []spec.JSONPathValue{spec.Value("hi"), spec.Value("h.")},
+			Exp:  spec.LogicalTrue,
+		},
+	})
+}
+
+func TestRunPanics(t *testing.T) {
+	t.Parallel()
+	reg := registry.New()
+	err := reg.Register(
+		"first", spec.FuncValue, registry.Params(registry.Nodes),
+		func(jv []spec.JSONPathValue) spec.JSONPathValue {
+			return spec.Value(spec.NodesFrom(jv[0])[0])
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunPanics(t, reg.Get("first"), []PanicCase{
+		{
+			Name:  "logical",
+			Args:  []spec.JSONPathValue{spec.LogicalTrue},
+			Panic: "unexpected argument of type spec.LogicalType",
+		},
+	})
+}
+
+func TestRunQueries(t *testing.T) {
+	t.Parallel()
+	RunQueries(t, registry.New(), []QueryCase{
+		{
+			Name:  "length",
+			Query: `$[?length(@) == 2]`,
+			Input: []any{"hi", "hello", []any{1, 2}},
+			Exp:   []any{"hi", []any{1, 2}},
+		},
+		{
+			Name:  "no_match",
+			Query: `$[?length(@) == 3]`,
+			Input: []any{"hi"},
+		},
+		{
+			Name:  "unknown",
+			Query: `$[?nope(@)]`,
+			Err:   "jsonpath: unknown function nope() at position 4",
+		},
+	})
+}
@@ -0,0 +1,191 @@
+package spec
+
+//go:generate stringer -linecomment -output arith_string.go -type ArithOp
+
+import (
+	"fmt"
+	"math"
+)
+
+// ArithOp defines the arithmetic operators of filter expressions, an
+// extension to RFC 9535 enabled by the parser's WithArithmetic option.
+type ArithOp uint8
+
+//revive:disable:exported
+const (
+	Add      ArithOp = iota + 1 // +
+	Subtract                    // -
+	Multiply                    // *
+	Divide                      // /
+	Modulo                      // %
+)
+
+//revive:enable:exported
+
+// precedence returns the precedence of op: 2 for multiplicative operators,
+// which bind more tightly than the additive operators, 1.
+func (op ArithOp) precedence() int {
+	if op >= Multiply {
+		return 2
+	}
+	return 1
+}
+
+// ArithmeticExpr represents an arithmetic operation on two comparable
+// values, which themselves may be arithmetic expressions, for use as an
+// operand of a [ComparisonExpr], as in @.price * @.qty > 100. An extension
+// to RFC 9535.
+//
+// ArithmeticExpr evaluates to a number when both operands are numbers,
+// including [json.Number] values, and to Nothing otherwise, so that, as for
+// missing values, comparisons to it are false except for equality to
+// Nothing. It coerces numbers as follows:
+//
+//   - Integers, including integral [json.Number] values within the range of
+//     int64, produce an int64 for [Add], [Subtract], [Multiply], and
+//     [Modulo], and for [Divide] when the quotient is an integer.
+//   - Other combinations produce a float64, as do operations on integers
+//     that overflow int64 and divisions with a remainder.
+//   - [Modulo] follows the sign of the dividend, as in Go and JavaScript.
+//   - Division or modulo by zero, and results that are not finite, produce
+//     Nothing.
+type ArithmeticExpr struct {
+	// An expression that produces the left operand.
+	Left CompVal
+	// The arithmetic operator.
+	Op ArithOp
+	// An expression that produces the right operand.
+	Right CompVal
+}
+
+// Arithmetic creates and returns a new ArithmeticExpr.
+func Arithmetic(left CompVal, op ArithOp, right CompVal) *ArithmeticExpr {
+	return &ArithmeticExpr{left, op, right}
+}
+
+// writeTo writes a string representation of ae to buf, parenthesizing
+// operands that bind less tightly than ae.Op.
+func (ae *ArithmeticExpr) writeTo(buf *writer) {
+	ae.writeOperand(buf, ae.Left, false)
+	fmt.Fprintf(buf, " %v ", ae.Op)
+	ae.writeOperand(buf, ae.Right, true)
+}
+
+// writeOperand writes val to buf, in parentheses if it is an
+// ArithmeticExpr with a lower precedence than ae, or with the same
+// precedence on the right, since operators associate to the left.
+func (ae *ArithmeticExpr) writeOperand(buf *writer, val CompVal, right bool) {
+	if sub, ok := val.(*ArithmeticExpr); ok {
+		prec := sub.Op.precedence()
+		if prec < ae.Op.precedence() || (right && prec == ae.Op.precedence()) {
+			buf.WriteByte('(')
+			sub.writeTo(buf)
+			buf.WriteByte(')')
+			return
+		}
+	}
+	val.writeTo(buf)
+}
+
+// asValue returns the result of applying ae.Op to the values of ae.Left and
+// ae.Right relative to current and root, or nil if either is not a number
+// or the result is undefined. Defined by the [CompVal] interface.
+func (ae *ArithmeticExpr) asValue(current, root any) JSONPathValue {
+	left, ok := ae.Left.asValue(current, root).(*ValueType)
+	if !ok {
+		return nil
+	}
+	right, ok := ae.Right.asValue(current, root).(*ValueType)
+	if !ok {
+		return nil
+	}
+	l, ok := toNumber(decodeRaw(left.any))
+	if !ok {
+		return nil
+	}
+	r, ok := toNumber(decodeRaw(right.any))
+	if !ok {
+		return nil
+	}
+	if res, ok := arithmetic(ae.Op, l, r); ok {
+		return Value(res)
+	}
+	return nil
+}
+
+// arithmetic applies op to l and r as described by [ArithmeticExpr] and
+// returns the result, or false if it is undefined.
+func arithmetic(op ArithOp, l, r number) (any, bool) {
+	if a, ok := l.int64(); ok {
+		if b, ok := r.int64(); ok {
+			if res, ok := intArithmetic(op, a, b); ok {
+				return res, true
+			}
+		}
+	}
+
+	a, b := l.float(), r.float()
+	var res float64
+	switch op {
+	case Add:
+		res = a + b
+	case Subtract:
+		res = a - b
+	case Multiply:
+		res = a * b
+	case Divide:
+		if b == 0 {
+			return nil, false
+		}
+		res = a / b
+	case Modulo:
+		if b == 0 {
+			return nil, false
+		}
+		res = math.Mod(a, b)
+	default:
+		panic(fmt.Sprintf("Unknown operator %v", op))
+	}
+
+	if math.IsNaN(res) || math.IsInf(res, 0) {
+		return nil, false
+	}
+	return res, true
+}
+
+// intArithmetic applies op to a and b and returns the result as an int64.
+// Returns false if the result overflows int64 or is not an integer, in
+// which case the caller must fall back on floating point arithmetic, or if
+// b is zero for [Divide] or [Modulo], which float64 arithmetic also rejects.
+func intArithmetic(op ArithOp, a, b int64) (int64, bool) {
+	switch op {
+	case Add:
+		res := a + b
+		return res, (res > a) == (b > 0)
+	case Subtract:
+		res := a - b
+		return res, (res < a) == (b > 0)
+	case Multiply:
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+		res := a * b
+		return res, res/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)
+	case Divide:
+		if b == 0 || a%b != 0 || (a == math.MinInt64 && b == -1) {
+			return 0, false
+		}
+		return a / b, true
+	case Modulo:
+		if b == 0 {
+			return 0, false
+		}
+		if b == -1 {
+			// Avoid overflow of math.MinInt64 % -1.
+			return 0, true
+		}
+		return a % b, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,139 @@
+package spec
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArithOp(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		op   ArithOp
+		str  string
+		prec int
+	}{
+		{Add, "+", 1},
+		{Subtract, "-", 1},
+		{Multiply, "*", 2},
+		{Divide, "/", 2},
+		{Modulo, "%", 2},
+	} {
+		a.Equal(tc.str, tc.op.String())
+		a.Equal(tc.prec, tc.op.precedence())
+	}
+	a.Equal("ArithOp(6)", ArithOp(6).String())
+}
+
+func TestArithmeticExpr(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		left  any
+		op    ArithOp
+		right any
+		exp   any
+	}{
+		{"add_ints", 1, Add, int64(2), int64(3)},
+		{"subtract_ints", int8(1), Subtract, uint16(3), int64(-2)},
+		{"multiply_ints", int32(6), Multiply, 7, int64(42)},
+		{"divide_ints_exact", 42, Divide, 6, int64(7)},
+		{"divide_ints_inexact", 7, Divide, 2, 3.5},
+		{"modulo_ints", 7, Modulo, 3, int64(1)},
+		{"modulo_negative", -7, Modulo, 3, int64(-1)},
+		{"add_floats", 1.5, Add, float32(2), 3.5},
+		{"add_int_float", 1, Add, 0.5, 1.5},
+		{"multiply_float_int", 2.5, Multiply, uint8(2), float64(5)},
+		{"modulo_floats", 7.5, Modulo, 2, 1.5},
+		{"json_numbers", json.Number("3"), Multiply, json.Number("4"), int64(12)},
+		{"json_number_float", json.Number("1.5"), Add, 1, 2.5},
+		{"raw_numbers", json.RawMessage("3"), Subtract, 1, float64(2)},
+		{"add_overflow", int64(math.MaxInt64), Add, 1, float64(math.MaxInt64) + 1},
+		{"subtract_overflow", int64(math.MinInt64), Subtract, 1, float64(math.MinInt64) - 1},
+		{"multiply_overflow", int64(math.MaxInt64), Multiply, 2, float64(math.MaxInt64) * 2},
+		{"multiply_min_neg", int64(math.MinInt64), Multiply, -1, -float64(math.MinInt64)},
+		{"multiply_neg_min", -1, Multiply, int64(math.MinInt64), -float64(math.MinInt64)},
+		{"divide_min_neg", int64(math.MinInt64), Divide, -1, -float64(math.MinInt64)},
+		{"modulo_min_neg", int64(math.MinInt64), Modulo, -1, int64(0)},
+		{"big_uint", uint64(math.MaxUint64), Subtract, 1, float64(math.MaxUint64) - 1},
+		{"big_json_number", json.Number("18446744073709551616"), Divide, 2, float64(1 << 63)},
+		{"multiply_zero", 0, Multiply, int64(math.MinInt64), int64(0)},
+		{"divide_zero", 1, Divide, 0, nil},
+		{"divide_float_zero", 1.5, Divide, 0.0, nil},
+		{"modulo_zero", 1, Modulo, 0, nil},
+		{"modulo_float_zero", 1.5, Modulo, 0.0, nil},
+		{"infinite", math.MaxFloat64, Multiply, 2, nil},
+		{"nan", math.NaN(), Add, 1, nil},
+		{"string", "1", Add, 1, nil},
+		{"string_right", 1, Add, "1", nil},
+		{"bool", true, Add, 1, nil},
+		{"null", nil, Add, 1, nil},
+		{"array", []any{1}, Add, 1, nil},
+		{"invalid_json_number", json.Number("x"), Add, 1, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if containsRaw(tc.left) || containsRaw(tc.right) {
+				skipRaw(t)
+			}
+			a := assert.New(t)
+
+			expr := Arithmetic(Literal(tc.left), tc.op, Literal(tc.right))
+			if tc.exp == nil {
+				a.Nil(expr.asValue(nil, nil))
+			} else {
+				a.Equal(Value(tc.exp), expr.asValue(nil, nil))
+			}
+		})
+	}
+
+	t.Run("queries", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+
+		expr := Arithmetic(SingularQuery(false, []Selector{Name("price")}), Multiply, SingularQuery(true, []Selector{Name("qty")}))
+		a.Equal(Value(float64(30)), expr.asValue(map[string]any{"price": 7.5}, map[string]any{"qty": 4}))
+		a.Nil(expr.asValue(map[string]any{}, map[string]any{"qty": 4}))
+		a.Nil(expr.asValue(map[string]any{"price": 7.5}, map[string]any{}))
+
+		// Compares like a missing value.
+		cmp := Comparison(expr, EqualTo, SingularQuery(false, []Selector{Name("nope")}))
+		a.True(cmp.testFilter(map[string]any{}, map[string]any{"qty": 4}))
+		cmp = Comparison(expr, GreaterThan, Literal(20))
+		a.True(cmp.testFilter(map[string]any{"price": 7.5}, map[string]any{"qty": 4}))
+		a.False(cmp.testFilter(map[string]any{"price": "7.5"}, map[string]any{"qty": 4}))
+	})
+}
+
+func TestArithmeticExprString(t *testing.T) {
+	t.Parallel()
+
+	x := SingularQuery(false, []Selector{Name("x")})
+	one, two := Literal(1), Literal(2)
+
+	for _, tc := range []struct {
+		name string
+		expr *ArithmeticExpr
+		exp  string
+	}{
+		{"simple", Arithmetic(x, Add, one), `@["x"] + 1`},
+		{"left_assoc", Arithmetic(Arithmetic(x, Subtract, one), Subtract, two), `@["x"] - 1 - 2`},
+		{"right_same", Arithmetic(x, Subtract, Arithmetic(one, Subtract, two)), `@["x"] - (1 - 2)`},
+		{"left_lower", Arithmetic(Arithmetic(x, Add, one), Multiply, two), `(@["x"] + 1) * 2`},
+		{"right_lower", Arithmetic(two, Modulo, Arithmetic(x, Add, one)), `2 % (@["x"] + 1)`},
+		{"left_higher", Arithmetic(Arithmetic(x, Multiply, one), Add, two), `@["x"] * 1 + 2`},
+		{"right_higher", Arithmetic(two, Add, Arithmetic(x, Divide, one)), `2 + @["x"] / 1`},
+		{"right_mul_div", Arithmetic(two, Divide, Arithmetic(x, Multiply, one)), `2 / (@["x"] * 1)`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.exp, bufString(tc.expr))
+		})
+	}
+}
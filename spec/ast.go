@@ -0,0 +1,671 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ASTVersion is the version of the AST format produced by [EncodeAST].
+// [DecodeAST] returns an [ErrAST] error for ASTs with any other version.
+const ASTVersion = 1
+
+// ErrAST errors are returned by [EncodeAST] for queries that cannot be
+// encoded and by [DecodeAST] for invalid ASTs.
+var ErrAST = errors.New("jsonpath: invalid AST")
+
+// FunctionResolver resolves the function named name called with args while
+// decoding an AST with [DecodeAST]. It should return an error if no such
+// function exists or if args are invalid for the function, just as the
+// parser does when parsing a function expression.
+type FunctionResolver func(name string, args []FunctionExprArg) (PathFunction, error)
+
+// EncodeAST encodes q as a JSON abstract syntax tree that [DecodeAST] can
+// decode into an equivalent PathQuery without lexing and parsing a query
+// string. The AST records the names of functions but not their
+// implementations, which DecodeAST resolves. Returns an [ErrAST] error if q
+// contains a literal that is not a JSON scalar or an array or object of
+// literals.
+func EncodeAST(q *PathQuery) ([]byte, error) {
+	query, err := encodeQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:wrapcheck // Cannot fail for the types in the AST.
+	return json.Marshal(astDocument{Version: ASTVersion, Query: query})
+}
+
+// DecodeAST decodes data, a JSON abstract syntax tree encoded by
+// [EncodeAST], into a PathQuery. Uses resolve to resolve function names;
+// pass nil to decode only ASTs without function expressions. Returns an
+// [ErrAST] error if data is not a valid AST or if it contains function
+// expressions that resolve does not resolve, or that do not type check.
+func DecodeAST(data []byte, resolve FunctionResolver) (*PathQuery, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	var doc astDocument
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAST, err)
+	}
+	if doc.Version != ASTVersion {
+		return nil, fmt.Errorf("%w: unsupported version %v", ErrAST, doc.Version)
+	}
+	if doc.Query == nil {
+		return nil, fmt.Errorf("%w: missing query", ErrAST)
+	}
+	d := &astDecoder{resolve: resolve}
+	return d.query(doc.Query)
+}
+
+// astDocument is the top-level object of an AST.
+type astDocument struct {
+	Version int       `json:"jsonpath_ast"`
+	Query   *astQuery `json:"query"`
+}
+
+// astQuery represents a [PathQuery] in an AST.
+type astQuery struct {
+	Root     bool          `json:"root,omitempty"`
+	Segments []*astSegment `json:"segments"`
+}
+
+// astSegment represents a [Segment] in an AST.
+type astSegment struct {
+	Descendant bool           `json:"descendant,omitempty"`
+	Selectors  []*astSelector `json:"selectors"`
+}
+
+// astSelector represents a [Selector] in an AST. Exactly one field must be
+// set. Wildcard records the position of the wildcard in the query string,
+// and Slice records the start, end, and step of a slice, with null for
+// default values.
+type astSelector struct {
+	Name     *string      `json:"name,omitempty"`
+	Index    *int         `json:"index,omitempty"`
+	Wildcard *int         `json:"wildcard,omitempty"`
+	Slice    []*int       `json:"slice,omitempty"`
+	Filter   [][]*astExpr `json:"filter,omitempty"`
+}
+
+// astExpr represents a filter expression or function argument in an AST.
+// Type determines which of the other fields apply:
+//
+//   - "paren", "not_paren", "logical": Or
+//   - "exists", "not_exists", "query": Query
+//   - "compare", "arithmetic": Left, Op, Right
+//   - "in": Left, Right
+//   - "function", "not_function": Name, Args
+//   - "literal": Value
+//   - "singular": Root, Selectors
+type astExpr struct {
+	Type      string          `json:"type"`
+	Or        [][]*astExpr    `json:"or,omitempty"`
+	Query     *astQuery       `json:"query,omitempty"`
+	Left      *astExpr        `json:"left,omitempty"`
+	Op        string          `json:"op,omitempty"`
+	Right     *astExpr        `json:"right,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Args      []*astExpr      `json:"args,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	Root      bool            `json:"root,omitempty"`
+	Selectors []*astSelector  `json:"selectors,omitempty"`
+}
+
+// encodeQuery encodes q as an astQuery.
+func encodeQuery(q *PathQuery) (*astQuery, error) {
+	segs := make([]*astSegment, len(q.segments))
+	for i, seg := range q.segments {
+		sels, err := encodeSelectors(seg.selectors)
+		if err != nil {
+			return nil, err
+		}
+		segs[i] = &astSegment{Descendant: seg.descendant, Selectors: sels}
+	}
+	return &astQuery{Root: q.root, Segments: segs}, nil
+}
+
+// encodeSelectors encodes sels as astSelectors.
+func encodeSelectors(sels []Selector) ([]*astSelector, error) {
+	res := make([]*astSelector, len(sels))
+	for i, sel := range sels {
+		switch sel := sel.(type) {
+		case Name:
+			name := string(sel)
+			res[i] = &astSelector{Name: &name}
+		case Index:
+			idx := int(sel)
+			res[i] = &astSelector{Index: &idx}
+		case WildcardSelector:
+			res[i] = &astSelector{Wildcard: &sel.pos}
+		case SliceSelector:
+			res[i] = &astSelector{Slice: encodeSlice(sel)}
+		case *FilterSelector:
+			or, err := encodeOr(sel.LogicalOr)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = &astSelector{Filter: or}
+		default:
+			return nil, fmt.Errorf("%w: cannot encode selector %T", ErrAST, sel)
+		}
+	}
+	return res, nil
+}
+
+// encodeSlice encodes s as its start, end, and step, with nil for a start or
+// end equal to the default for the direction of the step.
+func encodeSlice(s SliceSelector) []*int {
+	defStart, defEnd := Slice(nil, nil, s.step).start, Slice(nil, nil, s.step).end
+	res := []*int{nil, nil, &s.step}
+	if s.start != defStart {
+		res[0] = &s.start
+	}
+	if s.end != defEnd {
+		res[1] = &s.end
+	}
+	return res
+}
+
+// encodeOr encodes or as a list of lists of astExprs.
+func encodeOr(or LogicalOr) ([][]*astExpr, error) {
+	res := make([][]*astExpr, len(or))
+	for i, and := range or {
+		res[i] = make([]*astExpr, len(and))
+		for j, expr := range and {
+			ast, err := encodeBasicExpr(expr)
+			if err != nil {
+				return nil, err
+			}
+			res[i][j] = ast
+		}
+	}
+	return res, nil
+}
+
+// encodeBasicExpr encodes expr as an astExpr.
+func encodeBasicExpr(expr BasicExpr) (*astExpr, error) {
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		or, err := encodeOr(expr.LogicalOr)
+		return &astExpr{Type: "paren", Or: or}, err
+	case *NotParenExpr:
+		or, err := encodeOr(expr.LogicalOr)
+		return &astExpr{Type: "not_paren", Or: or}, err
+	case *ExistExpr:
+		q, err := encodeQuery(expr.PathQuery)
+		return &astExpr{Type: "exists", Query: q}, err
+	case *NonExistExpr:
+		q, err := encodeQuery(expr.PathQuery)
+		return &astExpr{Type: "not_exists", Query: q}, err
+	case NonExistExpr:
+		q, err := encodeQuery(expr.PathQuery)
+		return &astExpr{Type: "not_exists", Query: q}, err
+	case *ComparisonExpr:
+		left, err := encodeArg(expr.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := encodeArg(expr.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &astExpr{Type: "compare", Left: left, Op: expr.Op.String(), Right: right}, nil
+	case *MembershipExpr:
+		left, err := encodeArg(expr.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := encodeArg(expr.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &astExpr{Type: "in", Left: left, Right: right}, nil
+	case *FunctionExpr:
+		return encodeFunction("function", expr)
+	case NotFuncExpr:
+		return encodeFunction("not_function", expr.FunctionExpr)
+	default:
+		return nil, fmt.Errorf("%w: cannot encode expression %T", ErrAST, expr)
+	}
+}
+
+// encodeFunction encodes fe as an astExpr of type typ.
+func encodeFunction(typ string, fe *FunctionExpr) (*astExpr, error) {
+	args := make([]*astExpr, len(fe.args))
+	for i, arg := range fe.args {
+		ast, err := encodeArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = ast
+	}
+	return &astExpr{Type: typ, Name: fe.fn.Name(), Args: args}, nil
+}
+
+// encodeArg encodes arg, a [FunctionExprArg] or [CompVal], as an astExpr.
+func encodeArg(arg any) (*astExpr, error) {
+	switch arg := arg.(type) {
+	case *LiteralArg:
+		val, err := encodeLiteral(arg.literal)
+		return &astExpr{Type: "literal", Value: val}, err
+	case *SingularQueryExpr:
+		sels, err := encodeSelectors(arg.selectors)
+		return &astExpr{Type: "singular", Root: !arg.relative, Selectors: sels}, err
+	case *FilterQueryExpr:
+		q, err := encodeQuery(arg.PathQuery)
+		return &astExpr{Type: "query", Query: q}, err
+	case LogicalOr:
+		or, err := encodeOr(arg)
+		return &astExpr{Type: "logical", Or: or}, err
+	case *FunctionExpr:
+		return encodeFunction("function", arg)
+	case *ArithmeticExpr:
+		left, err := encodeArg(arg.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := encodeArg(arg.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &astExpr{Type: "arithmetic", Left: left, Op: arg.Op.String(), Right: right}, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot encode argument %T", ErrAST, arg)
+	}
+}
+
+// encodeLiteral encodes lit, a JSON scalar or a []any or map[string]any of
+// literals, as JSON. Always encodes floating point numbers with a decimal
+// point or exponent so that they decode as floating point numbers.
+func encodeLiteral(lit any) (json.RawMessage, error) {
+	switch lit := lit.(type) {
+	case []any:
+		arr := make([]json.RawMessage, len(lit))
+		for i, v := range lit {
+			val, err := encodeLiteral(v)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = val
+		}
+		//nolint:wrapcheck // Cannot fail for encoded literals.
+		return json.Marshal(arr)
+	case map[string]any:
+		obj := make(map[string]json.RawMessage, len(lit))
+		for k, v := range lit {
+			val, err := encodeLiteral(v)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = val
+		}
+		//nolint:wrapcheck // Cannot fail for encoded literals.
+		return json.Marshal(obj)
+	case float64:
+		return json.RawMessage(formatFloat(lit, 64)), nil
+	case float32:
+		return json.RawMessage(formatFloat(float64(lit), 32)), nil
+	case nil, bool, string, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		//nolint:wrapcheck // Cannot fail for scalars.
+		return json.Marshal(lit)
+	default:
+		return nil, fmt.Errorf("%w: cannot encode literal %T", ErrAST, lit)
+	}
+}
+
+// formatFloat formats f with bitSize precision, appending ".0" if the result
+// would otherwise look like an integer.
+func formatFloat(f float64, bitSize int) string {
+	str := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(str, ".e") {
+		str += ".0"
+	}
+	return str
+}
+
+// astDecoder decodes ASTs, using resolve to resolve function names.
+type astDecoder struct {
+	resolve FunctionResolver
+}
+
+// query decodes ast into a PathQuery.
+func (d *astDecoder) query(ast *astQuery) (*PathQuery, error) {
+	segs := make([]*Segment, len(ast.Segments))
+	for i, seg := range ast.Segments {
+		if seg == nil || len(seg.Selectors) == 0 {
+			return nil, fmt.Errorf("%w: segment has no selectors", ErrAST)
+		}
+		sels := make([]Selector, len(seg.Selectors))
+		for j, sel := range seg.Selectors {
+			s, err := d.selector(sel)
+			if err != nil {
+				return nil, err
+			}
+			sels[j] = s
+		}
+		segs[i] = &Segment{selectors: sels, descendant: seg.Descendant}
+	}
+	return &PathQuery{root: ast.Root, segments: segs}, nil
+}
+
+// selector decodes ast into a Selector.
+func (d *astDecoder) selector(ast *astSelector) (Selector, error) {
+	switch {
+	case ast == nil:
+		return nil, fmt.Errorf("%w: null selector", ErrAST)
+	case ast.Name != nil:
+		return Name(*ast.Name), nil
+	case ast.Index != nil:
+		return Index(*ast.Index), nil
+	case ast.Wildcard != nil:
+		return WildcardAt(*ast.Wildcard), nil
+	case ast.Slice != nil:
+		if len(ast.Slice) != 3 || ast.Slice[2] == nil {
+			return nil, fmt.Errorf("%w: slice requires start, end, and step", ErrAST)
+		}
+		args := make([]any, 3)
+		for i, arg := range ast.Slice {
+			if arg != nil {
+				args[i] = *arg
+			}
+		}
+		return Slice(args...), nil
+	case ast.Filter != nil:
+		or, err := d.or(ast.Filter)
+		if err != nil {
+			return nil, err
+		}
+		return Filter(or), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown selector", ErrAST)
+	}
+}
+
+// singularSelectors decodes ast into the Name and Index selectors of a
+// singular query.
+func (d *astDecoder) singularSelectors(ast []*astSelector) ([]Selector, error) {
+	sels := make([]Selector, len(ast))
+	for i, sel := range ast {
+		s, err := d.selector(sel)
+		if err != nil {
+			return nil, err
+		}
+		switch s.(type) {
+		case Name, Index:
+			sels[i] = s
+		default:
+			return nil, fmt.Errorf("%w: singular query selector must be a name or index", ErrAST)
+		}
+	}
+	return sels, nil
+}
+
+// or decodes ast into a LogicalOr.
+func (d *astDecoder) or(ast [][]*astExpr) (LogicalOr, error) {
+	if len(ast) == 0 {
+		return nil, fmt.Errorf("%w: empty logical expression", ErrAST)
+	}
+	or := make(LogicalOr, len(ast))
+	for i, and := range ast {
+		if len(and) == 0 {
+			return nil, fmt.Errorf("%w: empty logical expression", ErrAST)
+		}
+		or[i] = make(LogicalAnd, len(and))
+		for j, expr := range and {
+			basic, err := d.basicExpr(expr)
+			if err != nil {
+				return nil, err
+			}
+			or[i][j] = basic
+		}
+	}
+	return or, nil
+}
+
+// basicExpr decodes ast into a BasicExpr.
+func (d *astDecoder) basicExpr(ast *astExpr) (BasicExpr, error) {
+	if ast == nil {
+		return nil, fmt.Errorf("%w: null expression", ErrAST)
+	}
+	switch ast.Type {
+	case "paren", "not_paren":
+		or, err := d.or(ast.Or)
+		if err != nil {
+			return nil, err
+		}
+		if ast.Type == "paren" {
+			return Paren(or), nil
+		}
+		return NotParen(or), nil
+	case "exists", "not_exists":
+		q, err := d.filterQuery(ast)
+		if err != nil {
+			return nil, err
+		}
+		if ast.Type == "exists" {
+			return Existence(q), nil
+		}
+		return Nonexistence(q), nil
+	case "compare":
+		return d.comparison(ast)
+	case "in":
+		left, err := d.compVal(ast.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := d.compVal(ast.Right)
+		if err != nil {
+			return nil, err
+		}
+		return Membership(left, right), nil
+	case "function", "not_function":
+		fe, err := d.function(ast)
+		if err != nil {
+			return nil, err
+		}
+		if fe.ResultType() != FuncLogical {
+			return nil, fmt.Errorf("%w: missing comparison to function result", ErrAST)
+		}
+		if ast.Type == "function" {
+			return fe, nil
+		}
+		return NotFunction(fe), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown expression type %q", ErrAST, ast.Type)
+	}
+}
+
+// filterQuery decodes the query in ast.
+func (d *astDecoder) filterQuery(ast *astExpr) (*PathQuery, error) {
+	if ast.Query == nil {
+		return nil, fmt.Errorf("%w: %v expression missing query", ErrAST, ast.Type)
+	}
+	return d.query(ast.Query)
+}
+
+// comparison decodes ast into a ComparisonExpr.
+func (d *astDecoder) comparison(ast *astExpr) (*ComparisonExpr, error) {
+	var op CompOp
+	for o := EqualTo; o <= GreaterThanEqualTo; o++ {
+		if o.String() == ast.Op {
+			op = o
+			break
+		}
+	}
+	if op == 0 {
+		return nil, fmt.Errorf("%w: unknown comparison operator %q", ErrAST, ast.Op)
+	}
+
+	left, err := d.compVal(ast.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := d.compVal(ast.Right)
+	if err != nil {
+		return nil, err
+	}
+	return Comparison(left, op, right), nil
+}
+
+// compVal decodes ast into a CompVal.
+func (d *astDecoder) compVal(ast *astExpr) (CompVal, error) {
+	if ast == nil {
+		return nil, fmt.Errorf("%w: comparison missing operand", ErrAST)
+	}
+	switch ast.Type {
+	case "literal":
+		return d.literal(ast)
+	case "singular":
+		sels, err := d.singularSelectors(ast.Selectors)
+		if err != nil {
+			return nil, err
+		}
+		return SingularQuery(ast.Root, sels), nil
+	case "function":
+		fe, err := d.function(ast)
+		if err != nil {
+			return nil, err
+		}
+		if fe.ResultType() == FuncLogical {
+			return nil, fmt.Errorf("%w: cannot compare result of logical function", ErrAST)
+		}
+		return fe, nil
+	case "arithmetic":
+		return d.arithmetic(ast)
+	default:
+		return nil, fmt.Errorf("%w: cannot compare %q expression", ErrAST, ast.Type)
+	}
+}
+
+// arithmetic decodes ast into an ArithmeticExpr.
+func (d *astDecoder) arithmetic(ast *astExpr) (*ArithmeticExpr, error) {
+	var op ArithOp
+	for o := Add; o <= Modulo; o++ {
+		if o.String() == ast.Op {
+			op = o
+			break
+		}
+	}
+	if op == 0 {
+		return nil, fmt.Errorf("%w: unknown arithmetic operator %q", ErrAST, ast.Op)
+	}
+
+	left, err := d.compVal(ast.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := d.compVal(ast.Right)
+	if err != nil {
+		return nil, err
+	}
+	return Arithmetic(left, op, right), nil
+}
+
+// function decodes ast into a FunctionExpr, resolving its function with
+// d.resolve.
+func (d *astDecoder) function(ast *astExpr) (*FunctionExpr, error) {
+	args := make([]FunctionExprArg, len(ast.Args))
+	for i, arg := range ast.Args {
+		a, err := d.arg(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = a
+	}
+
+	if d.resolve == nil {
+		return nil, fmt.Errorf("%w: unknown function %v()", ErrAST, ast.Name)
+	}
+	fn, err := d.resolve(ast.Name, args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAST, err)
+	}
+	return Function(fn, args), nil
+}
+
+// arg decodes ast into a FunctionExprArg.
+func (d *astDecoder) arg(ast *astExpr) (FunctionExprArg, error) {
+	if ast == nil {
+		return nil, fmt.Errorf("%w: null function argument", ErrAST)
+	}
+	switch ast.Type {
+	case "literal":
+		return d.literal(ast)
+	case "singular":
+		sels, err := d.singularSelectors(ast.Selectors)
+		if err != nil {
+			return nil, err
+		}
+		return SingularQuery(ast.Root, sels), nil
+	case "query":
+		q, err := d.filterQuery(ast)
+		if err != nil {
+			return nil, err
+		}
+		return FilterQuery(q), nil
+	case "logical":
+		return d.or(ast.Or)
+	case "function":
+		return d.function(ast)
+	default:
+		return nil, fmt.Errorf("%w: unknown argument type %q", ErrAST, ast.Type)
+	}
+}
+
+// literal decodes the value of ast into a LiteralArg. Decodes integers as
+// int64 and other numbers as float64, as the parser does.
+func (d *astDecoder) literal(ast *astExpr) (*LiteralArg, error) {
+	if ast.Value == nil {
+		return nil, fmt.Errorf("%w: literal missing value", ErrAST)
+	}
+	dec := json.NewDecoder(bytes.NewReader(ast.Value))
+	dec.UseNumber()
+	var val any
+	if err := dec.Decode(&val); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAST, err)
+	}
+
+	lit, err := literalValue(val)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAST, err)
+	}
+	return Literal(lit), nil
+}
+
+// literalValue converts the [json.Number] values in val, decoded from JSON,
+// into int64 or float64 values, as the parser does, recursing into arrays
+// and objects.
+func literalValue(val any) (any, error) {
+	switch v := val.(type) {
+	case json.Number:
+		if !strings.ContainsAny(v.String(), ".eE") {
+			if i, err := v.Int64(); err == nil {
+				return i, nil
+			}
+		}
+		//nolint:wrapcheck // Wrapped by the caller.
+		return v.Float64()
+	case []any:
+		for i, e := range v {
+			lit, err := literalValue(e)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = lit
+		}
+	case map[string]any:
+		for k, e := range v {
+			lit, err := literalValue(e)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = lit
+		}
+	}
+	return val, nil
+}
@@ -0,0 +1,412 @@
+package spec
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAST(t *testing.T) {
+	t.Parallel()
+
+	trueFunc := newTrueFunc()
+	valFunc := &testFunc{
+		name:   "val",
+		result: FuncValue,
+		eval:   func([]JSONPathValue) JSONPathValue { return Value(42) },
+	}
+	resolve := func(name string, _ []FunctionExprArg) (PathFunction, error) {
+		switch name {
+		case trueFunc.name:
+			return trueFunc, nil
+		case valFunc.name:
+			return valFunc, nil
+		default:
+			return nil, fmt.Errorf("unknown function %v()", name)
+		}
+	}
+	sq := func(root bool, sel ...Selector) *SingularQueryExpr { return SingularQuery(root, sel) }
+
+	for _, tc := range []struct {
+		name  string
+		query *PathQuery
+		ast   string
+	}{
+		{
+			name:  "root",
+			query: Query(true, []*Segment{}),
+			ast:   `{"jsonpath_ast":1,"query":{"root":true,"segments":[]}}`,
+		},
+		{
+			name: "composite_literals",
+			query: Query(true, []*Segment{Child(Filter(LogicalOr{LogicalAnd{
+				Comparison(sq(false, Name("a")), EqualTo, Literal([]any{int64(1), 2.5, "x", nil, []any{}})),
+				Comparison(Literal(map[string]any{"b": true, "a": map[string]any{}}), NotEqualTo, sq(false, Name("b"))),
+			}}))}),
+			ast: `{"jsonpath_ast":1,"query":{"root":true,"segments":[{"selectors":[{"filter":[[` +
+				`{"type":"compare","left":{"type":"singular","selectors":[{"name":"a"}]},"op":"==",` +
+				`"right":{"type":"literal","value":[1,2.5,"x",null,[]]}},` +
+				`{"type":"compare","left":{"type":"literal","value":{"a":{},"b":true}},"op":"!=",` +
+				`"right":{"type":"singular","selectors":[{"name":"b"}]}}` +
+				`]]}]}]}}`,
+		},
+		{
+			name: "arithmetic",
+			query: Query(true, []*Segment{Child(Filter(LogicalOr{LogicalAnd{
+				Comparison(
+					Arithmetic(sq(false, Name("a")), Multiply, Arithmetic(Literal(int64(1)), Add, sq(true, Name("b")))),
+					EqualTo,
+					Literal(int64(2)),
+				),
+			}}))}),
+			ast: `{"jsonpath_ast":1,"query":{"root":true,"segments":[{"selectors":[{"filter":[[` +
+				`{"type":"compare","left":{"type":"arithmetic","left":{"type":"singular","selectors":[{"name":"a"}]},"op":"*",` +
+				`"right":{"type":"arithmetic","left":{"type":"literal","value":1},"op":"+",` +
+				`"right":{"type":"singular","root":true,"selectors":[{"name":"b"}]}}},` +
+				`"op":"==","right":{"type":"literal","value":2}}` +
+				`]]}]}]}}`,
+		},
+		{
+			name: "membership",
+			query: Query(true, []*Segment{Child(Filter(LogicalOr{LogicalAnd{
+				Membership(sq(false, Name("a")), Literal([]any{"x", int64(1)})),
+			}}))}),
+			ast: `{"jsonpath_ast":1,"query":{"root":true,"segments":[{"selectors":[{"filter":[[` +
+				`{"type":"in","left":{"type":"singular","selectors":[{"name":"a"}]},` +
+				`"right":{"type":"literal","value":["x",1]}}` +
+				`]]}]}]}}`,
+		},
+		{
+			name:  "relative",
+			query: Query(false, []*Segment{Child(Name("x"))}),
+			ast:   `{"jsonpath_ast":1,"query":{"segments":[{"selectors":[{"name":"x"}]}]}}`,
+		},
+		{
+			name:  "selectors",
+			query: Query(true, []*Segment{Child(Name("a"), Index(-1), WildcardAt(7)), Descendant(Wildcard())}),
+			ast: `{"jsonpath_ast":1,"query":{"root":true,"segments":[` +
+				`{"selectors":[{"name":"a"},{"index":-1},{"wildcard":7}]},` +
+				`{"descendant":true,"selectors":[{"wildcard":0}]}]}}`,
+		},
+		{
+			name: "slices",
+			query: Query(true, []*Segment{Child(
+				Slice(), Slice(1, 4), Slice(nil, nil, -1), Slice(5, 0, -2), Slice(0, math.MaxInt, 0),
+			)}),
+			ast: `{"jsonpath_ast":1,"query":{"root":true,"segments":[{"selectors":[` +
+				`{"slice":[null,null,1]},{"slice":[1,4,1]},{"slice":[null,null,-1]},` +
+				`{"slice":[5,0,-2]},{"slice":[null,null,0]}]}]}}`,
+		},
+		{
+			name: "comparisons",
+			query: Query(true, []*Segment{Child(Filter(LogicalOr{
+				LogicalAnd{
+					Comparison(sq(false, Name("a"), Index(0)), EqualTo, Literal(int64(1))),
+					Comparison(sq(true, Name("b")), LessThanEqualTo, Literal(1.0)),
+				},
+				LogicalAnd{
+					Comparison(Literal("x"), NotEqualTo, Literal(nil)),
+					Comparison(Literal(true), GreaterThan, Function(valFunc, []FunctionExprArg{})),
+				},
+			}))}),
+			ast: `{"jsonpath_ast":1,"query":{"root":true,"segments":[{"selectors":[{"filter":[` +
+				`[{"type":"compare","left":{"type":"singular","selectors":[{"name":"a"},{"index":0}]},` +
+				`"op":"==","right":{"type":"literal","value":1}},` +
+				`{"type":"compare","left":{"type":"singular","root":true,"selectors":[{"name":"b"}]},` +
+				`"op":"\u003c=","right":{"type":"literal","value":1.0}}],` +
+				`[{"type":"compare","left":{"type":"literal","value":"x"},"op":"!=","right":{"type":"literal","value":null}},` +
+				`{"type":"compare","left":{"type":"literal","value":true},"op":"\u003e","right":{"type":"function","name":"val"}}]` +
+				`]}]}]}}`,
+		},
+		{
+			name: "expressions",
+			query: Query(true, []*Segment{Child(Filter(LogicalOr{LogicalAnd{
+				Paren(LogicalOr{LogicalAnd{Existence(Query(false, []*Segment{Child(Name("a"))}))}}),
+				NotParen(LogicalOr{LogicalAnd{Nonexistence(Query(true, []*Segment{Child(Name("b"))}))}}),
+				Function(trueFunc, []FunctionExprArg{
+					FilterQuery(Query(false, []*Segment{Descendant(Wildcard())})),
+					LogicalOr{LogicalAnd{Existence(Query(false, []*Segment{}))}},
+					Function(valFunc, []FunctionExprArg{Literal(1.5e300)}),
+				}),
+				NotFunction(Function(trueFunc, []FunctionExprArg{SingularQuery(false, []Selector{})})),
+			}}))}),
+			ast: `{"jsonpath_ast":1,"query":{"root":true,"segments":[{"selectors":[{"filter":[[` +
+				`{"type":"paren","or":[[{"type":"exists","query":{"segments":[{"selectors":[{"name":"a"}]}]}}]]},` +
+				`{"type":"not_paren","or":[[{"type":"not_exists","query":{"root":true,"segments":[{"selectors":[{"name":"b"}]}]}}]]},` +
+				`{"type":"function","name":"__true","args":[` +
+				`{"type":"query","query":{"segments":[{"descendant":true,"selectors":[{"wildcard":0}]}]}},` +
+				`{"type":"logical","or":[[{"type":"exists","query":{"segments":[]}}]]},` +
+				`{"type":"function","name":"val","args":[{"type":"literal","value":1.5e+300}]}]},` +
+				`{"type":"not_function","name":"__true","args":[{"type":"singular"}]}` +
+				`]]}]}]}}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			data, err := EncodeAST(tc.query)
+			r.NoError(err)
+			a.JSONEq(tc.ast, string(data))
+			a.Equal(tc.ast, string(data))
+
+			q, err := DecodeAST(data, resolve)
+			r.NoError(err)
+			a.Equal(tc.query, q)
+			a.Equal(tc.query.String(), q.String())
+		})
+	}
+}
+
+func TestASTEncodeErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		query *PathQuery
+		err   string
+	}{
+		{
+			name: "array_literal",
+			query: Query(true, []*Segment{Child(Filter(LogicalOr{LogicalAnd{
+				Comparison(Literal([]any{1, map[string]any{"x": struct{}{}}}), EqualTo, Literal(1)),
+			}}))}),
+			err: "jsonpath: invalid AST: cannot encode literal struct {}",
+		},
+		{
+			name: "function_literal",
+			query: Query(true, []*Segment{Child(Filter(LogicalOr{LogicalAnd{
+				Function(newTrueFunc(), []FunctionExprArg{Literal(complex(1, 2))}),
+			}}))}),
+			err: "jsonpath: invalid AST: cannot encode literal complex128",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			data, err := EncodeAST(tc.query)
+			a.Nil(data)
+			a.EqualError(err, tc.err)
+			a.ErrorIs(err, ErrAST)
+		})
+	}
+}
+
+func TestASTDecodeErrors(t *testing.T) {
+	t.Parallel()
+
+	logical := &testFunc{name: "logical", result: FuncLogical}
+	value := &testFunc{name: "value", result: FuncValue}
+	resolve := func(name string, args []FunctionExprArg) (PathFunction, error) {
+		switch name {
+		case logical.name:
+			return logical, nil
+		case value.name:
+			if len(args) != 0 {
+				return nil, fmt.Errorf("function %v() expected no arguments", name)
+			}
+			return value, nil
+		default:
+			return nil, fmt.Errorf("unknown function %v()", name)
+		}
+	}
+	filter := func(expr string) string {
+		return `{"jsonpath_ast":1,"query":{"segments":[{"selectors":[{"filter":[[` + expr + `]]}]}]}}`
+	}
+	compare := func(left, right string) string {
+		return filter(`{"type":"compare","left":` + left + `,"op":"==","right":` + right + `}`)
+	}
+	lit := `{"type":"literal","value":1}`
+
+	for _, tc := range []struct {
+		name string
+		ast  string
+		err  string
+	}{
+		{
+			name: "not_json",
+			ast:  `{"jsonpath_ast":`,
+			err:  "jsonpath: invalid AST: unexpected EOF",
+		},
+		{
+			name: "unknown_field",
+			ast:  `{"jsonpath_ast":1,"query":{"segments":[]},"nope":1}`,
+			err:  `jsonpath: invalid AST: json: unknown field "nope"`,
+		},
+		{
+			name: "no_version",
+			ast:  `{"query":{"segments":[]}}`,
+			err:  "jsonpath: invalid AST: unsupported version 0",
+		},
+		{
+			name: "future_version",
+			ast:  `{"jsonpath_ast":2,"query":{"segments":[]}}`,
+			err:  "jsonpath: invalid AST: unsupported version 2",
+		},
+		{
+			name: "no_query",
+			ast:  `{"jsonpath_ast":1}`,
+			err:  "jsonpath: invalid AST: missing query",
+		},
+		{
+			name: "empty_segment",
+			ast:  `{"jsonpath_ast":1,"query":{"segments":[{"selectors":[]}]}}`,
+			err:  "jsonpath: invalid AST: segment has no selectors",
+		},
+		{
+			name: "null_selector",
+			ast:  `{"jsonpath_ast":1,"query":{"segments":[{"selectors":[null]}]}}`,
+			err:  "jsonpath: invalid AST: null selector",
+		},
+		{
+			name: "unknown_selector",
+			ast:  `{"jsonpath_ast":1,"query":{"segments":[{"selectors":[{}]}]}}`,
+			err:  "jsonpath: invalid AST: unknown selector",
+		},
+		{
+			name: "short_slice",
+			ast:  `{"jsonpath_ast":1,"query":{"segments":[{"selectors":[{"slice":[1,2]}]}]}}`,
+			err:  "jsonpath: invalid AST: slice requires start, end, and step",
+		},
+		{
+			name: "null_step",
+			ast:  `{"jsonpath_ast":1,"query":{"segments":[{"selectors":[{"slice":[1,2,null]}]}]}}`,
+			err:  "jsonpath: invalid AST: slice requires start, end, and step",
+		},
+		{
+			name: "empty_filter",
+			ast:  `{"jsonpath_ast":1,"query":{"segments":[{"selectors":[{"filter":[]}]}]}}`,
+			err:  "jsonpath: invalid AST: empty logical expression",
+		},
+		{
+			name: "empty_and",
+			ast:  filter(``),
+			err:  "jsonpath: invalid AST: empty logical expression",
+		},
+		{
+			name: "null_expr",
+			ast:  filter(`null`),
+			err:  "jsonpath: invalid AST: null expression",
+		},
+		{
+			name: "unknown_expr",
+			ast:  filter(`{"type":"nope"}`),
+			err:  `jsonpath: invalid AST: unknown expression type "nope"`,
+		},
+		{
+			name: "literal_expr",
+			ast:  filter(lit),
+			err:  `jsonpath: invalid AST: unknown expression type "literal"`,
+		},
+		{
+			name: "bad_paren",
+			ast:  filter(`{"type":"paren"}`),
+			err:  "jsonpath: invalid AST: empty logical expression",
+		},
+		{
+			name: "exists_no_query",
+			ast:  filter(`{"type":"exists"}`),
+			err:  "jsonpath: invalid AST: exists expression missing query",
+		},
+		{
+			name: "unknown_op",
+			ast:  filter(`{"type":"compare","left":` + lit + `,"op":"=","right":` + lit + `}`),
+			err:  `jsonpath: invalid AST: unknown comparison operator "="`,
+		},
+		{
+			name: "unknown_arithmetic_op",
+			ast:  filter(`{"type":"compare","left":{"type":"arithmetic","left":` + lit + `,"op":"^","right":` + lit + `},"op":"==","right":` + lit + `}`),
+			err:  `jsonpath: invalid AST: unknown arithmetic operator "^"`,
+		},
+		{
+			name: "missing_arithmetic_operand",
+			ast:  filter(`{"type":"compare","left":{"type":"arithmetic","left":` + lit + `,"op":"+"},"op":"==","right":` + lit + `}`),
+			err:  `jsonpath: invalid AST: comparison missing operand`,
+		},
+		{
+			name: "missing_operand",
+			ast:  filter(`{"type":"compare","left":` + lit + `,"op":"=="}`),
+			err:  "jsonpath: invalid AST: comparison missing operand",
+		},
+		{
+			name: "compare_query",
+			ast:  compare(lit, `{"type":"query","query":{"segments":[]}}`),
+			err:  `jsonpath: invalid AST: cannot compare "query" expression`,
+		},
+		{
+			name: "compare_logical_func",
+			ast:  compare(`{"type":"function","name":"logical"}`, lit),
+			err:  "jsonpath: invalid AST: cannot compare result of logical function",
+		},
+		{
+			name: "non_singular",
+			ast:  compare(`{"type":"singular","selectors":[{"wildcard":0}]}`, lit),
+			err:  "jsonpath: invalid AST: singular query selector must be a name or index",
+		},
+		{
+			name: "missing_value",
+			ast:  compare(`{"type":"literal"}`, lit),
+			err:  "jsonpath: invalid AST: literal missing value",
+		},
+		{
+			name: "huge_array_value",
+			ast:  compare(`{"type":"literal","value":[1,{"x":1e999}]}`, lit),
+			err:  `jsonpath: invalid AST: strconv.ParseFloat: parsing "1e999": value out of range`,
+		},
+		{
+			name: "huge_value",
+			ast:  compare(`{"type":"literal","value":1e999}`, lit),
+			err:  `jsonpath: invalid AST: strconv.ParseFloat: parsing "1e999": value out of range`,
+		},
+		{
+			name: "unknown_function",
+			ast:  filter(`{"type":"function","name":"nope"}`),
+			err:  "jsonpath: invalid AST: unknown function nope()",
+		},
+		{
+			name: "invalid_args",
+			ast:  compare(`{"type":"function","name":"value","args":[`+lit+`]}`, lit),
+			err:  "jsonpath: invalid AST: function value() expected no arguments",
+		},
+		{
+			name: "missing_comparison",
+			ast:  filter(`{"type":"not_function","name":"value"}`),
+			err:  "jsonpath: invalid AST: missing comparison to function result",
+		},
+		{
+			name: "null_arg",
+			ast:  filter(`{"type":"function","name":"logical","args":[null]}`),
+			err:  "jsonpath: invalid AST: null function argument",
+		},
+		{
+			name: "unknown_arg",
+			ast:  filter(`{"type":"function","name":"logical","args":[{"type":"compare"}]}`),
+			err:  `jsonpath: invalid AST: unknown argument type "compare"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			q, err := DecodeAST([]byte(tc.ast), resolve)
+			a.Nil(q)
+			a.EqualError(err, tc.err)
+			a.ErrorIs(err, ErrAST)
+		})
+	}
+
+	t.Run("no_resolver", func(t *testing.T) {
+		t.Parallel()
+		a := assert.New(t)
+
+		q, err := DecodeAST([]byte(filter(`{"type":"function","name":"logical"}`)), nil)
+		a.Nil(q)
+		a.EqualError(err, "jsonpath: invalid AST: unknown function logical()")
+		a.ErrorIs(err, ErrAST)
+	})
+}
@@ -0,0 +1,42 @@
+package spec
+
+import "sync"
+
+// singularCache caches the values of absolute singular queries evaluated
+// against a root value.
+type singularCache struct {
+	mu   sync.Mutex
+	vals map[*SingularQueryExpr]JSONPathValue
+}
+
+// CacheSingular wraps root so that each absolute singular query in filter
+// expressions, such as $.config.threshold, selects from it only once rather
+// than once for every node the filter tests. Relative singular queries,
+// which depend on the current node, are never cached.
+//
+// Pass the result as the root argument to the Select and SelectLocated
+// methods of [PathQuery], [Segment], and [Selector] for the duration of a
+// single evaluation, then discard it: the cache assumes that root does not
+// change.
+func CacheSingular(root any) any {
+	if rootOf(root).singularCache() != nil {
+		return root
+	}
+	r := wrapRoot(root)
+	r.cache = &singularCache{vals: map[*SingularQueryExpr]JSONPathValue{}}
+	return r
+}
+
+// value returns the value of sq selected from the value of root, selecting
+// and caching it on first use. Passes root to the selectors of sq, so that
+// they see its other options, such as that of [FoldNames].
+func (c *singularCache) value(sq *SingularQueryExpr, root any) JSONPathValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	val, ok := c.vals[sq]
+	if !ok {
+		val = sq.selectFrom(rootValue(root), root)
+		c.vals[sq] = val
+	}
+	return val
+}
@@ -0,0 +1,79 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheSingular(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	root := map[string]any{"t": 2, "a": []any{1, 2, 3}}
+	cached := CacheSingular(root)
+	a.Same(cached, CacheSingular(cached))
+	a.Equal(root, rootValue(cached))
+	a.Equal(root, rootValue(root))
+
+	// $.a[?@ > $.t]
+	threshold := SingularQuery(true, []Selector{Name("t")})
+	filter := Filter(LogicalOr{LogicalAnd{
+		Comparison(SingularQuery(false, nil), GreaterThan, threshold),
+	}})
+	query := Query(true, []*Segment{Child(Name("a")), Child(filter)})
+
+	a.Equal([]any{3}, query.Select(nil, cached))
+	a.Equal([]any{3}, query.Select(nil, root))
+	a.Equal(
+		[]*LocatedNode{{Node: 3, Path: NormalizedPath{Name("a"), Index(2)}}},
+		query.SelectLocated(nil, cached, nil),
+	)
+
+	// Changing the root changes the result only without the cache.
+	root["t"] = 0
+	a.Equal([]any{3}, query.Select(nil, cached))
+	a.Equal([]any{1, 2, 3}, query.Select(nil, root))
+
+	// Absolute queries with no selectors return the root.
+	a.Equal(&ValueType{root}, SingularQuery(true, nil).evaluate(nil, cached))
+
+	// Nothing is cached, too.
+	missing := SingularQuery(true, []Selector{Name("x")})
+	a.Nil(missing.evaluate(nil, cached))
+	root["x"] = 1
+	a.Nil(missing.evaluate(nil, cached))
+	a.Equal(&ValueType{1}, missing.evaluate(nil, root))
+}
+
+func TestCacheSingularRelative(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	second := map[string]any{"x": 2, "y": 3}
+	root := []any{
+		map[string]any{"x": 1, "y": 1},
+		second,
+		map[string]any{"x": 4, "y": 4},
+	}
+	cached := CacheSingular(root)
+
+	// Relative queries select from each current node, never the cache.
+	x := SingularQuery(false, []Selector{Name("x")})
+	a.Equal(&ValueType{1}, x.evaluate(root[0], cached))
+	a.Equal(&ValueType{2}, x.evaluate(root[1], cached))
+
+	// $[?@.x == @.y]
+	filter := Filter(LogicalOr{LogicalAnd{
+		Comparison(x, EqualTo, SingularQuery(false, []Selector{Name("y")})),
+	}})
+	a.Equal([]any{root[0], root[2]}, filter.Select(root, cached))
+
+	// Relative filter queries, too: $[?@.z]
+	exists := Filter(LogicalOr{LogicalAnd{
+		Existence(Query(false, []*Segment{Child(Name("z"))})),
+	}})
+	a.Empty(exists.Select(root, cached))
+	second["z"] = true
+	a.Equal([]any{second}, exists.Select(root, cached))
+}
@@ -0,0 +1,64 @@
+package spec
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Collation returns the collation key of s. Filter expressions compare the
+// keys of strings rather than the strings themselves when evaluated against
+// a root wrapped by [Collate], so that strings with the same key are equal,
+// and strings order by their keys.
+type Collation func(s string) string
+
+// FoldCase is a [Collation] that compares strings case-insensitively by
+// mapping each rune to the lower case of its upper case, so that "Go"
+// equals "GO", "gO", and "go", and "σ" equals "Σ" and "ς". It maps runes
+// one-to-one, so "ß" does not equal "SS".
+func FoldCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		return unicode.ToLower(unicode.ToUpper(r))
+	}, s)
+}
+
+// Collate wraps root so that comparison and membership expressions in
+// filter expressions compare strings by the keys key returns for them, as
+// in @.name == "café", @.name < "m", and @.name in ["a", "b"]. Strings in
+// arrays and objects compared with == compare exactly, as do the arguments
+// to functions, such as match() and search().
+//
+// Use [FoldCase] to compare strings case-insensitively, or a Unicode
+// normalization function, such as norm.NFC.String from
+// [golang.org/x/text/unicode/norm], to compare strings that differ only in
+// their normalization forms, such as NFD strings from some systems and NFC
+// strings in the query.
+//
+// Pass the result as the root argument to the Select and SelectLocated
+// methods of [PathQuery], [Segment], and [Selector] for the duration of a
+// single evaluation. Wrapping it again with Collate replaces key.
+//
+// [golang.org/x/text/unicode/norm]: https://pkg.go.dev/golang.org/x/text/unicode/norm
+func Collate(root any, key Collation) any {
+	r := wrapRoot(root)
+	r.key = key
+	return r
+}
+
+// collate returns a [ValueType] containing the key returned by key for the
+// string value of val, if val is a ValueType with a string value.
+// Otherwise it returns val.
+func collate(val JSONPathValue, key Collation) JSONPathValue {
+	if v, ok := val.(*ValueType); ok {
+		return Value(collateValue(v.any, key))
+	}
+	return val
+}
+
+// collateValue returns the key returned by key for val if val is a string,
+// including a [json.RawMessage] string. Otherwise it returns val.
+func collateValue(val any, key Collation) any {
+	if s, ok := decodeRaw(val).(string); ok {
+		return key(s)
+	}
+	return val
+}
@@ -0,0 +1,181 @@
+package spec
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldCase(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		str  string
+		exp  string
+	}{
+		{"empty", "", ""},
+		{"lower", "go", "go"},
+		{"upper", "GO", "go"},
+		{"mixed", "gO", "go"},
+		{"sigma", "Σσς", "σσσ"},
+		{"long_s", "ſ", "s"},
+		{"kelvin", "K", "k"},
+		{"sharp_s", "ß", "ß"},
+		{"non_letters", "a_1-Z", "a_1-z"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, FoldCase(tc.str))
+		})
+	}
+}
+
+func TestCollate(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// nfc composes the only decomposed character in the tests.
+	nfc := func(s string) string { return strings.ReplaceAll(s, "e\u0301", "\u00e9") }
+	root := map[string]any{"want": "Caf\u00e9"}
+	current := map[string]any{
+		"name": "cafe\u0301",
+		"raw":  json.RawMessage(`"CAF\u00c9"`),
+	}
+
+	for _, tc := range []struct {
+		name  string
+		expr  BasicExpr
+		key   Collation
+		exact bool
+		exp   bool
+		raw   bool
+	}{
+		{
+			name: "eq_nfc",
+			expr: Comparison(SingularQuery(false, []Selector{Name("name")}), EqualTo, Literal("caf\u00e9")),
+			key:  nfc,
+			exp:  true,
+		},
+		{
+			name:  "ne_nfc",
+			expr:  Comparison(SingularQuery(false, []Selector{Name("name")}), NotEqualTo, Literal("caf\u00e9")),
+			key:   nfc,
+			exact: true,
+			exp:   false,
+		},
+		{
+			name: "eq_fold",
+			expr: Comparison(SingularQuery(false, []Selector{Name("name")}), EqualTo, Literal("CAFE\u0301")),
+			key:  FoldCase,
+			exp:  true,
+		},
+		{
+			name: "eq_root_query",
+			expr: Comparison(
+				SingularQuery(false, []Selector{Name("name")}),
+				EqualTo,
+				SingularQuery(true, []Selector{Name("want")}),
+			),
+			key: func(s string) string { return FoldCase(nfc(s)) },
+			exp: true,
+		},
+		{
+			name: "eq_raw",
+			raw:  true,
+			expr: Comparison(SingularQuery(false, []Selector{Name("raw")}), EqualTo, Literal("caf\u00e9")),
+			key:  func(s string) string { return FoldCase(nfc(s)) },
+			exp:  true,
+		},
+		{
+			name: "lt_fold",
+			expr: Comparison(Literal("apple"), LessThan, Literal("Banana")),
+			key:  FoldCase,
+			exp:  true,
+		},
+		{
+			name:  "lt_fold_exact",
+			expr:  Comparison(Literal("Banana"), LessThan, Literal("apple")),
+			key:   FoldCase,
+			exact: true,
+			exp:   false,
+		},
+		{
+			name: "ge_fold",
+			expr: Comparison(Literal("APPLE"), GreaterThanEqualTo, Literal("apple")),
+			key:  FoldCase,
+			exp:  true,
+		},
+		{
+			name:  "numbers_unaffected",
+			expr:  Comparison(Literal(1), EqualTo, Literal(1.0)),
+			key:   FoldCase,
+			exact: true,
+			exp:   true,
+		},
+		{
+			name: "string_not_number",
+			expr: Comparison(Literal("1"), EqualTo, Literal(1)),
+			key:  func(string) string { return "1" },
+			exp:  false,
+		},
+		{
+			name: "nothing_unaffected",
+			expr: Comparison(SingularQuery(false, []Selector{Name("nope")}), EqualTo, Literal("x")),
+			key:  func(string) string { return "x" },
+			exp:  false,
+		},
+		{
+			name: "membership",
+			expr: Membership(
+				SingularQuery(false, []Selector{Name("name")}),
+				Literal([]any{"tea", "CAF\u00c9"}),
+			),
+			key: func(s string) string { return FoldCase(nfc(s)) },
+			exp: true,
+		},
+		{
+			name: "membership_miss",
+			expr: Membership(SingularQuery(false, []Selector{Name("name")}), Literal([]any{"tea", 1})),
+			key:  FoldCase,
+			exp:  false,
+		},
+		{
+			name: "membership_not_array",
+			expr: Membership(SingularQuery(false, []Selector{Name("name")}), Literal("caf\u00e9")),
+			key:  nfc,
+			exp:  false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if tc.raw {
+				skipRaw(t)
+			}
+			a := assert.New(t)
+			a.Equal(tc.exact, tc.expr.testFilter(current, root))
+			a.Equal(tc.exp, tc.expr.testFilter(current, Collate(root, tc.key)))
+		})
+	}
+
+	// Collation combines with the other root wrappers in any order.
+	collated := Collate(root, FoldCase)
+	a.Nil(rootOf(root).collation())
+	a.NotNil(rootOf(collated).collation())
+	a.Equal(root, rootValue(collated))
+	for _, wrapped := range []any{
+		CacheSingular(collated),
+		Collate(CacheSingular(root), FoldCase),
+		OverrideFunctions(collated, nil),
+		FoldNames(collated),
+		Collate(FoldNames(OverrideFunctions(CacheSingular(root), nil)), FoldCase),
+	} {
+		a.NotNil(rootOf(wrapped).collation())
+		a.Equal(root, rootValue(wrapped))
+	}
+	a.True(rootOf(FoldNames(collated)).foldsNames())
+	a.NotNil(rootOf(Collate(CacheSingular(root), FoldCase)).singularCache())
+	a.Nil(rootOf(Collate(root, FoldCase)).override("x"))
+}
@@ -0,0 +1,101 @@
+package spec
+
+// EvalContext describes the context in which a filter expression calls a
+// [ContextFunction].
+type EvalContext struct {
+	// Current is the node the filter expression is testing, the value of @.
+	Current any
+
+	// Parent is the array or object that contains Current, from which the
+	// filter selector selects.
+	Parent any
+
+	// Root is the root value, the value of $.
+	Root any
+
+	// Path is the normalized path to Current when evaluated by the
+	// SelectLocated methods of [PathQuery], [Segment], and [Selector], and
+	// nil when evaluated by their Select methods, which do not track
+	// paths.
+	Path NormalizedPath
+}
+
+// ContextFunction is a [PathFunction] that may depend on the context in
+// which a filter expression calls it, such as a function that returns the
+// normalized path of the current node. When UsesContext returns true,
+// [FunctionExpr] calls EvaluateContext rather than Evaluate. See
+// [github.com/theory/jsonpath/registry] for the implementation.
+type ContextFunction interface {
+	PathFunction
+
+	// UsesContext returns true if the function depends on its
+	// [EvalContext].
+	UsesContext() bool
+
+	// EvaluateContext executes the function against args in ctx.
+	EvaluateContext(ctx *EvalContext, args []JSONPathValue) JSONPathValue
+}
+
+// withContext returns root wrapped to record parent and path, the parent and
+// normalized path of the node tested by a filter selector, for the
+// [EvalContext] of [ContextFunction]s.
+func withContext(root, parent any, path NormalizedPath) any {
+	r := wrapRoot(root)
+	r.parent, r.path = parent, path
+	return r
+}
+
+// contextOf returns an [EvalContext] for current relative to root,
+// recording the parent and path recorded by withContext, if any.
+func contextOf(current, root any) *EvalContext {
+	ctx := &EvalContext{Current: current, Root: rootValue(root)}
+	if r := rootOf(root); r != nil {
+		ctx.Parent, ctx.Path = r.parent, r.path
+	}
+	return ctx
+}
+
+// usesContext returns true if or calls a [ContextFunction] that uses its
+// context, other than in the filter selectors of queries, which record
+// their own contexts.
+func usesContext(or LogicalOr) bool {
+	for _, and := range or {
+		for _, expr := range and {
+			if exprUsesContext(expr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// exprUsesContext returns true if expr calls a [ContextFunction] that uses
+// its context.
+func exprUsesContext(expr any) bool {
+	switch expr := expr.(type) {
+	case LogicalOr:
+		return usesContext(expr)
+	case *ParenExpr:
+		return usesContext(expr.LogicalOr)
+	case *NotParenExpr:
+		return usesContext(expr.LogicalOr)
+	case *ComparisonExpr:
+		return exprUsesContext(expr.Left) || exprUsesContext(expr.Right)
+	case *MembershipExpr:
+		return exprUsesContext(expr.Left) || exprUsesContext(expr.Right)
+	case *ArithmeticExpr:
+		return exprUsesContext(expr.Left) || exprUsesContext(expr.Right)
+	case NotFuncExpr:
+		return exprUsesContext(expr.FunctionExpr)
+	case *FunctionExpr:
+		if fn, ok := expr.fn.(ContextFunction); ok && fn.UsesContext() {
+			return true
+		}
+		for _, arg := range expr.args {
+			if exprUsesContext(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
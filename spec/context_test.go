@@ -0,0 +1,147 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Mock up a context function.
+type testContextFunc struct {
+	testFunc
+	eval func(ctx *EvalContext, args []JSONPathValue) JSONPathValue
+}
+
+func (tf *testContextFunc) UsesContext() bool { return tf.eval != nil }
+func (tf *testContextFunc) EvaluateContext(ctx *EvalContext, args []JSONPathValue) JSONPathValue {
+	return tf.eval(ctx, args)
+}
+
+// newPathFunc returns a context function that returns the string form of
+// the normalized path of the current node, or nil if there is none.
+func newPathFunc() *testContextFunc {
+	return &testContextFunc{
+		testFunc: testFunc{
+			name:   "__path",
+			result: FuncValue,
+			eval:   func([]JSONPathValue) JSONPathValue { return nil },
+		},
+		eval: func(ctx *EvalContext, _ []JSONPathValue) JSONPathValue {
+			if ctx.Path == nil {
+				return nil
+			}
+			return Value(ctx.Path.String())
+		},
+	}
+}
+
+func TestContextFunction(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ordered := &OrderedObject{}
+	ordered.Set("a", 1)
+	ordered.Set("b", 2)
+	root := map[string]any{"x": []any{"a", "b", "c"}, "o": ordered}
+
+	// $.x[?__path() == "$['x'][1]"]
+	pathFn := newPathFunc()
+	query := Query(true, []*Segment{
+		Child(Name("x")),
+		Child(Filter(LogicalOr{LogicalAnd{Comparison(
+			Function(pathFn, nil), EqualTo, Literal("$['x'][1]"),
+		)}})),
+	})
+	a.Equal(
+		[]*LocatedNode{{Node: "b", Path: NormalizedPath{Name("x"), Index(1)}}},
+		query.SelectLocated(nil, root, NormalizedPath{}),
+	)
+	// Select does not track paths.
+	a.Empty(query.Select(nil, root))
+
+	// The context works with the other root wrappers.
+	wrapped := OverrideFunctions(FoldNames(CacheSingular(root)), nil)
+	a.Equal(
+		[]*LocatedNode{{Node: "b", Path: NormalizedPath{Name("x"), Index(1)}}},
+		query.SelectLocated(nil, Collate(wrapped, FoldCase), NormalizedPath{}),
+	)
+
+	// Overrides replace context functions.
+	override := OverrideFunctions(root, map[string]func([]JSONPathValue) JSONPathValue{
+		"__path": func([]JSONPathValue) JSONPathValue { return Value("$['x'][1]") },
+	})
+	a.Equal([]any{"a", "b", "c"}, query.Select(nil, override))
+
+	// Record the context of each call.
+	var contexts []*EvalContext
+	record := &testContextFunc{
+		testFunc: testFunc{name: "__record", result: FuncLogical},
+		eval: func(ctx *EvalContext, _ []JSONPathValue) JSONPathValue {
+			contexts = append(contexts, ctx)
+			return LogicalTrue
+		},
+	}
+	filter := Filter(LogicalOr{LogicalAnd{Function(record, nil)}})
+
+	a.Equal([]any{1, 2}, filter.Select(ordered, CacheSingular(root)))
+	a.Equal([]*EvalContext{
+		{Current: 1, Parent: ordered, Root: root},
+		{Current: 2, Parent: ordered, Root: root},
+	}, contexts)
+
+	contexts = nil
+	a.Len(filter.SelectLocated(root["x"], root, NormalizedPath{Name("x")}), 3)
+	a.Equal([]*EvalContext{
+		{Current: "a", Parent: root["x"], Root: root, Path: NormalizedPath{Name("x"), Index(0)}},
+		{Current: "b", Parent: root["x"], Root: root, Path: NormalizedPath{Name("x"), Index(1)}},
+		{Current: "c", Parent: root["x"], Root: root, Path: NormalizedPath{Name("x"), Index(2)}},
+	}, contexts)
+
+	// Nested filters record their own contexts, without paths.
+	contexts = nil
+	nested := Filter(LogicalOr{LogicalAnd{
+		&ExistExpr{Query(false, []*Segment{Child(filter)})},
+	}})
+	data := []any{[]any{"z"}}
+	a.Len(nested.SelectLocated(data, root, NormalizedPath{}), 1)
+	a.Equal([]*EvalContext{{Current: "z", Parent: data[0], Root: root}}, contexts)
+
+	// Functions that don't use context get no context.
+	noCtx := &testContextFunc{testFunc: *newTrueFunc()}
+	a.False(usesContext(LogicalOr{LogicalAnd{Function(noCtx, nil)}}))
+	a.Equal([]any{"a", "b", "c"}, Filter(LogicalOr{LogicalAnd{Function(noCtx, nil)}}).Select(root["x"], root))
+}
+
+func TestUsesContext(t *testing.T) {
+	t.Parallel()
+	ctxFn := Function(newPathFunc(), nil)
+	plainFn := Function(newValueFunc(1), nil)
+	query := Query(false, []*Segment{Child(Filter(LogicalOr{LogicalAnd{ctxFn}}))})
+
+	for _, tc := range []struct {
+		name string
+		expr BasicExpr
+		exp  bool
+	}{
+		{"function", ctxFn, true},
+		{"plain_function", plainFn, false},
+		{"not_function", NotFunction(ctxFn), true},
+		{"function_arg", Function(newTrueFunc(), []FunctionExprArg{ctxFn}), true},
+		{"logical_arg", Function(newTrueFunc(), []FunctionExprArg{LogicalOr{LogicalAnd{ctxFn}}}), true},
+		{"plain_arg", Function(newTrueFunc(), []FunctionExprArg{plainFn}), false},
+		{"paren", Paren(LogicalOr{LogicalAnd{ctxFn}}), true},
+		{"not_paren", NotParen(LogicalOr{LogicalAnd{plainFn, ctxFn}}), true},
+		{"comparison_left", Comparison(ctxFn, EqualTo, Literal(1)), true},
+		{"comparison_right", Comparison(Literal(1), EqualTo, ctxFn), true},
+		{"comparison_plain", Comparison(plainFn, EqualTo, Literal(1)), false},
+		{"arithmetic", Comparison(Arithmetic(Literal(1), Add, ctxFn), EqualTo, Literal(1)), true},
+		{"membership", Membership(ctxFn, Literal([]any{1})), true},
+		{"exists", &ExistExpr{query}, false},
+		{"query_arg", Function(newTrueFunc(), []FunctionExprArg{&FilterQueryExpr{query}}), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, usesContext(LogicalOr{LogicalAnd{tc.expr}}))
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package spec
 
-import (
-	"strings"
-)
+import "iter"
 
 // BasicExpr defines the interface for filter expressions.
 type BasicExpr interface {
@@ -28,8 +26,21 @@ func (la LogicalAnd) testFilter(current, root any) bool {
 	return true
 }
 
+// All returns an iterator over the expressions in la.
+//
+// Range over la itself to get indexes and expressions.
+func (la LogicalAnd) All() iter.Seq[BasicExpr] {
+	return func(yield func(BasicExpr) bool) {
+		for _, e := range la {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
 // writeTo writes the string representation of la to buf.
-func (la LogicalAnd) writeTo(buf *strings.Builder) {
+func (la LogicalAnd) writeTo(buf *writer) {
 	for i, e := range la {
 		e.writeTo(buf)
 		if i < len(la)-1 {
@@ -51,8 +62,35 @@ func (lo LogicalOr) testFilter(current, root any) bool {
 	return false
 }
 
+// All returns an iterator over the [LogicalAnd] expressions in lo.
+//
+// Range over lo itself to get indexes and expressions.
+func (lo LogicalOr) All() iter.Seq[LogicalAnd] {
+	return func(yield func(LogicalAnd) bool) {
+		for _, e := range lo {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Expressions returns an iterator over all the expressions in all the
+// [LogicalAnd] expressions in lo, in order.
+func (lo LogicalOr) Expressions() iter.Seq[BasicExpr] {
+	return func(yield func(BasicExpr) bool) {
+		for _, la := range lo {
+			for _, e := range la {
+				if !yield(e) {
+					return
+				}
+			}
+		}
+	}
+}
+
 // writeTo writes the string representation of lo to buf.
-func (lo LogicalOr) writeTo(buf *strings.Builder) {
+func (lo LogicalOr) writeTo(buf *writer) {
 	for i, e := range lo {
 		e.writeTo(buf)
 		if i < len(lo)-1 {
@@ -83,8 +121,13 @@ func Paren(or LogicalOr) *ParenExpr {
 	return &ParenExpr{LogicalOr: or}
 }
 
+// Inner returns the expression enclosed by the parentheses.
+func (p *ParenExpr) Inner() LogicalOr {
+	return p.LogicalOr
+}
+
 // writeTo writes a string representation of p to buf.
-func (p *ParenExpr) writeTo(buf *strings.Builder) {
+func (p *ParenExpr) writeTo(buf *writer) {
 	buf.WriteRune('(')
 	p.LogicalOr.writeTo(buf)
 	buf.WriteRune(')')
@@ -100,8 +143,14 @@ func NotParen(or LogicalOr) *NotParenExpr {
 	return &NotParenExpr{LogicalOr: or}
 }
 
+// Inner returns the expression enclosed by the parentheses, the result of
+// which np negates.
+func (np *NotParenExpr) Inner() LogicalOr {
+	return np.LogicalOr
+}
+
 // writeTo writes a string representation of p to buf.
-func (np *NotParenExpr) writeTo(buf *strings.Builder) {
+func (np *NotParenExpr) writeTo(buf *writer) {
 	buf.WriteString("!(")
 	np.LogicalOr.writeTo(buf)
 	buf.WriteRune(')')
@@ -124,14 +173,14 @@ func Existence(q *PathQuery) *ExistExpr {
 }
 
 // testFilter returns true if e.Query selects any results from current or
-// root.
+// root. Stops evaluation at the first result.
 func (e *ExistExpr) testFilter(current, root any) bool {
-	return len(e.Select(current, root)) > 0
+	return e.Exists(current, root)
 }
 
 // writeTo writes a string representation of e to buf.
-func (e *ExistExpr) writeTo(buf *strings.Builder) {
-	buf.WriteString(e.PathQuery.String())
+func (e *ExistExpr) writeTo(buf *writer) {
+	e.PathQuery.writeTo(buf)
 }
 
 // NonExistExpr represents a nonexistence expression.
@@ -145,13 +194,13 @@ func Nonexistence(q *PathQuery) *NonExistExpr {
 }
 
 // writeTo writes a string representation of ne to buf.
-func (ne NonExistExpr) writeTo(buf *strings.Builder) {
+func (ne NonExistExpr) writeTo(buf *writer) {
 	buf.WriteRune('!')
-	buf.WriteString(ne.PathQuery.String())
+	ne.PathQuery.writeTo(buf)
 }
 
 // testFilter returns true if ne.Query selects no results from current or
-// root.
+// root. Stops evaluation at the first result.
 func (ne NonExistExpr) testFilter(current, root any) bool {
-	return len(ne.Select(current, root)) == 0
+	return !ne.Exists(current, root)
 }
@@ -1,7 +1,7 @@
 package spec
 
 import (
-	"strings"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -96,6 +96,7 @@ func TestLogicalAndExpr(t *testing.T) {
 			andExpr := LogicalAnd(tc.expr)
 			a.Equal(tc.exp, andExpr.testFilter(tc.current, tc.root))
 			a.Equal(tc.str, bufString(andExpr))
+			a.True(slices.Equal(tc.expr, slices.Collect(andExpr.All())))
 		})
 	}
 }
@@ -181,16 +182,24 @@ func TestLogicalOrExpr(t *testing.T) {
 			a.Equal(tc.exp, orExpr.testFilter(tc.current, tc.root))
 			a.Equal(LogicalFrom(tc.exp), orExpr.evaluate(tc.current, tc.root))
 			a.Equal(tc.str, bufString(orExpr))
+			a.Equal(tc.expr, slices.Collect(orExpr.All()))
+			exprs := []BasicExpr{}
+			for _, la := range tc.expr {
+				exprs = append(exprs, la...)
+			}
+			a.True(slices.Equal(exprs, slices.Collect(orExpr.Expressions())))
 
 			// Test ParenExpr.
 			pExpr := Paren(orExpr)
 			a.Equal(tc.exp, pExpr.testFilter(tc.current, tc.root))
 			a.Equal("("+tc.str+")", bufString(pExpr))
+			a.Equal(orExpr, pExpr.Inner())
 
 			// Test NotParenExpr.
 			npExpr := NotParen(orExpr)
 			a.Equal(!tc.exp, npExpr.testFilter(tc.current, tc.root))
 			a.Equal("!("+tc.str+")", bufString(npExpr))
+			a.Equal(orExpr, npExpr.Inner())
 		})
 	}
 }
@@ -237,7 +246,7 @@ func TestExistExpr(t *testing.T) {
 			// Test existExpr.
 			exist := ExistExpr{tc.query}
 			a.Equal(tc.exp, exist.testFilter(tc.current, tc.root))
-			buf := new(strings.Builder)
+			buf := newWriter()
 			exist.writeTo(buf)
 			a.Equal(tc.query.String(), buf.String())
 
@@ -250,3 +259,26 @@ func TestExistExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestLogicalIteratorsStop(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	x := Existence(Query(false, []*Segment{Child(Name("x"))}))
+	y := Existence(Query(false, []*Segment{Child(Name("y"))}))
+	or := LogicalOr{{x, y}, {y, x}}
+
+	// Stop each iterator after the first item.
+	for e := range or[0].All() {
+		a.Equal(x, e)
+		break
+	}
+	for la := range or.All() {
+		a.Equal(or[0], la)
+		break
+	}
+	for e := range or.Expressions() {
+		a.Equal(x, e)
+		break
+	}
+}
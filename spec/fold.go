@@ -0,0 +1,56 @@
+package spec
+
+import "strings"
+
+// FoldNames wraps root so that name selectors, including those in the
+// singular queries of filter expressions, match member names
+// case-insensitively under Unicode case folding, so that $.FOO selects the
+// member named "foo", "Foo", or "FOO". A name selector still selects at most
+// one member: the one whose name matches exactly, if any, and otherwise the
+// first case-insensitive match in member order, or, for a map[string]any,
+// whose members have no order, the one with the lowest name. Located nodes
+// record the name of the member selected, not that of the selector.
+//
+// Pass the result as the root argument to the Select and SelectLocated
+// methods of [PathQuery], [Segment], and [Selector] for the duration of a
+// single evaluation.
+func FoldNames(root any) any {
+	if rootOf(root).foldsNames() {
+		return root
+	}
+	r := wrapRoot(root)
+	r.fold = true
+	return r
+}
+
+// foldMember returns the name and value of the member of obj that matches
+// name as described by [FoldNames] and true, or false if obj is not an
+// object or has no matching member.
+func foldMember(obj any, name string) (string, any, bool) {
+	switch obj := decodeOrdered(obj).(type) {
+	case map[string]any:
+		if val, ok := obj[name]; ok {
+			return name, val, true
+		}
+		var key string
+		found := false
+		for k := range obj {
+			if strings.EqualFold(k, name) && (!found || k < key) {
+				key, found = k, true
+			}
+		}
+		if found {
+			return key, obj[key], true
+		}
+	case OrderedMap:
+		if val, ok := obj.Get(name); ok {
+			return name, val, true
+		}
+		for _, m := range membersOf(obj) {
+			if strings.EqualFold(m.name, name) {
+				return m.name, m.val, true
+			}
+		}
+	}
+	return "", nil, false
+}
@@ -0,0 +1,74 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFoldNames(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	ordered := &OrderedObject{}
+	ordered.Set("Id", 1)
+	ordered.Set("ID", 2)
+	ordered.Set("iD", 3)
+	root := map[string]any{
+		"Items": []any{
+			map[string]any{"Kind": "a", "n": 1},
+			map[string]any{"KIND": "b", "n": 2},
+		},
+		"Want":    "b",
+		"ordered": ordered,
+	}
+
+	// $.items[?@.kind == $.want].N
+	query := Query(true, []*Segment{
+		Child(Name("items")),
+		Child(Filter(LogicalOr{LogicalAnd{Comparison(
+			SingularQuery(false, []Selector{Name("kind")}),
+			EqualTo,
+			SingularQuery(true, []Selector{Name("want")}),
+		)}})),
+		Child(Name("N")),
+	})
+	a.Empty(query.Select(nil, root))
+
+	folded := FoldNames(root)
+	a.Same(folded, FoldNames(folded))
+	a.Equal(root, rootValue(folded))
+	a.Nil(rootOf(folded).singularCache())
+	a.True(rootOf(folded).foldsNames())
+	a.False(rootOf(root).foldsNames())
+	a.Equal([]any{2}, query.Select(nil, folded))
+	a.Equal(
+		[]*LocatedNode{{Node: 2, Path: NormalizedPath{Name("Items"), Index(1), Name("n")}}},
+		query.SelectLocated(nil, folded, nil),
+	)
+
+	// Folding combines with the other root wrappers in any order.
+	for _, wrapped := range []any{
+		CacheSingular(folded),
+		FoldNames(CacheSingular(root)),
+		OverrideFunctions(folded, nil),
+		FoldNames(OverrideFunctions(CacheSingular(root), nil)),
+	} {
+		a.True(rootOf(wrapped).foldsNames())
+		a.Equal(root, rootValue(wrapped))
+		a.Equal([]any{2}, query.Select(nil, wrapped))
+	}
+
+	// Exact matches win, then the first match in member order.
+	sel := Name("ID")
+	a.Equal([]any{2}, sel.Select(ordered, folded))
+	sel = Name("id")
+	a.Equal([]any{1}, sel.Select(ordered, folded))
+	a.Equal(
+		[]*LocatedNode{{Node: 1, Path: NormalizedPath{Name("Id")}}},
+		sel.SelectLocated(ordered, folded, NormalizedPath{}),
+	)
+	a.Empty(sel.Select(ordered, root))
+	a.Empty(sel.Select([]any{1}, folded))
+	a.Empty(Name("nope").Select(ordered, folded))
+}
@@ -0,0 +1,227 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// format configures the string representation of queries.
+type format struct {
+	// quote is the quotation mark for names and string literals.
+	quote rune
+
+	// dots enables dot notation for names and wildcards where legal.
+	dots bool
+}
+
+// FormatOption configures the string representation of a query returned
+// by [PathQuery.Format].
+type FormatOption func(*format)
+
+// WithSingleQuotes quotes names and string literals with single quotation
+// marks rather than double quotation marks, as in $['store'][?@.a == 'x'].
+func WithSingleQuotes() FormatOption {
+	return func(f *format) { f.quote = '\'' }
+}
+
+// WithDotNotation writes child and descendant segments consisting of a
+// single name selector in dot notation, as in $.store..price, when the
+// name is a valid member name shorthand, and those consisting of a single
+// wildcard selector as .* and ..*. Other segments use bracket notation.
+func WithDotNotation() FormatOption {
+	return func(f *format) { f.dots = true }
+}
+
+// writer is the buffer to which queries and their components write their
+// string representations, formatted as configured by its format.
+type writer struct {
+	strings.Builder
+	format
+}
+
+// newWriter creates a writer formatted with the defaults, double quotation
+// marks and bracket notation, as modified by opt.
+func newWriter(opt ...FormatOption) *writer {
+	buf := &writer{format: format{quote: '"'}}
+	for _, o := range opt {
+		o(&buf.format)
+	}
+	return buf
+}
+
+// writeDotted writes a segment consisting of sel, a descendant segment if
+// descendant is true, in dot notation if buf is configured for dot
+// notation and sel allows it. Otherwise it writes nothing and returns
+// false.
+func (buf *writer) writeDotted(sel Selector, descendant bool) bool {
+	if !buf.dots {
+		return false
+	}
+	var str string
+	switch sel := sel.(type) {
+	case Name:
+		if !IsShorthand(string(sel)) {
+			return false
+		}
+		str = string(sel)
+	case WildcardSelector:
+		str = "*"
+	default:
+		return false
+	}
+	if descendant {
+		buf.WriteString("..")
+	} else {
+		buf.WriteByte('.')
+	}
+	buf.WriteString(str)
+	return true
+}
+
+// IsShorthand returns true if name is a valid JSONPath [member name
+// shorthand], so that a query can select it in dot notation, as in
+// $.name, rather than in bracket notation, as in $["name"]. A valid
+// shorthand starts with an ASCII letter, an underscore, or a non-ASCII
+// character, followed by any of those or ASCII digits.
+//
+// [member name shorthand]: https://www.rfc-editor.org/rfc/rfc9535#section-2.5.1.1
+func IsShorthand(name string) bool {
+	if name == "" || !utf8.ValidString(name) {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r >= utf8.RuneSelf:
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// QuoteSingle returns s as a single-quoted JSONPath [string literal] that
+// parses back to s, escaping characters as described by [Quote] except
+// that it escapes single rather than double quotation marks.
+//
+// [string literal]: https://www.rfc-editor.org/rfc/rfc9535#section-2.3.1.1
+func QuoteSingle(s string) string {
+	buf := new(strings.Builder)
+	writeQuoted(buf, s, '\'')
+	return buf.String()
+}
+
+// ErrUnquote errors are returned by [Unquote] for invalid string literals.
+var ErrUnquote = errors.New("jsonpath: invalid string literal")
+
+// Unquote interprets s as a single- or double-quoted JSONPath [string
+// literal], such as those returned by [Quote] and [QuoteSingle], and
+// returns the string value it represents. Returns an [ErrUnquote] error if
+// s is not a valid string literal.
+//
+// [string literal]: https://www.rfc-editor.org/rfc/rfc9535#section-2.3.1.1
+func Unquote(s string) (string, error) {
+	if len(s) < 2 || (s[0] != '"' && s[0] != '\'') {
+		return "", fmt.Errorf("%w: missing quotation mark at position 1", ErrUnquote)
+	}
+	q := s[0]
+	buf := new(strings.Builder)
+	for pos := 1; pos < len(s); {
+		r, size := utf8.DecodeRuneInString(s[pos:])
+		switch {
+		case r == rune(q):
+			if pos+size < len(s) {
+				return "", fmt.Errorf("%w: unexpected %q at position %v", ErrUnquote, s[pos+size], pos+size+1)
+			}
+			return buf.String(), nil
+		case r == '\\':
+			n, err := unescape(buf, s[pos:], q)
+			if err != nil {
+				return "", fmt.Errorf("%w: %w at position %v", ErrUnquote, err, pos+1)
+			}
+			pos += n
+			continue
+		case r < ' ':
+			return "", fmt.Errorf("%w: invalid control character %q at position %v", ErrUnquote, r, pos+1)
+		case r == utf8.RuneError && size == 1:
+			return "", fmt.Errorf("%w: invalid UTF-8 at position %v", ErrUnquote, pos+1)
+		}
+		buf.WriteRune(r)
+		pos += size
+	}
+	return "", fmt.Errorf("%w: unterminated string at position %v", ErrUnquote, len(s)+1)
+}
+
+// unescape writes the character represented by the escape sequence at the
+// start of s to buf, where q is the quotation mark that delimits the
+// string. Returns the length of the escape sequence.
+func unescape(buf *strings.Builder, s string, q byte) (int, error) {
+	if len(s) < 2 {
+		return 0, errors.New("unterminated escape")
+	}
+	switch esc := s[1]; esc {
+	case 'b':
+		buf.WriteByte('\b')
+	case 'f':
+		buf.WriteByte('\f')
+	case 'n':
+		buf.WriteByte('\n')
+	case 'r':
+		buf.WriteByte('\r')
+	case 't':
+		buf.WriteByte('\t')
+	case '/', '\\', q:
+		buf.WriteByte(esc)
+	case 'u':
+		r, n, err := unescapeUnicode(s)
+		if err != nil {
+			return 0, err
+		}
+		buf.WriteRune(r)
+		return n, nil
+	default:
+		return 0, fmt.Errorf("invalid escape %q", s[:2])
+	}
+	return 2, nil
+}
+
+// unescapeUnicode decodes the \uXXXX escape at the start of s, and the
+// \uXXXX low surrogate that follows if it is a high surrogate. Returns the
+// rune and the length of the escapes.
+func unescapeUnicode(s string) (rune, int, error) {
+	r, err := hexRune(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !utf16.IsSurrogate(r) {
+		return r, 6, nil
+	}
+	if len(s) < 12 || s[6:8] != `\u` {
+		return 0, 0, errors.New("missing low surrogate")
+	}
+	low, err := hexRune(s[6:])
+	if err != nil {
+		return 0, 0, err
+	}
+	if r = utf16.DecodeRune(r, low); r == utf8.RuneError {
+		return 0, 0, errors.New("invalid surrogate pair")
+	}
+	return r, 12, nil
+}
+
+// hexRune decodes the four hexadecimal digits of the \uXXXX escape at the
+// start of s.
+func hexRune(s string) (rune, error) {
+	if len(s) < 6 {
+		return 0, errors.New("invalid unicode escape")
+	}
+	r, err := strconv.ParseUint(s[2:6], 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid unicode escape %q", s[:6])
+	}
+	return rune(r), nil
+}
@@ -0,0 +1,148 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	// $.store.book[?@.author[0] == "it's" && @.isbn && !@..*]..["it's",*]..price.*["1st"][:2]
+	query := Query(true, []*Segment{
+		Child(Name("store")),
+		Child(Name("book")),
+		Child(Filter(LogicalOr{LogicalAnd{
+			Comparison(
+				SingularQuery(false, []Selector{Name("author"), Index(0)}),
+				EqualTo,
+				Literal("it's"),
+			),
+			Existence(Query(false, []*Segment{Child(Name("isbn"))})),
+			Nonexistence(Query(false, []*Segment{Descendant(Wildcard())})),
+		}})),
+		Descendant(Name("it's"), Wildcard()),
+		Descendant(Name("price")),
+		Child(Wildcard()),
+		Child(Name("1st")),
+		Child(Slice(0, 2)),
+	})
+
+	for _, tc := range []struct {
+		name string
+		opts []FormatOption
+		exp  string
+	}{
+		{
+			name: "default",
+			exp:  `$["store"]["book"][?@["author"][0] == "it's" && @["isbn"] && !@..[*]]..["it's",*]..["price"][*]["1st"][:2]`,
+		},
+		{
+			name: "single_quotes",
+			opts: []FormatOption{WithSingleQuotes()},
+			exp:  `$['store']['book'][?@['author'][0] == 'it\'s' && @['isbn'] && !@..[*]]..['it\'s',*]..['price'][*]['1st'][:2]`,
+		},
+		{
+			name: "dot_notation",
+			opts: []FormatOption{WithDotNotation()},
+			exp:  `$.store.book[?@.author[0] == "it's" && @.isbn && !@..*]..["it's",*]..price.*["1st"][:2]`,
+		},
+		{
+			name: "both",
+			opts: []FormatOption{WithDotNotation(), WithSingleQuotes()},
+			exp:  `$.store.book[?@.author[0] == 'it\'s' && @.isbn && !@..*]..['it\'s',*]..price.*['1st'][:2]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			a.Equal(tc.exp, query.Format(tc.opts...))
+		})
+	}
+
+	assert.Equal(t, query.String(), query.Format())
+}
+
+func TestIsShorthand(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, name := range []string{"a", "Z", "_", "a1", "_9_", "héllo", "日本", "true", "null"} {
+		a.True(IsShorthand(name), name)
+	}
+	for _, name := range []string{"", "1a", "a-b", "a b", "a.b", "$", "@", "a\x7f", "\xff"} {
+		a.False(IsShorthand(name), name)
+	}
+}
+
+func TestQuoteSingle(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Equal(`''`, QuoteSingle(""))
+	a.Equal(`'a"b'`, QuoteSingle(`a"b`))
+	a.Equal(`'it\'s'`, QuoteSingle("it's"))
+	a.Equal(`'\\\b\f\n\r\t\u001f'`, QuoteSingle("\\\b\f\n\r\t\x1f"))
+}
+
+func TestUnquote(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		str  string
+		exp  string
+		err  string
+	}{
+		{name: "empty_double", str: `""`, exp: ""},
+		{name: "empty_single", str: `''`, exp: ""},
+		{name: "double", str: `"it's"`, exp: "it's"},
+		{name: "single", str: `'say "hi"'`, exp: `say "hi"`},
+		{name: "escaped_double", str: `"\""`, exp: `"`},
+		{name: "escaped_single", str: `'\''`, exp: "'"},
+		{name: "escapes", str: `"\b\f\n\r\t\/\\"`, exp: "\b\f\n\r\t/\\"},
+		{name: "unicode", str: `"é\u001F"`, exp: "é\x1f"},
+		{name: "surrogate_pair", str: `"\uD834\uDD1E"`, exp: "𝄞"},
+		{name: "utf8", str: `'日本'`, exp: "日本"},
+		{name: "empty", str: ``, err: "missing quotation mark at position 1"},
+		{name: "unquoted", str: `abc`, err: "missing quotation mark at position 1"},
+		{name: "lone_quote", str: `"`, err: "missing quotation mark at position 1"},
+		{name: "unterminated", str: `"abc`, err: "unterminated string at position 5"},
+		{name: "mismatched", str: `"abc'`, err: "unterminated string at position 6"},
+		{name: "trailing", str: `"a"b`, err: "unexpected 'b' at position 4"},
+		{name: "wrong_escaped_quote", str: `"\'"`, err: `invalid escape "\\'" at position 2`},
+		{name: "bad_escape", str: `'\x'`, err: `invalid escape "\\x" at position 2`},
+		{name: "unterminated_escape", str: `'\`, err: "unterminated escape at position 2"},
+		{name: "short_unicode", str: `'\u00'`, err: "invalid unicode escape at position 2"},
+		{name: "bad_unicode", str: `'\u00zz'`, err: `invalid unicode escape "\\u00zz" at position 2`},
+		{name: "lone_surrogate", str: `'\uD834'`, err: "missing low surrogate at position 2"},
+		{name: "bad_low_surrogate", str: `'\uD834\u0041'`, err: "invalid surrogate pair at position 2"},
+		{name: "bad_low_escape", str: `'\uD834\uzzzz'`, err: `invalid unicode escape "\\uzzzz" at position 2`},
+		{name: "control", str: "'a\nb'", err: `invalid control character '\n' at position 3`},
+		{name: "invalid_utf8", str: "'\xff'", err: "invalid UTF-8 at position 2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			str, err := Unquote(tc.str)
+			if tc.err != "" {
+				a.EqualError(err, "jsonpath: invalid string literal: "+tc.err)
+				a.ErrorIs(err, ErrUnquote)
+				a.Empty(str)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tc.exp, str)
+
+			// Round-trip through both quoting functions.
+			str, err = Unquote(Quote(tc.exp))
+			a.NoError(err)
+			a.Equal(tc.exp, str)
+			str, err = Unquote(QuoteSingle(tc.exp))
+			a.NoError(err)
+			a.Equal(tc.exp, str)
+		})
+	}
+}
@@ -3,8 +3,11 @@ package spec
 //go:generate stringer -linecomment -output function_string.go -type LogicalType,PathType,FuncType
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"strings"
+	"maps"
+	"slices"
 )
 
 // PathType represents the types of filter expression values.
@@ -85,22 +88,39 @@ func (NodesType) PathType() PathType { return PathNodes }
 // FuncType returns FuncNodeList. Defined by the JSONPathValue interface.
 func (NodesType) FuncType() FuncType { return FuncNodeList }
 
+// ErrConversion errors are returned by [NodesFromErr], [LogicalFromErr],
+// and [ValueFromErr] for values they cannot convert.
+var ErrConversion = errors.New("jsonpath: cannot convert value")
+
 // NodesFrom attempts to convert value to a NodesType and panics if it cannot.
+// Function extensions may use it to convert arguments validated at parse
+// time, in which case a panic indicates a programming error. Use
+// [NodesFromErr] to convert other values.
 func NodesFrom(value JSONPathValue) NodesType {
+	nodes, err := NodesFromErr(value)
+	if err != nil {
+		panic(fmt.Sprintf("unexpected argument of type %T", value))
+	}
+	return nodes
+}
+
+// NodesFromErr attempts to convert value to a NodesType. Returns an
+// [ErrConversion] error if it cannot.
+func NodesFromErr(value JSONPathValue) (NodesType, error) {
 	switch v := value.(type) {
 	case NodesType:
-		return v
+		return v, nil
 	case *ValueType:
-		return NodesType([]any{v.any})
+		return NodesType([]any{v.any}), nil
 	case nil:
-		return NodesType([]any{})
+		return NodesType([]any{}), nil
 	default:
-		panic(fmt.Sprintf("unexpected argument of type %T", v))
+		return nil, fmt.Errorf("%w of type %T to NodesType", ErrConversion, v)
 	}
 }
 
 // writeTo writes a string representation of the NodesType to buf.
-func (NodesType) writeTo(buf *strings.Builder) {
+func (NodesType) writeTo(buf *writer) {
 	buf.WriteString("NodesType")
 }
 
@@ -123,27 +143,39 @@ func (LogicalType) PathType() PathType { return PathLogical }
 func (LogicalType) FuncType() FuncType { return FuncLogical }
 
 // LogicalFrom attempts to convert value to a LogicalType and panics if it
-// cannot.
+// cannot. Function extensions may use it to convert arguments validated at
+// parse time, in which case a panic indicates a programming error. Use
+// [LogicalFromErr] to convert other values.
 func LogicalFrom(value any) LogicalType {
+	lt, err := LogicalFromErr(value)
+	if err != nil {
+		panic(fmt.Sprintf("unexpected argument of type %T", value))
+	}
+	return lt
+}
+
+// LogicalFromErr attempts to convert value to a LogicalType. Returns an
+// [ErrConversion] error if it cannot.
+func LogicalFromErr(value any) (LogicalType, error) {
 	switch v := value.(type) {
 	case LogicalType:
-		return v
+		return v, nil
 	case NodesType:
-		return LogicalFrom(len(v) > 0)
+		return LogicalFrom(len(v) > 0), nil
 	case bool:
 		if v {
-			return LogicalTrue
+			return LogicalTrue, nil
 		}
-		return LogicalFalse
+		return LogicalFalse, nil
 	case nil:
-		return LogicalFalse
+		return LogicalFalse, nil
 	default:
-		panic(fmt.Sprintf("unexpected argument of type %T", v))
+		return LogicalFalse, fmt.Errorf("%w of type %T to LogicalType", ErrConversion, v)
 	}
 }
 
 // writeTo writes a string representation of lt to buf.
-func (lt LogicalType) writeTo(buf *strings.Builder) {
+func (lt LogicalType) writeTo(buf *writer) {
 	buf.WriteString(lt.String())
 }
 
@@ -169,14 +201,27 @@ func (*ValueType) PathType() PathType { return PathValue }
 func (*ValueType) FuncType() FuncType { return FuncValue }
 
 // ValueFrom attempts to convert value to a ValueType and panics if it cannot.
+// Function extensions may use it to convert arguments validated at parse
+// time, in which case a panic indicates a programming error. Use
+// [ValueFromErr] to convert other values.
 func ValueFrom(value JSONPathValue) *ValueType {
+	vt, err := ValueFromErr(value)
+	if err != nil {
+		panic(fmt.Sprintf("unexpected argument of type %T", value))
+	}
+	return vt
+}
+
+// ValueFromErr attempts to convert value to a ValueType. Returns an
+// [ErrConversion] error if it cannot.
+func ValueFromErr(value JSONPathValue) (*ValueType, error) {
 	switch v := value.(type) {
 	case *ValueType:
-		return v
+		return v, nil
 	case nil:
-		return nil
+		return nil, nil //nolint:nilnil
 	}
-	panic(fmt.Sprintf("unexpected argument of type %T", value))
+	return nil, fmt.Errorf("%w of type %T to ValueType", ErrConversion, value)
 }
 
 // Returns true if vt.any is truthy. Defined by the BasicExpr interface.
@@ -210,13 +255,16 @@ func (vt *ValueType) testFilter(_, _ any) bool {
 		return v != float32(0)
 	case float64:
 		return v != float64(0)
+	case json.Number:
+		c, ok := CompareNumbers(v, 0)
+		return !ok || c != 0
 	default:
 		return true
 	}
 }
 
 // writeTo writes a string representation of vt to buf.
-func (vt *ValueType) writeTo(buf *strings.Builder) {
+func (vt *ValueType) writeTo(buf *writer) {
 	buf.WriteString("ValueType")
 }
 
@@ -257,11 +305,41 @@ func (la *LiteralArg) ResultType() FuncType {
 }
 
 // writeTo writes a string representation of la to buf.
-func (la *LiteralArg) writeTo(buf *strings.Builder) {
-	if la.literal == nil {
+func (la *LiteralArg) writeTo(buf *writer) {
+	writeLiteral(buf, la.literal)
+}
+
+// writeLiteral writes a string representation of lit to buf, writing a
+// []any as an array literal and a map[string]any as an object literal with
+// members sorted by name.
+func writeLiteral(buf *writer, lit any) {
+	switch lit := lit.(type) {
+	case nil:
 		buf.WriteString("null")
-	} else {
-		fmt.Fprintf(buf, "%#v", la.literal)
+	case string:
+		writeQuoted(buf, lit, buf.quote)
+	case []any:
+		buf.WriteByte('[')
+		for i, v := range lit {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeLiteral(buf, v)
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		buf.WriteByte('{')
+		for i, k := range slices.Sorted(maps.Keys(lit)) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeQuoted(buf, k, buf.quote)
+			buf.WriteByte(':')
+			writeLiteral(buf, lit[k])
+		}
+		buf.WriteByte('}')
+	default:
+		fmt.Fprintf(buf, "%#v", lit)
 	}
 }
 
@@ -286,23 +364,42 @@ func SingularQuery(root bool, selectors []Selector) *SingularQueryExpr {
 	return &SingularQueryExpr{relative: !root, selectors: selectors}
 }
 
+// Selectors returns the Name and Index selectors of sq.
+func (sq *SingularQueryExpr) Selectors() []Selector {
+	return sq.selectors
+}
+
+// IsRoot returns true if sq selects from the root node ($) and false if it
+// selects from the current node (@).
+func (sq *SingularQueryExpr) IsRoot() bool {
+	return !sq.relative
+}
+
 // evaluate returns a [ValueType] containing the return value of executing sq.
 // Defined by the [FunctionExprArg] interface.
 func (sq *SingularQueryExpr) evaluate(current, root any) JSONPathValue {
-	target := root
 	if sq.relative {
-		target = current
+		return sq.selectFrom(current, root)
+	}
+	if c := rootOf(root).singularCache(); c != nil {
+		return c.value(sq, root)
 	}
+	return sq.selectFrom(rootValue(root), root)
+}
 
+// selectFrom returns a [ValueType] containing the value sq selects from
+// target, or nil if it selects nothing. Passes root, which may be wrapped by
+// [FoldNames], to its selectors.
+func (sq *SingularQueryExpr) selectFrom(target, root any) JSONPathValue {
 	for _, seg := range sq.selectors {
-		res := seg.Select(target, nil)
+		res := seg.Select(target, root)
 		if len(res) == 0 {
 			return nil
 		}
 		target = res[0]
 	}
 
-	return &ValueType{target}
+	return &ValueType{decodeRaw(target)}
 }
 
 // ResultType returns FuncSingularQuery. Defined by the [FunctionExprArg]
@@ -318,16 +415,19 @@ func (sq *SingularQueryExpr) asValue(current, root any) JSONPathValue {
 }
 
 // writeTo writes a string representation of sq to buf.
-func (sq *SingularQueryExpr) writeTo(buf *strings.Builder) {
+func (sq *SingularQueryExpr) writeTo(buf *writer) {
 	if sq.relative {
 		buf.WriteRune('@')
 	} else {
 		buf.WriteRune('$')
 	}
 
-	for _, seg := range sq.selectors {
+	for _, sel := range sq.selectors {
+		if buf.writeDotted(sel, false) {
+			continue
+		}
 		buf.WriteRune('[')
-		seg.writeTo(buf)
+		writeSelector(buf, sel)
 		buf.WriteRune(']')
 	}
 }
@@ -358,15 +458,16 @@ func (fq *FilterQueryExpr) ResultType() FuncType {
 }
 
 // writeTo writes a string representation of fq to buf.
-func (fq *FilterQueryExpr) writeTo(buf *strings.Builder) {
-	buf.WriteString(fq.PathQuery.String())
+func (fq *FilterQueryExpr) writeTo(buf *writer) {
+	fq.PathQuery.writeTo(buf)
 }
 
 // FunctionExpr represents a function expression, consisting of a named
 // function and its arguments.
 type FunctionExpr struct {
-	args []FunctionExprArg
-	fn   PathFunction
+	args     []FunctionExprArg
+	fn       PathFunction
+	prepared func(args []JSONPathValue) JSONPathValue
 }
 
 // PathFunction represents a JSONPath function. See
@@ -377,14 +478,41 @@ type PathFunction interface {
 	Evaluate(args []JSONPathValue) JSONPathValue
 }
 
+// PreparedFunction is a [PathFunction] that prepares an evaluator for the
+// arguments of each function expression that calls it, such as to compile a
+// regular expression from a literal argument once rather than on every
+// evaluation. [Function] calls Prepare for each expression it creates.
+type PreparedFunction interface {
+	PathFunction
+
+	// Prepare returns a function to execute in place of Evaluate for calls
+	// with args, or nil to execute Evaluate.
+	Prepare(args []FunctionExprArg) func(args []JSONPathValue) JSONPathValue
+}
+
 // Function creates an returns a new function expression that will execute fn
-// against the return values of args.
+// against the return values of args. If fn is a [PreparedFunction], the
+// expression executes the function fn.Prepare returns for args, if any.
 func Function(fn PathFunction, args []FunctionExprArg) *FunctionExpr {
-	return &FunctionExpr{args: args, fn: fn}
+	fe := &FunctionExpr{args: args, fn: fn}
+	if p, ok := fn.(PreparedFunction); ok {
+		fe.prepared = p.Prepare(args)
+	}
+	return fe
+}
+
+// Name returns the name of fe's function.
+func (fe *FunctionExpr) Name() string {
+	return fe.fn.Name()
+}
+
+// Args returns fe's arguments.
+func (fe *FunctionExpr) Args() []FunctionExprArg {
+	return fe.args
 }
 
 // writeTo writes the string representation of fe to buf.
-func (fe *FunctionExpr) writeTo(buf *strings.Builder) {
+func (fe *FunctionExpr) writeTo(buf *writer) {
 	buf.WriteString(fe.fn.Name() + "(")
 	for i, arg := range fe.args {
 		arg.writeTo(buf)
@@ -395,14 +523,27 @@ func (fe *FunctionExpr) writeTo(buf *strings.Builder) {
 	buf.WriteRune(')')
 }
 
-// evaluate returns a [NodesType] containing the results of executing each
-// argument in fe.args. Defined by the [FunctionExprArg] interface.
+// evaluate returns the result of executing fe's function against the
+// results of evaluating each argument in fe.args, or of executing its
+// override if root was returned by [OverrideFunctions]. Passes an
+// [EvalContext] for current and root to a [ContextFunction] that uses it,
+// and executes the prepared function of a [PreparedFunction], if any.
+// Defined by the [FunctionExprArg] interface.
 func (fe *FunctionExpr) evaluate(current, root any) JSONPathValue {
 	res := []JSONPathValue{}
 	for _, a := range fe.args {
 		res = append(res, a.evaluate(current, root))
 	}
 
+	if fn := rootOf(root).override(fe.fn.Name()); fn != nil {
+		return fn(res)
+	}
+	if fn, ok := fe.fn.(ContextFunction); ok && fn.UsesContext() {
+		return fn.EvaluateContext(contextOf(current, root), res)
+	}
+	if fe.prepared != nil {
+		return fe.prepared(res)
+	}
 	return fe.fn.Evaluate(res)
 }
 
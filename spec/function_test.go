@@ -1,15 +1,15 @@
 package spec
 
 import (
+	"encoding/json"
 	"fmt"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func bufString(sw stringWriter) string {
-	buf := new(strings.Builder)
+	buf := newWriter()
 	sw.writeTo(buf)
 	return buf.String()
 }
@@ -110,9 +110,16 @@ func TestNodesType(t *testing.T) {
 			t.Parallel()
 			if tc.err != "" {
 				a.PanicsWithValue(tc.err, func() { NodesFrom(tc.from) })
+				nt, err := NodesFromErr(tc.from)
+				a.ErrorIs(err, ErrConversion)
+				a.EqualError(err, fmt.Sprintf("jsonpath: cannot convert value of type %T to NodesType", tc.from))
+				a.Nil(nt)
 				return
 			}
 			nt := NodesFrom(tc.from)
+			nt2, err := NodesFromErr(tc.from)
+			a.NoError(err)
+			a.Equal(nt, nt2)
 			a.Equal(tc.exp, nt)
 			a.Equal(PathNodes, nt.PathType())
 			a.Equal(FuncNodeList, nt.FuncType())
@@ -145,9 +152,16 @@ func TestLogicalType(t *testing.T) {
 			t.Parallel()
 			if tc.err != "" {
 				a.PanicsWithValue(tc.err, func() { LogicalFrom(tc.from) })
+				lt, err := LogicalFromErr(tc.from)
+				a.ErrorIs(err, ErrConversion)
+				a.EqualError(err, fmt.Sprintf("jsonpath: cannot convert value of type %T to LogicalType", tc.from))
+				a.Equal(LogicalFalse, lt)
 				return
 			}
 			lt := LogicalFrom(tc.from)
+			lt2, err := LogicalFromErr(tc.from)
+			a.NoError(err)
+			a.Equal(lt, lt2)
 			a.Equal(tc.exp, lt)
 			a.Equal(PathLogical, lt.PathType())
 			a.Equal(FuncLogical, lt.FuncType())
@@ -199,6 +213,10 @@ func TestValueType(t *testing.T) {
 		{"float32_zero", float32(0), false},
 		{"float64", float64(1), true},
 		{"float64_zero", float64(0), false},
+		{"json_number", json.Number("0.5"), true},
+		{"json_number_zero", json.Number("0"), false},
+		{"json_number_zero_float", json.Number("-0.0e1"), false},
+		{"json_number_invalid", json.Number("x"), true},
 		{"object", map[string]any{}, true},
 		{"array", []any{}, true},
 		{"struct", struct{}{}, true},
@@ -234,10 +252,17 @@ func TestValueTypeFrom(t *testing.T) {
 			t.Parallel()
 			if tc.err != "" {
 				a.PanicsWithValue(tc.err, func() { ValueFrom(tc.val) })
+				val, err := ValueFromErr(tc.val)
+				a.ErrorIs(err, ErrConversion)
+				a.EqualError(err, fmt.Sprintf("jsonpath: cannot convert value of type %T to ValueType", tc.val))
+				a.Nil(val)
 				return
 			}
 			val := ValueFrom(tc.val)
 			a.Equal(tc.exp, val)
+			val, err := ValueFromErr(tc.val)
+			a.NoError(err)
+			a.Equal(tc.exp, val)
 		})
 	}
 }
@@ -317,10 +342,15 @@ func TestLiteralArg(t *testing.T) {
 		str     string
 	}{
 		{"string", "hi", `"hi"`},
+		{"string_escapes", "\"\\\a\x1f'", `"\"\\\u0007\u001f'"`},
 		{"number", 42, "42"},
 		{"true", true, "true"},
 		{"false", false, "false"},
 		{"null", nil, "null"},
+		{"empty_array", []any{}, "[]"},
+		{"array", []any{"a", int64(1), 2.5, true, nil, []any{}}, `["a",1,2.5,true,null,[]]`},
+		{"empty_object", map[string]any{}, "{}"},
+		{"object", map[string]any{"b": []any{"x"}, "a": map[string]any{"'": nil}}, `{"a":{"'":null},"b":["x"]}`},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
@@ -394,12 +424,15 @@ func TestSingularQuery(t *testing.T) {
 
 			// Start with absolute query.
 			a.False(sq.relative)
+			a.True(sq.IsRoot())
+			a.Equal(tc.selectors, sq.Selectors())
 			a.Equal(tc.exp, sq.evaluate(nil, tc.input))
 			a.Equal(tc.exp, sq.asValue(nil, tc.input))
 			a.Equal("$"+tc.str, bufString(sq))
 
 			// Try a relative query.
 			sq.relative = true
+			a.False(sq.IsRoot())
 			a.Equal(tc.exp, sq.evaluate(tc.input, nil))
 			a.Equal(tc.exp, sq.asValue(tc.input, nil))
 			a.Equal("@"+tc.str, bufString(sq))
@@ -449,7 +482,7 @@ func TestFilterQuery(t *testing.T) {
 		},
 		{
 			name:     "current_wildcard",
-			query:    Query(false, []*Segment{Child(Wildcard)}),
+			query:    Query(false, []*Segment{Child(Wildcard())}),
 			current:  []any{13, 2, []any{4}},
 			exp:      []any{13, 2, []any{4}},
 			typeKind: FuncNodeList,
@@ -515,9 +548,9 @@ func newNodesFunc() *testFunc {
 // Mock up a valid JSONPathValue that returns a new type.
 type newValueType struct{}
 
-func (newValueType) PathType() PathType         { return PathType(15) }
-func (newValueType) FuncType() FuncType         { return FuncType(16) }
-func (newValueType) writeTo(b *strings.Builder) { b.WriteString("FuncType(16)") }
+func (newValueType) PathType() PathType { return PathType(15) }
+func (newValueType) FuncType() FuncType { return FuncType(16) }
+func (newValueType) writeTo(b *writer)  { b.WriteString("FuncType(16)") }
 
 func newTypeFunc() *testFunc {
 	return &testFunc{
@@ -586,6 +619,8 @@ func TestFunctionExpr(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			fe := Function(tc.fn, tc.args)
+			a.Equal(tc.fn.Name(), fe.Name())
+			a.Equal(tc.args, fe.Args())
 			a.Equal(tc.fn.result, fe.ResultType())
 			a.Equal(tc.exp, fe.evaluate(tc.current, tc.root))
 			a.Equal(tc.exp, fe.asValue(tc.current, tc.root))
@@ -595,3 +630,47 @@ func TestFunctionExpr(t *testing.T) {
 		})
 	}
 }
+
+// Mock up a prepared function.
+type testPreparedFunc struct {
+	testFunc
+	prepare func(args []FunctionExprArg) func([]JSONPathValue) JSONPathValue
+}
+
+func (tf *testPreparedFunc) Prepare(args []FunctionExprArg) func([]JSONPathValue) JSONPathValue {
+	return tf.prepare(args)
+}
+
+func TestPreparedFunction(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Prepare an evaluator for literal arguments only.
+	prepared := 0
+	fn := &testPreparedFunc{
+		testFunc: *newValueFunc("evaluated"),
+		prepare: func(args []FunctionExprArg) func([]JSONPathValue) JSONPathValue {
+			prepared++
+			if lit, ok := args[0].(*LiteralArg); ok {
+				return func([]JSONPathValue) JSONPathValue { return Value(lit.Value()) }
+			}
+			return nil
+		},
+	}
+
+	lit := Function(fn, []FunctionExprArg{Literal("prepared")})
+	a.Equal(1, prepared)
+	a.Equal(Value("prepared"), lit.evaluate(nil, nil))
+	a.Equal(Value("prepared"), lit.evaluate(nil, nil))
+	a.Equal(1, prepared)
+
+	query := Function(fn, []FunctionExprArg{SingularQuery(false, []Selector{Name("x")})})
+	a.Equal(2, prepared)
+	a.Equal(Value("evaluated"), query.evaluate(map[string]any{"x": 1}, nil))
+
+	// Overrides replace prepared functions.
+	root := OverrideFunctions(nil, map[string]func([]JSONPathValue) JSONPathValue{
+		fn.Name(): func([]JSONPathValue) JSONPathValue { return Value("override") },
+	})
+	a.Equal(Value("override"), lit.evaluate(nil, root))
+}
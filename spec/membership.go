@@ -0,0 +1,56 @@
+package spec
+
+import "slices"
+
+// MembershipExpr represents a test for the membership of a value in an
+// array, as in @.status in ["active", "pending"], an extension to RFC 9535
+// enabled by the parser's WithMembership option. It is equivalent to a
+// chain of == comparisons joined by ||, one for each element of the array.
+type MembershipExpr struct {
+	// An expression that produces the value to look for.
+	Left CompVal
+	// An expression that produces the array in which to look for it.
+	Right CompVal
+}
+
+// Membership creates and returns a new MembershipExpr that tests whether
+// the value of left is a member of the array produced by right.
+func Membership(left, right CompVal) *MembershipExpr {
+	return &MembershipExpr{left, right}
+}
+
+// writeTo writes a string representation of me to buf.
+func (me *MembershipExpr) writeTo(buf *writer) {
+	me.Left.writeTo(buf)
+	buf.WriteString(" in ")
+	me.Right.writeTo(buf)
+}
+
+// testFilter returns true if the value of me.Left relative to current and
+// root equals an element of the array produced by me.Right, comparing them
+// as [ComparisonExpr] does for ==, including by the keys of the [Collation]
+// of root, if it is wrapped by [Collate]. Returns false if either produces
+// Nothing, or if me.Right produces a value other than an array.
+func (me *MembershipExpr) testFilter(current, root any) bool {
+	left, ok := me.Left.asValue(current, root).(*ValueType)
+	if !ok {
+		return false
+	}
+	right, ok := me.Right.asValue(current, root).(*ValueType)
+	if !ok {
+		return false
+	}
+	arr, ok := decodeArray(right.any).([]any)
+	if !ok {
+		return false
+	}
+	if key := rootOf(root).collation(); key != nil {
+		needle := collateValue(left.any, key)
+		return slices.ContainsFunc(arr, func(elem any) bool {
+			return valueEqualTo(needle, collateValue(elem, key))
+		})
+	}
+	return slices.ContainsFunc(arr, func(elem any) bool {
+		return valueEqualTo(left.any, elem)
+	})
+}
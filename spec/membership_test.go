@@ -0,0 +1,134 @@
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembershipExpr(t *testing.T) {
+	t.Parallel()
+
+	status := SingularQuery(false, []Selector{Name("status")})
+	list := SingularQuery(true, []Selector{Name("list")})
+	root := map[string]any{
+		"list": []any{"active", 1, []any{"x"}},
+		"raw":  json.RawMessage(`["active", 2]`),
+		"obj":  map[string]any{"active": true},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		left    CompVal
+		right   CompVal
+		current any
+		exp     bool
+		str     string
+		raw     bool
+	}{
+		{
+			name:    "literal_array",
+			left:    status,
+			right:   Literal([]any{"active", "pending"}),
+			current: map[string]any{"status": "pending"},
+			exp:     true,
+			str:     `@["status"] in ["active","pending"]`,
+		},
+		{
+			name:    "literal_array_missing",
+			left:    status,
+			right:   Literal([]any{"active", "pending"}),
+			current: map[string]any{"status": "closed"},
+			str:     `@["status"] in ["active","pending"]`,
+		},
+		{
+			name:    "empty_array",
+			left:    status,
+			right:   Literal([]any{}),
+			current: map[string]any{"status": "closed"},
+			str:     `@["status"] in []`,
+		},
+		{
+			name:    "numbers_by_value",
+			left:    status,
+			right:   Literal([]any{int64(1), 2.5}),
+			current: map[string]any{"status": json.Number("1.0")},
+			exp:     true,
+			str:     `@["status"] in [1,2.5]`,
+		},
+		{
+			name:    "arrays_deeply",
+			left:    status,
+			right:   list,
+			current: map[string]any{"status": []any{"x"}},
+			exp:     true,
+			str:     `@["status"] in $["list"]`,
+		},
+		{
+			name:    "query_array",
+			left:    Literal(1.0),
+			right:   list,
+			current: map[string]any{},
+			exp:     true,
+			str:     `1 in $["list"]`,
+		},
+		{
+			name:    "raw_array",
+			raw:     true,
+			left:    Literal(2),
+			right:   SingularQuery(true, []Selector{Name("raw")}),
+			current: map[string]any{},
+			exp:     true,
+			str:     `2 in $["raw"]`,
+		},
+		{
+			name:    "nothing_left",
+			left:    status,
+			right:   Literal([]any{nil}),
+			current: map[string]any{},
+			str:     `@["status"] in [null]`,
+		},
+		{
+			name:    "null_left",
+			left:    status,
+			right:   Literal([]any{nil}),
+			current: map[string]any{"status": nil},
+			exp:     true,
+			str:     `@["status"] in [null]`,
+		},
+		{
+			name:    "nothing_right",
+			left:    status,
+			right:   SingularQuery(true, []Selector{Name("nope")}),
+			current: map[string]any{"status": "active"},
+			str:     `@["status"] in $["nope"]`,
+		},
+		{
+			name:    "object_right",
+			left:    status,
+			right:   SingularQuery(true, []Selector{Name("obj")}),
+			current: map[string]any{"status": "active"},
+			str:     `@["status"] in $["obj"]`,
+		},
+		{
+			name:    "string_right",
+			left:    Literal("a"),
+			right:   Literal("abc"),
+			current: map[string]any{},
+			str:     `"a" in "abc"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if tc.raw {
+				skipRaw(t)
+			}
+			a := assert.New(t)
+
+			expr := Membership(tc.left, tc.right)
+			a.Equal(tc.exp, expr.testFilter(tc.current, root))
+			a.Equal(tc.str, bufString(expr))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package spec
+
+// Array is implemented by JSON array types other than []any, such as the
+// sequences of a custom data model. Selectors select the elements of an
+// Array as they do those of a []any, looking up single elements by index
+// and copying the elements into a []any to iterate over them.
+type Array interface {
+	// Len returns the number of elements.
+	Len() int
+
+	// Index returns the element at index i, where 0 <= i < Len().
+	Index(i int) any
+}
+
+// Adapter is implemented by values of custom data models that adapt
+// themselves to the JSON data model when selectors select from them or
+// filter expressions evaluate them.
+type Adapter interface {
+	// Adapt returns the JSON value equivalent to the value: nil, a bool,
+	// string, or number, a []any, map[string]any, [OrderedMap], or [Array].
+	// It must not return a [encoding/json.RawMessage] or another Adapter.
+	Adapt() any
+}
+
+// decodeArray decodes val like [decodeRaw], except that it copies the
+// elements of an [Array] into a []any, for use by selectors and operators
+// that iterate over them.
+func decodeArray(val any) any {
+	val = decodeRaw(val)
+	if arr, ok := val.(Array); ok {
+		return elementsOf(arr)
+	}
+	return val
+}
+
+// elementsOf returns the elements of arr in a new slice.
+func elementsOf(arr Array) []any {
+	res := make([]any, arr.Len())
+	for i := range res {
+		res[i] = arr.Index(i)
+	}
+	return res
+}
@@ -0,0 +1,69 @@
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testArray is an Array of test values.
+type testArray []any
+
+func (a testArray) Len() int        { return len(a) }
+func (a testArray) Index(i int) any { return a[i] }
+
+// testAdapter is an Adapter that adapts to its value.
+type testAdapter struct{ val any }
+
+func (a testAdapter) Adapt() any { return a.val }
+
+func TestDecodeArray(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		val  any
+		exp  any
+	}{
+		{"scalar", "x", "x"},
+		{"slice", []any{1, 2}, []any{1, 2}},
+		{"raw_array", json.RawMessage(`[1, "x"]`), []any{float64(1), "x"}},
+		{"array", testArray{1, testAdapter{2}}, []any{1, testAdapter{2}}},
+		{"empty_array", testArray{}, []any{}},
+		{"adapter_scalar", testAdapter{true}, true},
+		{"adapter_array", testAdapter{testArray{"a"}}, []any{"a"}},
+		{"adapter_object", testAdapter{map[string]any{"a": 1}}, map[string]any{"a": 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if containsRaw(tc.val) {
+				skipRaw(t)
+			}
+			assert.Equal(t, tc.exp, decodeArray(tc.val))
+		})
+	}
+}
+
+func TestArraySelectors(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	arr := testArray{"a", testAdapter{"b"}, "c"}
+	input := testAdapter{arr}
+
+	a.Equal([]any{testAdapter{"b"}}, Index(1).Select(input, nil))
+	a.Equal([]any{"c"}, Index(-1).Select(input, nil))
+	a.Equal([]any{}, Index(3).Select(input, nil))
+	a.Equal(
+		[]*LocatedNode{{Path: NormalizedPath{Index(2)}, Node: "c"}},
+		Index(-1).SelectLocated(arr, nil, NormalizedPath{}),
+	)
+	a.Equal([]any{"a", testAdapter{"b"}, "c"}, Wildcard().Select(input, nil))
+	a.Equal([]any{"c", testAdapter{"b"}, "a"}, Slice(nil, nil, -1).Select(arr, nil))
+
+	val, ok := NormalizedPath{Index(1)}.Resolve(input)
+	a.True(ok)
+	a.Equal(testAdapter{"b"}, val)
+	a.Equal(&NodeInfo{Type: JSONArray, Len: 3}, InfoOf(input))
+}
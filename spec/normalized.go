@@ -1,17 +1,38 @@
 package spec
 
 import (
+	"bufio"
 	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
 	"strings"
 )
 
+// normalWriter defines the interface for buffers to which normalized paths
+// write themselves. Implemented by [strings.Builder] and [bufio.Writer].
+type normalWriter interface {
+	io.Writer
+	io.StringWriter
+	WriteRune(r rune) (int, error)
+}
+
 // NormalSelector represents a single selector in a normalized path.
 // Implemented by [Name] and [Index].
 type NormalSelector interface {
 	// writeNormalizedTo writes n to buf formatted as a [normalized path] element.
 	//
 	// [normalized path]: https://www.rfc-editor.org/rfc/rfc9535#section-2.7
-	writeNormalizedTo(buf *strings.Builder)
+	writeNormalizedTo(buf normalWriter)
+
+	// writePointerTo writes n to buf formatted as a [JSON Pointer] reference
+	// token, including the leading slash.
+	//
+	// [JSON Pointer]: https://www.rfc-editor.org/rfc/rfc6901
+	writePointerTo(buf normalWriter)
 }
 
 // NormalizedPath represents a normalized path identifying a single value in a
@@ -23,13 +44,87 @@ type NormalizedPath []NormalSelector
 // String returns the string representation of np.
 func (np NormalizedPath) String() string {
 	buf := new(strings.Builder)
+	np.writeTo(buf)
+	return buf.String()
+}
+
+// writeTo writes the string representation of np to buf.
+func (np NormalizedPath) writeTo(buf normalWriter) {
 	buf.WriteRune('$')
 	for _, e := range np {
 		e.writeNormalizedTo(buf)
 	}
+}
+
+// Pointer returns the [RFC 6901] JSON Pointer representation of np, such as
+// /store/book/0 for $['store']['book'][0]. Returns the empty string, which
+// points to the entire document, for an empty np.
+//
+// [RFC 6901]: https://www.rfc-editor.org/rfc/rfc6901
+func (np NormalizedPath) Pointer() string {
+	buf := new(strings.Builder)
+	for _, e := range np {
+		e.writePointerTo(buf)
+	}
 	return buf.String()
 }
 
+// PointerTokens returns the unescaped [RFC 6901] JSON Pointer reference
+// tokens of np, such as ["store", "book", "0"] for $['store']['book'][0].
+// Useful for systems that construct pointers or traverse documents token by
+// token. Returns an empty slice for an empty np.
+//
+// [RFC 6901]: https://www.rfc-editor.org/rfc/rfc6901
+func (np NormalizedPath) PointerTokens() []string {
+	tokens := make([]string, len(np))
+	for i, e := range np {
+		switch e := e.(type) {
+		case Name:
+			tokens[i] = string(e)
+		case Index:
+			tokens[i] = strconv.FormatInt(int64(e), 10)
+		}
+	}
+	return tokens
+}
+
+// Query returns a singular [PathQuery] that selects the value identified by
+// np, such as $["store"]["book"][0] for $['store']['book'][0]. Useful for
+// resolving a path returned by [ParseNormalizedPath] with the features of a
+// full query, such as located selection.
+func (np NormalizedPath) Query() *PathQuery {
+	segs := make([]*Segment, len(np))
+	for i, e := range np {
+		switch e := e.(type) {
+		case Name:
+			segs[i] = Child(e)
+		case Index:
+			segs[i] = Child(e)
+		}
+	}
+	return Query(true, segs)
+}
+
+// Resolve returns the value identified by np in input and true, or false if
+// input does not contain it. Resolves an empty np to input itself. Resolve
+// walks input directly and so is faster than selecting with the result of
+// [NormalizedPath.Query].
+func (np NormalizedPath) Resolve(input any) (any, bool) {
+	for _, e := range np {
+		var ok bool
+		switch e := e.(type) {
+		case Name:
+			input, ok = member(input, string(e))
+		case Index:
+			input, _, ok = e.resolve(input)
+		}
+		if !ok {
+			return nil, false
+		}
+	}
+	return input, true
+}
+
 // Compare compares np to np2 and returns -1 if np is less than np2, 1 if it's
 // greater than np2, and 0 if they're equal. Indexes are always considered
 // less than names.
@@ -71,6 +166,209 @@ func (np NormalizedPath) MarshalText() ([]byte, error) {
 	return []byte(np.String()), nil
 }
 
+// UnmarshalText parses text, a normalized path string, into np. Returns an
+// [ErrNormalizedPath] error if text is not a valid normalized path. It
+// implements [encoding.TextUnmarshaler].
+func (np *NormalizedPath) UnmarshalText(text []byte) error {
+	path, err := ParseNormalizedPath(string(text))
+	if err != nil {
+		return err
+	}
+	*np = path
+	return nil
+}
+
+// MarshalJSON marshals np into a JSON string containing its normalized path
+// string. It implements [json.Marshaler].
+func (np NormalizedPath) MarshalJSON() ([]byte, error) {
+	//nolint:wrapcheck // Cannot fail for a string.
+	return json.Marshal(np.String())
+}
+
+// UnmarshalJSON parses data, a JSON string containing a normalized path
+// string, into np. Returns an [ErrNormalizedPath] error if data is not a
+// JSON string or if it does not contain a valid normalized path. It
+// implements [json.Unmarshaler].
+func (np *NormalizedPath) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("%w: %w", ErrNormalizedPath, err)
+	}
+	return np.UnmarshalText([]byte(str))
+}
+
+// ErrNormalizedPath errors are returned by [ParseNormalizedPath] for invalid
+// normalized paths.
+var ErrNormalizedPath = errors.New("jsonpath: invalid normalized path")
+
+// ParseNormalizedPath parses path, the string representation of a
+// normalized path such as $['store']['book'][0], as returned by
+// [NormalizedPath.String]. Returns an [ErrNormalizedPath] error if path
+// is not a valid normalized path.
+func ParseNormalizedPath(path string) (NormalizedPath, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("%w: missing $ at position 1", ErrNormalizedPath)
+	}
+
+	np := NormalizedPath{}
+	for pos := 1; pos < len(path); {
+		if path[pos] != '[' {
+			return nil, fmt.Errorf("%w: expected '[' at position %v", ErrNormalizedPath, pos+1)
+		}
+		pos++
+
+		var (
+			sel  NormalSelector
+			size int
+			err  error
+		)
+		if pos < len(path) && path[pos] == '\'' {
+			sel, size, err = parseNormalName(path[pos:])
+		} else {
+			sel, size, err = parseNormalIndex(path[pos:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w at position %v", ErrNormalizedPath, err, pos+1+size)
+		}
+		pos += size
+
+		if pos >= len(path) || path[pos] != ']' {
+			return nil, fmt.Errorf("%w: expected ']' at position %v", ErrNormalizedPath, pos+1)
+		}
+		pos++
+		np = append(np, sel)
+	}
+	return np, nil
+}
+
+// parseNormalIndex parses the non-negative integer without leading zeros at
+// the start of str into an Index. Returns the Index and the number of bytes
+// parsed, or the number of bytes parsed before an error.
+func parseNormalIndex(str string) (Index, int, error) {
+	end := 0
+	for end < len(str) && str[end] >= '0' && str[end] <= '9' {
+		end++
+	}
+	switch {
+	case end == 0:
+		return 0, 0, errors.New("expected name or index")
+	case end > 1 && str[0] == '0':
+		return 0, 0, errors.New("index has leading zero")
+	}
+	idx, err := strconv.Atoi(str[:end])
+	if err != nil {
+		return 0, 0, errors.New("index out of range")
+	}
+	return Index(idx), end, nil
+}
+
+// parseNormalName parses the single-quoted name at the start of str, as
+// written by [writeQuoted], into a Name. Returns the Name and the number of
+// bytes parsed, including the quotation marks, or the number of bytes
+// parsed before an error.
+func parseNormalName(str string) (Name, int, error) {
+	buf := new(strings.Builder)
+	for pos := 1; pos < len(str); pos++ {
+		switch c := str[pos]; c {
+		case '\'':
+			return Name(buf.String()), pos + 1, nil
+		case '\\':
+			pos++
+			if pos >= len(str) {
+				return "", pos, errors.New("unterminated name")
+			}
+			switch esc := str[pos]; esc {
+			case 'b':
+				buf.WriteByte('\b')
+			case 'f':
+				buf.WriteByte('\f')
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case '\'', '\\':
+				buf.WriteByte(esc)
+			case 'u':
+				if pos+5 > len(str) {
+					return "", pos - 1, errors.New("invalid escape")
+				}
+				r, err := strconv.ParseUint(str[pos+1:pos+5], 16, 16)
+				if err != nil {
+					return "", pos - 1, errors.New("invalid escape")
+				}
+				buf.WriteRune(rune(r))
+				pos += 4
+			default:
+				return "", pos - 1, errors.New("invalid escape")
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", len(str), errors.New("unterminated name")
+}
+
+// NormalizedPaths is a list of normalized paths, such as those selected by
+// a query, with methods for efficiently serializing large numbers of them.
+type NormalizedPaths []NormalizedPath
+
+// Strings returns the string representations of all the paths in nps.
+// More efficient than calling [NormalizedPath.String] on each path, as it
+// writes all of the paths to a single buffer and slices the results from it.
+func (nps NormalizedPaths) Strings() []string {
+	if len(nps) == 0 {
+		return []string{}
+	}
+
+	buf := new(strings.Builder)
+	ends := make([]int, len(nps))
+	for i, np := range nps {
+		np.writeTo(buf)
+		ends[i] = buf.Len()
+	}
+
+	all := buf.String()
+	strs := make([]string, len(nps))
+	start := 0
+	for i, end := range ends {
+		strs[i] = all[start:end]
+		start = end
+	}
+	return strs
+}
+
+// WriteTo writes the string representations of all the paths in nps to w,
+// each followed by a newline. Writes are buffered, so it's efficient to pass
+// an unbuffered writer such as a file. Returns the number of bytes written
+// and any error encountered. Implements [io.WriterTo].
+func (nps NormalizedPaths) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	buf := bufio.NewWriter(cw)
+	for _, np := range nps {
+		np.writeTo(buf)
+		if _, err := buf.WriteRune('\n'); err != nil {
+			break
+		}
+	}
+	err := buf.Flush()
+	return cw.n, err
+}
+
+// countingWriter wraps an io.Writer to count the bytes written to it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write writes p to the underlying writer and counts the bytes written.
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err //nolint:wrapcheck
+}
+
 // LocatedNode pairs a value with its location within the JSON query argument
 // from which it was selected.
 type LocatedNode struct {
@@ -80,6 +378,122 @@ type LocatedNode struct {
 	// Path is the normalized path that uniquely identifies the location of
 	// Node in a JSON query argument.
 	Path NormalizedPath `json:"path"`
+
+	// Info describes the JSON type and size of Node. Nil unless requested,
+	// as by the root package's WithNodeInfo option.
+	Info *NodeInfo `json:"info,omitempty"`
+}
+
+// JSONType identifies the JSON type of a value.
+type JSONType string
+
+//revive:disable:exported
+const (
+	JSONNull    JSONType = "null"
+	JSONBoolean JSONType = "boolean"
+	JSONNumber  JSONType = "number"
+	JSONString  JSONType = "string"
+	JSONArray   JSONType = "array"
+	JSONObject  JSONType = "object"
+)
+
+//revive:enable:exported
+
+// NodeInfo describes the JSON type of a node and, for arrays and objects,
+// the number of children, so that applications such as UIs can render
+// result trees lazily without inspecting the values.
+type NodeInfo struct {
+	// Type is the JSON type of the node. Empty for values that have no
+	// JSON equivalent, such as structs, functions, and channels.
+	Type JSONType `json:"type"`
+
+	// Len is the number of elements of an array or members of an object,
+	// and zero for other types.
+	Len int `json:"len,omitempty"`
+}
+
+// InfoOf returns a NodeInfo that describes val.
+func InfoOf(val any) *NodeInfo {
+	val = decodeRaw(val)
+	switch v := val.(type) {
+	case nil:
+		return &NodeInfo{Type: JSONNull}
+	case bool:
+		return &NodeInfo{Type: JSONBoolean}
+	case string:
+		return &NodeInfo{Type: JSONString}
+	case json.Number:
+		return &NodeInfo{Type: JSONNumber}
+	case []any:
+		return &NodeInfo{Type: JSONArray, Len: len(v)}
+	case map[string]any:
+		return &NodeInfo{Type: JSONObject, Len: len(v)}
+	case OrderedMap:
+		return &NodeInfo{Type: JSONObject, Len: v.Len()}
+	case Array:
+		return &NodeInfo{Type: JSONArray, Len: v.Len()}
+	}
+
+	if isNumber(val) {
+		return &NodeInfo{Type: JSONNumber}
+	}
+
+	// Fall back on reflection for other Go types.
+	rv := reflect.ValueOf(val)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return &NodeInfo{Type: JSONBoolean}
+	case reflect.String:
+		return &NodeInfo{Type: JSONString}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return &NodeInfo{Type: JSONNumber}
+	case reflect.Slice, reflect.Array:
+		return &NodeInfo{Type: JSONArray, Len: rv.Len()}
+	case reflect.Map:
+		return &NodeInfo{Type: JSONObject, Len: rv.Len()}
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return &NodeInfo{Type: JSONNull}
+		}
+		return InfoOf(rv.Elem().Interface())
+	default:
+		return &NodeInfo{}
+	}
+}
+
+// locatedNode is an alias for LocatedNode without its JSON methods.
+type locatedNode LocatedNode
+
+// MarshalJSON marshals ln into a JSON object with the fields "node",
+// containing the JSON representation of ln.Node; "path", containing the
+// normalized path string of ln.Path; and, if ln.Info is not nil, "info". It
+// implements [json.Marshaler].
+func (ln LocatedNode) MarshalJSON() ([]byte, error) {
+	//nolint:wrapcheck // Let callers see the errors for unsupported nodes.
+	return json.Marshal(locatedNode(ln))
+}
+
+// UnmarshalJSON parses data, a JSON object as produced by
+// [LocatedNode.MarshalJSON], into ln. Decodes the node as
+// [json.Unmarshal] decodes values into an interface. Returns an
+// [ErrNormalizedPath] error if the object has no "path" field or if the
+// path is invalid. It implements [json.Unmarshaler].
+func (ln *LocatedNode) UnmarshalJSON(data []byte) error {
+	var obj struct {
+		locatedNode
+		Path *NormalizedPath `json:"path"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err //nolint:wrapcheck
+	}
+	if obj.Path == nil {
+		return fmt.Errorf("%w: located node missing path", ErrNormalizedPath)
+	}
+	obj.locatedNode.Path = *obj.Path
+	*ln = LocatedNode(obj.locatedNode)
+	return nil
 }
 
 // newLocatedNode creates and returns a new [Node]. It makes a copy of path.
@@ -1,7 +1,10 @@
 package spec
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"strings"
 	"testing"
 
@@ -53,6 +56,21 @@ func TestNormalSelector(t *testing.T) {
 			elem: Name("\u0001\u0002\u0003\u0004\u0005\u0006\u0007\u000e\u000F"),
 			exp:  `['\u0001\u0002\u0003\u0004\u0005\u0006\u0007\u000e\u000f']`,
 		},
+		{
+			name: "escape_unicode_1x_runes",
+			elem: Name("\u0010\u0011\u0012\u0013\u0014\u0015\u0016\u0017\u0018\u0019\u001a\u001b\u001c\u001d\u001e\u001f"),
+			exp:  `['\u0010\u0011\u0012\u0013\u0014\u0015\u0016\u0017\u0018\u0019\u001a\u001b\u001c\u001d\u001e\u001f']`,
+		},
+		{
+			name: "no_escape_quotation_mark",
+			elem: Name(`"hi"`),
+			exp:  `['"hi"']`,
+		},
+		{
+			name: "no_escape_unicode",
+			elem: Name("fo\u00f8 \u2028 \U0001D11E \u007f"),
+			exp:  "['fo\u00f8 \u2028 \U0001D11E \u007f']",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
@@ -71,43 +89,253 @@ func TestNormalizedPath(t *testing.T) {
 		name string
 		path NormalizedPath
 		exp  string
+		ptr  string
+		toks []string
 	}{
+		{
+			name: "empty",
+			path: NormalizedPath{},
+			exp:  "$",
+			ptr:  "",
+			toks: []string{},
+		},
 		{
 			name: "object_value",
 			path: NormalizedPath{Name("a")},
 			exp:  "$['a']",
+			ptr:  "/a",
+			toks: []string{"a"},
 		},
 		{
 			name: "array_index",
 			path: NormalizedPath{Index(1)},
 			exp:  "$[1]",
+			ptr:  "/1",
+			toks: []string{"1"},
 		},
 		{
 			name: "neg_for_len_5",
 			path: NormalizedPath{Index(2)},
 			exp:  "$[2]",
+			ptr:  "/2",
+			toks: []string{"2"},
 		},
 		{
 			name: "nested_structure",
 			path: NormalizedPath{Name("a"), Name("b"), Index(1)},
 			exp:  "$['a']['b'][1]",
+			ptr:  "/a/b/1",
+			toks: []string{"a", "b", "1"},
 		},
 		{
 			name: "unicode_escape",
 			path: NormalizedPath{Name("\u000B")},
 			exp:  `$['\u000b']`,
+			ptr:  "/\u000b",
+			toks: []string{"\u000b"},
 		},
 		{
 			name: "unicode_character",
 			path: NormalizedPath{Name("\u0061")},
 			exp:  "$['a']",
+			ptr:  "/a",
+			toks: []string{"a"},
+		},
+		{
+			name: "pointer_escapes",
+			path: NormalizedPath{Name("a/b"), Name("~c~1"), Name(""), Index(0)},
+			exp:  "$['a/b']['~c~1'][''][0]",
+			ptr:  "/a~1b/~0c~01//0",
+			toks: []string{"a/b", "~c~1", "", "0"},
+		},
+		{
+			name: "escapes",
+			path: NormalizedPath{Name("\b\f\n\r\t'\\\x00\x1f\"é"), Index(10)},
+			exp:  `$['\b\f\n\r\t\'\\\u0000\u001f"é'][10]`,
+			ptr:  "/\b\f\n\r\t'\\\x00\x1f\"é/10",
+			toks: []string{"\b\f\n\r\t'\\\x00\x1f\"é", "10"},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
+			r := require.New(t)
 			a.Equal(tc.exp, tc.path.String())
+			a.Equal(tc.ptr, tc.path.Pointer())
+			a.Equal(tc.toks, tc.path.PointerTokens())
+
+			// Round-trip the string and JSON representations.
+			np, err := ParseNormalizedPath(tc.exp)
+			r.NoError(err)
+			a.Equal(tc.path, np)
+
+			data, err := json.Marshal(tc.path)
+			r.NoError(err)
+			var str string
+			r.NoError(json.Unmarshal(data, &str))
+			a.Equal(tc.exp, str)
+			np = nil
+			r.NoError(json.Unmarshal(data, &np))
+			a.Equal(tc.path, np)
+		})
+	}
+}
+
+func TestParseNormalizedPathErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		path string
+		err  string
+	}{
+		{"empty", "", "missing $ at position 1"},
+		{"no_root", "['a']", "missing $ at position 1"},
+		{"dot_name", "$.a", "expected '[' at position 2"},
+		{"unclosed", "$['a'", "expected ']' at position 6"},
+		{"unclosed_index", "$[1", "expected ']' at position 4"},
+		{"double_quoted", `$["a"]`, "expected name or index at position 3"},
+		{"negative", "$[-1]", "expected name or index at position 3"},
+		{"wildcard", "$[*]", "expected name or index at position 3"},
+		{"leading_zero", "$[01]", "index has leading zero at position 3"},
+		{"huge_index", "$[99999999999999999999]", "index out of range at position 3"},
+		{"unterminated", "$['a", "unterminated name at position 5"},
+		{"unterminated_escape", `$['a\`, "unterminated name at position 6"},
+		{"bad_escape", `$['a\x']`, "invalid escape at position 5"},
+		{"short_unicode", `$['\u00']`, "invalid escape at position 4"},
+		{"bad_unicode", `$['\u00zz']`, "invalid escape at position 4"},
+		{"trailing", "$[0]x", "expected '[' at position 5"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			np, err := ParseNormalizedPath(tc.path)
+			a.Nil(np)
+			a.EqualError(err, "jsonpath: invalid normalized path: "+tc.err)
+			a.ErrorIs(err, ErrNormalizedPath)
+
+			var path NormalizedPath
+			err = path.UnmarshalText([]byte(tc.path))
+			a.ErrorIs(err, ErrNormalizedPath)
+			a.Nil(path)
+		})
+	}
+
+	var np NormalizedPath
+	err := json.Unmarshal([]byte(`42`), &np)
+	assert.EqualError(t, err, "jsonpath: invalid normalized path: json: cannot unmarshal number into Go value of type string")
+	assert.ErrorIs(t, err, ErrNormalizedPath)
+}
+
+func TestNormalizedPathResolve(t *testing.T) {
+	t.Parallel()
+
+	ordered := &OrderedObject{}
+	ordered.Set("x", []any{"y"})
+	doc := map[string]any{
+		"store": map[string]any{
+			"book":    []any{map[string]any{"title": "a"}, map[string]any{"title": "b"}},
+			"ordered": ordered,
+			"raw":     json.RawMessage(`{"z": [1, 2]}`),
+		},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		query string
+		exp   any
+		found bool
+		raw   bool
+	}{
+		{
+			name:  "root",
+			path:  "$",
+			query: "$",
+			exp:   doc,
+			found: true,
+		},
+		{
+			name:  "name_index_name",
+			path:  "$['store']['book'][1]['title']",
+			query: `$["store"]["book"][1]["title"]`,
+			exp:   "b",
+			found: true,
+		},
+		{
+			name:  "ordered",
+			path:  "$['store']['ordered']['x'][0]",
+			query: `$["store"]["ordered"]["x"][0]`,
+			exp:   "y",
+			found: true,
+		},
+		{
+			name:  "raw",
+			raw:   true,
+			path:  "$['store']['raw']['z'][1]",
+			query: `$["store"]["raw"]["z"][1]`,
+			exp:   float64(2),
+			found: true,
+		},
+		{
+			name:  "missing_name",
+			path:  "$['store']['nope']",
+			query: `$["store"]["nope"]`,
+		},
+		{
+			name:  "index_out_of_range",
+			path:  "$['store']['book'][2]",
+			query: `$["store"]["book"][2]`,
+		},
+		{
+			name:  "name_in_array",
+			path:  "$['store']['book']['title']",
+			query: `$["store"]["book"]["title"]`,
+		},
+		{
+			name:  "index_in_object",
+			path:  "$['store'][0]",
+			query: `$["store"][0]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if tc.raw {
+				skipRaw(t)
+			}
+			a := assert.New(t)
+			r := require.New(t)
+
+			np, err := ParseNormalizedPath(tc.path)
+			r.NoError(err)
+			q := np.Query()
+			a.True(q.IsRoot())
+			a.NotNil(q.Singular())
+			a.Equal(tc.query, q.String())
+
+			val, ok := np.Resolve(doc)
+			a.Equal(tc.found, ok)
+			a.Equal(tc.exp, val)
+
+			located := q.SelectLocated(nil, doc, nil)
+			if !tc.found {
+				a.Empty(located)
+				return
+			}
+			a.Equal([]*LocatedNode{{Node: tc.exp, Path: np}}, located)
 		})
 	}
+
+	// Paths from SelectLocated round-trip through their string
+	// representations to the same nodes.
+	query := Query(true, []*Segment{Descendant(Wildcard())})
+	for _, n := range query.SelectLocated(nil, doc, nil) {
+		np, err := ParseNormalizedPath(n.Path.String())
+		require.NoError(t, err)
+		val, ok := np.Resolve(doc)
+		assert.True(t, ok)
+		assert.Equal(t, n.Node, val)
+	}
 }
 
 func TestNormalizedPathCompare(t *testing.T) {
@@ -222,6 +450,119 @@ func TestNormalizedPathCompare(t *testing.T) {
 	}
 }
 
+func TestNormalizedPaths(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	for _, tc := range []struct {
+		name  string
+		paths NormalizedPaths
+		exp   []string
+	}{
+		{
+			name:  "empty",
+			paths: NormalizedPaths{},
+			exp:   []string{},
+		},
+		{
+			name:  "root",
+			paths: NormalizedPaths{{}},
+			exp:   []string{"$"},
+		},
+		{
+			name: "several",
+			paths: NormalizedPaths{
+				{Name("a"), Index(1)},
+				{},
+				{Name("it's"), Name("b\nc")},
+				{Index(0), Index(2), Name("x")},
+			},
+			exp: []string{`$['a'][1]`, `$`, `$['it\'s']['b\nc']`, `$[0][2]['x']`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(tc.exp, tc.paths.Strings())
+			for i, p := range tc.paths {
+				a.Equal(p.String(), tc.exp[i])
+			}
+
+			buf := new(bytes.Buffer)
+			n, err := tc.paths.WriteTo(buf)
+			r.NoError(err)
+			a.Equal(int64(buf.Len()), n)
+			exp := ""
+			for _, str := range tc.exp {
+				exp += str + "\n"
+			}
+			a.Equal(exp, buf.String())
+		})
+	}
+}
+
+type errWriter struct{ max int }
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if len(p) > w.max {
+		return w.max, errors.New("oops")
+	}
+	w.max -= len(p)
+	return len(p), nil
+}
+
+func TestNormalizedPathsWriteToError(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	paths := make(NormalizedPaths, 1000)
+	for i := range paths {
+		paths[i] = NormalizedPath{Name("hello"), Index(i)}
+	}
+
+	n, err := paths.WriteTo(&errWriter{max: 10})
+	a.EqualError(err, "oops")
+	a.Equal(int64(10), n)
+}
+
+func benchPaths() NormalizedPaths {
+	paths := make(NormalizedPaths, 10_000)
+	for i := range paths {
+		paths[i] = NormalizedPath{Name("store"), Name("book"), Index(i), Name("title")}
+	}
+	return paths
+}
+
+func BenchmarkNormalizedPathString(b *testing.B) {
+	paths := benchPaths()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		strs := make([]string, len(paths))
+		for i, p := range paths {
+			strs[i] = p.String()
+		}
+	}
+}
+
+func BenchmarkNormalizedPathsStrings(b *testing.B) {
+	paths := benchPaths()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		_ = paths.Strings()
+	}
+}
+
+func BenchmarkNormalizedPathsWriteTo(b *testing.B) {
+	paths := benchPaths()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		_, _ = paths.WriteTo(io.Discard)
+	}
+}
+
 func TestLocatedNode(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -247,12 +588,109 @@ func TestLocatedNode(t *testing.T) {
 			node: LocatedNode{Path: NormalizedPath{Name(`'a'`)}, Node: true},
 			exp:  `{"path": "$['\\'a\\'']", "node": true}`,
 		},
+		{
+			name: "info",
+			node: LocatedNode{
+				Path: NormalizedPath{Index(1)},
+				Node: []any{1, 2},
+				Info: &NodeInfo{Type: JSONArray, Len: 2},
+			},
+			exp: `{"path": "$[1]", "node": [1, 2], "info": {"type": "array", "len": 2}}`,
+		},
+		{
+			name: "info_scalar",
+			node: LocatedNode{Path: NormalizedPath{}, Node: 1, Info: &NodeInfo{Type: JSONNumber}},
+			exp:  `{"path": "$", "node": 1, "info": {"type": "number"}}`,
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			json, err := json.Marshal(tc.node)
+			data, err := json.Marshal(tc.node)
+			r.NoError(err)
+			a.JSONEq(tc.exp, string(data))
+
+			// Pointers marshal the same way.
+			data, err = json.Marshal(&tc.node)
 			r.NoError(err)
-			a.JSONEq(tc.exp, string(json))
+			a.JSONEq(tc.exp, string(data))
+
+			// Round-trip.
+			var node LocatedNode
+			r.NoError(json.Unmarshal(data, &node))
+			a.Equal(tc.node.Path, node.Path)
+			a.Equal(tc.node.Info, node.Info)
+			data, err = json.Marshal(node)
+			r.NoError(err)
+			a.JSONEq(tc.exp, string(data))
+		})
+	}
+
+	t.Run("unmarshal_errors", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range []struct {
+			name string
+			json string
+			err  string
+		}{
+			{"no_path", `{"node": 1}`, "jsonpath: invalid normalized path: located node missing path"},
+			{"null_path", `{"node": 1, "path": null}`, "jsonpath: invalid normalized path: located node missing path"},
+			{"bad_path", `{"node": 1, "path": "$.a"}`, "jsonpath: invalid normalized path: expected '[' at position 2"},
+		} {
+			var node LocatedNode
+			err := json.Unmarshal([]byte(tc.json), &node)
+			a.EqualError(err, tc.err, tc.name)
+		}
+
+		var node LocatedNode
+		a.Error(json.Unmarshal([]byte(`[]`), &node))
+
+		// Null nodes are fine.
+		r.NoError(json.Unmarshal([]byte(`{"node": null, "path": "$[0]"}`), &node))
+		a.Equal(LocatedNode{Path: NormalizedPath{Index(0)}}, node)
+	})
+}
+
+func TestInfoOf(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	str := "x"
+	for _, tc := range []struct {
+		name string
+		val  any
+		exp  NodeInfo
+	}{
+		{"nil", nil, NodeInfo{Type: JSONNull}},
+		{"true", true, NodeInfo{Type: JSONBoolean}},
+		{"string", "hi", NodeInfo{Type: JSONString}},
+		{"float64", 98.6, NodeInfo{Type: JSONNumber}},
+		{"int", 42, NodeInfo{Type: JSONNumber}},
+		{"uint8", uint8(1), NodeInfo{Type: JSONNumber}},
+		{"json_number", json.Number("42"), NodeInfo{Type: JSONNumber}},
+		{"array", []any{1, 2, 3}, NodeInfo{Type: JSONArray, Len: 3}},
+		{"empty_array", []any{}, NodeInfo{Type: JSONArray}},
+		{"object", map[string]any{"a": 1}, NodeInfo{Type: JSONObject, Len: 1}},
+		{"typed_slice", []string{"a", "b"}, NodeInfo{Type: JSONArray, Len: 2}},
+		{"go_array", [3]int{}, NodeInfo{Type: JSONArray, Len: 3}},
+		{"typed_map", map[string]int{"a": 1, "b": 2}, NodeInfo{Type: JSONObject, Len: 2}},
+		{"named_bool", reflectBool(true), NodeInfo{Type: JSONBoolean}},
+		{"named_string", reflectString("x"), NodeInfo{Type: JSONString}},
+		{"named_number", reflectInt(1), NodeInfo{Type: JSONNumber}},
+		{"pointer", &str, NodeInfo{Type: JSONString}},
+		{"nil_pointer", (*string)(nil), NodeInfo{Type: JSONNull}},
+		{"struct", struct{}{}, NodeInfo{}},
+		{"func", func() {}, NodeInfo{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(&tc.exp, InfoOf(tc.val))
 		})
 	}
 }
+
+type (
+	reflectBool   bool
+	reflectString string
+	reflectInt    int
+)
@@ -0,0 +1,246 @@
+package spec
+
+import (
+	"cmp"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// numKind identifies the representation of a number.
+type numKind uint8
+
+const (
+	// numInt represents a signed integer as an int64.
+	numInt numKind = iota
+
+	// numUint represents an unsigned integer as a uint64.
+	numUint
+
+	// numFloat represents a floating point number as a float64.
+	numFloat
+
+	// numBig represents a [json.Number] too large for the other kinds as a
+	// [big.Float].
+	numBig
+)
+
+// maxExactInt is the largest integer magnitude that float64 represents
+// exactly, 2^53.
+const maxExactInt = 1 << 53
+
+// number represents a JSON number of any Go numeric type.
+type number struct {
+	i    int64
+	u    uint64
+	f    float64
+	big  *big.Float
+	kind numKind
+}
+
+// CompareNumbers compares left and right as JSON numbers and returns -1 if
+// left is less than right, 0 if they're equal, or +1 if left is greater
+// than right, and true. Returns false if either is not a number or is NaN.
+// Filter comparisons use CompareNumbers to compare numbers exactly, by
+// value, regardless of their types, coercing them as follows:
+//
+//	Type                                  Compared as
+//	------------------------------------  -------------------------------
+//	int, int8, int16, int32, int64        int64
+//	uint, uint8, uint16, uint32, uint64   uint64
+//	float32, float64                      float64
+//	json.Number integer                   int64 or uint64 if in range,
+//	                                      otherwise an exact big.Float
+//	json.Number with fraction or exponent float64 if in range, otherwise
+//	                                      a big.Float
+//
+// Numbers of the same kind compare natively, and int64s and uint64s
+// compare without overflow. Integers compare with float64s natively when
+// their magnitudes do not exceed 2^53, which float64 represents exactly,
+// and otherwise as exact [big.Float] values, so that int64(1<<53 + 1)
+// does not equal float64(1<<53). Thus json.Number("3") equals int64(3) and
+// float64(3), and json.Number("18446744073709551617") is greater than
+// uint64(math.MaxUint64).
+func CompareNumbers(left, right any) (int, bool) {
+	l, ok := toNumber(left)
+	if !ok {
+		return 0, false
+	}
+	r, ok := toNumber(right)
+	if !ok {
+		return 0, false
+	}
+	return compareNumbers(l, r)
+}
+
+// ToFloat64 returns val as a float64 and true if val is a Go numeric type
+// or a valid [json.Number], rounding it to the nearest float64 if necessary
+// and converting numbers out of the range of float64 to ±Inf. Returns false
+// for other values. Use [CompareNumbers] to compare numbers exactly.
+func ToFloat64(val any) (float64, bool) {
+	n, ok := toNumber(val)
+	if !ok {
+		return 0, false
+	}
+	return n.float(), true
+}
+
+// isNumber returns true if val is a Go numeric type or a valid
+// [json.Number].
+func isNumber(val any) bool {
+	_, ok := toNumber(val)
+	return ok
+}
+
+// toNumber converts val to a number. Returns false if val is neither a Go
+// numeric type nor a valid [json.Number].
+func toNumber(val any) (number, bool) {
+	switch v := val.(type) {
+	case int:
+		return number{kind: numInt, i: int64(v)}, true
+	case int8:
+		return number{kind: numInt, i: int64(v)}, true
+	case int16:
+		return number{kind: numInt, i: int64(v)}, true
+	case int32:
+		return number{kind: numInt, i: int64(v)}, true
+	case int64:
+		return number{kind: numInt, i: v}, true
+	case uint:
+		return number{kind: numUint, u: uint64(v)}, true
+	case uint8:
+		return number{kind: numUint, u: uint64(v)}, true
+	case uint16:
+		return number{kind: numUint, u: uint64(v)}, true
+	case uint32:
+		return number{kind: numUint, u: uint64(v)}, true
+	case uint64:
+		return number{kind: numUint, u: v}, true
+	case float32:
+		return number{kind: numFloat, f: float64(v)}, true
+	case float64:
+		return number{kind: numFloat, f: v}, true
+	case json.Number:
+		return parseNumber(string(v))
+	default:
+		return number{}, false
+	}
+}
+
+// parseNumber parses str, the string representation of a JSON number, into
+// the narrowest kind of number that represents it: int64 or uint64 for
+// integers, float64 for others, and an exact big.Float for integers too
+// large for uint64 or other numbers out of the range of float64.
+func parseNumber(str string) (number, bool) {
+	if !strings.ContainsAny(str, ".eE") {
+		if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return number{kind: numInt, i: i}, true
+		}
+		if u, err := strconv.ParseUint(str, 10, 64); err == nil {
+			return number{kind: numUint, u: u}, true
+		}
+		if i, ok := new(big.Int).SetString(str, 10); ok {
+			return number{kind: numBig, big: new(big.Float).SetInt(i)}, true
+		}
+		return number{}, false
+	}
+
+	if f, err := strconv.ParseFloat(str, 64); err == nil {
+		return number{kind: numFloat, f: f}, true
+	}
+	const prec = 256
+	if f, _, err := big.ParseFloat(str, 10, prec, big.ToNearestEven); err == nil {
+		return number{kind: numBig, big: f}, true
+	}
+	return number{}, false
+}
+
+// compareNumbers compares l and r as described by [CompareNumbers].
+func compareNumbers(l, r number) (int, bool) {
+	if l.kind == numFloat && math.IsNaN(l.f) || r.kind == numFloat && math.IsNaN(r.f) {
+		return 0, false
+	}
+
+	switch {
+	case l.kind == r.kind && l.kind != numBig:
+		switch l.kind {
+		case numInt:
+			return cmp.Compare(l.i, r.i), true
+		case numUint:
+			return cmp.Compare(l.u, r.u), true
+		default:
+			return cmp.Compare(l.f, r.f), true
+		}
+	case l.kind == numInt && r.kind == numUint:
+		if l.i < 0 {
+			return -1, true
+		}
+		return cmp.Compare(uint64(l.i), r.u), true
+	case l.kind == numUint && r.kind == numInt:
+		c, ok := compareNumbers(r, l)
+		return -c, ok
+	case l.kind == numFloat && r.exact():
+		return cmp.Compare(l.f, r.float()), true
+	case r.kind == numFloat && l.exact():
+		return cmp.Compare(l.float(), r.f), true
+	}
+
+	return l.bigFloat().Cmp(r.bigFloat()), true
+}
+
+// exact returns true if n is an integer that float64 represents exactly.
+func (n number) exact() bool {
+	switch n.kind {
+	case numInt:
+		return -maxExactInt <= n.i && n.i <= maxExactInt
+	case numUint:
+		return n.u <= maxExactInt
+	default:
+		return false
+	}
+}
+
+// float returns n as a float64, rounding to the nearest float64 if
+// necessary.
+func (n number) float() float64 {
+	switch n.kind {
+	case numInt:
+		return float64(n.i)
+	case numUint:
+		return float64(n.u)
+	case numFloat:
+		return n.f
+	default:
+		f, _ := n.big.Float64()
+		return f
+	}
+}
+
+// int64 returns n as an int64. Returns false if n is not an integer within
+// the range of int64.
+func (n number) int64() (int64, bool) {
+	switch n.kind {
+	case numInt:
+		return n.i, true
+	case numUint:
+		return int64(n.u), n.u <= math.MaxInt64
+	default:
+		return 0, false
+	}
+}
+
+// bigFloat returns n as an exact big.Float. n must not be NaN.
+func (n number) bigFloat() *big.Float {
+	switch n.kind {
+	case numInt:
+		return new(big.Float).SetInt64(n.i)
+	case numUint:
+		return new(big.Float).SetUint64(n.u)
+	case numFloat:
+		return new(big.Float).SetFloat64(n.f)
+	default:
+		return n.big
+	}
+}
@@ -0,0 +1,109 @@
+package spec
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareNumbers(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		left  any
+		right any
+		exp   int
+		ok    bool
+	}{
+		{"ints", 1, int64(1), 0, true},
+		{"int_lt", int8(-1), int32(1), -1, true},
+		{"uints", uint8(2), uint64(1), 1, true},
+		{"floats", float32(1.5), 1.5, 0, true},
+		{"int_float", 3, 3.0, 0, true},
+		{"float_int", 2.5, int16(3), -1, true},
+		{"uint_float", uint(3), 2.5, 1, true},
+		{"neg_int_uint", -1, uint64(0), -1, true},
+		{"uint_neg_int", uint64(0), -1, 1, true},
+		{"max_int_uint", int64(math.MaxInt64), uint64(math.MaxInt64), 0, true},
+		{"max_uint_int", uint64(math.MaxUint64), int64(math.MaxInt64), 1, true},
+		{"big_int_float_ne", int64(1<<53 + 1), float64(1 << 53), 1, true},
+		{"big_float_int_ne", float64(1 << 53), int64(1<<53 + 1), -1, true},
+		{"big_int_float_eq", int64(1 << 60), float64(1 << 60), 0, true},
+		{"big_uint_float", uint64(math.MaxUint64), float64(math.MaxUint64), -1, true},
+		{"number_int", json.Number("3"), 3, 0, true},
+		{"number_float", json.Number("3"), 3.0, 0, true},
+		{"number_uint", uint8(3), json.Number("3"), 0, true},
+		{"number_fraction", json.Number("2.5"), 3, -1, true},
+		{"number_exponent", json.Number("1e2"), 100, 0, true},
+		{"number_neg", json.Number("-7"), json.Number("-7.0"), 0, true},
+		{"number_big_int", json.Number("9007199254740993"), float64(9007199254740992), 1, true},
+		{"number_big_int_eq", json.Number("9007199254740993"), int64(9007199254740993), 0, true},
+		{"number_max_uint", json.Number("18446744073709551615"), uint64(math.MaxUint64), 0, true},
+		{"number_over_uint", json.Number("18446744073709551617"), uint64(math.MaxUint64), 1, true},
+		{"number_over_uints", json.Number("18446744073709551617"), json.Number("18446744073709551616"), 1, true},
+		{"number_under_int", json.Number("-9223372036854775809"), int64(math.MinInt64), -1, true},
+		{"number_huge", json.Number("1e400"), math.MaxFloat64, 1, true},
+		{"number_huge_int", json.Number("1e400"), json.Number("100000000000000000000000"), 1, true},
+		{"number_tiny", json.Number("-1e400"), -math.MaxFloat64, -1, true},
+		{"inf", math.Inf(1), json.Number("1e400"), 1, true},
+		{"nan", math.NaN(), 1, 0, false},
+		{"nan_right", 1, math.NaN(), 0, false},
+		{"invalid_number", json.Number("x"), 1, 0, false},
+		{"invalid_number_right", 1, json.Number("1.x"), 0, false},
+		{"string", "1", 1, 0, false},
+		{"string_right", 1, "1", 0, false},
+		{"nil", nil, 0, 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			c, ok := CompareNumbers(tc.left, tc.right)
+			a.Equal(tc.exp, c)
+			a.Equal(tc.ok, ok)
+			if ok {
+				// Reversing the operands reverses the result.
+				c, ok = CompareNumbers(tc.right, tc.left)
+				a.True(ok)
+				a.Equal(-tc.exp, c)
+			}
+		})
+	}
+}
+
+func TestToFloat64(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		val  any
+		exp  float64
+		ok   bool
+	}{
+		{"int", -3, -3, true},
+		{"int8", int8(8), 8, true},
+		{"uint64", uint64(math.MaxUint64), math.MaxUint64, true},
+		{"float32", float32(1.5), 1.5, true},
+		{"float64", 98.6, 98.6, true},
+		{"number_int", json.Number("42"), 42, true},
+		{"number_float", json.Number("1.25e2"), 125, true},
+		{"number_big_int", json.Number("18446744073709551617"), math.MaxUint64, true},
+		{"number_out_of_range", json.Number("1e400"), math.Inf(1), true},
+		{"number_neg_out_of_range", json.Number("-1e400"), math.Inf(-1), true},
+		{"number_invalid", json.Number("x"), 0, false},
+		{"string", "1", 0, false},
+		{"nil", nil, 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			f, ok := ToFloat64(tc.val)
+			a.Equal(tc.exp, f)
+			a.Equal(tc.ok, ok)
+		})
+	}
+}
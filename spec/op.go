@@ -5,7 +5,7 @@ package spec
 import (
 	"fmt"
 	"reflect"
-	"strings"
+	"slices"
 )
 
 // CompOp defines the JSONPath filter comparison operators.
@@ -48,17 +48,21 @@ func Comparison(left CompVal, op CompOp, right CompVal) *ComparisonExpr {
 }
 
 // writeTo writes a string representation of ce to buf.
-func (ce *ComparisonExpr) writeTo(buf *strings.Builder) {
+func (ce *ComparisonExpr) writeTo(buf *writer) {
 	ce.Left.writeTo(buf)
 	fmt.Fprintf(buf, " %v ", ce.Op)
 	ce.Right.writeTo(buf)
 }
 
 // testFilter uses ce.Op to compare the values returned by ce.Left and
-// ce.Right relative to current and root.
+// ce.Right relative to current and root, comparing strings by the keys of
+// the [Collation] of root, if it is wrapped by [Collate].
 func (ce *ComparisonExpr) testFilter(current, root any) bool {
 	left := ce.Left.asValue(current, root)
 	right := ce.Right.asValue(current, root)
+	if key := rootOf(root).collation(); key != nil {
+		left, right = collate(left, key), collate(right, key)
+	}
 	switch ce.Op {
 	case EqualTo:
 		return equalTo(left, right)
@@ -67,11 +71,11 @@ func (ce *ComparisonExpr) testFilter(current, root any) bool {
 	case LessThan:
 		return sameType(left, right) && lessThan(left, right)
 	case GreaterThan:
-		return sameType(left, right) && !lessThan(left, right) && !equalTo(left, right)
+		return sameType(left, right) && lessThan(right, left)
 	case LessThanEqualTo:
 		return sameType(left, right) && (lessThan(left, right) || equalTo(left, right))
 	case GreaterThanEqualTo:
-		return sameType(left, right) && !lessThan(left, right)
+		return sameType(left, right) && (lessThan(right, left) || equalTo(left, right))
 	default:
 		panic(fmt.Sprintf("Unknown operator %v", ce.Op))
 	}
@@ -92,49 +96,75 @@ func equalTo(left, right JSONPathValue) bool {
 	return false
 }
 
-// toFloat converts val to a float64 if it is a numeric value, setting ok to
-// true. Otherwise it returns false for ok.
-func toFloat(val any) (float64, bool) {
-	switch val := val.(type) {
-	case int:
-		return float64(val), true
-	case int8:
-		return float64(val), true
-	case int16:
-		return float64(val), true
-	case int32:
-		return float64(val), true
-	case int64:
-		return float64(val), true
-	case uint:
-		return float64(val), true
-	case uint8:
-		return float64(val), true
-	case uint16:
-		return float64(val), true
-	case uint32:
-		return float64(val), true
-	case uint64:
-		return float64(val), true
-	case float32:
-		return float64(val), true
-	case float64:
-		return float64(val), true
-	default:
-		return 0, false
-	}
+// EqualValues returns true if left and right are equal as compared by the
+// == operator of [ComparisonExpr], for function extensions that compare
+// values the same way: numbers by value regardless of their types, as
+// described by [CompareNumbers], and arrays and objects deeply, as
+// described by [RFC 9535 Section 2.3.5.2.2].
+//
+// [RFC 9535 Section 2.3.5.2.2]: https://www.rfc-editor.org/rfc/rfc9535#section-2.3.5.2.2
+func EqualValues(left, right any) bool {
+	return valueEqualTo(left, right)
 }
 
-// valueEqualTo returns true if left and right are equal.
+// valueEqualTo returns true if left and right are equal. Compares numbers
+// by value regardless of their types, as described by [CompareNumbers],
+// and arrays and objects deeply, as
+// described by [RFC 9535 Section 2.3.5.2.2], whether they're Go slices and
+// maps, [Array] or [OrderedMap] values, or [json.RawMessage] values, so that
+// an array with the int 1 equals an array with the float64 1.0.
+//
+// [RFC 9535 Section 2.3.5.2.2]: https://www.rfc-editor.org/rfc/rfc9535#section-2.3.5.2.2
 func valueEqualTo(left, right any) bool {
-	if left, ok := toFloat(left); ok {
-		if right, ok := toFloat(right); ok {
-			return left == right
+	left, right = decodeArray(left), decodeArray(right)
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			c, ok := compareNumbers(l, r)
+			return ok && c == 0
 		}
 		return false
 	}
 
-	return reflect.DeepEqual(left, right)
+	switch l := left.(type) {
+	case []any:
+		r, ok := right.([]any)
+		return ok && slices.EqualFunc(l, r, valueEqualTo)
+	case map[string]any:
+		return objectEqualTo(l, len(l), right)
+	case OrderedMap:
+		return objectEqualTo(l, l.Len(), right)
+	}
+
+	return reflect.DeepEqual(decodeDeep(left), decodeDeep(right))
+}
+
+// objectEqualTo returns true if right is an object with size members, each
+// of which has the same name as a member of left, and a value that
+// [valueEqualTo] reports equal to it.
+func objectEqualTo(left any, size int, right any) bool {
+	switch r := right.(type) {
+	case map[string]any:
+		if len(r) != size {
+			return false
+		}
+		for name, val := range r {
+			if lv, ok := member(left, name); !ok || !valueEqualTo(lv, val) {
+				return false
+			}
+		}
+		return true
+	case OrderedMap:
+		if r.Len() != size {
+			return false
+		}
+		for _, m := range membersOf(r) {
+			if lv, ok := member(left, m.name); !ok || !valueEqualTo(lv, m.val) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
 }
 
 // lessThan returns true if left and right are both ValueTypes and
@@ -192,24 +222,23 @@ func sameType(left, right JSONPathValue) bool {
 }
 
 // valCompType returns true if left and right are comparable types, which
-// means either both are a numeric type or are otherwise the same type.
+// means either both are numbers, including [json.Number], or are otherwise
+// the same type.
 func valCompType(left, right any) bool {
-	switch left.(type) {
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
-		switch right.(type) {
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
-			return true
-		}
+	if isNumber(left) && isNumber(right) {
+		return true
 	}
 	return reflect.TypeOf(left) == reflect.TypeOf(right)
 }
 
 // valueLessThan returns true if left and right are both numeric values or
-// string values and left is less than right.
+// string values and left is less than right. Compares numbers as described
+// by [CompareNumbers].
 func valueLessThan(left, right any) bool {
-	if left, ok := toFloat(left); ok {
-		if right, ok := toFloat(right); ok {
-			return left < right
+	if l, ok := toNumber(left); ok {
+		if r, ok := toNumber(right); ok {
+			c, ok := compareNumbers(l, r)
+			return ok && c < 0
 		}
 		return false
 	}
@@ -1,7 +1,9 @@
 package spec
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -74,6 +76,14 @@ func TestEqualTo(t *testing.T) {
 		{"float64_zero_one", float64(0), float64(1), false},
 		{"int_float_true", int64(10), float64(10), true},
 		{"int_float_false", int64(10), float64(11), false},
+		{"json_number_int", json.Number("3"), 3, true},
+		{"json_number_float", 3.0, json.Number("3"), true},
+		{"json_numbers", json.Number("3"), json.Number("3.0"), true},
+		{"json_number_ne", json.Number("3.5"), 3, false},
+		{"json_number_big_ne", json.Number("9007199254740993"), float64(9007199254740992), false},
+		{"big_int_float_ne", int64(9007199254740993), float64(9007199254740992), false},
+		{"json_number_string", json.Number("3"), "3", false},
+		{"nans", math.NaN(), math.NaN(), false},
 		{"empty_strings", "", "", true},
 		{"strings", "xyz", "xyz", true},
 		{"strings_false", "xyz", "abc", false},
@@ -88,10 +98,31 @@ func TestEqualTo(t *testing.T) {
 		{"objects_eq", map[string]any{"x": 1, "y": 2}, map[string]any{"x": 1, "y": 2}, true},
 		{"object_keys_ne", map[string]any{"x": 1, "y": 2}, map[string]any{"x": 1, "z": 2}, false},
 		{"object_vals_ne", map[string]any{"x": 1, "y": 2}, map[string]any{"x": 1, "y": 3}, false},
+		{"object_size_ne", map[string]any{"x": 1}, map[string]any{"x": 1, "y": 2}, false},
+		{"arrays_numeric_types", []any{int64(1), 2.0, uint8(3)}, []any{1.0, 2, int32(3)}, true},
+		{"arrays_order_ne", []any{1, 2}, []any{2, 1}, false},
+		{"array_object", []any{}, map[string]any{}, false},
+		{"object_array", map[string]any{}, []any{}, false},
+		{"object_scalar", map[string]any{}, 1, false},
+		{"nested_numeric_types", map[string]any{"x": []any{int64(1), map[string]any{"y": 2.0}}}, map[string]any{"x": []any{1.0, map[string]any{"y": 2}}}, true},
+		{"nested_ne", map[string]any{"x": []any{1, map[string]any{"y": 2}}}, map[string]any{"x": []any{1, map[string]any{"y": 3}}}, false},
+		{"ordered_map", orderedObject("x", 1, "y", []any{2}), map[string]any{"y": []any{2.0}, "x": 1.0}, true},
+		{"map_ordered", map[string]any{"y": []any{2.0}, "x": 1.0}, orderedObject("y", []any{2}, "x", 1), true},
+		{"ordered_ordered", orderedObject("x", 1, "y", 2), orderedObject("y", 2.0, "x", 1.0), true},
+		{"ordered_ne", orderedObject("x", 1, "y", 2), orderedObject("x", 1, "z", 2), false},
+		{"ordered_size_ne", orderedObject("x", 1), orderedObject("x", 1, "y", 2), false},
+		{"raw_array", json.RawMessage(`[1, {"x": [true, null]}]`), []any{int64(1), map[string]any{"x": []any{true, nil}}}, true},
+		{"raw_object", map[string]any{"x": int64(1)}, json.RawMessage(`{"x": 1.0}`), true},
+		{"raw_number", json.RawMessage(`3`), 3, true},
+		{"raw_ne", json.RawMessage(`[1, 2]`), []any{1, 3}, false},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
+			if containsRaw(tc.left) || containsRaw(tc.right) {
+				skipRaw(t)
+			}
 			a.Equal(tc.exp, valueEqualTo(tc.left, tc.right))
+			a.Equal(tc.exp, EqualValues(tc.left, tc.right))
 			a.Equal(tc.exp, equalTo(Value(tc.left), Value(tc.right)))
 		})
 	}
@@ -155,6 +186,11 @@ func TestLessThan(t *testing.T) {
 		{"int_float_false", 99, 98.6, false},
 		{"float_int_false", 98.6, 98, false},
 		{"float_int_true", 98.6, 99, true},
+		{"json_number_int", json.Number("2"), 3, true},
+		{"int_json_number", 3, json.Number("2.5"), false},
+		{"json_numbers", json.Number("-1e400"), json.Number("-1"), true},
+		{"json_number_big", uint64(math.MaxUint64), json.Number("18446744073709551616"), true},
+		{"json_number_string", json.Number("1"), "2", false},
 		{"empty_string_sting", "", "x", true},
 		{"empty_strings", "", "", false},
 		{"string_a_b", "a", "b", true},
@@ -207,6 +243,9 @@ func TestSameType(t *testing.T) {
 		{"nil_vals", Value(nil), Value(nil), true},
 		{"int_float_vals", Value(1), Value(98.6), true},
 		{"int64_uint32_vals", Value(int64(1)), Value(uint32(8)), true},
+		{"json_number_int_vals", Value(json.Number("1")), Value(2), true},
+		{"float_json_number_vals", Value(1.5), Value(json.Number("2e3")), true},
+		{"json_number_string_vals", Value(json.Number("1")), Value("1"), false},
 		{"int_bool_vals", Value(1), Value(false), false},
 		{"string_obj_vals", Value("hi"), Value(map[string]any{}), false},
 		{"int64_array_vals", Value(int64(9)), Value([]any{}), false},
@@ -337,6 +376,38 @@ func TestComparisonExpr(t *testing.T) {
 			expect: []bool{false, true, false, true, false, true},
 			str:    `$["y"] %v $["x"]`,
 		},
+		{
+			name:    "query_array_literal_eq",
+			left:    SingularQuery(false, []Selector{Name("tags")}),
+			right:   Literal([]any{"a", int64(1)}),
+			current: map[string]any{"tags": []any{"a", 1.0}},
+			expect:  []bool{true, false, false, false, true, true},
+			str:     `@["tags"] %v ["a",1]`,
+		},
+		{
+			name:    "query_array_literal_ne",
+			left:    SingularQuery(false, []Selector{Name("tags")}),
+			right:   Literal([]any{"a", int64(1)}),
+			current: map[string]any{"tags": []any{"a", 2.0}},
+			expect:  []bool{false, true, false, false, false, false},
+			str:     `@["tags"] %v ["a",1]`,
+		},
+		{
+			name:   "object_literal_query_eq",
+			left:   Literal(map[string]any{"y": []any{}, "x": int64(0)}),
+			right:  SingularQuery(true, []Selector{Name("p")}),
+			root:   map[string]any{"p": map[string]any{"x": 0.0, "y": []any{}}},
+			expect: []bool{true, false, false, false, true, true},
+			str:    `{"x":0,"y":[]} %v $["p"]`,
+		},
+		{
+			name:   "object_queries_ne",
+			left:   SingularQuery(true, []Selector{Name("p")}),
+			right:  SingularQuery(true, []Selector{Name("q")}),
+			root:   map[string]any{"p": map[string]any{"x": 0}, "q": map[string]any{"x": 1}},
+			expect: []bool{false, true, false, false, false, false},
+			str:    `$["p"] %v $["q"]`,
+		},
 		{
 			name: "func_numbers_eq",
 			left: &FunctionExpr{
@@ -412,3 +483,14 @@ func TestComparisonExpr(t *testing.T) {
 		})
 	}
 }
+
+// orderedObject returns an OrderedObject with the members named and valued
+// by alternating elements of kv.
+func orderedObject(kv ...any) *OrderedObject {
+	obj := &OrderedObject{}
+	for i := 0; i < len(kv); i += 2 {
+		name, _ := kv[i].(string)
+		obj.Set(name, kv[i+1])
+	}
+	return obj
+}
@@ -0,0 +1,182 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+)
+
+// OrderedMap is implemented by JSON object types that preserve the order of
+// their members. Wildcard, filter, and descendant selectors select the
+// members of an OrderedMap in the order All returns them, rather than in
+// the random order of a map[string]any.
+type OrderedMap interface {
+	// Len returns the number of members.
+	Len() int
+
+	// Get returns the value of the member named name and true, or false if
+	// no member has that name.
+	Get(name string) (any, bool)
+
+	// All returns an iterator over the names and values of the members, in
+	// order.
+	All() iter.Seq2[string, any]
+}
+
+// OrderedObject is an [OrderedMap] that stores members in the order in
+// which they're first set. The zero value is an empty object ready to use.
+// Its JSON methods preserve member order, too; use [UnmarshalOrdered] to
+// decode JSON into OrderedObject values for querying.
+type OrderedObject struct {
+	names []string
+	vals  map[string]any
+}
+
+// Len returns the number of members of o.
+func (o *OrderedObject) Len() int {
+	return len(o.names)
+}
+
+// Get returns the value of the member of o named name and true, or false if
+// o has no member with that name.
+func (o *OrderedObject) Get(name string) (any, bool) {
+	val, ok := o.vals[name]
+	return val, ok
+}
+
+// Set sets the value of the member of o named name. Appends new members to
+// the end of o, while existing members keep their positions.
+func (o *OrderedObject) Set(name string, val any) {
+	if o.vals == nil {
+		o.vals = map[string]any{}
+	}
+	if _, ok := o.vals[name]; !ok {
+		o.names = append(o.names, name)
+	}
+	o.vals[name] = val
+}
+
+// Delete removes the member of o named name, if any.
+func (o *OrderedObject) Delete(name string) {
+	if _, ok := o.vals[name]; !ok {
+		return
+	}
+	delete(o.vals, name)
+	o.names = slices.DeleteFunc(o.names, func(n string) bool { return n == name })
+}
+
+// All returns an iterator over the names and values of the members of o,
+// in order.
+func (o *OrderedObject) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, name := range o.names {
+			if !yield(name, o.vals[name]) {
+				return
+			}
+		}
+	}
+}
+
+// MarshalJSON encodes o as a JSON object with its members in order.
+func (o *OrderedObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i, name := range o.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(o.vals[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes data, which must be a JSON object, into o,
+// replacing its members. Decodes nested objects as *OrderedObject values,
+// as for [UnmarshalOrdered].
+func (o *OrderedObject) UnmarshalJSON(data []byte) error {
+	val, err := UnmarshalOrdered(data)
+	if err != nil {
+		return err
+	}
+	obj, ok := val.(*OrderedObject)
+	if !ok {
+		return fmt.Errorf("cannot unmarshal %v into OrderedObject", InfoOf(val).Type)
+	}
+	*o = *obj
+	return nil
+}
+
+// UnmarshalOrdered decodes JSON data like [json.Unmarshal] into an any
+// value, except that it decodes objects as *OrderedObject values, so that
+// queries select their members in the order they appear in data. As for a
+// map, the value of the last of duplicate member names wins, but at the
+// position of the first.
+func UnmarshalOrdered(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	val, err := decodeOrderedValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return val, nil
+}
+
+// decodeOrderedValue decodes the next value from dec, decoding objects as
+// *OrderedObject values.
+func decodeOrderedValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		obj := &OrderedObject{}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			name, _ := tok.(string)
+			val, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(name, val)
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			val, err := decodeOrderedValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		_, err := dec.Token()
+		return arr, err
+	default:
+		return tok, nil
+	}
+}
@@ -0,0 +1,212 @@
+package spec
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedObject(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	var obj OrderedObject
+	a.Equal(0, obj.Len())
+	val, ok := obj.Get("x")
+	a.False(ok)
+	a.Nil(val)
+	obj.Delete("x")
+	a.Empty(maps.Collect(obj.All()))
+
+	obj.Set("z", 1)
+	obj.Set("a", 2)
+	obj.Set("m", 3)
+	obj.Set("a", 4)
+	a.Equal(3, obj.Len())
+	val, ok = obj.Get("a")
+	a.True(ok)
+	a.Equal(4, val)
+
+	names := []string{}
+	vals := []any{}
+	for k, v := range obj.All() {
+		names = append(names, k)
+		vals = append(vals, v)
+	}
+	a.Equal([]string{"z", "a", "m"}, names)
+	a.Equal([]any{1, 4, 3}, vals)
+
+	// Stop iteration early.
+	for k := range obj.All() {
+		a.Equal("z", k)
+		break
+	}
+
+	obj.Delete("a")
+	a.Equal(2, obj.Len())
+	_, ok = obj.Get("a")
+	a.False(ok)
+	obj.Set("a", 5)
+	a.Equal([]string{"z", "m", "a"}, obj.names)
+}
+
+func TestOrderedObjectJSON(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	src := `{"z":1,"a":{"y":[true,null,{"q":"x","b":2.5}],"b":"hi"},"m":[]}`
+	var obj OrderedObject
+	r.NoError(json.Unmarshal([]byte(src), &obj))
+	a.Equal(3, obj.Len())
+
+	js, err := json.Marshal(&obj)
+	r.NoError(err)
+	a.JSONEq(src, string(js))
+	a.Equal(src, string(js))
+
+	inner, ok := obj.Get("a")
+	r.True(ok)
+	a.IsType(&OrderedObject{}, inner)
+
+	// Empty object.
+	js, err = json.Marshal(&OrderedObject{})
+	r.NoError(err)
+	a.Equal("{}", string(js))
+
+	// Unmarshal errors.
+	a.EqualError(json.Unmarshal([]byte(`[1]`), &obj), "cannot unmarshal array into OrderedObject")
+	a.Error(json.Unmarshal([]byte(`{"a":}`), &obj))
+
+	// Marshal errors.
+	bad := &OrderedObject{}
+	bad.Set("f", func() {})
+	_, err = json.Marshal(bad)
+	r.Error(err)
+}
+
+func TestUnmarshalOrdered(t *testing.T) {
+	t.Parallel()
+
+	ordered := func(members ...any) *OrderedObject {
+		obj := &OrderedObject{}
+		for i := 0; i < len(members); i += 2 {
+			obj.Set(members[i].(string), members[i+1])
+		}
+		return obj
+	}
+
+	for _, tc := range []struct {
+		name string
+		data string
+		exp  any
+		err  bool
+	}{
+		{"null", "null", nil, false},
+		{"number", " 42 ", float64(42), false},
+		{"string", `"x"`, "x", false},
+		{"array", `[1, "x", {}]`, []any{float64(1), "x", &OrderedObject{}}, false},
+		{"empty_array", `[]`, []any{}, false},
+		{
+			name: "object",
+			data: `{"b": 1, "a": [{"d": 1, "c": 2}], "b": 3}`,
+			exp:  ordered("b", float64(3), "a", []any{ordered("d", float64(1), "c", float64(2))}),
+		},
+		{"empty", "", nil, true},
+		{"truncated_object", `{"a": 1`, nil, true},
+		{"truncated_array", `[1`, nil, true},
+		{"bad_value", `{"a": x}`, nil, true},
+		{"bad_array", `[x]`, nil, true},
+		{"bad_name", `{1: 2}`, nil, true},
+		{"trailing", `{} {}`, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			val, err := UnmarshalOrdered([]byte(tc.data))
+			if tc.err {
+				// Messages vary by encoding/json implementation.
+				a.Error(err)
+				a.Nil(val)
+			} else {
+				a.NoError(err)
+				a.Equal(tc.exp, val)
+			}
+		})
+	}
+}
+
+func TestSelectOrdered(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	input, err := UnmarshalOrdered([]byte(`{
+		"c": {"x": 1},
+		"a": {"x": 2},
+		"b": {"x": 3, "y": {"x": 4}}
+	}`))
+	r.NoError(err)
+	x := SingularQuery(false, []Selector{Name("x")})
+
+	for _, tc := range []struct {
+		name  string
+		query *PathQuery
+		exp   []any
+		paths []string
+	}{
+		{
+			name:  "name",
+			query: Query(true, []*Segment{Child(Name("b")), Child(Name("y")), Child(Name("x"))}),
+			exp:   []any{float64(4)},
+			paths: []string{"$['b']['y']['x']"},
+		},
+		{
+			name:  "wildcard",
+			query: Query(true, []*Segment{Child(Wildcard()), Child(Name("x"))}),
+			exp:   []any{float64(1), float64(2), float64(3)},
+			paths: []string{"$['c']['x']", "$['a']['x']", "$['b']['x']"},
+		},
+		{
+			name: "filter",
+			query: Query(true, []*Segment{
+				Child(Filter(LogicalOr{LogicalAnd{Comparison(x, GreaterThan, Literal(1))}})),
+				Child(Name("x")),
+			}),
+			exp:   []any{float64(2), float64(3)},
+			paths: []string{"$['a']['x']", "$['b']['x']"},
+		},
+		{
+			name: "filter_equal_map",
+			query: Query(true, []*Segment{
+				Child(Filter(LogicalOr{LogicalAnd{Comparison(
+					SingularQuery(false, []Selector{}),
+					EqualTo,
+					Literal(map[string]any{"x": float64(2)}),
+				)}})),
+				Child(Name("x")),
+			}),
+			exp:   []any{float64(2)},
+			paths: []string{"$['a']['x']"},
+		},
+		{
+			name:  "descendant",
+			query: Query(true, []*Segment{Descendant(Name("x"))}),
+			exp:   []any{float64(1), float64(2), float64(3), float64(4)},
+			paths: []string{"$['c']['x']", "$['a']['x']", "$['b']['x']", "$['b']['y']['x']"},
+		},
+	} {
+		a.Equal(tc.exp, tc.query.Select(nil, input), tc.name)
+		located := tc.query.SelectLocated(nil, input, nil)
+		paths := make([]string, len(located))
+		for i, n := range located {
+			paths[i] = n.Path.String()
+		}
+		a.Equal(tc.paths, paths, tc.name)
+	}
+
+	a.Equal(&NodeInfo{Type: JSONObject, Len: 3}, InfoOf(input))
+}
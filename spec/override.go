@@ -0,0 +1,33 @@
+package spec
+
+import "maps"
+
+// OverrideFunctions wraps root so that function expressions in filter
+// expressions execute the evaluator in funcs with the name of their
+// function, if any, rather than the function they were parsed with. Use it
+// to evaluate a query with functions that depend on data scoped to a single
+// evaluation, such as a function that checks the permissions of the user
+// making a request, without parsing the query again.
+//
+// Overrides replace only evaluation: parsing validates the arguments to
+// each function and determines its result type, and so an override must
+// accept the same arguments and return the same type as the function it
+// overrides.
+//
+// Pass the result as the root argument to the Select and SelectLocated
+// methods of [PathQuery], [Segment], and [Selector] for the duration of a
+// single evaluation. Wrapping it again with OverrideFunctions adds the
+// evaluators in funcs, which take precedence over those with the same
+// names.
+func OverrideFunctions(root any, funcs map[string]func(args []JSONPathValue) JSONPathValue) any {
+	r := wrapRoot(root)
+	if r.overrides != nil && funcs != nil {
+		merged := maps.Clone(r.overrides)
+		maps.Copy(merged, funcs)
+		funcs = merged
+	}
+	if funcs != nil {
+		r.overrides = funcs
+	}
+	return r
+}
@@ -0,0 +1,88 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideFunctions(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	root := map[string]any{
+		"t": 2,
+		"a": []any{
+			map[string]any{"owner": "alice", "n": 1},
+			map[string]any{"owner": "bob", "n": 2},
+			map[string]any{"owner": "carol", "n": 3},
+		},
+	}
+
+	// Mock up a function that never grants permission by default.
+	perm := &testFunc{
+		name:   "perm",
+		result: FuncLogical,
+		eval:   func([]JSONPathValue) JSONPathValue { return LogicalFalse },
+	}
+	allow := func(users ...string) map[string]func([]JSONPathValue) JSONPathValue {
+		return map[string]func([]JSONPathValue) JSONPathValue{
+			"perm": func(args []JSONPathValue) JSONPathValue {
+				owner := ValueFrom(args[0]).Value()
+				for _, u := range users {
+					if owner == u {
+						return LogicalTrue
+					}
+				}
+				return LogicalFalse
+			},
+		}
+	}
+
+	// $.a[?perm(@.owner) && @.n >= $.t].n
+	filter := Filter(LogicalOr{LogicalAnd{
+		Function(perm, []FunctionExprArg{SingularQuery(false, []Selector{Name("owner")})}),
+		Comparison(
+			SingularQuery(false, []Selector{Name("n")}),
+			GreaterThanEqualTo,
+			SingularQuery(true, []Selector{Name("t")}),
+		),
+	}})
+	query := Query(true, []*Segment{Child(Name("a")), Child(filter), Child(Name("n"))})
+
+	a.Empty(query.Select(nil, root))
+
+	alice := OverrideFunctions(root, allow("alice", "bob"))
+	a.Equal(root, rootValue(alice))
+	a.Nil(rootOf(alice).singularCache())
+	a.Equal([]any{2}, query.Select(nil, alice))
+	a.Equal(
+		[]*LocatedNode{{Node: 2, Path: NormalizedPath{Name("a"), Index(1), Name("n")}}},
+		query.SelectLocated(nil, alice, nil),
+	)
+
+	// Overrides apply only to functions with the same name.
+	other := OverrideFunctions(root, map[string]func([]JSONPathValue) JSONPathValue{
+		"other": func([]JSONPathValue) JSONPathValue { return LogicalTrue },
+	})
+	a.Empty(query.Select(nil, other))
+
+	// Overrides combine with the singular cache in either order.
+	cachedFirst := OverrideFunctions(CacheSingular(root), allow("bob", "carol"))
+	a.Equal(root, rootValue(cachedFirst))
+	a.NotNil(rootOf(cachedFirst).singularCache())
+	a.Equal([]any{2, 3}, query.Select(nil, cachedFirst))
+
+	cachedLast := CacheSingular(OverrideFunctions(root, allow("carol")))
+	a.Equal(root, rootValue(cachedLast))
+	a.NotNil(rootOf(cachedLast).singularCache())
+	a.Equal([]any{3}, query.Select(nil, cachedLast))
+
+	// An outer override of a name takes precedence over an inner one.
+	nested := OverrideFunctions(OverrideFunctions(root, allow("alice", "bob", "carol")), other.(*evalRoot).overrides)
+	a.Equal([]any{2, 3}, query.Select(nil, nested))
+	nested = OverrideFunctions(OverrideFunctions(root, allow("alice", "bob", "carol")), allow("carol"))
+	a.Equal([]any{3}, query.Select(nil, nested))
+	a.Nil(rootOf(nested).override("nope"))
+	a.Nil(rootOf(root).override("perm"))
+}
@@ -0,0 +1,58 @@
+package spec
+
+import "sync"
+
+const (
+	// maxPooledLen is the maximum capacity of slices returned to a pool, so
+	// that pools do not retain the large slices of unusually large
+	// selections.
+	maxPooledLen = 1 << 16
+
+	// pathBufLen is the initial capacity of pooled normalized path buffers,
+	// enough for the depth of most JSON documents.
+	pathBufLen = 32
+)
+
+// nodePool pools the slices that hold the intermediate results of
+// [PathQuery.Select].
+//
+//nolint:gochecknoglobals
+var nodePool = sync.Pool{New: func() any {
+	s := make([]any, 0, 64)
+	return &s
+}}
+
+// locatedPool pools the slices that hold the intermediate results of
+// [PathQuery.SelectLocated].
+//
+//nolint:gochecknoglobals
+var locatedPool = sync.Pool{New: func() any {
+	s := make([]*LocatedNode, 0, 64)
+	return &s
+}}
+
+// pathPool pools the normalized path buffers used by descendant segments to
+// build the paths of the nodes they select.
+//
+//nolint:gochecknoglobals
+var pathPool = sync.Pool{New: func() any {
+	p := make([]NormalSelector, 0, pathBufLen)
+	return &p
+}}
+
+// getSlice returns an empty slice from pool.
+func getSlice[T any](pool *sync.Pool) *[]T {
+	s, _ := pool.Get().(*[]T)
+	return s
+}
+
+// putSlice clears s and returns it to pool, unless it has grown too large
+// to retain.
+func putSlice[T any](pool *sync.Pool, s *[]T) {
+	if cap(*s) > maxPooledLen {
+		return
+	}
+	clear(*s)
+	*s = (*s)[:0]
+	pool.Put(s)
+}
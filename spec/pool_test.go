@@ -0,0 +1,66 @@
+package spec
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutSlice(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	a.Empty(*getSlice[any](&nodePool))
+
+	pool := &sync.Pool{}
+	s := []any{1, "x"}
+	putSlice(pool, &s)
+	a.Empty(s)
+	a.Equal([]any{nil, nil}, s[:2], "should clear elements")
+
+	// Does not pool oversized slices.
+	big := make([]any, maxPooledLen+1)
+	putSlice(pool, &big)
+	a.Len(big, maxPooledLen+1)
+	got, _ := pool.Get().(*[]any)
+	a.True(got != &big, "should not pool oversized slices")
+}
+
+func TestSelectPooled(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Results do not share memory with pooled intermediate results.
+	doc := map[string]any{"a": []any{
+		map[string]any{"b": 1}, map[string]any{"b": 2}, map[string]any{"b": 3},
+	}}
+	query := Query(true, []*Segment{Child(Name("a")), Child(Wildcard()), Child(Name("b"))})
+	first := query.Select(nil, doc)
+	located := query.SelectLocated(nil, doc, nil)
+	for range 10 {
+		query.Select(nil, doc)
+		query.SelectLocated(nil, doc, nil)
+	}
+	a.Equal([]any{1, 2, 3}, first)
+	a.Equal([]*LocatedNode{
+		{Node: 1, Path: NormalizedPath{Name("a"), Index(0), Name("b")}},
+		{Node: 2, Path: NormalizedPath{Name("a"), Index(1), Name("b")}},
+		{Node: 3, Path: NormalizedPath{Name("a"), Index(2), Name("b")}},
+	}, located)
+
+	// Descendant paths deeper than the pooled path buffer.
+	var deep any = "x"
+	for range pathBufLen + 8 {
+		deep = []any{deep}
+	}
+	nodes := Descendant(Index(0)).SelectLocated(deep, deep, NormalizedPath{Name("deep")})
+	a.Len(nodes, pathBufLen+8)
+	last := nodes[len(nodes)-1]
+	a.Equal("x", last.Node)
+	a.Equal("$['deep']"+strings.Repeat("[0]", pathBufLen+8), last.Path.String())
+	for i, n := range nodes {
+		a.Len(n.Path, i+2)
+	}
+}
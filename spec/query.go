@@ -1,7 +1,5 @@
 package spec
 
-import "strings"
-
 // PathQuery represents a JSONPath expression.
 type PathQuery struct {
 	segments []*Segment
@@ -18,18 +16,41 @@ func (q *PathQuery) Segments() []*Segment {
 	return q.segments
 }
 
+// IsRoot returns true if q selects from the root node ($) and false if it
+// selects from the current node (@).
+func (q *PathQuery) IsRoot() bool {
+	return q.root
+}
+
 // String returns a string representation of q.
 func (q *PathQuery) String() string {
-	buf := new(strings.Builder)
+	buf := newWriter()
+	q.writeTo(buf)
+	return buf.String()
+}
+
+// Format returns a string representation of q formatted according to opt.
+// With no options, returns the same result as [PathQuery.String]: names
+// and string literals in double quotation marks and all segments in
+// bracket notation, such as $["store"]["book"][?@["price"] < 10]. Use
+// [WithSingleQuotes] and [WithDotNotation] to format q in other common
+// styles, such as $.store.book[?@.price < 10].
+func (q *PathQuery) Format(opt ...FormatOption) string {
+	buf := newWriter(opt...)
+	q.writeTo(buf)
+	return buf.String()
+}
+
+// writeTo writes a string representation of q to buf.
+func (q *PathQuery) writeTo(buf *writer) {
 	if q.root {
 		buf.WriteRune('$')
 	} else {
 		buf.WriteRune('@')
 	}
 	for _, s := range q.segments {
-		buf.WriteString(s.String())
+		s.writeTo(buf)
 	}
-	return buf.String()
 }
 
 // Select selects q.segments from current or root and returns the result.
@@ -38,35 +59,142 @@ func (q *PathQuery) String() string {
 func (q *PathQuery) Select(current, root any) []any {
 	res := []any{current}
 	if q.root {
-		res[0] = root
+		res[0] = rootValue(root)
 	}
-	for _, seg := range q.segments {
-		segRes := []any{}
+	var buf *[]any
+	for i, seg := range q.segments {
+		if i == len(q.segments)-1 {
+			// Allocate the final result, which the caller retains.
+			segRes := make([]any, 0, resultHint(seg, res))
+			for _, v := range res {
+				segRes = seg.appendSelected(segRes, v, root)
+			}
+			res = segRes
+			break
+		}
+
+		// Collect intermediate results in pooled slices.
+		next := getSlice[any](&nodePool)
 		for _, v := range res {
-			segRes = append(segRes, seg.Select(v, root)...)
+			*next = seg.appendSelected(*next, v, root)
 		}
-		res = segRes
+		if buf != nil {
+			putSlice(&nodePool, buf)
+		}
+		buf, res = next, *next
+	}
+	if buf != nil {
+		putSlice(&nodePool, buf)
 	}
 
 	return res
 }
 
+// Exists returns true if q selects at least one node from current or root.
+// Unlike [PathQuery.Select], it collects no results: it evaluates q
+// depth-first and stops at the first node selected, testing the children
+// of a node against a filter selector only until one leads to a match.
+func (q *PathQuery) Exists(current, root any) bool {
+	if q.root {
+		current = rootValue(root)
+	}
+	return anySelected(q.segments, current, root)
+}
+
+// anySelected returns true if segs select at least one node from current.
+func anySelected(segs []*Segment, current, root any) bool {
+	if len(segs) == 0 {
+		return true
+	}
+	seg, rest := segs[0], segs[1:]
+	match := func(v any) bool { return anySelected(rest, v, root) }
+	for _, sel := range seg.selectors {
+		if f, ok := sel.(*FilterSelector); ok {
+			if f.anyMatch(current, root, match) {
+				return true
+			}
+			continue
+		}
+		for _, v := range sel.Select(current, root) {
+			if match(v) {
+				return true
+			}
+		}
+	}
+
+	if seg.descendant {
+		switch val := decodeOrdered(current).(type) {
+		case []any:
+			for _, v := range val {
+				if anySelected(segs, v, root) {
+					return true
+				}
+			}
+		case map[string]any:
+			for _, v := range val {
+				if anySelected(segs, v, root) {
+					return true
+				}
+			}
+		case OrderedMap:
+			for _, m := range membersOf(val) {
+				if anySelected(segs, m.val, root) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// resultHint returns the initial capacity for the results of selecting seg
+// from each of nodes: the size hint of seg for a single node, and otherwise
+// the number of nodes.
+func resultHint[T any](seg *Segment, nodes []T) int {
+	if len(nodes) != 1 {
+		return len(nodes)
+	}
+	var node any = nodes[0]
+	if n, ok := node.(*LocatedNode); ok {
+		node = n.Node
+	}
+	return max(1, seg.sizeHint(node))
+}
+
 // SelectLocated selects q.segments from current or root and returns the
 // resulting values as [LocatedNode] structs. Returns just current if q has no
 // segments. Defined by the [Selector] interface.
 func (q *PathQuery) SelectLocated(current, root any, parent NormalizedPath) []*LocatedNode {
 	res := []*LocatedNode{nil}
 	if q.root {
-		res[0] = newLocatedNode(nil, root)
+		res[0] = newLocatedNode(nil, rootValue(root))
 	} else {
 		res[0] = newLocatedNode(parent, current)
 	}
-	for _, seg := range q.segments {
-		segRes := []*LocatedNode{}
+	var buf *[]*LocatedNode
+	for i, seg := range q.segments {
+		if i == len(q.segments)-1 {
+			// Allocate the final result, which the caller retains.
+			segRes := make([]*LocatedNode, 0, resultHint(seg, res))
+			for _, v := range res {
+				segRes = seg.appendLocated(segRes, v.Node, root, v.Path)
+			}
+			res = segRes
+			break
+		}
+
+		// Collect intermediate results in pooled slices.
+		next := getSlice[*LocatedNode](&locatedPool)
 		for _, v := range res {
-			segRes = append(segRes, seg.SelectLocated(v.Node, root, v.Path)...)
+			*next = seg.appendLocated(*next, v.Node, root, v.Path)
 		}
-		res = segRes
+		if buf != nil {
+			putSlice(&locatedPool, buf)
+		}
+		buf, res = next, *next
+	}
+	if buf != nil {
+		putSlice(&locatedPool, buf)
 	}
 
 	return res
@@ -36,6 +36,7 @@ func TestQueryRoot(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			q := Query(false, nil)
+			a.False(q.IsRoot())
 			a.Equal([]any{tc.val}, q.Select(tc.val, nil))
 		})
 	}
@@ -72,12 +73,12 @@ func TestQueryString(t *testing.T) {
 		},
 		{
 			name: "segs_plus_descendant",
-			segs: []*Segment{Child(Name("x"), Name("y")), Child(Wildcard), Descendant(Index(0))},
+			segs: []*Segment{Child(Name("x"), Name("y")), Child(Wildcard()), Descendant(Index(0))},
 			str:  `["x","y"][*]..[0]`,
 		},
 		{
 			name: "segs_with_slice",
-			segs: []*Segment{Child(Name("x"), Slice(2)), Child(Wildcard), Descendant(Index(0))},
+			segs: []*Segment{Child(Name("x"), Slice(2)), Child(Wildcard()), Descendant(Index(0))},
 			str:  `["x",2:][*]..[0]`,
 		},
 	} {
@@ -86,6 +87,7 @@ func TestQueryString(t *testing.T) {
 			q := Query(false, tc.segs)
 			a.Equal("@"+tc.str, q.String())
 			q = Query(true, tc.segs)
+			a.True(q.IsRoot())
 			a.Equal("$"+tc.str, q.String())
 		})
 	}
@@ -192,7 +194,7 @@ func TestQueryObject(t *testing.T) {
 		{
 			name: "wildcard_keys",
 			segs: []*Segment{
-				Child(Wildcard),
+				Child(Wildcard()),
 				Child(Name("a"), Name("b")),
 			},
 			input: map[string]any{
@@ -211,7 +213,7 @@ func TestQueryObject(t *testing.T) {
 		{
 			name: "any_key_indexes",
 			segs: []*Segment{
-				Child(Wildcard),
+				Child(Wildcard()),
 				Child(Index(0), Index(1)),
 			},
 			input: map[string]any{
@@ -229,7 +231,7 @@ func TestQueryObject(t *testing.T) {
 		},
 		{
 			name: "any_key_nonexistent_index",
-			segs: []*Segment{Child(Wildcard), Child(Index(1))},
+			segs: []*Segment{Child(Wildcard()), Child(Index(1))},
 			input: map[string]any{
 				"x": []any{"a", "go", "b", 2, "c", 5},
 				"y": []any{"a"},
@@ -255,7 +257,7 @@ func TestQueryObject(t *testing.T) {
 		},
 		{
 			name:  "wildcard_then_nonexistent_key",
-			segs:  []*Segment{Child(Wildcard), Child(Name("x"))},
+			segs:  []*Segment{Child(Wildcard()), Child(Name("x"))},
 			input: map[string]any{"y": map[string]any{"a": 1}},
 			exp:   []any{},
 			loc:   []*LocatedNode{},
@@ -382,7 +384,7 @@ func TestQueryArray(t *testing.T) {
 		},
 		{
 			name:  "wildcard_indexes_index",
-			segs:  []*Segment{Child(Wildcard), Child(Index(0), Index(2))},
+			segs:  []*Segment{Child(Wildcard()), Child(Index(0), Index(2))},
 			input: []any{[]any{1, 2, 3}, []any{3, 2, 1}, []any{4, 5, 6}},
 			exp:   []any{1, 3, 3, 1, 4, 6},
 			loc: []*LocatedNode{
@@ -403,7 +405,7 @@ func TestQueryArray(t *testing.T) {
 		},
 		{
 			name:  "nonexistent_child_index",
-			segs:  []*Segment{Child(Wildcard), Child(Index(3))},
+			segs:  []*Segment{Child(Wildcard()), Child(Index(3))},
 			input: []any{[]any{0, 1, 2, 3}, []any{0, 1, 2}},
 			exp:   []any{3},
 			loc: []*LocatedNode{
@@ -426,7 +428,7 @@ func TestQueryArray(t *testing.T) {
 		},
 		{
 			name:  "wildcard_not_an_array_index_1",
-			segs:  []*Segment{Child(Wildcard), Child(Index(0))},
+			segs:  []*Segment{Child(Wildcard()), Child(Index(0))},
 			input: []any{"x", true},
 			exp:   []any{},
 			loc:   []*LocatedNode{},
@@ -434,7 +436,7 @@ func TestQueryArray(t *testing.T) {
 		{
 			name: "mix_wildcard_keys",
 			segs: []*Segment{
-				Child(Wildcard, Index(1)),
+				Child(Wildcard(), Index(1)),
 				Child(Name("x"), Index(1), Name("y")),
 			},
 			input: []any{
@@ -460,7 +462,7 @@ func TestQueryArray(t *testing.T) {
 		{
 			name: "mix_wildcard_nonexistent_key",
 			segs: []*Segment{
-				Child(Wildcard, Index(1)),
+				Child(Wildcard(), Index(1)),
 				Child(Name("x"), Name("y")),
 			},
 			input: []any{
@@ -479,7 +481,7 @@ func TestQueryArray(t *testing.T) {
 		{
 			name: "mix_wildcard_index",
 			segs: []*Segment{
-				Child(Wildcard, Index(1)),
+				Child(Wildcard(), Index(1)),
 				Child(Index(0), Index(1)),
 			},
 			input: []any{
@@ -502,7 +504,7 @@ func TestQueryArray(t *testing.T) {
 		{
 			name: "mix_wildcard_nonexistent_index",
 			segs: []*Segment{
-				Child(Wildcard, Index(1)),
+				Child(Wildcard(), Index(1)),
 				Child(Index(0), Index(3)),
 			},
 			input: []any{
@@ -520,7 +522,7 @@ func TestQueryArray(t *testing.T) {
 		},
 		{
 			name: "wildcard_nonexistent_key",
-			segs: []*Segment{Child(Wildcard), Child(Name("a"))},
+			segs: []*Segment{Child(Wildcard()), Child(Name("a"))},
 			input: []any{
 				map[string]any{"a": 1, "b": 2},
 				map[string]any{"z": 3, "b": 4},
@@ -532,7 +534,7 @@ func TestQueryArray(t *testing.T) {
 		},
 		{
 			name: "wildcard_nonexistent_middle_key",
-			segs: []*Segment{Child(Wildcard), Child(Name("a"))},
+			segs: []*Segment{Child(Wildcard()), Child(Name("a"))},
 			input: []any{
 				map[string]any{"a": 1, "b": 2},
 				map[string]any{"z": 3, "b": 4},
@@ -548,8 +550,8 @@ func TestQueryArray(t *testing.T) {
 		{
 			name: "wildcard_nested_nonexistent_key",
 			segs: []*Segment{
-				Child(Wildcard),
-				Child(Wildcard),
+				Child(Wildcard()),
+				Child(Wildcard()),
 				Child(Name("a")),
 			},
 			input: []any{
@@ -569,8 +571,8 @@ func TestQueryArray(t *testing.T) {
 		{
 			name: "wildcard_nested_nonexistent_index",
 			segs: []*Segment{
-				Child(Wildcard),
-				Child(Wildcard),
+				Child(Wildcard()),
+				Child(Wildcard()),
 				Child(Index(1)),
 			},
 			input: []any{
@@ -800,7 +802,7 @@ func TestQuerySlice(t *testing.T) {
 		{
 			name: "wildcard_slices_index",
 			segs: []*Segment{
-				Child(Wildcard),
+				Child(Wildcard()),
 				Child(Slice(0, 2), Slice(3, 4)),
 			},
 			input: []any{
@@ -830,7 +832,7 @@ func TestQuerySlice(t *testing.T) {
 		},
 		{
 			name:  "nonexistent_branch_index",
-			segs:  []*Segment{Child(Wildcard), Child(Slice(3, 5))},
+			segs:  []*Segment{Child(Wildcard()), Child(Slice(3, 5))},
 			input: []any{[]any{0, 1, 2, 3, 4}, []any{0, 1, 2}},
 			exp:   []any{3, 4},
 			loc: []*LocatedNode{
@@ -854,7 +856,7 @@ func TestQuerySlice(t *testing.T) {
 		},
 		{
 			name:  "wildcard_not_an_array_index_1",
-			segs:  []*Segment{Child(Wildcard), Child(Slice(0, 5))},
+			segs:  []*Segment{Child(Wildcard()), Child(Slice(0, 5))},
 			input: []any{"x", true},
 			exp:   []any{},
 			loc:   []*LocatedNode{},
@@ -975,7 +977,7 @@ func TestQuerySlice(t *testing.T) {
 			name: "slice_nested_nonexistent_key",
 			segs: []*Segment{
 				Child(Slice(0, 5)),
-				Child(Wildcard),
+				Child(Wildcard()),
 				Child(Name("a")),
 			},
 			input: []any{
@@ -996,7 +998,7 @@ func TestQuerySlice(t *testing.T) {
 			name: "slice_nested_nonexistent_index",
 			segs: []*Segment{
 				Child(Slice(0, 5)),
-				Child(Wildcard),
+				Child(Wildcard()),
 				Child(Index(1)),
 			},
 			input: []any{
@@ -1105,7 +1107,7 @@ func TestQueryDescendants(t *testing.T) {
 		},
 		{
 			name:  "nested_wildcard",
-			segs:  []*Segment{Child(Name("o")), Descendant(Wildcard)},
+			segs:  []*Segment{Child(Name("o")), Descendant(Wildcard())},
 			input: json,
 			exp:   []any{1, 2},
 			loc: []*LocatedNode{
@@ -1250,6 +1252,8 @@ func TestQueryInputs(t *testing.T) {
 		q.SelectLocated(x, y, NormalizedPath{}),
 	)
 	a.Equal([]*LocatedNode{}, q.SelectLocated(y, x, NormalizedPath{}))
+	a.True(q.Exists(x, y))
+	a.False(q.Exists(y, x))
 
 	// Test root.
 	q.root = true
@@ -1260,6 +1264,110 @@ func TestQueryInputs(t *testing.T) {
 		[]*LocatedNode{{Path: NormalizedPath{Name("x")}, Node: "x"}},
 		q.SelectLocated(y, x, NormalizedPath{}),
 	)
+	a.False(q.Exists(x, y))
+	a.True(q.Exists(y, x))
+}
+
+func TestQueryExists(t *testing.T) {
+	t.Parallel()
+
+	// Count the nodes tested by a filter function.
+	calls := 0
+	seen := &testFunc{
+		name:   "seen",
+		result: FuncLogical,
+		eval: func([]JSONPathValue) JSONPathValue {
+			calls++
+			return LogicalTrue
+		},
+	}
+	seenFilter := Filter(LogicalOr{LogicalAnd{Function(seen, nil)}})
+
+	ordered := &OrderedObject{}
+	ordered.Set("a", []any{1, 2})
+	ordered.Set("b", []any{3, 4})
+	doc := map[string]any{"x": []any{[]any{1, 2}, []any{3, 4}}, "o": ordered}
+
+	for _, tc := range []struct {
+		name  string
+		query *PathQuery
+		exp   bool
+		calls int
+	}{
+		{
+			name:  "no_segments",
+			query: Query(true, nil),
+			exp:   true,
+		},
+		{
+			name:  "name",
+			query: Query(true, []*Segment{Child(Name("x"))}),
+			exp:   true,
+		},
+		{
+			name:  "missing",
+			query: Query(true, []*Segment{Child(Name("y"), Index(0))}),
+			exp:   false,
+		},
+		{
+			name:  "union_second",
+			query: Query(true, []*Segment{Child(Name("y"), Name("x")), Child(Index(1))}),
+			exp:   true,
+		},
+		{
+			name:  "filter_first",
+			query: Query(true, []*Segment{Child(Name("x")), Child(seenFilter)}),
+			exp:   true,
+			calls: 1,
+		},
+		{
+			name:  "filter_then_missing",
+			query: Query(true, []*Segment{Child(Name("x")), Child(seenFilter), Child(Index(5))}),
+			exp:   false,
+			calls: 2,
+		},
+		{
+			name:  "descendant_first",
+			query: Query(true, []*Segment{Descendant(seenFilter)}),
+			exp:   true,
+			calls: 1,
+		},
+		{
+			name:  "descendant_ordered",
+			query: Query(true, []*Segment{Child(Name("o")), Descendant(Index(1))}),
+			exp:   true,
+		},
+		{
+			name:  "descendant_missing",
+			query: Query(true, []*Segment{Descendant(Name("nope"))}),
+			exp:   false,
+		},
+		{
+			name: "nested_exists",
+			query: Query(true, []*Segment{Child(Name("x")), Child(Filter(LogicalOr{LogicalAnd{
+				Existence(Query(false, []*Segment{Child(seenFilter)})),
+			}}))}),
+			exp:   true,
+			calls: 1,
+		},
+		{
+			name: "nested_nonexists",
+			query: Query(true, []*Segment{Child(Name("x")), Child(Filter(LogicalOr{LogicalAnd{
+				Nonexistence(Query(false, []*Segment{Child(seenFilter)})),
+			}}))}),
+			exp:   false,
+			calls: 2,
+		},
+	} {
+		// Not parallel: the cases share the calls counter.
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+			calls = 0
+			a.Equal(tc.exp, tc.query.Exists(nil, doc))
+			a.Equal(tc.calls, calls)
+			a.Equal(tc.exp, len(tc.query.Select(nil, doc)) > 0)
+		})
+	}
 }
 
 func TestSingularExpr(t *testing.T) {
@@ -1295,7 +1403,7 @@ func TestSingularExpr(t *testing.T) {
 		},
 		{
 			name:  "wildcard",
-			query: Query(false, []*Segment{Child(Wildcard)}),
+			query: Query(false, []*Segment{Child(Wildcard())}),
 		},
 		{
 			name:  "filter",
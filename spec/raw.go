@@ -0,0 +1,125 @@
+package spec
+
+import (
+	"encoding/json"
+	"iter"
+)
+
+// objectMember is a member of an ordered JSON object.
+type objectMember struct {
+	name string
+	val  any
+}
+
+// rawObject lists the members of a JSON object in the order they appear in
+// the object. Implements [OrderedMap].
+type rawObject []objectMember
+
+// Len returns the number of members of obj.
+func (obj rawObject) Len() int { return len(obj) }
+
+// Get returns the value of the member of obj named name and true, or false
+// if obj has no member with that name.
+func (obj rawObject) Get(name string) (any, bool) {
+	for _, m := range obj {
+		if m.name == name {
+			return m.val, true
+		}
+	}
+	return nil, false
+}
+
+// All returns an iterator over the names and values of the members of obj,
+// in order.
+func (obj rawObject) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for _, m := range obj {
+			if !yield(m.name, m.val) {
+				return
+			}
+		}
+	}
+}
+
+// membersOf returns the members of obj in order. Selectors iterate over the
+// result rather than obj.All(), so that the variables they use in the loop
+// need not escape to the heap when selecting from other types.
+func membersOf(obj OrderedMap) []objectMember {
+	if raw, ok := obj.(rawObject); ok {
+		return raw
+	}
+	res := make([]objectMember, 0, obj.Len())
+	for k, v := range obj.All() {
+		res = append(res, objectMember{k, v})
+	}
+	return res
+}
+
+// decodeDeep fully decodes every [json.RawMessage] in val, adapts every
+// [Adapter], and converts every [OrderedMap] to a map[string]any and every
+// [Array] to a []any, so that values that are partly decoded by [decodeRaw],
+// ordered, or of a custom data model compare equal to the same values
+// decoded up front into maps and slices. Returns val unchanged if it
+// contains none of these values.
+func decodeDeep(val any) any {
+	res, _ := decodeDeepChanged(val)
+	return res
+}
+
+// decodeDeepChanged implements [decodeDeep], returning true if it decoded
+// or converted any values, in which case it returns copies of objects and
+// arrays rather than modifying them.
+func decodeDeepChanged(val any) (any, bool) {
+	switch val := val.(type) {
+	case json.RawMessage:
+		return decodeMessage(val)
+	case Adapter:
+		res, _ := decodeDeepChanged(val.Adapt())
+		return res, true
+	case OrderedMap:
+		res := make(map[string]any, val.Len())
+		for k, v := range val.All() {
+			res[k] = decodeDeep(v)
+		}
+		return res, true
+	case Array:
+		res := make([]any, val.Len())
+		for i := range res {
+			res[i] = decodeDeep(val.Index(i))
+		}
+		return res, true
+	case []any:
+		var res []any
+		for i, v := range val {
+			if d, ok := decodeDeepChanged(v); ok {
+				if res == nil {
+					res = append(make([]any, 0, len(val)), val...)
+				}
+				res[i] = d
+			}
+		}
+		if res == nil {
+			return val, false
+		}
+		return res, true
+	case map[string]any:
+		var res map[string]any
+		for k, v := range val {
+			if d, ok := decodeDeepChanged(v); ok {
+				if res == nil {
+					res = make(map[string]any, len(val))
+					for k, v := range val {
+						res[k] = v
+					}
+				}
+				res[k] = d
+			}
+		}
+		if res == nil {
+			return val, false
+		}
+		return res, true
+	default:
+		return val, false
+	}
+}
@@ -0,0 +1,132 @@
+//go:build !jsonpath_minimal
+
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// decodeRaw lazily decodes val if it's a [json.RawMessage], so that
+// selectors can descend into it. Decodes objects and arrays only one level
+// deep: their scalar members become native Go values, while their object and
+// array members remain json.RawMessage values until a selector descends into
+// them, too. Adapts val if it's an [Adapter]. Returns val unchanged if it's
+// neither a json.RawMessage nor an Adapter, or fails to decode.
+func decodeRaw(val any) any {
+	raw, ok := val.(json.RawMessage)
+	if !ok {
+		if a, ok := val.(Adapter); ok {
+			return a.Adapt()
+		}
+		return val
+	}
+
+	switch firstByte(raw) {
+	case '{':
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(raw, &obj) != nil {
+			return val
+		}
+		res := make(map[string]any, len(obj))
+		for k, v := range obj {
+			res[k] = lazyMember(v)
+		}
+		return res
+	case '[':
+		var arr []json.RawMessage
+		if json.Unmarshal(raw, &arr) != nil {
+			return val
+		}
+		res := make([]any, len(arr))
+		for i, v := range arr {
+			res[i] = lazyMember(v)
+		}
+		return res
+	default:
+		var res any
+		if json.Unmarshal(raw, &res) != nil {
+			return val
+		}
+		return res
+	}
+}
+
+// decodeOrdered decodes val like [decodeRaw], except that it decodes a
+// [json.RawMessage] object into a rawObject, so that selectors that iterate
+// over object members select them in the order they appear in the JSON, and
+// copies the elements of an [Array] into a []any, so that selectors iterate
+// over them as over the elements of any array.
+func decodeOrdered(val any) any {
+	raw, ok := val.(json.RawMessage)
+	if !ok || firstByte(raw) != '{' {
+		return decodeArray(val)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil {
+		return val
+	}
+	obj := rawObject{}
+	seen := map[string]int{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return val
+		}
+		name, _ := tok.(string)
+		var member json.RawMessage
+		if err := dec.Decode(&member); err != nil {
+			return val
+		}
+		// As when decoding into a map, the last of duplicate names wins.
+		if i, ok := seen[name]; ok {
+			obj[i].val = lazyMember(member)
+			continue
+		}
+		seen[name] = len(obj)
+		obj = append(obj, objectMember{name, lazyMember(member)})
+	}
+	if _, err := dec.Token(); err != nil {
+		return val
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		// Trailing data.
+		return val
+	}
+	return obj
+}
+
+// lazyMember returns raw, a member of an object or array decoded by
+// [decodeRaw], unchanged if it's an object or array and decoded otherwise.
+func lazyMember(raw json.RawMessage) any {
+	switch firstByte(raw) {
+	case '{', '[':
+		return raw
+	}
+	var res any
+	_ = json.Unmarshal(raw, &res) // Already validated by decodeRaw.
+	return res
+}
+
+// firstByte returns the first byte of raw that's not JSON blank space, or 0
+// if raw is empty.
+func firstByte(raw json.RawMessage) byte {
+	raw = bytes.TrimLeft(raw, " \t\r\n")
+	if len(raw) == 0 {
+		return 0
+	}
+	return raw[0]
+}
+
+// decodeMessage fully decodes raw and returns the result and true, or raw
+// and false if raw is not valid JSON.
+func decodeMessage(raw json.RawMessage) (any, bool) {
+	var res any
+	if json.Unmarshal(raw, &res) != nil {
+		return raw, false
+	}
+	return res, true
+}
@@ -0,0 +1,249 @@
+//go:build !jsonpath_minimal
+
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// skipRaw does nothing, because json.RawMessage values decode unless built
+// with the jsonpath_minimal build tag.
+func skipRaw(*testing.T) {}
+
+func TestDecodeRaw(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		val  any
+		exp  any
+	}{
+		{"not_raw", map[string]any{"x": 1}, map[string]any{"x": 1}},
+		{"null", json.RawMessage("null"), nil},
+		{"true", json.RawMessage(" true"), true},
+		{"number", json.RawMessage("42.5"), 42.5},
+		{"string", json.RawMessage(`"hi"`), "hi"},
+		{"empty", json.RawMessage(""), json.RawMessage("")},
+		{"invalid", json.RawMessage(`{"x"`), json.RawMessage(`{"x"`)},
+		{
+			name: "object",
+			val:  json.RawMessage(`{"a": 1, "b": "x", "c": [1, 2], "d": {"e": null}, "f": null}`),
+			exp: map[string]any{
+				"a": float64(1),
+				"b": "x",
+				"c": json.RawMessage("[1, 2]"),
+				"d": json.RawMessage(`{"e": null}`),
+				"f": nil,
+			},
+		},
+		{
+			name: "array",
+			val:  json.RawMessage("\n[true, {}, [], 3]"),
+			exp:  []any{true, json.RawMessage("{}"), json.RawMessage("[]"), float64(3)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, decodeRaw(tc.val))
+		})
+	}
+}
+
+func TestDecodeOrdered(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		val  any
+		exp  any
+	}{
+		{"not_raw", map[string]any{"x": 1}, map[string]any{"x": 1}},
+		{"array", json.RawMessage(`[1, {}]`), []any{float64(1), json.RawMessage(`{}`)}},
+		{"scalar", json.RawMessage(`"x"`), "x"},
+		{"empty", json.RawMessage(" {}"), rawObject{}},
+		{
+			name: "object",
+			val:  json.RawMessage(`{"z": 1, "a": [true], "m": {"x": null}}`),
+			exp: rawObject{
+				{"z", float64(1)},
+				{"a", json.RawMessage("[true]")},
+				{"m", json.RawMessage(`{"x": null}`)},
+			},
+		},
+		{
+			name: "duplicate",
+			val:  json.RawMessage(`{"b": 1, "a": 2, "b": 3}`),
+			exp:  rawObject{{"b", float64(3)}, {"a", float64(2)}},
+		},
+		{"truncated", json.RawMessage(`{"a": 1`), json.RawMessage(`{"a": 1`)},
+		{"bad_member", json.RawMessage(`{"a": }`), json.RawMessage(`{"a": }`)},
+		{"trailing", json.RawMessage(`{"a": 1} {}`), json.RawMessage(`{"a": 1} {}`)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, decodeOrdered(tc.val))
+		})
+	}
+}
+
+func TestDecodeDeep(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		val  any
+		exp  any
+	}{
+		{"scalar", 1, 1},
+		{"raw", json.RawMessage(`{"a": [1]}`), map[string]any{"a": []any{float64(1)}}},
+		{"invalid", json.RawMessage(`[`), json.RawMessage(`[`)},
+		{"plain_array", []any{1, "x"}, []any{1, "x"}},
+		{"plain_object", map[string]any{"a": 1}, map[string]any{"a": 1}},
+		{
+			name: "nested_array",
+			val:  []any{1, json.RawMessage(`{"b": 2}`)},
+			exp:  []any{1, map[string]any{"b": float64(2)}},
+		},
+		{
+			name: "nested_object",
+			val:  map[string]any{"a": 1, "b": json.RawMessage(`[true]`)},
+			exp:  map[string]any{"a": 1, "b": []any{true}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.exp, decodeDeep(tc.val))
+		})
+	}
+
+	// Does not modify its argument.
+	val := []any{json.RawMessage(`1`)}
+	decodeDeep(val)
+	assert.Equal(t, []any{json.RawMessage(`1`)}, val)
+}
+
+func TestSelectRaw(t *testing.T) {
+	t.Parallel()
+
+	input := json.RawMessage(`{
+		"store": {
+			"book": [
+				{"title": "Sayings", "price": 8.95, "tags": ["a", "b"]},
+				{"title": "Sword", "price": 12.99, "tags": []}
+			],
+			"blob": {"huge": [1, 2, 3]}
+		}
+	}`)
+
+	for _, tc := range []struct {
+		name  string
+		query *PathQuery
+		exp   []any
+	}{
+		{
+			name:  "name",
+			query: Query(true, []*Segment{Child(Name("store")), Child(Name("blob"))}),
+			exp:   []any{json.RawMessage(`{"huge": [1, 2, 3]}`)},
+		},
+		{
+			name: "index_name",
+			query: Query(true, []*Segment{
+				Child(Name("store")), Child(Name("book")), Child(Index(-1)), Child(Name("title")),
+			}),
+			exp: []any{"Sword"},
+		},
+		{
+			name: "slice_wildcard",
+			query: Query(true, []*Segment{
+				Child(Name("store")), Child(Name("book")), Child(Slice(0, 1)), Child(Name("tags")), Child(Wildcard()),
+			}),
+			exp: []any{"a", "b"},
+		},
+		{
+			name: "filter",
+			query: Query(true, []*Segment{
+				Child(Name("store")),
+				Child(Name("book")),
+				Child(Filter(LogicalOr{LogicalAnd{Comparison(
+					SingularQuery(false, []Selector{Name("price")}),
+					LessThan,
+					Literal(10),
+				)}})),
+				Child(Name("title")),
+			}),
+			exp: []any{"Sayings"},
+		},
+		{
+			name: "filter_equal_object",
+			query: Query(true, []*Segment{
+				Child(Name("store")),
+				Child(Filter(LogicalOr{LogicalAnd{Comparison(
+					SingularQuery(false, []Selector{}),
+					EqualTo,
+					SingularQuery(true, []Selector{Name("store"), Name("blob")}),
+				)}})),
+				Child(Name("huge")),
+				Child(Index(0)),
+			}),
+			exp: []any{float64(1)},
+		},
+		{
+			name:  "descendant",
+			query: Query(true, []*Segment{Descendant(Name("huge")), Child(Wildcard())}),
+			exp:   []any{float64(1), float64(2), float64(3)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.ElementsMatch(t, tc.exp, tc.query.Select(nil, input))
+		})
+	}
+}
+
+func TestSelectRawOrder(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	input := json.RawMessage(`{"c": {"x": 1}, "a": {"x": 2}, "b": {"x": 3, "y": {"x": 4}}}`)
+	x := SingularQuery(false, []Selector{Name("x")})
+
+	for _, tc := range []struct {
+		name  string
+		query *PathQuery
+		exp   []any
+		paths []string
+	}{
+		{
+			name:  "wildcard",
+			query: Query(true, []*Segment{Child(Wildcard()), Child(Name("x"))}),
+			exp:   []any{float64(1), float64(2), float64(3)},
+			paths: []string{"$['c']['x']", "$['a']['x']", "$['b']['x']"},
+		},
+		{
+			name: "filter",
+			query: Query(true, []*Segment{
+				Child(Filter(LogicalOr{LogicalAnd{Comparison(x, GreaterThan, Literal(1))}})),
+				Child(Name("x")),
+			}),
+			exp:   []any{float64(2), float64(3)},
+			paths: []string{"$['a']['x']", "$['b']['x']"},
+		},
+		{
+			name:  "descendant",
+			query: Query(true, []*Segment{Descendant(Name("x"))}),
+			exp:   []any{float64(1), float64(2), float64(3), float64(4)},
+			paths: []string{"$['c']['x']", "$['a']['x']", "$['b']['x']", "$['b']['y']['x']"},
+		},
+	} {
+		a.Equal(tc.exp, tc.query.Select(nil, input), tc.name)
+		located := tc.query.SelectLocated(nil, input, nil)
+		paths := make([]string, len(located))
+		for i, n := range located {
+			paths[i] = n.Path.String()
+		}
+		a.Equal(tc.paths, paths, tc.name)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build jsonpath_minimal
+
+package spec
+
+import "encoding/json"
+
+// decodeRaw adapts val if it's an [Adapter], and otherwise returns val
+// unchanged. The jsonpath_minimal build tag omits the decoding of
+// [json.RawMessage] values, which selectors therefore treat as values
+// with no JSON representation.
+func decodeRaw(val any) any {
+	if a, ok := val.(Adapter); ok {
+		return a.Adapt()
+	}
+	return val
+}
+
+// decodeOrdered copies the elements of an [Array] into a []any, as
+// described by [decodeArray], and otherwise returns val like [decodeRaw].
+func decodeOrdered(val any) any {
+	return decodeArray(val)
+}
+
+// decodeMessage returns raw and false, because the jsonpath_minimal build
+// tag omits the decoding of [json.RawMessage] values.
+func decodeMessage(raw json.RawMessage) (any, bool) {
+	return raw, false
+}
@@ -0,0 +1,35 @@
+//go:build jsonpath_minimal
+
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// skipRaw skips t, because the jsonpath_minimal build tag omits the
+// decoding of json.RawMessage values.
+func skipRaw(t *testing.T) {
+	t.Helper()
+	t.Skip("jsonpath_minimal omits json.RawMessage decoding")
+}
+
+func TestDecodeRawMinimal(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	raw := json.RawMessage(`{"a": [1]}`)
+	a.Equal(raw, decodeRaw(raw))
+	a.Equal(raw, decodeOrdered(raw))
+	a.Equal([]any{raw}, decodeDeep([]any{raw}))
+	a.Equal("x", decodeRaw(testAdapter{"x"}))
+	a.Equal([]any{1, 2}, decodeOrdered(testArray{1, 2}))
+
+	// Selects nothing from a json.RawMessage.
+	q := Query(true, []*Segment{Child(Name("a"))})
+	a.Empty(q.Select(raw, raw))
+	doc := map[string]any{"a": raw}
+	a.Equal([]any{raw}, q.Select(doc, doc))
+}
@@ -0,0 +1,26 @@
+package spec
+
+import "encoding/json"
+
+// containsRaw returns true if val is or contains a [json.RawMessage], so
+// that tests can call skipRaw for values that the jsonpath_minimal build
+// tag cannot decode.
+func containsRaw(val any) bool {
+	switch val := val.(type) {
+	case json.RawMessage:
+		return true
+	case []any:
+		for _, v := range val {
+			if containsRaw(v) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, v := range val {
+			if containsRaw(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package spec
+
+import "fmt"
+
+// Rewrite returns a copy of the abstract syntax tree rooted at node, with
+// nodes replaced by fn. It rewrites the children of each node, as defined
+// by [Walk], before the node itself, then passes fn a copy of the node with
+// the rewritten children, and uses the node fn returns in place of the
+// node. To leave a node unchanged, fn returns its argument. Rewrite never
+// modifies the tree rooted at node.
+//
+// Use Rewrite to substitute values into parsed queries rather than into
+// query strings, e.g., to replace a placeholder string literal with a
+// value from user input, which cannot change the structure of the query:
+//
+//	q = spec.Rewrite(q, func(node spec.Node) spec.Node {
+//		if lit, ok := node.(*spec.LiteralArg); ok && lit.Value() == "{{user}}" {
+//			return spec.Literal(user)
+//		}
+//		return node
+//	}).(*spec.PathQuery)
+//
+// Rewrite does not validate the rewritten query, e.g., that the arguments
+// of a function expression have the types it requires. Panics if fn returns
+// a node that cannot replace the original in its parent, such as a
+// [Selector] in place of a [BasicExpr].
+func Rewrite(node Node, fn func(node Node) Node) Node {
+	switch node := node.(type) {
+	case *PathQuery:
+		return fn(Query(node.root, rewriteAll[*Segment](node.segments, fn)))
+	case *Segment:
+		return fn(&Segment{
+			selectors:  rewriteAll[Selector](node.selectors, fn),
+			descendant: node.descendant,
+		})
+	case *FilterSelector:
+		return fn(Filter(rewriteAs[LogicalOr](node.LogicalOr, fn)))
+	case *ParenExpr:
+		return fn(Paren(rewriteAs[LogicalOr](node.LogicalOr, fn)))
+	case *NotParenExpr:
+		return fn(NotParen(rewriteAs[LogicalOr](node.LogicalOr, fn)))
+	case LogicalOr:
+		return fn(LogicalOr(rewriteAll[LogicalAnd](node, fn)))
+	case LogicalAnd:
+		return fn(LogicalAnd(rewriteAll[BasicExpr](node, fn)))
+	case *ExistExpr:
+		return fn(Existence(rewriteAs[*PathQuery](node.PathQuery, fn)))
+	case *NonExistExpr:
+		return fn(Nonexistence(rewriteAs[*PathQuery](node.PathQuery, fn)))
+	case NonExistExpr:
+		return fn(NonExistExpr{rewriteAs[*PathQuery](node.PathQuery, fn)})
+	case *FilterQueryExpr:
+		return fn(FilterQuery(rewriteAs[*PathQuery](node.PathQuery, fn)))
+	case *ComparisonExpr:
+		return fn(Comparison(
+			rewriteAs[CompVal](node.Left, fn), node.Op,
+			rewriteAs[CompVal](node.Right, fn),
+		))
+	case *MembershipExpr:
+		return fn(Membership(
+			rewriteAs[CompVal](node.Left, fn),
+			rewriteAs[CompVal](node.Right, fn),
+		))
+	case *ArithmeticExpr:
+		return fn(Arithmetic(
+			rewriteAs[CompVal](node.Left, fn), node.Op,
+			rewriteAs[CompVal](node.Right, fn),
+		))
+	case NotFuncExpr:
+		return fn(NotFunction(rewriteAs[*FunctionExpr](node.FunctionExpr, fn)))
+	case *FunctionExpr:
+		// Function prepares the function for the rewritten arguments.
+		return fn(Function(node.fn, rewriteAll[FunctionExprArg](node.args, fn)))
+	case *SingularQueryExpr:
+		return fn(&SingularQueryExpr{
+			relative:  node.relative,
+			selectors: rewriteAll[Selector](node.selectors, fn),
+		})
+	case *LiteralArg:
+		return fn(Literal(cloneLiteral(node.literal)))
+	case *ValueType:
+		return fn(&ValueType{cloneLiteral(node.any)})
+	default:
+		// Values such as Name and Index, and custom selectors.
+		return fn(node)
+	}
+}
+
+// Clone returns a deep copy of the abstract syntax tree rooted at node,
+// such as a *[PathQuery], which shares no mutable state with node. Clone
+// does not copy custom [Selector] implementations or the [PathFunction]
+// values of function expressions.
+func Clone[T Node](node T) T {
+	return Rewrite(node, func(node Node) Node { return node }).(T)
+}
+
+// rewriteAs rewrites node with fn and returns the result as a T. Panics if
+// the result is not a T.
+func rewriteAs[T any](node Node, fn func(node Node) Node) T {
+	res := Rewrite(node, fn)
+	t, ok := res.(T)
+	if !ok {
+		panic(fmt.Sprintf("cannot replace %T with %T", node, res))
+	}
+	return t
+}
+
+// rewriteAll rewrites each of nodes with fn and returns the results in a
+// new slice.
+func rewriteAll[T, E any](nodes []E, fn func(node Node) Node) []T {
+	if nodes == nil {
+		return nil
+	}
+	res := make([]T, len(nodes))
+	for i, node := range nodes {
+		res[i] = rewriteAs[T](node, fn)
+	}
+	return res
+}
+
+// cloneLiteral returns a deep copy of lit, a JSON scalar or a []any or
+// map[string]any of literals.
+func cloneLiteral(lit any) any {
+	switch lit := lit.(type) {
+	case []any:
+		res := make([]any, len(lit))
+		for i, v := range lit {
+			res[i] = cloneLiteral(v)
+		}
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(lit))
+		for k, v := range lit {
+			res[k] = cloneLiteral(v)
+		}
+		return res
+	default:
+		return lit
+	}
+}
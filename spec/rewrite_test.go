@@ -0,0 +1,163 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	fn := Function(newValueFunc(1), []FunctionExprArg{
+		FilterQuery(Query(false, []*Segment{Child(Name("c"))})),
+		LogicalOr{LogicalAnd{Existence(Query(false, nil))}},
+	})
+
+	for _, tc := range []struct {
+		name string
+		node Node
+	}{
+		{"query", Query(true, []*Segment{Child(Name("a"), Index(1)), Descendant(Wildcard(), Slice(1, 3))})},
+		{"empty_query", Query(false, nil)},
+		{"segment", Child(Name("a"))},
+		{"name", Name("a")},
+		{"filter", Filter(LogicalOr{
+			LogicalAnd{
+				Comparison(SingularQuery(false, []Selector{Name("b")}), EqualTo, Literal([]any{1, map[string]any{"x": nil}})),
+				Membership(SingularQuery(true, []Selector{Index(0)}), Literal([]any{"a"})),
+			},
+			LogicalAnd{Paren(LogicalOr{LogicalAnd{
+				Nonexistence(Query(false, []*Segment{Child(Name("d"))})),
+				NonExistExpr{Query(false, []*Segment{Child(Name("e"))})},
+			}})},
+			LogicalAnd{NotParen(LogicalOr{LogicalAnd{NotFunction(fn)}})},
+			LogicalAnd{Comparison(Arithmetic(fn, Multiply, Literal(2)), LessThan, Literal(3.5))},
+			LogicalAnd{Value(true)},
+		})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			clone := Clone(tc.node)
+			a.Equal(tc.node, clone)
+			a.Equal(bufString(tc.node.(stringWriter)), bufString(clone.(stringWriter)))
+
+			// The clone shares no nodes with the original.
+			original := map[Node]bool{}
+			Walk(tc.node, func(node Node) bool {
+				if isPointer(node) {
+					original[node] = true
+				}
+				return true
+			})
+			Walk(clone, func(node Node) bool {
+				a.False(isPointer(node) && original[node], "shared %T", node)
+				return true
+			})
+		})
+	}
+
+	// Composite literals are deep copies.
+	lit := Literal(map[string]any{"x": []any{1}})
+	clone := Clone(lit)
+	clone.Value().(map[string]any)["x"].([]any)[0] = 2
+	assert.Equal(t, map[string]any{"x": []any{1}}, lit.Value())
+}
+
+// isPointer returns true if node is a pointer.
+func isPointer(node Node) bool {
+	switch node.(type) {
+	case *PathQuery, *Segment, *FilterSelector, *ParenExpr, *NotParenExpr,
+		*ExistExpr, *NonExistExpr, *FilterQueryExpr, *ComparisonExpr,
+		*MembershipExpr, *ArithmeticExpr, *FunctionExpr, *SingularQueryExpr,
+		*LiteralArg, *ValueType:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// $[?@.user == "{{user}}"]["id"]
+	query := Query(true, []*Segment{
+		Child(Filter(LogicalOr{LogicalAnd{Comparison(
+			SingularQuery(false, []Selector{Name("user")}), EqualTo, Literal("{{user}}"),
+		)}})),
+		Child(Name("id")),
+	})
+	input := []any{
+		map[string]any{"user": "alice", "id": 1},
+		map[string]any{"user": `" || true || "`, "id": 2},
+	}
+
+	// Substitute a placeholder literal.
+	substitute := func(user string) *PathQuery {
+		return Rewrite(query, func(node Node) Node {
+			if lit, ok := node.(*LiteralArg); ok && lit.Value() == "{{user}}" {
+				return Literal(user)
+			}
+			return node
+		}).(*PathQuery)
+	}
+
+	q := substitute("alice")
+	a.Equal(`$[?@["user"] == "alice"]["id"]`, q.String())
+	a.Equal([]any{1}, q.Select(input, input))
+	q = substitute(`" || true || "`)
+	a.Equal(`$[?@["user"] == "\" || true || \""]["id"]`, q.String())
+	a.Equal([]any{2}, q.Select(input, input))
+
+	// The original is unchanged.
+	a.Equal(`$[?@["user"] == "{{user}}"]["id"]`, query.String())
+
+	// Rewrite root queries as relative queries.
+	q = Rewrite(Query(true, []*Segment{Child(Filter(LogicalOr{LogicalAnd{
+		Comparison(SingularQuery(true, []Selector{Name("x")}), EqualTo, Literal(1)),
+		Existence(Query(true, []*Segment{Child(Name("y"))})),
+	}}))}), func(node Node) Node {
+		switch node := node.(type) {
+		case *SingularQueryExpr:
+			return SingularQuery(false, node.Selectors())
+		case *PathQuery:
+			return Query(false, node.Segments())
+		}
+		return node
+	}).(*PathQuery)
+	a.Equal(`@[?@["x"] == 1 && @["y"]]`, q.String())
+
+	// Rewritten function arguments are prepared again.
+	prepared := []FunctionExprArg{}
+	fn := &testPreparedFunc{
+		testFunc: *newValueFunc(1),
+		prepare: func(args []FunctionExprArg) func([]JSONPathValue) JSONPathValue {
+			prepared = append(prepared, args[0])
+			return nil
+		},
+	}
+	fe := Function(fn, []FunctionExprArg{Literal("a")})
+	fe = Rewrite(fe, func(node Node) Node {
+		if _, ok := node.(*LiteralArg); ok {
+			return Literal("b")
+		}
+		return node
+	}).(*FunctionExpr)
+	a.Equal([]FunctionExprArg{Literal("a"), Literal("b")}, prepared)
+	a.Equal(`__val("b")`, bufString(fe))
+
+	// Replacing a node with a node of an incompatible type panics.
+	r.PanicsWithValue("cannot replace spec.Name with *spec.LiteralArg", func() {
+		Rewrite(Child(Name("a")), func(node Node) Node {
+			if _, ok := node.(Name); ok {
+				return Literal("a")
+			}
+			return node
+		})
+	})
+}
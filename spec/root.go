@@ -0,0 +1,85 @@
+package spec
+
+// evalRoot wraps a root value with the options of a single evaluation, as
+// set by [CacheSingular], [FoldNames], [Collate], and [OverrideFunctions],
+// and by filter selectors for [ContextFunction]s. Each copies the evalRoot
+// that wraps the root, if any, rather than wrapping it again, so that a
+// root is wrapped only once and its options are read without unwrapping.
+type evalRoot struct {
+	root any
+
+	// cache caches the values of absolute singular queries.
+	cache *singularCache
+
+	// fold makes name selectors match member names case-insensitively.
+	fold bool
+
+	// key is the collation for comparing strings.
+	key Collation
+
+	// overrides map function names to the evaluators that override them.
+	overrides map[string]func(args []JSONPathValue) JSONPathValue
+
+	// parent and path record the parent and normalized path of the node
+	// tested by a filter selector, for ContextFunctions.
+	parent any
+	path   NormalizedPath
+}
+
+// wrapRoot returns a copy of the evalRoot that wraps root, or a new
+// evalRoot wrapping root if there is none.
+func wrapRoot(root any) *evalRoot {
+	if r, ok := root.(*evalRoot); ok {
+		wrapped := *r
+		return &wrapped
+	}
+	return &evalRoot{root: root}
+}
+
+// rootOf returns the evalRoot that wraps root, or nil if root is not
+// wrapped. The methods of evalRoot accept a nil receiver.
+func rootOf(root any) *evalRoot {
+	r, _ := root.(*evalRoot)
+	return r
+}
+
+// rootValue returns the root value wrapped by root, or root if it's not
+// wrapped.
+func rootValue(root any) any {
+	if r, ok := root.(*evalRoot); ok {
+		return r.root
+	}
+	return root
+}
+
+// singularCache returns the singular query cache set by [CacheSingular],
+// or nil if there is none.
+func (r *evalRoot) singularCache() *singularCache {
+	if r == nil {
+		return nil
+	}
+	return r.cache
+}
+
+// foldsNames returns true if [FoldNames] set r to fold names.
+func (r *evalRoot) foldsNames() bool {
+	return r != nil && r.fold
+}
+
+// collation returns the [Collation] set by [Collate], or nil if there is
+// none.
+func (r *evalRoot) collation() Collation {
+	if r == nil {
+		return nil
+	}
+	return r.key
+}
+
+// override returns the evaluator set by [OverrideFunctions] to override
+// the function named name, or nil if there is none.
+func (r *evalRoot) override(name string) func(args []JSONPathValue) JSONPathValue {
+	if r == nil {
+		return nil
+	}
+	return r.overrides[name]
+}
@@ -0,0 +1,51 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalRoot(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	root := map[string]any{"x": 1}
+	a.Nil(rootOf(root))
+	a.Equal(root, rootValue(root))
+
+	// A nil evalRoot has no options.
+	var none *evalRoot
+	a.Nil(none.singularCache())
+	a.False(none.foldsNames())
+	a.Nil(none.collation())
+	a.Nil(none.override("x"))
+
+	// Options wrap the root value only once.
+	cached := CacheSingular(root)
+	folded := FoldNames(cached)
+	collated := Collate(folded, FoldCase)
+	wrapped := OverrideFunctions(collated, map[string]func([]JSONPathValue) JSONPathValue{
+		"x": func([]JSONPathValue) JSONPathValue { return LogicalTrue },
+	})
+	r := rootOf(wrapped)
+	a.NotNil(r)
+	a.Equal(root, r.root)
+	a.Equal(root, rootValue(wrapped))
+	a.Same(rootOf(cached).singularCache(), r.singularCache())
+	a.True(r.foldsNames())
+	a.NotNil(r.collation())
+	a.NotNil(r.override("x"))
+
+	// Each option copies the evalRoot rather than modifying it.
+	a.False(rootOf(cached).foldsNames())
+	a.Nil(rootOf(folded).collation())
+	a.Nil(rootOf(collated).override("x"))
+
+	// So does the context of a filter selector.
+	path := NormalizedPath{Name("x")}
+	ctx := withContext(wrapped, root, path)
+	a.Equal(&EvalContext{Current: 1, Parent: root, Root: root, Path: path}, contextOf(1, ctx))
+	a.Equal(&EvalContext{Current: 1, Root: root}, contextOf(1, wrapped))
+	a.True(rootOf(ctx).foldsNames())
+}
@@ -1,9 +1,5 @@
 package spec
 
-import (
-	"strings"
-)
-
 // Segment represents a single segment in an RFC 9535 JSONPath query,
 // consisting of a list of Selectors and child Segments.
 type Segment struct {
@@ -32,7 +28,16 @@ func (s *Segment) Selectors() []Selector {
 // String returns a string representation of seg, including all of its child
 // segments in as a tree diagram.
 func (s *Segment) String() string {
-	buf := new(strings.Builder)
+	buf := newWriter()
+	s.writeTo(buf)
+	return buf.String()
+}
+
+// writeTo writes a string representation of s to buf.
+func (s *Segment) writeTo(buf *writer) {
+	if len(s.selectors) == 1 && buf.writeDotted(s.selectors[0], s.descendant) {
+		return
+	}
 	if s.descendant {
 		buf.WriteString("..")
 	}
@@ -41,71 +46,133 @@ func (s *Segment) String() string {
 		if i > 0 {
 			buf.WriteByte(',')
 		}
-		sel.writeTo(buf)
+		writeSelector(buf, sel)
 	}
 	buf.WriteByte(']')
-	return buf.String()
 }
 
 // Select selects and returns values from current or root for each of seg's
 // selectors. Defined by the [Selector] interface.
 func (s *Segment) Select(current, root any) []any {
-	ret := []any{}
-	for _, sel := range s.selectors {
-		ret = append(ret, sel.Select(current, root)...)
-	}
-	if s.descendant {
-		ret = append(ret, s.descend(current, root)...)
-	}
-	return ret
+	return s.appendSelected(make([]any, 0, s.sizeHint(current)), current, root)
 }
 
 // SelectLocated selects and returns values as [LocatedNode] structs from
 // current or root for each of seg's selectors. Defined by the [Selector]
 // interface.
 func (s *Segment) SelectLocated(current, root any, parent NormalizedPath) []*LocatedNode {
-	ret := []*LocatedNode{}
-	for _, sel := range s.selectors {
-		ret = append(ret, sel.SelectLocated(current, root, parent)...)
+	return s.appendLocated(make([]*LocatedNode, 0, s.sizeHint(current)), current, root, parent)
+}
+
+// sizeHint returns the initial capacity for the results of selecting from
+// current: one for each singular selector plus the number of children of
+// current for each other selector, such as a wildcard or filter selector,
+// and for the descendant segment itself. Exact for wildcards, and an upper
+// bound for filters.
+func (s *Segment) sizeHint(current any) int {
+	size := 0
+	switch val := current.(type) {
+	case []any:
+		size = len(val)
+	case map[string]any:
+		size = len(val)
+	case OrderedMap:
+		size = val.Len()
+	case Array:
+		size = val.Len()
 	}
+
+	hint := 0
 	if s.descendant {
-		ret = append(ret, s.descendLocated(current, root, parent)...)
+		hint = size
 	}
-	return ret
+	for _, sel := range s.selectors {
+		if isSingular(sel) {
+			hint++
+		} else {
+			hint += size
+		}
+	}
+	return hint
 }
 
-// descend recursively executes seg.Select for each value in current and/or
-// root and returns the results.
-func (s *Segment) descend(current, root any) []any {
-	ret := []any{}
-	switch val := current.(type) {
-	case []any:
-		for _, v := range val {
-			ret = append(ret, s.Select(v, root)...)
+// appendSelected appends the values s selects from current or root to dst
+// and returns the result. Descendant segments append the values they select
+// from descendants of current to the same slice, rather than allocate a
+// slice for each.
+func (s *Segment) appendSelected(dst []any, current, root any) []any {
+	for _, sel := range s.selectors {
+		dst = appendSelected(dst, sel, current, root)
+	}
+	if s.descendant {
+		switch val := decodeOrdered(current).(type) {
+		case []any:
+			for _, v := range val {
+				dst = s.appendSelected(dst, v, root)
+			}
+		case map[string]any:
+			for _, v := range val {
+				dst = s.appendSelected(dst, v, root)
+			}
+		case OrderedMap:
+			for _, m := range membersOf(val) {
+				dst = s.appendSelected(dst, m.val, root)
+			}
 		}
-	case map[string]any:
-		for _, v := range val {
-			ret = append(ret, s.Select(v, root)...)
+	}
+	return dst
+}
+
+// appendLocated appends the nodes s selects from current or root to dst and
+// returns the result. Descendant segments build the paths of descendants in
+// a pooled buffer, which is safe because [LocatedNode] structs copy their
+// paths.
+func (s *Segment) appendLocated(
+	dst []*LocatedNode,
+	current, root any,
+	parent NormalizedPath,
+) []*LocatedNode {
+	if !s.descendant {
+		for _, sel := range s.selectors {
+			dst = appendLocated(dst, sel, current, root, parent)
 		}
+		return dst
 	}
-	return ret
+
+	buf := getSlice[NormalSelector](&pathPool)
+	*buf = append(*buf, parent...)
+	dst = s.appendDescendants(dst, current, root, *buf)
+	putSlice(&pathPool, buf)
+	return dst
 }
 
-// descend recursively executes seg.Select for each value in current and/or
-// root and returns the results.
-func (s *Segment) descendLocated(current, root any, parent NormalizedPath) []*LocatedNode {
-	ret := []*LocatedNode{}
-	switch val := current.(type) {
+// appendDescendants appends the nodes s selects from current and all of its
+// descendants to dst and returns the result. Appends the normalized path
+// selector of each descendant to parent, overwriting that of its previous
+// sibling, so that parent must be a buffer that no selected node retains.
+func (s *Segment) appendDescendants(
+	dst []*LocatedNode,
+	current, root any,
+	parent NormalizedPath,
+) []*LocatedNode {
+	for _, sel := range s.selectors {
+		dst = appendLocated(dst, sel, current, root, parent)
+	}
+	switch val := decodeOrdered(current).(type) {
 	case []any:
 		for i, v := range val {
-			ret = append(ret, s.SelectLocated(v, root, append(parent, Index(i)))...)
+			dst = s.appendDescendants(dst, v, root, append(parent, Index(i)))
 		}
 	case map[string]any:
 		for k, v := range val {
-			ret = append(ret, s.SelectLocated(v, root, append(parent, Name(k)))...)
+			dst = s.appendDescendants(dst, v, root, append(parent, Name(k)))
+		}
+	case OrderedMap:
+		for _, m := range membersOf(val) {
+			dst = s.appendDescendants(dst, m.val, root, append(parent, Name(m.name)))
 		}
 	}
-	return ret
+	return dst
 }
 
 // isSingular returns true if the segment selects at most one node. Defined by
@@ -114,7 +181,7 @@ func (s *Segment) isSingular() bool {
 	if s.descendant || len(s.selectors) != 1 {
 		return false
 	}
-	return s.selectors[0].isSingular()
+	return isSingular(s.selectors[0])
 }
 
 // IsDescendant returns true if the segment is a descendant selector that
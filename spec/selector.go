@@ -1,11 +1,41 @@
 // Package spec provides object definitions and execution for RFC 9535
 // JSONPath query expressions. It remains under active development therefore
 // should generally not be used directly except for experimental purposes.
+//
+// # Data Model
+//
+// Selectors operate on the JSON data model: nil, bool, string, and number
+// scalars, arrays, and objects. They natively support arrays and objects
+// represented as []any and map[string]any, as decoded by encoding/json, as
+// well as [encoding/json.RawMessage] values, which they decode lazily.
+// Custom data models, such as YAML node trees, BSON documents, or protocol
+// buffer Structs, integrate by implementing these interfaces, rather than
+// by converting whole documents up front:
+//
+//   - [OrderedMap] for objects: Len, Get to look up a member by name, and
+//     All to iterate over members in order.
+//   - [Array] for arrays: Len and Index to look up an element by index.
+//   - [Adapter] for values that represent any JSON value, such as a node
+//     type that may be a mapping, sequence, or scalar.
+//
+// The members and elements of OrderedMap and Array values may themselves
+// be values of the custom model, so that selectors adapt only the parts of
+// a document into which a query descends, and queries select values of the
+// custom model.
+//
+// # Evaluation Options
+//
+// [CacheSingular], [FoldNames], [Collate], and [OverrideFunctions] wrap a
+// root value to configure a single evaluation. They combine in any order:
+// each adds its option to a root already wrapped by the others rather than
+// wrapping it again.
 package spec
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -14,13 +44,18 @@ import (
 // representations of themselves to a string buffer.
 type stringWriter interface {
 	// writeTo writes a string to buf.
-	writeTo(buf *strings.Builder)
+	writeTo(buf *writer)
 }
 
 // Selector represents a single Selector in an RFC 9535 JSONPath query.
+// Packages may implement Selector to define custom selectors, such as
+// those parsed by the parser's WithSelector option. A custom selector's
+// String method must return its query syntax, which segments write
+// between square brackets, and its SelectLocated method must return nodes
+// whose paths append [Name] or [Index] selectors to copies of parent, so
+// that they're valid normalized paths.
 type Selector interface {
 	fmt.Stringer
-	stringWriter
 
 	// Select selects values from current and/or root and returns them.
 	Select(current, root any) []any
@@ -28,12 +63,70 @@ type Selector interface {
 	// SelectLocated selects values from current and/or root and returns them
 	// in [LocatedNode] structs with their located normalized paths
 	SelectLocated(current, root any, parent NormalizedPath) []*LocatedNode
+}
 
+// singularSelector is implemented by selectors that can only return a
+// single value. Custom selectors are never singular.
+type singularSelector interface {
 	// isSingular returns true for selectors that can only return a single
 	// value.
 	isSingular() bool
 }
 
+// isSingular returns true if sel can only return a single value.
+func isSingular(sel Selector) bool {
+	s, ok := sel.(singularSelector)
+	return ok && s.isSingular()
+}
+
+// writeSelector writes the string representation of sel to buf, formatted
+// as configured by buf for the selectors of this package and as returned
+// by String for custom selectors.
+func writeSelector(buf *writer, sel Selector) {
+	if w, ok := sel.(stringWriter); ok {
+		w.writeTo(buf)
+		return
+	}
+	buf.WriteString(sel.String())
+}
+
+// appender is implemented by selectors that can append the values they
+// select to an existing slice, so that a [Segment] can collect the results
+// of all of its selectors, and of all of the descendants it selects from,
+// in a single slice rather than allocating a slice for each.
+type appender interface {
+	// appendSelected appends the values selected from current and/or root
+	// to dst and returns the result.
+	appendSelected(dst []any, current, root any) []any
+
+	// appendLocated appends the values selected from current and/or root
+	// to dst as [LocatedNode] structs and returns the result.
+	appendLocated(dst []*LocatedNode, current, root any, parent NormalizedPath) []*LocatedNode
+}
+
+// appendSelected appends the values sel selects from current and/or root to
+// dst and returns the result.
+func appendSelected(dst []any, sel Selector, current, root any) []any {
+	if a, ok := sel.(appender); ok {
+		return a.appendSelected(dst, current, root)
+	}
+	return append(dst, sel.Select(current, root)...)
+}
+
+// appendLocated appends the nodes sel selects from current and/or root to
+// dst and returns the result.
+func appendLocated(
+	dst []*LocatedNode,
+	sel Selector,
+	current, root any,
+	parent NormalizedPath,
+) []*LocatedNode {
+	if a, ok := sel.(appender); ok {
+		return a.appendLocated(dst, current, root, parent)
+	}
+	return append(dst, sel.SelectLocated(current, root, parent)...)
+}
+
 // Name is a key name selector, e.g., .name or ["name"].
 type Name string
 
@@ -41,49 +134,40 @@ type Name string
 // Defined by the [Selector] interface.
 func (Name) isSingular() bool { return true }
 
-// String returns a quoted string representation of n.
+// String returns a quoted string representation of n, as returned by
+// [Quote].
 func (n Name) String() string {
-	return strconv.Quote(string(n))
-}
-
-// writeTo writes a quoted string representation of i to buf.
-func (n Name) writeTo(buf *strings.Builder) {
-	buf.WriteString(n.String())
+	return Quote(string(n))
 }
 
-// Select selects n from input and returns it as a single value in a slice.
-// Returns an empty slice if input is not a map[string]any or if it does not
-// contain n. Defined by the [Selector] interface.
-func (n Name) Select(input, _ any) []any {
-	if obj, ok := input.(map[string]any); ok {
-		if val, ok := obj[string(n)]; ok {
-			return []any{val}
-		}
-	}
-	return make([]any, 0)
+// writeTo writes a quoted string representation of n to buf.
+func (n Name) writeTo(buf *writer) {
+	writeQuoted(buf, string(n), buf.quote)
 }
 
-// SelectLocated selects n from input and returns it with its normalized path
-// as a single [LocatedNode] in a slice. Returns an empty slice if input is
-// not a map[string]any or if it does not contain n. Defined by the [Selector]
-// interface.
-func (n Name) SelectLocated(input, _ any, parent NormalizedPath) []*LocatedNode {
-	if obj, ok := input.(map[string]any); ok {
-		if val, ok := obj[string(n)]; ok {
-			return []*LocatedNode{newLocatedNode(append(parent, n), val)}
-		}
-	}
-	return make([]*LocatedNode, 0)
+// Quote returns s as a double-quoted JSONPath [string literal] that parses
+// back to s. Escapes quotation marks and backslashes with a backslash, and
+// control characters with \b, \t, \n, \f, or \r, or, for those without a
+// short escape, \u00XX with lowercase hexadecimal digits, such as \u001f.
+// Because JSONPath strings cannot contain invalid UTF-8, Quote replaces
+// each invalid byte in s with the Unicode replacement character U+FFFD.
+//
+// [string literal]: https://www.rfc-editor.org/rfc/rfc9535#section-2.3.1.1
+func Quote(s string) string {
+	buf := new(strings.Builder)
+	writeQuoted(buf, s, '"')
+	return buf.String()
 }
 
-// writeNormalizedTo writes n to buf formatted as a [normalized path] element.
-// Implements [NormalSelector].
+// writeQuoted writes s to buf as a JSONPath string literal quoted by q,
+// either ' or ", escaping characters as described by [Quote]. Escapes only
+// the quotation mark that matches q, as required for [normalized paths].
 //
-// [normalized path]: https://www.rfc-editor.org/rfc/rfc9535#section-2.7
-func (n Name) writeNormalizedTo(buf *strings.Builder) {
-	// https://www.rfc-editor.org/rfc/rfc9535#section-2.7
-	buf.WriteString("['")
-	for _, r := range string(n) {
+// [normalized paths]: https://www.rfc-editor.org/rfc/rfc9535#section-2.7
+func writeQuoted(buf normalWriter, s string, q rune) {
+	const hexDigits = "0123456789abcdef"
+	buf.WriteRune(q)
+	for _, r := range s {
 		switch r {
 		case '\b': //  b BS backspace U+0008
 			buf.WriteString(`\b`)
@@ -95,30 +179,154 @@ func (n Name) writeNormalizedTo(buf *strings.Builder) {
 			buf.WriteString(`\r`)
 		case '\t': // t HT horizontal tab U+0009
 			buf.WriteString(`\t`)
-		case '\'': // ' apostrophe U+0027
-			buf.WriteString(`\'`)
-		case '\\': // \ backslash (reverse solidus) U+005C
-			buf.WriteString(`\\`)
-		case '\x00', '\x01', '\x02', '\x03', '\x04', '\x05', '\x06', '\x07', '\x0b', '\x0e', '\x0f':
-			// "00"-"07", "0b", "0e"-"0f"
-			buf.WriteString(fmt.Sprintf(`\u000%x`, r))
-		default:
+		case q, '\\': // quotation mark q or \ backslash U+005C
+			buf.WriteRune('\\')
 			buf.WriteRune(r)
+		default:
+			if r < ' ' {
+				// "00"-"07", "0b", "0e"-"0f", "10"-"1f"
+				buf.WriteString(`\u00`)
+				buf.WriteString(hexDigits[r>>4 : r>>4+1])
+				buf.WriteString(hexDigits[r&0xf : r&0xf+1])
+			} else {
+				buf.WriteRune(r)
+			}
 		}
 	}
-	buf.WriteString("']")
+	buf.WriteRune(q)
+}
+
+// Select selects n from input and returns it as a single value in a slice.
+// Returns an empty slice if input is not a map[string]any or [OrderedMap] or
+// if it does not contain n. Matches n case-insensitively if root was
+// returned by [FoldNames]. Defined by the [Selector] interface.
+func (n Name) Select(input, root any) []any {
+	if _, val, ok := n.lookup(input, root); ok {
+		return []any{val}
+	}
+	return make([]any, 0)
+}
+
+// SelectLocated selects n from input and returns it with its normalized path
+// as a single [LocatedNode] in a slice. Returns an empty slice if input is
+// not a map[string]any or [OrderedMap] or if it does not contain n. Matches
+// n case-insensitively if root was returned by [FoldNames], in which case
+// the path ends with the name of the selected member. Defined by the
+// [Selector] interface.
+func (n Name) SelectLocated(input, root any, parent NormalizedPath) []*LocatedNode {
+	if name, val, ok := n.lookup(input, root); ok {
+		return []*LocatedNode{newLocatedNode(append(parent, Name(name)), val)}
+	}
+	return make([]*LocatedNode, 0)
+}
+
+// appendSelected appends the value n selects from input to dst and returns
+// the result. Implements appender.
+func (n Name) appendSelected(dst []any, input, root any) []any {
+	if _, val, ok := n.lookup(input, root); ok {
+		return append(dst, val)
+	}
+	return dst
+}
+
+// appendLocated appends the node n selects from input to dst and returns the
+// result. Implements appender.
+func (n Name) appendLocated(dst []*LocatedNode, input, root any, parent NormalizedPath) []*LocatedNode {
+	if name, val, ok := n.lookup(input, root); ok {
+		return append(dst, newLocatedNode(append(parent, Name(name)), val))
+	}
+	return dst
+}
+
+// lookup returns the name and value of the member of input that n selects
+// and true, or false if it selects none.
+func (n Name) lookup(input, root any) (string, any, bool) {
+	if rootOf(root).foldsNames() {
+		return foldMember(input, string(n))
+	}
+	val, ok := member(input, string(n))
+	return string(n), val, ok
+}
+
+// member returns the value of the member of obj named name and true, or
+// false if obj is not an object or has no member with that name.
+func member(obj any, name string) (any, bool) {
+	switch obj := decodeRaw(obj).(type) {
+	case map[string]any:
+		val, ok := obj[name]
+		return val, ok
+	case OrderedMap:
+		return obj.Get(name)
+	}
+	return nil, false
+}
+
+// writeNormalizedTo writes n to buf formatted as a [normalized path] element.
+// Implements [NormalSelector].
+//
+// [normalized path]: https://www.rfc-editor.org/rfc/rfc9535#section-2.7
+func (n Name) writeNormalizedTo(buf normalWriter) {
+	buf.WriteRune('[')
+	writeQuoted(buf, string(n), '\'')
+	buf.WriteRune(']')
+}
+
+// pointerEscaper escapes JSON Pointer reference tokens.
+//
+//nolint:gochecknoglobals
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// writePointerTo writes n to buf formatted as a [JSON Pointer] reference
+// token, escaping ~ as ~0 and / as ~1. Implements [NormalSelector].
+//
+// [JSON Pointer]: https://www.rfc-editor.org/rfc/rfc6901
+func (n Name) writePointerTo(buf normalWriter) {
+	buf.WriteRune('/')
+	pointerEscaper.WriteString(buf, string(n)) //nolint:errcheck
 }
 
-// WildcardSelector is the underlying nil value used by [Wildcard].
-type WildcardSelector struct{}
+// WildcardSelector is a wildcard selector, e.g., * or [*]. Use [Wildcard] or
+// [WildcardAt] to create one.
+type WildcardSelector struct {
+	// pos is the position of the selector in the query string, starting from
+	// 1. Zero indicates an unknown position.
+	pos int
+}
 
-// Wildcard is a wildcard selector, e.g., * or [*].
+// wildcard is the shared WildcardSelector returned by [Wildcard].
 //
 //nolint:gochecknoglobals
-var Wildcard = WildcardSelector{}
+var wildcard = WildcardSelector{}
+
+// Wildcard returns the shared wildcard selector, e.g., * or [*].
+func Wildcard() WildcardSelector { return wildcard }
+
+// WildcardAt returns a wildcard selector that records pos, its position in
+// a query string, starting from 1, for use in diagnostics. Returns the same
+// value as [Wildcard] if pos is less than 1. Position metadata does not
+// affect the selection or string representation of the selector; use
+// [WildcardSelector.Equal] to compare wildcard selectors without regard to
+// their positions.
+func WildcardAt(pos int) WildcardSelector {
+	if pos < 1 {
+		return wildcard
+	}
+	return WildcardSelector{pos: pos}
+}
+
+// Position returns the position of the selector in its query string,
+// starting from 1, or 0 if the position is unknown.
+func (w WildcardSelector) Position() int { return w.pos }
+
+// Equal returns true if sel is also a wildcard selector, regardless of
+// position metadata.
+func (WildcardSelector) Equal(sel Selector) bool {
+	_, ok := sel.(WildcardSelector)
+	return ok
+}
 
 // writeTo  writes "*" to buf.
-func (WildcardSelector) writeTo(buf *strings.Builder) { buf.WriteByte('*') }
+func (WildcardSelector) writeTo(buf *writer) { buf.WriteByte('*') }
 
 // String returns "*".
 func (WildcardSelector) String() string { return "*" }
@@ -128,10 +336,11 @@ func (WildcardSelector) String() string { return "*" }
 func (WildcardSelector) isSingular() bool { return false }
 
 // Select selects the values from input and returns them in a slice. Returns
-// an empty slice if input is not []any map[string]any. Defined by the
-// [Selector] interface.
+// an empty slice if input is not an array or object, such as a []any,
+// map[string]any, [Array], or [OrderedMap]. Defined by the [Selector]
+// interface.
 func (WildcardSelector) Select(input, _ any) []any {
-	switch val := input.(type) {
+	switch val := decodeOrdered(input).(type) {
 	case []any:
 		return val
 	case map[string]any:
@@ -140,30 +349,65 @@ func (WildcardSelector) Select(input, _ any) []any {
 			vals = append(vals, v)
 		}
 		return vals
+	case OrderedMap:
+		vals := make([]any, 0, val.Len())
+		for _, m := range membersOf(val) {
+			vals = append(vals, m.val)
+		}
+		return vals
 	}
 	return make([]any, 0)
 }
 
 // SelectLocated selects the values from input and returns them with their
 // normalized paths in a slice of [LocatedNode] structs. Returns an empty
-// slice if input is not []any map[string]any. Defined by the [Selector]
+// slice if input is not an array or object. Defined by the [Selector]
 // interface.
-func (WildcardSelector) SelectLocated(input, _ any, parent NormalizedPath) []*LocatedNode {
-	switch val := input.(type) {
+func (w WildcardSelector) SelectLocated(input, root any, parent NormalizedPath) []*LocatedNode {
+	return w.appendLocated(make([]*LocatedNode, 0), input, root, parent)
+}
+
+// appendSelected appends the values from input to dst and returns the
+// result. Implements appender.
+func (WildcardSelector) appendSelected(dst []any, input, _ any) []any {
+	switch val := decodeOrdered(input).(type) {
 	case []any:
-		vals := make([]*LocatedNode, len(val))
+		return append(dst, val...)
+	case map[string]any:
+		dst = slices.Grow(dst, len(val))
+		for _, v := range val {
+			dst = append(dst, v)
+		}
+	case OrderedMap:
+		dst = slices.Grow(dst, val.Len())
+		for _, m := range membersOf(val) {
+			dst = append(dst, m.val)
+		}
+	}
+	return dst
+}
+
+// appendLocated appends the values from input with their normalized paths
+// to dst and returns the result. Implements appender.
+func (WildcardSelector) appendLocated(dst []*LocatedNode, input, _ any, parent NormalizedPath) []*LocatedNode {
+	switch val := decodeOrdered(input).(type) {
+	case []any:
+		dst = slices.Grow(dst, len(val))
 		for i, v := range val {
-			vals[i] = newLocatedNode(append(parent, Index(i)), v)
+			dst = append(dst, newLocatedNode(append(parent, Index(i)), v))
 		}
-		return vals
 	case map[string]any:
-		vals := make([]*LocatedNode, 0, len(val))
+		dst = slices.Grow(dst, len(val))
 		for k, v := range val {
-			vals = append(vals, newLocatedNode(append(parent, Name(k)), v))
+			dst = append(dst, newLocatedNode(append(parent, Name(k)), v))
+		}
+	case OrderedMap:
+		dst = slices.Grow(dst, val.Len())
+		for _, m := range membersOf(val) {
+			dst = append(dst, newLocatedNode(append(parent, Name(m.name)), m.val))
 		}
-		return vals
 	}
-	return make([]*LocatedNode, 0)
+	return dst
 }
 
 // Index is an array index selector, e.g., [3].
@@ -174,7 +418,7 @@ type Index int
 func (Index) isSingular() bool { return true }
 
 // writeTo writes a string representation of i to buf.
-func (i Index) writeTo(buf *strings.Builder) {
+func (i Index) writeTo(buf *writer) {
 	buf.WriteString(i.String())
 }
 
@@ -185,15 +429,8 @@ func (i Index) String() string { return strconv.FormatInt(int64(i), 10) }
 // Returns an empty slice if input is not a slice or if i it outside the
 // bounds of input. Defined by the [Selector] interface.
 func (i Index) Select(input, _ any) []any {
-	if val, ok := input.([]any); ok {
-		idx := int(i)
-		if idx < 0 {
-			if idx = len(val) + idx; idx >= 0 {
-				return []any{val[idx]}
-			}
-		} else if idx < len(val) {
-			return []any{val[idx]}
-		}
+	if val, _, ok := i.resolve(input); ok {
+		return []any{val}
 	}
 	return make([]any, 0)
 }
@@ -203,29 +440,75 @@ func (i Index) Select(input, _ any) []any {
 // not a slice or if i it outside the bounds of input. Defined by the
 // [Selector] interface.
 func (i Index) SelectLocated(input, _ any, parent NormalizedPath) []*LocatedNode {
-	if val, ok := input.([]any); ok {
-		idx := int(i)
-		if idx < 0 {
-			if idx = len(val) + idx; idx >= 0 {
-				return []*LocatedNode{newLocatedNode(append(parent, Index(idx)), val[idx])}
-			}
-		} else if idx < len(val) {
-			return []*LocatedNode{newLocatedNode(append(parent, Index(idx)), val[idx])}
-		}
+	if val, idx, ok := i.resolve(input); ok {
+		return []*LocatedNode{newLocatedNode(append(parent, Index(idx)), val)}
 	}
 	return make([]*LocatedNode, 0)
 }
 
+// appendSelected appends the value i selects from input to dst and returns
+// the result. Implements appender.
+func (i Index) appendSelected(dst []any, input, _ any) []any {
+	if val, _, ok := i.resolve(input); ok {
+		return append(dst, val)
+	}
+	return dst
+}
+
+// appendLocated appends the node i selects from input to dst and returns the
+// result. Implements appender.
+func (i Index) appendLocated(dst []*LocatedNode, input, _ any, parent NormalizedPath) []*LocatedNode {
+	if val, idx, ok := i.resolve(input); ok {
+		return append(dst, newLocatedNode(append(parent, Index(idx)), val))
+	}
+	return dst
+}
+
+// resolve returns the element of input that i selects and its non-negative
+// index, or false if input is not an array or i is outside its bounds.
+func (i Index) resolve(input any) (any, int, bool) {
+	switch val := decodeRaw(input).(type) {
+	case []any:
+		if idx, ok := i.within(len(val)); ok {
+			return val[idx], idx, true
+		}
+	case Array:
+		if idx, ok := i.within(val.Len()); ok {
+			return val.Index(idx), idx, true
+		}
+	}
+	return nil, 0, false
+}
+
+// within returns the non-negative index i selects from an array of length,
+// or false if i is outside its bounds.
+func (i Index) within(length int) (int, bool) {
+	idx := int(i)
+	if idx < 0 {
+		idx += length
+	}
+	return idx, idx >= 0 && idx < length
+}
+
 // writeNormalizedTo writes n to buf formatted as a [normalized path] element.
 // Implements [NormalSelector].
 //
 // [normalized path]: https://www.rfc-editor.org/rfc/rfc9535#section-2.7
-func (i Index) writeNormalizedTo(buf *strings.Builder) {
+func (i Index) writeNormalizedTo(buf normalWriter) {
 	buf.WriteRune('[')
 	buf.WriteString(strconv.FormatInt(int64(i), 10))
 	buf.WriteRune(']')
 }
 
+// writePointerTo writes i to buf formatted as a [JSON Pointer] reference
+// token. Implements [NormalSelector].
+//
+// [JSON Pointer]: https://www.rfc-editor.org/rfc/rfc6901
+func (i Index) writePointerTo(buf normalWriter) {
+	buf.WriteRune('/')
+	buf.WriteString(strconv.FormatInt(int64(i), 10))
+}
+
 // SliceSelector is a slice selector, e.g., [0:100:5].
 type SliceSelector struct {
 	// Start of the slice; defaults to 0.
@@ -240,9 +523,42 @@ type SliceSelector struct {
 // value from an array. Defined by the [Selector] interface.
 func (SliceSelector) isSingular() bool { return false }
 
+// ErrSliceArg errors are returned by [TrySlice] for arguments that are
+// neither integers nor nil.
+var ErrSliceArg = errors.New("jsonpath: slice argument is not an integer")
+
 // Slice creates a new SliceSelector. Pass up to three integers or nils for
 // the start, end, and step arguments. Subsequent arguments are ignored.
+// Panics if any of the arguments is neither an integer nor nil, which
+// indicates a programming error; use [TrySlice] to validate arguments from
+// other sources.
 func Slice(args ...any) SliceSelector {
+	s, bad := newSlice(args)
+	if bad >= 0 {
+		ordinals := [...]string{"First", "Second", "Third"}
+		panic(ordinals[bad] + " value passed to NewSlice is not an integer")
+	}
+	return s
+}
+
+// TrySlice creates a new SliceSelector. Pass up to three integers or nils
+// for the start, end, and step arguments. Subsequent arguments are ignored.
+// Returns an [ErrSliceArg] error if any of the arguments is neither an
+// integer nor nil.
+func TrySlice(args ...any) (SliceSelector, error) {
+	s, bad := newSlice(args)
+	if bad >= 0 {
+		return SliceSelector{}, fmt.Errorf(
+			"%w: argument %d is %T", ErrSliceArg, bad+1, args[bad],
+		)
+	}
+	return s, nil
+}
+
+// newSlice creates a new SliceSelector from up to three integers or nils.
+// Returns the index of the first argument that is neither an integer nor
+// nil, or -1 if all are valid.
+func newSlice(args []any) (SliceSelector, int) {
 	const (
 		startArg = 0
 		endArg   = 1
@@ -259,7 +575,7 @@ func Slice(args ...any) SliceSelector {
 		case nil:
 			// Nothing to do
 		default:
-			panic("Third value passed to NewSlice is not an integer")
+			return s, stepArg
 		}
 		fallthrough
 	case endArg:
@@ -273,7 +589,7 @@ func Slice(args ...any) SliceSelector {
 				s.end = math.MinInt
 			}
 		default:
-			panic("Second value passed to NewSlice is not an integer")
+			return s, endArg
 		}
 		fallthrough
 	case startArg:
@@ -286,14 +602,14 @@ func Slice(args ...any) SliceSelector {
 				s.start = math.MaxInt
 			}
 		default:
-			panic("First value passed to NewSlice is not an integer")
+			return s, startArg
 		}
 	}
-	return s
+	return s, -1
 }
 
 // writeTo writes a string representation of s to buf.
-func (s SliceSelector) writeTo(buf *strings.Builder) {
+func (s SliceSelector) writeTo(buf *writer) {
 	if s.start != 0 && (s.step >= 0 || s.start != math.MaxInt) {
 		buf.WriteString(strconv.FormatInt(int64(s.start), 10))
 	}
@@ -309,7 +625,7 @@ func (s SliceSelector) writeTo(buf *strings.Builder) {
 
 // String returns a quoted string representation of s.
 func (s SliceSelector) String() string {
-	buf := new(strings.Builder)
+	buf := newWriter()
 	s.writeTo(buf)
 	return buf.String()
 }
@@ -318,47 +634,57 @@ func (s SliceSelector) String() string {
 // by s. Returns an empty slice if input is not a slice. Indexes outside the
 // bounds of input will not be included in the return value. Defined by the
 // [Selector] interface.
-func (s SliceSelector) Select(input, _ any) []any {
-	if val, ok := input.([]any); ok {
+func (s SliceSelector) Select(input, root any) []any {
+	return s.appendSelected(make([]any, 0), input, root)
+}
+
+// SelectLocated selects values from input for the indexes specified by s and
+// returns thm with their normalized paths as [LocatedNode] structs. Returns
+// an empty slice if input is not a slice. Indexes outside the bounds of input
+// will not be included in the return value. Defined by the [Selector]
+// interface.
+func (s SliceSelector) SelectLocated(input, root any, parent NormalizedPath) []*LocatedNode {
+	return s.appendLocated(make([]*LocatedNode, 0), input, root, parent)
+}
+
+// appendSelected appends the values s selects from input to dst and returns
+// the result. Implements appender.
+func (s SliceSelector) appendSelected(dst []any, input, _ any) []any {
+	if val, ok := decodeArray(input).([]any); ok {
 		lower, upper := s.Bounds(len(val))
-		res := make([]any, 0, len(val))
+		dst = slices.Grow(dst, len(val))
 		switch {
 		case s.step > 0:
 			for i := lower; i < upper; i += s.step {
-				res = append(res, val[i])
+				dst = append(dst, val[i])
 			}
 		case s.step < 0:
 			for i := upper; lower < i; i += s.step {
-				res = append(res, val[i])
+				dst = append(dst, val[i])
 			}
 		}
-		return res
 	}
-	return make([]any, 0)
+	return dst
 }
 
-// SelectLocated selects values from input for the indexes specified by s and
-// returns thm with their normalized paths as [LocatedNode] structs. Returns
-// an empty slice if input is not a slice. Indexes outside the bounds of input
-// will not be included in the return value. Defined by the [Selector]
-// interface.
-func (s SliceSelector) SelectLocated(input, _ any, parent NormalizedPath) []*LocatedNode {
-	if val, ok := input.([]any); ok {
+// appendLocated appends the nodes s selects from input to dst and returns
+// the result. Implements appender.
+func (s SliceSelector) appendLocated(dst []*LocatedNode, input, _ any, parent NormalizedPath) []*LocatedNode {
+	if val, ok := decodeArray(input).([]any); ok {
 		lower, upper := s.Bounds(len(val))
-		res := make([]*LocatedNode, 0, len(val))
+		dst = slices.Grow(dst, len(val))
 		switch {
 		case s.step > 0:
 			for i := lower; i < upper; i += s.step {
-				res = append(res, newLocatedNode(append(parent, Index(i)), val[i]))
+				dst = append(dst, newLocatedNode(append(parent, Index(i)), val[i]))
 			}
 		case s.step < 0:
 			for i := upper; lower < i; i += s.step {
-				res = append(res, newLocatedNode(append(parent, Index(i)), val[i]))
+				dst = append(dst, newLocatedNode(append(parent, Index(i)), val[i]))
 			}
 		}
-		return res
 	}
-	return make([]*LocatedNode, 0)
+	return dst
 }
 
 // Start returns the start position.
@@ -412,13 +738,13 @@ func Filter(or LogicalOr) *FilterSelector {
 
 // String returns a string representation of f.
 func (f *FilterSelector) String() string {
-	buf := new(strings.Builder)
+	buf := newWriter()
 	f.writeTo(buf)
 	return buf.String()
 }
 
 // writeTo writes a string representation of f to buf.
-func (f *FilterSelector) writeTo(buf *strings.Builder) {
+func (f *FilterSelector) writeTo(buf *writer) {
 	buf.WriteRune('?')
 	f.LogicalOr.writeTo(buf)
 }
@@ -427,47 +753,117 @@ func (f *FilterSelector) writeTo(buf *strings.Builder) {
 // expressions may evaluate the current value (@), the root value ($), or any
 // path expression. Defined by the [Selector] interface.
 func (f *FilterSelector) Select(current, root any) []any {
-	ret := []any{}
-	switch current := current.(type) {
+	return f.appendSelected([]any{}, current, root)
+}
+
+// SelectLocated selects and returns [LocatedNode] structs with values that f
+// filters from current. Filter expressions may evaluate the current value
+// (@), the root value ($), or any path expression. Defined by the [Selector]
+// interface.
+func (f *FilterSelector) SelectLocated(current, root any, parent NormalizedPath) []*LocatedNode {
+	return f.appendLocated([]*LocatedNode{}, current, root, parent)
+}
+
+// appendSelected appends the values f filters from current to dst and
+// returns the result. Implements appender.
+func (f *FilterSelector) appendSelected(dst []any, current, root any) []any {
+	ctx := usesContext(f.LogicalOr)
+	switch val := decodeOrdered(current).(type) {
 	case []any:
-		for _, v := range current {
-			if f.Eval(v, root) {
-				ret = append(ret, v)
+		for _, v := range val {
+			if f.evalIn(v, root, val, nil, ctx) {
+				dst = append(dst, v)
 			}
 		}
 	case map[string]any:
-		for _, v := range current {
-			if f.Eval(v, root) {
-				ret = append(ret, v)
+		for _, v := range val {
+			if f.evalIn(v, root, val, nil, ctx) {
+				dst = append(dst, v)
+			}
+		}
+	case OrderedMap:
+		for _, m := range membersOf(val) {
+			if f.evalIn(m.val, root, val, nil, ctx) {
+				dst = append(dst, m.val)
 			}
 		}
 	}
+	return dst
+}
 
-	return ret
+// anyMatch returns true if match returns true for any of the values f
+// filters from current, testing values against f only until it does.
+func (f *FilterSelector) anyMatch(current, root any, match func(v any) bool) bool {
+	ctx := usesContext(f.LogicalOr)
+	switch val := decodeOrdered(current).(type) {
+	case []any:
+		for _, v := range val {
+			if f.evalIn(v, root, val, nil, ctx) && match(v) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, v := range val {
+			if f.evalIn(v, root, val, nil, ctx) && match(v) {
+				return true
+			}
+		}
+	case OrderedMap:
+		for _, m := range membersOf(val) {
+			if f.evalIn(m.val, root, val, nil, ctx) && match(m.val) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-// SelectLocated selects and returns [LocatedNode] structs with values that f
-// filters from current. Filter expressions may evaluate the current value
-// (@), the root value ($), or any path expression. Defined by the [Selector]
-// interface.
-func (f *FilterSelector) SelectLocated(current, root any, parent NormalizedPath) []*LocatedNode {
-	ret := []*LocatedNode{}
-	switch current := current.(type) {
+// appendLocated appends [LocatedNode] structs with the values f filters from
+// current to dst and returns the result. Implements appender.
+func (f *FilterSelector) appendLocated(
+	dst []*LocatedNode,
+	current, root any,
+	parent NormalizedPath,
+) []*LocatedNode {
+	ctx := usesContext(f.LogicalOr)
+	if ctx {
+		// Allocate a new path for each node, so that functions may retain it.
+		parent = parent[:len(parent):len(parent)]
+	}
+	switch val := decodeOrdered(current).(type) {
 	case []any:
-		for i, v := range current {
-			if f.Eval(v, root) {
-				ret = append(ret, newLocatedNode(append(parent, Index(i)), v))
+		for i, v := range val {
+			path := append(parent, Index(i))
+			if f.evalIn(v, root, val, path, ctx) {
+				dst = append(dst, newLocatedNode(path, v))
 			}
 		}
 	case map[string]any:
-		for k, v := range current {
-			if f.Eval(v, root) {
-				ret = append(ret, newLocatedNode(append(parent, Name(k)), v))
+		for k, v := range val {
+			path := append(parent, Name(k))
+			if f.evalIn(v, root, val, path, ctx) {
+				dst = append(dst, newLocatedNode(path, v))
+			}
+		}
+	case OrderedMap:
+		for _, m := range membersOf(val) {
+			path := append(parent, Name(m.name))
+			if f.evalIn(m.val, root, val, path, ctx) {
+				dst = append(dst, newLocatedNode(path, m.val))
 			}
 		}
 	}
+	return dst
+}
 
-	return ret
+// evalIn evaluates f's logical expression against node and root. If ctx is
+// true, it first wraps root to record parent and path, the normalized path
+// to node, for the [EvalContext] of [ContextFunction]s.
+func (f *FilterSelector) evalIn(node, root, parent any, path NormalizedPath, ctx bool) bool {
+	if ctx {
+		root = withContext(root, parent, path)
+	}
+	return f.Eval(node, root)
 }
 
 // Eval evaluates the f's logical expression against node and root. Used
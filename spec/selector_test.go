@@ -3,7 +3,6 @@ package spec
 import (
 	"fmt"
 	"math"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,7 +19,7 @@ func TestSelectorInterface(t *testing.T) {
 		{"name", Name("hi")},
 		{"index", Index(42)},
 		{"slice", Slice()},
-		{"wildcard", Wildcard},
+		{"wildcard", Wildcard()},
 		{"filter", Filter(nil)},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
@@ -64,6 +63,12 @@ func TestSelectorString(t *testing.T) {
 			str:  `"hi 😀"`,
 			sing: true,
 		},
+		{
+			name: "name_controls",
+			tok:  Name("\a\b\v\x00\x7f"),
+			str:  `"\u0007\b\u000b\u0000` + "\x7f\"",
+			sing: true,
+		},
 		{
 			name: "name_digits",
 			tok:  Name(`42`),
@@ -175,15 +180,15 @@ func TestSelectorString(t *testing.T) {
 		},
 		{
 			name: "wildcard",
-			tok:  Wildcard,
+			tok:  Wildcard(),
 			str:  "*",
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			a.Equal(tc.sing, tc.tok.isSingular())
-			buf := new(strings.Builder)
-			tc.tok.writeTo(buf)
+			a.Equal(tc.sing, isSingular(tc.tok))
+			buf := newWriter()
+			writeSelector(buf, tc.tok)
 			a.Equal(tc.str, buf.String())
 			a.Equal(tc.str, tc.tok.String())
 		})
@@ -349,6 +354,96 @@ func TestSlicePanic(t *testing.T) {
 	)
 }
 
+func TestTrySlice(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name string
+		args []any
+		exp  SliceSelector
+		err  string
+	}{
+		{
+			name: "no_args",
+			exp:  Slice(),
+		},
+		{
+			name: "all_args",
+			args: []any{1, nil, -2},
+			exp:  Slice(1, nil, -2),
+		},
+		{
+			name: "bad_start",
+			args: []any{"hi"},
+			err:  "jsonpath: slice argument is not an integer: argument 1 is string",
+		},
+		{
+			name: "bad_end",
+			args: []any{nil, 1.2},
+			err:  "jsonpath: slice argument is not an integer: argument 2 is float64",
+		},
+		{
+			name: "bad_step",
+			args: []any{nil, 42, true},
+			err:  "jsonpath: slice argument is not an integer: argument 3 is bool",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s, err := TrySlice(tc.args...)
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+				a.ErrorIs(err, ErrSliceArg)
+				a.Equal(SliceSelector{}, s)
+				return
+			}
+			a.NoError(err)
+			a.Equal(tc.exp, s)
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		name string
+		str  string
+		exp  string
+	}{
+		{"empty", "", `""`},
+		{"ascii", "hi there", `"hi there"`},
+		{"quotation_marks", `"hi"`, `"\"hi\""`},
+		{"apostrophes", `'hi'`, `"'hi'"`},
+		{"backslash", `a\b`, `"a\\b"`},
+		{"slash", `a/b`, `"a/b"`},
+		{"short_escapes", "\b\f\n\r\t", `"\b\f\n\r\t"`},
+		{"delete", "\x7f", "\"\x7f\""},
+		{"unicode", "fo\u00f8 \u2028 \U0001D11E", "\"fo\u00f8 \u2028 \U0001D11E\""},
+		{"invalid_utf8", "a\xffb", "\"a\uFFFDb\""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a.Equal(tc.exp, Quote(tc.str))
+			a.Equal(tc.exp, Name(tc.str).String())
+		})
+	}
+
+	// Test every control character.
+	short := map[rune]string{'\b': `\b`, '\t': `\t`, '\n': `\n`, '\f': `\f`, '\r': `\r`}
+	for r := range rune(0x20) {
+		exp, ok := short[r]
+		if !ok {
+			exp = fmt.Sprintf(`\u%04x`, r)
+		}
+		a.Equal(`"`+exp+`"`, Quote(string(r)), "U+%04X", r)
+		a.Equal(`"`+exp+`"`, bufString(Name(string(r))), "U+%04X", r)
+		a.Equal(`$['`+exp+`']`, NormalizedPath{Name(string(r))}.String(), "U+%04X", r)
+	}
+}
+
 func TestNameSelect(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
@@ -505,16 +600,46 @@ func TestWildcardSelect(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			if _, ok := tc.src.(map[string]any); ok {
-				a.ElementsMatch(tc.exp, Wildcard.Select(tc.src, nil))
-				a.ElementsMatch(tc.loc, Wildcard.SelectLocated(tc.src, nil, NormalizedPath{}))
+				a.ElementsMatch(tc.exp, Wildcard().Select(tc.src, nil))
+				a.ElementsMatch(tc.loc, Wildcard().SelectLocated(tc.src, nil, NormalizedPath{}))
 			} else {
-				a.Equal(tc.exp, Wildcard.Select(tc.src, nil))
-				a.Equal(tc.loc, Wildcard.SelectLocated(tc.src, nil, NormalizedPath{}))
+				a.Equal(tc.exp, Wildcard().Select(tc.src, nil))
+				a.Equal(tc.loc, Wildcard().SelectLocated(tc.src, nil, NormalizedPath{}))
 			}
 		})
 	}
 }
 
+func TestWildcardPosition(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Common case returns the shared singleton.
+	a.Equal(wildcard, Wildcard())
+	a.Zero(Wildcard().Position())
+	a.Equal(Wildcard(), WildcardAt(0))
+	a.Equal(Wildcard(), WildcardAt(-1))
+
+	// Positioned instance.
+	w := WildcardAt(3)
+	a.Equal(3, w.Position())
+	a.NotEqual(Wildcard(), w)
+	a.True(w.Equal(Wildcard()))
+	a.True(Wildcard().Equal(w))
+	a.False(w.Equal(Name("*")))
+	a.False(w.Equal(Index(0)))
+
+	// Position does not affect string or selection.
+	a.Equal(Wildcard().String(), w.String())
+	src := []any{1, 2, 3}
+	a.Equal(Wildcard().Select(src, nil), w.Select(src, nil))
+	a.Equal(
+		Wildcard().SelectLocated(src, nil, NormalizedPath{}),
+		w.SelectLocated(src, nil, NormalizedPath{}),
+	)
+	a.Equal(Child(Wildcard()).String(), Child(w).String())
+}
+
 func TestSliceSelect(t *testing.T) {
 	t.Parallel()
 	a := assert.New(t)
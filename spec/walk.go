@@ -0,0 +1,94 @@
+package spec
+
+// Node is a node in the abstract syntax tree of a [PathQuery], as passed to
+// the visit function of [Walk]: a *PathQuery, a *Segment, a [Selector], a
+// [LogicalOr], a [LogicalAnd], a [BasicExpr], or a [FunctionExprArg] or
+// [CompVal], such as a *FunctionExpr or *LiteralArg.
+type Node = any
+
+// Walk traverses the abstract syntax tree rooted at node in depth-first
+// order, in the order in which nodes appear in the string representation
+// of the query. It calls visit for node, and if visit returns true, walks
+// each of the children of node. Use Walk to analyze queries, e.g., to find
+// the functions they call or the names they select:
+//
+//	spec.Walk(q, func(node spec.Node) bool {
+//		if fe, ok := node.(*spec.FunctionExpr); ok {
+//			names = append(names, fe.Name())
+//		}
+//		return true
+//	})
+//
+// The children of a node are:
+//
+//   - *PathQuery: its segments
+//   - *Segment: its selectors
+//   - *FilterSelector, *ParenExpr, and *NotParenExpr: their [LogicalOr]
+//   - [LogicalOr]: its [LogicalAnd] expressions
+//   - [LogicalAnd]: its [BasicExpr] expressions
+//   - *ExistExpr, *NonExistExpr, and *FilterQueryExpr: their *PathQuery
+//   - *ComparisonExpr, *MembershipExpr, and *ArithmeticExpr: their left
+//     and right operands
+//   - [NotFuncExpr]: its *FunctionExpr
+//   - *FunctionExpr: its arguments
+//   - *SingularQueryExpr: its selectors
+//
+// All other nodes, including literals and custom selectors, have no
+// children.
+func Walk(node Node, visit func(node Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *PathQuery:
+		for _, seg := range node.segments {
+			Walk(seg, visit)
+		}
+	case *Segment:
+		for _, sel := range node.selectors {
+			Walk(sel, visit)
+		}
+	case *FilterSelector:
+		Walk(node.LogicalOr, visit)
+	case *ParenExpr:
+		Walk(node.LogicalOr, visit)
+	case *NotParenExpr:
+		Walk(node.LogicalOr, visit)
+	case LogicalOr:
+		for _, and := range node {
+			Walk(and, visit)
+		}
+	case LogicalAnd:
+		for _, expr := range node {
+			Walk(expr, visit)
+		}
+	case *ExistExpr:
+		Walk(node.PathQuery, visit)
+	case *NonExistExpr:
+		Walk(node.PathQuery, visit)
+	case NonExistExpr:
+		Walk(node.PathQuery, visit)
+	case *FilterQueryExpr:
+		Walk(node.PathQuery, visit)
+	case *ComparisonExpr:
+		Walk(node.Left, visit)
+		Walk(node.Right, visit)
+	case *MembershipExpr:
+		Walk(node.Left, visit)
+		Walk(node.Right, visit)
+	case *ArithmeticExpr:
+		Walk(node.Left, visit)
+		Walk(node.Right, visit)
+	case NotFuncExpr:
+		Walk(node.FunctionExpr, visit)
+	case *FunctionExpr:
+		for _, arg := range node.args {
+			Walk(arg, visit)
+		}
+	case *SingularQueryExpr:
+		for _, sel := range node.selectors {
+			Walk(sel, visit)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package spec
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(t *testing.T) {
+	t.Parallel()
+
+	// $.a[?@.b == 1 && __val(@.c) > 2 + $.x || !(@.d) || !__true(@.e[*]) ||
+	// @.f in [1]][0]..g
+	fn := Function(newValueFunc(1), []FunctionExprArg{SingularQuery(false, []Selector{Name("c")})})
+	notFn := NotFunction(Function(newTrueFunc(), []FunctionExprArg{
+		FilterQuery(Query(false, []*Segment{Child(Name("e")), Child(Wildcard())})),
+	}))
+	query := Query(true, []*Segment{
+		Child(Name("a")),
+		Child(Filter(LogicalOr{
+			LogicalAnd{
+				Comparison(SingularQuery(false, []Selector{Name("b")}), EqualTo, Literal(1)),
+				Comparison(fn, GreaterThan, Arithmetic(
+					Literal(2), Add, SingularQuery(true, []Selector{Name("x")}),
+				)),
+			},
+			LogicalAnd{NotParen(LogicalOr{LogicalAnd{
+				Existence(Query(false, []*Segment{Child(Name("d"))})),
+			}})},
+			LogicalAnd{notFn},
+			LogicalAnd{Membership(SingularQuery(false, []Selector{Name("f")}), Literal([]any{1}))},
+		}), Index(0)),
+		Descendant(Name("g")),
+	})
+
+	for _, tc := range []struct {
+		name  string
+		node  Node
+		prune func(node Node) bool
+		exp   []string
+	}{
+		{
+			name: "query",
+			node: query,
+			exp: []string{
+				"*spec.PathQuery",
+				"*spec.Segment", `spec.Name "a"`,
+				"*spec.Segment", "*spec.FilterSelector",
+				"spec.LogicalOr",
+				"spec.LogicalAnd",
+				"*spec.ComparisonExpr",
+				"*spec.SingularQueryExpr", `spec.Name "b"`,
+				"*spec.LiteralArg",
+				"*spec.ComparisonExpr",
+				"*spec.FunctionExpr", "*spec.SingularQueryExpr", `spec.Name "c"`,
+				"*spec.ArithmeticExpr", "*spec.LiteralArg",
+				"*spec.SingularQueryExpr", `spec.Name "x"`,
+				"spec.LogicalAnd",
+				"*spec.NotParenExpr", "spec.LogicalOr", "spec.LogicalAnd",
+				"*spec.ExistExpr", "*spec.PathQuery", "*spec.Segment", `spec.Name "d"`,
+				"spec.LogicalAnd",
+				"spec.NotFuncExpr", "*spec.FunctionExpr", "*spec.FilterQueryExpr",
+				"*spec.PathQuery", "*spec.Segment", `spec.Name "e"`,
+				"*spec.Segment", "spec.WildcardSelector",
+				"spec.LogicalAnd",
+				"*spec.MembershipExpr",
+				"*spec.SingularQueryExpr", `spec.Name "f"`,
+				"*spec.LiteralArg",
+				"spec.Index",
+				"*spec.Segment", `spec.Name "g"`,
+			},
+		},
+		{
+			name: "prune_filters",
+			node: query,
+			prune: func(node Node) bool {
+				_, ok := node.(*FilterSelector)
+				return ok
+			},
+			exp: []string{
+				"*spec.PathQuery",
+				"*spec.Segment", `spec.Name "a"`,
+				"*spec.Segment", "*spec.FilterSelector", "spec.Index",
+				"*spec.Segment", `spec.Name "g"`,
+			},
+		},
+		{
+			name: "paren_non_exist",
+			node: Paren(LogicalOr{LogicalAnd{
+				Nonexistence(Query(false, []*Segment{Child(Index(1))})),
+				NonExistExpr{Query(true, nil)},
+			}}),
+			exp: []string{
+				"*spec.ParenExpr", "spec.LogicalOr", "spec.LogicalAnd",
+				"*spec.NonExistExpr", "*spec.PathQuery", "*spec.Segment", "spec.Index",
+				"spec.NonExistExpr", "*spec.PathQuery",
+			},
+		},
+		{
+			name: "leaf",
+			node: Slice(1, 2),
+			exp:  []string{"spec.SliceSelector"},
+		},
+		{
+			name: "nil",
+			node: nil,
+			exp:  []string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			visited := []string{}
+			Walk(tc.node, func(node Node) bool {
+				str := fmt.Sprintf("%T", node)
+				if name, ok := node.(Name); ok {
+					str += fmt.Sprintf(" %q", string(name))
+				}
+				visited = append(visited, str)
+				return tc.prune == nil || !tc.prune(node)
+			})
+			a.Equal(tc.exp, visited)
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// Package structpbpath evaluates JSONPath queries against the
+// [structpb.Struct] and [structpb.Value] trees common in gRPC APIs, without
+// first converting them to map[string]any with [structpb.Struct.AsMap]:
+//
+//	for _, v := range structpbpath.Select(path, req.GetParams()) {
+//		fmt.Println(v.(*structpb.Value).GetStringValue())
+//	}
+//
+// Queries adapt a tree only as they descend into it, so a query that
+// selects a few values from a large Struct visits only the values along
+// its paths. Struct fields are objects that, like Go maps, select members
+// in no particular order, ListValues are arrays, and the scalar kinds are
+// the JSON scalars they represent. Queries select the original
+// *structpb.Value of each value, or, for the root, the value passed to
+// [Select] or [SelectLocated].
+package structpbpath
+
+import (
+	"iter"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Select returns the values p selects from msg, a *structpb.Struct,
+// *structpb.Value, or *structpb.ListValue, in the order p selects them.
+// Selected values other than the root are *structpb.Value values.
+func Select(p *jsonpath.Path, msg any) jsonpath.NodeList {
+	nodes := p.Select(adapt(msg))
+	for i, n := range nodes {
+		nodes[i] = unwrap(n)
+	}
+	return nodes
+}
+
+// SelectLocated returns the values p selects from msg, a *structpb.Struct,
+// *structpb.Value, or *structpb.ListValue, along with their normalized
+// paths, in the order p selects them. Selected values other than the root
+// are *structpb.Value values.
+func SelectLocated(p *jsonpath.Path, msg any) jsonpath.LocatedNodeList {
+	nodes := p.SelectLocated(adapt(msg))
+	for _, n := range nodes {
+		n.Node = unwrap(n.Node)
+	}
+	return nodes
+}
+
+// value adapts a *structpb.Value to [spec.Adapter].
+type value struct{ v *structpb.Value }
+
+// Adapt returns the JSON equivalent of v: nil, a bool, float64, or string,
+// or, for Struct and ListValue kinds, a [spec.OrderedMap] or [spec.Array]
+// that adapts their contents. Returns nil for a Value with no kind.
+func (v value) Adapt() any {
+	switch k := v.v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return k.BoolValue
+	case *structpb.Value_NumberValue:
+		return k.NumberValue
+	case *structpb.Value_StringValue:
+		return k.StringValue
+	case *structpb.Value_StructValue:
+		return object{k.StructValue}
+	case *structpb.Value_ListValue:
+		return list{k.ListValue}
+	default:
+		return nil
+	}
+}
+
+// object adapts a *structpb.Struct to [spec.OrderedMap]. All yields its
+// fields in no particular order.
+type object struct{ s *structpb.Struct }
+
+// Len returns the number of fields in o.
+func (o object) Len() int { return len(o.s.GetFields()) }
+
+// Get returns the adapted value of the field of o named name.
+func (o object) Get(name string) (any, bool) {
+	v, ok := o.s.GetFields()[name]
+	if !ok {
+		return nil, false
+	}
+	return value{v}, true
+}
+
+// All returns an iterator over the names and adapted values of the fields
+// of o.
+func (o object) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		for k, v := range o.s.GetFields() {
+			if !yield(k, value{v}) {
+				return
+			}
+		}
+	}
+}
+
+// list adapts a *structpb.ListValue to [spec.Array].
+type list struct{ l *structpb.ListValue }
+
+// Len returns the number of values in l.
+func (l list) Len() int { return len(l.l.GetValues()) }
+
+// Index returns the adapted value in l at index i.
+func (l list) Index(i int) any { return value{l.l.GetValues()[i]} }
+
+// adapt adapts msg for selection. Returns values of other types unchanged.
+func adapt(msg any) any {
+	switch msg := msg.(type) {
+	case *structpb.Value:
+		return value{msg}
+	case *structpb.Struct:
+		return object{msg}
+	case *structpb.ListValue:
+		return list{msg}
+	default:
+		return msg
+	}
+}
+
+// unwrap returns the original protocol buffer message of val, as adapted
+// by [adapt].
+func unwrap(val any) any {
+	switch v := val.(type) {
+	case value:
+		return v.v
+	case object:
+		return v.s
+	case list:
+		return v.l
+	default:
+		return v
+	}
+}
+
+// The adapted types implement the data model interfaces.
+var (
+	_ spec.Adapter    = value{}
+	_ spec.OrderedMap = object{}
+	_ spec.Array      = list{}
+)
@@ -0,0 +1,29 @@
+package structpbpath_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/structpbpath"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func ExampleSelect() {
+	params, err := structpb.NewStruct(map[string]any{
+		"filters": []any{
+			map[string]any{"field": "status", "value": "active"},
+			map[string]any{"field": "region", "value": "eu"},
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := jsonpath.MustParse(`$.filters[?@.field == "region"].value`)
+	for _, v := range structpbpath.Select(p, params) {
+		fmt.Println(v.(*structpb.Value).GetStringValue())
+	}
+	// Output:
+	// eu
+}
@@ -0,0 +1,163 @@
+package structpbpath
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	doc, err := structpb.NewStruct(map[string]any{
+		"name":    "gateway",
+		"enabled": true,
+		"limit":   nil,
+		"routes": []any{
+			map[string]any{"path": "/a", "weight": 1, "tags": []any{"x"}},
+			map[string]any{"path": "/b", "weight": 3, "tags": []any{}},
+		},
+	})
+	require.NoError(t, err)
+	fields := doc.GetFields()
+	routes := fields["routes"].GetListValue().GetValues()
+	route0 := routes[0].GetStructValue().GetFields()
+	route1 := routes[1].GetStructValue().GetFields()
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		exp   jsonpath.NodeList
+		paths []string
+	}{
+		{
+			name:  "root",
+			path:  "$",
+			exp:   jsonpath.NodeList{doc},
+			paths: []string{"$"},
+		},
+		{
+			name:  "name",
+			path:  "$.name",
+			exp:   jsonpath.NodeList{fields["name"]},
+			paths: []string{"$['name']"},
+		},
+		{
+			name:  "index",
+			path:  "$.routes[-1].path",
+			exp:   jsonpath.NodeList{route1["path"]},
+			paths: []string{"$['routes'][1]['path']"},
+		},
+		{
+			name:  "slice",
+			path:  "$.routes[:1].weight",
+			exp:   jsonpath.NodeList{route0["weight"]},
+			paths: []string{"$['routes'][0]['weight']"},
+		},
+		{
+			name:  "compare_number",
+			path:  "$.routes[?@.weight > 2].path",
+			exp:   jsonpath.NodeList{route1["path"]},
+			paths: []string{"$['routes'][1]['path']"},
+		},
+		{
+			name:  "compare_bool",
+			path:  "$[?@ == true]",
+			exp:   jsonpath.NodeList{fields["enabled"]},
+			paths: []string{"$['enabled']"},
+		},
+		{
+			name:  "compare_null",
+			path:  "$[?@ == null]",
+			exp:   jsonpath.NodeList{fields["limit"]},
+			paths: []string{"$['limit']"},
+		},
+		{
+			name:  "compare_list",
+			path:  `$.routes[?@.tags == $.routes[0].tags].path`,
+			exp:   jsonpath.NodeList{route0["path"]},
+			paths: []string{"$['routes'][0]['path']"},
+		},
+		{
+			name:  "length",
+			path:  "$.routes[?length(@.tags) == 0].path",
+			exp:   jsonpath.NodeList{route1["path"]},
+			paths: []string{"$['routes'][1]['path']"},
+		},
+		{
+			name:  "descendants",
+			path:  "$..tags[*]",
+			exp:   jsonpath.NodeList{route0["tags"].GetListValue().GetValues()[0]},
+			paths: []string{"$['routes'][0]['tags'][0]"},
+		},
+		{
+			name:  "none",
+			path:  "$.name[0]",
+			exp:   jsonpath.NodeList{},
+			paths: []string{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+
+			p := jsonpath.MustParse(tc.path)
+			a.Equal(tc.exp, Select(p, doc))
+
+			located := SelectLocated(p, doc)
+			a.Len(located, len(tc.exp))
+			paths := make([]string, len(located))
+			for i, n := range located {
+				a.Same(tc.exp[i], n.Node)
+				paths[i] = n.Path.String()
+			}
+			a.Equal(tc.paths, paths)
+		})
+	}
+}
+
+func TestSelectRoots(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	list, err := structpb.NewList([]any{"a", map[string]any{"b": 2}})
+	require.NoError(t, err)
+	val := structpb.NewListValue(list)
+	p := jsonpath.MustParse("$[1].b")
+	exp := jsonpath.NodeList{list.GetValues()[1].GetStructValue().GetFields()["b"]}
+
+	a.Equal(exp, Select(p, list))
+	a.Equal(exp, Select(p, val))
+	a.Equal(jsonpath.NodeList{list}, Select(jsonpath.MustParse("$"), list))
+	a.Equal(jsonpath.NodeList{val}, Select(jsonpath.MustParse("$"), val))
+
+	// Values with no kind are null.
+	a.Equal(jsonpath.NodeList{}, Select(p, &structpb.Value{}))
+	a.Nil(value{&structpb.Value{}}.Adapt())
+}
+
+func BenchmarkSelect(b *testing.B) {
+	fields := make(map[string]any, 1000)
+	for i := range 1000 {
+		fields[fmt.Sprintf("key%v", i)] = map[string]any{"id": i, "tags": []any{"a", "b", "c"}}
+	}
+	doc, err := structpb.NewStruct(fields)
+	require.NoError(b, err)
+	p := jsonpath.MustParse("$.key500.id")
+
+	b.Run("adapt", func(b *testing.B) {
+		for range b.N {
+			Select(p, doc)
+		}
+	})
+
+	b.Run("as_map", func(b *testing.B) {
+		for range b.N {
+			p.Select(doc.AsMap())
+		}
+	})
+}
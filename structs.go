@@ -0,0 +1,47 @@
+package jsonpath
+
+import "errors"
+
+// ErrUnsupportedValue errors are returned by [Path.SelectWith] and
+// [Path.SelectLocatedWith] when configured with [WithStructSupport] for
+// input that contains a value with no JSON representation, such as a
+// channel, a function, or a pointer cycle.
+var ErrUnsupportedValue = errors.New("jsonpath: unsupported value")
+
+// WithStructSupport configures [Path.SelectWith] and [Path.SelectLocatedWith]
+// to select from Go values of any type, not just the map[string]any and
+// []any values produced by [encoding/json], so that queries can select from
+// typed values such as configuration structs without a JSON round trip.
+//
+// Before evaluation, the input is converted by reflection into a
+// map[string]any and []any tree that mirrors its JSON encoding: exported
+// struct fields become object members named as by their json tags,
+// honoring "-" and omitempty and promoting the fields of embedded structs;
+// maps with string, integer, or [encoding.TextMarshaler] keys become
+// objects; slices and arrays of any element type become arrays, except for
+// byte slices, which become base64 strings; pointers and interfaces resolve
+// to the values they point to; values that implement
+// [encoding/json.Marshaler] or [encoding.TextMarshaler] become the values
+// they marshal to; and other values become their underlying bool, int64,
+// uint64, float64, or string values. Queries therefore select the converted
+// values rather than the original Go values. Returns an
+// [ErrUnsupportedValue] error if the input contains a value with no JSON
+// representation.
+//
+// The conversion is eager: each evaluation converts the entire input, not
+// just the parts the query selects from, so its cost grows with the size of
+// the input rather than of the result. To run several queries against the
+// same large value, convert it once with a JSON round trip and select from
+// the result instead. Struct support is available only through
+// [Path.SelectWith] and [Path.SelectLocatedWith]; [Path.Select] and other
+// methods without options treat structs as scalar values, and select
+// nothing from within them.
+//
+// Struct support depends on reflection and [encoding/json], which add
+// considerably to the size of binaries such as WASM apps. Build with the
+// jsonpath_minimal build tag to omit it, along with the decoding of
+// json.RawMessage values, in which case evaluation with WithStructSupport
+// returns an [ErrUnsupportedValue] error for any input.
+func WithStructSupport() SelectOption {
+	return func(c *evalConfig) { c.structs = true }
+}
@@ -0,0 +1,35 @@
+//go:build !jsonpath_minimal
+
+package jsonpath_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+)
+
+// Select from Go structs with WithStructSupport.
+func ExampleWithStructSupport() {
+	type Container struct {
+		Name  string `json:"name"`
+		Image string `json:"image"`
+		Port  int    `json:"port,omitempty"`
+	}
+	type Config struct {
+		Containers []Container `json:"containers"`
+	}
+	cfg := Config{Containers: []Container{
+		{Name: "web", Image: "nginx", Port: 80},
+		{Name: "sidecar", Image: "envoy"},
+	}}
+
+	// Select the names of containers that expose a port.
+	p := jsonpath.MustParse(`$.containers[?@.port].name`)
+	nodes, err := p.SelectWith(cfg, jsonpath.WithStructSupport())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%v\n", nodes)
+	// Output: [web]
+}
@@ -0,0 +1,11 @@
+//go:build jsonpath_minimal
+
+package jsonpath
+
+import "fmt"
+
+// toJSONValue returns an ErrUnsupportedValue error, because the
+// jsonpath_minimal build tag omits struct support.
+func toJSONValue(any) (any, error) {
+	return nil, fmt.Errorf("%w: struct support omitted by the jsonpath_minimal build tag", ErrUnsupportedValue)
+}
@@ -0,0 +1,43 @@
+//go:build jsonpath_minimal
+
+package jsonpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// skipRaw skips t, because the jsonpath_minimal build tag omits the
+// decoding of json.RawMessage values.
+func skipRaw(t *testing.T) {
+	t.Helper()
+	t.Skip("jsonpath_minimal omits json.RawMessage decoding")
+}
+
+func TestWithStructSupportMinimal(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	p := MustParse(`$.a`)
+
+	for _, input := range []any{
+		map[string]any{"a": 1},
+		struct{ A int }{1},
+		nil,
+	} {
+		res, err := p.SelectWith(input, WithStructSupport())
+		a.Nil(res)
+		require.ErrorIs(t, err, ErrUnsupportedValue)
+		a.EqualError(err, "jsonpath: unsupported value: struct support omitted by the jsonpath_minimal build tag")
+
+		loc, err := p.SelectLocatedWith(input, WithStructSupport())
+		a.Nil(loc)
+		require.ErrorIs(t, err, ErrUnsupportedValue)
+
+		// Evaluation without struct support is unaffected.
+		res, err = p.SelectWith(map[string]any{"a": 1})
+		require.NoError(t, err)
+		a.Equal(NodeList{1}, res)
+	}
+}
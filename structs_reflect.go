@@ -0,0 +1,296 @@
+//go:build !jsonpath_minimal
+
+package jsonpath
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fieldCache caches the fields converted by toJSONValue by struct type.
+//
+//nolint:gochecknoglobals
+var fieldCache sync.Map
+
+// jsonField describes a struct field that toJSONValue converts to an object
+// member.
+type jsonField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+}
+
+// Reflection types of marshaler interfaces.
+//
+//nolint:gochecknoglobals
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// toJSONValue converts val to the map[string]any and []any tree of its JSON
+// encoding, as described by [WithStructSupport].
+func toJSONValue(val any) (any, error) {
+	c := &converter{visiting: map[visit]struct{}{}}
+	return c.convert(reflect.ValueOf(val))
+}
+
+// visit identifies a pointer, map, or slice visited by a converter.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// converter converts Go values to JSON values.
+type converter struct {
+	// visiting records the pointers, maps, and slices on the path to the
+	// value being converted, to detect cycles.
+	visiting map[visit]struct{}
+}
+
+// convert converts v to a JSON value.
+//
+//nolint:gocyclo,exhaustive
+func (c *converter) convert(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+	}
+
+	if v.Type().Implements(jsonMarshalerType) {
+		return marshalJSON(v)
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText() //nolint:forcetypeassert
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnsupportedValue, err)
+		}
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Interface:
+		return c.convert(v.Elem())
+	case reflect.Pointer:
+		return c.visit(v, func() (any, error) { return c.convert(v.Elem()) })
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		return c.visit(v, func() (any, error) { return c.array(v) })
+	case reflect.Array:
+		return c.array(v)
+	case reflect.Map:
+		return c.visit(v, func() (any, error) { return c.object(v) })
+	case reflect.Struct:
+		return c.structure(v)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedValue, v.Type())
+	}
+}
+
+// visit calls fn to convert v, a pointer, map, or slice, unless v is
+// already being converted, in which case it returns an error for the
+// cycle.
+func (c *converter) visit(v reflect.Value, fn func() (any, error)) (any, error) {
+	key := visit{v.Pointer(), v.Type()}
+	if _, ok := c.visiting[key]; ok {
+		return nil, fmt.Errorf("%w: encountered a cycle via %v", ErrUnsupportedValue, v.Type())
+	}
+	c.visiting[key] = struct{}{}
+	defer delete(c.visiting, key)
+	return fn()
+}
+
+// array converts v, a slice or array, to []any.
+func (c *converter) array(v reflect.Value) (any, error) {
+	res := make([]any, v.Len())
+	for i := range res {
+		var err error
+		if res[i], err = c.convert(v.Index(i)); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// object converts v, a map, to map[string]any.
+func (c *converter) object(v reflect.Value) (any, error) {
+	res := make(map[string]any, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		if res[key], err = c.convert(iter.Value()); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// mapKey converts k, a map key, to a string as [encoding/json] does.
+//
+//nolint:exhaustive
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if k.Type().Implements(textMarshalerType) {
+		text, err := k.Interface().(encoding.TextMarshaler).MarshalText() //nolint:forcetypeassert
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrUnsupportedValue, err)
+		}
+		return string(text), nil
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	default:
+		return "", fmt.Errorf("%w: map key type %v", ErrUnsupportedValue, k.Type())
+	}
+}
+
+// structure converts v, a struct, to map[string]any.
+func (c *converter) structure(v reflect.Value) (any, error) {
+	fields := jsonFields(v.Type())
+	res := make(map[string]any, len(fields))
+	for _, f := range fields {
+		fv, err := v.FieldByIndexErr(f.index)
+		if err != nil {
+			// Promoted from a nil embedded pointer.
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		if res[f.name], err = c.convert(fv); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// isEmptyValue returns true if v is empty as defined by the omitempty
+// option of [encoding/json]: false, 0, a nil pointer or interface, or an
+// empty array, map, slice, or string.
+//
+//nolint:exhaustive
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	default:
+		return false
+	}
+}
+
+// jsonFields returns the fields of typ, a struct type, to convert to object
+// members, caching them for subsequent calls. Like [encoding/json], when
+// fields share a name, it selects the least nested.
+func jsonFields(typ reflect.Type) []jsonField {
+	if fields, ok := fieldCache.Load(typ); ok {
+		return fields.([]jsonField) //nolint:forcetypeassert
+	}
+
+	fields := []jsonField{}
+	byName := map[string]int{}
+	for _, f := range reflect.VisibleFields(typ) {
+		tag := f.Tag.Get("json")
+		name, opts, _ := strings.Cut(tag, ",")
+		switch {
+		case tag == "-":
+			continue
+		case f.Anonymous && name == "" && isStruct(f.Type):
+			// Its fields are promoted.
+			continue
+		case !f.IsExported() || !isPromoted(typ, f.Index):
+			continue
+		case name == "":
+			name = f.Name
+		}
+
+		field := jsonField{
+			index:     f.Index,
+			name:      name,
+			omitEmpty: slices.Contains(strings.Split(opts, ","), "omitempty"),
+		}
+		if i, ok := byName[name]; ok {
+			if len(fields[i].index) > len(f.Index) {
+				fields[i] = field
+			}
+			continue
+		}
+		byName[name] = len(fields)
+		fields = append(fields, field)
+	}
+
+	fieldCache.Store(typ, fields)
+	return fields
+}
+
+// isStruct returns true if typ is a struct or a pointer to a struct.
+func isStruct(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct
+}
+
+// isPromoted returns false if the field of typ at index is nested in an
+// embedded struct that is not promoted, because it's tagged with a name or
+// with "-", and true otherwise.
+func isPromoted(typ reflect.Type, index []int) bool {
+	for i := 1; i < len(index); i++ {
+		if name, _, _ := strings.Cut(typ.FieldByIndex(index[:i]).Tag.Get("json"), ","); name != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// marshalJSON converts v, a [json.Marshaler], to the JSON value it
+// marshals to.
+func marshalJSON(v reflect.Value) (any, error) {
+	js, err := v.Interface().(json.Marshaler).MarshalJSON() //nolint:forcetypeassert
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedValue, err)
+	}
+	var res any
+	if err := json.Unmarshal(js, &res); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedValue, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,312 @@
+//go:build !jsonpath_minimal
+
+package jsonpath
+
+import (
+	"errors"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// skipRaw does nothing, because json.RawMessage values decode unless built
+// with the jsonpath_minimal build tag.
+func skipRaw(*testing.T) {}
+
+type structBase struct {
+	ID      int    `json:"id"`
+	Kind    string `json:"kind,omitempty"`
+	Comment string
+}
+
+type structTags struct {
+	Tags []string `json:"tags"`
+}
+
+type structItem struct {
+	structBase
+	*structTags
+	Named   structBase            `json:"named"`
+	Name    string                `json:"name"`
+	Kind    string                `json:"kind"`
+	Price   float32               `json:"price"`
+	Count   uint8                 `json:"count,omitempty"`
+	Skip    string                `json:"-"`
+	Dash    string                `json:"-,"`
+	Data    []byte                `json:"data,omitempty"`
+	Next    *structItem           `json:"next,omitempty"`
+	Any     any                   `json:"any"`
+	Created time.Time             `json:"created"`
+	Addr    netip.Addr            `json:"addr"`
+	Attrs   map[int]bool          `json:"attrs,omitempty"`
+	Hosts   map[netip.Addr]string `json:"hosts,omitempty"`
+	private string
+}
+
+type structCycle struct {
+	Next *structCycle `json:"next"`
+}
+
+type structMarshalErr struct{}
+
+func (structMarshalErr) MarshalJSON() ([]byte, error) { return nil, errors.New("oops") }
+
+func TestWithStructSupport(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	created := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+	addr := netip.MustParseAddr("192.0.2.1")
+	item := &structItem{
+		structBase: structBase{ID: 42, Kind: "embedded", Comment: "hi"},
+		structTags: &structTags{Tags: []string{"x", "y"}},
+		Named:      structBase{ID: 7},
+		Name:       "widget",
+		Kind:       "gadget",
+		Price:      9.5,
+		Skip:       "skipped",
+		Dash:       "dash",
+		Data:       []byte("hello"),
+		Next:       &structItem{Name: "next", Count: 3},
+		Any:        []int{1, 2, 3},
+		Created:    created,
+		Addr:       addr,
+		Attrs:      map[int]bool{1: true, 2: false},
+		Hosts:      map[netip.Addr]string{addr: "example"},
+		private:    "private",
+	}
+
+	for _, tc := range []struct {
+		name  string
+		path  string
+		input any
+		exp   NodeList
+		paths []string
+	}{
+		{
+			name:  "field",
+			path:  `$.name`,
+			input: item,
+			exp:   NodeList{"widget"},
+			paths: []string{`$['name']`},
+		},
+		{
+			name:  "shallow_field_wins",
+			path:  `$.kind`,
+			input: item,
+			exp:   NodeList{"gadget"},
+		},
+		{
+			name:  "promoted_field",
+			path:  `$["id","Comment"]`,
+			input: item,
+			exp:   NodeList{int64(42), "hi"},
+		},
+		{
+			name:  "promoted_pointer_field",
+			path:  `$.tags[*]`,
+			input: item,
+			exp:   NodeList{"x", "y"},
+			paths: []string{`$['tags'][0]`, `$['tags'][1]`},
+		},
+		{
+			name:  "nil_embedded_pointer",
+			path:  `$.next.tags`,
+			input: item,
+			exp:   NodeList{},
+		},
+		{
+			name:  "named_struct",
+			path:  `$.named`,
+			input: item,
+			exp:   NodeList{map[string]any{"id": int64(7), "Comment": ""}},
+		},
+		{
+			name:  "omitempty",
+			path:  `$.next`,
+			input: item,
+			exp: NodeList{map[string]any{
+				"id": int64(0), "Comment": "", "named": map[string]any{"id": int64(0), "Comment": ""},
+				"name": "next", "kind": "", "price": float64(0), "count": uint64(3),
+				"-": "", "any": nil, "created": "0001-01-01T00:00:00Z", "addr": "",
+			}},
+		},
+		{
+			name:  "excluded",
+			path:  `$["Skip","skip","private","structBase","structTags"]`,
+			input: item,
+			exp:   NodeList{},
+		},
+		{
+			name:  "dash_name",
+			path:  `$["-"]`,
+			input: item,
+			exp:   NodeList{"dash"},
+		},
+		{
+			name:  "bytes",
+			path:  `$.data`,
+			input: item,
+			exp:   NodeList{"aGVsbG8="},
+		},
+		{
+			name:  "interface_slice",
+			path:  `$.any[?@ > 1]`,
+			input: item,
+			exp:   NodeList{int64(2), int64(3)},
+		},
+		{
+			name:  "json_marshaler",
+			path:  `$.created`,
+			input: item,
+			exp:   NodeList{"2024-06-01T12:30:00Z"},
+		},
+		{
+			name:  "text_marshaler",
+			path:  `$.addr`,
+			input: item,
+			exp:   NodeList{"192.0.2.1"},
+		},
+		{
+			name:  "int_keys",
+			path:  `$.attrs["1"]`,
+			input: item,
+			exp:   NodeList{true},
+		},
+		{
+			name:  "text_marshaler_keys",
+			path:  `$.hosts["192.0.2.1"]`,
+			input: item,
+			exp:   NodeList{"example"},
+		},
+		{
+			name:  "filter",
+			path:  `$[?@.price < 10 && @.id == 42].name`,
+			input: []structItem{*item, {Name: "pricey", Price: 20}},
+			exp:   NodeList{"widget"},
+			paths: []string{`$[0]['name']`},
+		},
+		{
+			name:  "array",
+			path:  `$[1]`,
+			input: [3]uint16{1, 2, 3},
+			exp:   NodeList{uint64(2)},
+		},
+		{
+			name:  "nil",
+			path:  `$`,
+			input: (*structItem)(nil),
+			exp:   NodeList{nil},
+		},
+		{
+			name:  "json_values",
+			path:  `$.a[0]`,
+			input: map[string]any{"a": []any{"x"}},
+			exp:   NodeList{"x"},
+		},
+		{
+			name:  "shared_not_cycle",
+			path:  `$[*][0]`,
+			input: func() any { s := []int{1}; return [][]int{s, s} }(),
+			exp:   NodeList{int64(1), int64(1)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(tc.path)
+
+			res, err := p.SelectWith(tc.input, WithStructSupport())
+			require.NoError(t, err)
+			a.Equal(tc.exp, res)
+
+			loc, err := p.SelectLocatedWith(tc.input, WithStructSupport())
+			require.NoError(t, err)
+			a.ElementsMatch(tc.exp, slices.Collect(loc.Nodes()))
+			if tc.paths != nil {
+				paths := []string{}
+				for p := range loc.Paths() {
+					paths = append(paths, p.String())
+				}
+				a.Equal(tc.paths, paths)
+			}
+		})
+	}
+}
+
+func TestWithStructSupportErrors(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	cycle := &structCycle{}
+	cycle.Next = cycle
+	loop := map[string]any{}
+	loop["self"] = loop
+
+	for _, tc := range []struct {
+		name  string
+		input any
+		err   string
+	}{
+		{
+			name:  "chan",
+			input: map[string]any{"c": make(chan int)},
+			err:   "jsonpath: unsupported value: chan int",
+		},
+		{
+			name:  "func",
+			input: []any{func() {}},
+			err:   "jsonpath: unsupported value: func()",
+		},
+		{
+			name:  "map_key",
+			input: map[float64]string{1.5: "x"},
+			err:   "jsonpath: unsupported value: map key type float64",
+		},
+		{
+			name:  "pointer_cycle",
+			input: cycle,
+			err:   "jsonpath: unsupported value: encountered a cycle via *jsonpath.structCycle",
+		},
+		{
+			name:  "map_cycle",
+			input: loop,
+			err:   "jsonpath: unsupported value: encountered a cycle via map[string]interface {}",
+		},
+		{
+			name:  "marshal_error",
+			input: []any{structMarshalErr{}},
+			err:   "jsonpath: unsupported value: oops",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := MustParse(`$`)
+
+			res, err := p.SelectWith(tc.input, WithStructSupport())
+			a.Nil(res)
+			require.ErrorIs(t, err, ErrUnsupportedValue)
+			a.EqualError(err, tc.err)
+
+			loc, err := p.SelectLocatedWith(tc.input, WithStructSupport())
+			a.Nil(loc)
+			require.ErrorIs(t, err, ErrUnsupportedValue)
+			a.EqualError(err, tc.err)
+		})
+	}
+}
+
+func TestFingerprintStructs(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	// Structs hash as their JSON representations.
+	val := structBase{ID: 1, Comment: "x"}
+	obj := map[string]any{"id": 1, "Comment": "x"}
+	a.Equal(FingerprintResults([]any{obj}), FingerprintResults([]any{val}))
+	a.Equal(FingerprintResults([]any{obj}), FingerprintResults([]any{&val}))
+	a.NotEqual(FingerprintResults([]any{obj}), FingerprintResults([]any{structBase{ID: 2}}))
+}
@@ -0,0 +1,147 @@
+// Package testsupport loads corpora of JSONPath test cases in the format of
+// the [JSONPath Compliance Test Suite], for testing JSONPath implementations.
+//
+// The package embeds a small corpus, returned by [Embedded], and loads
+// additional corpora from the directory named by the [EnvCorpus]
+// environment variable, returned by [External]. Downstream packagers can
+// therefore run extended or vendor corpora against the engine without
+// rebuilding:
+//
+//	JSONPATH_TEST_CORPUS=/path/to/corpora go test github.com/theory/jsonpath
+//
+// [JSONPath Compliance Test Suite]: https://github.com/jsonpath-standard/jsonpath-compliance-test-suite
+package testsupport
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// EnvCorpus is the name of the environment variable that names a directory
+// of additional corpus files for [External] to load.
+const EnvCorpus = "JSONPATH_TEST_CORPUS"
+
+//go:embed corpus/*.json
+var embedded embed.FS
+
+// Case represents a single test case.
+//
+//nolint:tagliatelle
+type Case struct {
+	// Name describes the test case.
+	Name string `json:"name"`
+
+	// Selector is the JSONPath query to parse.
+	Selector string `json:"selector"`
+
+	// Document is the JSON value from which to select.
+	Document any `json:"document"`
+
+	// Result lists the values Selector selects from Document. Nil when
+	// the test case allows multiple results or expects an invalid
+	// selector.
+	Result []any `json:"result"`
+
+	// Results lists the valid alternatives for the values Selector selects
+	// from Document, for queries that select object members in
+	// nondeterministic order.
+	Results [][]any `json:"results"`
+
+	// ResultPaths lists the normalized paths of the nodes Selector selects
+	// from Document, in the same order as Result. Nil when the test case
+	// does not specify them.
+	ResultPaths []string `json:"result_paths"`
+
+	// ResultsPaths lists the normalized paths of the nodes for each
+	// alternative in Results, in the same order.
+	ResultsPaths [][]string `json:"results_paths"`
+
+	// InvalidSelector is true if Selector is invalid and must fail to
+	// parse.
+	InvalidSelector bool `json:"invalid_selector"`
+
+	// Source is the name of the file from which the case was loaded.
+	Source string `json:"-"`
+}
+
+// Load decodes the test cases in a corpus from r. The corpus must be a JSON
+// object with a tests array, as in the JSONPath Compliance Test Suite's
+// cts.json file.
+func Load(r io.Reader) ([]Case, error) {
+	var corpus struct {
+		Tests []Case `json:"tests"`
+	}
+	if err := json.NewDecoder(r).Decode(&corpus); err != nil {
+		return nil, fmt.Errorf("testsupport: cannot decode corpus: %w", err)
+	}
+	return corpus.Tests, nil
+}
+
+// LoadFile loads the test cases in the corpus file at name.
+func LoadFile(name string) ([]Case, error) {
+	return loadFile(os.DirFS(filepath.Dir(name)), filepath.Base(name))
+}
+
+// LoadFS loads the test cases from all of the corpus files with the .json
+// extension in the root directory of fsys, in lexical order by file name.
+func LoadFS(fsys fs.FS) ([]Case, error) {
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("testsupport: %w", err)
+	}
+
+	cases := []Case{}
+	for _, name := range names {
+		c, err := loadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c...)
+	}
+	return cases, nil
+}
+
+// Embedded returns the test cases of the corpus embedded in the package.
+func Embedded() []Case {
+	sub, _ := fs.Sub(embedded, "corpus")
+	cases, err := LoadFS(sub)
+	if err != nil {
+		panic(err)
+	}
+	return cases
+}
+
+// External loads the test cases from the corpus files in the directory
+// named by the [EnvCorpus] environment variable, as described by [LoadFS].
+// Returns no cases if the variable is not set.
+func External() ([]Case, error) {
+	dir := os.Getenv(EnvCorpus)
+	if dir == "" {
+		return nil, nil
+	}
+	return LoadFS(os.DirFS(dir))
+}
+
+// loadFile loads the test cases in the corpus file name in fsys, setting
+// the Source of each to name.
+func loadFile(fsys fs.FS, name string) ([]Case, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("testsupport: %w", err)
+	}
+	defer f.Close()
+
+	cases, err := Load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w in %v", err, name)
+	}
+	for i := range cases {
+		cases[i].Source = name
+	}
+	return cases, nil
+}
@@ -0,0 +1,133 @@
+package testsupport
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	cases, err := Load(strings.NewReader(`{"tests": [
+		{"name": "one", "selector": "$.a", "document": {"a": 1}, "result": [1]},
+		{"name": "two", "selector": "$.*", "document": {"a": 1, "b": 2}, "results": [[1, 2], [2, 1]]},
+		{"name": "three", "selector": "$[", "invalid_selector": true},
+		{"name": "four", "selector": "$.a", "document": {}, "result": []},
+		{"name": "five", "selector": "$[0]", "document": [1], "result": [1], "result_paths": ["$[0]"]},
+		{"name": "six", "selector": "$.*", "document": {"a": 1}, "results": [[1]], "results_paths": [["$['a']"]]}
+	]}`))
+	r.NoError(err)
+	a.Equal([]Case{
+		{Name: "one", Selector: "$.a", Document: map[string]any{"a": 1.0}, Result: []any{1.0}},
+		{
+			Name:     "two",
+			Selector: "$.*",
+			Document: map[string]any{"a": 1.0, "b": 2.0},
+			Results:  [][]any{{1.0, 2.0}, {2.0, 1.0}},
+		},
+		{Name: "three", Selector: "$[", InvalidSelector: true},
+		{Name: "four", Selector: "$.a", Document: map[string]any{}, Result: []any{}},
+		{
+			Name:        "five",
+			Selector:    "$[0]",
+			Document:    []any{1.0},
+			Result:      []any{1.0},
+			ResultPaths: []string{"$[0]"},
+		},
+		{
+			Name:         "six",
+			Selector:     "$.*",
+			Document:     map[string]any{"a": 1.0},
+			Results:      [][]any{{1.0}},
+			ResultsPaths: [][]string{{"$['a']"}},
+		},
+	}, cases)
+
+	_, err = Load(strings.NewReader(`{"tests": `))
+	r.EqualError(err, "testsupport: cannot decode corpus: unexpected EOF")
+}
+
+func TestLoadFS(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	fsys := fstest.MapFS{
+		"b.json":     {Data: []byte(`{"tests": [{"name": "b1"}, {"name": "b2"}]}`)},
+		"a.json":     {Data: []byte(`{"tests": [{"name": "a1"}]}`)},
+		"c.txt":      {Data: []byte(`not json`)},
+		"sub/d.json": {Data: []byte(`{"tests": [{"name": "d1"}]}`)},
+	}
+	cases, err := LoadFS(fsys)
+	r.NoError(err)
+	a.Equal([]Case{
+		{Name: "a1", Source: "a.json"},
+		{Name: "b1", Source: "b.json"},
+		{Name: "b2", Source: "b.json"},
+	}, cases)
+
+	// Empty directory.
+	cases, err = LoadFS(fstest.MapFS{})
+	r.NoError(err)
+	a.Empty(cases)
+
+	// Invalid file.
+	fsys["bad.json"] = &fstest.MapFile{Data: []byte(`[`)}
+	_, err = LoadFS(fsys)
+	r.EqualError(err, "testsupport: cannot decode corpus: unexpected EOF in bad.json")
+}
+
+func TestLoadFile(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	cases, err := LoadFile(filepath.Join("corpus", "rfc9535.json"))
+	r.NoError(err)
+	a.Equal(Embedded(), cases)
+	a.NotEmpty(cases)
+	for _, c := range cases {
+		a.Equal("rfc9535.json", c.Source)
+		a.NotEmpty(c.Name)
+		a.NotEmpty(c.Selector)
+	}
+
+	_, err = LoadFile(filepath.Join("corpus", "nonesuch.json"))
+	r.ErrorIs(err, os.ErrNotExist)
+}
+
+//nolint:paralleltest // uses t.Setenv
+func TestExternal(t *testing.T) {
+	a := assert.New(t)
+	r := require.New(t)
+
+	t.Setenv(EnvCorpus, "")
+	cases, err := External()
+	r.NoError(err)
+	a.Nil(cases)
+
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(
+		filepath.Join(dir, "vendor.json"),
+		[]byte(`{"tests": [{"name": "v", "selector": "$", "document": 1, "result": [1]}]}`),
+		0o600,
+	))
+	t.Setenv(EnvCorpus, dir)
+	cases, err = External()
+	r.NoError(err)
+	a.Equal([]Case{{
+		Name:     "v",
+		Selector: "$",
+		Document: 1.0,
+		Result:   []any{1.0},
+		Source:   "vendor.json",
+	}}, cases)
+}
@@ -0,0 +1,267 @@
+package jsonpath
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/theory/jsonpath/parser"
+	"github.com/theory/jsonpath/spec"
+)
+
+// Validation finding codes.
+const (
+	// FindingUnreachableSelector indicates a selector that can never select
+	// a node, such as the slice [1:1] or a filter that is always false, or
+	// a segment that can never be reached because a previous segment can
+	// never select a node.
+	FindingUnreachableSelector = "unreachable-selector"
+
+	// FindingAlwaysFalse indicates a filter comparison that is false for
+	// every node, such as @.a < true, because only numbers and strings can
+	// be ordered, or a comparison of literals such as 1 == 2.
+	FindingAlwaysFalse = "always-false"
+
+	// FindingUnknownFunction indicates a call to a function unknown to the
+	// parser. Its message suggests a known function with a similar name, if
+	// any.
+	FindingUnknownFunction = "unknown-function"
+
+	// FindingDeprecatedSyntax indicates a query that uses legacy syntax
+	// accepted only by lenient parsers, such as $[foo]. Its message suggests
+	// the equivalent RFC 9535 query.
+	FindingDeprecatedSyntax = "deprecated-syntax"
+)
+
+// ValidationReport describes the result of validating a query with
+// [Validate] or [Parser.Validate].
+type ValidationReport struct {
+	// Query is the normalized string representation of the query, or empty
+	// if it failed to parse.
+	Query string `json:"query,omitempty"`
+
+	// Warnings lists potential problems with the query and hints for fixing
+	// it. Includes the findings of [Path.Analyze].
+	Warnings []Finding `json:"warnings"`
+}
+
+// Validate parses path with the default [Parser] and reports potential
+// problems with it. See [Parser.Validate] for details.
+func Validate(path string) (*ValidationReport, error) {
+	return NewParser().Validate(path)
+}
+
+// Validate parses path and, beyond determining whether it's valid, reports
+// potential problems with it as warnings, without evaluating it. Useful for
+// linting queries in configuration files. The warnings include the findings
+// of [Path.Analyze] and findings with these codes:
+//
+//   - [FindingUnreachableSelector]: selectors that can never select a node
+//     and segments that can never be reached
+//   - [FindingAlwaysFalse]: filter comparisons that are false for every node
+//   - [FindingUnknownFunction]: calls to unknown functions, with suggestions
+//     for similarly named functions
+//   - [FindingDeprecatedSyntax]: legacy syntax accepted by [WithLenient],
+//     with the equivalent RFC 9535 query
+//
+// Always returns a report. Returns an ErrPathParse error if c cannot parse
+// path, in which case the report contains only any hints for fixing it.
+//
+//nolint:wrapcheck
+func (c *Parser) Validate(path string) (*ValidationReport, error) {
+	report := &ValidationReport{Warnings: []Finding{}}
+	strict := c.opts
+	if c.feat.Lenient {
+		strict = c.opts[:len(c.opts)-1]
+	}
+
+	q, err := parser.Parse(c.reg, path, strict...)
+	if err != nil {
+		lq, lerr := parser.Parse(c.reg, path, append(slices.Clip(strict), parser.WithLenient())...)
+		if lerr != nil {
+			c.suggestFunction(report, lerr)
+			return report, err
+		}
+		report.find(FindingDeprecatedSyntax, "query uses syntax that RFC 9535 does not allow; use "+lq.String())
+		if !c.feat.Lenient {
+			return report, err
+		}
+		q = lq
+	}
+
+	p := New(q)
+	report.Query = p.String()
+	report.query(q)
+	for _, f := range p.Analyze().Findings {
+		report.find(f.Code, f.Message)
+	}
+	return report, nil
+}
+
+// suggestFunction records a [FindingUnknownFunction] in report if err
+// reports an unknown function, suggesting the function in c's registry with
+// the most similar name, if any.
+func (c *Parser) suggestFunction(report *ValidationReport, err error) {
+	var perr *ParseError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrSemantic) ||
+		!strings.HasPrefix(perr.Message(), "unknown function ") {
+		return
+	}
+	name := perr.Token()
+
+	msg := "unknown function " + name + "()"
+	best, bestDist := "", len(name)/2+1
+	for _, known := range c.reg.Names() {
+		if dist := editDistance(strings.ToLower(name), known); dist < bestDist {
+			best, bestDist = known, dist
+		}
+	}
+	if best != "" {
+		msg += "; did you mean " + best + "()?"
+	}
+	report.find(FindingUnknownFunction, msg)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ar {
+		cur[0] = i + 1
+		for j := range br {
+			cost := 1
+			if ar[i] == br[j] {
+				cost = 0
+			}
+			cur[j+1] = min(prev[j+1]+1, cur[j]+1, prev[j]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+// find records a warning unless it has already been recorded.
+func (vr *ValidationReport) find(code, msg string) {
+	f := Finding{Code: code, Message: msg}
+	if !slices.Contains(vr.Warnings, f) {
+		vr.Warnings = append(vr.Warnings, f)
+	}
+}
+
+// query records warnings for the segments of q and the expressions in its
+// filters.
+func (vr *ValidationReport) query(q *spec.PathQuery) {
+	segs := q.Segments()
+	for i, seg := range segs {
+		reachable := false
+		for _, sel := range seg.Selectors() {
+			if vr.selector(sel) {
+				reachable = true
+			} else {
+				vr.find(FindingUnreachableSelector, "selector ["+sel.String()+"] never selects a node")
+			}
+		}
+		if !reachable && i < len(segs)-1 {
+			rest := spec.Query(q.IsRoot(), segs[i+1:]).String()[1:]
+			vr.find(FindingUnreachableSelector, "segment "+seg.String()+" selects no nodes, so "+rest+" is unreachable")
+			return
+		}
+	}
+}
+
+// selector records warnings for the expressions in sel, if it's a filter,
+// and returns false if sel can never select a node.
+func (vr *ValidationReport) selector(sel spec.Selector) bool {
+	switch sel := sel.(type) {
+	case spec.SliceSelector:
+		return !emptySlice(sel)
+	case *spec.FilterSelector:
+		vr.or(sel.LogicalOr)
+		_, t := optimizeOr(sel.LogicalOr)
+		return t != truthFalse
+	default:
+		return true
+	}
+}
+
+// or records warnings for the expressions in lo.
+func (vr *ValidationReport) or(lo spec.LogicalOr) {
+	for expr := range lo.Expressions() {
+		vr.expr(expr)
+	}
+}
+
+// expr records warnings for expr and the queries and expressions it
+// contains.
+func (vr *ValidationReport) expr(expr spec.BasicExpr) {
+	switch expr := expr.(type) {
+	case *spec.ParenExpr:
+		vr.or(expr.Inner())
+	case *spec.NotParenExpr:
+		vr.or(expr.Inner())
+	case *spec.ComparisonExpr:
+		if alwaysFalse(expr) {
+			vr.find(FindingAlwaysFalse, "comparison "+spec.Filter(spec.LogicalOr{{expr}}).String()[1:]+" is false for every node")
+		}
+		vr.arg(expr.Left)
+		vr.arg(expr.Right)
+	case *spec.MembershipExpr:
+		vr.arg(expr.Left)
+		vr.arg(expr.Right)
+	case *spec.ExistExpr:
+		vr.query(expr.PathQuery)
+	case *spec.NonExistExpr:
+		vr.query(expr.PathQuery)
+	case *spec.FunctionExpr:
+		vr.arg(expr)
+	case spec.NotFuncExpr:
+		vr.arg(expr.FunctionExpr)
+	}
+}
+
+// arg records warnings for arg, a comparison value or function argument.
+func (vr *ValidationReport) arg(arg any) {
+	switch arg := arg.(type) {
+	case *spec.FilterQueryExpr:
+		vr.query(arg.PathQuery)
+	case spec.LogicalOr:
+		vr.or(arg)
+	case *spec.FunctionExpr:
+		for _, a := range arg.Args() {
+			vr.arg(a)
+		}
+	case *spec.ArithmeticExpr:
+		vr.arg(arg.Left)
+		vr.arg(arg.Right)
+	}
+}
+
+// alwaysFalse returns true if expr is false for every node: a comparison of
+// literals that is false, or a < or > comparison with a boolean or null
+// literal, which RFC 9535 defines as false because only numbers and strings
+// can be ordered.
+func alwaysFalse(expr *spec.ComparisonExpr) bool {
+	left, lLit := expr.Left.(*spec.LiteralArg)
+	right, rLit := expr.Right.(*spec.LiteralArg)
+	if lLit && rLit {
+		return !spec.Filter(spec.LogicalOr{{expr}}).Eval(nil, nil)
+	}
+
+	if expr.Op != spec.LessThan && expr.Op != spec.GreaterThan {
+		return false
+	}
+	for _, lit := range []*spec.LiteralArg{left, right} {
+		if lit == nil {
+			continue
+		}
+		switch lit.Value().(type) {
+		case nil, bool:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,210 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath/registry"
+	"github.com/theory/jsonpath/spec"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		path     string
+		query    string
+		warnings []Finding
+		err      string
+	}{
+		{
+			name:     "clean",
+			path:     `$.a[?@.b > 1].c`,
+			query:    `$["a"][?@["b"] > 1]["c"]`,
+			warnings: []Finding{},
+		},
+		{
+			name:  "empty_slice",
+			path:  `$[1:1,0]`,
+			query: `$[1:1,0]`,
+			warnings: []Finding{
+				{FindingUnreachableSelector, "selector [1:1] never selects a node"},
+			},
+		},
+		{
+			name:  "unreachable_segment",
+			path:  `$.a[::0].b[0]`,
+			query: `$["a"][::0]["b"][0]`,
+			warnings: []Finding{
+				{FindingUnreachableSelector, "selector [::0] never selects a node"},
+				{FindingUnreachableSelector, `segment [::0] selects no nodes, so ["b"][0] is unreachable`},
+			},
+		},
+		{
+			name:  "false_filter",
+			path:  `$[?1 == 2 || @[2:1]].x`,
+			query: `$[?1 == 2 || @[2:1]]["x"]`,
+			warnings: []Finding{
+				{FindingAlwaysFalse, "comparison 1 == 2 is false for every node"},
+				{FindingUnreachableSelector, "selector [2:1] never selects a node"},
+				{FindingUnreachableSelector, "selector [?1 == 2 || @[2:1]] never selects a node"},
+				{FindingUnreachableSelector, `segment [?1 == 2 || @[2:1]] selects no nodes, so ["x"] is unreachable`},
+				{FindingConstantComparison, "comparison of literals has the same result for every node"},
+			},
+		},
+		{
+			name:  "ordered_bool",
+			path:  `$[?@.a < true || null > @.b || @.c <= false]`,
+			query: `$[?@["a"] < true || null > @["b"] || @["c"] <= false]`,
+			warnings: []Finding{
+				{FindingAlwaysFalse, `comparison @["a"] < true is false for every node`},
+				{FindingAlwaysFalse, `comparison null > @["b"] is false for every node`},
+			},
+		},
+		{
+			name:  "nested",
+			path:  `$[?count(@[?(@.x > false)]) > 0 && !@.y[0:0]]`,
+			query: `$[?count(@[?(@["x"] > false)]) > 0 && !@["y"][:0]]`,
+			warnings: []Finding{
+				{FindingAlwaysFalse, `comparison @["x"] > false is false for every node`},
+				{FindingUnreachableSelector, "selector [:0] never selects a node"},
+			},
+		},
+		{
+			name:  "analysis",
+			path:  `$..*`,
+			query: `$..[*]`,
+			warnings: []Finding{
+				{FindingDescendantWildcard, "descendant wildcard selects every node in the input"},
+			},
+		},
+		{
+			name: "misspelled_function",
+			path: `$[?lenght(@) > 1]`,
+			warnings: []Finding{
+				{FindingUnknownFunction, "unknown function lenght(); did you mean length()?"},
+			},
+			err: "jsonpath: unknown function lenght() at position 4",
+		},
+		{
+			name: "capitalized_function",
+			path: `$[?Count(@.*) > 1]`,
+			warnings: []Finding{
+				{FindingUnknownFunction, "unknown function Count(); did you mean count()?"},
+			},
+			err: "jsonpath: unknown function Count() at position 4",
+		},
+		{
+			name: "unknown_function",
+			path: `$[?nope(@)]`,
+			warnings: []Finding{
+				{FindingUnknownFunction, "unknown function nope()"},
+			},
+			err: "jsonpath: unknown function nope() at position 4",
+		},
+		{
+			name: "unquoted_name",
+			path: `$[foo]`,
+			warnings: []Finding{
+				{FindingDeprecatedSyntax, `query uses syntax that RFC 9535 does not allow; use $["foo"]`},
+			},
+			err: "jsonpath: unexpected identifier at position 3",
+		},
+		{
+			name: "single_equals",
+			path: `$[?@.a = 1]`,
+			warnings: []Finding{
+				{FindingDeprecatedSyntax, `query uses syntax that RFC 9535 does not allow; use $[?@["a"] == 1]`},
+			},
+			err: "jsonpath: invalid comparison operator at position 8",
+		},
+		{
+			name:     "syntax_error",
+			path:     `$[`,
+			warnings: []Finding{},
+			err:      "jsonpath: unexpected eof at position 3",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			report, err := Validate(tc.path)
+			r.NotNil(report)
+			a.Equal(&ValidationReport{Query: tc.query, Warnings: tc.warnings}, report)
+			if tc.err == "" {
+				r.NoError(err)
+				_, err = Parse(tc.path)
+				r.NoError(err)
+			} else {
+				r.EqualError(err, tc.err)
+				r.ErrorIs(err, ErrPathParse)
+			}
+		})
+	}
+}
+
+func TestParserValidate(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	// Lenient parsers accept legacy syntax, but warn about it.
+	report, err := NewParser(WithLenient()).Validate(`$[foo][1:1]`)
+	r.NoError(err)
+	a.Equal(&ValidationReport{
+		Query: `$["foo"][1:1]`,
+		Warnings: []Finding{
+			{FindingDeprecatedSyntax, `query uses syntax that RFC 9535 does not allow; use $["foo"][1:1]`},
+			{FindingUnreachableSelector, "selector [1:1] never selects a node"},
+		},
+	}, report)
+
+	report, err = NewParser(WithLenient()).Validate(`$["foo"]`)
+	r.NoError(err)
+	a.Equal(&ValidationReport{Query: `$["foo"]`, Warnings: []Finding{}}, report)
+
+	// Suggest functions from the parser's registry.
+	reg := registry.New()
+	r.NoError(reg.Register(
+		"first", spec.FuncValue,
+		func([]spec.FunctionExprArg) error { return nil },
+		func([]spec.JSONPathValue) spec.JSONPathValue { return nil },
+	))
+	report, err = NewParser(WithRegistry(reg)).Validate(`$[?frist(@.*) == 1]`)
+	r.EqualError(err, "jsonpath: unknown function frist() at position 4")
+	a.Equal(&ValidationReport{Warnings: []Finding{
+		{FindingUnknownFunction, "unknown function frist(); did you mean first()?"},
+	}}, report)
+
+	js, err := json.Marshal(report)
+	r.NoError(err)
+	a.JSONEq(
+		`{"warnings": [{"code": "unknown-function", "message": "unknown function frist(); did you mean first()?"}]}`,
+		string(js),
+	)
+}
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	for _, tc := range []struct {
+		a, b string
+		exp  int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"length", "length", 0},
+		{"lenght", "length", 2},
+		{"kitten", "sitting", 3},
+		{"café", "cafe", 1},
+	} {
+		a.Equal(tc.exp, editDistance(tc.a, tc.b), tc.a+" vs "+tc.b)
+	}
+}
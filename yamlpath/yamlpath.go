@@ -0,0 +1,291 @@
+// Package yamlpath evaluates JSONPath queries against [yaml.Node] trees
+// decoded by [gopkg.in/yaml.v3], and returns the YAML node of each value a
+// query selects, so that applications such as editors can report or jump
+// to the line and column in the source of each match:
+//
+//	var doc yaml.Node
+//	if err := yaml.Unmarshal(src, &doc); err != nil {
+//		return err
+//	}
+//	matches, err := yamlpath.Select(path, &doc)
+//	if err != nil {
+//		return err
+//	}
+//	for _, m := range matches {
+//		fmt.Printf("%v:%v: %v\n", m.Node.Line, m.Node.Column, m.Path)
+//	}
+//
+// Queries select from YAML as from the equivalent JSON: mappings are
+// objects that select members in the order they appear in the source,
+// sequences are arrays, and scalars decode as they would into an any
+// value, except that timestamps remain strings. Aliases resolve to their
+// anchored nodes, and merge keys (<<) merge the members of the mappings
+// they reference, as when decoding.
+package yamlpath
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnsupported errors are returned by [Select] for YAML that has no JSON
+// equivalent, such as mappings with sequence or mapping keys, or aliases
+// that refer to themselves.
+var ErrUnsupported = errors.New("yamlpath: unsupported YAML")
+
+// Match represents a node selected by [Select].
+type Match struct {
+	// Path is the normalized path of the node.
+	Path spec.NormalizedPath
+
+	// Node is the YAML node. Its Line and Column fields report its location
+	// in the source. For values included by an alias or merge key, Node is
+	// the node in the anchored source, so several matches may share it. Nil
+	// for the root of an empty document.
+	Node *yaml.Node
+
+	// Value is the decoded value of the node, with mappings decoded as
+	// *[spec.OrderedObject] values.
+	Value any
+}
+
+// Select returns a Match for each node p selects from node, in the order p
+// selects them. Node may be a document node, as decoded by [yaml.Unmarshal],
+// or the root node of its content. Returns an [ErrUnsupported] error if
+// node contains YAML that cannot be queried as JSON.
+func Select(p *jsonpath.Path, node *yaml.Node) ([]Match, error) {
+	root := content(node)
+	input, err := decode(root, map[*yaml.Node]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := p.SelectLocated(input)
+	matches := make([]Match, len(nodes))
+	for i, n := range nodes {
+		matches[i] = Match{Path: n.Path, Node: resolve(root, n.Path), Value: n.Node}
+	}
+	return matches, nil
+}
+
+// content returns the root node of the content of node, a document node,
+// or node itself if it's not a document node. Returns nil for an empty
+// document.
+func content(node *yaml.Node) *yaml.Node {
+	if node == nil || node.Kind != yaml.DocumentNode {
+		return node
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+	return node.Content[0]
+}
+
+// decode decodes node into the JSON data model, with mappings decoded as
+// *spec.OrderedObject values. Expanding records the mappings and sequences
+// being decoded, to detect aliases that refer to nodes that contain them.
+func decode(node *yaml.Node, expanding map[*yaml.Node]bool) (any, error) {
+	if node == nil {
+		return nil, nil
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		expanding[node] = true
+		defer delete(expanding, node)
+	case yaml.AliasNode:
+		if expanding[node.Alias] {
+			return nil, fmt.Errorf(
+				"%w: alias *%v refers to itself at line %v, column %v",
+				ErrUnsupported, node.Value, node.Line, node.Column,
+			)
+		}
+	}
+
+	switch node.Kind {
+	case yaml.AliasNode:
+		return decode(node.Alias, expanding)
+	case yaml.MappingNode:
+		members, err := mappingMembers(node, expanding)
+		if err != nil {
+			return nil, err
+		}
+		obj := &spec.OrderedObject{}
+		for _, m := range members {
+			val, err := decode(m.node, expanding)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(m.name, val)
+		}
+		return obj, nil
+	case yaml.SequenceNode:
+		arr := make([]any, len(node.Content))
+		for i, n := range node.Content {
+			val, err := decode(n, expanding)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = val
+		}
+		return arr, nil
+	case yaml.DocumentNode:
+		return decode(content(node), expanding)
+	default:
+		return decodeScalar(node)
+	}
+}
+
+// decodeScalar decodes the scalar node as when decoding into an any value,
+// except that it decodes timestamps as strings.
+func decodeScalar(node *yaml.Node) (any, error) {
+	if node.ShortTag() == "!!timestamp" {
+		return node.Value, nil
+	}
+	var val any
+	if err := node.Decode(&val); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+	return val, nil
+}
+
+// member is a member of a YAML mapping.
+type member struct {
+	name string
+	node *yaml.Node
+}
+
+// mappingMembers returns the members of the mapping node in the order they
+// appear in node. As when decoding, the value of the last of duplicate keys
+// wins, but at the position of the first, and a merge key merges, at its
+// position, the members of the mappings it references that node does not
+// define itself, with the members of earlier mappings taking precedence.
+// Returns an [ErrUnsupported] error for keys that are not scalars, merge
+// keys that do not reference mappings, and aliases that refer to
+// themselves.
+func mappingMembers(node *yaml.Node, expanding map[*yaml.Node]bool) ([]member, error) {
+	// Collect the keys node defines itself, which override merged keys.
+	defined := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := unalias(node.Content[i])
+		if key.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf(
+				"%w: non-scalar mapping key at line %v, column %v",
+				ErrUnsupported, key.Line, key.Column,
+			)
+		}
+		if !isMerge(key) {
+			defined[key.Value] = true
+		}
+	}
+
+	members := make([]member, 0, len(node.Content)/2)
+	index := map[string]int{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := unalias(node.Content[i]), node.Content[i+1]
+		if !isMerge(key) {
+			if j, ok := index[key.Value]; ok {
+				members[j].node = val
+				continue
+			}
+			index[key.Value] = len(members)
+			members = append(members, member{key.Value, val})
+			continue
+		}
+
+		merged, err := mergedMembers(val, expanding)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range merged {
+			if _, ok := index[m.name]; !ok && !defined[m.name] {
+				index[m.name] = len(members)
+				members = append(members, m)
+			}
+		}
+	}
+	return members, nil
+}
+
+// mergedMembers returns the members that the value of a merge key merges
+// into a mapping: the members of the mapping val references, or, if val is
+// a sequence, of each mapping it references, with those of earlier
+// mappings taking precedence.
+func mergedMembers(val *yaml.Node, expanding map[*yaml.Node]bool) ([]member, error) {
+	sources := []*yaml.Node{val}
+	if unalias(val).Kind == yaml.SequenceNode {
+		sources = unalias(val).Content
+	}
+
+	var res []member
+	seen := map[string]bool{}
+	for _, src := range sources {
+		target := unalias(src)
+		if target.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf(
+				"%w: merge key value is not a mapping at line %v, column %v",
+				ErrUnsupported, src.Line, src.Column,
+			)
+		}
+		if expanding[target] {
+			return nil, fmt.Errorf(
+				"%w: alias *%v refers to itself at line %v, column %v",
+				ErrUnsupported, src.Value, src.Line, src.Column,
+			)
+		}
+		expanding[target] = true
+		members, err := mappingMembers(target, expanding)
+		delete(expanding, target)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if !seen[m.name] {
+				seen[m.name] = true
+				res = append(res, m)
+			}
+		}
+	}
+	return res, nil
+}
+
+// isMerge returns true if key is a merge key (<<).
+func isMerge(key *yaml.Node) bool {
+	return key.Kind == yaml.ScalarNode && key.ShortTag() == "!!merge"
+}
+
+// unalias returns the node that node refers to if it's an alias, and node
+// otherwise.
+func unalias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// resolve returns the node identified by np in root, which [decode] has
+// already decoded, so that np identifies a node in it.
+func resolve(root *yaml.Node, np spec.NormalizedPath) *yaml.Node {
+	node := root
+	for _, e := range np {
+		node = unalias(node)
+		switch e := e.(type) {
+		case spec.Name:
+			// Decoding succeeded, so listing members cannot fail.
+			members, _ := mappingMembers(node, map[*yaml.Node]bool{})
+			for _, m := range members {
+				if m.name == string(e) {
+					node = m.node
+					break
+				}
+			}
+		case spec.Index:
+			node = node.Content[e]
+		}
+	}
+	return unalias(node)
+}
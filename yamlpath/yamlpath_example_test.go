@@ -0,0 +1,36 @@
+package yamlpath_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/yamlpath"
+	"gopkg.in/yaml.v3"
+)
+
+func ExampleSelect() {
+	src := `spec:
+  containers:
+    - name: web
+      image: nginx:1.27
+    - name: sidecar
+      image: envoy:1.31
+`
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		log.Fatal(err)
+	}
+
+	p := jsonpath.MustParse("$.spec.containers[*].image")
+	matches, err := yamlpath.Select(p, &doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, m := range matches {
+		fmt.Printf("%v:%v: %v\n", m.Node.Line, m.Node.Column, m.Value)
+	}
+	// Output:
+	// 4:14: nginx:1.27
+	// 6:14: envoy:1.31
+}
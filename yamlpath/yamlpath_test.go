@@ -0,0 +1,179 @@
+package yamlpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/theory/jsonpath"
+	"github.com/theory/jsonpath/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// location is the location of a selected YAML node.
+type location struct {
+	path   string
+	line   int
+	column int
+	value  any
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+
+	src := `base: &base
+  image: nginx
+  port: 80
+spec:
+  containers:
+    - name: web
+      <<: *base
+      port: 8080
+    - name: sidecar
+      image: envoy
+  created: 2024-03-01
+  labels: {z: 1, a: 2, m: 3}
+  ref: *base
+`
+
+	for _, tc := range []struct {
+		name string
+		path string
+		exp  []location
+	}{
+		{
+			name: "root",
+			path: "$.spec.created",
+			exp:  []location{{"$['spec']['created']", 11, 12, "2024-03-01"}},
+		},
+		{
+			name: "names",
+			path: "$.spec.containers[*].name",
+			exp: []location{
+				{"$['spec']['containers'][0]['name']", 6, 13, "web"},
+				{"$['spec']['containers'][1]['name']", 9, 13, "sidecar"},
+			},
+		},
+		{
+			name: "merge",
+			path: "$.spec.containers[0].*",
+			exp: []location{
+				{"$['spec']['containers'][0]['name']", 6, 13, "web"},
+				{"$['spec']['containers'][0]['image']", 2, 10, "nginx"},
+				{"$['spec']['containers'][0]['port']", 8, 13, 8080},
+			},
+		},
+		{
+			name: "filter",
+			path: "$.spec.containers[?@.port == 8080].image",
+			exp:  []location{{"$['spec']['containers'][0]['image']", 2, 10, "nginx"}},
+		},
+		{
+			name: "alias",
+			path: "$.spec.ref.port",
+			exp:  []location{{"$['spec']['ref']['port']", 3, 9, 80}},
+		},
+		{
+			name: "member_order",
+			path: "$.spec.labels.*",
+			exp: []location{
+				{"$['spec']['labels']['z']", 12, 15, 1},
+				{"$['spec']['labels']['a']", 12, 21, 2},
+				{"$['spec']['labels']['m']", 12, 27, 3},
+			},
+		},
+		{
+			name: "none",
+			path: "$.nope",
+			exp:  []location{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			var doc yaml.Node
+			r.NoError(yaml.Unmarshal([]byte(src), &doc))
+			matches, err := Select(jsonpath.MustParse(tc.path), &doc)
+			r.NoError(err)
+
+			locs := make([]location, len(matches))
+			for i, m := range matches {
+				locs[i] = location{m.Path.String(), m.Node.Line, m.Node.Column, m.Value}
+			}
+			a.Equal(tc.exp, locs)
+		})
+	}
+}
+
+func TestSelectValues(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+	r := require.New(t)
+
+	var doc yaml.Node
+	r.NoError(yaml.Unmarshal([]byte("a: 1\nb: [x, true, null, 1.5]\na: 2\n"), &doc))
+
+	// Select from the content of the document.
+	matches, err := Select(jsonpath.MustParse("$"), doc.Content[0])
+	r.NoError(err)
+	r.Len(matches, 1)
+	a.Equal(doc.Content[0], matches[0].Node)
+	a.Equal(spec.NormalizedPath{}, matches[0].Path)
+
+	// The last of duplicate keys wins at the position of the first.
+	obj := &spec.OrderedObject{}
+	obj.Set("a", 2)
+	obj.Set("b", []any{"x", true, nil, 1.5})
+	a.Equal(obj, matches[0].Value)
+
+	// Empty documents select null.
+	matches, err = Select(jsonpath.MustParse("$"), &yaml.Node{Kind: yaml.DocumentNode})
+	r.NoError(err)
+	a.Equal([]Match{{Path: spec.NormalizedPath{}}}, matches)
+}
+
+func TestSelectUnsupported(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{
+			name: "sequence_key",
+			src:  "? [a]\n: 1\n",
+			err:  "yamlpath: unsupported YAML: non-scalar mapping key at line 1, column 3",
+		},
+		{
+			name: "merge_scalar",
+			src:  "a: &a 1\nb:\n  <<: *a\n",
+			err:  "yamlpath: unsupported YAML: merge key value is not a mapping at line 3, column 7",
+		},
+		{
+			name: "merge_self",
+			src:  "a: &a\n  b: 1\n  c:\n    <<: *a\n",
+			err:  "yamlpath: unsupported YAML: alias *a refers to itself at line 4, column 9",
+		},
+		{
+			name: "alias_self",
+			src:  "a: &a [1, *a]\n",
+			err:  "yamlpath: unsupported YAML: alias *a refers to itself at line 1, column 11",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			a := assert.New(t)
+			r := require.New(t)
+
+			var doc yaml.Node
+			r.NoError(yaml.Unmarshal([]byte(tc.src), &doc))
+			matches, err := Select(jsonpath.MustParse("$"), &doc)
+			r.ErrorIs(err, ErrUnsupported)
+			a.EqualError(err, tc.err)
+			a.Nil(matches)
+		})
+	}
+}